@@ -61,18 +61,18 @@ func newAccountCreateCmd() *cobra.Command {
 			imported, err := rpc.IsAccountImported(account.Address)
 			if err == nil && !imported {
 				// Import the account with the generated passphrase
-				fmt.Println("Importing account with generated passphrase...")
+				statusln("Importing account with generated passphrase...")
 				importedAddress, err := rpc.ImportRawKey(account.PrivateKey, passphrase)
 				if err != nil {
 					// If import fails, continue anyway - account might already be there
-					fmt.Printf("⚠️  Warning: Failed to import account (may already exist): %v\n", err)
+					statusf("⚠️  Warning: Failed to import account (may already exist): %v\n", err)
 				} else if importedAddress != account.Address {
-					fmt.Printf("⚠️  Warning: Imported address (%s) differs from created address (%s)\n", importedAddress, account.Address)
+					statusf("⚠️  Warning: Imported address (%s) differs from created address (%s)\n", importedAddress, account.Address)
 				} else {
-					fmt.Println("✅ Account imported successfully")
+					statusln("✅ Account imported successfully")
 				}
 			} else {
-				fmt.Println("ℹ️  Account already imported (from createAccount)")
+				statusln("ℹ️  Account already imported (from createAccount)")
 			}
 
 			// Create credentials struct
@@ -104,18 +104,18 @@ func newAccountCreateCmd() *cobra.Command {
 				savePath = CredentialsFileName
 			}
 
-			fmt.Println("✅ Account created and imported successfully!")
-			fmt.Printf("Address:    %s\n", account.Address)
-			fmt.Printf("Public Key: %s\n", account.PublicKey)
-			fmt.Printf("Private Key: %s\n", account.PrivateKey)
-			fmt.Printf("Passphrase: %s\n", passphrase)
-			fmt.Printf("\n📝 Credentials saved to: %s\n", savePath)
-			fmt.Println("\n⚠️  IMPORTANT: Keep this file secure! It contains your private key and passphrase.")
-			fmt.Printf("\n💡 Next steps:\n")
-			fmt.Printf("   1. Fund this address with some NIM (mainnet)\n")
-			fmt.Printf("   2. Unlock account: nimiq-uploader account unlock --passphrase \"%s\"\n", passphrase)
-			fmt.Printf("   3. Check balance: nimiq-uploader account balance\n")
-			fmt.Printf("   4. Wait for funds: nimiq-uploader account wait-funds\n")
+			statusln("✅ Account created and imported successfully!")
+			resultf("Address:    %s\n", account.Address)
+			resultf("Public Key: %s\n", account.PublicKey)
+			secretf("Private Key: %s\n", account.PrivateKey)
+			secretf("Passphrase: %s\n", passphrase)
+			statusf("\n📝 Credentials saved to: %s\n", savePath)
+			statusln("\n⚠️  IMPORTANT: Keep this file secure! It contains your private key and passphrase.")
+			statusf("\n💡 Next steps:\n")
+			statusf("   1. Fund this address with some NIM (mainnet)\n")
+			statusf("   2. Unlock account: nimiq-uploader account unlock (reads the passphrase from the credentials file)\n")
+			statusf("   3. Check balance: nimiq-uploader account balance\n")
+			statusf("   4. Wait for funds: nimiq-uploader account wait-funds\n")
 
 			return nil
 		},
@@ -153,10 +153,14 @@ func newAccountImportCmd() *cobra.Command {
 					return fmt.Errorf("failed to load credentials: %w", err)
 				}
 				if privateKey == "" {
-					privateKey = creds["PRIVATE_KEY"]
+					if privateKey, err = credentialSecret(creds, "PRIVATE_KEY"); err != nil {
+						return err
+					}
 				}
 				if passphrase == "" {
-					passphrase = creds["PASSPHRASE"]
+					if passphrase, err = credentialSecret(creds, "PASSPHRASE"); err != nil {
+						return err
+					}
 				}
 				if rpcURL == "" && creds["RPC_URL"] != "" {
 					rpcURL = creds["RPC_URL"]
@@ -194,60 +198,61 @@ func newAccountImportCmd() *cobra.Command {
 			if checkAddress != "" {
 				imported, checkErr := rpc.IsAccountImported(checkAddress)
 				if checkErr == nil && imported {
-					fmt.Printf("ℹ️  Account %s is already imported\n", checkAddress)
+					statusf("ℹ️  Account %s is already imported\n", checkAddress)
 					address = checkAddress
 				} else {
 					// Account not imported, try to import it
-					fmt.Println("Importing account...")
+					statusln("Importing account...")
 					importedAddress, err := rpc.ImportRawKey(privateKey, passphrase)
 					if err != nil {
 						return fmt.Errorf("failed to import account: %w", err)
 					}
 					address = importedAddress
-					fmt.Printf("✅ Account imported successfully!\n")
-					fmt.Printf("Address: %s\n", address)
+					statusf("✅ Account imported successfully!\n")
+					resultf("Address: %s\n", address)
 				}
 			} else {
 				// No address in credentials, try to import
-				fmt.Println("Importing account...")
+				statusln("Importing account...")
 				importedAddress, err := rpc.ImportRawKey(privateKey, passphrase)
 				if err != nil {
 					return fmt.Errorf("failed to import account: %w", err)
 				}
 				address = importedAddress
-				fmt.Printf("✅ Account imported successfully!\n")
-				fmt.Printf("Address: %s\n", address)
+				statusf("✅ Account imported successfully!\n")
+				resultf("Address: %s\n", address)
 			}
 
 			// Unlock the account if requested
 			if unlock {
-				fmt.Println("Checking account status...")
+				statusln("Checking account status...")
 				alreadyUnlocked, err := rpc.IsAccountUnlocked(address)
 				if err == nil && alreadyUnlocked {
-					fmt.Println("✅ Account is already unlocked - ready for transactions")
+					statusln("✅ Account is already unlocked - ready for transactions")
 				} else {
 					// Check if account was created via createAccount (not encrypted)
 					// Accounts created this way don't need unlocking with a passphrase
 					imported, err := rpc.IsAccountImported(address)
 					if err == nil && imported {
-						fmt.Println("Attempting to unlock account...")
-						unlocked, err := rpc.UnlockAccount(address, passphrase, 0) // 0 = indefinitely
+						statusln("Attempting to unlock account...")
+						unlocked, err := rpc.UnlockAccount(address, passphrase, DefaultUnlockDuration)
 						if err != nil {
 							// If unlock fails with internal error, account might not be encrypted
 							// This is normal for accounts created via createAccount
-							fmt.Printf("ℹ️  Cannot unlock with passphrase: %v\n", err)
-							fmt.Println("   Accounts created via 'createAccount' are not encrypted with a passphrase.")
-							fmt.Println("   Even though status shows 'locked', the account should work for transactions.")
-							fmt.Println("   Try sending a transaction - it should work without unlocking.")
+							statusf("ℹ️  Cannot unlock with passphrase: %v\n", err)
+							statusln("   Accounts created via 'createAccount' are not encrypted with a passphrase.")
+							statusln("   Even though status shows 'locked', the account should work for transactions.")
+							statusln("   Try sending a transaction - it should work without unlocking.")
 						} else if unlocked {
-							fmt.Println("✅ Account unlocked successfully")
+							statusf("✅ Account unlocked for %d seconds\n", DefaultUnlockDuration)
+							warnRemoteUnlock(rpcURL, address, DefaultUnlockDuration)
 						} else {
-							fmt.Println("⚠️  Account unlock returned false - checking final status...")
+							statusln("⚠️  Account unlock returned false - checking final status...")
 							finalStatus, _ := rpc.IsAccountUnlocked(address)
 							if finalStatus {
-								fmt.Println("✅ Account is unlocked")
+								statusln("✅ Account is unlocked")
 							} else {
-								fmt.Println("ℹ️  Account may not require unlocking (created without encryption)")
+								statusln("ℹ️  Account may not require unlocking (created without encryption)")
 							}
 						}
 					}
@@ -299,8 +304,7 @@ func newAccountStatusCmd() *cobra.Command {
 				return fmt.Errorf("failed to check consensus: %w", err)
 			}
 			if !consensus {
-				fmt.Println("⚠️  Warning: Node does not have consensus with the network")
-				fmt.Println("   Account status may be inaccurate. Wait for sync to complete.")
+				warnf("node does not have consensus with the network; account status may be inaccurate until sync completes")
 			}
 
 			imported, err := rpc.IsAccountImported(address)
@@ -313,16 +317,19 @@ func newAccountStatusCmd() *cobra.Command {
 				return fmt.Errorf("failed to check unlock status: %w", err)
 			}
 
-			fmt.Printf("Account: %s\n", address)
-			fmt.Printf("Imported: %v\n", imported)
-			fmt.Printf("Unlocked: %v\n", unlocked)
+			resultf("Account: %s\n", address)
+			resultf("Imported: %v\n", imported)
+			resultf("Unlocked: %v\n", unlocked)
 
 			if !imported {
-				fmt.Println("\n⚠️  Account is not imported. Use 'account import' command.")
+				statusln("\n⚠️  Account is not imported. Use 'account import' command.")
 			} else if !unlocked {
-				fmt.Println("\n⚠️  Account is locked. Please unlock it first.")
+				statusln("\n⚠️  Account is locked. Upload commands unlock it themselves with the passphrase from credentials.json or NIMIQ_PASSPHRASE, or run 'account unlock'.")
 			} else {
-				fmt.Println("\n✅ Account is ready to send transactions.")
+				statusln("\n✅ Account is ready to send transactions.")
+				if isRemoteRPC(rpcURL) {
+					statusf("⚠️  It is unlocked on the remote node %s; lock it with 'account lock' when done\n", rpcURL)
+				}
 			}
 
 			return nil
@@ -353,13 +360,13 @@ func newAccountConsensusCmd() *cobra.Command {
 				return fmt.Errorf("failed to check consensus: %w", err)
 			}
 
-			fmt.Printf("RPC URL: %s\n", rpcURL)
-			fmt.Printf("Consensus: %v\n", consensus)
+			resultf("RPC URL: %s\n", rpcURL)
+			resultf("Consensus: %v\n", consensus)
 
 			if consensus {
-				fmt.Println("\n✅ Node has consensus with the network - ready for operations")
+				statusln("\n✅ Node has consensus with the network - ready for operations")
 			} else {
-				fmt.Println("\n⚠️  Node does NOT have consensus - wait for sync before operations")
+				statusln("\n⚠️  Node does NOT have consensus - wait for sync before operations")
 			}
 
 			return nil
@@ -382,6 +389,12 @@ func newAccountUnlockCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "unlock",
 		Short: "Unlock an account",
+		Long: fmt.Sprintf(`Unlock an account on the node for --duration seconds (default %d).
+--duration 0 unlocks it until 'account lock' or a node restart.
+
+Upload commands don't need this: they unlock a locked account themselves with
+the passphrase from credentials.json or NIMIQ_PASSPHRASE, and lock it again
+when they exit.`, DefaultUnlockDuration),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Get RPC URL from env, credentials file, or default
 			if rpcURL == "" {
@@ -413,8 +426,8 @@ func newAccountUnlockCmd() *cobra.Command {
 				return fmt.Errorf("passphrase is required (--passphrase or set NIMIQ_PASSPHRASE)")
 			}
 
-			if duration <= 0 {
-				duration = 0 // 0 = unlock indefinitely
+			if duration < 0 {
+				return fmt.Errorf("--duration must be 0 (indefinitely) or positive")
 			}
 
 			rpc := NewNimiqRPC(rpcURL)
@@ -425,12 +438,13 @@ func newAccountUnlockCmd() *cobra.Command {
 
 			if unlocked {
 				if duration == 0 {
-					fmt.Printf("✅ Account %s unlocked indefinitely\n", address)
+					statusf("✅ Account %s unlocked indefinitely\n", address)
 				} else {
-					fmt.Printf("✅ Account %s unlocked for %d seconds\n", address, duration)
+					statusf("✅ Account %s unlocked for %d seconds\n", address, duration)
 				}
+				warnRemoteUnlock(rpcURL, address, duration)
 			} else {
-				fmt.Printf("⚠️  Account unlock returned false - account may already be unlocked or passphrase incorrect\n")
+				statusf("⚠️  Account unlock returned false - account may already be unlocked or passphrase incorrect\n")
 			}
 
 			return nil
@@ -440,7 +454,7 @@ func newAccountUnlockCmd() *cobra.Command {
 	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
 	cmd.Flags().StringVar(&address, "address", "", "Account address (defaults to address from credentials.json)")
 	cmd.Flags().StringVar(&passphrase, "passphrase", "", "Passphrase to unlock account (defaults to passphrase from credentials.json)")
-	cmd.Flags().IntVar(&duration, "duration", 0, "Unlock duration in seconds (0 = indefinitely)")
+	cmd.Flags().IntVar(&duration, "duration", DefaultUnlockDuration, "Unlock duration in seconds (0 = indefinitely)")
 
 	return cmd
 }
@@ -475,7 +489,7 @@ func newAccountLockCmd() *cobra.Command {
 				return fmt.Errorf("failed to lock account: %w", err)
 			}
 
-			fmt.Printf("✅ Account %s locked\n", address)
+			statusf("✅ Account %s locked\n", address)
 			return nil
 		},
 	}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// UploadAccounting tallies what the transactions of one run cost.
+// Every transaction carries TxValueLuna of value plus the fee: CART and DATA
+// transactions move value to the cartridge address, CENT to the catalog.
+type UploadAccounting struct {
+	FeeLuna            int64 // fee per transaction
+	ToCartridge        int   // CART and DATA transactions sent
+	ToCatalog          int   // CENT transactions sent
+	CartridgeSweepable bool  // cartridge address key is in the node wallet
+}
+
+// Transactions returns the number of transactions sent
+func (a *UploadAccounting) Transactions() int {
+	return a.ToCartridge + a.ToCatalog
+}
+
+// FeesLuna returns the total fees paid
+func (a *UploadAccounting) FeesLuna() int64 {
+	return int64(a.Transactions()) * a.FeeLuna
+}
+
+// ValueLuna returns the total value moved to other addresses
+func (a *UploadAccounting) ValueLuna() int64 {
+	return int64(a.Transactions()) * TxValueLuna
+}
+
+// RecoverableLuna returns the value that can be swept back from the
+// cartridge address (only if its key is in the node wallet)
+func (a *UploadAccounting) RecoverableLuna() int64 {
+	if !a.CartridgeSweepable {
+		return 0
+	}
+	return int64(a.ToCartridge) * TxValueLuna
+}
+
+// PrintReport prints the accounting report at the end of a run
+func (a *UploadAccounting) PrintReport() {
+	total := a.FeesLuna() + a.ValueLuna()
+	fmt.Println("\nAccounting (this run):")
+	fmt.Printf("  Transactions: %d (%d to cartridge, %d to catalog)\n", a.Transactions(), a.ToCartridge, a.ToCatalog)
+	fmt.Printf("  Value moved:  %d Luna\n", a.ValueLuna())
+	fmt.Printf("  Fees:         %d Luna\n", a.FeesLuna())
+	fmt.Printf("  Total spent:  %d Luna (%s)\n", total, formatNIM(total))
+	if a.CartridgeSweepable {
+		fmt.Printf("  Recoverable:  %d Luna (value on the cartridge address, sweepable with its key)\n", a.RecoverableLuna())
+	} else if a.ToCartridge > 0 {
+		fmt.Printf("  Recoverable:  0 Luna (cartridge address key is not in the node wallet)\n")
+	}
+}
+
+// ProjectSpend is the cumulative spend of an app in the project state
+type ProjectSpend struct {
+	Transactions    int   `json:"transactions"`
+	ValueLuna       int64 `json:"value_luna"`
+	FeesLuna        int64 `json:"fees_luna"`
+	RecoverableLuna int64 `json:"recoverable_luna"`
+}
+
+// TotalLuna returns value plus fees
+func (s ProjectSpend) TotalLuna() int64 {
+	return s.ValueLuna + s.FeesLuna
+}
+
+// RecordSpend adds a run's accounting to the app's cumulative spend in the
+// project state file. Failures are reported as warnings.
+func RecordSpend(catalogAddr, title string, appID uint32, a *UploadAccounting) {
+	if a.Transactions() == 0 {
+		return
+	}
+	state, err := LoadProjectState(ProjectStateFileName)
+	if err != nil {
+		warnf("failed to load project state: %v", err)
+		return
+	}
+
+	app := state.findOrAddApp(catalogAddr, title, appID)
+	app.Spend.Transactions += a.Transactions()
+	app.Spend.ValueLuna += a.ValueLuna()
+	app.Spend.FeesLuna += a.FeesLuna()
+	app.Spend.RecoverableLuna += a.RecoverableLuna()
+
+	if err := SaveProjectState(ProjectStateFileName, state); err != nil {
+		warnf("failed to save project state: %v", err)
+	}
+}
+
+func newSpendCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "spend",
+		Short: "Show cumulative upload spend per app (from nimiq-project.json)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state, err := LoadProjectState(ProjectStateFileName)
+			if err != nil {
+				return err
+			}
+			if len(state.Apps) == 0 {
+				fmt.Printf("No apps recorded in %s\n", ProjectStateFileName)
+				return nil
+			}
+
+			var total ProjectSpend
+			fmt.Printf("%-7s %-16s %8s %12s %12s %12s\n", "APP-ID", "TITLE", "TXS", "VALUE", "FEES", "RECOVERABLE")
+			for _, app := range state.Apps {
+				s := app.Spend
+				fmt.Printf("%-7d %-16s %8d %12d %12d %12d\n", app.AppID, app.Title, s.Transactions, s.ValueLuna, s.FeesLuna, s.RecoverableLuna)
+				total.Transactions += s.Transactions
+				total.ValueLuna += s.ValueLuna
+				total.FeesLuna += s.FeesLuna
+				total.RecoverableLuna += s.RecoverableLuna
+			}
+			fmt.Printf("%-7s %-16s %8d %12d %12d %12d\n", "", "TOTAL", total.Transactions, total.ValueLuna, total.FeesLuna, total.RecoverableLuna)
+			fmt.Printf("\nTotal spent: %d Luna (%s), of which %d Luna recoverable\n", total.TotalLuna(), formatNIM(total.TotalLuna()), total.RecoverableLuna)
+			return nil
+		},
+	}
+}
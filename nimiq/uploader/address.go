@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Nimiq base32 alphabet (excludes I, O, W, Z to avoid confusion)
+const nimiqBase32Alphabet = "0123456789ABCDEFGHJKLMNPQRSTUVXY"
+
+// nimiqCountryCode is the IBAN-style country code prefix of every Nimiq address
+const nimiqCountryCode = "NQ"
+
+// AddressNQToBytes converts a Nimiq address string (NQ...) to 20-byte binary
+// Nimiq address format is IBAN-style:
+// - NQ (2 chars) + check digits (2 chars) + address body (32 base32 chars)
+// - The check digits are MOD-97-10 calculated over the address body
+// The check digits are verified before the 32-char address body is decoded
+func AddressNQToBytes(address string) ([20]byte, error) {
+	var result [20]byte
+
+	// Remove spaces (Nimiq addresses are often formatted with spaces)
+	address = strings.ToUpper(strings.ReplaceAll(address, " ", ""))
+
+	if len(address) != 36 || address[:2] != nimiqCountryCode {
+		return result, fmt.Errorf("invalid address format: expected NQ + 34 chars, got %d chars", len(address))
+	}
+
+	// Skip NQ (2 chars) and check digits (2 chars), decode only the 32-char address body
+	base32Str := address[4:]
+	if len(base32Str) != 32 {
+		return result, fmt.Errorf("invalid address body length: expected 32 base32 chars, got %d", len(base32Str))
+	}
+
+	// Build a lookup map for the base32 alphabet
+	alphabetMap := make(map[byte]int)
+	for i, c := range nimiqBase32Alphabet {
+		alphabetMap[byte(c)] = i
+	}
+
+	// Decode 32 base32 characters = 160 bits = exactly 20 bytes
+	decoded := make([]byte, 0, 20)
+	bitBuffer := uint64(0)
+	bitsInBuffer := 0
+
+	for i := 0; i < 32; i++ {
+		char := base32Str[i]
+		value, ok := alphabetMap[char]
+		if !ok {
+			return result, fmt.Errorf("invalid base32 character: %c (not in Nimiq alphabet)", char)
+		}
+
+		bitBuffer = (bitBuffer << 5) | uint64(value)
+		bitsInBuffer += 5
+
+		for bitsInBuffer >= 8 {
+			decoded = append(decoded, byte(bitBuffer>>(bitsInBuffer-8)))
+			bitsInBuffer -= 8
+			bitBuffer &= (1 << bitsInBuffer) - 1
+		}
+	}
+
+	if len(decoded) != 20 {
+		return result, fmt.Errorf("decoded address wrong size: got %d bytes, expected 20", len(decoded))
+	}
+
+	// Verify check digits: body + country code + check digits must be 1 mod 97
+	if ibanCheck(base32Str+address[:4]) != 1 {
+		return result, fmt.Errorf("invalid address checksum: %s", FormatAddressNQ(address))
+	}
+
+	copy(result[:], decoded)
+	return result, nil
+}
+
+// BytesToAddressNQ converts a 20-byte binary address into the user-friendly
+// Nimiq format with correct check digits (e.g. "NQ07 0000 ... 0000")
+func BytesToAddressNQ(addr [20]byte) string {
+	// Encode 20 bytes = 160 bits = exactly 32 base32 characters
+	var body strings.Builder
+	bitBuffer := uint64(0)
+	bitsInBuffer := 0
+
+	for _, b := range addr {
+		bitBuffer = (bitBuffer << 8) | uint64(b)
+		bitsInBuffer += 8
+
+		for bitsInBuffer >= 5 {
+			body.WriteByte(nimiqBase32Alphabet[(bitBuffer>>(bitsInBuffer-5))&0x1f])
+			bitsInBuffer -= 5
+			bitBuffer &= (1 << bitsInBuffer) - 1
+		}
+	}
+
+	base32Str := body.String()
+	check := 98 - ibanCheck(base32Str+nimiqCountryCode+"00")
+
+	return FormatAddressNQ(fmt.Sprintf("%s%02d%s", nimiqCountryCode, check, base32Str))
+}
+
+// ValidateAddressNQ checks the format and MOD-97-10 checksum of a Nimiq address
+func ValidateAddressNQ(address string) error {
+	_, err := AddressNQToBytes(address)
+	return err
+}
+
+// FormatAddressNQ normalizes a Nimiq address to uppercase with 4-char groups
+func FormatAddressNQ(address string) string {
+	normalized := normalizeAddress(address)
+
+	groups := make([]string, 0, (len(normalized)+3)/4)
+	for i := 0; i < len(normalized); i += 4 {
+		end := i + 4
+		if end > len(normalized) {
+			end = len(normalized)
+		}
+		groups = append(groups, normalized[i:end])
+	}
+	return strings.Join(groups, " ")
+}
+
+// ibanCheck computes the IBAN MOD-97-10 remainder of a string, mapping
+// digits to themselves and letters to their two-digit values (A=10 ... Z=35)
+func ibanCheck(s string) int {
+	var digits strings.Builder
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			digits.WriteRune(c)
+		} else {
+			digits.WriteString(fmt.Sprintf("%d", c-'A'+10))
+		}
+	}
+
+	num, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return -1
+	}
+	return int(new(big.Int).Mod(num, big.NewInt(97)).Int64())
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+)
+
+func TestPublicKeyToAddress(t *testing.T) {
+	// BLAKE2b-256 of the public key of the all-zero seed, from Python's
+	// hashlib.blake2b(pub, digest_size=32)
+	pub := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+	if got := hex.EncodeToString(PublicKeyToAddress(pub)); got != "689dae2f77b048dcc08e14d73104ea14222b5be1" {
+		t.Errorf("PublicKeyToAddress = %s", got)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// CatalogAliasesFileName is the catalog alias registry in the config directory
+const CatalogAliasesFileName = "catalogs.json"
+
+// CatalogAlias is a named catalog address
+type CatalogAlias struct {
+	Address string `json:"address"`
+	Network string `json:"network,omitempty"` // "main" or "test"
+}
+
+// builtinCatalogAliases are always available; entries in the registry file
+// with the same name take precedence
+var builtinCatalogAliases = map[string]CatalogAlias{
+	"main": {Address: "NQ15 NXMP 11A0 TMKP G1Q8 4ABD U16C XD6Q D948", Network: NetworkMain},
+	"test": {Address: "NQ32 0VD4 26TR 1394 KXBJ 862C NFKG 61M5 GFJ0", Network: NetworkTest},
+}
+
+// catalogAliasesPath returns the path of the alias registry file
+func catalogAliasesPath() string {
+	return filepath.Join(GetConfigDir(), CatalogAliasesFileName)
+}
+
+// loadUserCatalogAliases reads the alias registry file (empty if it doesn't exist)
+func loadUserCatalogAliases() (map[string]CatalogAlias, error) {
+	aliases := make(map[string]CatalogAlias)
+	data, err := os.ReadFile(catalogAliasesPath())
+	if os.IsNotExist(err) {
+		return aliases, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", catalogAliasesPath(), err)
+	}
+	return aliases, nil
+}
+
+// saveUserCatalogAliases writes the alias registry file
+func saveUserCatalogAliases(aliases map[string]CatalogAlias) error {
+	if err := EnsureConfigDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(aliases, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(catalogAliasesPath(), data, 0644)
+}
+
+// LoadCatalogAliases returns the built-in aliases merged with the registry file
+func LoadCatalogAliases() map[string]CatalogAlias {
+	aliases := make(map[string]CatalogAlias)
+	for name, alias := range builtinCatalogAliases {
+		aliases[name] = alias
+	}
+	user, err := loadUserCatalogAliases()
+	if err != nil {
+		warnf("%v", err)
+		return aliases
+	}
+	for name, alias := range user {
+		aliases[name] = alias
+	}
+	return aliases
+}
+
+// catalogAliasFor returns the alias name and entry registered for a catalog address
+func catalogAliasFor(catalogAddr string) (string, CatalogAlias, bool) {
+	aliases := LoadCatalogAliases()
+	names := sortedAliasNames(aliases)
+	for _, name := range names {
+		if normalizeAddress(aliases[name].Address) == normalizeAddress(catalogAddr) {
+			return name, aliases[name], true
+		}
+	}
+	return "", CatalogAlias{}, false
+}
+
+// catalogAliasesOnNetwork returns the names of the aliases on a network
+func catalogAliasesOnNetwork(network string) []string {
+	aliases := LoadCatalogAliases()
+	var names []string
+	for _, name := range sortedAliasNames(aliases) {
+		if aliases[name].Network == network {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// sortedAliasNames returns alias names in alphabetical order
+func sortedAliasNames(aliases map[string]CatalogAlias) []string {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newCatalogAliasCmd() *cobra.Command {
+	aliasCmd := &cobra.Command{
+		Use:   "catalog-alias",
+		Short: "Manage catalog address shortcuts",
+		Long: `Manage named shortcuts for catalog addresses, usable anywhere a
+--catalog-addr is accepted.
+
+The 'main' and 'test' shortcuts are built in. Aliases are stored in
+~/.config/nimiq-uploader/catalogs.json.`,
+	}
+
+	aliasCmd.AddCommand(newCatalogAliasAddCmd())
+	aliasCmd.AddCommand(newCatalogAliasListCmd())
+	aliasCmd.AddCommand(newCatalogAliasRmCmd())
+
+	return aliasCmd
+}
+
+func newCatalogAliasAddCmd() *cobra.Command {
+	var network string
+
+	cmd := &cobra.Command{
+		Use:   "add NAME ADDRESS",
+		Short: "Add or replace a catalog alias",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, address := strings.ToLower(args[0]), args[1]
+			if strings.HasPrefix(strings.ToUpper(name), "NQ") {
+				return fmt.Errorf("alias name %q looks like an address", args[0])
+			}
+			if err := ValidateAddressNQ(address); err != nil {
+				return fmt.Errorf("invalid catalog address %s: %w", address, err)
+			}
+			network = strings.ToLower(network)
+			if network != "" && network != NetworkMain && network != NetworkTest {
+				return fmt.Errorf("--network must be '%s' or '%s'", NetworkMain, NetworkTest)
+			}
+
+			aliases, err := loadUserCatalogAliases()
+			if err != nil {
+				return err
+			}
+			aliases[name] = CatalogAlias{Address: FormatAddressNQ(address), Network: network}
+			if err := saveUserCatalogAliases(aliases); err != nil {
+				return fmt.Errorf("failed to save aliases: %w", err)
+			}
+
+			if _, builtin := builtinCatalogAliases[name]; builtin {
+				statusf("⚠️  '%s' now overrides the built-in catalog\n", name)
+			}
+			statusf("✓ Added alias '%s' → %s\n", name, FormatAddressNQ(address))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&network, "network", "", "Network the catalog is on ('main' or 'test')")
+
+	return cmd
+}
+
+func newCatalogAliasListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List catalog aliases",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			user, err := loadUserCatalogAliases()
+			if err != nil {
+				return err
+			}
+			aliases := LoadCatalogAliases()
+
+			fmt.Printf("%-16s %-8s %-46s %s\n", "NAME", "NETWORK", "ADDRESS", "SOURCE")
+			for _, name := range sortedAliasNames(aliases) {
+				source := "builtin"
+				if _, ok := user[name]; ok {
+					source = catalogAliasesPath()
+				}
+				alias := aliases[name]
+				network := alias.Network
+				if network == "" {
+					network = "-"
+				}
+				fmt.Printf("%-16s %-8s %-46s %s\n", name, network, alias.Address, source)
+			}
+			return nil
+		},
+	}
+}
+
+func newCatalogAliasRmCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm NAME",
+		Short: "Remove a catalog alias",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := strings.ToLower(args[0])
+			aliases, err := loadUserCatalogAliases()
+			if err != nil {
+				return err
+			}
+			if _, ok := aliases[name]; !ok {
+				if _, builtin := builtinCatalogAliases[name]; builtin {
+					return fmt.Errorf("'%s' is a built-in alias and can't be removed (override it with catalog-alias add)", name)
+				}
+				return fmt.Errorf("no alias named '%s'", name)
+			}
+			delete(aliases, name)
+			if err := saveUserCatalogAliases(aliases); err != nil {
+				return fmt.Errorf("failed to save aliases: %w", err)
+			}
+			statusf("✓ Removed alias '%s'\n", name)
+			return nil
+		},
+	}
+}
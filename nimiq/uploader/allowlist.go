@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// MagicCALW marks a catalog allowlist record
+const MagicCALW = "CALW"
+
+// Allowlist record operations
+const (
+	AllowlistAdd    = 0x01
+	AllowlistRemove = 0x02
+)
+
+// AllowlistRecord adds or removes a publisher from a catalog's allowlist.
+//
+// Records are sent from the catalog address itself (to the publisher's
+// address), so the transaction signature proves the catalog owner emitted
+// them. Layout (64 bytes):
+//
+//	magic "CALW" (4) | version (1) | op (1) | publisher address (20) | reserved (38)
+type AllowlistRecord struct {
+	Version   uint8
+	Op        uint8
+	Publisher [20]byte
+}
+
+// EncodeAllowlistRecord encodes an allowlist record into a 64-byte payload
+func EncodeAllowlistRecord(record AllowlistRecord) ([]byte, error) {
+	if record.Op != AllowlistAdd && record.Op != AllowlistRemove {
+		return nil, fmt.Errorf("invalid allowlist op: %d", record.Op)
+	}
+	payload := make([]byte, 64)
+	copy(payload[0:4], MagicCALW)
+	payload[4] = record.Version
+	payload[5] = record.Op
+	copy(payload[6:26], record.Publisher[:])
+	return payload, nil
+}
+
+// DecodeAllowlistRecord decodes a 64-byte allowlist payload
+func DecodeAllowlistRecord(payload []byte) (*AllowlistRecord, error) {
+	if len(payload) < 64 {
+		return nil, fmt.Errorf("allowlist payload too short: %d bytes", len(payload))
+	}
+	if string(payload[0:4]) != MagicCALW {
+		return nil, fmt.Errorf("not an allowlist payload")
+	}
+	record := &AllowlistRecord{
+		Version: payload[4],
+		Op:      payload[5],
+	}
+	copy(record.Publisher[:], payload[6:26])
+	return record, nil
+}
+
+// Allowlist is the set of publishers a catalog owner allows. A catalog without
+// any allowlist records is open to every publisher.
+type Allowlist struct {
+	Active     bool
+	Publishers map[string]bool // normalized address -> allowed
+}
+
+// Allows reports whether the allowlist admits a publisher
+func (a *Allowlist) Allows(publisher string) bool {
+	return !a.Active || a.Publishers[normalizeAddress(publisher)]
+}
+
+// Sorted returns the allowed publishers in address order
+func (a *Allowlist) Sorted() []string {
+	var publishers []string
+	for addr, allowed := range a.Publishers {
+		if allowed {
+			publishers = append(publishers, FormatAddressNQ(addr))
+		}
+	}
+	sort.Strings(publishers)
+	return publishers
+}
+
+// LoadAllowlist replays the allowlist records a catalog address has sent
+func LoadAllowlist(rpc *NimiqRPC, catalogAddr string) (*Allowlist, error) {
+	transactions, err := GetAllTransactionsByAddress(rpc, normalizeAddress(catalogAddr), 500)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog: %w", err)
+	}
+
+	allowlist := &Allowlist{Publishers: make(map[string]bool)}
+	// Transactions are newest first; replay oldest first
+	for i := len(transactions) - 1; i >= 0; i-- {
+		tx := transactions[i]
+		if normalizeAddress(tx.From) != normalizeAddress(catalogAddr) {
+			continue // only the catalog owner can emit records
+		}
+		record, err := DecodeAllowlistRecord(txPayload(tx))
+		if err != nil {
+			continue
+		}
+		allowlist.Active = true
+		publisher := normalizeAddress(BytesToAddressNQ(record.Publisher))
+		switch record.Op {
+		case AllowlistAdd:
+			allowlist.Publishers[publisher] = true
+		case AllowlistRemove:
+			delete(allowlist.Publishers, publisher)
+		}
+	}
+	return allowlist, nil
+}
+
+func newCatalogAllowlistCmd() *cobra.Command {
+	allowlistCmd := &cobra.Command{
+		Use:   "allowlist",
+		Short: "Manage which publishers appear in a curated catalog",
+		Long: `Manage a catalog's publisher allowlist.
+
+Records are sent from the catalog address, so the catalog's key must be
+imported and unlocked in the node. Once a catalog has any allowlist record,
+listings (catalog apps) only show publishers on the list.`,
+	}
+
+	allowlistCmd.AddCommand(newCatalogAllowlistUpdateCmd("add", AllowlistAdd))
+	allowlistCmd.AddCommand(newCatalogAllowlistUpdateCmd("remove", AllowlistRemove))
+	allowlistCmd.AddCommand(newCatalogAllowlistListCmd())
+
+	return allowlistCmd
+}
+
+func newCatalogAllowlistUpdateCmd(use string, op uint8) *cobra.Command {
+	var (
+		rpcURL      string
+		catalogAddr string
+		fee         int64
+		dryRun      bool
+	)
+
+	short := "Allow a publisher in the catalog"
+	if op == AllowlistRemove {
+		short = "Remove a publisher from the catalog's allowlist"
+	}
+
+	cmd := &cobra.Command{
+		Use:   use + " PUBLISHER",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get RPC URL from env, credentials file, or default
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			catalogAddr = resolveCatalogAddress(catalogAddr)
+
+			publisher, err := AddressNQToBytes(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid publisher address %s: %w", args[0], err)
+			}
+			payload, err := EncodeAllowlistRecord(AllowlistRecord{Version: 1, Op: op, Publisher: publisher})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Catalog: %s\n", catalogAddr)
+			fmt.Printf("Publisher: %s\n", FormatAddressNQ(args[0]))
+
+			if dryRun {
+				fmt.Printf("Dry-run: Would send allowlist record (%s) from the catalog address\n", use)
+				return nil
+			}
+
+			// The record goes to the publisher; it must be signed by the catalog
+			rpcSender, err := NewRPCSender(rpcURL, catalogAddr, args[0], fee)
+			if err != nil {
+				return fmt.Errorf("failed to initialize RPC sender (the catalog account must be imported and unlocked): %w", err)
+			}
+			txHash, err := rpcSender.SendTransaction(payload)
+			if err != nil {
+				return fmt.Errorf("failed to send allowlist record: %w", err)
+			}
+
+			statusf("✓ Allowlist record sent: %s\n", txHash)
+			printExplorerLink("  ", networkForCatalog(catalogAddr), LinkTx, txHash)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address or alias (required)")
+	cmd.Flags().Int64Var(&fee, "fee", 0, "Transaction fee in Luna (default: 0, minimum)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Dry-run mode (show what would be sent)")
+	cmd.MarkFlagRequired("catalog-addr")
+
+	return cmd
+}
+
+func newCatalogAllowlistListCmd() *cobra.Command {
+	var (
+		rpcURL      string
+		catalogAddr string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Show a catalog's publisher allowlist",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get RPC URL from env, credentials file, or default
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			catalogAddr = resolveCatalogAddress(catalogAddr)
+
+			allowlist, err := LoadAllowlist(NewNimiqRPC(rpcURL), catalogAddr)
+			if err != nil {
+				return err
+			}
+			if !allowlist.Active {
+				fmt.Println("No allowlist: the catalog is open to every publisher.")
+				return nil
+			}
+
+			publishers := allowlist.Sorted()
+			fmt.Printf("Allowed publishers (%d):\n", len(publishers))
+			for _, publisher := range publishers {
+				fmt.Printf("  %s\n", publisher)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address or alias (required)")
+	cmd.MarkFlagRequired("catalog-addr")
+
+	return cmd
+}
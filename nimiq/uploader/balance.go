@@ -109,7 +109,7 @@ func newAccountWaitFundsCmd() *cobra.Command {
 				return fmt.Errorf("node does not have consensus with the network - wait for sync")
 			}
 			
-			fmt.Printf("Waiting for account %s to have at least %.5f NIM...\n", address, minNIM)
+			statusf("Waiting for account %s to have at least %.5f NIM...\n", address, minNIM)
 			fmt.Printf("Checking every %d seconds...\n\n", interval)
 
 			for {
@@ -129,7 +129,7 @@ func newAccountWaitFundsCmd() *cobra.Command {
 					return nil
 				}
 
-				fmt.Printf(" ⏳ Waiting...\n")
+				statusf(" ⏳ Waiting...\n")
 				time.Sleep(time.Duration(interval) * time.Second)
 			}
 		},
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// benchmarkLevels are the worker counts tried by the benchmark (the upload
+// commands allow at most 10 workers)
+var benchmarkLevels = []int{1, 2, 4, 8, 10}
+
+// callsPerSend is the number of RPC calls RPCSender makes per transaction
+// (consensus check, block height, send). The benchmark probe makes the first
+// two, so measured probe throughput is scaled by probeCalls/callsPerSend.
+const (
+	callsPerSend = 3
+	probeCalls   = 2
+)
+
+// benchmarkResult holds the measurements for one concurrency level
+type benchmarkResult struct {
+	Concurrency int
+	Probes      int
+	Errors      int
+	Elapsed     time.Duration
+	Latencies   []time.Duration
+}
+
+// Throughput returns successful probes per second
+func (r *benchmarkResult) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Probes-r.Errors) / r.Elapsed.Seconds()
+}
+
+// ErrorRate returns the fraction of failed probes
+func (r *benchmarkResult) ErrorRate() float64 {
+	if r.Probes == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Probes)
+}
+
+// Percentile returns the p-th percentile latency (p in 0..100)
+func (r *benchmarkResult) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), r.Latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(float64(len(sorted)-1) * p / 100)
+	return sorted[idx]
+}
+
+// runBenchmarkLevel runs probes with the given number of workers until the
+// duration is up. A probe makes the read-only calls a transaction send makes,
+// so nothing is sent and no funds are needed.
+func runBenchmarkLevel(rpc *NimiqRPC, concurrency int, duration time.Duration) *benchmarkResult {
+	result := &benchmarkResult{Concurrency: concurrency}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	deadline := start.Add(duration)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				probeStart := time.Now()
+				_, err := rpc.IsConsensusEstablished()
+				if err == nil {
+					_, err = rpc.GetBlockNumber()
+				}
+				latency := time.Since(probeStart)
+
+				mu.Lock()
+				result.Probes++
+				if err != nil {
+					result.Errors++
+				} else {
+					result.Latencies = append(result.Latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	result.Elapsed = time.Since(start)
+	return result
+}
+
+// recommendBenchmark picks the smallest concurrency that reaches 90% of the
+// best throughput without errors above 1%, and a rate with 20% headroom
+func recommendBenchmark(results []*benchmarkResult) (concurrency int, rate float64, ok bool) {
+	var best float64
+	for _, r := range results {
+		if r.ErrorRate() <= 0.01 && r.Throughput() > best {
+			best = r.Throughput()
+		}
+	}
+	if best == 0 {
+		return 0, 0, false
+	}
+	for _, r := range results {
+		if r.ErrorRate() <= 0.01 && r.Throughput() >= 0.9*best {
+			rate := r.Throughput() * probeCalls / callsPerSend * 0.8
+			if rate < 1 {
+				rate = 1
+			}
+			return r.Concurrency, rate, true
+		}
+	}
+	return 0, 0, false
+}
+
+func newBenchmarkCmd() *cobra.Command {
+	var (
+		rpcURL         string
+		duration       time.Duration
+		maxConcurrency int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "benchmark",
+		Short: "Measure node throughput and recommend --rate/--concurrency",
+		Long: `Measure how many requests the node handles at increasing worker counts and
+recommend --rate and --concurrency values for upload-cartridge and
+execute-plan.
+
+The benchmark only makes read-only RPC calls (the consensus and block height
+checks done before every send), so no transactions are sent and no funds are
+needed. The duration is split evenly across the worker counts tried.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get RPC URL from env, credentials file, or default
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			if maxConcurrency < 1 || maxConcurrency > 10 {
+				return fmt.Errorf("--max-concurrency must be between 1 and 10")
+			}
+
+			rpc := NewNimiqRPC(rpcURL)
+			if _, err := rpc.GetBlockNumber(); err != nil {
+				return fmt.Errorf("failed to reach node: %w", err)
+			}
+
+			var levels []int
+			for _, level := range benchmarkLevels {
+				if level <= maxConcurrency {
+					levels = append(levels, level)
+				}
+			}
+			perLevel := duration / time.Duration(len(levels))
+			if perLevel < time.Second {
+				return fmt.Errorf("--duration too short: need at least %s", time.Duration(len(levels))*time.Second)
+			}
+
+			fmt.Printf("RPC URL: %s\n", rpcURL)
+			fmt.Printf("Benchmarking for %s (%s per level)...\n\n", duration, perLevel)
+			fmt.Printf("%-8s %8s %10s %10s %10s %8s\n", "WORKERS", "PROBES", "PROBES/S", "P50", "P95", "ERRORS")
+
+			var results []*benchmarkResult
+			for _, level := range levels {
+				r := runBenchmarkLevel(rpc, level, perLevel)
+				results = append(results, r)
+				fmt.Printf("%-8d %8d %10.1f %10s %10s %7.1f%%\n",
+					r.Concurrency, r.Probes, r.Throughput(),
+					r.Percentile(50).Round(time.Millisecond), r.Percentile(95).Round(time.Millisecond),
+					r.ErrorRate()*100)
+			}
+
+			concurrency, rate, ok := recommendBenchmark(results)
+			if !ok {
+				return fmt.Errorf("every level had more than 1%% errors; check the node before uploading")
+			}
+
+			fmt.Printf("\nRecommended: --concurrency %d --rate %.0f\n", concurrency, rate)
+			statusf("💡 Sends are heavier than probes (signing, mempool); lower --rate if uploads see errors.\n")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().DurationVar(&duration, "duration", 60*time.Second, "Total benchmark duration")
+	cmd.Flags().IntVar(&maxConcurrency, "max-concurrency", 10, "Highest worker count to try (max: 10)")
+
+	return cmd
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// BuildInfo identifies the exact build of the uploader: the ldflags version
+// and build time plus what the Go toolchain embeds (VCS revision, Go version,
+// build settings and the checksums of every module linked in)
+type BuildInfo struct {
+	Version      string            `json:"version"`
+	Built        string            `json:"built"`
+	GoVersion    string            `json:"go_version"`
+	Platform     string            `json:"platform"`
+	Module       string            `json:"module,omitempty"`
+	Revision     string            `json:"vcs_revision,omitempty"`
+	RevisionTime string            `json:"vcs_time,omitempty"`
+	Modified     bool              `json:"vcs_modified"`
+	Settings     map[string]string `json:"settings,omitempty"`
+	Deps         []BuildDep        `json:"deps,omitempty"`
+}
+
+// BuildDep is a module linked into the binary
+type BuildDep struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+	// Replace is the module it was replaced with (path@version or a local path)
+	Replace string `json:"replace,omitempty"`
+}
+
+func newVersionCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print version information",
+		Long: `Print the version and build time of nimiq-uploader.
+
+-v/--verbose adds the VCS revision the binary was built from (and whether the
+tree had local changes), the Go version, the build settings and every
+dependency with its go.sum checksum. --json prints all of it as JSON, for
+auditing which build the publisher machines run.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := ReadBuildInfo()
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(info)
+			}
+
+			fmt.Printf("nimiq-uploader %s\n", info.Version)
+			fmt.Printf("Built: %s\n", info.Built)
+			if info.Revision != "" {
+				fmt.Printf("Commit: %s%s\n", info.Revision, modifiedSuffix(info.Modified))
+			}
+			fmt.Printf("Config dir: %s\n", GetConfigDir())
+			if !verbose(levelVerbose) {
+				return nil
+			}
+
+			if info.RevisionTime != "" {
+				fmt.Printf("Commit time: %s\n", info.RevisionTime)
+			}
+			fmt.Printf("Go: %s %s\n", info.GoVersion, info.Platform)
+			if info.Module != "" {
+				fmt.Printf("Module: %s\n", info.Module)
+			}
+			if len(info.Settings) > 0 {
+				keys := make([]string, 0, len(info.Settings))
+				for key := range info.Settings {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				fmt.Println("Build settings:")
+				for _, key := range keys {
+					fmt.Printf("  %s=%s\n", key, info.Settings[key])
+				}
+			}
+			if len(info.Deps) > 0 {
+				fmt.Println("Dependencies:")
+				for _, dep := range info.Deps {
+					line := fmt.Sprintf("  %s %s", dep.Path, dep.Version)
+					if dep.Replace != "" {
+						line += " => " + dep.Replace
+					}
+					if dep.Sum != "" {
+						line += " " + dep.Sum
+					}
+					fmt.Println(line)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the full build info as JSON")
+
+	return cmd
+}
+
+// ReadBuildInfo combines the ldflags variables with the build info embedded
+// by the Go toolchain. Binaries built without VCS info (-buildvcs=false or
+// outside a git checkout) have no revision.
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Built:     BuildTime,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = bi.GoVersion
+	info.Module = bi.Main.Path
+	// go install module@version sets the module version instead of ldflags
+	if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+
+	info.Settings = make(map[string]string)
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.RevisionTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		default:
+			info.Settings[setting.Key] = setting.Value
+		}
+	}
+
+	for _, mod := range bi.Deps {
+		dep := BuildDep{Path: mod.Path, Version: mod.Version, Sum: mod.Sum}
+		if r := mod.Replace; r != nil {
+			dep.Replace = r.Path
+			if r.Version != "" {
+				dep.Replace += "@" + r.Version
+			}
+			dep.Sum = r.Sum
+		}
+		info.Deps = append(info.Deps, dep)
+	}
+	return info
+}
+
+// modifiedSuffix marks builds from a tree with uncommitted changes, which
+// can't be rebuilt from the revision alone
+func modifiedSuffix(modified bool) string {
+	if modified {
+		return " (modified)"
+	}
+	return ""
+}
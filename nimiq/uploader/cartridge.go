@@ -4,19 +4,58 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
-	"strings"
 )
 
 const (
 	MagicCART = "CART"
 	MagicDATA = "DATA"
 	MagicCENT = "CENT"
+	MagicCMET = "CMET"
+
+	// CENTSchemaMetadata is the first CENT schema whose entries may have
+	// extended metadata in CMET records (see EncodeCENT2)
+	CENTSchemaMetadata = 2
 
 	// CENT flags
 	FlagRetired = 0x01 // Bit 0: App is retired and should not be shown in listings
+	FlagBeta    = 0x02 // Bit 1: Version is on the beta channel, hidden from default listings
+)
+
+// Release channels of a CENT entry
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
 )
 
+// ParseChannel validates a release channel name ("" is stable)
+func ParseChannel(s string) (string, error) {
+	switch s {
+	case "", ChannelStable:
+		return ChannelStable, nil
+	case ChannelBeta:
+		return ChannelBeta, nil
+	}
+	return "", fmt.Errorf("unknown channel %q (use %s or %s)", s, ChannelStable, ChannelBeta)
+}
+
+// ChannelFlags returns the CENT flag bits of a release channel
+func ChannelFlags(channel string) uint8 {
+	if channel == ChannelBeta {
+		return FlagBeta
+	}
+	return 0
+}
+
+// Channel returns the release channel of an entry
+func (e *CENTEntry) Channel() string {
+	if e.Flags&FlagBeta != 0 {
+		return ChannelBeta
+	}
+	return ChannelStable
+}
+
 // CARTHeader represents a cartridge header payload (64 bytes)
 type CARTHeader struct {
 	Schema      uint8
@@ -26,6 +65,10 @@ type CARTHeader struct {
 	CartridgeID uint32
 	TotalSize   uint64
 	SHA256      [32]byte
+
+	// Schema 2, compressed cartridges only (see compress.go)
+	UncompressedSize   uint32
+	UncompressedSHA256 [8]byte // first 8 bytes of the uncompressed SHA256
 }
 
 // EncodeCART encodes a CART header into a 64-byte payload
@@ -56,12 +99,40 @@ func EncodeCART(header CARTHeader) ([]byte, error) {
 	// sha256 (32 bytes)
 	copy(payload[20:52], header.SHA256[:])
 
-	// reserved (12 bytes) - already zero
+	// schema 2: uncompressed_size (u32, little-endian) and the first 8
+	// bytes of the uncompressed sha256; reserved (zero) otherwise
+	binary.LittleEndian.PutUint32(payload[52:56], header.UncompressedSize)
+	copy(payload[56:64], header.UncompressedSHA256[:])
 
 	return payload, nil
 }
 
-// DATAPayload represents a DATA chunk payload (64 bytes)
+// DecodeCART decodes a 64-byte CART header payload
+func DecodeCART(payload []byte) (*CARTHeader, error) {
+	if len(payload) < 64 {
+		return nil, fmt.Errorf("CART payload too short: %d bytes", len(payload))
+	}
+	if string(payload[0:4]) != MagicCART {
+		return nil, fmt.Errorf("not a CART payload")
+	}
+	header := &CARTHeader{
+		Schema:      payload[4],
+		Platform:    payload[5],
+		ChunkSize:   payload[6],
+		Flags:       payload[7],
+		CartridgeID: binary.LittleEndian.Uint32(payload[8:12]),
+		TotalSize:   binary.LittleEndian.Uint64(payload[12:20]),
+	}
+	copy(header.SHA256[:], payload[20:52])
+	if header.Schema >= CARTSchemaCompressed {
+		header.UncompressedSize = binary.LittleEndian.Uint32(payload[52:56])
+		copy(header.UncompressedSHA256[:], payload[56:64])
+	}
+	return header, nil
+}
+
+// DATAPayload represents a DATA chunk payload (64 bytes, or 13 + len for
+// chunks over DefaultChunkSize bytes)
 type DATAPayload struct {
 	CartridgeID uint32
 	ChunkIndex  uint32
@@ -69,9 +140,12 @@ type DATAPayload struct {
 	Data        []byte
 }
 
-// EncodeDATA encodes a DATA chunk into a 64-byte payload
+// EncodeDATA encodes a DATA chunk into a payload of DATAPayloadSize bytes
 func EncodeDATA(payload DATAPayload) ([]byte, error) {
-	buf := make([]byte, 64)
+	if int(payload.Length) != len(payload.Data) {
+		return nil, fmt.Errorf("chunk length %d doesn't match its %d bytes of data", payload.Length, len(payload.Data))
+	}
+	buf := make([]byte, DATAPayloadSize(len(payload.Data)))
 
 	// MAGIC "DATA" (4 bytes)
 	copy(buf[0:4], MagicDATA)
@@ -83,20 +157,34 @@ func EncodeDATA(payload DATAPayload) ([]byte, error) {
 	binary.LittleEndian.PutUint32(buf[8:12], payload.ChunkIndex)
 
 	// len (1 byte)
-	if payload.Length > 51 {
-		return nil, fmt.Errorf("chunk data too large: %d bytes (max 51)", payload.Length)
-	}
 	buf[12] = payload.Length
 
-	// bytes (51 bytes)
-	if len(payload.Data) > 51 {
-		return nil, fmt.Errorf("chunk data too large: %d bytes (max 51)", len(payload.Data))
-	}
-	copy(buf[13:13+len(payload.Data)], payload.Data)
+	// bytes (len bytes, zero-padded to 64 bytes for chunks up to 51 bytes)
+	copy(buf[DATAHeaderSize:], payload.Data)
 
 	return buf, nil
 }
 
+// DecodeDATA decodes a DATA chunk payload
+func DecodeDATA(payload []byte) (*DATAPayload, error) {
+	if len(payload) < DATAHeaderSize {
+		return nil, fmt.Errorf("DATA payload too short: %d bytes", len(payload))
+	}
+	if string(payload[0:4]) != MagicDATA {
+		return nil, fmt.Errorf("not a DATA payload")
+	}
+	length := payload[12]
+	if int(length) > len(payload)-DATAHeaderSize {
+		return nil, fmt.Errorf("DATA length %d exceeds its %d-byte payload", length, len(payload))
+	}
+	return &DATAPayload{
+		CartridgeID: binary.LittleEndian.Uint32(payload[4:8]),
+		ChunkIndex:  binary.LittleEndian.Uint32(payload[8:12]),
+		Length:      length,
+		Data:        payload[DATAHeaderSize : DATAHeaderSize+int(length)],
+	}, nil
+}
+
 // CENTEntry represents a CENT catalog entry payload (64 bytes)
 type CENTEntry struct {
 	Schema        uint8
@@ -106,6 +194,22 @@ type CENTEntry struct {
 	Semver        [3]uint8 // major, minor, patch
 	CartridgeAddr [20]byte // 20-byte address
 	TitleShort    string   // max 16 bytes (null-terminated)
+
+	// Schema 2, entries with extended metadata only (see EncodeCENT2)
+	MetaRecords uint8   // number of CMET records
+	MetaSize    uint16  // size of the encoded metadata
+	MetaSHA256  [8]byte // first 8 bytes of the metadata's SHA256
+
+	// Meta is the decoded metadata, set by readers that found its records
+	Meta *CENTMetadata
+}
+
+// Title returns the full title from the entry's metadata, or the short title
+func (e *CENTEntry) Title() string {
+	if e.Meta != nil && e.Meta.Title != "" {
+		return e.Meta.Title
+	}
+	return e.TitleShort
 }
 
 // EncodeCENT encodes a CENT entry into a 64-byte payload
@@ -143,79 +247,331 @@ func EncodeCENT(entry CENTEntry) ([]byte, error) {
 	copy(payload[34:34+len(titleBytes)], titleBytes)
 	// null terminator is already zero (rest of buffer is zero)
 
-	// reserved (14 bytes) - already zero
+	// schema 2: meta_records (1 byte), meta_size (u16, little-endian) and
+	// the first 8 bytes of the metadata's sha256; reserved (zero) otherwise
+	if entry.Schema >= CENTSchemaMetadata {
+		payload[50] = entry.MetaRecords
+		binary.LittleEndian.PutUint16(payload[51:53], entry.MetaSize)
+		copy(payload[53:61], entry.MetaSHA256[:])
+	}
+
+	// reserved (3 bytes) - already zero
 
 	return payload, nil
 }
 
-// Nimiq base32 alphabet (excludes I, O, U, V, W, Z to avoid confusion)
-const nimiqBase32Alphabet = "0123456789ABCDEFGHJKLMNPQRSTUVXY"
+// DecodeCENT decodes a 64-byte CENT payload
+func DecodeCENT(payload []byte) (*CENTEntry, error) {
+	if len(payload) < 64 {
+		return nil, fmt.Errorf("CENT payload too short: %d bytes", len(payload))
+	}
+	if string(payload[0:4]) != MagicCENT {
+		return nil, fmt.Errorf("not a CENT payload")
+	}
 
-// AddressNQToBytes converts a Nimiq address string (NQ...) to 20-byte binary
-// Nimiq address format is IBAN-style:
-// - NQ (2 chars) + check digits (2 chars) + address body (32 base32 chars)
-// - The check digits are MOD-97-10 calculated over the address body
-// - We skip the check digits and decode only the 32-char address body
-func AddressNQToBytes(address string) ([20]byte, error) {
-	var result [20]byte
+	entry := &CENTEntry{
+		Schema:   payload[4],
+		Platform: payload[5],
+		Flags:    payload[6],
+		AppID:    binary.LittleEndian.Uint32(payload[7:11]),
+		Semver:   [3]uint8{payload[11], payload[12], payload[13]},
+	}
+	copy(entry.CartridgeAddr[:], payload[14:34])
 
-	// Remove spaces (Nimiq addresses are often formatted with spaces)
-	address = strings.ReplaceAll(address, " ", "")
+	// title_short (16 bytes, null-terminated)
+	title := payload[34:50]
+	for i, c := range title {
+		if c == 0 {
+			title = title[:i]
+			break
+		}
+	}
+	entry.TitleShort = string(title)
 
-	if len(address) != 36 || address[:2] != "NQ" {
-		return result, fmt.Errorf("invalid address format: expected NQ + 34 chars, got %d chars", len(address))
+	if entry.Schema >= CENTSchemaMetadata {
+		entry.MetaRecords = payload[50]
+		entry.MetaSize = binary.LittleEndian.Uint16(payload[51:53])
+		copy(entry.MetaSHA256[:], payload[53:61])
 	}
 
-	// Skip NQ (2 chars) and check digits (2 chars), decode only the 32-char address body
-	base32Str := strings.ToUpper(address[4:]) // Skip "NQ" + check digits
-	if len(base32Str) != 32 {
-		return result, fmt.Errorf("invalid address body length: expected 32 base32 chars, got %d", len(base32Str))
+	return entry, nil
+}
+
+// CMET records carry the extended metadata of a CENT v2 entry. They are sent
+// to the catalog by the entry's publisher before the CENT entry itself, so
+// the metadata is complete once the entry is visible. Readers that only know
+// CENT v1 skip them (wrong magic) and ignore the entry's schema 2 fields.
+const (
+	// CMETSchema is the schema of CMET records
+	CMETSchema = 1
+	// CMETHeaderSize is the part of a CMET payload before the metadata bytes
+	CMETHeaderSize = 20
+	// CMETDataSize is the number of metadata bytes one CMET record carries
+	CMETDataSize = 64 - CMETHeaderSize
+	// MaxCENTMetadataSize is the largest metadata that fits in 255 records
+	MaxCENTMetadataSize = 255 * CMETDataSize
+)
+
+// Metadata fields (tag, u16 little-endian length, value). Readers skip tags
+// they don't know, so fields can be added without a new schema.
+const (
+	cmetTagTitle       = 1
+	cmetTagGenre       = 2
+	cmetTagYear        = 3 // u16, little-endian
+	cmetTagPublisher   = 4
+	cmetTagDescription = 5
+)
+
+// CENTMetadata is the extended metadata of a CENT v2 entry
+type CENTMetadata struct {
+	Title       string `json:"title,omitempty"` // full title
+	Genre       string `json:"genre,omitempty"`
+	Year        uint16 `json:"year,omitempty"`
+	Publisher   string `json:"publisher,omitempty"` // publisher name, not address
+	Description string `json:"description,omitempty"`
+}
+
+// IsZero reports whether no metadata field is set
+func (m CENTMetadata) IsZero() bool {
+	return m == CENTMetadata{}
+}
+
+// encode serializes the set fields
+func (m CENTMetadata) encode() []byte {
+	var buf []byte
+	field := func(tag uint8, value []byte) {
+		if len(value) == 0 {
+			return
+		}
+		buf = append(buf, tag)
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(len(value)))
+		buf = append(buf, value...)
+	}
+	field(cmetTagTitle, []byte(m.Title))
+	field(cmetTagGenre, []byte(m.Genre))
+	if m.Year != 0 {
+		field(cmetTagYear, binary.LittleEndian.AppendUint16(nil, m.Year))
 	}
+	field(cmetTagPublisher, []byte(m.Publisher))
+	field(cmetTagDescription, []byte(m.Description))
+	return buf
+}
 
-	// Build a lookup map for the base32 alphabet
-	alphabetMap := make(map[byte]int)
-	for i, c := range nimiqBase32Alphabet {
-		alphabetMap[byte(c)] = i
+// decodeCENTMetadata parses serialized metadata, skipping unknown fields
+func decodeCENTMetadata(buf []byte) (*CENTMetadata, error) {
+	meta := &CENTMetadata{}
+	for len(buf) > 0 {
+		if len(buf) < 3 {
+			return nil, fmt.Errorf("truncated metadata field")
+		}
+		tag, size := buf[0], int(binary.LittleEndian.Uint16(buf[1:3]))
+		if len(buf) < 3+size {
+			return nil, fmt.Errorf("metadata field %d is %d bytes, only %d left", tag, size, len(buf)-3)
+		}
+		value := buf[3 : 3+size]
+		switch tag {
+		case cmetTagTitle:
+			meta.Title = string(value)
+		case cmetTagGenre:
+			meta.Genre = string(value)
+		case cmetTagYear:
+			if size == 2 {
+				meta.Year = binary.LittleEndian.Uint16(value)
+			}
+		case cmetTagPublisher:
+			meta.Publisher = string(value)
+		case cmetTagDescription:
+			meta.Description = string(value)
+		}
+		buf = buf[3+size:]
 	}
+	return meta, nil
+}
 
-	// Decode 32 base32 characters = 160 bits = exactly 20 bytes
-	decoded := make([]byte, 0, 20)
-	bitBuffer := uint64(0)
-	bitsInBuffer := 0
+// EncodeCENT2 encodes a CENT entry with extended metadata: the 64-byte CENT
+// payload (schema 2, pointing at the metadata) and the CMET payloads to send
+// before it. Without metadata it is the plain CENT entry and no records.
+func EncodeCENT2(entry CENTEntry, meta CENTMetadata) ([]byte, [][]byte, error) {
+	if meta.IsZero() {
+		cent, err := EncodeCENT(entry)
+		return cent, nil, err
+	}
 
-	for i := 0; i < 32; i++ {
-		char := base32Str[i]
-		value, ok := alphabetMap[char]
-		if !ok {
-			return result, fmt.Errorf("invalid base32 character: %c (not in Nimiq alphabet)", char)
-		}
+	blob := meta.encode()
+	if len(blob) > MaxCENTMetadataSize {
+		return nil, nil, fmt.Errorf("metadata is %d bytes; at most %d fit in CMET records", len(blob), MaxCENTMetadataSize)
+	}
+	sum := sha256.Sum256(blob)
+	count := (len(blob) + CMETDataSize - 1) / CMETDataSize
+
+	entry.Schema = max(entry.Schema, CENTSchemaMetadata)
+	entry.MetaRecords = uint8(count)
+	entry.MetaSize = uint16(len(blob))
+	copy(entry.MetaSHA256[:], sum[:8])
+	cent, err := EncodeCENT(entry)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		bitBuffer = (bitBuffer << 5) | uint64(value)
-		bitsInBuffer += 5
+	records := make([][]byte, count)
+	for i := range records {
+		data := blob[i*CMETDataSize : min((i+1)*CMETDataSize, len(blob))]
+		record := make([]byte, 64)
 
-		for bitsInBuffer >= 8 {
-			decoded = append(decoded, byte(bitBuffer>>(bitsInBuffer-8)))
-			bitsInBuffer -= 8
-			bitBuffer &= (1 << bitsInBuffer) - 1
-		}
+		// MAGIC "CMET" (4 bytes)
+		copy(record[0:4], MagicCMET)
+
+		// schema (1 byte)
+		record[4] = CMETSchema
+
+		// app_id (u32, little-endian)
+		binary.LittleEndian.PutUint32(record[5:9], entry.AppID)
+
+		// first 8 bytes of the metadata's sha256, tying the record to its entry
+		copy(record[9:17], entry.MetaSHA256[:])
+
+		// index, count and len (1 byte each)
+		record[17] = uint8(i)
+		record[18] = uint8(count)
+		record[19] = uint8(len(data))
+
+		// bytes (len bytes, zero-padded)
+		copy(record[CMETHeaderSize:], data)
+		records[i] = record
 	}
+	return cent, records, nil
+}
 
-	if len(decoded) != 20 {
-		return result, fmt.Errorf("decoded address wrong size: got %d bytes, expected 20", len(decoded))
+// DecodeCENT2 reassembles the metadata of a schema 2 CENT entry from CMET
+// payloads sent by the entry's publisher. Payloads of other entries (or that
+// aren't CMET records) are skipped, so callers can pass every payload the
+// publisher sent to the catalog. It returns nil for entries without metadata.
+func DecodeCENT2(entry *CENTEntry, payloads [][]byte) (*CENTMetadata, error) {
+	if entry.Schema < CENTSchemaMetadata || entry.MetaRecords == 0 {
+		return nil, nil
 	}
 
-	copy(result[:], decoded)
-	return result, nil
+	parts := make([][]byte, entry.MetaRecords)
+	found := 0
+	for _, payload := range payloads {
+		if len(payload) < 64 || string(payload[0:4]) != MagicCMET || payload[4] != CMETSchema {
+			continue
+		}
+		if binary.LittleEndian.Uint32(payload[5:9]) != entry.AppID || [8]byte(payload[9:17]) != entry.MetaSHA256 {
+			continue
+		}
+		index, count, size := payload[17], payload[18], int(payload[19])
+		if count != entry.MetaRecords || index >= count || size > CMETDataSize || parts[index] != nil {
+			continue
+		}
+		parts[index] = payload[CMETHeaderSize : CMETHeaderSize+size]
+		found++
+	}
+	if found < len(parts) {
+		return nil, fmt.Errorf("only %d of %d CMET records found", found, len(parts))
+	}
+
+	var blob []byte
+	for _, part := range parts {
+		blob = append(blob, part...)
+	}
+	sum := sha256.Sum256(blob)
+	if len(blob) != int(entry.MetaSize) || [8]byte(sum[:8]) != entry.MetaSHA256 {
+		return nil, fmt.Errorf("CMET records don't match the entry's metadata hash")
+	}
+	return decodeCENTMetadata(blob)
 }
 
-// CalculateFileSHA256 calculates SHA256 hash of a file
-func CalculateFileSHA256(filePath string) ([32]byte, error) {
+// CalculateFileSHA256 calculates the SHA256 hash and size of a file, reading
+// it in a stream instead of loading it into memory
+func CalculateFileSHA256(filePath string) ([32]byte, int64, error) {
 	var hash [32]byte
-	data, err := os.ReadFile(filePath)
+	f, err := os.Open(filePath)
+	if err != nil {
+		return hash, 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return hash, 0, err
+	}
+	copy(hash[:], h.Sum(nil))
+	return hash, size, nil
+}
+
+// FileChunks reads fixed-size chunks of a file on demand, so uploads never
+// hold the whole file in memory. It is safe for concurrent use.
+type FileChunks struct {
+	file      *os.File
+	offset    int64
+	size      int64
+	chunkSize int
+}
+
+// OpenFileChunks opens a file for reading in chunks of chunkSize bytes
+func OpenFileChunks(filePath string, chunkSize int) (*FileChunks, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	info, err := file.Stat()
 	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+	return &FileChunks{file: file, size: info.Size(), chunkSize: chunkSize}, nil
+}
+
+// Size returns the file size in bytes
+func (c *FileChunks) Size() int64 {
+	return c.size
+}
+
+// Count returns the number of chunks
+func (c *FileChunks) Count() int {
+	return int((c.size + int64(c.chunkSize) - 1) / int64(c.chunkSize))
+}
+
+// Chunk reads chunk index; only the last chunk may be shorter than the chunk size
+func (c *FileChunks) Chunk(index uint32) ([]byte, error) {
+	offset := int64(index) * int64(c.chunkSize)
+	if offset >= c.size {
+		return nil, fmt.Errorf("chunk %d out of range (%d chunks)", index, c.Count())
+	}
+	length := int64(c.chunkSize)
+	if offset+length > c.size {
+		length = c.size - offset
+	}
+	data := make([]byte, length)
+	if _, err := c.file.ReadAt(data, c.offset+offset); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read chunk %d: %w", index, err)
+	}
+	return data, nil
+}
+
+// Section returns the chunks of size bytes starting at offset, which must be a
+// multiple of the chunk size. It shares the file, so only close the parent.
+func (c *FileChunks) Section(offset, size int64) *FileChunks {
+	return &FileChunks{file: c.file, offset: c.offset + offset, size: size, chunkSize: c.chunkSize}
+}
+
+// SHA256 hashes the chunks' bytes as a stream
+func (c *FileChunks) SHA256() ([32]byte, error) {
+	var hash [32]byte
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(c.file, c.offset, c.size)); err != nil {
 		return hash, err
 	}
-	h := sha256.Sum256(data)
-	copy(hash[:], h[:])
+	copy(hash[:], h.Sum(nil))
 	return hash, nil
 }
+
+// Close closes the underlying file
+func (c *FileChunks) Close() error {
+	return c.file.Close()
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// CatalogApp is the latest CENT entry of one app on one release channel
+type CatalogApp struct {
+	Catalog       string // alias name, or the address if it has none
+	Publisher     string
+	AppID         uint32
+	Title         string
+	Semver        string
+	CartridgeAddr string
+	Channel       string
+	Retired       bool
+	TxHash        string
+}
+
+// ListCatalogApps returns the latest entry of every app in a namespace per
+// release channel, ordered by publisher, app-id and channel. A beta entry is
+// only listed while it is newer than the app's latest stable entry, and an
+// app whose newest entry is retired is retired on every channel.
+func ListCatalogApps(rpc *NimiqRPC, ns AppNamespace, catalogName string) ([]CatalogApp, error) {
+	txs, entries, err := centEntries(rpc, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries are newest first, so the first one seen per app and channel
+	// is the latest
+	retired := make(map[string]bool)
+	seen := make(map[string]bool)
+	var apps []CatalogApp
+	for i, entry := range entries {
+		key := fmt.Sprintf("%s/%d", normalizeAddress(txs[i].From), entry.AppID)
+		if _, ok := retired[key]; !ok {
+			retired[key] = entry.Flags&FlagRetired != 0
+		}
+		channel := entry.Channel()
+		if seen[key+"@"+channel] || (channel == ChannelBeta && seen[key+"@"+ChannelStable]) {
+			continue
+		}
+		seen[key+"@"+channel] = true
+
+		apps = append(apps, CatalogApp{
+			Catalog:       catalogName,
+			Publisher:     FormatAddressNQ(txs[i].From),
+			AppID:         entry.AppID,
+			Title:         entry.Title(),
+			Semver:        fmt.Sprintf("%d.%d.%d", entry.Semver[0], entry.Semver[1], entry.Semver[2]),
+			CartridgeAddr: BytesToAddressNQ(entry.CartridgeAddr),
+			Channel:       channel,
+			Retired:       retired[key] || entry.Flags&FlagRetired != 0,
+			TxHash:        txs[i].Hash,
+		})
+	}
+
+	sort.SliceStable(apps, func(i, j int) bool {
+		if apps[i].Publisher != apps[j].Publisher {
+			return apps[i].Publisher < apps[j].Publisher
+		}
+		if apps[i].AppID != apps[j].AppID {
+			return apps[i].AppID < apps[j].AppID
+		}
+		return apps[i].Channel == ChannelStable && apps[j].Channel != ChannelStable
+	})
+	return apps, nil
+}
+
+func newCatalogCmd() *cobra.Command {
+	catalogCmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Query catalogs",
+	}
+
+	catalogCmd.AddCommand(newCatalogAppsCmd())
+	catalogCmd.AddCommand(newCatalogAllowlistCmd())
+	catalogCmd.AddCommand(newCatalogFsckCmd())
+
+	return catalogCmd
+}
+
+func newCatalogAppsCmd() *cobra.Command {
+	var (
+		rpcURL          string
+		catalogAddr     string
+		allCatalogs     bool
+		publisher       string
+		allPublishers   bool
+		showRetired     bool
+		ignoreAllowlist bool
+		channel         string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "apps",
+		Short: "List the apps a publisher has registered in one or all catalogs",
+		Long: `List the latest version of every app registered in a catalog.
+
+App-ids are allocated per (publisher, catalog): the same app-id in two
+catalogs refers to two different games. With --all-catalogs, every catalog
+in the alias registry (see catalog-alias list) on the node's network is
+queried and the results are merged, with the catalog shown per app.
+
+Catalogs with a publisher allowlist (see catalog allowlist) only show
+allowed publishers unless --ignore-allowlist is set.
+
+Only the stable channel is listed by default. --channel beta lists beta
+versions that are newer than the app's stable version, --channel all both.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if channel != "all" {
+				if _, err := ParseChannel(channel); err != nil {
+					return err
+				}
+			}
+			// Get RPC URL from env, credentials file, or default
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			if !allPublishers && publisher == "" {
+				publisher = GetDefaultAddress()
+			}
+			if allPublishers {
+				publisher = ""
+			}
+
+			rpc := NewNimiqRPC(rpcURL)
+
+			// Catalogs to query: name -> address
+			type catalogRef struct{ name, addr string }
+			var catalogs []catalogRef
+			switch {
+			case allCatalogs:
+				network := ""
+				if networkID, err := rpc.GetNetworkID(); err == nil {
+					network = networkFromID(networkID)
+				}
+				aliases := LoadCatalogAliases()
+				seen := make(map[string]bool)
+				for _, name := range sortedAliasNames(aliases) {
+					alias := aliases[name]
+					if network != "" && alias.Network != "" && alias.Network != network {
+						continue
+					}
+					if seen[normalizeAddress(alias.Address)] {
+						continue
+					}
+					seen[normalizeAddress(alias.Address)] = true
+					catalogs = append(catalogs, catalogRef{name, alias.Address})
+				}
+			case catalogAddr != "":
+				name := catalogAddr
+				catalogAddr = resolveCatalogAddress(catalogAddr)
+				if aliasName, _, ok := catalogAliasFor(catalogAddr); ok {
+					name = aliasName
+				}
+				catalogs = append(catalogs, catalogRef{name, catalogAddr})
+			default:
+				return fmt.Errorf("catalog address is required (--catalog-addr or --all-catalogs)")
+			}
+
+			var apps []CatalogApp
+			for _, c := range catalogs {
+				found, err := ListCatalogApps(rpc, AppNamespace{Catalog: c.addr, Publisher: publisher}, c.name)
+				if err != nil {
+					if allCatalogs {
+						warnf("skipping catalog %s: %v", c.name, err)
+						continue
+					}
+					return err
+				}
+
+				if !ignoreAllowlist {
+					allowlist, err := LoadAllowlist(rpc, c.addr)
+					if err != nil {
+						return err
+					}
+					hidden := 0
+					for _, app := range found {
+						if allowlist.Allows(app.Publisher) {
+							apps = append(apps, app)
+						} else {
+							hidden++
+						}
+					}
+					if hidden > 0 {
+						fmt.Printf("Hiding %d app(s) from publishers not on the %s allowlist (--ignore-allowlist to show)\n", hidden, c.name)
+					}
+					continue
+				}
+				apps = append(apps, found...)
+			}
+
+			if !showRetired {
+				filtered := apps[:0]
+				for _, app := range apps {
+					if !app.Retired {
+						filtered = append(filtered, app)
+					}
+				}
+				apps = filtered
+			}
+
+			if channel != "all" {
+				want, _ := ParseChannel(channel)
+				filtered := apps[:0]
+				hidden := 0
+				for _, app := range apps {
+					if app.Channel == want {
+						filtered = append(filtered, app)
+					} else {
+						hidden++
+					}
+				}
+				apps = filtered
+				if hidden > 0 {
+					fmt.Printf("Hiding %d version(s) on other channels (--channel all to show)\n", hidden)
+				}
+			}
+
+			if len(apps) == 0 {
+				fmt.Println("No apps found.")
+				return nil
+			}
+
+			if allPublishers {
+				fmt.Printf("%-12s %-46s %-7s %-16s %-9s %s\n", "CATALOG", "PUBLISHER", "APP-ID", "TITLE", "VERSION", "CARTRIDGE")
+			} else {
+				fmt.Printf("%-12s %-7s %-16s %-9s %s\n", "CATALOG", "APP-ID", "TITLE", "VERSION", "CARTRIDGE")
+			}
+			for _, app := range apps {
+				version := app.Semver
+				if app.Channel != ChannelStable {
+					version += "-" + app.Channel
+				}
+				if app.Retired {
+					version += " (retired)"
+				}
+				if allPublishers {
+					fmt.Printf("%-12s %-46s %-7d %-16s %-9s %s\n", app.Catalog, app.Publisher, app.AppID, app.Title, version, app.CartridgeAddr)
+				} else {
+					fmt.Printf("%-12s %-7d %-16s %-9s %s\n", app.Catalog, app.AppID, app.Title, version, app.CartridgeAddr)
+				}
+			}
+
+			// The same app-id in several catalogs is expected, but easy to
+			// mistake for the same game
+			if len(catalogs) > 1 {
+				byID := make(map[string][]string)
+				for _, app := range apps {
+					key := fmt.Sprintf("%s/%d", app.Publisher, app.AppID)
+					if names := byID[key]; len(names) > 0 && names[len(names)-1] == app.Catalog {
+						continue // another channel of the same app
+					}
+					byID[key] = append(byID[key], app.Catalog)
+				}
+				var notes []string
+				for key, names := range byID {
+					if len(names) > 1 {
+						appID := key[strings.LastIndex(key, "/")+1:]
+						notes = append(notes, fmt.Sprintf("app-id %s is used in %s", appID, strings.Join(names, ", ")))
+					}
+				}
+				sort.Strings(notes)
+				if len(notes) > 0 {
+					fmt.Println("\n💡 App-ids are per catalog; these are separate apps:")
+					for _, note := range notes {
+						fmt.Printf("  %s\n", note)
+					}
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address or alias")
+	cmd.Flags().BoolVar(&allCatalogs, "all-catalogs", false, "Query every catalog in the alias registry on the node's network")
+	cmd.Flags().StringVar(&publisher, "publisher", "", "Publisher address (defaults to ADDRESS from credentials)")
+	cmd.Flags().BoolVar(&allPublishers, "all-publishers", false, "Show apps of every publisher")
+	cmd.Flags().BoolVar(&showRetired, "show-retired", false, "Include retired apps")
+	cmd.Flags().StringVar(&channel, "channel", ChannelStable, "Release channel to list: stable, beta or all")
+	cmd.Flags().BoolVar(&ignoreAllowlist, "ignore-allowlist", false, "Show apps from publishers not on the catalog's allowlist")
+
+	return cmd
+}
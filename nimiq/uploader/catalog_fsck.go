@@ -0,0 +1,257 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// cartridgeHealth is the result of checking one cartridge address
+type cartridgeHealth struct {
+	Problems []string // why the cartridge can't be loaded
+	Warnings []string // it loads, but something is off
+}
+
+func (h *cartridgeHealth) problem(format string, args ...interface{}) {
+	h.Problems = append(h.Problems, fmt.Sprintf(format, args...))
+}
+
+func (h *cartridgeHealth) warn(format string, args ...interface{}) {
+	h.Warnings = append(h.Warnings, fmt.Sprintf(format, args...))
+}
+
+// checkCartridge checks that a cartridge address holds a CART header from
+// publisher and a complete set of chunks matching its size and SHA256, the
+// way loaders read it. Sharded cartridges are followed through their SHRD
+// links and the reassembled file is checked against the primary CART header.
+func checkCartridge(rpc *NimiqRPC, addr, publisher string) *cartridgeHealth {
+	health := &cartridgeHealth{}
+	txs, err := GetAllTransactionsByAddress(rpc, addr, 500)
+	if err != nil {
+		health.problem("failed to query cartridge address: %v", err)
+		return health
+	}
+	set, err := analyzeCartridgeChunks(txs, publisher)
+	if err != nil {
+		health.problem("%v", err)
+		return health
+	}
+
+	if set.Header.Flags&CARTFlagSharded == 0 {
+		checkChunkSet(health, "", set)
+		if len(health.Problems) == 0 && set.loaderSHA256() != set.Header.SHA256 {
+			health.problem("chunks don't match the CART header's SHA256 (see repair-cartridge)")
+		}
+		return health
+	}
+
+	links, count := newestShardLinks(txs, publisher, set.Header.CartridgeID)
+	if count == 0 {
+		health.problem("sharded CART header but no SHRD records")
+		return health
+	}
+
+	file := sha256.New()
+	var offset uint64
+	for i := uint16(0); i < count; i++ {
+		link, ok := links[i]
+		if !ok {
+			health.problem("SHRD record of shard %d/%d missing", i+1, count)
+			return health
+		}
+		if link.Offset != offset {
+			health.problem("shard %d starts at byte %d, expected %d", i, link.Offset, offset)
+			return health
+		}
+		shardAddr := BytesToAddressNQ(link.ShardAddr)
+		shardTxs, err := GetAllTransactionsByAddress(rpc, shardAddr, 500)
+		if err != nil {
+			health.problem("failed to query shard %d (%s): %v", i, shardAddr, err)
+			return health
+		}
+		shard, err := analyzeCartridgeChunks(shardTxs, publisher)
+		if err != nil {
+			health.problem("shard %d (%s): %v", i, shardAddr, err)
+			return health
+		}
+		if shard.Header.TotalSize != uint64(link.Size) {
+			health.problem("shard %d holds %d bytes, its SHRD record says %d", i, shard.Header.TotalSize, link.Size)
+			return health
+		}
+		checkChunkSet(health, fmt.Sprintf("shard %d: ", i), shard)
+		if len(health.Problems) > 0 {
+			return health
+		}
+		if shard.loaderSHA256() != shard.Header.SHA256 {
+			health.problem("shard %d: chunks don't match its CART header's SHA256 (see repair-cartridge on %s)", i, shardAddr)
+			return health
+		}
+		shard.writeLoaded(file)
+		offset += uint64(link.Size)
+	}
+	if offset != set.Header.TotalSize {
+		health.problem("shards hold %d bytes, the CART header says %d", offset, set.Header.TotalSize)
+	} else if [32]byte(file.Sum(nil)) != set.Header.SHA256 {
+		health.problem("reassembled shards don't match the CART header's SHA256")
+	}
+	return health
+}
+
+// checkChunkSet reports missing, garbled, conflicting and duplicate chunks
+func checkChunkSet(health *cartridgeHealth, prefix string, set *cartridgeChunkSet) {
+	if missing := set.missing(); len(missing) > 0 {
+		health.problem("%s%d/%d chunks missing", prefix, len(missing), set.Expected)
+	}
+	st := set.stats()
+	if len(st.Conflicting) > 0 {
+		health.warn("%s%d chunk indexes with conflicting data", prefix, len(st.Conflicting))
+	}
+	if st.ExtraTxs > 0 {
+		health.warn("%s%d duplicate chunk transactions", prefix, st.ExtraTxs)
+	}
+	if set.OutOfRange > 0 {
+		health.warn("%s%d chunks past the end of the file", prefix, set.OutOfRange)
+	}
+}
+
+// newCatalogFsckCmd creates the catalog fsck command
+func newCatalogFsckCmd() *cobra.Command {
+	var (
+		rpcURL      string
+		catalogAddr string
+		publisher   string
+		latestOnly  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "fsck",
+		Short: "Check that every cartridge in a catalog is complete and matches its CART header",
+		Long: `Walk every CENT entry of a catalog and check the cartridge it points to:
+the cartridge address must hold a CART header from the entry's publisher and
+a complete set of DATA chunks whose size and SHA256 match the header. Sharded
+cartridges are followed through their SHRD records.
+
+The report lists every app with the health of each version. Conflicting or
+duplicate chunks that don't stop the cartridge from loading are shown as
+warnings; repair-cartridge analyzes and fixes them. The command fails if any
+cartridge is broken.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			if catalogAddr == "" {
+				return fmt.Errorf("catalog address is required (--catalog-addr)")
+			}
+			catalogAddr = resolveCatalogAddress(catalogAddr)
+
+			rpc := NewNimiqRPC(rpcURL)
+			warnNetworkMismatch(rpc, catalogAddr)
+
+			txs, entries, err := centEntries(rpc, AppNamespace{Catalog: catalogAddr, Publisher: publisher})
+			if err != nil {
+				return err
+			}
+
+			// Entries are newest first; group them per publisher and app
+			type version struct {
+				entry *CENTEntry
+				tx    Transaction
+			}
+			type app struct {
+				publisher string
+				appID     uint32
+				versions  []version
+			}
+			apps := make(map[string]*app)
+			seen := make(map[string]bool)
+			for i, entry := range entries {
+				key := fmt.Sprintf("%s/%d", normalizeAddress(txs[i].From), entry.AppID)
+				if latestOnly {
+					if seen[key+"@"+entry.Channel()] {
+						continue
+					}
+					seen[key+"@"+entry.Channel()] = true
+				}
+				if apps[key] == nil {
+					apps[key] = &app{publisher: FormatAddressNQ(txs[i].From), appID: entry.AppID}
+				}
+				apps[key].versions = append(apps[key].versions, version{entry, txs[i]})
+			}
+			if len(apps) == 0 {
+				fmt.Println("No CENT entries found.")
+				return nil
+			}
+			var keys []string
+			for key := range apps {
+				keys = append(keys, key)
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				a, b := apps[keys[i]], apps[keys[j]]
+				if a.publisher != b.publisher {
+					return a.publisher < b.publisher
+				}
+				return a.appID < b.appID
+			})
+
+			// Several entries can point to the same cartridge (retire, promote)
+			checked := make(map[string]*cartridgeHealth)
+			broken := make(map[string]bool)
+			entriesChecked := 0
+			for _, key := range keys {
+				a := apps[key]
+				fmt.Printf("\nApp %d %q (publisher %s)\n", a.appID, a.versions[0].entry.Title(), a.publisher)
+				for _, v := range a.versions {
+					entriesChecked++
+					cartridgeAddr := BytesToAddressNQ(v.entry.CartridgeAddr)
+					cacheKey := normalizeAddress(cartridgeAddr) + "/" + normalizeAddress(v.tx.From)
+					health, ok := checked[cacheKey]
+					if !ok {
+						health = checkCartridge(rpc, cartridgeAddr, v.tx.From)
+						checked[cacheKey] = health
+					}
+
+					label := fmt.Sprintf("%d.%d.%d", v.entry.Semver[0], v.entry.Semver[1], v.entry.Semver[2])
+					if v.entry.Channel() != ChannelStable {
+						label += "-" + v.entry.Channel()
+					}
+					if v.entry.Flags&FlagRetired != 0 {
+						label += " (retired)"
+					}
+					status := "✓ ok"
+					if len(health.Problems) > 0 {
+						status = "✗ " + health.Problems[0]
+						broken[cacheKey] = true
+					} else if len(health.Warnings) > 0 {
+						status = "⚠️  ok"
+					}
+					fmt.Printf("  %-20s %s  %s\n", label, cartridgeAddr, status)
+					if !ok {
+						for _, p := range health.Problems[min(1, len(health.Problems)):] {
+							fmt.Printf("  %-20s %s\n", "", "✗ "+p)
+						}
+						for _, w := range health.Warnings {
+							fmt.Printf("  %-20s %s\n", "", "⚠️  "+w)
+						}
+					}
+				}
+			}
+
+			fmt.Printf("\nChecked %d CENT entries, %d cartridges\n", entriesChecked, len(checked))
+			if len(broken) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d cartridges are broken", len(broken), len(checked))
+			}
+			fmt.Printf("✓ All cartridges are complete and match their CART headers\n")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address or alias (required)")
+	cmd.Flags().StringVar(&publisher, "publisher", "", "Only check entries of this publisher (default: every publisher)")
+	cmd.Flags().BoolVar(&latestOnly, "latest", false, "Only check the latest entry of every app per release channel")
+
+	return cmd
+}
@@ -5,9 +5,17 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 )
 
+// DefaultMaxTransactions is the default cap on transactions fetched per address
+const DefaultMaxTransactions = 100000
+
+// MaxTransactions caps how many transactions GetAllTransactionsByAddress will
+// collect for a single address (set via the global --max-transactions flag)
+var MaxTransactions = DefaultMaxTransactions
+
 // normalizeAddress removes spaces and converts to uppercase for comparison
 func normalizeAddress(addr string) string {
 	return strings.ToUpper(strings.ReplaceAll(addr, " ", ""))
@@ -22,143 +30,141 @@ func getMapKeys(m map[string]interface{}) []string {
 	return keys
 }
 
-// GetMaxAppID queries the catalog and returns the maximum app-id + 1
-func GetMaxAppID(rpc *NimiqRPC, catalogAddr, publisherAddr string) (uint32, error) {
-	// Normalize catalog address (remove spaces) for RPC call
-	normalizedCatalogAddr := normalizeAddress(catalogAddr)
+// AppNamespace is the scope app-ids are allocated in. An app-id only
+// identifies a game together with the catalog it was registered in and the
+// publisher that registered it; the same number in another catalog (or from
+// another publisher) is a different game.
+type AppNamespace struct {
+	Catalog   string
+	Publisher string // empty matches every publisher
+}
+
+// String returns "publisher@catalog" for messages
+func (ns AppNamespace) String() string {
+	publisher := ns.Publisher
+	if publisher == "" {
+		publisher = "*"
+	}
+	return fmt.Sprintf("%s@%s", FormatAddressNQ(publisher), FormatAddressNQ(ns.Catalog))
+}
+
+// Contains reports whether a CENT transaction was sent by the namespace's publisher
+func (ns AppNamespace) Contains(tx Transaction) bool {
+	return ns.Publisher == "" || normalizeAddress(tx.From) == normalizeAddress(ns.Publisher)
+}
 
-	// Query all transactions from catalog address
-	transactions, err := GetAllTransactionsByAddress(rpc, normalizedCatalogAddr, 500)
+// txPayload returns the decoded data field of a transaction (nil if none)
+func txPayload(tx Transaction) []byte {
+	dataHex := tx.Data
+	if dataHex == "" {
+		dataHex = tx.RecipientData
+	}
+	if dataHex == "" {
+		dataHex = tx.SenderData
+	}
+	if dataHex == "" {
+		return nil
+	}
+	data, err := hex.DecodeString(dataHex)
 	if err != nil {
-		return 0, fmt.Errorf("failed to query catalog: %w", err)
+		return nil
 	}
+	return data
+}
 
-	maxAppID := uint32(0)
-	centCount := 0
+// centEntries returns the CENT entries sent to the namespace's catalog by
+// its publisher, newest first
+func centEntries(rpc *NimiqRPC, ns AppNamespace) ([]Transaction, []*CENTEntry, error) {
+	idx := openCatalogIndex(ns.Catalog)
+	transactions, err := catalogTransactions(rpc, idx, ns.Catalog)
+	saveIndex(idx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query catalog: %w", err)
+	}
 
-	// Parse all CENT entries to find max app-id
-	normalizedPublisher := normalizeAddress(publisherAddr)
+	var txs []Transaction
+	var entries []*CENTEntry
+	metaPayloads := make(map[string][][]byte) // CMET records per publisher
 	for _, tx := range transactions {
-		// Filter by publisher if specified (normalize both addresses for comparison)
-		if normalizedPublisher != "" && normalizeAddress(tx.From) != normalizedPublisher {
+		if !ns.Contains(tx) {
 			continue
 		}
-
-		// Parse CENT entry from transaction data
-		dataHex := tx.Data
-		if dataHex == "" {
-			dataHex = tx.RecipientData
-		}
-		if dataHex == "" {
-			dataHex = tx.SenderData
-		}
-		if dataHex == "" {
+		payload := txPayload(tx)
+		if len(payload) >= 4 && string(payload[0:4]) == MagicCMET {
+			from := normalizeAddress(tx.From)
+			metaPayloads[from] = append(metaPayloads[from], payload)
 			continue
 		}
-
-		data, err := hex.DecodeString(dataHex)
-		if err != nil || len(data) < 64 {
+		entry, err := DecodeCENT(payload)
+		if err != nil {
 			continue
 		}
+		txs = append(txs, tx)
+		entries = append(entries, entry)
+	}
 
-		// Check magic
-		if string(data[0:4]) != MagicCENT {
-			continue
+	// CENT v2 entries get their extended metadata; entries whose records
+	// are missing keep the short title
+	for i, entry := range entries {
+		if meta, err := DecodeCENT2(entry, metaPayloads[normalizeAddress(txs[i].From)]); err == nil {
+			entry.Meta = meta
 		}
+	}
+	return txs, entries, nil
+}
+
+// GetMaxAppID returns the next free app-id in a namespace (max app-id + 1)
+func GetMaxAppID(rpc *NimiqRPC, ns AppNamespace) (uint32, error) {
+	_, entries, err := centEntries(rpc, ns)
+	if err != nil {
+		return 0, err
+	}
 
-		centCount++
-		// Parse app-id (little-endian u32 at offset 7)
-		appID := binary.LittleEndian.Uint32(data[7:11])
-		if appID > maxAppID {
-			maxAppID = appID
+	maxAppID := uint32(0)
+	for _, entry := range entries {
+		if entry.AppID > maxAppID {
+			maxAppID = entry.AppID
 		}
 	}
 
 	// Return next app-id (max + 1, or 1 if none found)
-	if maxAppID == 0 {
-		return 1, nil
-	}
 	return maxAppID + 1, nil
 }
 
-// FindAppIDByTitle queries the catalog to find app-id for a given title
+// FindAppIDByTitle looks up the app-id registered for a title in a namespace
 // Returns the app-id if found, or 0 if not found
-func FindAppIDByTitle(rpc *NimiqRPC, catalogAddr, publisherAddr, title string) (uint32, error) {
-	// Normalize catalog address (remove spaces) for RPC call
-	normalizedCatalogAddr := normalizeAddress(catalogAddr)
-
-	// Query all transactions from catalog address
-	transactions, err := GetAllTransactionsByAddress(rpc, normalizedCatalogAddr, 500)
-	if err != nil {
-		return 0, fmt.Errorf("failed to query catalog: %w", err)
-	}
-
+func FindAppIDByTitle(rpc *NimiqRPC, ns AppNamespace, title string) (uint32, error) {
 	// Normalize title for comparison (trim, lowercase)
 	normalizedTitle := strings.ToLower(strings.TrimSpace(title))
 	if normalizedTitle == "" {
 		return 0, fmt.Errorf("title cannot be empty")
 	}
 
-	// Parse all CENT entries to find matching title
-	normalizedPublisher := normalizeAddress(publisherAddr)
-	for _, tx := range transactions {
-		// Filter by publisher if specified (normalize both addresses for comparison)
-		if normalizedPublisher != "" && normalizeAddress(tx.From) != normalizedPublisher {
-			continue
-		}
-
-		// Parse CENT entry from transaction data
-		dataHex := tx.Data
-		if dataHex == "" {
-			dataHex = tx.RecipientData
-		}
-		if dataHex == "" {
-			dataHex = tx.SenderData
-		}
-		if dataHex == "" {
-			continue
-		}
-
-		data, err := hex.DecodeString(dataHex)
-		if err != nil || len(data) < 64 {
-			continue
-		}
-
-		// Check magic
-		if string(data[0:4]) != MagicCENT {
-			continue
-		}
-
-		// Parse app-id
-		appID := binary.LittleEndian.Uint32(data[7:11])
-
-		// Extract title (16 bytes at offset 34, null-terminated)
-		titleBytes := data[34:50]
-		centTitle := ""
-		for i := 0; i < 16; i++ {
-			if titleBytes[i] == 0 {
-				break
-			}
-			centTitle += string(titleBytes[i])
-		}
-		centTitle = strings.ToLower(strings.TrimSpace(centTitle))
+	_, entries, err := centEntries(rpc, ns)
+	if err != nil {
+		return 0, err
+	}
 
-		// Compare titles (exact match after normalization)
-		if centTitle == normalizedTitle {
-			return appID, nil
+	for _, entry := range entries {
+		// Compare titles (exact match after normalization); the full title
+		// of CENT v2 entries, or the short one
+		if strings.ToLower(strings.TrimSpace(entry.Title())) == normalizedTitle ||
+			strings.ToLower(strings.TrimSpace(entry.TitleShort)) == normalizedTitle {
+			return entry.AppID, nil
 		}
 	}
 
 	return 0, nil // Not found
 }
 
-// GetMaxCartridgeID queries the catalog for a specific app-id and returns the maximum cartridge-id + 1
-func GetMaxCartridgeID(rpc *NimiqRPC, catalogAddr, publisherAddr string, appID uint32) (uint32, error) {
-	// Normalize catalog address (remove spaces) for RPC call
-	normalizedCatalogAddr := normalizeAddress(catalogAddr)
+// GetMaxCartridgeID queries the catalog for a specific app-id in a namespace and returns the maximum cartridge-id + 1
+func GetMaxCartridgeID(rpc *NimiqRPC, ns AppNamespace, appID uint32) (uint32, error) {
+	catalogAddr, publisherAddr := ns.Catalog, ns.Publisher
 
-	// Query all transactions from catalog address
-	transactions, err := GetAllTransactionsByAddress(rpc, normalizedCatalogAddr, 500)
+	// Query all transactions from catalog address (or its index)
+	idx := openCatalogIndex(catalogAddr)
+	defer saveIndex(idx)
+	transactions, err := catalogTransactions(rpc, idx, catalogAddr)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query catalog: %w", err)
 	}
@@ -204,20 +210,15 @@ func GetMaxCartridgeID(rpc *NimiqRPC, catalogAddr, publisherAddr string, appID u
 
 		// Extract cartridge address (20 bytes at offset 14)
 		// Convert to NQ format for querying
-		addrBytes := data[14:34]
-		addrHex := ""
-		for _, b := range addrBytes {
-			addrHex += fmt.Sprintf("%02x", b)
-		}
-		cartridgeAddr := "NQ" + addrHex
+		var addrBytes [20]byte
+		copy(addrBytes[:], data[14:34])
+		cartridgeAddr := BytesToAddressNQ(addrBytes)
 		cartridgeAddresses[cartridgeAddr] = true
 	}
 
 	// Query each cartridge address to get CART headers and find max cartridge-id
 	for cartridgeAddr := range cartridgeAddresses {
-		// Normalize cartridge address (remove spaces) for RPC call
-		normalizedCartAddr := normalizeAddress(cartridgeAddr)
-		cartTxs, err := GetAllTransactionsByAddress(rpc, normalizedCartAddr, 500)
+		cartTxs, err := cartridgeTransactions(rpc, idx, cartridgeAddr)
 		if err != nil {
 			// Skip if we can't query this address
 			continue
@@ -280,92 +281,50 @@ type Transaction struct {
 	BlockNumber   int64  `json:"blockNumber"` // Some RPCs use blockNumber instead of height
 }
 
-// GetAllTransactionsByAddress queries all transactions for an address with paging
+// GetAllTransactionsByAddress queries all transactions for an address with paging.
+// Transactions are deduplicated by hash and returned sorted by block height,
+// newest first. Paging stops when a page yields no new transactions (some nodes
+// repeat the startAt transaction or restart from the top), and an error is
+// returned if more than MaxTransactions transactions are found.
 func GetAllTransactionsByAddress(rpc *NimiqRPC, address string, maxPerPage int) ([]Transaction, error) {
 	// Normalize address (remove spaces) before RPC call
 	normalizedAddr := normalizeAddress(address)
 
 	var allTxs []Transaction
+	seenHashes := make(map[string]bool)
 	startAt := ""
 
 	for {
-		params := map[string]interface{}{
-			"address": normalizedAddr,
-			"max":     maxPerPage,
-		}
-		if startAt != "" {
-			params["startAt"] = startAt
-		}
-
-		result, err := rpc.Call("getTransactionsByAddress", params)
+		txs, err := fetchTransactionsPage(rpc, normalizedAddr, maxPerPage, startAt)
 		if err != nil {
-			return nil, fmt.Errorf("failed to call getTransactionsByAddress: %w", err)
+			return nil, err
 		}
 
-		// Parse response - RPC returns {"data": [...]} format
-		var responseWrapper struct {
-			Data []Transaction `json:"data"`
+		if len(txs) == 0 {
+			break
 		}
 
-		var txs []Transaction
-		if err := json.Unmarshal(result, &responseWrapper); err == nil && len(responseWrapper.Data) > 0 {
-			// Successfully parsed from "data" field
-			txs = responseWrapper.Data
-		} else {
-			// Try direct array format
-			if err := json.Unmarshal(result, &txs); err != nil {
-				// Try wrapped format with "transactions" field
-				var wrapped struct {
-					Transactions []Transaction `json:"transactions"`
-				}
-				if err2 := json.Unmarshal(result, &wrapped); err2 == nil {
-					txs = wrapped.Transactions
-				} else {
-					// Try as map to extract from various fields
-					var resultMap map[string]interface{}
-					if err3 := json.Unmarshal(result, &resultMap); err3 == nil {
-						// Try to extract transactions from various possible fields
-						if txsRaw, ok := resultMap["data"]; ok {
-							if txsBytes, err := json.Marshal(txsRaw); err == nil {
-								json.Unmarshal(txsBytes, &txs)
-							}
-						} else if txsRaw, ok := resultMap["transactions"]; ok {
-							if txsBytes, err := json.Marshal(txsRaw); err == nil {
-								json.Unmarshal(txsBytes, &txs)
-							}
-						} else if txsRaw, ok := resultMap["result"]; ok {
-							if txsBytes, err := json.Marshal(txsRaw); err == nil {
-								json.Unmarshal(txsBytes, &txs)
-							}
-						}
-					}
-
-					// If still no transactions, log the error
-					if len(txs) == 0 {
-						responsePreview := string(result)
-						if len(responsePreview) > 1000 {
-							responsePreview = responsePreview[:1000] + "..."
-						}
-						fmt.Printf("Failed to parse transactions. Response: %s\n", responsePreview)
-						return nil, fmt.Errorf("failed to parse transactions: %w (tried multiple formats)", err)
-					}
+		newTxs := 0
+		for _, tx := range txs {
+			if tx.Hash != "" {
+				if seenHashes[tx.Hash] {
+					continue
 				}
+				seenHashes[tx.Hash] = true
 			}
+			allTxs = append(allTxs, tx)
+			newTxs++
 		}
 
-		// Normalize transactions: use blockNumber as height if height is 0
-		for i := range txs {
-			if txs[i].Height == 0 && txs[i].BlockNumber > 0 {
-				txs[i].Height = txs[i].BlockNumber
-			}
+		if MaxTransactions > 0 && len(allTxs) > MaxTransactions {
+			return nil, fmt.Errorf("address %s has more than %d transactions; raise --max-transactions to scan the full history", normalizedAddr, MaxTransactions)
 		}
 
-		if len(txs) == 0 {
+		// A page with nothing new means the node is repeating itself - stop here
+		if newTxs == 0 {
 			break
 		}
 
-		allTxs = append(allTxs, txs...)
-
 		// Use last transaction hash as next startAt
 		startAt = txs[len(txs)-1].Hash
 
@@ -375,5 +334,86 @@ func GetAllTransactionsByAddress(rpc *NimiqRPC, address string, maxPerPage int)
 		}
 	}
 
+	// Sort by block height (newest first); stable so same-block order is kept
+	sort.SliceStable(allTxs, func(i, j int) bool {
+		return allTxs[i].Height > allTxs[j].Height
+	})
+
 	return allTxs, nil
 }
+
+// fetchTransactionsPage returns one page of getTransactionsByAddress (newest
+// first), accepting the response formats different nodes use
+func fetchTransactionsPage(rpc *NimiqRPC, normalizedAddr string, maxPerPage int, startAt string) ([]Transaction, error) {
+	params := map[string]interface{}{
+		"address": normalizedAddr,
+		"max":     maxPerPage,
+	}
+	if startAt != "" {
+		params["startAt"] = startAt
+	}
+
+	result, err := rpc.Call("getTransactionsByAddress", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call getTransactionsByAddress: %w", err)
+	}
+
+	// Parse response - RPC returns {"data": [...]} format
+	var responseWrapper struct {
+		Data []Transaction `json:"data"`
+	}
+
+	var txs []Transaction
+	if err := json.Unmarshal(result, &responseWrapper); err == nil && len(responseWrapper.Data) > 0 {
+		// Successfully parsed from "data" field
+		txs = responseWrapper.Data
+	} else {
+		// Try direct array format
+		if err := json.Unmarshal(result, &txs); err != nil {
+			// Try wrapped format with "transactions" field
+			var wrapped struct {
+				Transactions []Transaction `json:"transactions"`
+			}
+			if err2 := json.Unmarshal(result, &wrapped); err2 == nil {
+				txs = wrapped.Transactions
+			} else {
+				// Try as map to extract from various fields
+				var resultMap map[string]interface{}
+				if err3 := json.Unmarshal(result, &resultMap); err3 == nil {
+					// Try to extract transactions from various possible fields
+					if txsRaw, ok := resultMap["data"]; ok {
+						if txsBytes, err := json.Marshal(txsRaw); err == nil {
+							json.Unmarshal(txsBytes, &txs)
+						}
+					} else if txsRaw, ok := resultMap["transactions"]; ok {
+						if txsBytes, err := json.Marshal(txsRaw); err == nil {
+							json.Unmarshal(txsBytes, &txs)
+						}
+					} else if txsRaw, ok := resultMap["result"]; ok {
+						if txsBytes, err := json.Marshal(txsRaw); err == nil {
+							json.Unmarshal(txsBytes, &txs)
+						}
+					}
+				}
+
+				// If still no transactions, log the error
+				if len(txs) == 0 {
+					responsePreview := string(result)
+					if len(responsePreview) > 1000 {
+						responsePreview = responsePreview[:1000] + "..."
+					}
+					fmt.Printf("Failed to parse transactions. Response: %s\n", responsePreview)
+					return nil, fmt.Errorf("failed to parse transactions: %w (tried multiple formats)", err)
+				}
+			}
+		}
+	}
+
+	// Normalize transactions: use blockNumber as height if height is 0
+	for i := range txs {
+		if txs[i].Height == 0 && txs[i].BlockNumber > 0 {
+			txs[i].Height = txs[i].BlockNumber
+		}
+	}
+	return txs, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// transactionPages serves getTransactionsByAddress from pages keyed by the
+// startAt hash ("" for the first page) and records the startAt values
+// requested
+func transactionPages(t *testing.T, pages map[string][]Transaction, requests *[]string) *NimiqRPC {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+			Params struct {
+				Address string `json:"address"`
+				Max     int    `json:"max"`
+				StartAt string `json:"startAt"`
+			} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Method != "getTransactionsByAddress" || req.Params.Address != "NQ0712345678" {
+			t.Errorf("unexpected call %s for %q", req.Method, req.Params.Address)
+		}
+		*requests = append(*requests, req.Params.StartAt)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result":  map[string]interface{}{"data": pages[req.Params.StartAt]},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return NewNimiqRPC(srv.URL)
+}
+
+func txHashes(txs []Transaction) string {
+	hashes := make([]string, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash
+	}
+	return strings.Join(hashes, ",")
+}
+
+func TestGetAllTransactionsByAddressPages(t *testing.T) {
+	var requests []string
+	rpc := transactionPages(t, map[string][]Transaction{
+		"":   {{Hash: "h1", Height: 30}, {Hash: "h2", Height: 20}, {Hash: "h3", Height: 10}},
+		"h3": {{Hash: "h3", Height: 10}, {Hash: "h4", Height: 5}},
+	}, &requests)
+
+	txs, err := GetAllTransactionsByAddress(rpc, "nq07 1234 5678", 3)
+	if err != nil {
+		t.Fatalf("GetAllTransactionsByAddress: %v", err)
+	}
+	if got := txHashes(txs); got != "h1,h2,h3,h4" {
+		t.Errorf("transactions = %s, want h1,h2,h3,h4", got)
+	}
+	if got := strings.Join(requests, ","); got != ",h3" {
+		t.Errorf("startAt requested = %q, want \",h3\"", got)
+	}
+}
+
+func TestGetAllTransactionsByAddressSortsByBlockNumber(t *testing.T) {
+	var requests []string
+	rpc := transactionPages(t, map[string][]Transaction{
+		"": {{Hash: "old", BlockNumber: 100}, {Hash: "new", BlockNumber: 300}, {Hash: "mid", Height: 200}},
+	}, &requests)
+
+	txs, err := GetAllTransactionsByAddress(rpc, "NQ0712345678", 10)
+	if err != nil {
+		t.Fatalf("GetAllTransactionsByAddress: %v", err)
+	}
+	if got := txHashes(txs); got != "new,mid,old" {
+		t.Errorf("transactions = %s, want new,mid,old", got)
+	}
+	if txs[0].Height != 300 || txs[2].Height != 100 {
+		t.Errorf("blockNumber was not copied to Height: %+v", txs)
+	}
+}
+
+func TestGetAllTransactionsByAddressStopsOnRepeatedPage(t *testing.T) {
+	// The node ignores startAt and restarts from the top
+	page := []Transaction{{Hash: "h1", Height: 2}, {Hash: "h2", Height: 1}}
+	var requests []string
+	rpc := transactionPages(t, map[string][]Transaction{"": page, "h2": page}, &requests)
+
+	txs, err := GetAllTransactionsByAddress(rpc, "NQ0712345678", 2)
+	if err != nil {
+		t.Fatalf("GetAllTransactionsByAddress: %v", err)
+	}
+	if got := txHashes(txs); got != "h1,h2" {
+		t.Errorf("transactions = %s, want h1,h2", got)
+	}
+	if len(requests) != 2 {
+		t.Errorf("made %d requests, want 2", len(requests))
+	}
+}
+
+func TestGetAllTransactionsByAddressCap(t *testing.T) {
+	defer func(max int) { MaxTransactions = max }(MaxTransactions)
+	MaxTransactions = 3
+
+	var requests []string
+	rpc := transactionPages(t, map[string][]Transaction{
+		"":   {{Hash: "h1"}, {Hash: "h2"}},
+		"h2": {{Hash: "h3"}, {Hash: "h4"}},
+		"h4": {{Hash: "h5"}},
+	}, &requests)
+
+	txs, err := GetAllTransactionsByAddress(rpc, "NQ0712345678", 2)
+	if err == nil || !strings.Contains(err.Error(), "more than 3 transactions") {
+		t.Fatalf("GetAllTransactionsByAddress = %d transactions, %v; want the cap error", len(txs), err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("made %d requests, want 2 (stop at the cap)", len(requests))
+	}
+}
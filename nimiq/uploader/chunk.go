@@ -24,8 +24,6 @@ package main
 import (
 	"encoding/binary"
 	"fmt"
-	"io"
-	"os"
 )
 
 const (
@@ -41,44 +39,19 @@ type ChunkPayload struct {
 	Data     []byte
 }
 
-// ChunkFile reads a file and returns chunk payloads
-func ChunkFile(filePath string, gameID uint32) ([]ChunkPayload, error) {
-	file, err := os.Open(filePath)
+// ReadChunk reads DOOM chunk index of a file opened with
+// OpenFileChunks(path, ChunkSize)
+func ReadChunk(file *FileChunks, gameID uint32, index uint32) (ChunkPayload, error) {
+	data, err := file.Chunk(index)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return ChunkPayload{}, err
 	}
-	defer file.Close()
-
-	var chunks []ChunkPayload
-	idx := uint32(0)
-	buffer := make([]byte, ChunkSize)
-
-	for {
-		n, err := file.Read(buffer)
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to read file: %w", err)
-		}
-
-		chunk := ChunkPayload{
-			GameID: gameID,
-			Index:  idx,
-			Length: uint8(n),
-			Data:   make([]byte, n),
-		}
-		copy(chunk.Data, buffer[:n])
-
-		chunks = append(chunks, chunk)
-		idx++
-
-		if n < ChunkSize {
-			break // Last chunk
-		}
-	}
-
-	return chunks, nil
+	return ChunkPayload{
+		GameID: gameID,
+		Index:  index,
+		Length: uint8(len(data)),
+		Data:   data,
+	}, nil
 }
 
 // EncodePayload encodes a chunk into a 64-byte payload
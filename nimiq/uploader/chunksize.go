@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultChunkSize fits a DATA chunk into the classic 64-byte payload
+	DefaultChunkSize = 51
+
+	// MaxChunkSize is the largest chunk the DATA length byte can describe
+	MaxChunkSize = 255
+
+	// DATAHeaderSize is the part of a DATA payload before the chunk bytes
+	DATAHeaderSize = 13
+
+	// MagicPROB marks the throwaway payloads sent by chunk size probes
+	MagicPROB = "PROB"
+
+	// ChunkSizeCacheFileName keeps probed chunk sizes per RPC URL
+	ChunkSizeCacheFileName = "chunk_sizes.json"
+
+	// chunkSizeCacheTTL is how long a probed chunk size is trusted
+	chunkSizeCacheTTL = 7 * 24 * time.Hour
+)
+
+// chunkSizeCandidates are tried by --chunk-size auto, largest first
+var chunkSizeCandidates = []uint8{MaxChunkSize, 128, 64}
+
+// DATAPayloadSize returns the size of a DATA payload carrying n chunk bytes:
+// the classic 64 bytes, or more for chunks over DefaultChunkSize
+func DATAPayloadSize(n int) int {
+	if n <= DefaultChunkSize {
+		return 64
+	}
+	return DATAHeaderSize + n
+}
+
+// ParseChunkSize parses --chunk-size: a size of 1-255 bytes or "auto"
+func ParseChunkSize(s string) (size uint8, auto bool, err error) {
+	if s == "auto" {
+		return 0, true, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil || n == 0 {
+		return 0, false, fmt.Errorf("invalid --chunk-size %q (use 1-%d or auto)", s, MaxChunkSize)
+	}
+	return uint8(n), false, nil
+}
+
+// chunkSizeCacheEntry is the probed chunk size of one node
+type chunkSizeCacheEntry struct {
+	ChunkSize uint8     `json:"chunk_size"`
+	ProbedAt  time.Time `json:"probed_at"`
+}
+
+func chunkSizeCachePath() string {
+	return filepath.Join(GetStateBaseDir(), ChunkSizeCacheFileName)
+}
+
+func loadChunkSizeCache() map[string]chunkSizeCacheEntry {
+	cache := make(map[string]chunkSizeCacheEntry)
+	if data, err := os.ReadFile(chunkSizeCachePath()); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+func saveChunkSizeCache(cache map[string]chunkSizeCacheEntry) {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	os.MkdirAll(filepath.Dir(chunkSizeCachePath()), 0700)
+	os.WriteFile(chunkSizeCachePath(), data, 0644)
+}
+
+// progressChunkSize returns the chunk size recorded in the progress file of
+// a cartridge or sharded upload, or 0 if there is none. A resumed upload
+// must keep it: chunks already on chain were cut at that size.
+func progressChunkSize(runDir string, appID, cartridgeID uint32) uint8 {
+	if data, err := os.ReadFile(filepath.Join(runDir, fmt.Sprintf("upload_shards_%d_%d.json", appID, cartridgeID))); err == nil {
+		var progress ShardedUploadProgress
+		if json.Unmarshal(data, &progress) == nil {
+			if progress.ChunkSize == 0 {
+				return DefaultChunkSize
+			}
+			return progress.ChunkSize
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(runDir, fmt.Sprintf("upload_cartridge_%d_%d.json", appID, cartridgeID)))
+	if err != nil {
+		return 0
+	}
+	var progress CartridgeUploadProgress
+	if json.Unmarshal(data, &progress) != nil || progress.SentChunks == 0 {
+		return 0
+	}
+	if progress.ChunkSize == 0 {
+		return DefaultChunkSize
+	}
+	return progress.ChunkSize
+}
+
+// ResolveAutoChunkSize finds the largest chunk size the node accepts. Nodes
+// don't report their data size limit over RPC, so it is probed: a throwaway
+// PROB payload of each candidate size is sent from sender to the burn
+// address (costing 1 Luna plus fee each) until one is accepted. The result
+// is cached per RPC URL. Without probe (dry runs, unsigned uploads), only
+// the cache is used.
+func ResolveAutoChunkSize(rpcURL, sender string, fee int64, probe bool) uint8 {
+	cache := loadChunkSizeCache()
+	if entry, ok := cache[rpcURL]; ok && time.Since(entry.ProbedAt) < chunkSizeCacheTTL {
+		fmt.Printf("Chunk size: %d bytes (probed %s)\n", entry.ChunkSize, entry.ProbedAt.Format(time.RFC3339))
+		return entry.ChunkSize
+	}
+	if !probe {
+		fmt.Printf("Chunk size: %d bytes (not probed for dry runs or unsigned uploads)\n", DefaultChunkSize)
+		return DefaultChunkSize
+	}
+
+	txSender, err := NewRPCSender(rpcURL, sender, BytesToAddressNQ([20]byte{}), fee)
+	if err != nil {
+		warnf("can't probe chunk size (%v), using %d bytes", err, DefaultChunkSize)
+		return DefaultChunkSize
+	}
+	for _, candidate := range chunkSizeCandidates {
+		payload := make([]byte, DATAPayloadSize(int(candidate)))
+		copy(payload, MagicPROB)
+		statusf("Probing %d-byte payloads (chunk size %d)...\n", len(payload), candidate)
+		if _, err := txSender.SendTransaction(payload); err != nil {
+			fmt.Printf("  Rejected: %v\n", err)
+			continue
+		}
+		cache[rpcURL] = chunkSizeCacheEntry{ChunkSize: candidate, ProbedAt: time.Now()}
+		saveChunkSizeCache(cache)
+		fmt.Printf("Chunk size: %d bytes\n", candidate)
+		return candidate
+	}
+	fmt.Printf("Chunk size: %d bytes (no larger payload was accepted)\n", DefaultChunkSize)
+	return DefaultChunkSize
+}
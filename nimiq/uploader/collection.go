@@ -0,0 +1,481 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// MagicCOLL marks a player collection record
+const MagicCOLL = "COLL"
+
+// Collection record operations
+const (
+	CollectionCreate = 0x01 // create or rename the collection
+	CollectionAdd    = 0x02
+	CollectionRemove = 0x03
+)
+
+// Collection limits (the same as the Sui collection module)
+const (
+	CollectionNameSize      = 32
+	MaxCollectionCartridges = 500
+)
+
+// CollectionRecord changes one of a player's collections: lists of
+// cartridges (favorites, shelves) frontends render.
+//
+// Records are sent from the player's address, so the transaction signature
+// proves who owns the collection. Create records go to the burn address,
+// add and remove records to the cartridge. Layout (64 bytes):
+//
+//	magic "COLL" (4) | version (1) | op (1) | collection id (u32 LE, 4) |
+//	cartridge address (20, zero for create) | name (32, create only) | reserved (2)
+type CollectionRecord struct {
+	Version      uint8
+	Op           uint8
+	CollectionID uint32
+	Cartridge    [20]byte
+	Name         string
+}
+
+// EncodeCollectionRecord encodes a collection record into a 64-byte payload
+func EncodeCollectionRecord(record CollectionRecord) ([]byte, error) {
+	switch record.Op {
+	case CollectionCreate:
+		if record.Name == "" {
+			return nil, fmt.Errorf("collection name can't be empty")
+		}
+		if len(record.Name) > CollectionNameSize {
+			return nil, fmt.Errorf("collection name is %d bytes, at most %d fit", len(record.Name), CollectionNameSize)
+		}
+	case CollectionAdd, CollectionRemove:
+	default:
+		return nil, fmt.Errorf("invalid collection op: %d", record.Op)
+	}
+	payload := make([]byte, 64)
+	copy(payload[0:4], MagicCOLL)
+	payload[4] = record.Version
+	payload[5] = record.Op
+	binary.LittleEndian.PutUint32(payload[6:10], record.CollectionID)
+	copy(payload[10:30], record.Cartridge[:])
+	copy(payload[30:62], record.Name)
+	return payload, nil
+}
+
+// DecodeCollectionRecord decodes a 64-byte collection payload
+func DecodeCollectionRecord(payload []byte) (*CollectionRecord, error) {
+	if len(payload) < 64 {
+		return nil, fmt.Errorf("collection payload too short: %d bytes", len(payload))
+	}
+	if string(payload[0:4]) != MagicCOLL {
+		return nil, fmt.Errorf("not a collection payload")
+	}
+	record := &CollectionRecord{
+		Version:      payload[4],
+		Op:           payload[5],
+		CollectionID: binary.LittleEndian.Uint32(payload[6:10]),
+	}
+	copy(record.Cartridge[:], payload[10:30])
+	name := payload[30:62]
+	for i, c := range name {
+		if c == 0 {
+			name = name[:i]
+			break
+		}
+	}
+	record.Name = string(name)
+	return record, nil
+}
+
+// Collection is one of a player's collections
+type Collection struct {
+	ID         uint32   `json:"id"`
+	Name       string   `json:"name"`
+	Cartridges []string `json:"cartridges"` // cartridge addresses, in the order added
+}
+
+// Contains reports whether the collection lists a cartridge
+func (c *Collection) Contains(cartridge string) bool {
+	for _, addr := range c.Cartridges {
+		if normalizeAddress(addr) == normalizeAddress(cartridge) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadCollections replays the collection records a player has sent, ordered
+// by collection id. Records the Sui module would reject (adding to a missing
+// collection, a cartridge twice or past the limit) are ignored.
+func LoadCollections(rpc *NimiqRPC, owner string) ([]*Collection, error) {
+	transactions, err := GetAllTransactionsByAddress(rpc, normalizeAddress(owner), 500)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query player address: %w", err)
+	}
+
+	byID := make(map[uint32]*Collection)
+	// Transactions are newest first; replay oldest first
+	for i := len(transactions) - 1; i >= 0; i-- {
+		tx := transactions[i]
+		if normalizeAddress(tx.From) != normalizeAddress(owner) {
+			continue // only the player can change their collections
+		}
+		record, err := DecodeCollectionRecord(txPayload(tx))
+		if err != nil {
+			continue
+		}
+		c := byID[record.CollectionID]
+		cartridge := BytesToAddressNQ(record.Cartridge)
+		switch record.Op {
+		case CollectionCreate:
+			if c == nil {
+				c = &Collection{ID: record.CollectionID, Cartridges: []string{}}
+				byID[record.CollectionID] = c
+			}
+			c.Name = record.Name
+		case CollectionAdd:
+			if c != nil && !c.Contains(cartridge) && len(c.Cartridges) < MaxCollectionCartridges {
+				c.Cartridges = append(c.Cartridges, cartridge)
+			}
+		case CollectionRemove:
+			if c == nil {
+				continue
+			}
+			for j, addr := range c.Cartridges {
+				if normalizeAddress(addr) == normalizeAddress(cartridge) {
+					c.Cartridges = append(c.Cartridges[:j], c.Cartridges[j+1:]...)
+					break
+				}
+			}
+		}
+	}
+
+	collections := make([]*Collection, 0, len(byID))
+	for _, c := range byID {
+		collections = append(collections, c)
+	}
+	sort.Slice(collections, func(i, j int) bool { return collections[i].ID < collections[j].ID })
+	return collections, nil
+}
+
+// findCollection returns the collection with an id, nil if there is none
+func findCollection(collections []*Collection, id uint32) *Collection {
+	for _, c := range collections {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func newCollectionCmd() *cobra.Command {
+	collectionCmd := &cobra.Command{
+		Use:   "collection",
+		Short: "Manage player collections of cartridges (favorites, shelves)",
+		Long: `Manage a player's collections: lists of cartridges (favorites, a "couch
+co-op" shelf, a list to share) that frontends render.
+
+Collections mirror the Sui collection module. They are COLL records sent from
+the player's address (--sender), so its account must be imported and unlocked
+in the node. Cartridges are given by cartridge address. Collections are
+numbered per player; a collection holds at most 500 cartridges.
+
+Example:
+  nimiq-uploader collection create "Favorites"
+  nimiq-uploader collection add --collection 1 "NQ.. CARTRIDGE"
+  nimiq-uploader collection remove --collection 1 "NQ.. CARTRIDGE"
+  nimiq-uploader collection list
+  nimiq-uploader collection list --collection 1 --catalog-addr main`,
+	}
+
+	collectionCmd.AddCommand(newCollectionCreateCmd())
+	collectionCmd.AddCommand(newCollectionUpdateCmd("add", CollectionAdd))
+	collectionCmd.AddCommand(newCollectionUpdateCmd("remove", CollectionRemove))
+	collectionCmd.AddCommand(newCollectionListCmd())
+
+	return collectionCmd
+}
+
+// collectionSender resolves --sender, or the address from the credentials
+func collectionSender(sender string) (string, error) {
+	if sender == "" {
+		sender = GetDefaultAddress()
+	}
+	if sender == "" {
+		return "", fmt.Errorf("sender address is required (--sender or set in account_credentials.txt)")
+	}
+	return sender, nil
+}
+
+func newCollectionCreateCmd() *cobra.Command {
+	var (
+		rpcURL string
+		sender string
+		id     uint32
+		fee    int64
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create a collection (or rename one with --collection)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get RPC URL from env, credentials file, or default
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			sender, err := collectionSender(sender)
+			if err != nil {
+				return err
+			}
+			name := strings.TrimSpace(args[0])
+
+			collections, err := LoadCollections(NewNimiqRPC(rpcURL), sender)
+			if err != nil {
+				return err
+			}
+			if id == 0 {
+				// Number new collections after the player's last one
+				id = 1
+				if len(collections) > 0 {
+					id = collections[len(collections)-1].ID + 1
+				}
+			} else if findCollection(collections, id) == nil {
+				return fmt.Errorf("%s has no collection %d", FormatAddressNQ(sender), id)
+			}
+
+			payload, err := EncodeCollectionRecord(CollectionRecord{Version: 1, Op: CollectionCreate, CollectionID: id, Name: name})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Player: %s\n", FormatAddressNQ(sender))
+			fmt.Printf("Collection: %d (%s)\n", id, name)
+
+			if dryRun {
+				fmt.Printf("Dry-run: Would send collection record (create) from the player address\n")
+				return nil
+			}
+
+			// Create records have no cartridge to go to
+			rpcSender, err := NewRPCSender(rpcURL, sender, BytesToAddressNQ([20]byte{}), fee)
+			if err != nil {
+				return fmt.Errorf("failed to initialize RPC sender (the player account must be imported and unlocked): %w", err)
+			}
+			txHash, err := rpcSender.SendTransaction(payload)
+			if err != nil {
+				return fmt.Errorf("failed to send collection record: %w", err)
+			}
+
+			statusf("✓ Collection record sent: %s\n", txHash)
+			printExplorerLink("  ", GetDefaultNetwork(), LinkTx, txHash)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().StringVar(&sender, "sender", "", "Player address (defaults to ADDRESS from account_credentials.txt)")
+	cmd.Flags().Uint32Var(&id, "collection", 0, "Rename this collection instead of creating one")
+	cmd.Flags().Int64Var(&fee, "fee", 0, "Transaction fee in Luna (default: 0, minimum)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Dry-run mode (show what would be sent)")
+
+	return cmd
+}
+
+func newCollectionUpdateCmd(use string, op uint8) *cobra.Command {
+	var (
+		rpcURL string
+		sender string
+		id     uint32
+		fee    int64
+		dryRun bool
+	)
+
+	short := "Add cartridges to a collection"
+	if op == CollectionRemove {
+		short = "Remove cartridges from a collection"
+	}
+
+	cmd := &cobra.Command{
+		Use:   use + " CARTRIDGE...",
+		Short: short,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get RPC URL from env, credentials file, or default
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			sender, err := collectionSender(sender)
+			if err != nil {
+				return err
+			}
+
+			collections, err := LoadCollections(NewNimiqRPC(rpcURL), sender)
+			if err != nil {
+				return err
+			}
+			collection := findCollection(collections, id)
+			if collection == nil {
+				return fmt.Errorf("%s has no collection %d (see collection list)", FormatAddressNQ(sender), id)
+			}
+
+			// Skip what the records wouldn't change
+			var cartridges [][20]byte
+			var todo []string
+			for _, arg := range args {
+				cartridge, err := AddressNQToBytes(arg)
+				if err != nil {
+					return fmt.Errorf("invalid cartridge address %s: %w", arg, err)
+				}
+				addr := BytesToAddressNQ(cartridge)
+				in := collection.Contains(addr)
+				switch {
+				case containsAddress(todo, addr):
+					// Given twice
+				case op == CollectionAdd && in:
+					warnf("%s is already in %s", addr, collection.Name)
+				case op == CollectionRemove && !in:
+					warnf("%s is not in %s", addr, collection.Name)
+				default:
+					cartridges = append(cartridges, cartridge)
+					todo = append(todo, addr)
+				}
+			}
+			if len(todo) == 0 {
+				statusf("✓ Nothing to do\n")
+				return nil
+			}
+			if op == CollectionAdd && len(collection.Cartridges)+len(todo) > MaxCollectionCartridges {
+				return fmt.Errorf("a collection can hold at most %d cartridges, %s would have %d", MaxCollectionCartridges, collection.Name, len(collection.Cartridges)+len(todo))
+			}
+
+			fmt.Printf("Player: %s\n", FormatAddressNQ(sender))
+			fmt.Printf("Collection: %d (%s)\n", collection.ID, collection.Name)
+
+			if dryRun {
+				fmt.Printf("Dry-run: Would send %d collection record(s) (%s) from the player address\n", len(todo), use)
+				return nil
+			}
+
+			for i, cartridge := range cartridges {
+				payload, err := EncodeCollectionRecord(CollectionRecord{Version: 1, Op: op, CollectionID: id, Cartridge: cartridge})
+				if err != nil {
+					return err
+				}
+				// The record goes to the cartridge; it must be signed by the player
+				rpcSender, err := NewRPCSender(rpcURL, sender, todo[i], fee)
+				if err != nil {
+					return fmt.Errorf("failed to initialize RPC sender (the player account must be imported and unlocked): %w", err)
+				}
+				txHash, err := rpcSender.SendTransaction(payload)
+				if err != nil {
+					return fmt.Errorf("failed to send collection record for %s (%d of %d sent): %w", todo[i], i, len(todo), err)
+				}
+				statusf("✓ [%d/%d] %s: %s\n", i+1, len(todo), todo[i], txHash)
+				printExplorerLink("  ", GetDefaultNetwork(), LinkTx, txHash)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().StringVar(&sender, "sender", "", "Player address (defaults to ADDRESS from account_credentials.txt)")
+	cmd.Flags().Uint32Var(&id, "collection", 0, "Collection id (required, see collection list)")
+	cmd.Flags().Int64Var(&fee, "fee", 0, "Transaction fee in Luna (default: 0, minimum)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Dry-run mode (show what would be sent)")
+	cmd.MarkFlagRequired("collection")
+
+	return cmd
+}
+
+func newCollectionListCmd() *cobra.Command {
+	var (
+		rpcURL      string
+		owner       string
+		id          uint32
+		catalogAddr string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List a player's collections, or the cartridges of one collection",
+		Long: `List a player's collections, or with --collection the cartridges of one.
+With --catalog-addr, cartridges are shown with the title of the catalog
+entry that points to them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get RPC URL from env, credentials file, or default
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			owner, err := collectionSender(owner)
+			if err != nil {
+				return err
+			}
+			rpc := NewNimiqRPC(rpcURL)
+
+			collections, err := LoadCollections(rpc, owner)
+			if err != nil {
+				return err
+			}
+			if id == 0 {
+				if len(collections) == 0 {
+					fmt.Printf("No collections for %s\n", FormatAddressNQ(owner))
+					return nil
+				}
+				fmt.Printf("Collections of %s:\n", FormatAddressNQ(owner))
+				for _, c := range collections {
+					fmt.Printf("  %3d  %-32s  %d cartridge(s)\n", c.ID, c.Name, len(c.Cartridges))
+				}
+				return nil
+			}
+
+			collection := findCollection(collections, id)
+			if collection == nil {
+				return fmt.Errorf("%s has no collection %d", FormatAddressNQ(owner), id)
+			}
+			titles := map[string]string{}
+			if catalogAddr != "" {
+				catalogAddr = resolveCatalogAddress(catalogAddr)
+				apps, err := ListCatalogApps(rpc, AppNamespace{Catalog: catalogAddr}, catalogAddr)
+				if err != nil {
+					return err
+				}
+				for _, app := range apps {
+					titles[normalizeAddress(app.CartridgeAddr)] = app.Title
+				}
+			}
+
+			fmt.Printf("%s (%d cartridge(s)):\n", collection.Name, len(collection.Cartridges))
+			for _, addr := range collection.Cartridges {
+				if title, ok := titles[normalizeAddress(addr)]; ok {
+					fmt.Printf("  %s  %s\n", FormatAddressNQ(addr), title)
+				} else {
+					fmt.Printf("  %s\n", FormatAddressNQ(addr))
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().StringVar(&owner, "owner", "", "Player address (defaults to ADDRESS from account_credentials.txt)")
+	cmd.Flags().Uint32Var(&id, "collection", 0, "List the cartridges of this collection")
+	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address or alias to look up cartridge titles in")
+
+	return cmd
+}
+
+// containsAddress reports whether addrs contains addr
+func containsAddress(addrs []string, addr string) bool {
+	for _, a := range addrs {
+		if normalizeAddress(a) == normalizeAddress(addr) {
+			return true
+		}
+	}
+	return false
+}
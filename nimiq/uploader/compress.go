@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ============================================================================
+// Cartridge compression (CART schema 2)
+// ============================================================================
+
+// A compressed cartridge stores the compressed file in its DATA chunks. The
+// CART header's total_size and sha256 describe the stored (compressed) bytes,
+// so chunking, sharding and repair work unchanged; schema 2 headers add the
+// compression in the flags and, in the reserved bytes:
+//
+//	uncompressed_size   u32 LE  (offset 52)
+//	uncompressed_sha256 8 bytes (offset 56, first 8 bytes of the SHA256)
+//
+// Loaders verify the stored bytes against sha256, decompress, then check
+// the result against both uncompressed fields.
+const (
+	CARTSchemaCompressed = 2
+
+	CARTFlagGzip        = 0x02 // Bit 1: stored file is gzip compressed
+	CARTFlagZstd        = 0x04 // Bit 2: stored file is zstd compressed
+	CARTCompressionMask = CARTFlagGzip | CARTFlagZstd
+)
+
+// ParseCompression validates a --compress value and returns its CART flag
+// ("" and "none" are 0)
+func ParseCompression(s string) (uint8, error) {
+	switch s {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return CARTFlagGzip, nil
+	case "zstd":
+		return CARTFlagZstd, nil
+	}
+	return 0, fmt.Errorf("unknown compression %q (use none, gzip or zstd)", s)
+}
+
+// CompressionName returns the compression named by CART flags
+func CompressionName(flags uint8) string {
+	switch flags & CARTCompressionMask {
+	case CARTFlagGzip:
+		return "gzip"
+	case CARTFlagZstd:
+		return "zstd"
+	case 0:
+		return "none"
+	}
+	return "unknown"
+}
+
+// Compressed reports whether the stored file of a cartridge is compressed
+func (h *CARTHeader) Compressed() bool {
+	return h.Schema >= CARTSchemaCompressed && h.Flags&CARTCompressionMask != 0
+}
+
+// CARTCompression describes the compression stage of an upload: the CART
+// flag and the uncompressed file the loader has to end up with
+type CARTCompression struct {
+	Flag               uint8
+	UncompressedSize   uint32
+	UncompressedSHA256 [8]byte
+}
+
+// Apply records the compression in a CART header
+func (c CARTCompression) Apply(header *CARTHeader) {
+	if c.Flag == 0 {
+		return
+	}
+	header.Schema = max(header.Schema, CARTSchemaCompressed)
+	header.Flags |= c.Flag
+	header.UncompressedSize = c.UncompressedSize
+	header.UncompressedSHA256 = c.UncompressedSHA256
+}
+
+// compressCartridgeFile compresses filePath into runDir and returns the path
+// of the compressed file. The output is deterministic, so a resumed upload
+// recreates the same chunks. If compression doesn't make the file smaller,
+// filePath is returned with a zero CARTCompression.
+func compressCartridgeFile(filePath, runDir string, cartridgeID uint32, flag uint8) (string, CARTCompression, error) {
+	sum, size, err := CalculateFileSHA256(filePath)
+	if err != nil {
+		return "", CARTCompression{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	if size > math.MaxUint32 {
+		return "", CARTCompression{}, fmt.Errorf("file is too large to compress (%d bytes, at most %d)", size, uint32(math.MaxUint32))
+	}
+
+	in, err := os.Open(filePath)
+	if err != nil {
+		return "", CARTCompression{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer in.Close()
+
+	outPath := filepath.Join(runDir, fmt.Sprintf("compressed_%d.%s", cartridgeID, CompressionName(flag)))
+	out, err := os.Create(outPath)
+	if err != nil {
+		return "", CARTCompression{}, fmt.Errorf("failed to create %s: %w", outPath, err)
+	}
+	var w io.WriteCloser
+	switch flag {
+	case CARTFlagGzip:
+		// No name or modification time in the header keeps it deterministic
+		w, err = gzip.NewWriterLevel(out, gzip.BestCompression)
+	case CARTFlagZstd:
+		w, err = zstd.NewWriter(out, zstd.WithEncoderLevel(zstd.SpeedBestCompression), zstd.WithEncoderConcurrency(1))
+	default:
+		err = fmt.Errorf("unknown compression flag 0x%02x", flag)
+	}
+	if err == nil {
+		if _, err = io.Copy(w, in); err == nil {
+			err = w.Close()
+		}
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(outPath)
+		return "", CARTCompression{}, fmt.Errorf("failed to compress %s: %w", filePath, err)
+	}
+
+	info, err := os.Stat(outPath)
+	if err != nil {
+		return "", CARTCompression{}, err
+	}
+	if info.Size() >= size {
+		os.Remove(outPath)
+		statusf("⚠️  %s compression doesn't make the file smaller (%d -> %d bytes); uploading it uncompressed\n", CompressionName(flag), size, info.Size())
+		return filePath, CARTCompression{}, nil
+	}
+	fmt.Printf("Compressed with %s: %d -> %d bytes (%.0f%%)\n", CompressionName(flag), size, info.Size(), float64(info.Size())*100/float64(size))
+
+	c := CARTCompression{Flag: flag, UncompressedSize: uint32(size)}
+	copy(c.UncompressedSHA256[:], sum[:])
+	return outPath, c, nil
+}
+
+// DecompressCartridge decompresses the stored file of a cartridge, already
+// checked against the CART header's sha256, and checks the result against
+// the header's uncompressed size and SHA256. Uncompressed cartridges are
+// returned as they are.
+func DecompressCartridge(data []byte, header *CARTHeader) ([]byte, error) {
+	if !header.Compressed() {
+		return data, nil
+	}
+
+	var r io.Reader
+	switch header.Flags & CARTCompressionMask {
+	case CARTFlagGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress (gzip): %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case CARTFlagZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress (zstd): %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return nil, fmt.Errorf("unknown compression flags 0x%02x in CART header", header.Flags&CARTCompressionMask)
+	}
+
+	// Never read more than the header announces
+	out, err := io.ReadAll(io.LimitReader(r, int64(header.UncompressedSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress (%s): %w", CompressionName(header.Flags), err)
+	}
+	if uint64(len(out)) != uint64(header.UncompressedSize) {
+		return nil, fmt.Errorf("decompressed %d bytes, the CART header says %d", len(out), header.UncompressedSize)
+	}
+	sum := sha256.Sum256(out)
+	if !bytes.Equal(sum[:len(header.UncompressedSHA256)], header.UncompressedSHA256[:]) {
+		return nil, fmt.Errorf("decompressed file doesn't match the CART header's uncompressed SHA256")
+	}
+	return out, nil
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/retro-crypto/shared/seal"
 	"github.com/spf13/cobra"
 )
 
@@ -33,8 +34,15 @@ type Credentials struct {
 	PrivateKey string `json:"private_key,omitempty"`
 	Passphrase string `json:"passphrase,omitempty"`
 	RPCURL     string `json:"rpc_url,omitempty"`
+	Network    string `json:"network,omitempty"`  // "main" or "test", for explorer links
+	Explorer   string `json:"explorer,omitempty"` // Custom explorer URL template
 	CreatedAt  string `json:"created_at,omitempty"`
 	Comment    string `json:"comment,omitempty"`
+	// KeySource moves private_key and passphrase out of this file; see keystore.go
+	KeySource string `json:"key_source,omitempty"`
+	// Sealed holds private_key and passphrase encrypted by key encrypt; see
+	// credentials_seal.go
+	Sealed *seal.Box `json:"sealed,omitempty"`
 }
 
 // GetConfigDir returns the config directory path
@@ -141,6 +149,24 @@ func loadCredentialsJSON(filename string) (map[string]string, error) {
 	if creds.RPCURL != "" {
 		result["RPC_URL"] = creds.RPCURL
 	}
+	if creds.Network != "" {
+		result["NETWORK"] = creds.Network
+	}
+	if creds.Explorer != "" {
+		result["EXPLORER"] = creds.Explorer
+	}
+	if creds.KeySource != "" {
+		result["KEY_SOURCE"] = creds.KeySource
+	}
+	// Sealed secrets are only opened when credentialSecret needs them
+	if creds.Sealed != nil {
+		sealed, err := json.Marshal(creds.Sealed)
+		if err != nil {
+			return nil, err
+		}
+		result["SEALED"] = string(sealed)
+		result["FILE"] = filename
+	}
 
 	return result, nil
 }
@@ -192,6 +218,9 @@ func LoadCredentialsStruct(filename string) (*Credentials, error) {
 		PrivateKey: creds["PRIVATE_KEY"],
 		Passphrase: creds["PASSPHRASE"],
 		RPCURL:     creds["RPC_URL"],
+		Network:    creds["NETWORK"],
+		Explorer:   creds["EXPLORER"],
+		KeySource:  creds["KEY_SOURCE"],
 	}, nil
 }
 
@@ -205,13 +234,18 @@ func GetDefaultAddress() string {
 	return creds["ADDRESS"]
 }
 
-// GetDefaultPassphrase tries to load passphrase from credentials file
+// GetDefaultPassphrase tries to load passphrase from credentials file, or
+// from the keystore its key_source points at
 func GetDefaultPassphrase() string {
 	creds, err := LoadCredentials("")
 	if err != nil {
 		return ""
 	}
-	return creds["PASSPHRASE"]
+	passphrase, err := credentialSecret(creds, "PASSPHRASE")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+	}
+	return passphrase
 }
 
 // GetDefaultRPCURL returns the RPC URL from (in order):
@@ -328,7 +362,7 @@ a new credentials.json file with the same data in a structured JSON format.`,
 				return fmt.Errorf("output file already exists: %s (use --output to specify different path)", outputFile)
 			}
 
-			fmt.Printf("Migrating credentials...\n")
+			statusf("Migrating credentials...\n")
 			fmt.Printf("  From: %s\n", inputFile)
 			fmt.Printf("  To:   %s\n", outputFile)
 
@@ -336,7 +370,7 @@ a new credentials.json file with the same data in a structured JSON format.`,
 				return err
 			}
 
-			fmt.Printf("\n✓ Successfully migrated to JSON format!\n")
+			statusf("\n✓ Successfully migrated to JSON format!\n")
 			fmt.Printf("\nYou can now delete the old file: %s\n", inputFile)
 
 			return nil
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/retro-crypto/shared/seal"
+	"github.com/spf13/cobra"
+)
+
+// sealedCredentialsFormat is the additional data of the sealed secrets of
+// credentials.json, so they can't be passed off as another kind of box
+const sealedCredentialsFormat = "nimiq-uploader-credentials-v1"
+
+// sealedSecrets is the plaintext of Credentials.Sealed
+type sealedSecrets struct {
+	PrivateKey string `json:"private_key,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+// openedCredentials caches the secrets of each sealed credentials file, so
+// the passphrase is asked for once per run
+var openedCredentials = map[string]*sealedSecrets{}
+
+// openSealedCredentials decrypts the sealed secrets of a credentials file
+// with $NIMIQ_UPLOADER_KEYSTORE_PASSPHRASE or a prompt
+func openSealedCredentials(path string, box *seal.Box) (*sealedSecrets, error) {
+	if secrets, ok := openedCredentials[path]; ok {
+		return secrets, nil
+	}
+	passphrase, err := keystorePassphrase(path, false)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := box.Open(passphrase, []byte(sealedCredentialsFormat))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var secrets sealedSecrets
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("corrupt sealed secrets in %s: %w", path, err)
+	}
+	openedCredentials[path] = &secrets
+	return &secrets, nil
+}
+
+// sealedCredentialSecret returns PRIVATE_KEY or PASSPHRASE from the sealed
+// secrets loadCredentialsJSON found
+func sealedCredentialSecret(creds map[string]string, field string) (string, error) {
+	var box seal.Box
+	if err := json.Unmarshal([]byte(creds["SEALED"]), &box); err != nil {
+		return "", err
+	}
+	secrets, err := openSealedCredentials(creds["FILE"], &box)
+	if err != nil {
+		return "", err
+	}
+	if field == "PASSPHRASE" {
+		return secrets.Passphrase, nil
+	}
+	return secrets.PrivateKey, nil
+}
+
+// readCredentialsFile reads a JSON credentials file as it is, with its
+// sealed secrets opened into PrivateKey and Passphrase. Unlike
+// LoadCredentialsStruct it keeps created_at and comment, so rewriting the
+// file changes nothing else.
+func readCredentialsFile(path string) (*Credentials, error) {
+	if !strings.HasSuffix(path, ".json") {
+		return nil, fmt.Errorf("%s is a legacy credentials file; run nimiq-uploader migrate first", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if creds.Sealed != nil {
+		secrets, err := openSealedCredentials(path, creds.Sealed)
+		if err != nil {
+			return nil, err
+		}
+		creds.PrivateKey, creds.Passphrase = secrets.PrivateKey, secrets.Passphrase
+	}
+	return &creds, nil
+}
+
+func newKeyEncryptCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt private_key and passphrase in credentials.json",
+		Long: `Seals private_key and passphrase of credentials.json with a passphrase
+(scrypt + AES-256-GCM, like keystore files); the address, RPC URL and other
+settings stay readable. Running it again changes the passphrase.
+
+Commands then unlock the secrets with $NIMIQ_UPLOADER_KEYSTORE_PASSPHRASE or
+a prompt.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := GetCredentialsPath()
+			creds, err := readCredentialsFile(path)
+			if err != nil {
+				return err
+			}
+			if creds.PrivateKey == "" && creds.Passphrase == "" {
+				return fmt.Errorf("%s has no private_key or passphrase to encrypt", path)
+			}
+			// A new passphrase is asked for twice, even to re-key
+			passphrase, err := keystorePassphrase(path, true)
+			if err != nil {
+				return err
+			}
+			plaintext, err := json.Marshal(sealedSecrets{PrivateKey: creds.PrivateKey, Passphrase: creds.Passphrase})
+			if err != nil {
+				return err
+			}
+			wasSealed := creds.Sealed != nil
+			if creds.Sealed, err = seal.Seal(plaintext, passphrase, []byte(sealedCredentialsFormat)); err != nil {
+				return err
+			}
+			creds.PrivateKey, creds.Passphrase = "", ""
+			if err := SaveCredentials(creds, path); err != nil {
+				return fmt.Errorf("failed to update %s: %w", path, err)
+			}
+			if wasSealed {
+				statusf("✓ Changed the passphrase of the secrets in %s\n", path)
+			} else {
+				statusf("✓ Encrypted private_key and passphrase in %s\n", path)
+			}
+			return nil
+		},
+	}
+}
+
+func newKeyDecryptCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "decrypt",
+		Short: "Store private_key and passphrase in credentials.json in plain text again",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := GetCredentialsPath()
+			creds, err := readCredentialsFile(path)
+			if err != nil {
+				return err
+			}
+			if creds.Sealed == nil {
+				return fmt.Errorf("%s has no encrypted secrets", path)
+			}
+			creds.Sealed = nil
+			if err := SaveCredentials(creds, path); err != nil {
+				return fmt.Errorf("failed to update %s: %w", path, err)
+			}
+			statusf("✓ Decrypted private_key and passphrase in %s\n", path)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+)
+
+// ==============================================================================
+// Upload control socket
+// ==============================================================================
+
+// ControlSocketName is the control socket of a running upload, in its state
+// directory
+const ControlSocketName = "control.sock"
+
+// ControlStatus is what a running upload reports over its control socket
+type ControlStatus struct {
+	PID       int       `json:"pid"`
+	Command   string    `json:"command"`
+	RunDir    string    `json:"run_dir"`
+	State     string    `json:"state"` // running or paused
+	Rate      float64   `json:"rate"`
+	Sent      int64     `json:"sent"`
+	Failed    int64     `json:"failed"`
+	Expected  int64     `json:"expected"`
+	LastTx    string    `json:"last_tx,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// UploadControl rate limits the transactions of an upload and lets the
+// operator pause, resume and re-rate it through a unix socket while it runs
+type UploadControl struct {
+	limiter  *rate.Limiter
+	command  string
+	runDir   string
+	started  time.Time
+	listener net.Listener
+
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{} // closed when a pause ends
+
+	sent     int64
+	failed   int64
+	expected int64
+	lastTx   atomic.Value
+}
+
+// StartUploadControl creates the control of an upload sending at most
+// txPerSec transactions per second (burst transactions at once). With a
+// runDir it listens on <runDir>/control.sock; if the socket can't be
+// created the upload runs without it.
+func StartUploadControl(runDir, command string, txPerSec float64, burst int) *UploadControl {
+	c := &UploadControl{
+		limiter: rate.NewLimiter(rate.Limit(txPerSec), burst),
+		command: command,
+		runDir:  runDir,
+		started: time.Now(),
+	}
+	if runDir == "" {
+		return c
+	}
+
+	socketPath := filepath.Join(runDir, ControlSocketName)
+	if _, err := os.Stat(socketPath); err == nil {
+		if _, err := controlRequest(socketPath, http.MethodGet, "/status"); err == nil {
+			warnf("another upload is listening on %s; running without a control socket", socketPath)
+			return c
+		}
+		// Left behind by a run that died
+		os.Remove(socketPath)
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		warnf("no control socket (%v)", err)
+		return c
+	}
+	c.listener = listener
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", c.handle(nil))
+	mux.HandleFunc("/pause", c.handle(func(r *http.Request) error { c.Pause(); return nil }))
+	mux.HandleFunc("/resume", c.handle(func(r *http.Request) error { c.Resume(); return nil }))
+	mux.HandleFunc("/rate", c.handle(func(r *http.Request) error {
+		txPerSec, err := strconv.ParseFloat(r.URL.Query().Get("tx_per_sec"), 64)
+		if err != nil || txPerSec <= 0 {
+			return fmt.Errorf("tx_per_sec must be a positive number")
+		}
+		c.SetRate(txPerSec)
+		return nil
+	}))
+	go http.Serve(listener, mux)
+	fmt.Printf("Control socket: %s (nimiq-uploader ctl status)\n", socketPath)
+	return c
+}
+
+// handle serves a control request: action (nil for status) runs on POST,
+// and the status is returned either way
+func (c *UploadControl) handle(action func(r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if action != nil {
+			if r.Method != http.MethodPost {
+				http.Error(w, "use POST", http.StatusMethodNotAllowed)
+				return
+			}
+			if err := action(r); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.Status())
+	}
+}
+
+// Close stops listening and removes the socket
+func (c *UploadControl) Close() {
+	if c.listener != nil {
+		c.listener.Close()
+		os.Remove(filepath.Join(c.runDir, ControlSocketName))
+	}
+}
+
+// Wait blocks while the upload is paused, then for the rate limiter
+func (c *UploadControl) Wait(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		paused, resumed := c.paused, c.resumed
+		c.mu.Unlock()
+		if !paused {
+			break
+		}
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return c.limiter.Wait(ctx)
+}
+
+// Pause stops new transactions; ones already being sent complete
+func (c *UploadControl) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		c.paused = true
+		c.resumed = make(chan struct{})
+		fmt.Println("⏸  Upload paused (nimiq-uploader ctl resume)")
+	}
+}
+
+// Resume lets a paused upload continue
+func (c *UploadControl) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		c.paused = false
+		close(c.resumed)
+		fmt.Println("▶  Upload resumed")
+	}
+}
+
+// SetRate changes the transaction rate limit
+func (c *UploadControl) SetRate(txPerSec float64) {
+	c.limiter.SetLimit(rate.Limit(txPerSec))
+	fmt.Printf("Rate limit set to %.2f tx/s\n", txPerSec)
+}
+
+// Expect adds n transactions to the number the upload expects to send
+func (c *UploadControl) Expect(n int) {
+	atomic.AddInt64(&c.expected, int64(n))
+}
+
+// Status reports the state of the upload
+func (c *UploadControl) Status() ControlStatus {
+	c.mu.Lock()
+	state := "running"
+	if c.paused {
+		state = "paused"
+	}
+	c.mu.Unlock()
+	lastTx, _ := c.lastTx.Load().(string)
+	return ControlStatus{
+		PID:       os.Getpid(),
+		Command:   c.command,
+		RunDir:    c.runDir,
+		State:     state,
+		Rate:      float64(c.limiter.Limit()),
+		Sent:      atomic.LoadInt64(&c.sent),
+		Failed:    atomic.LoadInt64(&c.failed),
+		Expected:  atomic.LoadInt64(&c.expected),
+		LastTx:    lastTx,
+		StartedAt: c.started,
+	}
+}
+
+// Track wraps a sender so its transactions are counted in the status
+func (c *UploadControl) Track(sender TxSender) TxSender {
+	return &trackedSender{sender: sender, control: c}
+}
+
+type trackedSender struct {
+	sender  TxSender
+	control *UploadControl
+}
+
+func (t *trackedSender) SendTransaction(payload []byte) (string, error) {
+	txHash, err := t.sender.SendTransaction(payload)
+	if err != nil {
+		atomic.AddInt64(&t.control.failed, 1)
+		return "", err
+	}
+	atomic.AddInt64(&t.control.sent, 1)
+	t.control.lastTx.Store(txHash)
+	return txHash, nil
+}
+
+// controlRequest sends a request to a control socket and returns the status
+func controlRequest(socketPath, method, path string) (*ControlStatus, error) {
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+	req, err := http.NewRequest(method, "http://uploader"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", string(body))
+	}
+	var status ControlStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, fmt.Errorf("invalid status: %w", err)
+	}
+	return &status, nil
+}
+
+// liveControlSockets returns the control sockets under the state directory
+// that a running upload answers on
+func liveControlSockets() []string {
+	base := GetStateBaseDir()
+	var sockets []string
+	for _, pattern := range []string{
+		filepath.Join(base, "*", "*", ControlSocketName),
+		filepath.Join(base, "*", ControlSocketName),
+	} {
+		matches, _ := filepath.Glob(pattern)
+		for _, socketPath := range matches {
+			if _, err := controlRequest(socketPath, http.MethodGet, "/status"); err == nil {
+				sockets = append(sockets, socketPath)
+			}
+		}
+	}
+	return sockets
+}
+
+func printControlStatus(socketPath string, s *ControlStatus) {
+	fmt.Printf("Upload %s (pid %d, %s)\n", s.RunDir, s.PID, s.Command)
+	fmt.Printf("  State:    %s\n", s.State)
+	fmt.Printf("  Rate:     %.2f tx/s\n", s.Rate)
+	if s.Expected > 0 {
+		fmt.Printf("  Sent:     %d/%d transactions (%d failed)\n", s.Sent, s.Expected, s.Failed)
+	} else {
+		fmt.Printf("  Sent:     %d transactions (%d failed)\n", s.Sent, s.Failed)
+	}
+	if s.LastTx != "" {
+		fmt.Printf("  Last tx:  %s\n", s.LastTx)
+	}
+	fmt.Printf("  Running:  %s\n", time.Since(s.StartedAt).Round(time.Second))
+	fmt.Printf("  Socket:   %s\n", socketPath)
+}
+
+// newCtlCmd creates the ctl command for controlling running uploads
+func newCtlCmd() *cobra.Command {
+	var (
+		socketPath string
+		stateDir   string
+	)
+
+	// resolveSocket picks the socket of the upload to control
+	resolveSocket := func(all bool) ([]string, error) {
+		if socketPath != "" {
+			return []string{socketPath}, nil
+		}
+		if stateDir != "" {
+			return []string{filepath.Join(stateDir, ControlSocketName)}, nil
+		}
+		sockets := liveControlSockets()
+		switch {
+		case len(sockets) == 0:
+			return nil, fmt.Errorf("no running upload found under %s (use --socket or --state-dir)", GetStateBaseDir())
+		case len(sockets) > 1 && !all:
+			msg := "several uploads are running; pick one with --socket:"
+			for _, s := range sockets {
+				msg += "\n  " + s
+			}
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return sockets, nil
+	}
+
+	run := func(method, path string, all bool) error {
+		sockets, err := resolveSocket(all)
+		if err != nil {
+			return err
+		}
+		var statuses []*ControlStatus
+		for _, s := range sockets {
+			status, err := controlRequest(s, method, path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", s, err)
+			}
+			statuses = append(statuses, status)
+		}
+		for i, status := range statuses {
+			if i > 0 {
+				fmt.Println()
+			}
+			printControlStatus(sockets[i], status)
+		}
+		return nil
+	}
+
+	cmd := &cobra.Command{
+		Use:   "ctl",
+		Short: "Control running uploads (status, pause, resume, set-rate)",
+		Long: `Talks to the control socket of a running upload-cartridge or execute-plan
+(control.sock in its state directory). Without --socket or --state-dir, the
+running uploads under the state base directory are found automatically.`,
+	}
+	cmd.PersistentFlags().StringVar(&socketPath, "socket", "", "Control socket of the upload")
+	cmd.PersistentFlags().StringVar(&stateDir, "state-dir", "", "State directory of the upload")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show the state of running uploads",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(http.MethodGet, "/status", true)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "pause",
+		Short: "Pause an upload after the transactions being sent",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(http.MethodPost, "/pause", false)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "resume",
+		Short: "Resume a paused upload",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(http.MethodPost, "/resume", false)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set-rate TX_PER_SEC",
+		Short: "Change the transaction rate of an upload",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			txPerSec, err := strconv.ParseFloat(args[0], 64)
+			if err != nil || txPerSec <= 0 {
+				return fmt.Errorf("rate must be a positive number of transactions per second")
+			}
+			return run(http.MethodPost, "/rate?tx_per_sec="+strconv.FormatFloat(txPerSec, 'f', -1, 64), false)
+		},
+	})
+
+	return cmd
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Cartridge download
+// ============================================================================
+
+// newestShardLinks returns the newest SHRD record per shard index sent for
+// cartridgeID, like loaders, and the shard count they name
+func newestShardLinks(txs []Transaction, publisher string, cartridgeID uint32) (map[uint16]*SHRDLink, uint16) {
+	links := make(map[uint16]*SHRDLink)
+	var count uint16
+	for _, tx := range txs {
+		if publisher != "" && normalizeAddress(tx.From) != normalizeAddress(publisher) {
+			continue
+		}
+		link, err := DecodeSHRD(txPayload(tx))
+		if err != nil || link.CartridgeID != cartridgeID {
+			continue
+		}
+		if _, ok := links[link.ShardIndex]; !ok {
+			links[link.ShardIndex] = link
+			count = max(count, link.ShardCount)
+		}
+	}
+	return links, count
+}
+
+// assembleChunks puts the chunks of a cartridge together in index order.
+// Loaders' picks (the newest transaction of each index) are used when they
+// match the CART header; otherwise the combination of conflicting chunks
+// that does is searched for.
+func assembleChunks(set *cartridgeChunkSet) ([]byte, error) {
+	if missing := set.missing(); len(missing) > 0 {
+		return nil, fmt.Errorf("%d/%d chunks are missing: %v", len(missing), set.Expected, missing)
+	}
+
+	var buf bytes.Buffer
+	if set.loaderSHA256() == set.Header.SHA256 {
+		set.writeLoaded(&buf)
+		return buf.Bytes(), nil
+	}
+	canonical, err := set.resolve()
+	if err != nil {
+		return nil, err
+	}
+	if canonical == nil {
+		set.writeLoaded(&buf)
+		if uint64(buf.Len()) != set.Header.TotalSize {
+			return nil, fmt.Errorf("chunks hold %d bytes, the CART header says %d", buf.Len(), set.Header.TotalSize)
+		}
+		return nil, fmt.Errorf("chunks don't match the CART header's SHA256 (see repair-cartridge)")
+	}
+	for i := 0; i < set.Expected; i++ {
+		buf.Write(canonical[uint32(i)])
+	}
+	return buf.Bytes(), nil
+}
+
+// downloadCartridge reconstructs the file stored at a cartridge address,
+// following the SHRD records of sharded cartridges, and checks it against
+// the CART header's size and SHA256. Compressed cartridges are decompressed
+// and checked against the uncompressed size and SHA256 too.
+func downloadCartridge(rpc *NimiqRPC, addr, publisher string) ([]byte, *CARTHeader, error) {
+	txs, err := GetAllTransactionsByAddress(rpc, addr, 500)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query cartridge address: %w", err)
+	}
+	set, err := analyzeCartridgeChunks(txs, publisher)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var data []byte
+	if set.Header.Flags&CARTFlagSharded == 0 {
+		if data, err = assembleChunks(set); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		links, count := newestShardLinks(txs, publisher, set.Header.CartridgeID)
+		if count == 0 {
+			return nil, nil, fmt.Errorf("sharded CART header but no SHRD records")
+		}
+		var buf bytes.Buffer
+		for i := uint16(0); i < count; i++ {
+			link, ok := links[i]
+			if !ok {
+				return nil, nil, fmt.Errorf("SHRD record of shard %d/%d missing", i+1, count)
+			}
+			if link.Offset != uint64(buf.Len()) {
+				return nil, nil, fmt.Errorf("shard %d starts at byte %d, expected %d", i, link.Offset, buf.Len())
+			}
+			shardAddr := BytesToAddressNQ(link.ShardAddr)
+			fmt.Printf("  Shard %d/%d: %s\n", i+1, count, shardAddr)
+			shardTxs, err := GetAllTransactionsByAddress(rpc, shardAddr, 500)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to query shard %d (%s): %w", i, shardAddr, err)
+			}
+			shard, err := analyzeCartridgeChunks(shardTxs, publisher)
+			if err != nil {
+				return nil, nil, fmt.Errorf("shard %d (%s): %w", i, shardAddr, err)
+			}
+			if shard.Header.TotalSize != uint64(link.Size) {
+				return nil, nil, fmt.Errorf("shard %d holds %d bytes, its SHRD record says %d", i, shard.Header.TotalSize, link.Size)
+			}
+			shardData, err := assembleChunks(shard)
+			if err != nil {
+				return nil, nil, fmt.Errorf("shard %d (%s): %w", i, shardAddr, err)
+			}
+			buf.Write(shardData)
+		}
+		data = buf.Bytes()
+	}
+
+	if uint64(len(data)) != set.Header.TotalSize {
+		return nil, nil, fmt.Errorf("reconstructed %d bytes, the CART header says %d", len(data), set.Header.TotalSize)
+	}
+	if sha256.Sum256(data) != set.Header.SHA256 {
+		return nil, nil, fmt.Errorf("reconstructed file doesn't match the CART header's SHA256")
+	}
+	if data, err = DecompressCartridge(data, set.Header); err != nil {
+		return nil, nil, err
+	}
+	return data, set.Header, nil
+}
+
+// newDownloadCartridgeCmd creates the download-cartridge command
+func newDownloadCartridgeCmd() *cobra.Command {
+	var (
+		cartridgeAddr string
+		publisher     string
+		outputPath    string
+		force         bool
+		rpcURL        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "download-cartridge",
+		Short: "Reconstruct the file stored at a cartridge address",
+		Long: `Fetch every DATA chunk sent to a cartridge address, put them together in
+index order and write the file, after checking it against the CART header's
+total size and SHA256. Sharded cartridges are followed through their SHRD
+records.
+
+Like loaders, the newest transaction of each chunk index is used. If those
+don't match the CART header, the combination of conflicting chunks that does
+is used instead.
+
+Compressed cartridges (schema 2) are decompressed, and the result is checked
+against the uncompressed size and SHA256 of the CART header as well.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cartridgeAddr == "" {
+				return fmt.Errorf("cartridge address is required (--cartridge-addr)")
+			}
+			if err := ValidateAddressNQ(cartridgeAddr); err != nil {
+				return fmt.Errorf("invalid cartridge address %s: %w", cartridgeAddr, err)
+			}
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			if outputPath != "" && !force {
+				if _, err := os.Stat(outputPath); err == nil {
+					return fmt.Errorf("%s already exists (use --force to overwrite)", outputPath)
+				}
+			}
+
+			statusf("Downloading cartridge %s...\n", cartridgeAddr)
+			data, header, err := downloadCartridge(NewNimiqRPC(rpcURL), cartridgeAddr, publisher)
+			if err != nil {
+				return err
+			}
+
+			if outputPath == "" {
+				outputPath = fmt.Sprintf("cartridge-%d.bin", header.CartridgeID)
+				if _, err := os.Stat(outputPath); err == nil && !force {
+					return fmt.Errorf("%s already exists (use --output or --force)", outputPath)
+				}
+			}
+			// Write next to the target first so a failed write leaves no partial file
+			tmpPath := outputPath + ".part"
+			if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+			}
+			if err := os.Rename(tmpPath, outputPath); err != nil {
+				os.Remove(tmpPath)
+				return fmt.Errorf("failed to write %s: %w", outputPath, err)
+			}
+
+			fmt.Printf("✓ Wrote %s (%d bytes, cartridge ID %d)\n", outputPath, len(data), header.CartridgeID)
+			if header.Compressed() {
+				sum := sha256.Sum256(data)
+				fmt.Printf("  Stored: %d bytes %s, SHA256 %s (matches the CART header)\n", header.TotalSize, CompressionName(header.Flags), hex.EncodeToString(header.SHA256[:]))
+				fmt.Printf("  SHA256: %s (matches the CART header's uncompressed SHA256)\n", hex.EncodeToString(sum[:]))
+			} else {
+				fmt.Printf("  SHA256: %s (matches the CART header)\n", hex.EncodeToString(header.SHA256[:]))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&cartridgeAddr, "cartridge-addr", "", "Cartridge address to download (required)")
+	cmd.Flags().StringVar(&publisher, "publisher", "", "Only use transactions from this address, like loaders of curated catalogs")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "File to write (default: cartridge-<id>.bin)")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite the output file if it exists")
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+
+	return cmd
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// newExecutePlanCmd creates the execute-plan command
+func newExecutePlanCmd() *cobra.Command {
+	var (
+		sender      string
+		rpcURL      string
+		rateLimit   float64
+		stateDir    string
+		forceUnlock bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "execute-plan PLAN_FILE",
+		Short: "Send exactly the transactions of a plan written by upload-cartridge --dry-run --plan-out",
+		Long: `Execute a previously generated upload plan.
+
+Every transaction is sent exactly as listed in the plan (same recipients,
+payloads and fee), so a plan can be reviewed by one person and executed by
+another. Progress is shared with upload-cartridge: if a run is interrupted,
+run the same command again to resume.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plan, err := LoadCartridgePlan(args[0])
+			if err != nil {
+				return err
+			}
+
+			// Decode and check every operation before sending anything
+			payloads, dataCount, err := verifyCartridgePlan(plan)
+			if err != nil {
+				return fmt.Errorf("invalid plan: %w", err)
+			}
+
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			if sender == "" {
+				sender = GetDefaultAddress()
+			}
+			if sender == "" {
+				return fmt.Errorf("sender address is required (--sender or set in account_credentials.txt)")
+			}
+			if rateLimit <= 0 {
+				rateLimit = plan.Rate
+			}
+			if rateLimit <= 0 {
+				rateLimit = 25
+			}
+
+			statusf("Plan: %s\n", args[0])
+			statusf("File: %s (%d bytes, SHA256 %s)\n", plan.File, plan.Size, plan.SHA256)
+			statusf("App ID: %d, Cartridge ID: %d\n", plan.AppID, plan.CartridgeID)
+			statusf("Cartridge Address: %s\n", plan.CartridgeAddr)
+			statusf("Catalog Address: %s\n", plan.CatalogAddr)
+			statusf("Sender: %s\n", sender)
+			PrintPlanSummary(plan)
+
+			// Plans with auto-generated IDs reserve them only once the CENT
+			// entry lands, so hold the catalog lock like upload-cartridge does
+			catalogLock, err := AcquireLock(catalogLockPath(plan.CatalogAddr), "execute-plan", forceUnlock)
+			if err != nil {
+				return err
+			}
+			defer catalogLock.Release()
+
+			runDir, err := ResolveRunDir(stateDir, plan.CatalogAddr, plan.AppID)
+			if err != nil {
+				return err
+			}
+			uploadRunDir = runDir
+
+			cartridgeLock, err := AcquireLock(filepath.Join(runDir, fmt.Sprintf("upload_cartridge_%d_%d.lock", plan.AppID, plan.CartridgeID)), "execute-plan", forceUnlock)
+			if err != nil {
+				return err
+			}
+			defer cartridgeLock.Release()
+
+			progressFile := filepath.Join(runDir, fmt.Sprintf("upload_cartridge_%d_%d.json", plan.AppID, plan.CartridgeID))
+			progress, err := loadPlanProgress(progressFile, plan, dataCount)
+			if err != nil {
+				return err
+			}
+
+			logCartridgeUpload("=== Plan Execution Started ===")
+			logCartridgeUpload("Plan: " + args[0])
+			logCartridgeUpload(fmt.Sprintf("App ID: %d", plan.AppID))
+			logCartridgeUpload(fmt.Sprintf("Cartridge ID: %d", plan.CartridgeID))
+			logCartridgeUpload(fmt.Sprintf("Sender: %s", sender))
+
+			rpc := NewNimiqRPC(rpcURL)
+			warnNetworkMismatch(rpc, plan.CatalogAddr)
+			consensus, err := rpc.IsConsensusEstablished()
+			if err != nil {
+				return fmt.Errorf("failed to check consensus: %w", err)
+			}
+			if !consensus {
+				return fmt.Errorf("node does not have consensus with the network - cannot upload. Wait for sync")
+			}
+
+			control := StartUploadControl(runDir, "execute-plan", rateLimit, 1)
+			defer control.Close()
+
+			// One sender per recipient (cartridge address and catalog address)
+			senders := make(map[string]TxSender)
+			senderFor := func(to string) (TxSender, error) {
+				key := normalizeAddress(to)
+				if s, ok := senders[key]; ok {
+					return s, nil
+				}
+				s, err := NewRPCSender(rpcURL, sender, to, plan.Fee)
+				if err != nil {
+					return nil, fmt.Errorf("failed to initialize RPC sender: %w", err)
+				}
+				senders[key] = control.Track(s)
+				return senders[key], nil
+			}
+
+			// Adopt what an interrupted run sent but didn't get to save
+			target := reconcileTarget{Senders: []string{sender}, CatalogAddr: plan.CatalogAddr}
+			chunkPayloads := make(map[uint32][]byte)
+			for i, op := range plan.Operations {
+				switch op.Type {
+				case "DATA":
+					chunkPayloads[*op.ChunkIndex] = payloads[i]
+				case "CART":
+					target.CART = payloads[i]
+				case "CENT":
+					target.CENT = payloads[i]
+				}
+			}
+			target.Chunk = func(index uint32) ([]byte, error) {
+				return chunkPayloads[index], nil
+			}
+			reconcileAndSave(rpc, progress, progressFile, target)
+
+			sentHashes := make(map[uint32]string)
+			for _, entry := range progress.Plan {
+				if entry.TxHash != "" {
+					sentHashes[entry.Index] = entry.TxHash
+				}
+			}
+
+			remaining := len(plan.Operations) - len(sentHashes)
+			if progress.CARTTxHash != "" {
+				remaining--
+			}
+			if progress.CENTTxHash != "" {
+				remaining--
+			}
+			remaining -= len(progress.CMETTxHashes)
+			control.Expect(remaining)
+			sentThisRun := 0
+			accounting := &UploadAccounting{FeeLuna: plan.Fee}
+			network := networkForCatalog(plan.CatalogAddr)
+
+			for i, op := range plan.Operations {
+				switch op.Type {
+				case "DATA":
+					if _, ok := sentHashes[*op.ChunkIndex]; ok {
+						continue
+					}
+				case "CART":
+					if progress.CARTTxHash != "" {
+						statusf("CART header already sent: %s\n", progress.CARTTxHash)
+						continue
+					}
+					if progress.SentChunks != progress.TotalChunks {
+						return fmt.Errorf("only %d/%d DATA chunks sent; run execute-plan again to retry before sending CART", progress.SentChunks, progress.TotalChunks)
+					}
+				case "CMET":
+					// Metadata records are sent in order, right before the CENT entry
+					if metaIndex := i - dataCount - 1; metaIndex < len(progress.CMETTxHashes) {
+						continue
+					}
+				case "CENT":
+					if progress.CENTTxHash != "" {
+						statusf("CENT entry already sent: %s\n", progress.CENTTxHash)
+						continue
+					}
+				}
+
+				if err := control.Wait(cmd.Context()); err != nil {
+					return err
+				}
+				txSender, err := senderFor(op.To)
+				if err != nil {
+					return err
+				}
+
+				txHash, err := txSender.SendTransaction(payloads[i])
+				if err != nil {
+					saveCartridgeProgress(progressFile, progress)
+					return fmt.Errorf("step %d (%s) failed: %w", op.Step, op.Type, err)
+				}
+				sentThisRun++
+				if op.Type == "CENT" || op.Type == "CMET" {
+					accounting.ToCatalog++
+				} else {
+					accounting.ToCartridge++
+				}
+
+				switch op.Type {
+				case "DATA":
+					progress.Plan = append(progress.Plan, UploadPlan{
+						Index:   *op.ChunkIndex,
+						Payload: op.Payload,
+						TxHash:  txHash,
+					})
+					progress.SentChunks++
+					sentHashes[*op.ChunkIndex] = txHash
+					statusf("Sent chunk %d/%d\n", progress.SentChunks, progress.TotalChunks)
+					if progress.SentChunks%10 == 0 {
+						saveCartridgeProgress(progressFile, progress)
+					}
+				case "CART":
+					progress.CARTTxHash = txHash
+					statusf("✓ CART header sent: %s\n", txHash)
+					printExplorerLink("  ", network, LinkTx, txHash)
+					logCartridgeUpload(fmt.Sprintf("CART header sent: %s", txHash))
+					saveCartridgeProgress(progressFile, progress)
+				case "CMET":
+					progress.CMETTxHashes = append(progress.CMETTxHashes, txHash)
+					statusf("✓ Metadata record %d sent to catalog: %s\n", len(progress.CMETTxHashes), txHash)
+					saveCartridgeProgress(progressFile, progress)
+				case "CENT":
+					progress.CENTTxHash = txHash
+					statusf("✓ CENT entry sent to catalog: %s\n", txHash)
+					printExplorerLink("  ", network, LinkTx, txHash)
+					logCartridgeUpload(fmt.Sprintf("CENT entry sent to catalog: %s", txHash))
+					saveCartridgeProgress(progressFile, progress)
+					RecordCartridge(plan.CatalogAddr, plan.Title, plan.AppID, ProjectCartridge{
+						CartridgeID:   plan.CartridgeID,
+						CartridgeAddr: plan.CartridgeAddr,
+						Semver:        plan.Semver,
+						File:          plan.File,
+						SHA256:        plan.SHA256,
+						CENTTxHash:    txHash,
+					})
+				}
+			}
+
+			saveCartridgeProgress(progressFile, progress)
+
+			statusf("\n✓ Plan executed! (%d transactions sent this run)\n", sentThisRun)
+			resultf("  Cartridge address: %s\n", plan.CartridgeAddr)
+			printExplorerLink("    ", network, LinkAddress, plan.CartridgeAddr)
+			resultf("  CART header: %s\n", progress.CARTTxHash)
+			printExplorerLink("    ", network, LinkTx, progress.CARTTxHash)
+			resultf("  DATA chunks: %d/%d\n", progress.SentChunks, progress.TotalChunks)
+			resultf("  CENT entry: %s\n", progress.CENTTxHash)
+			printExplorerLink("    ", network, LinkTx, progress.CENTTxHash)
+
+			if sentThisRun > 0 {
+				accounting.CartridgeSweepable, _ = rpc.IsAccountImported(plan.CartridgeAddr)
+				accounting.PrintReport()
+				RecordSpend(plan.CatalogAddr, plan.Title, plan.AppID, accounting)
+			}
+
+			logCartridgeUpload("=== Plan Execution Complete ===")
+
+			cartridgeSummary(plan.Title, progress, plan.CatalogAddr, plan.Semver, plan.SHA256, plan.Fee).write()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&sender, "sender", "", "Sender address (defaults to ADDRESS from account_credentials.txt)")
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().Float64Var(&rateLimit, "rate", 0, "Transaction rate limit (tx/s, default: rate recorded in the plan)")
+	cmd.Flags().StringVar(&stateDir, "state-dir", "", "Directory for progress and log files (default: $XDG_STATE_HOME/nimiq-uploader/<catalog>/<app-id>)")
+	cmd.Flags().BoolVar(&forceUnlock, "force-unlock", false, "Take over lock files left by another run (only if it is no longer running)")
+
+	return cmd
+}
+
+// verifyCartridgePlan decodes every payload and checks the operations are in
+// upload-cartridge order (DATA chunks, CART, CMET records, CENT) with the
+// expected recipients
+func verifyCartridgePlan(plan *CartridgePlan) ([][]byte, int, error) {
+	if err := ValidateAddressNQ(plan.CartridgeAddr); err != nil {
+		return nil, 0, fmt.Errorf("cartridge address: %w", err)
+	}
+	if err := ValidateAddressNQ(plan.CatalogAddr); err != nil {
+		return nil, 0, fmt.Errorf("catalog address: %w", err)
+	}
+
+	payloads := make([][]byte, len(plan.Operations))
+	dataCount := 0
+	for i, op := range plan.Operations {
+		if op.Step != i+1 {
+			return nil, 0, fmt.Errorf("operation %d has step %d", i+1, op.Step)
+		}
+
+		payload, err := hex.DecodeString(op.Payload)
+		if err != nil {
+			return nil, 0, fmt.Errorf("step %d: invalid payload hex: %w", op.Step, err)
+		}
+		// DATA chunks over the default size have longer payloads
+		expectedLen := 64
+		if op.Type == MagicDATA && len(payload) > 12 && payload[12] <= plan.ChunkSize {
+			expectedLen = DATAPayloadSize(int(payload[12]))
+		}
+		if len(payload) != expectedLen {
+			return nil, 0, fmt.Errorf("step %d: payload must be %d bytes (got %d)", op.Step, expectedLen, len(payload))
+		}
+		if string(payload[:4]) != op.Type {
+			return nil, 0, fmt.Errorf("step %d: payload magic %q doesn't match type %s", op.Step, payload[:4], op.Type)
+		}
+		payloads[i] = payload
+
+		expectedTo := plan.CartridgeAddr
+		switch op.Type {
+		case "DATA":
+			if op.ChunkIndex == nil {
+				return nil, 0, fmt.Errorf("step %d: DATA operation without chunk_index", op.Step)
+			}
+			if i != dataCount {
+				return nil, 0, fmt.Errorf("step %d: DATA operation after CART/CENT", op.Step)
+			}
+			dataCount++
+		case "CART":
+			if i != dataCount {
+				return nil, 0, fmt.Errorf("step %d: CART must directly follow the DATA chunks", op.Step)
+			}
+		case "CMET":
+			if i <= dataCount {
+				return nil, 0, fmt.Errorf("step %d: CMET records must follow the CART header", op.Step)
+			}
+			expectedTo = plan.CatalogAddr
+		case "CENT":
+			if i != len(plan.Operations)-1 {
+				return nil, 0, fmt.Errorf("step %d: CENT must be the last operation", op.Step)
+			}
+			expectedTo = plan.CatalogAddr
+		default:
+			return nil, 0, fmt.Errorf("step %d: unknown operation type %q", op.Step, op.Type)
+		}
+
+		if normalizeAddress(op.To) != normalizeAddress(expectedTo) {
+			return nil, 0, fmt.Errorf("step %d: %s must be sent to %s (got %s)", op.Step, op.Type, expectedTo, op.To)
+		}
+	}
+
+	if len(plan.Operations) < dataCount+2 || plan.Operations[dataCount].Type != "CART" {
+		return nil, 0, fmt.Errorf("plan must contain DATA chunks followed by one CART, any CMET and one CENT operation")
+	}
+	return payloads, dataCount, nil
+}
+
+// loadPlanProgress loads the upload-cartridge progress file for a plan.
+// Only chunks with a transaction hash count as sent (dry-run progress files
+// record chunks without hashes).
+func loadPlanProgress(progressFile string, plan *CartridgePlan, dataCount int) (*CartridgeUploadProgress, error) {
+	progress := &CartridgeUploadProgress{
+		AppID:         plan.AppID,
+		CartridgeID:   plan.CartridgeID,
+		CartridgeAddr: plan.CartridgeAddr,
+		TotalChunks:   dataCount,
+		ChunkSize:     plan.ChunkSize,
+		Plan:          make([]UploadPlan, 0, dataCount),
+	}
+
+	data, err := os.ReadFile(progressFile)
+	if os.IsNotExist(err) {
+		return progress, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read progress file: %w", err)
+	}
+
+	var loaded CartridgeUploadProgress
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse progress file %s: %w", progressFile, err)
+	}
+	if loaded.CartridgeAddr != plan.CartridgeAddr || loaded.TotalChunks != dataCount {
+		return nil, fmt.Errorf("progress file %s belongs to a different upload; remove it or use --state-dir", progressFile)
+	}
+
+	progress.CARTTxHash = loaded.CARTTxHash
+	progress.CMETTxHashes = loaded.CMETTxHashes
+	progress.CENTTxHash = loaded.CENTTxHash
+	for _, entry := range loaded.Plan {
+		if entry.TxHash != "" {
+			progress.Plan = append(progress.Plan, entry)
+			progress.SentChunks++
+		}
+	}
+	if progress.SentChunks > 0 || progress.CARTTxHash != "" {
+		statusf("Resuming from progress file: %s (%d/%d chunks sent)\n", progressFile, progress.SentChunks, progress.TotalChunks)
+	}
+	return progress, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// NetworkMain and NetworkTest are the Nimiq networks explorer links point at
+	NetworkMain = "main"
+	NetworkTest = "test"
+
+	// DefaultExplorerMain and DefaultExplorerTest are the default explorer
+	// templates; {kind} is "tx" or "address" and {id} the hash or address
+	DefaultExplorerMain = "https://nimiq.watch/#{id}"
+	DefaultExplorerTest = "https://test.nimiq.watch/#{id}"
+)
+
+// Link kinds accepted by explorerURL
+const (
+	LinkTx      = "tx"
+	LinkAddress = "address"
+)
+
+// GetDefaultNetwork returns the configured network from (in order):
+// 1. NIMIQ_NETWORK environment variable
+// 2. network in credentials file
+// Returns "" if neither is set.
+func GetDefaultNetwork() string {
+	if network := os.Getenv("NIMIQ_NETWORK"); network != "" {
+		return strings.ToLower(network)
+	}
+	creds, err := LoadCredentials("")
+	if err == nil && creds["NETWORK"] != "" {
+		return strings.ToLower(creds["NETWORK"])
+	}
+	return ""
+}
+
+// GetDefaultExplorer returns the custom explorer template from the
+// NIMIQ_EXPLORER environment variable or explorer in the credentials file
+func GetDefaultExplorer() string {
+	if explorer := os.Getenv("NIMIQ_EXPLORER"); explorer != "" {
+		return explorer
+	}
+	creds, err := LoadCredentials("")
+	if err == nil {
+		return creds["EXPLORER"]
+	}
+	return ""
+}
+
+// networkForCatalog returns the network explorer links should use: the
+// configured network, or the network of the catalog's alias (catalogs
+// without one are assumed to be on mainnet)
+func networkForCatalog(catalogAddr string) string {
+	if network := GetDefaultNetwork(); network != "" {
+		return network
+	}
+	if _, alias, ok := catalogAliasFor(catalogAddr); ok && alias.Network != "" {
+		return alias.Network
+	}
+	return NetworkMain
+}
+
+// explorerURL links to a transaction or address on the explorer for network.
+// A custom template may use {network}, {kind} and {id}.
+func explorerURL(network, kind, id string) string {
+	if id == "" {
+		return ""
+	}
+	template := GetDefaultExplorer()
+	if template == "" {
+		template = DefaultExplorerMain
+		if network == NetworkTest {
+			template = DefaultExplorerTest
+		}
+	}
+	return strings.NewReplacer(
+		"{network}", network,
+		"{kind}", kind,
+		"{id}", strings.ReplaceAll(id, " ", ""),
+	).Replace(template)
+}
+
+// printExplorerLink prints an explorer link for a transaction or address, if any
+func printExplorerLink(indent, network, kind, id string) {
+	if url := explorerURL(network, kind, id); url != "" {
+		fmt.Printf("%s🔗 %s\n", indent, url)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ghSummaryEnabled is set by the global --gh-summary flag
+var ghSummaryEnabled bool
+
+// ghSummary collects a GitHub Actions job summary and step outputs
+type ghSummary struct {
+	title   string
+	rows    [][2]string
+	outputs [][2]string
+}
+
+// newGHSummary starts a summary with the given heading
+func newGHSummary(title string) *ghSummary {
+	return &ghSummary{title: title}
+}
+
+// row adds a line to the summary table (skipped if value is empty)
+func (s *ghSummary) row(label, value string) *ghSummary {
+	if value != "" {
+		s.rows = append(s.rows, [2]string{label, value})
+	}
+	return s
+}
+
+// output exports a step output (skipped if value is empty)
+func (s *ghSummary) output(name, value string) *ghSummary {
+	if value != "" {
+		s.outputs = append(s.outputs, [2]string{name, value})
+	}
+	return s
+}
+
+// markdown renders the summary as a markdown table
+func (s *ghSummary) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", s.title)
+	b.WriteString("| | |\n|---|---|\n")
+	for _, r := range s.rows {
+		fmt.Fprintf(&b, "| %s | %s |\n", r[0], strings.ReplaceAll(r[1], "|", "\\|"))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// write appends the summary to $GITHUB_STEP_SUMMARY and the outputs to
+// $GITHUB_OUTPUT. It does nothing unless --gh-summary is set; outside of
+// GitHub Actions the summary is printed instead.
+func (s *ghSummary) write() {
+	if !ghSummaryEnabled {
+		return
+	}
+
+	summaryPath := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryPath == "" {
+		fmt.Println("\n⚠️  GITHUB_STEP_SUMMARY is not set (not running in GitHub Actions); summary:")
+		fmt.Println()
+		fmt.Print(s.markdown())
+		for _, o := range s.outputs {
+			fmt.Printf("%s=%s\n", o[0], o[1])
+		}
+		return
+	}
+
+	if err := appendFile(summaryPath, s.markdown()); err != nil {
+		warnf("failed to write job summary: %v", err)
+	}
+
+	if outputPath := os.Getenv("GITHUB_OUTPUT"); outputPath != "" {
+		var b strings.Builder
+		for _, o := range s.outputs {
+			fmt.Fprintf(&b, "%s=%s\n", o[0], o[1])
+		}
+		if err := appendFile(outputPath, b.String()); err != nil {
+			warnf("failed to write step outputs: %v", err)
+		}
+	}
+}
+
+// appendFile appends text to a file, creating it if needed
+func appendFile(path, text string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// mdLink renders a markdown link, or just the text if url is empty
+func mdLink(text, url string) string {
+	if url == "" {
+		return text
+	}
+	return fmt.Sprintf("[%s](%s)", text, url)
+}
+
+// formatNIM formats a Luna amount as NIM
+func formatNIM(luna int64) string {
+	return fmt.Sprintf("%.5f NIM", float64(luna)/LunaPerNIM)
+}
+
+// cartridgeSummary builds the GitHub Actions summary of a cartridge upload
+func cartridgeSummary(title string, progress *CartridgeUploadProgress, catalogAddr, semver, sha256Hex string, fee int64) *ghSummary {
+	txCount := int64(progress.SentChunks)
+	if progress.CARTTxHash != "" {
+		txCount++
+	}
+	if progress.CENTTxHash != "" {
+		txCount++
+	}
+	cost := txCount * (fee + TxValueLuna)
+
+	network := networkForCatalog(catalogAddr)
+	link := func(kind, id string) string {
+		if id == "" {
+			return ""
+		}
+		return mdLink("`"+id+"`", explorerURL(network, kind, id))
+	}
+
+	return newGHSummary(fmt.Sprintf("Uploaded %s %s", title, semver)).
+		row("App ID", fmt.Sprintf("%d", progress.AppID)).
+		row("Cartridge ID", fmt.Sprintf("%d", progress.CartridgeID)).
+		row("Cartridge address", link(LinkAddress, progress.CartridgeAddr)).
+		row("Catalog address", link(LinkAddress, catalogAddr)).
+		row("SHA256", "`"+sha256Hex+"`").
+		row("DATA chunks", fmt.Sprintf("%d/%d", progress.SentChunks, progress.TotalChunks)).
+		row("CART transaction", link(LinkTx, progress.CARTTxHash)).
+		row("CENT transaction", link(LinkTx, progress.CENTTxHash)).
+		row("Cost", fmt.Sprintf("%d Luna (%s)", cost, formatNIM(cost))).
+		output("app_id", fmt.Sprintf("%d", progress.AppID)).
+		output("cartridge_id", fmt.Sprintf("%d", progress.CartridgeID)).
+		output("cartridge_addr", progress.CartridgeAddr).
+		output("cart_tx_hash", progress.CARTTxHash).
+		output("cent_tx_hash", progress.CENTTxHash).
+		output("sha256", sha256Hex)
+}
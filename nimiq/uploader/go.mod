@@ -3,11 +3,17 @@ module github.com/maestroi/nimiq-doom/uploader
 go 1.21
 
 require (
+	github.com/klauspost/compress v1.17.11
+	github.com/retro-crypto/shared v0.0.0
 	github.com/spf13/cobra v1.8.0
+	github.com/spf13/pflag v1.0.5
+	golang.org/x/crypto v0.21.0
 	golang.org/x/time v0.5.0
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/sys v0.18.0 // indirect
 )
+
+replace github.com/retro-crypto/shared => ../../shared
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Outbound HTTP settings
+// ============================================================================
+
+// Defaults of the --http-* flags
+const (
+	DefaultHTTPMaxIdleConns    = 100
+	DefaultHTTPMaxConnsPerHost = 32
+	httpMaxIdleConnsPerHost    = 16
+)
+
+var (
+	httpTimeout         time.Duration
+	httpMaxIdleConns    int
+	httpMaxConnsPerHost int
+
+	// httpTransport is shared by every RPC client, so the connection limits
+	// hold across them
+	httpTransport = newHTTPTransport(DefaultHTTPMaxIdleConns, DefaultHTTPMaxConnsPerHost)
+)
+
+func addHTTPFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().DurationVar(&httpTimeout, "http-timeout", 0, "Timeout of every RPC request (default: $NIMIQ_UPLOADER_HTTP_TIMEOUT or 30s)")
+	cmd.PersistentFlags().IntVar(&httpMaxIdleConns, "http-max-idle-conns", DefaultHTTPMaxIdleConns, "Keep-alive connections kept open (or $NIMIQ_UPLOADER_HTTP_MAX_IDLE_CONNS)")
+	cmd.PersistentFlags().IntVar(&httpMaxConnsPerHost, "http-max-conns-per-host", DefaultHTTPMaxConnsPerHost, "Concurrent connections per RPC host, 0 for unlimited (or $NIMIQ_UPLOADER_HTTP_MAX_CONNS_PER_HOST)")
+}
+
+// setupHTTP applies the --http-* flags, falling back to their environment
+// variables, to the RPC clients created afterwards
+func setupHTTP(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	if v := os.Getenv("NIMIQ_UPLOADER_HTTP_TIMEOUT"); v != "" && !flags.Changed("http-timeout") {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("NIMIQ_UPLOADER_HTTP_TIMEOUT: %q is not a duration (e.g. 45s or 2m)", v)
+		}
+		httpTimeout = d
+	}
+	if err := intFromEnv(flags.Changed("http-max-idle-conns"), "NIMIQ_UPLOADER_HTTP_MAX_IDLE_CONNS", &httpMaxIdleConns); err != nil {
+		return err
+	}
+	if err := intFromEnv(flags.Changed("http-max-conns-per-host"), "NIMIQ_UPLOADER_HTTP_MAX_CONNS_PER_HOST", &httpMaxConnsPerHost); err != nil {
+		return err
+	}
+
+	if httpTimeout < 0 {
+		return fmt.Errorf("--http-timeout must not be negative")
+	}
+	if httpMaxIdleConns < 0 || httpMaxConnsPerHost < 0 {
+		return fmt.Errorf("--http-max-idle-conns and --http-max-conns-per-host must not be negative")
+	}
+	httpTransport = newHTTPTransport(httpMaxIdleConns, httpMaxConnsPerHost)
+	return nil
+}
+
+func intFromEnv(flagSet bool, env string, value *int) error {
+	v := os.Getenv(env)
+	if flagSet || v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a number", env, v)
+	}
+	*value = n
+	return nil
+}
+
+func newHTTPTransport(maxIdleConns, maxConnsPerHost int) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = maxIdleConns
+	t.MaxIdleConnsPerHost = min(httpMaxIdleConnsPerHost, maxIdleConns)
+	t.MaxConnsPerHost = maxConnsPerHost
+	return t
+}
+
+// newHTTPClient returns a client on the shared transport; --http-timeout
+// overrides defaultTimeout, and -v logs its requests
+func newHTTPClient(defaultTimeout time.Duration) *http.Client {
+	timeout := defaultTimeout
+	if httpTimeout > 0 {
+		timeout = httpTimeout
+	}
+	return &http.Client{Timeout: timeout, Transport: traced(httpTransport)}
+}
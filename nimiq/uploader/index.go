@@ -0,0 +1,414 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Catalog index (index command)
+// ============================================================================
+
+// indexFilePath is the index file set with the global --index-file flag
+var indexFilePath string
+
+// CatalogIndex is a local copy of what uploads read from a catalog: the CENT
+// and CMET transactions sent to it, and the CART headers and DATA chunk
+// counts of the cartridges its entries point to. It is synced incrementally,
+// so only transactions newer than the last synced block are fetched.
+//
+// The index is one JSON file rather than a SQLite or bbolt store: neither
+// database is a dependency of this module, and the file is small enough to
+// load and rewrite whole on each sync.
+type CatalogIndex struct {
+	Catalog      string                       `json:"catalog"`
+	SyncedAt     string                       `json:"synced_at,omitempty"`
+	Sync         IndexSync                    `json:"sync"`
+	Transactions []Transaction                `json:"transactions"` // CENT and CMET, newest first
+	Cartridges   map[string]*IndexedCartridge `json:"cartridges"`   // by normalized address
+
+	path string
+}
+
+// IndexSync is how far an address has been synced
+type IndexSync struct {
+	Height int64 `json:"height"`
+	// Boundary lists the synced transactions of block Height, which a
+	// later sync fetches again
+	Boundary []string `json:"boundary,omitempty"`
+}
+
+// IndexedCartridge is the indexed part of a cartridge address
+type IndexedCartridge struct {
+	Sync       IndexSync     `json:"sync"`
+	Headers    []Transaction `json:"headers"` // CART, newest first
+	DataChunks int           `json:"data_chunks"`
+}
+
+// defaultIndexPath is <state base>/index/<catalog>.json
+func defaultIndexPath(catalogAddr string) string {
+	return filepath.Join(GetStateBaseDir(), "index", normalizeAddress(catalogAddr)+".json")
+}
+
+// LoadCatalogIndex reads an index file (an empty index if it doesn't exist)
+func LoadCatalogIndex(path, catalogAddr string) (*CatalogIndex, error) {
+	idx := &CatalogIndex{
+		Catalog:    normalizeAddress(catalogAddr),
+		Cartridges: make(map[string]*IndexedCartridge),
+		path:       path,
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if idx.Catalog != normalizeAddress(catalogAddr) {
+		return nil, fmt.Errorf("%s indexes catalog %s, not %s", path, FormatAddressNQ(idx.Catalog), FormatAddressNQ(catalogAddr))
+	}
+	if idx.Cartridges == nil {
+		idx.Cartridges = make(map[string]*IndexedCartridge)
+	}
+	return idx, nil
+}
+
+// openCatalogIndex returns the index kept for a catalog by 'index' (from
+// --index-file or the default location), or nil if there is none. Uploads read
+// it instead of scanning the catalog; an unusable index is skipped.
+func openCatalogIndex(catalogAddr string) *CatalogIndex {
+	path := indexFilePath
+	if path == "" {
+		path = defaultIndexPath(catalogAddr)
+		if _, err := os.Stat(path); err != nil {
+			return nil
+		}
+	}
+	idx, err := LoadCatalogIndex(path, catalogAddr)
+	if err != nil {
+		warnf("ignoring catalog index: %v", err)
+		return nil
+	}
+	debugf(levelVerbose, "[index] using %s (block %d)", path, idx.Sync.Height)
+	return idx
+}
+
+// Save writes the index atomically, so a reader never sees a partial file
+func (idx *CatalogIndex) Save() error {
+	idx.SyncedAt = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(idx.path), ".index-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), idx.path)
+}
+
+// saveIndex saves an index opened by openCatalogIndex (nil is a no-op).
+// Failures are only reported: the index is a cache.
+func saveIndex(idx *CatalogIndex) {
+	if idx == nil {
+		return
+	}
+	if err := idx.Save(); err != nil {
+		warnf("failed to save catalog index %s: %v", idx.path, err)
+	}
+}
+
+// SyncCatalog fetches the catalog's new transactions, keeps its CENT and
+// CMET ones and registers the cartridges new entries point to. It returns
+// the number of transactions added.
+func (idx *CatalogIndex) SyncCatalog(rpc *NimiqRPC) (int, error) {
+	fresh, err := syncAddress(rpc, idx.Catalog, &idx.Sync)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sync catalog: %w", err)
+	}
+	added := 0
+	for _, tx := range fresh {
+		if normalizeAddress(tx.To) != idx.Catalog {
+			continue
+		}
+		payload := txPayload(tx)
+		if len(payload) < 4 {
+			continue
+		}
+		switch string(payload[0:4]) {
+		case MagicCENT:
+			if entry, err := DecodeCENT(payload); err == nil {
+				cartridgeAddr := normalizeAddress(BytesToAddressNQ(entry.CartridgeAddr))
+				if idx.Cartridges[cartridgeAddr] == nil {
+					idx.Cartridges[cartridgeAddr] = &IndexedCartridge{}
+				}
+			}
+		case MagicCMET:
+		default:
+			continue
+		}
+		idx.Transactions = append(idx.Transactions, tx)
+		added++
+	}
+	sortNewestFirst(idx.Transactions)
+	return added, nil
+}
+
+// SyncCartridge fetches a cartridge address's new transactions, keeps its
+// CART headers and counts its DATA chunks. It returns the number of headers
+// and chunks added.
+func (idx *CatalogIndex) SyncCartridge(rpc *NimiqRPC, cartridgeAddr string) (headers, chunks int, err error) {
+	key := normalizeAddress(cartridgeAddr)
+	cart := idx.Cartridges[key]
+	if cart == nil {
+		cart = &IndexedCartridge{}
+		idx.Cartridges[key] = cart
+	}
+	fresh, err := syncAddress(rpc, key, &cart.Sync)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to sync cartridge %s: %w", FormatAddressNQ(key), err)
+	}
+	for _, tx := range fresh {
+		if normalizeAddress(tx.To) != key {
+			continue
+		}
+		payload := txPayload(tx)
+		if len(payload) < 4 {
+			continue
+		}
+		switch string(payload[0:4]) {
+		case MagicCART:
+			cart.Headers = append(cart.Headers, tx)
+			headers++
+		case MagicDATA:
+			cart.DataChunks++
+			chunks++
+		}
+	}
+	sortNewestFirst(cart.Headers)
+	return headers, chunks, nil
+}
+
+// syncAddress returns the transactions of an address newer than sync and
+// advances sync past them. Pages are fetched newest first until one reaches
+// the synced block; transactions not yet in a block are left for later.
+func syncAddress(rpc *NimiqRPC, address string, sync *IndexSync) ([]Transaction, error) {
+	normalizedAddr := normalizeAddress(address)
+	boundary := make(map[string]bool)
+	for _, hash := range sync.Boundary {
+		boundary[hash] = true
+	}
+
+	var fresh []Transaction
+	seenHashes := make(map[string]bool)
+	startAt := ""
+	for {
+		txs, err := fetchTransactionsPage(rpc, normalizedAddr, 500, startAt)
+		if err != nil {
+			return nil, err
+		}
+		newTxs := 0
+		reached := false
+		for _, tx := range txs {
+			if tx.Hash != "" {
+				if seenHashes[tx.Hash] {
+					continue
+				}
+				seenHashes[tx.Hash] = true
+			}
+			newTxs++
+			if tx.Height == 0 {
+				continue
+			}
+			if tx.Height < sync.Height || (tx.Height == sync.Height && boundary[tx.Hash]) {
+				reached = true
+				continue
+			}
+			fresh = append(fresh, tx)
+		}
+		if MaxTransactions > 0 && len(fresh) > MaxTransactions {
+			return nil, fmt.Errorf("address %s has more than %d new transactions; raise --max-transactions to index the full history", normalizedAddr, MaxTransactions)
+		}
+		if reached || newTxs == 0 || len(txs) < 500 {
+			break
+		}
+		startAt = txs[len(txs)-1].Hash
+	}
+
+	for _, tx := range fresh {
+		switch {
+		case tx.Height > sync.Height:
+			sync.Height = tx.Height
+			sync.Boundary = []string{tx.Hash}
+		case tx.Height == sync.Height:
+			sync.Boundary = append(sync.Boundary, tx.Hash)
+		}
+	}
+	return fresh, nil
+}
+
+// sortNewestFirst orders transactions by block height, newest first
+func sortNewestFirst(txs []Transaction) {
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].Height > txs[j].Height
+	})
+}
+
+// catalogTransactions returns the transactions of a catalog address, newest
+// first: from idx after syncing it, or by scanning the address without one.
+// An index only holds CENT and CMET transactions.
+func catalogTransactions(rpc *NimiqRPC, idx *CatalogIndex, catalogAddr string) ([]Transaction, error) {
+	if idx == nil {
+		return GetAllTransactionsByAddress(rpc, normalizeAddress(catalogAddr), 500)
+	}
+	if _, err := idx.SyncCatalog(rpc); err != nil {
+		return nil, err
+	}
+	return idx.Transactions, nil
+}
+
+// cartridgeTransactions is catalogTransactions for a cartridge address; an
+// index only holds its CART headers
+func cartridgeTransactions(rpc *NimiqRPC, idx *CatalogIndex, cartridgeAddr string) ([]Transaction, error) {
+	if idx == nil {
+		return GetAllTransactionsByAddress(rpc, normalizeAddress(cartridgeAddr), 500)
+	}
+	if _, _, err := idx.SyncCartridge(rpc, cartridgeAddr); err != nil {
+		return nil, err
+	}
+	return idx.Cartridges[normalizeAddress(cartridgeAddr)].Headers, nil
+}
+
+// newIndexCmd creates the index command
+func newIndexCmd() *cobra.Command {
+	var (
+		rpcURL      string
+		catalogAddr string
+		indexFile   string
+		watch       bool
+		interval    time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Keep a local index of a catalog so uploads don't rescan it",
+		Long: `Sync the CENT and CMET transactions of a catalog, and the CART headers and
+DATA chunk counts of the cartridges its entries point to, into a local index
+file. The first run scans everything; later runs only fetch transactions newer
+than the last synced block.
+
+upload-cartridge, list-catalog and the other catalog readers use the index
+when there is one for the catalog (at the default location, or --index-file):
+they sync it first, which costs a page per address instead of the whole
+history. Without an index they scan the catalog as before.
+
+With --watch the index is synced every --interval until interrupted.`,
+		Example: `  nimiq-uploader index --catalog-addr main
+  nimiq-uploader index --catalog-addr main --file catalog-index.json --watch --interval 1m
+  nimiq-uploader --index-file catalog-index.json upload-cartridge --catalog-addr main ...`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if catalogAddr == "" {
+				return fmt.Errorf("catalog address is required (--catalog-addr)")
+			}
+			if watch && interval < time.Second {
+				return fmt.Errorf("--interval must be at least 1s")
+			}
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			catalogAddr = resolveCatalogAddress(catalogAddr)
+			if err := ValidateAddressNQ(catalogAddr); err != nil {
+				return fmt.Errorf("invalid catalog address: %w", err)
+			}
+			if indexFile == "" {
+				indexFile = indexFilePath
+			}
+			if indexFile == "" {
+				indexFile = defaultIndexPath(catalogAddr)
+			}
+			rpc := NewNimiqRPC(rpcURL)
+
+			for {
+				if err := syncCatalogIndex(rpc, indexFile, catalogAddr); err != nil {
+					if !watch {
+						return err
+					}
+					warnf("%v", err)
+				}
+				if !watch {
+					return nil
+				}
+				time.Sleep(interval)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address or alias: main, test")
+	cmd.Flags().StringVar(&indexFile, "file", "", "Index file (default: --index-file or <state dir>/index/<catalog>.json)")
+	cmd.Flags().StringVar(&indexFile, "db", "", "Alias for --file")
+	cmd.Flags().MarkDeprecated("db", "use --file; the index is a JSON file, not a database")
+	cmd.Flags().BoolVar(&watch, "watch", false, "Keep syncing every --interval until interrupted")
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "Time between syncs with --watch")
+	return cmd
+}
+
+// syncCatalogIndex brings the index file of a catalog up to date
+func syncCatalogIndex(rpc *NimiqRPC, path, catalogAddr string) error {
+	idx, err := LoadCatalogIndex(path, catalogAddr)
+	if err != nil {
+		return err
+	}
+	added, err := idx.SyncCatalog(rpc)
+	if err != nil {
+		return err
+	}
+
+	addrs := make([]string, 0, len(idx.Cartridges))
+	for addr := range idx.Cartridges {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	headers, chunks, failed := 0, 0, 0
+	for _, addr := range addrs {
+		h, c, err := idx.SyncCartridge(rpc, addr)
+		if err != nil {
+			warnf("%v", err)
+			failed++
+			continue
+		}
+		headers += h
+		chunks += c
+	}
+
+	if err := idx.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	statusf("✓ [%s] %s at block %d: +%d catalog transaction(s) (%d total), %d cartridge(s), +%d header(s), +%d chunk(s)\n",
+		time.Now().Format("15:04:05"), FormatAddressNQ(catalogAddr), idx.Sync.Height, added, len(idx.Transactions), len(addrs), headers, chunks)
+	if failed > 0 {
+		statusf("  %d cartridge(s) failed to sync and will be retried\n", failed)
+	}
+	debugf(levelVerbose, "[index] saved %s", path)
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keychainStore stores secrets in the OS keychain under service/name. macOS and
+// Linux go through the security and secret-tool commands, so secrets are
+// passed on stdin and never show up in process listings; Windows calls the
+// Credential Manager API.
+type keychainStore struct {
+	service string
+}
+
+func newKeychainStore(service string) *keychainStore {
+	return &keychainStore{service: service}
+}
+
+func (k *keychainStore) String() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "macOS Keychain"
+	case "windows":
+		return "Windows Credential Manager"
+	}
+	return "Secret Service"
+}
+
+func (k *keychainStore) Get(name string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := k.security(fmt.Sprintf("find-generic-password -s %s -a %s -w", securityQuote(k.service), securityQuote(name)))
+		if err != nil {
+			return "", k.notFound(name, err)
+		}
+		return strings.TrimRight(out, "\n"), nil
+	case "windows":
+		secret, err := credRead(k.target(name))
+		if err != nil {
+			return "", k.notFound(name, err)
+		}
+		return secret, nil
+	}
+	out, err := k.secretTool(nil, "lookup", "service", k.service, "account", name)
+	if err != nil || out == "" {
+		return "", k.notFound(name, err)
+	}
+	return strings.TrimRight(out, "\n"), nil
+}
+
+func (k *keychainStore) Set(name, secret string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := k.security(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s", securityQuote(k.service), securityQuote(name), securityQuote(secret)))
+		return err
+	case "windows":
+		return credWrite(k.target(name), name, secret)
+	}
+	_, err := k.secretTool(strings.NewReader(secret), "store", "--label", k.service+" "+name, "service", k.service, "account", name)
+	return err
+}
+
+func (k *keychainStore) Delete(name string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := k.security(fmt.Sprintf("delete-generic-password -s %s -a %s", securityQuote(k.service), securityQuote(name))); err != nil {
+			return k.notFound(name, err)
+		}
+		return nil
+	case "windows":
+		if err := credDelete(k.target(name)); err != nil {
+			return k.notFound(name, err)
+		}
+		return nil
+	}
+	if _, err := k.Get(name); err != nil {
+		return err
+	}
+	_, err := k.secretTool(nil, "clear", "service", k.service, "account", name)
+	return err
+}
+
+// target is the Credential Manager name of a secret
+func (k *keychainStore) target(name string) string {
+	return k.service + ":" + name
+}
+
+func (k *keychainStore) notFound(name string, err error) error {
+	if err == nil || errors.Is(err, errKeyNotFound) {
+		return fmt.Errorf("%s has no key %q for %s: %w", k, name, k.service, errKeyNotFound)
+	}
+	return fmt.Errorf("%s: %w", k, err)
+}
+
+// security runs one command of macOS security in interactive mode, which
+// reads it from stdin
+func (k *keychainStore) security(command string) (string, error) {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(command + "\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("security failed: %w", err)
+	}
+	// security -i reports failures on stderr but exits 0
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		if strings.Contains(msg, "could not be found") {
+			return "", errKeyNotFound
+		}
+		return "", fmt.Errorf("security: %s", msg)
+	}
+	return stdout.String(), nil
+}
+
+// secretTool runs secret-tool (libsecret), the command line client of the
+// Secret Service that GNOME Keyring and KWallet provide
+func (k *keychainStore) secretTool(stdin *strings.Reader, args ...string) (string, error) {
+	cmd := exec.Command("secret-tool", args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("secret-tool is not installed (install libsecret-tools, or use key_source file)")
+	}
+	if err != nil {
+		if args[0] == "lookup" && stderr.Len() == 0 {
+			return "", errKeyNotFound
+		}
+		return "", fmt.Errorf("secret-tool %s failed: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// securityQuote quotes an argument for the security -i command line
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
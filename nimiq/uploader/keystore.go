@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Key sources (key_source in credentials.json) keep the private key and the
+// node account passphrase out of credentials.json:
+//
+//	config            private_key / passphrase in credentials.json (default)
+//	env[:VAR]         $NIMIQ_PRIVATE_KEY (or VAR) and $NIMIQ_PASSPHRASE
+//	keychain[:NAME]   the OS keychain: macOS Keychain, Secret Service
+//	                  (secret-tool) or Windows Credential Manager
+//	file[:PATH]       an encrypted keystore file (scrypt + AES-256-GCM)
+//
+// Keystores hold the private key as NAME ("default" if not given) and the
+// passphrase as NAME.passphrase.
+const (
+	KeySourceConfig   = "config"
+	KeySourceEnv      = "env"
+	KeySourceKeychain = "keychain"
+	KeySourceFile     = "file"
+
+	// KeySourceEnvVar overrides key_source
+	KeySourceEnvVar = "NIMIQ_UPLOADER_KEY_SOURCE"
+	// KeystorePassphraseEnv unlocks keystore files
+	KeystorePassphraseEnv = "NIMIQ_UPLOADER_KEYSTORE_PASSPHRASE"
+	// KeystoreFileName is the keystore of a plain "file" key source, in the
+	// config directory
+	KeystoreFileName = "keystore.json"
+
+	keychainService    = "nimiq-uploader"
+	defaultKeyName     = "default"
+	passphraseKeySufix = ".passphrase"
+)
+
+// errKeyNotFound is returned when a keystore has no secret of that name
+var errKeyNotFound = errors.New("no such key in the keystore")
+
+// KeyStore reads and writes named secrets
+type KeyStore interface {
+	Get(name string) (string, error)
+	Set(name, secret string) error
+	Delete(name string) error
+	String() string
+}
+
+// KeySource is a parsed key_source value
+type KeySource struct {
+	Kind string
+	// Arg is the variable of env, the name of keychain and the path of file
+	Arg string
+}
+
+// ParseKeySource parses a key_source value; empty means config
+func ParseKeySource(s string) (KeySource, error) {
+	kind, arg, _ := strings.Cut(strings.TrimSpace(s), ":")
+	switch kind {
+	case "":
+		return KeySource{Kind: KeySourceConfig}, nil
+	case KeySourceConfig:
+		if arg != "" {
+			return KeySource{}, fmt.Errorf("key_source %q: config takes no argument", s)
+		}
+	case KeySourceEnv, KeySourceKeychain, KeySourceFile:
+	default:
+		return KeySource{}, fmt.Errorf("unknown key_source %q (want config, env[:VAR], keychain[:NAME] or file[:PATH])", s)
+	}
+	return KeySource{Kind: kind, Arg: arg}, nil
+}
+
+func (s KeySource) String() string {
+	if s.Arg == "" {
+		return s.Kind
+	}
+	return s.Kind + ":" + s.Arg
+}
+
+// secretNames returns the names of the private key and the passphrase in
+// the source's store
+func (s KeySource) secretNames() (privateKey, passphrase string) {
+	switch s.Kind {
+	case KeySourceEnv:
+		privateKey = s.Arg
+		if privateKey == "" {
+			privateKey = "NIMIQ_PRIVATE_KEY"
+		}
+		return privateKey, "NIMIQ_PASSPHRASE"
+	case KeySourceKeychain:
+		name := s.Arg
+		if name == "" {
+			name = defaultKeyName
+		}
+		return name, name + passphraseKeySufix
+	}
+	return defaultKeyName, defaultKeyName + passphraseKeySufix
+}
+
+// Open returns the store of the source; config sources have none
+func (s KeySource) Open() (KeyStore, error) {
+	switch s.Kind {
+	case KeySourceEnv:
+		return envKeyStore{}, nil
+	case KeySourceKeychain:
+		return newKeychainStore(keychainService), nil
+	case KeySourceFile:
+		path := s.Arg
+		if path == "" {
+			path = filepath.Join(GetConfigDir(), KeystoreFileName)
+		} else if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, rest)
+			}
+		}
+		return &fileKeyStore{path: path, passphrase: keystorePassphrase}, nil
+	}
+	return nil, fmt.Errorf("key_source %s has no keystore", s)
+}
+
+// credentialsKeySource returns the key source of the credentials:
+// $NIMIQ_UPLOADER_KEY_SOURCE, else key_source in credentials.json
+func credentialsKeySource(creds map[string]string) (KeySource, error) {
+	if source := os.Getenv(KeySourceEnvVar); source != "" {
+		return ParseKeySource(source)
+	}
+	return ParseKeySource(creds["KEY_SOURCE"])
+}
+
+// credentialSecret returns PRIVATE_KEY or PASSPHRASE: from credentials.json
+// (opening its sealed secrets if they are encrypted), or from the keystore
+// key_source points at. A secret the keystore doesn't
+// hold is empty.
+func credentialSecret(creds map[string]string, field string) (string, error) {
+	src, err := credentialsKeySource(creds)
+	if err != nil {
+		return "", err
+	}
+	if src.Kind == KeySourceConfig {
+		if creds["SEALED"] != "" {
+			return sealedCredentialSecret(creds, field)
+		}
+		return creds[field], nil
+	}
+	store, err := src.Open()
+	if err != nil {
+		return "", err
+	}
+	privateKeyName, passphraseName := src.secretNames()
+	name := privateKeyName
+	if field == "PASSPHRASE" {
+		name = passphraseName
+	}
+	secret, err := store.Get(name)
+	if errors.Is(err, errKeyNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("key_source %s: %w", src, err)
+	}
+	return secret, nil
+}
+
+// envKeyStore reads secrets from environment variables named like the
+// secret; whatever starts the uploader injects them, so it is read-only
+type envKeyStore struct{}
+
+func (envKeyStore) Get(name string) (string, error) {
+	if v := os.Getenv(name); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("$%s is not set: %w", name, errKeyNotFound)
+}
+
+func (envKeyStore) Set(name, secret string) error {
+	return fmt.Errorf("environment keys are read-only; set $%s where the uploader is started", name)
+}
+
+func (envKeyStore) Delete(name string) error {
+	return fmt.Errorf("environment keys are read-only; unset $%s where the uploader is started", name)
+}
+
+func (envKeyStore) String() string { return "environment" }
+
+// keystorePassphrase returns the passphrase of a keystore file from
+// $NIMIQ_UPLOADER_KEYSTORE_PASSPHRASE or a prompt; a new one is typed twice
+func keystorePassphrase(path string, create bool) (string, error) {
+	if p := os.Getenv(KeystorePassphraseEnv); p != "" {
+		return p, nil
+	}
+	if !stdinIsTerminal() {
+		return "", fmt.Errorf("%s is encrypted: set %s", path, KeystorePassphraseEnv)
+	}
+	if !create {
+		return readSecret(fmt.Sprintf("Passphrase for %s: ", path))
+	}
+	passphrase, err := readSecret(fmt.Sprintf("New passphrase for %s: ", path))
+	if err != nil {
+		return "", err
+	}
+	again, err := readSecret("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", fmt.Errorf("passphrases don't match")
+	}
+	return passphrase, nil
+}
+
+// stdinReader is shared by prompts so buffered input isn't lost between them
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readSecret prompts on stderr and reads a line without echoing it
+func readSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	if err := stty("-echo"); err == nil {
+		defer func() {
+			stty("echo")
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func stty(args ...string) error {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// stdinIsTerminal reports whether stdin is a terminal
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0 && stty("-g") == nil
+}
+
+func newKeyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "key",
+		Short: "Keep the private key and passphrase in the OS keychain or an encrypted keystore",
+		Long: `The private key (for --signer local and account import) and the node
+account passphrase are read from where key_source in credentials.json points:
+
+  config            private_key / passphrase in credentials.json (default)
+  env[:VAR]         $NIMIQ_PRIVATE_KEY (or VAR) and $NIMIQ_PASSPHRASE
+  keychain[:NAME]   the OS keychain: macOS Keychain, Secret Service
+                    (secret-tool) or Windows Credential Manager
+  file[:PATH]       an encrypted keystore file (scrypt + AES-256-GCM,
+                    ~/.config/nimiq-uploader/keystore.json by default)
+
+$NIMIQ_UPLOADER_KEY_SOURCE overrides key_source. Keystore files are unlocked
+with $NIMIQ_UPLOADER_KEYSTORE_PASSPHRASE or a prompt, and so are the secrets
+of credentials.json once key encrypt has sealed them.`,
+		Example: `  nimiq-uploader key import --to keychain
+  nimiq-uploader key status`,
+	}
+	cmd.AddCommand(newKeyStatusCmd())
+	cmd.AddCommand(newKeySetCmd())
+	cmd.AddCommand(newKeyImportCmd())
+	cmd.AddCommand(newKeyEncryptCmd())
+	cmd.AddCommand(newKeyDecryptCmd())
+	return cmd
+}
+
+func newKeyStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show where the private key and passphrase come from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			creds, err := LoadCredentials("")
+			if err != nil {
+				creds = map[string]string{}
+			}
+			src, err := credentialsKeySource(creds)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Key source: %s\n", src)
+			if src.Kind != KeySourceConfig {
+				store, err := src.Open()
+				if err != nil {
+					return err
+				}
+				privateKeyName, passphraseName := src.secretNames()
+				fmt.Printf("Keystore:   %s (%s, %s)\n", store, privateKeyName, passphraseName)
+			}
+
+			privateKey, err := credentialSecret(creds, "PRIVATE_KEY")
+			if err != nil {
+				return err
+			}
+			if privateKey == "" {
+				fmt.Println("Private key: not set (needed for --signer local and account import)")
+			} else {
+				key, err := parsePrivateKey(privateKey)
+				if err != nil {
+					return fmt.Errorf("the configured private key is invalid: %w", err)
+				}
+				var address [20]byte
+				copy(address[:], PublicKeyToAddress(key.Public().(ed25519.PublicKey)))
+				fmt.Printf("Private key: set, address %s\n", BytesToAddressNQ(address))
+			}
+			passphrase, err := credentialSecret(creds, "PASSPHRASE")
+			if err != nil {
+				return err
+			}
+			if passphrase == "" {
+				fmt.Println("Passphrase:  not set")
+			} else {
+				fmt.Println("Passphrase:  set")
+			}
+			if src.Kind == KeySourceConfig && creds["SEALED"] != "" {
+				fmt.Printf("Encrypted:   yes, in %s\n", creds["FILE"])
+			} else if src.Kind == KeySourceConfig && (creds["PRIVATE_KEY"] != "" || creds["PASSPHRASE"] != "") {
+				statusf("💡 Secrets are stored in plain text in %s; move them with: nimiq-uploader key import --to keychain (or encrypt them with: nimiq-uploader key encrypt)\n", GetCredentialsPath())
+			}
+			return nil
+		},
+	}
+}
+
+func newKeySetCmd() *cobra.Command {
+	var (
+		passphrase bool
+		fromStdin  bool
+		del        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Store the private key (or --passphrase) in the keystore key_source points at",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			creds, err := LoadCredentials("")
+			if err != nil {
+				creds = map[string]string{}
+			}
+			src, err := credentialsKeySource(creds)
+			if err != nil {
+				return err
+			}
+			if src.Kind == KeySourceConfig {
+				return fmt.Errorf("key_source is config; set key_source in credentials.json to keychain or file first (or use key import --to)")
+			}
+			store, err := src.Open()
+			if err != nil {
+				return err
+			}
+			name, passphraseName := src.secretNames()
+			label := "Private key (hex)"
+			if passphrase {
+				name, label = passphraseName, "Passphrase"
+			}
+			if del {
+				if err := store.Delete(name); err != nil {
+					return err
+				}
+				statusf("✓ Deleted %s from %s\n", name, store)
+				return nil
+			}
+
+			var secret string
+			if fromStdin || !stdinIsTerminal() {
+				data, err := io.ReadAll(stdinReader)
+				if err != nil {
+					return fmt.Errorf("failed to read the secret: %w", err)
+				}
+				secret = strings.TrimSpace(string(data))
+			} else if secret, err = readSecret(label + ": "); err != nil {
+				return err
+			}
+			if secret == "" {
+				return fmt.Errorf("nothing to store")
+			}
+			if !passphrase {
+				if _, err := parsePrivateKey(secret); err != nil {
+					return fmt.Errorf("invalid private key: %w", err)
+				}
+			}
+			if err := store.Set(name, secret); err != nil {
+				return fmt.Errorf("failed to store %s in %s: %w", name, store, err)
+			}
+			statusf("✓ Stored %s in %s\n", name, store)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&passphrase, "passphrase", false, "Store the node account passphrase instead of the private key")
+	cmd.Flags().BoolVar(&fromStdin, "stdin", false, "Read the secret from stdin instead of prompting")
+	cmd.Flags().BoolVar(&del, "delete", false, "Remove the secret from the keystore")
+
+	return cmd
+}
+
+func newKeyImportCmd() *cobra.Command {
+	var to string
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Move private_key and passphrase from credentials.json into a keystore",
+		Long: `Copies private_key and passphrase of credentials.json into the keystore --to
+names (default: key_source), checks that they read back, then sets
+key_source in credentials.json and removes the plain text secrets.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := GetCredentialsPath()
+			creds, err := readCredentialsFile(path)
+			if err != nil {
+				return err
+			}
+			if to == "" {
+				to = creds.KeySource
+			}
+			src, err := ParseKeySource(to)
+			if err != nil {
+				return err
+			}
+			if src.Kind == KeySourceConfig || src.Kind == KeySourceEnv {
+				return fmt.Errorf("--to must be a keychain or file key source, got %s", src)
+			}
+			if creds.PrivateKey == "" && creds.Passphrase == "" {
+				return fmt.Errorf("%s has no private_key or passphrase to import", path)
+			}
+			if creds.PrivateKey != "" {
+				if _, err := parsePrivateKey(creds.PrivateKey); err != nil {
+					return fmt.Errorf("the private key in %s is invalid: %w", path, err)
+				}
+			}
+
+			store, err := src.Open()
+			if err != nil {
+				return err
+			}
+			privateKeyName, passphraseName := src.secretNames()
+			for name, secret := range map[string]string{privateKeyName: creds.PrivateKey, passphraseName: creds.Passphrase} {
+				if secret == "" {
+					continue
+				}
+				if err := store.Set(name, secret); err != nil {
+					return fmt.Errorf("failed to store %s in %s: %w", name, store, err)
+				}
+				if stored, err := store.Get(name); err != nil || stored != secret {
+					return fmt.Errorf("%s doesn't read back from %s (%v); %s was left unchanged", name, store, err, path)
+				}
+				statusf("✓ Stored %s in %s\n", name, store)
+			}
+
+			creds.KeySource = src.String()
+			creds.PrivateKey = ""
+			creds.Passphrase = ""
+			creds.Sealed = nil
+			if err := SaveCredentials(creds, path); err != nil {
+				return fmt.Errorf("failed to update %s: %w", path, err)
+			}
+			statusf("✓ Set key_source to %s and removed the secrets from %s\n", src, path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "Key source to move the secrets to, e.g. keychain or file (default: key_source)")
+
+	return cmd
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// platformNames are the names of the platform codes in CENT entries
+var platformNames = map[uint8]string{
+	0: "DOS",
+	1: "Game Boy",
+	2: "Game Boy Color",
+	3: "NES",
+}
+
+// platformName returns the name of a platform code
+func platformName(code uint8) string {
+	if name, ok := platformNames[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (%d)", code)
+}
+
+// CatalogListing is the highest version of one app in a catalog
+type CatalogListing struct {
+	Title         string `json:"title"`
+	AppID         uint32 `json:"app_id"`
+	Version       string `json:"version"`
+	Channel       string `json:"channel"`
+	Platform      string `json:"platform"`
+	PlatformCode  uint8  `json:"platform_code"`
+	Publisher     string `json:"publisher"`
+	CartridgeAddr string `json:"cartridge_addr"`
+	Retired       bool   `json:"retired"`
+	TxHash        string `json:"tx_hash"`
+	// Metadata is the extended metadata of CENT v2 entries
+	Metadata *CENTMetadata `json:"metadata,omitempty"`
+}
+
+// semverLess orders CENT versions
+func semverLess(a, b [3]uint8) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// ListCatalog returns the highest version of every app in a namespace, per
+// (publisher, app-id), ordered by publisher and app-id. Only entries on
+// channel are considered ("all" for every channel). An app is retired if its
+// newest entry is retired, or if the listed version itself was retired.
+func ListCatalog(rpc *NimiqRPC, ns AppNamespace, channel string) ([]CatalogListing, error) {
+	txs, entries, err := centEntries(rpc, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries are newest first: on equal versions the newest entry wins
+	retired := make(map[string]bool)
+	latest := make(map[string]int)
+	var keys []string
+	for i, entry := range entries {
+		key := fmt.Sprintf("%s/%d", normalizeAddress(txs[i].From), entry.AppID)
+		if _, ok := retired[key]; !ok {
+			retired[key] = entry.Flags&FlagRetired != 0
+		}
+		if channel != "all" && entry.Channel() != channel {
+			continue
+		}
+		best, ok := latest[key]
+		if !ok {
+			keys = append(keys, key)
+		}
+		if !ok || semverLess(entries[best].Semver, entry.Semver) {
+			latest[key] = i
+		}
+	}
+
+	var listings []CatalogListing
+	for _, key := range keys {
+		i := latest[key]
+		entry := entries[i]
+		listings = append(listings, CatalogListing{
+			Title:         entry.Title(),
+			AppID:         entry.AppID,
+			Version:       fmt.Sprintf("%d.%d.%d", entry.Semver[0], entry.Semver[1], entry.Semver[2]),
+			Channel:       entry.Channel(),
+			Platform:      platformName(entry.Platform),
+			PlatformCode:  entry.Platform,
+			Publisher:     FormatAddressNQ(txs[i].From),
+			CartridgeAddr: BytesToAddressNQ(entry.CartridgeAddr),
+			Retired:       retired[key] || entry.Flags&FlagRetired != 0,
+			TxHash:        txs[i].Hash,
+			Metadata:      entry.Meta,
+		})
+	}
+
+	sort.SliceStable(listings, func(i, j int) bool {
+		if listings[i].Publisher != listings[j].Publisher {
+			return listings[i].Publisher < listings[j].Publisher
+		}
+		return listings[i].AppID < listings[j].AppID
+	})
+	return listings, nil
+}
+
+// newListCatalogCmd creates the list-catalog command
+func newListCatalogCmd() *cobra.Command {
+	var (
+		rpcURL          string
+		catalogAddr     string
+		publisher       string
+		channel         string
+		ignoreAllowlist bool
+		jsonOutput      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list-catalog",
+		Short: "List what is published in a catalog",
+		Long: `Scan the CENT entries of a catalog and list the highest version of every
+app, per publisher and app-id: title, app-id, version, platform, publisher and
+cartridge address. Retired apps are listed and marked.
+
+Every publisher is listed unless --publisher is set. Catalogs with a
+publisher allowlist (see catalog allowlist) only show allowed publishers
+unless --ignore-allowlist is set.
+
+Only the stable channel is considered by default; --channel beta or all
+changes that.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if catalogAddr == "" {
+				return fmt.Errorf("catalog address is required (--catalog-addr)")
+			}
+			if channel != "all" {
+				var err error
+				if channel, err = ParseChannel(channel); err != nil {
+					return err
+				}
+			}
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+			catalogAddr = resolveCatalogAddress(catalogAddr)
+			rpc := NewNimiqRPC(rpcURL)
+
+			listings, err := ListCatalog(rpc, AppNamespace{Catalog: catalogAddr, Publisher: publisher}, channel)
+			if err != nil {
+				return err
+			}
+			hidden := 0
+			if !ignoreAllowlist {
+				allowlist, err := LoadAllowlist(rpc, catalogAddr)
+				if err != nil {
+					return err
+				}
+				filtered := listings[:0]
+				for _, l := range listings {
+					if allowlist.Allows(l.Publisher) {
+						filtered = append(filtered, l)
+					} else {
+						hidden++
+					}
+				}
+				listings = filtered
+			}
+
+			if jsonOutput {
+				if listings == nil {
+					listings = []CatalogListing{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(listings)
+			}
+
+			if hidden > 0 {
+				fmt.Printf("Hiding %d app(s) from publishers not on the catalog's allowlist (--ignore-allowlist to show)\n", hidden)
+			}
+			if len(listings) == 0 {
+				fmt.Println("No apps found.")
+				return nil
+			}
+			fmt.Printf("%-16s %-7s %-9s %-15s %-46s %s\n", "TITLE", "APP-ID", "VERSION", "PLATFORM", "PUBLISHER", "CARTRIDGE")
+			retired := 0
+			for _, l := range listings {
+				version := l.Version
+				if l.Channel != ChannelStable {
+					version += "-" + l.Channel
+				}
+				cartridge := l.CartridgeAddr
+				if l.Retired {
+					cartridge += "  (retired)"
+					retired++
+				}
+				fmt.Printf("%-16s %-7d %-9s %-15s %-46s %s\n", l.Title, l.AppID, version, l.Platform, l.Publisher, cartridge)
+			}
+			fmt.Printf("\n%d app(s), %d retired\n", len(listings), retired)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address or alias (required)")
+	cmd.Flags().StringVar(&publisher, "publisher", "", "Only list apps of this publisher")
+	cmd.Flags().StringVar(&channel, "channel", ChannelStable, "Release channel to consider: stable, beta or all")
+	cmd.Flags().BoolVar(&ignoreAllowlist, "ignore-allowlist", false, "Show apps from publishers not on the catalog's allowlist")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the list as JSON")
+
+	return cmd
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// StaleLockAge is the age after which a lock held by a process on another
+// host (whose liveness can't be checked) is considered stale
+const StaleLockAge = 24 * time.Hour
+
+// LockInfo is the content of a lock file
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Hostname  string    `json:"hostname"`
+	Command   string    `json:"command"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Lock is an advisory lock file held by the current process
+type Lock struct {
+	path     string
+	released bool
+}
+
+// AcquireLock creates the lock file at path. If another run holds the lock,
+// an error is returned unless the lock is stale (owner process gone) or
+// force is set, in which case the lock is taken over.
+func AcquireLock(path, command string, force bool) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	info := LockInfo{
+		PID:       os.Getpid(),
+		Hostname:  hostname,
+		Command:   command,
+		CreatedAt: time.Now().UTC(),
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock info: %w", err)
+	}
+
+	// Two attempts: the second one after removing a stale or forced lock
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, writeErr := file.Write(data)
+			closeErr := file.Close()
+			if writeErr != nil || closeErr != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write lock file %s", path)
+			}
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file %s: %w", path, err)
+		}
+
+		holder, readErr := readLockInfo(path)
+		switch {
+		case readErr == nil && lockIsStale(holder):
+			fmt.Printf("Removing stale lock %s (pid %d on %s, started %s)\n",
+				path, holder.PID, holder.Hostname, holder.CreatedAt.Local().Format(time.RFC3339))
+		case force:
+			warnf("--force-unlock given, taking over lock %s", path)
+		case readErr != nil:
+			return nil, fmt.Errorf("lock file %s exists but is unreadable (%v); use --force-unlock if no other run is active", path, readErr)
+		default:
+			return nil, fmt.Errorf("another run holds %s (%s, pid %d on %s, started %s); use --force-unlock if it is no longer running",
+				path, holder.Command, holder.PID, holder.Hostname, holder.CreatedAt.Local().Format(time.RFC3339))
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove lock file %s: %w", path, err)
+		}
+	}
+
+	return nil, fmt.Errorf("failed to acquire lock %s: lock was re-created by another run", path)
+}
+
+// Release removes the lock file. Safe to call more than once.
+func (l *Lock) Release() {
+	if l == nil || l.released {
+		return
+	}
+	l.released = true
+	os.Remove(l.path)
+}
+
+// readLockInfo reads the lock file at path
+func readLockInfo(path string) (*LockInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// lockIsStale reports whether the lock owner is gone. Locks from this host
+// are checked by PID; locks from other hosts expire after StaleLockAge.
+func lockIsStale(info *LockInfo) bool {
+	hostname, _ := os.Hostname()
+	if info.Hostname == hostname {
+		return !processAlive(info.PID)
+	}
+	return time.Since(info.CreatedAt) > StaleLockAge
+}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = proc.Signal(syscall.Signal(0))
+	if err == nil || errors.Is(err, syscall.EPERM) {
+		return true
+	}
+	return !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH)
+}
+
+// catalogLockPath returns the lock file guarding operations on a catalog
+// (app-id/cartridge-id allocation, retiring apps)
+func catalogLockPath(catalogAddr string) string {
+	return filepath.Join(GetStateBaseDir(), normalizeAddress(catalogAddr), "catalog.lock")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/retro-crypto/shared/logging"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Log (--log-format, --log-file)
+// ============================================================================
+
+// The log is shared with catalogctl (github.com/retro-crypto/shared/logging):
+// the console (stderr) at the level picked with -q/-v, and a rotating log
+// file in the config directory that keeps every run's status lines, upload
+// log, warnings and errors (and more with -v).
+
+var (
+	logFormat  string
+	logFile    string
+	logMaxMB   int64
+	logBackups int
+)
+
+func addLoggingFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Format of warnings and debug output on stderr and of the log file: text or json")
+	cmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Log file, rotated by size (default: ~/.config/nimiq-uploader/logs/nimiq-uploader.log; off disables it)")
+	cmd.PersistentFlags().Int64Var(&logMaxMB, "log-max-mb", logging.DefaultMaxSize>>20, "Rotate the log file when it exceeds this size in MB")
+	cmd.PersistentFlags().IntVar(&logBackups, "log-backups", logging.DefaultBackups, "Rotated log files to keep")
+}
+
+// defaultLogFile is logs/nimiq-uploader.log in the config directory
+func defaultLogFile() string {
+	return filepath.Join(GetConfigDir(), "logs", "nimiq-uploader.log")
+}
+
+// setupLogging starts the log at the verbosity set by setupVerbosity. The
+// console shows errors with -q, warnings by default and debug detail with
+// -v/-vv; the log file also keeps status lines and the upload log.
+func setupLogging() error {
+	if logFormat != "text" && logFormat != "json" {
+		return fmt.Errorf("--log-format must be text or json, got %q", logFormat)
+	}
+	if logMaxMB < 1 {
+		return fmt.Errorf("--log-max-mb must be at least 1")
+	}
+
+	consoleLevel := slog.LevelWarn
+	switch {
+	case quiet():
+		consoleLevel = slog.LevelError
+	case verbose(levelDebug):
+		consoleLevel = logging.LevelTrace
+	case verbose(levelVerbose):
+		consoleLevel = slog.LevelDebug
+	}
+	fileLevel := slog.LevelInfo
+	if consoleLevel < fileLevel {
+		fileLevel = consoleLevel
+	}
+
+	path := logFile
+	switch path {
+	case "":
+		path = defaultLogFile()
+	case "off":
+		path = ""
+	}
+	err := logging.Setup(logging.Options{
+		Console:      os.Stderr,
+		ConsoleLevel: consoleLevel,
+		JSON:         logFormat == "json",
+		File:         path,
+		FileLevel:    fileLevel,
+		MaxSize:      logMaxMB << 20,
+		Backups:      logBackups,
+		Prefixes: map[slog.Level]string{
+			slog.LevelWarn:  "Warning",
+			slog.LevelError: "Error",
+		},
+		// Upload workers log concurrently; keep their lines apart
+		ConsoleLock: &traceMu,
+	})
+	if err != nil {
+		// A missing log file never stops a command
+		warnf("log file disabled: %v", err)
+	}
+	return nil
+}
+
+// slogLevel maps -v levels to log levels
+func slogLevel(level int) slog.Level {
+	if level >= levelDebug {
+		return logging.LevelTrace
+	}
+	return slog.LevelDebug
+}
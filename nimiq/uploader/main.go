@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/retro-crypto/shared/logging"
 	"github.com/spf13/cobra"
 )
 
@@ -11,9 +13,13 @@ import (
 var (
 	Version   = "dev"
 	BuildTime = "unknown"
+	// ReleasePublicKey verifies self-update downloads (base64 DER)
+	ReleasePublicKey = ""
 )
 
 func main() {
+	var backend, memoryDBPath, signer string
+
 	var rootCmd = &cobra.Command{
 		Use:   "nimiq-uploader",
 		Short: "Nimiq blockchain game uploader CLI",
@@ -32,18 +38,41 @@ Legacy txt format is also supported:
 
 Use 'nimiq-uploader account create --global' to save credentials globally.
 Use 'nimiq-uploader migrate --global' to convert old txt to new JSON format.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := setupVerbosity(); err != nil {
+				return err
+			}
+			if err := setupLogging(); err != nil {
+				return err
+			}
+			debugf(levelVerbose, "[config] %s", GetCredentialsPath())
+			if err := validateFlags(cmd); err != nil {
+				return err
+			}
+			if err := setupHTTP(cmd); err != nil {
+				return err
+			}
+			if err := setupSigner(signer); err != nil {
+				return err
+			}
+			return setupBackend(backend, memoryDBPath)
+		},
 	}
 
+	rootCmd.PersistentFlags().BoolVar(&ghSummaryEnabled, "gh-summary", false, "Write a GitHub Actions job summary ($GITHUB_STEP_SUMMARY) and step outputs ($GITHUB_OUTPUT)")
+	rootCmd.PersistentFlags().StringVar(&backend, "backend", "", "Node backend: node or memory (offline simulation; default: $NIMIQ_UPLOADER_BACKEND or node)")
+	rootCmd.PersistentFlags().StringVar(&memoryDBPath, "memory-db", "", "Database of the memory backend (default: ~/.config/nimiq-uploader/memory.json)")
+	rootCmd.PersistentFlags().StringVar(&signer, "signer", "", "Transaction signer: node (the node's wallet) or local (private key from credentials; default: $NIMIQ_UPLOADER_SIGNER or node)")
+	rootCmd.PersistentFlags().IntVar(&MaxTransactions, "max-transactions", DefaultMaxTransactions, "Maximum transactions to fetch per address when querying catalogs")
+	rootCmd.PersistentFlags().StringVar(&indexFilePath, "index-file", "", "Catalog index kept by the index command (default: <state dir>/index/<catalog>.json when it exists)")
+	rootCmd.PersistentFlags().StringVar(&indexFilePath, "index-db", "", "Alias for --index-file")
+	rootCmd.PersistentFlags().MarkDeprecated("index-db", "use --index-file; the index is a JSON file, not a database")
+	addHTTPFlags(rootCmd)
+	addVerbosityFlags(rootCmd)
+	addLoggingFlags(rootCmd)
+
 	// Add version command
-	rootCmd.AddCommand(&cobra.Command{
-		Use:   "version",
-		Short: "Print version information",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Printf("nimiq-uploader %s\n", Version)
-			fmt.Printf("Built: %s\n", BuildTime)
-			fmt.Printf("Config dir: %s\n", GetConfigDir())
-		},
-	})
+	rootCmd.AddCommand(newVersionCmd())
 
 	// Add config command
 	rootCmd.AddCommand(&cobra.Command{
@@ -53,6 +82,7 @@ Use 'nimiq-uploader migrate --global' to convert old txt to new JSON format.`,
 			fmt.Println("Configuration Paths:")
 			fmt.Printf("  Config directory: %s\n", GetConfigDir())
 			fmt.Printf("  Credentials file: %s\n", GetCredentialsPath())
+			fmt.Printf("  State directory:  %s\n", GetStateBaseDir())
 			fmt.Println()
 
 			// Check if credentials exist
@@ -65,6 +95,12 @@ Use 'nimiq-uploader migrate --global' to convert old txt to new JSON format.`,
 					fmt.Printf("  RPC URL: %s\n", rpcURL)
 				}
 				fmt.Printf("  RPC URL (effective): %s\n", GetDefaultRPCURL())
+				if network := GetDefaultNetwork(); network != "" {
+					fmt.Printf("  Network: %s\n", network)
+				}
+				if explorer := GetDefaultExplorer(); explorer != "" {
+					fmt.Printf("  Explorer: %s\n", explorer)
+				}
 			} else {
 				fmt.Printf("No credentials found. Run 'nimiq-uploader account create' to create an account.\n")
 			}
@@ -74,16 +110,44 @@ Use 'nimiq-uploader migrate --global' to convert old txt to new JSON format.`,
 	// Main commands
 	rootCmd.AddCommand(newUploadCartridgeCmd())
 	rootCmd.AddCommand(newRetireAppCmd())
+	rootCmd.AddCommand(newPromoteChannelCmd())
 	rootCmd.AddCommand(newAccountCmd())
 	rootCmd.AddCommand(newPackageCmd())
 	rootCmd.AddCommand(newMigrateCmd()) // Migrate legacy txt to JSON
+	rootCmd.AddCommand(newKeyCmd())
+	rootCmd.AddCommand(newTelemetryCmd())
+	rootCmd.AddCommand(newExecutePlanCmd())
+	rootCmd.AddCommand(newPruneCmd())
+	rootCmd.AddCommand(newNetworkCmd())
+	rootCmd.AddCommand(newCatalogAliasCmd())
+	rootCmd.AddCommand(newCatalogCmd())
+	rootCmd.AddCommand(newCollectionCmd())
+	rootCmd.AddCommand(newSpendCmd())
+	rootCmd.AddCommand(newBenchmarkCmd())
+	rootCmd.AddCommand(newSubmitSignedCmd())
+	rootCmd.AddCommand(newCtlCmd())
+	rootCmd.AddCommand(newRepairCartridgeCmd())
+	rootCmd.AddCommand(newDownloadCartridgeCmd())
+	rootCmd.AddCommand(newListCatalogCmd())
+	rootCmd.AddCommand(newIndexCmd())
+	rootCmd.AddCommand(newSelfUpdateCmd())
 
 	// Legacy commands (kept for backwards compatibility)
 	rootCmd.AddCommand(newUploadCmd())   // Legacy: uses old DOOM format
 	rootCmd.AddCommand(newManifestCmd()) // Legacy: generates old-style manifest
 
-	if err := rootCmd.Execute(); err != nil {
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	// Lock accounts the run unlocked itself
+	relockSessionAccounts()
+	elapsed := time.Since(start)
+	reportUsage(cmd, elapsed, err)
+	if err != nil {
+		logging.File().Error("command failed", "command", cmd.CommandPath(), "error", err, "duration", elapsed)
+		logging.Close()
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	logging.File().Info("command done", "command", cmd.CommandPath(), "duration", elapsed)
+	logging.Close()
 }
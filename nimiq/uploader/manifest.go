@@ -11,7 +11,6 @@ package main
 // ==============================================================================
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -58,14 +57,11 @@ func newManifestCmd() *cobra.Command {
 				}
 			}
 
-			// Read file
-			data, err := os.ReadFile(filePath)
+			// Hash the file as a stream; it never needs to be in memory
+			hash, size, err := CalculateFileSHA256(filePath)
 			if err != nil {
 				return fmt.Errorf("failed to read file: %w", err)
 			}
-
-			// Calculate SHA256
-			hash := sha256.Sum256(data)
 			sha256Hex := fmt.Sprintf("%x", hash)
 
 			// Get filename
@@ -80,7 +76,7 @@ func newManifestCmd() *cobra.Command {
 				Platform:      platform,
 				Filename:      filename,
 				Executable:    "", // Can be set manually after manifest generation
-				TotalSize:     uint64(len(data)),
+				TotalSize:     uint64(size),
 				ChunkSize:     51,
 				SHA256:        sha256Hex,
 				SenderAddress: sender,
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ============================================================================
+// Simulation backend (--backend memory)
+// ============================================================================
+
+const (
+	backendNode   = "node"
+	backendMemory = "memory"
+
+	// memoryNetwork is the network the simulated node reports
+	memoryNetwork = "TestAlbatross"
+	// memoryFaucetLuna is the balance every address starts with (1000 NIM)
+	memoryFaucetLuna = 1000 * 100000
+	// memoryMaxDataSize is the simulated node's limit on transaction data,
+	// so --chunk-size auto has a limit to find
+	memoryMaxDataSize = 160
+)
+
+// setupBackend starts the in-process node for --backend memory and points
+// every command at it through NIMIQ_RPC_URL (an explicit --rpc-url still wins)
+func setupBackend(backend, dbPath string) error {
+	if backend == "" {
+		backend = os.Getenv("NIMIQ_UPLOADER_BACKEND")
+	}
+	switch backend {
+	case "", backendNode:
+		return nil
+	case backendMemory:
+	default:
+		return fmt.Errorf("unknown --backend %q (use %s or %s)", backend, backendNode, backendMemory)
+	}
+
+	if dbPath == "" {
+		dbPath = filepath.Join(GetConfigDir(), "memory.json")
+	}
+	node, err := OpenMemoryNode(dbPath)
+	if err != nil {
+		return err
+	}
+	url, err := node.Serve()
+	if err != nil {
+		return err
+	}
+	os.Setenv("NIMIQ_RPC_URL", url)
+	fmt.Fprintf(os.Stderr, "Using memory backend (%s)\n", dbPath)
+	return nil
+}
+
+// MemoryNode is a simulated Nimiq node answering the JSON-RPC methods the
+// uploader uses. Every address starts funded and is treated as unlocked;
+// each transaction is mined into its own block. Addresses of created or
+// imported accounts are derived like on the real network, so --signer local
+// works against it too.
+type MemoryNode struct {
+	path string
+
+	mu    sync.Mutex
+	state memoryNodeState
+}
+
+type memoryNodeState struct {
+	Height       int64            `json:"height"`
+	Balances     map[string]int64 `json:"balances"`
+	Transactions []Transaction    `json:"transactions"` // oldest first
+}
+
+// OpenMemoryNode loads the node database, creating it if it doesn't exist
+func OpenMemoryNode(path string) (*MemoryNode, error) {
+	n := &MemoryNode{path: path, state: memoryNodeState{Height: 1, Balances: map[string]int64{}}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return n, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory database: %w", err)
+	}
+	if err := json.Unmarshal(data, &n.state); err != nil {
+		return nil, fmt.Errorf("failed to parse memory database %s: %w", path, err)
+	}
+	if n.state.Balances == nil {
+		n.state.Balances = map[string]int64{}
+	}
+	return n, nil
+}
+
+// save writes the database; callers hold n.mu
+func (n *MemoryNode) save() error {
+	data, err := json.MarshalIndent(n.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory database: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(n.path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(n.path), err)
+	}
+	return os.WriteFile(n.path, data, 0644)
+}
+
+// Serve starts the JSON-RPC endpoint on a random local port
+func (n *MemoryNode) Serve() (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to start memory backend: %w", err)
+	}
+	go http.Serve(ln, n)
+	return "http://" + ln.Addr().String(), nil
+}
+
+func (n *MemoryNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     int             `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON-RPC request", http.StatusBadRequest)
+		return
+	}
+
+	resp := JSONRPCResponse{JSONRPC: "2.0", ID: req.ID}
+	n.mu.Lock()
+	result, err := n.handle(req.Method, req.Params)
+	n.mu.Unlock()
+	if err != nil {
+		resp.Error = &JSONRPCError{Code: -32000, Message: err.Error()}
+	} else {
+		data, _ := json.Marshal(map[string]interface{}{"data": result, "metadata": nil})
+		resp.Result = data
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// memoryParams accepts both object params and the positional arrays some
+// calls (importRawKey, sendBasicTransactionWithData) fall back to
+type memoryParams struct {
+	named      map[string]json.RawMessage
+	positional []json.RawMessage
+}
+
+func (p memoryParams) get(name string, index int, v interface{}) {
+	raw, ok := p.named[name]
+	if !ok && index < len(p.positional) {
+		raw, ok = p.positional[index], true
+	}
+	if ok {
+		json.Unmarshal(raw, v)
+	}
+}
+
+func (n *MemoryNode) handle(method string, rawParams json.RawMessage) (interface{}, error) {
+	var p memoryParams
+	if json.Unmarshal(rawParams, &p.named) != nil {
+		json.Unmarshal(rawParams, &p.positional)
+	}
+
+	switch method {
+	case "isConsensusEstablished", "isAccountImported", "isAccountUnlocked", "unlockAccount":
+		return true, nil
+	case "lockAccount":
+		return nil, nil
+	case "getBlockNumber":
+		return n.state.Height, nil
+	case "getLatestBlock":
+		return map[string]interface{}{"number": n.state.Height, "network": memoryNetwork}, nil
+
+	case "getAccountByAddress":
+		var address string
+		p.get("address", 0, &address)
+		return map[string]interface{}{"address": FormatAddressNQ(address), "balance": n.balance(address), "type": "basic"}, nil
+
+	case "createAccount":
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return AccountInfo{
+			Address:    memoryAddress(pub),
+			PublicKey:  hex.EncodeToString(pub),
+			PrivateKey: hex.EncodeToString(priv.Seed()),
+		}, nil
+
+	case "importRawKey":
+		var keyData string
+		p.get("keyData", 0, &keyData)
+		seed, err := hex.DecodeString(keyData)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid private key")
+		}
+		return memoryAddress(ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)), nil
+
+	case "sendBasicTransactionWithData":
+		var wallet, recipient, data string
+		var value, fee int64
+		p.get("wallet", 0, &wallet)
+		p.get("recipient", 1, &recipient)
+		p.get("data", 2, &data)
+		p.get("value", 3, &value)
+		p.get("fee", 4, &fee)
+		return n.mine(wallet, recipient, data, value, fee)
+
+	case "sendRawTransaction":
+		var rawTx string
+		p.get("rawTx", 0, &rawTx)
+		raw, err := hex.DecodeString(rawTx)
+		if err != nil {
+			return nil, fmt.Errorf("invalid raw transaction")
+		}
+		from, to, data, value, fee, err := parseRawTransaction(raw)
+		if err != nil {
+			return nil, err
+		}
+		return n.mine(from, to, data, value, fee)
+
+	case "getTransactionsByAddress":
+		var address, startAt string
+		max := 500
+		p.get("address", 0, &address)
+		p.get("max", 1, &max)
+		p.get("startAt", 2, &startAt)
+		return n.transactionsOf(address, startAt, max), nil
+
+	case "mempoolContent":
+		// Transactions are mined as they are sent, so nothing is ever pending
+		return []Transaction{}, nil
+
+	default:
+		return nil, fmt.Errorf("method %s is not supported by the memory backend", method)
+	}
+}
+
+// balance returns the balance of an address, funding it on first use
+func (n *MemoryNode) balance(address string) int64 {
+	key := normalizeAddress(address)
+	if _, ok := n.state.Balances[key]; !ok {
+		n.state.Balances[key] = memoryFaucetLuna
+	}
+	return n.state.Balances[key]
+}
+
+// mine applies a transaction in a new block and returns its hash
+func (n *MemoryNode) mine(from, to, dataHex string, value, fee int64) (string, error) {
+	if err := ValidateAddressNQ(from); err != nil {
+		return "", fmt.Errorf("invalid sender: %w", err)
+	}
+	if err := ValidateAddressNQ(to); err != nil {
+		return "", fmt.Errorf("invalid recipient: %w", err)
+	}
+	if len(dataHex)/2 > memoryMaxDataSize {
+		return "", fmt.Errorf("transaction data too large: %d bytes (max %d)", len(dataHex)/2, memoryMaxDataSize)
+	}
+	if n.balance(from) < value+fee {
+		return "", fmt.Errorf("insufficient funds")
+	}
+	n.state.Balances[normalizeAddress(from)] -= value + fee
+	n.state.Balances[normalizeAddress(to)] = n.balance(to) + value
+	n.state.Height++
+
+	var height [8]byte
+	binary.BigEndian.PutUint64(height[:], uint64(n.state.Height))
+	sum := sha256.Sum256(append(height[:], []byte(from+to+dataHex)...))
+	tx := Transaction{
+		Hash:          hex.EncodeToString(sum[:]),
+		From:          FormatAddressNQ(from),
+		To:            FormatAddressNQ(to),
+		RecipientData: dataHex,
+		Height:        n.state.Height,
+	}
+	n.state.Transactions = append(n.state.Transactions, tx)
+	if err := n.save(); err != nil {
+		return "", err
+	}
+	return tx.Hash, nil
+}
+
+// transactionsOf returns transactions involving address, newest first,
+// starting after the startAt hash
+func (n *MemoryNode) transactionsOf(address, startAt string, max int) []Transaction {
+	key := normalizeAddress(address)
+	txs := []Transaction{}
+	skipping := startAt != ""
+	for i := len(n.state.Transactions) - 1; i >= 0 && len(txs) < max; i-- {
+		tx := n.state.Transactions[i]
+		if skipping {
+			skipping = tx.Hash != startAt
+			continue
+		}
+		if normalizeAddress(tx.From) == key || normalizeAddress(tx.To) == key {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+// memoryAddress derives the address of a public key
+func memoryAddress(pub ed25519.PublicKey) string {
+	var addr [20]byte
+	copy(addr[:], PublicKeyToAddress(pub))
+	return BytesToAddressNQ(addr)
+}
+
+// parseRawTransaction reads a signed extended transaction: a 0x01 type byte
+// followed by the content layout of serializeTxContent and the proof
+func parseRawTransaction(raw []byte) (from, to, dataHex string, value, fee int64, err error) {
+	if len(raw) < 3 || raw[0] != 1 {
+		return "", "", "", 0, 0, fmt.Errorf("memory backend only accepts extended transactions")
+	}
+	dataLen := int(binary.BigEndian.Uint16(raw[1:3]))
+	rest := raw[3:]
+	if len(rest) < dataLen+20+1+20+1+8+8 {
+		return "", "", "", 0, 0, fmt.Errorf("truncated transaction")
+	}
+	dataHex = hex.EncodeToString(rest[:dataLen])
+	rest = rest[dataLen:]
+	var sender, recipient [20]byte
+	copy(sender[:], rest[:20])
+	copy(recipient[:], rest[21:41])
+	value = int64(binary.BigEndian.Uint64(rest[42:50]))
+	fee = int64(binary.BigEndian.Uint64(rest[50:58]))
+	return BytesToAddressNQ(sender), BytesToAddressNQ(recipient), dataHex, value, fee, nil
+}
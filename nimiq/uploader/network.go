@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// networkFromID maps a node's network ID (e.g. "MainAlbatross",
+// "TestAlbatross") to the catalog shortcut for that network, or "" if the
+// network has no shortcut (devnet, local networks)
+func networkFromID(networkID string) string {
+	id := strings.ToLower(networkID)
+	switch {
+	case strings.HasPrefix(id, "main"):
+		return NetworkMain
+	case strings.HasPrefix(id, "test"):
+		return NetworkTest
+	default:
+		return ""
+	}
+}
+
+// warnNetworkMismatch prints a warning when the catalog alias or the
+// configured network doesn't match the network the node is connected to.
+// Nothing is printed if the node can't be queried.
+func warnNetworkMismatch(rpc *NimiqRPC, catalogAddr string) {
+	networkID, err := rpc.GetNetworkID()
+	if err != nil {
+		return
+	}
+	nodeNetwork := networkFromID(networkID)
+
+	if name, alias, ok := catalogAliasFor(catalogAddr); ok && alias.Network != "" && alias.Network != nodeNetwork {
+		statusf("⚠️  Warning: the '%s' catalog is a %snet catalog, but the node is connected to %s\n", name, alias.Network, networkID)
+		if others := catalogAliasesOnNetwork(nodeNetwork); nodeNetwork != "" && len(others) > 0 {
+			fmt.Printf("   Use --catalog-addr %s or point --rpc-url at a %snet node.\n", others[0], alias.Network)
+		}
+	}
+	if configured := GetDefaultNetwork(); configured != "" && configured != nodeNetwork {
+		statusf("⚠️  Warning: network is set to '%s' but the node is connected to %s\n", configured, networkID)
+	}
+}
+
+func newNetworkCmd() *cobra.Command {
+	networkCmd := &cobra.Command{
+		Use:   "network",
+		Short: "Network information",
+	}
+
+	networkCmd.AddCommand(newNetworkInfoCmd())
+
+	return networkCmd
+}
+
+func newNetworkInfoCmd() *cobra.Command {
+	var rpcURL string
+
+	cmd := &cobra.Command{
+		Use:   "info",
+		Short: "Show which network the node is connected to",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get RPC URL from env, credentials file, or default
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+
+			rpc := NewNimiqRPC(rpcURL)
+			networkID, err := rpc.GetNetworkID()
+			if err != nil {
+				return fmt.Errorf("failed to query network: %w", err)
+			}
+
+			fmt.Printf("RPC URL: %s\n", rpcURL)
+			fmt.Printf("Network: %s\n", networkID)
+
+			if height, err := rpc.GetBlockNumber(); err == nil {
+				fmt.Printf("Block height: %d\n", height)
+			}
+			if consensus, err := rpc.IsConsensusEstablished(); err == nil {
+				fmt.Printf("Consensus: %v\n", consensus)
+			}
+
+			nodeNetwork := networkFromID(networkID)
+			if names := catalogAliasesOnNetwork(nodeNetwork); nodeNetwork != "" && len(names) > 0 {
+				for _, name := range names {
+					fmt.Printf("Catalog alias: --catalog-addr %s (%s)\n", name, resolveCatalogAddress(name))
+				}
+			} else {
+				fmt.Println("Catalog alias: none (use a full catalog address)")
+			}
+
+			if configured := GetDefaultNetwork(); configured != "" {
+				fmt.Printf("Configured network: %s\n", configured)
+				if configured != nodeNetwork {
+					statusf("⚠️  Warning: configured network '%s' doesn't match the node\n", configured)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+
+	return cmd
+}
@@ -69,7 +69,7 @@ The ZIP file will contain all game files with proper structure for DOS emulation
 			if gameExecutable == "" {
 				gameExecutable = findGameExecutable(inputDir)
 				if gameExecutable == "" {
-					fmt.Printf("Warning: No game executable found (.exe, .com, or .bat). You may need to specify --exe\n")
+					warnf("No game executable found (.exe, .com, or .bat). You may need to specify --exe")
 				} else {
 					fmt.Printf("Found game executable: %s\n", gameExecutable)
 				}
@@ -139,7 +139,7 @@ The ZIP file will contain all game files with proper structure for DOS emulation
 			// Calculate SHA256 of the ZIP file
 			hash, err := calculateSHA256(outputFile)
 			if err != nil {
-				fmt.Printf("Warning: failed to calculate SHA256: %v\n", err)
+				warnf("failed to calculate SHA256: %v", err)
 			} else {
 				fmt.Printf("\nSHA256: %s\n", hash)
 			}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const (
+	// CartridgePlanVersion is the version of the upload plan file format
+	CartridgePlanVersion = 1
+	// CartridgePlanKind identifies upload-cartridge plans
+	CartridgePlanKind = "upload-cartridge"
+	// TxValueLuna is the value sent with every data transaction (see RPCSender)
+	TxValueLuna = 1
+	// LunaPerNIM is the number of Luna in one NIM
+	LunaPerNIM = 100000
+)
+
+// CartridgePlan is a complete, machine-readable description of an
+// upload-cartridge run. It contains no timestamps or run-specific data, so the
+// same inputs always produce the same plan.
+type CartridgePlan struct {
+	Version       int             `json:"version"`
+	Kind          string          `json:"kind"`
+	File          string          `json:"file"`
+	Size          uint64          `json:"size"`
+	SHA256        string          `json:"sha256"`
+	AppID         uint32          `json:"app_id"`
+	CartridgeID   uint32          `json:"cartridge_id"`
+	CartridgeAddr string          `json:"cartridge_addr"`
+	CatalogAddr   string          `json:"catalog_addr"`
+	Title         string          `json:"title"`
+	Semver        string          `json:"semver"`
+	Platform      uint8           `json:"platform"`
+	Schema        uint8           `json:"schema"`
+	ChunkSize     uint8           `json:"chunk_size"`
+	Fee           int64           `json:"fee"`
+	Rate          float64         `json:"rate"`
+	Operations    []PlanOperation `json:"operations"`
+	Estimate      PlanEstimate    `json:"estimate"`
+}
+
+// PlanOperation is a single transaction of a plan, in send order
+type PlanOperation struct {
+	Step       int     `json:"step"`
+	Type       string  `json:"type"` // DATA, CART, CMET or CENT
+	To         string  `json:"to"`
+	ChunkIndex *uint32 `json:"chunk_index,omitempty"`
+	Payload    string  `json:"payload_hex"`
+	Size       int     `json:"size"`
+}
+
+// PlanEstimate summarizes the cost and duration of a plan
+type PlanEstimate struct {
+	Transactions    int     `json:"transactions"`
+	PayloadBytes    int     `json:"payload_bytes"`
+	FeeLuna         int64   `json:"fee_luna"`
+	ValueLuna       int64   `json:"value_luna"`
+	TotalLuna       int64   `json:"total_luna"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// BuildCartridgePlan encodes every DATA chunk and the CART header of an
+// upload and adds them, the CMET metadata records and the CENT entry (see
+// EncodeCENT2) in the order upload-cartridge sends them
+func BuildCartridgePlan(plan CartridgePlan, chunks *FileChunks, cartHeader CARTHeader, centPayload []byte, metaRecords [][]byte) (*CartridgePlan, error) {
+	plan.Version = CartridgePlanVersion
+	plan.Kind = CartridgePlanKind
+	plan.Operations = nil
+
+	addOp := func(opType, to string, chunkIndex *uint32, payload []byte) {
+		plan.Operations = append(plan.Operations, PlanOperation{
+			Step:       len(plan.Operations) + 1,
+			Type:       opType,
+			To:         to,
+			ChunkIndex: chunkIndex,
+			Payload:    hex.EncodeToString(payload),
+			Size:       len(payload),
+		})
+	}
+
+	for i := 0; i < chunks.Count(); i++ {
+		chunkIdx := uint32(i)
+		data, err := chunks.Chunk(chunkIdx)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded, err := EncodeDATA(DATAPayload{
+			CartridgeID: plan.CartridgeID,
+			ChunkIndex:  chunkIdx,
+			Length:      uint8(len(data)),
+			Data:        data,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode chunk %d: %w", chunkIdx, err)
+		}
+		addOp("DATA", plan.CartridgeAddr, &chunkIdx, encoded)
+	}
+
+	cartPayload, err := EncodeCART(cartHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode CART header: %w", err)
+	}
+	addOp("CART", plan.CartridgeAddr, nil, cartPayload)
+
+	for _, record := range metaRecords {
+		addOp("CMET", plan.CatalogAddr, nil, record)
+	}
+	addOp("CENT", plan.CatalogAddr, nil, centPayload)
+
+	txCount := len(plan.Operations)
+	plan.Estimate = PlanEstimate{
+		Transactions: txCount,
+		FeeLuna:      plan.Fee * int64(txCount),
+		ValueLuna:    TxValueLuna * int64(txCount),
+	}
+	for _, op := range plan.Operations {
+		plan.Estimate.PayloadBytes += op.Size
+	}
+	plan.Estimate.TotalLuna = plan.Estimate.FeeLuna + plan.Estimate.ValueLuna
+	if plan.Rate > 0 {
+		plan.Estimate.DurationSeconds = float64(txCount) / plan.Rate
+	}
+
+	return &plan, nil
+}
+
+// SaveCartridgePlan writes a plan as indented JSON
+func SaveCartridgePlan(filename string, plan *CartridgePlan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write plan file: %w", err)
+	}
+	return nil
+}
+
+// LoadCartridgePlan reads a plan written by SaveCartridgePlan
+func LoadCartridgePlan(filename string) (*CartridgePlan, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+	var plan CartridgePlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file: %w", err)
+	}
+	if plan.Kind != CartridgePlanKind {
+		return nil, fmt.Errorf("unsupported plan kind %q (expected %q)", plan.Kind, CartridgePlanKind)
+	}
+	if plan.Version != CartridgePlanVersion {
+		return nil, fmt.Errorf("unsupported plan version %d (expected %d)", plan.Version, CartridgePlanVersion)
+	}
+	return &plan, nil
+}
+
+// PrintPlanSummary prints the estimate of a plan
+func PrintPlanSummary(plan *CartridgePlan) {
+	counts := make(map[string]int)
+	for _, op := range plan.Operations {
+		counts[op.Type]++
+	}
+
+	fmt.Printf("\n=== Upload Plan ===\n")
+	fmt.Printf("Operations: %d (DATA: %d, CART: %d, CMET: %d, CENT: %d)\n",
+		len(plan.Operations), counts["DATA"], counts["CART"], counts["CMET"], counts["CENT"])
+	fmt.Printf("Payload bytes: %d\n", plan.Estimate.PayloadBytes)
+	fmt.Printf("Fees: %d Luna + %d Luna value = %d Luna (%.5f NIM)\n",
+		plan.Estimate.FeeLuna, plan.Estimate.ValueLuna, plan.Estimate.TotalLuna,
+		float64(plan.Estimate.TotalLuna)/LunaPerNIM)
+	if plan.Estimate.DurationSeconds > 0 {
+		fmt.Printf("Estimated duration: %s at %.1f tx/s\n", formatDuration(plan.Estimate.DurationSeconds), plan.Rate)
+	}
+	fmt.Printf("===================\n")
+}
+
+// formatDuration formats seconds as e.g. "1h02m03s"
+func formatDuration(seconds float64) string {
+	total := int(seconds + 0.5)
+	h, m, s := total/3600, (total%3600)/60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%dh%02dm%02ds", h, m, s)
+	}
+	if m > 0 {
+		return fmt.Sprintf("%dm%02ds", m, s)
+	}
+	return fmt.Sprintf("%ds", s)
+}
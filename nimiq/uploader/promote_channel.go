@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newPromoteChannelCmd() *cobra.Command {
+	var (
+		appID       uint32
+		catalogAddr string
+		sender      string
+		from        string
+		to          string
+		dryRun      bool
+		forceUnlock bool
+		rpcURL      string
+		fee         int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "promote-channel",
+		Short: "Move the latest version of an app from one release channel to another",
+		Long: `Promote the latest version of an app on the --from channel by sending a CENT
+entry for the same app-id, semver and cartridge address with the channel flag
+of --to. The cartridge itself is not uploaded again.
+
+Example:
+  nimiq-uploader upload-cartridge --file doom.zip --title DOOM --semver 1.1.0 --catalog-addr main --channel beta
+  nimiq-uploader promote-channel --app-id 3 --catalog-addr main --from beta --to stable`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get RPC URL from env, credentials file, or default
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+
+			// Try to get sender from credentials file if not provided
+			if sender == "" {
+				sender = GetDefaultAddress()
+			}
+
+			if sender == "" {
+				return fmt.Errorf("sender address is required (--sender or set in account_credentials.txt)")
+			}
+
+			fromChannel, err := ParseChannel(from)
+			if err != nil {
+				return err
+			}
+			toChannel, err := ParseChannel(to)
+			if err != nil {
+				return err
+			}
+			if fromChannel == toChannel {
+				return fmt.Errorf("--from and --to are both %s", fromChannel)
+			}
+
+			// Resolve catalog address shortcuts
+			catalogAddr = resolveCatalogAddress(catalogAddr)
+
+			rpc := NewNimiqRPC(rpcURL)
+			warnNetworkMismatch(rpc, catalogAddr)
+
+			// Entries are newest first: the first one of the app is its
+			// newest, the first one on --from the version to promote
+			_, entries, err := centEntries(rpc, AppNamespace{Catalog: catalogAddr, Publisher: sender})
+			if err != nil {
+				return err
+			}
+			var newest, source *CENTEntry
+			for _, entry := range entries {
+				if entry.AppID != appID {
+					continue
+				}
+				if newest == nil {
+					newest = entry
+				}
+				if entry.Channel() == fromChannel {
+					source = entry
+					break
+				}
+			}
+			if newest == nil {
+				return fmt.Errorf("app ID %d not found in catalog", appID)
+			}
+			if newest.Flags&FlagRetired != 0 {
+				return fmt.Errorf("app ID %d is retired", appID)
+			}
+			if source == nil {
+				return fmt.Errorf("app ID %d has no version on the %s channel", appID, fromChannel)
+			}
+
+			promoted := *source
+			promoted.Flags = source.Flags&^FlagBeta | ChannelFlags(toChannel)
+			semver := fmt.Sprintf("%d.%d.%d", promoted.Semver[0], promoted.Semver[1], promoted.Semver[2])
+
+			fmt.Printf("=== Promote Channel ===\n")
+			fmt.Printf("App ID: %d (%s)\n", appID, promoted.Title())
+			fmt.Printf("Version: %s\n", semver)
+			fmt.Printf("Channel: %s -> %s\n", fromChannel, toChannel)
+			fmt.Printf("Cartridge Address: %s\n", BytesToAddressNQ(promoted.CartridgeAddr))
+			fmt.Printf("Catalog Address: %s\n", catalogAddr)
+			fmt.Printf("Sender: %s\n", sender)
+			fmt.Printf("\n")
+
+			if dryRun {
+				fmt.Printf("Dry-run: Would send CENT entry for the %s channel\n", toChannel)
+				return nil
+			}
+
+			catalogLock, err := AcquireLock(catalogLockPath(catalogAddr), "promote-channel", forceUnlock)
+			if err != nil {
+				return err
+			}
+			defer catalogLock.Release()
+
+			centPayload, err := EncodeCENT(promoted)
+			if err != nil {
+				return fmt.Errorf("failed to encode CENT entry: %w", err)
+			}
+
+			catalogRpcSender, err := NewRPCSender(rpcURL, sender, catalogAddr, fee)
+			if err != nil {
+				return fmt.Errorf("failed to initialize RPC sender: %w", err)
+			}
+
+			txHash, err := catalogRpcSender.SendTransaction(centPayload)
+			if err != nil {
+				return fmt.Errorf("failed to send CENT entry: %w", err)
+			}
+
+			statusf("✓ CENT entry sent: %s\n", txHash)
+			printExplorerLink("  ", networkForCatalog(catalogAddr), LinkTx, txHash)
+			fmt.Printf("\nApp ID %d v%s is now on the %s channel.\n", appID, semver, toChannel)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Uint32Var(&appID, "app-id", 0, "App ID to promote (required)")
+	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address or alias (NQ..., 'main', 'test', see catalog-alias list; required)")
+	cmd.Flags().StringVar(&sender, "sender", "", "Sender address (defaults to ADDRESS from account_credentials.txt)")
+	cmd.Flags().StringVar(&from, "from", ChannelBeta, "Channel to promote from")
+	cmd.Flags().StringVar(&to, "to", ChannelStable, "Channel to promote to")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Dry-run mode (show what would be sent)")
+	cmd.Flags().BoolVar(&forceUnlock, "force-unlock", false, "Take over the catalog lock left by another run")
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().Int64Var(&fee, "fee", 0, "Transaction fee in Luna (default: 0, minimum)")
+
+	cmd.MarkFlagRequired("app-id")
+	cmd.MarkFlagRequired("catalog-addr")
+
+	return cmd
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// ============================================================================
+// Progress reconciliation
+// ============================================================================
+
+// A crash can happen after a transaction was sent but before it was saved in
+// the progress file (progress is saved every 10 chunks). Resuming blindly
+// would send those transactions again, so before sending anything the
+// progress is reconciled with what the node has: the transactions on the
+// cartridge address and, if the node exposes it, its mempool.
+
+// reconcileTarget is what an upload sends: who sends it and the exact
+// payloads, so that only transactions identical to ours are adopted
+type reconcileTarget struct {
+	Senders     []string                           // accounts the upload sends from
+	Chunk       func(index uint32) ([]byte, error) // expected DATA payload of a chunk
+	CART        []byte                             // expected CART header payload
+	CatalogAddr string                             // where the CENT entry goes ("" to skip)
+	CENT        []byte                             // expected CENT entry payload
+}
+
+// encodeChunk returns the DATA payload of a chunk of a file
+func encodeChunk(chunks *FileChunks, cartridgeID, index uint32) ([]byte, error) {
+	data, err := chunks.Chunk(index)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeDATA(DATAPayload{
+		CartridgeID: cartridgeID,
+		ChunkIndex:  index,
+		Length:      uint8(len(data)),
+		Data:        data,
+	})
+}
+
+func (t reconcileTarget) sentBy(tx Transaction) bool {
+	for _, s := range t.Senders {
+		if s != "" && normalizeAddress(tx.From) == normalizeAddress(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// mempoolTransactions returns the node's pending transactions, or nil if the
+// node doesn't expose its mempool over RPC
+func mempoolTransactions(rpc *NimiqRPC) []Transaction {
+	result, err := rpc.Call("mempoolContent", map[string]interface{}{"includeTransactions": true})
+	if err != nil {
+		return nil
+	}
+	var wrapped struct {
+		Data []Transaction `json:"data"`
+	}
+	if json.Unmarshal(result, &wrapped) == nil && wrapped.Data != nil {
+		return wrapped.Data
+	}
+	var txs []Transaction
+	json.Unmarshal(result, &txs)
+	return txs
+}
+
+// reconcileProgress records in progress the DATA chunks, CART header and CENT
+// entry that an earlier run sent without saving. Transactions carrying other
+// data for one of our chunk indexes are reported and the chunk is sent again.
+// It returns the number of transactions recovered.
+func reconcileProgress(rpc *NimiqRPC, progress *CartridgeUploadProgress, target reconcileTarget) (int, error) {
+	onChain, err := GetAllTransactionsByAddress(rpc, progress.CartridgeAddr, 500)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query cartridge address: %w", err)
+	}
+	mempool := mempoolTransactions(rpc)
+	candidates := append(onChain, mempool...)
+
+	sent := make(map[uint32]bool)
+	for _, entry := range progress.Plan {
+		if entry.TxHash != "" {
+			sent[entry.Index] = true
+		}
+	}
+
+	recovered := 0
+	for _, tx := range candidates {
+		if normalizeAddress(tx.To) != normalizeAddress(progress.CartridgeAddr) || !target.sentBy(tx) {
+			continue
+		}
+		payload := txPayload(tx)
+
+		if progress.CARTTxHash == "" && target.CART != nil && bytes.Equal(payload, target.CART) {
+			progress.CARTTxHash = tx.Hash
+			recovered++
+			fmt.Printf("  Found CART header: %s\n", tx.Hash)
+			continue
+		}
+
+		if len(payload) < DATAHeaderSize || string(payload[0:4]) != MagicDATA ||
+			binary.LittleEndian.Uint32(payload[4:8]) != progress.CartridgeID {
+			continue
+		}
+		index := binary.LittleEndian.Uint32(payload[8:12])
+		if sent[index] || int(index) >= progress.TotalChunks {
+			continue
+		}
+		expected, err := target.Chunk(index)
+		if err != nil {
+			return recovered, err
+		}
+		if !bytes.Equal(payload, expected) {
+			statusf("  ⚠️  Transaction %s carries different data for chunk %d; the chunk will be sent again\n", tx.Hash, index)
+			continue
+		}
+		progress.Plan = append(progress.Plan, UploadPlan{
+			Index:   index,
+			Payload: hex.EncodeToString(payload),
+			TxHash:  tx.Hash,
+		})
+		progress.SentChunks++
+		sent[index] = true
+		recovered++
+	}
+
+	// The CENT entry only exists once the CART header does; the catalog is
+	// only queried in that case
+	if target.CatalogAddr != "" && target.CENT != nil && progress.CARTTxHash != "" && progress.CENTTxHash == "" {
+		catalogTxs, err := GetAllTransactionsByAddress(rpc, target.CatalogAddr, 500)
+		if err != nil {
+			return recovered, fmt.Errorf("failed to query catalog: %w", err)
+		}
+		for _, tx := range append(catalogTxs, mempool...) {
+			if normalizeAddress(tx.To) == normalizeAddress(target.CatalogAddr) && target.sentBy(tx) && bytes.Equal(txPayload(tx), target.CENT) {
+				progress.CENTTxHash = tx.Hash
+				recovered++
+				fmt.Printf("  Found CENT entry: %s\n", tx.Hash)
+				break
+			}
+		}
+	}
+
+	if mempool == nil {
+		fmt.Printf("  (node doesn't expose its mempool; only transactions in blocks were checked)\n")
+	}
+	return recovered, nil
+}
+
+// reconcileAndSave runs reconcileProgress and saves the progress file if
+// anything was recovered. Failing to reconcile is not fatal: the upload
+// resumes from the progress file as before.
+func reconcileAndSave(rpc *NimiqRPC, progress *CartridgeUploadProgress, progressFile string, target reconcileTarget) {
+	if progress.SentChunks == progress.TotalChunks && progress.CARTTxHash != "" &&
+		(progress.CENTTxHash != "" || target.CatalogAddr == "") {
+		return
+	}
+	statusf("Checking %s for transactions sent before the last run stopped...\n", progress.CartridgeAddr)
+	recovered, err := reconcileProgress(rpc, progress, target)
+	if err != nil {
+		statusf("⚠️  Could not reconcile progress (%v); resuming from the progress file\n", err)
+	}
+	if recovered > 0 {
+		statusf("✓ Recovered %d transactions missing from the progress file (%d/%d chunks sent)\n", recovered, progress.SentChunks, progress.TotalChunks)
+		logCartridgeUpload(fmt.Sprintf("Recovered %d in-flight transactions from the node", recovered))
+		saveCartridgeProgress(progressFile, progress)
+	}
+}
@@ -0,0 +1,788 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Cartridge repair
+// ============================================================================
+
+// maxRepairCombinations caps how many combinations of conflicting chunks are
+// hashed when looking for the set that matches the CART header
+const maxRepairCombinations = 256
+
+// errNoCARTHeader is returned for addresses without a CART header, such as
+// cartridges whose upload stopped before it (it is sent after the chunks)
+var errNoCARTHeader = errors.New("no CART header found on the cartridge address")
+
+// chunkVariant is one distinct payload seen for a chunk index and the
+// transactions carrying it, newest first
+type chunkVariant struct {
+	Data     []byte
+	TxHashes []string
+	Valid    bool // length fits the index (only valid variants can be canonical)
+}
+
+// cartridgeChunkSet is what a cartridge address holds: its CART header and
+// every DATA chunk sent for it
+type cartridgeChunkSet struct {
+	Header     *CARTHeader
+	CARTTx     string
+	CARTFrom   string
+	Expected   int
+	Variants   map[uint32][]*chunkVariant // newest first: loaders use the first
+	Foreign    int                        // DATA for other cartridge ids
+	OutOfRange int                        // DATA past the last chunk
+}
+
+// chunkLength returns the length chunk index must have
+func (s *cartridgeChunkSet) chunkLength(index uint32) int {
+	size := int(s.Header.ChunkSize)
+	if int(index) == s.Expected-1 {
+		return int(s.Header.TotalSize) - (s.Expected-1)*size
+	}
+	return size
+}
+
+// analyzeCartridgeChunks groups the DATA chunks of a cartridge address by
+// index. Transactions must be newest first; with publisher set, others'
+// transactions are ignored like loaders of curated catalogs do.
+func analyzeCartridgeChunks(txs []Transaction, publisher string) (*cartridgeChunkSet, error) {
+	set := &cartridgeChunkSet{Variants: make(map[uint32][]*chunkVariant)}
+	var fromPublisher []Transaction
+	for _, tx := range txs {
+		if publisher != "" && normalizeAddress(tx.From) != normalizeAddress(publisher) {
+			continue
+		}
+		fromPublisher = append(fromPublisher, tx)
+		if set.Header != nil {
+			continue
+		}
+		if header, err := DecodeCART(txPayload(tx)); err == nil {
+			set.Header, set.CARTTx, set.CARTFrom = header, tx.Hash, tx.From
+		}
+	}
+	if set.Header == nil {
+		return nil, errNoCARTHeader
+	}
+	if set.Header.Flags&CARTFlagSharded != 0 {
+		// The chunks are on the shard addresses
+		return set, nil
+	}
+	if err := set.collect(fromPublisher); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// collect groups the DATA chunks of txs (newest first) that belong to the
+// set's header by index
+func (s *cartridgeChunkSet) collect(txs []Transaction) error {
+	if s.Header.ChunkSize == 0 {
+		return fmt.Errorf("CART header has a chunk size of 0")
+	}
+	s.Expected = int((s.Header.TotalSize + uint64(s.Header.ChunkSize) - 1) / uint64(s.Header.ChunkSize))
+
+	for _, tx := range txs {
+		chunk, err := DecodeDATA(txPayload(tx))
+		if err != nil {
+			continue
+		}
+		if chunk.CartridgeID != s.Header.CartridgeID {
+			s.Foreign++
+			continue
+		}
+		if int(chunk.ChunkIndex) >= s.Expected {
+			s.OutOfRange++
+			continue
+		}
+		variants := s.Variants[chunk.ChunkIndex]
+		var found *chunkVariant
+		for _, v := range variants {
+			if bytes.Equal(v.Data, chunk.Data) {
+				found = v
+				break
+			}
+		}
+		if found == nil {
+			found = &chunkVariant{Data: chunk.Data, Valid: len(chunk.Data) == s.chunkLength(chunk.ChunkIndex)}
+			s.Variants[chunk.ChunkIndex] = append(variants, found)
+		}
+		found.TxHashes = append(found.TxHashes, tx.Hash)
+	}
+	return nil
+}
+
+// missing returns the chunk indexes with no DATA transaction
+func (s *cartridgeChunkSet) missing() []uint32 {
+	var missing []uint32
+	for i := 0; i < s.Expected; i++ {
+		if len(s.Variants[uint32(i)]) == 0 {
+			missing = append(missing, uint32(i))
+		}
+	}
+	return missing
+}
+
+// writeLoaded writes the chunks loaders pick (the newest of each index) to w
+func (s *cartridgeChunkSet) writeLoaded(w io.Writer) {
+	for i := 0; i < s.Expected; i++ {
+		if variants := s.Variants[uint32(i)]; len(variants) > 0 {
+			w.Write(variants[0].Data)
+		}
+	}
+}
+
+// loaderSHA256 hashes the chunks loaders pick
+func (s *cartridgeChunkSet) loaderSHA256() [32]byte {
+	h := sha256.New()
+	s.writeLoaded(h)
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// resolve finds the valid variant of every chunk such that the chunks hash to
+// the CART header's SHA256. Only indexes with several valid variants are
+// branched on, resuming from a saved hash state. It returns the chosen data
+// per index, or nil if no combination matches.
+func (s *cartridgeChunkSet) resolve() (map[uint32][]byte, error) {
+	if missing := s.missing(); len(missing) > 0 {
+		return nil, fmt.Errorf("%d chunks are missing; use --file to supply them", len(missing))
+	}
+
+	candidates := make(map[uint32][]*chunkVariant)
+	var conflicts []uint32
+	combinations := 1
+	for i := 0; i < s.Expected; i++ {
+		var valid []*chunkVariant
+		for _, v := range s.Variants[uint32(i)] {
+			if v.Valid {
+				valid = append(valid, v)
+			}
+		}
+		if len(valid) == 0 {
+			return nil, fmt.Errorf("chunk %d has no transaction of the right length; use --file to supply it", i)
+		}
+		candidates[uint32(i)] = valid
+		if len(valid) > 1 {
+			conflicts = append(conflicts, uint32(i))
+			if combinations *= len(valid); combinations > maxRepairCombinations {
+				return nil, fmt.Errorf("more than %d combinations of conflicting chunks; use --file to supply the original", maxRepairCombinations)
+			}
+		}
+	}
+
+	chosen := make(map[uint32][]byte)
+	var search func(h hash.Hash, from uint32, k int) bool
+	search = func(h hash.Hash, from uint32, k int) bool {
+		end := uint32(s.Expected)
+		if k < len(conflicts) {
+			end = conflicts[k]
+		}
+		for i := from; i < end; i++ {
+			h.Write(candidates[i][0].Data)
+		}
+		if k == len(conflicts) {
+			return bytes.Equal(h.Sum(nil), s.Header.SHA256[:])
+		}
+		state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			return false
+		}
+		for _, v := range candidates[end] {
+			branch := sha256.New()
+			if branch.(encoding.BinaryUnmarshaler).UnmarshalBinary(state) != nil {
+				return false
+			}
+			branch.Write(v.Data)
+			if search(branch, end+1, k+1) {
+				chosen[end] = v.Data
+				return true
+			}
+		}
+		return false
+	}
+	if !search(sha256.New(), 0, 0) {
+		return nil, nil
+	}
+	for i, v := range candidates {
+		if _, ok := chosen[i]; !ok {
+			chosen[i] = v[0].Data
+		}
+	}
+	return chosen, nil
+}
+
+// canonicalFromChunks reads the canonical chunks from the original file, or
+// the part of it stored at the address, which must match the CART header
+func (s *cartridgeChunkSet) canonicalFromChunks(chunks *FileChunks) (map[uint32][]byte, error) {
+	sum, err := chunks.SHA256()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash the file: %w", err)
+	}
+	if sum != s.Header.SHA256 || chunks.Size() != int64(s.Header.TotalSize) {
+		return nil, fmt.Errorf("the file doesn't match the CART header (SHA256 %x, %d bytes)", s.Header.SHA256, s.Header.TotalSize)
+	}
+	canonical := make(map[uint32][]byte, s.Expected)
+	for i := 0; i < s.Expected; i++ {
+		data, err := chunks.Chunk(uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		canonical[uint32(i)] = data
+	}
+	return canonical, nil
+}
+
+// chunkStats counts what is wrong with a chunk set
+type chunkStats struct {
+	Duplicates  int      // indexes sent more than once with the same data only
+	ExtraTxs    int      // transactions beyond one per distinct payload
+	Conflicting []uint32 // indexes with different data, ascending
+	Garbled     int      // transactions with the wrong length for their index
+}
+
+func (s *cartridgeChunkSet) stats() chunkStats {
+	var st chunkStats
+	for index, variants := range s.Variants {
+		txs := 0
+		for _, v := range variants {
+			txs += len(v.TxHashes)
+			if !v.Valid {
+				st.Garbled += len(v.TxHashes)
+			}
+		}
+		if len(variants) > 1 {
+			st.Conflicting = append(st.Conflicting, index)
+		} else if txs > 1 {
+			st.Duplicates++
+		}
+		st.ExtraTxs += txs - len(variants)
+	}
+	sort.Slice(st.Conflicting, func(i, j int) bool { return st.Conflicting[i] < st.Conflicting[j] })
+	return st
+}
+
+// printCartridgeChunkReport prints the duplicates and conflicts of a chunk set
+func printCartridgeChunkReport(addr string, s *cartridgeChunkSet) {
+	st := s.stats()
+
+	resultf("Cartridge %s\n", addr)
+	cartTx := s.CARTTx
+	if cartTx == "" {
+		cartTx = "missing, rebuilt from --file"
+	}
+	resultf("  CART:        %s (cartridge %d, %d bytes, chunk size %d)\n", cartTx, s.Header.CartridgeID, s.Header.TotalSize, s.Header.ChunkSize)
+	resultf("  Chunks:      %d/%d present\n", len(s.Variants), s.Expected)
+	resultf("  Duplicates:  %d indexes sent more than once with the same data (%d extra transactions)\n", st.Duplicates, st.ExtraTxs)
+	resultf("  Conflicts:   %d indexes with different data\n", len(st.Conflicting))
+	if st.Garbled > 0 {
+		resultf("  Garbled:     %d transactions with the wrong length for their index\n", st.Garbled)
+	}
+	if s.OutOfRange > 0 {
+		resultf("  Out of range: %d DATA transactions past the last chunk\n", s.OutOfRange)
+	}
+	if s.Foreign > 0 {
+		resultf("  Other ids:   %d DATA transactions for other cartridge ids\n", s.Foreign)
+	}
+
+	for _, index := range st.Conflicting {
+		resultf("\n  Chunk %d:\n", index)
+		for i, v := range s.Variants[index] {
+			marker := "        "
+			if i == 0 {
+				marker = "[loaded]"
+			}
+			note := ""
+			if !v.Valid {
+				note = fmt.Sprintf(" (%d bytes, expected %d)", len(v.Data), s.chunkLength(index))
+			}
+			resultf("    %s %s%s", marker, v.TxHashes[0], note)
+			if len(v.TxHashes) > 1 {
+				resultf(" (+%d duplicates)", len(v.TxHashes)-1)
+			}
+			resultln("")
+		}
+	}
+	if missing := s.missing(); len(missing) > 0 {
+		resultf("\n  Missing chunks: %v\n", missing)
+	}
+}
+
+// cartridgeRepair is one run of repair-cartridge, which can span the shard
+// addresses of a sharded cartridge
+type cartridgeRepair struct {
+	rpc       *NimiqRPC
+	rpcURL    string
+	publisher string
+	filePath  string
+	fix       bool
+	sender    string
+	fee       int64
+	rateLimit float64
+	tmpDir    string
+
+	// Layout of an upload that stopped before its CART header (0: inferred
+	// from the DATA on the address)
+	cartridgeID uint32
+	chunkSize   uint8
+	platform    uint8
+	compress    uint8
+
+	control    *UploadControl
+	accounting UploadAccounting
+	pending    int  // chunks and headers that need a corrective upload
+	sentCART   bool // a missing CART header was sent
+}
+
+// storedFile returns the file whose bytes the cartridge stores: --file, or
+// --file compressed like the upload when it is the uncompressed original of
+// a compressed cartridge
+func (r *cartridgeRepair) storedFile(header *CARTHeader) (string, error) {
+	if !header.Compressed() {
+		return r.filePath, nil
+	}
+	sum, size, err := CalculateFileSHA256(r.filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", r.filePath, err)
+	}
+	if sum == header.SHA256 || uint64(size) != uint64(header.UncompressedSize) || !bytes.Equal(sum[:8], header.UncompressedSHA256[:]) {
+		// Already the stored file, or not the original at all (reported later)
+		return r.filePath, nil
+	}
+	path, _, err := compressCartridgeFile(r.filePath, r.tmpDir, header.CartridgeID, header.Flags&CARTCompressionMask)
+	return path, err
+}
+
+// unfinishedSet builds the chunk set of an address whose upload stopped
+// before the CART header, with the header that upload would have sent. The
+// cartridge id and chunk size come from the DATA already on the address
+// unless --cartridge-id and --chunk-size are given. It returns the set and
+// the stored file.
+func (r *cartridgeRepair) unfinishedSet(txs []Transaction) (*cartridgeChunkSet, string, error) {
+	var fromPublisher []Transaction
+	counts := make(map[uint32]int)
+	longest := make(map[uint32]int)
+	for _, tx := range txs {
+		if r.publisher != "" && normalizeAddress(tx.From) != normalizeAddress(r.publisher) {
+			continue
+		}
+		fromPublisher = append(fromPublisher, tx)
+		if chunk, err := DecodeDATA(txPayload(tx)); err == nil {
+			counts[chunk.CartridgeID]++
+			longest[chunk.CartridgeID] = max(longest[chunk.CartridgeID], len(chunk.Data))
+		}
+	}
+
+	cartridgeID := r.cartridgeID
+	if cartridgeID == 0 {
+		switch len(counts) {
+		case 0:
+			return nil, "", fmt.Errorf("no CART header and no DATA chunks on the address; pass --cartridge-id to upload the whole file")
+		case 1:
+			for id := range counts {
+				cartridgeID = id
+			}
+		default:
+			ids := make([]uint32, 0, len(counts))
+			for id := range counts {
+				ids = append(ids, id)
+			}
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+			return nil, "", fmt.Errorf("the address has DATA chunks of several cartridge ids %v; pick one with --cartridge-id", ids)
+		}
+	}
+	chunkSize := r.chunkSize
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+		if n := longest[cartridgeID]; n > 0 {
+			chunkSize = uint8(n)
+		}
+		statusf("Chunk size: %d bytes (from the DATA on the address; --chunk-size overrides)\n", chunkSize)
+	}
+
+	path := r.filePath
+	var compression CARTCompression
+	if r.compress != 0 {
+		var err error
+		if path, compression, err = compressCartridgeFile(r.filePath, r.tmpDir, cartridgeID, r.compress); err != nil {
+			return nil, "", err
+		}
+	}
+	sum, size, err := CalculateFileSHA256(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	header := &CARTHeader{
+		Schema:      1,
+		Platform:    r.platform,
+		ChunkSize:   chunkSize,
+		CartridgeID: cartridgeID,
+		TotalSize:   uint64(size),
+		SHA256:      sum,
+	}
+	compression.Apply(header)
+
+	set := &cartridgeChunkSet{Header: header, Variants: make(map[uint32][]*chunkVariant)}
+	if err := set.collect(fromPublisher); err != nil {
+		return nil, "", err
+	}
+	return set, path, nil
+}
+
+// repairSharded repairs every shard of a sharded cartridge. With --file the
+// shards are checked against their part of the file, and shards whose upload
+// stopped before their CART header get it rebuilt.
+func (r *cartridgeRepair) repairSharded(ctx context.Context, primary *cartridgeChunkSet, txs []Transaction) error {
+	links, count := newestShardLinks(txs, r.publisher, primary.Header.CartridgeID)
+	if count == 0 {
+		return fmt.Errorf("sharded CART header but no SHRD records")
+	}
+	resultf("Sharded cartridge: %d shards (cartridge %d, %d bytes)\n", count, primary.Header.CartridgeID, primary.Header.TotalSize)
+
+	var file *FileChunks
+	if r.filePath != "" {
+		path, err := r.storedFile(primary.Header)
+		if err != nil {
+			return err
+		}
+		if file, err = OpenFileChunks(path, int(primary.Header.ChunkSize)); err != nil {
+			return err
+		}
+		defer file.Close()
+		sum, err := file.SHA256()
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", path, err)
+		}
+		if sum != primary.Header.SHA256 || file.Size() != int64(primary.Header.TotalSize) {
+			return fmt.Errorf("%s doesn't match the CART header (SHA256 %x, %d bytes)", r.filePath, primary.Header.SHA256, primary.Header.TotalSize)
+		}
+	}
+
+	// Shards were uploaded with the primary's settings, before compression
+	// raised its schema
+	schema := primary.Header.Schema
+	if primary.Header.Compressed() {
+		schema = 1
+	}
+	for i := uint16(0); i < count; i++ {
+		link, ok := links[i]
+		if !ok {
+			return fmt.Errorf("SHRD record of shard %d/%d missing", i+1, count)
+		}
+		shardAddr := BytesToAddressNQ(link.ShardAddr)
+		shardTxs, err := GetAllTransactionsByAddress(r.rpc, shardAddr, 500)
+		if err != nil {
+			return fmt.Errorf("failed to query shard %d (%s): %w", i, shardAddr, err)
+		}
+		var section *FileChunks
+		if file != nil {
+			section = file.Section(int64(link.Offset), int64(link.Size))
+		}
+
+		shard, err := analyzeCartridgeChunks(shardTxs, r.publisher)
+		if errors.Is(err, errNoCARTHeader) && section != nil {
+			sum, hashErr := section.SHA256()
+			if hashErr != nil {
+				return fmt.Errorf("failed to hash shard %d: %w", i, hashErr)
+			}
+			shard = &cartridgeChunkSet{
+				Header: &CARTHeader{
+					Schema:      schema,
+					Platform:    primary.Header.Platform,
+					ChunkSize:   primary.Header.ChunkSize,
+					CartridgeID: primary.Header.CartridgeID,
+					TotalSize:   uint64(link.Size),
+					SHA256:      sum,
+				},
+				Variants: make(map[uint32][]*chunkVariant),
+			}
+			err = shard.collect(shardTxs)
+		}
+		if err != nil {
+			return fmt.Errorf("shard %d (%s): %w", i, shardAddr, err)
+		}
+		if shard.Header.TotalSize != uint64(link.Size) {
+			return fmt.Errorf("shard %d holds %d bytes, its SHRD record says %d", i, shard.Header.TotalSize, link.Size)
+		}
+
+		resultf("\n=== Shard %d/%d ===\n", i+1, count)
+		if err := r.repairAddress(ctx, shardAddr, shard, section); err != nil {
+			return fmt.Errorf("shard %d (%s): %w", i, shardAddr, err)
+		}
+	}
+	return nil
+}
+
+// repairAddress reports the chunk set of one address and, with --fix, sends
+// the chunks loaders get wrong or miss, then the CART header if it is
+// missing. chunks is the part of --file stored at the address (nil without
+// --file).
+func (r *cartridgeRepair) repairAddress(ctx context.Context, addr string, set *cartridgeChunkSet, chunks *FileChunks) error {
+	printCartridgeChunkReport(addr, set)
+
+	var canonical map[uint32][]byte
+	var err error
+	if chunks != nil {
+		if canonical, err = set.canonicalFromChunks(chunks); err != nil {
+			return err
+		}
+		resultf("\nCanonical chunks: read from %s\n", r.filePath)
+	} else if canonical, err = set.resolve(); err != nil {
+		resultf("\n⚠️  Can't determine the canonical chunks: %v\n", err)
+	} else if canonical == nil {
+		resultf("\n✗ No combination of the chunks on chain matches the CART header's SHA256; use --file to supply the original\n")
+	} else {
+		resultf("\n✓ Canonical chunks: found the combination matching the CART header's SHA256\n")
+	}
+
+	if set.CARTTx != "" && set.loaderSHA256() == set.Header.SHA256 && len(set.missing()) == 0 {
+		resultf("✓ Loaders reconstruct the file correctly\n")
+		return nil
+	}
+	if set.CARTTx == "" {
+		resultf("✗ The CART header is missing, so loaders can't find the file\n")
+	} else {
+		resultf("✗ Loaders can't reconstruct the file (missing chunks or wrong data)\n")
+	}
+	if canonical == nil {
+		return nil
+	}
+
+	var bad []uint32
+	for i := 0; i < set.Expected; i++ {
+		variants := set.Variants[uint32(i)]
+		if len(variants) == 0 || !bytes.Equal(variants[0].Data, canonical[uint32(i)]) {
+			bad = append(bad, uint32(i))
+		}
+	}
+	resultf("  %d chunks need a corrective upload: %v\n", len(bad), bad)
+	r.pending += len(bad)
+	if set.CARTTx == "" {
+		resultf("  The CART header is sent after them\n")
+		r.pending++
+	}
+	if !r.fix {
+		return nil
+	}
+	return r.send(ctx, addr, set, canonical, bad)
+}
+
+// prepareFix checks the sender and the node before the first corrective
+// transaction
+func (r *cartridgeRepair) prepareFix() error {
+	if r.control != nil {
+		return nil
+	}
+	if r.sender == "" {
+		r.sender = GetDefaultAddress()
+	}
+	if r.sender == "" {
+		return fmt.Errorf("sender address is required (--sender or set in account_credentials.txt)")
+	}
+	consensus, err := r.rpc.IsConsensusEstablished()
+	if err != nil {
+		return fmt.Errorf("failed to check consensus: %w", err)
+	}
+	if !consensus {
+		return fmt.Errorf("node does not have consensus with the network - cannot upload. Wait for sync")
+	}
+	r.control = StartUploadControl("", "repair-cartridge", r.rateLimit, 1)
+	r.accounting.FeeLuna = r.fee
+	return nil
+}
+
+// send sends the canonical data of the bad chunks to addr, then the CART
+// header if the address has none
+func (r *cartridgeRepair) send(ctx context.Context, addr string, set *cartridgeChunkSet, canonical map[uint32][]byte, bad []uint32) error {
+	if err := r.prepareFix(); err != nil {
+		return err
+	}
+	if set.CARTFrom != "" && normalizeAddress(r.sender) != normalizeAddress(set.CARTFrom) {
+		return fmt.Errorf("corrective chunks must come from %s, which sent the CART header (--sender)", set.CARTFrom)
+	}
+	rpcSender, err := NewRPCSender(r.rpcURL, r.sender, addr, r.fee)
+	if err != nil {
+		return fmt.Errorf("failed to initialize RPC sender: %w", err)
+	}
+
+	for _, index := range bad {
+		data := canonical[index]
+		encoded, err := EncodeDATA(DATAPayload{
+			CartridgeID: set.Header.CartridgeID,
+			ChunkIndex:  index,
+			Length:      uint8(len(data)),
+			Data:        data,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode chunk %d: %w", index, err)
+		}
+		if err := r.control.Wait(ctx); err != nil {
+			return err
+		}
+		txHash, err := rpcSender.SendTransaction(encoded)
+		if err != nil {
+			return fmt.Errorf("failed to send chunk %d: %w", index, err)
+		}
+		r.accounting.ToCartridge++
+		statusf("Sent chunk %d: %s\n", index, txHash)
+	}
+
+	if set.CARTTx == "" {
+		// Like uploads, the header goes last so it is among the newest
+		// transactions loaders read
+		cartPayload, err := EncodeCART(*set.Header)
+		if err != nil {
+			return fmt.Errorf("failed to encode CART header: %w", err)
+		}
+		if err := r.control.Wait(ctx); err != nil {
+			return err
+		}
+		txHash, err := rpcSender.SendTransaction(cartPayload)
+		if err != nil {
+			return fmt.Errorf("failed to send CART header: %w", err)
+		}
+		r.accounting.ToCartridge++
+		r.sentCART = true
+		statusf("Sent CART header: %s\n", txHash)
+	}
+	return nil
+}
+
+// newRepairCartridgeCmd creates the repair-cartridge command
+func newRepairCartridgeCmd() *cobra.Command {
+	var (
+		cartridgeAddr string
+		compress      string
+		r             = &cartridgeRepair{}
+	)
+
+	cmd := &cobra.Command{
+		Use:   "repair-cartridge",
+		Short: "Find missing, duplicate and conflicting chunks of a cartridge and re-upload bad ones",
+		Long: `Analyze the DATA chunks on a cartridge address: chunk indexes sent more
+than once with the same data (duplicates), indexes with different data
+(conflicts), chunks of the wrong length and missing chunks.
+
+Loaders use the newest transaction of each index. The canonical chunk set is
+the one matching the CART header's SHA256: with --file it is read from the
+original file, otherwise it is found by hashing the combinations of
+conflicting chunks. For compressed cartridges --file may be the original,
+uncompressed file; it is compressed like the upload.
+
+An upload that failed part way has no CART header yet (it is sent after the
+chunks). With --file, the header that upload would have sent is rebuilt: the
+cartridge id and chunk size are taken from the DATA already on the address
+(or --cartridge-id and --chunk-size), and --platform and --compress must
+match the upload. Sharded cartridges are repaired shard by shard.
+
+With --fix, every index where loaders would pick the wrong data (or nothing)
+gets a new DATA transaction with the canonical data, followed by the CART
+header if it is missing. They must be sent by the account that sent the CART
+header so that publisher-filtered loaders accept them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cartridgeAddr == "" {
+				return fmt.Errorf("cartridge address is required (--cartridge-addr)")
+			}
+			if err := ValidateAddressNQ(cartridgeAddr); err != nil {
+				return fmt.Errorf("invalid cartridge address %s: %w", cartridgeAddr, err)
+			}
+			var err error
+			if r.compress, err = ParseCompression(compress); err != nil {
+				return err
+			}
+			if r.rpcURL == "" {
+				r.rpcURL = GetDefaultRPCURL()
+			}
+			r.rpc = NewNimiqRPC(r.rpcURL)
+			if r.filePath != "" {
+				// Compressed copies of --file are written here
+				if r.tmpDir, err = os.MkdirTemp("", "repair-cartridge-"); err != nil {
+					return fmt.Errorf("failed to create temporary directory: %w", err)
+				}
+				defer os.RemoveAll(r.tmpDir)
+			}
+
+			txs, err := GetAllTransactionsByAddress(r.rpc, cartridgeAddr, 500)
+			if err != nil {
+				return fmt.Errorf("failed to query cartridge address: %w", err)
+			}
+			set, err := analyzeCartridgeChunks(txs, r.publisher)
+			storedPath := ""
+			if errors.Is(err, errNoCARTHeader) {
+				if r.filePath == "" {
+					return fmt.Errorf("%w; if its upload stopped before the header, pass the original with --file to rebuild it", err)
+				}
+				set, storedPath, err = r.unfinishedSet(txs)
+			}
+			if err != nil {
+				return err
+			}
+
+			if set.Header.Flags&CARTFlagSharded != 0 {
+				err = r.repairSharded(cmd.Context(), set, txs)
+			} else {
+				var chunks *FileChunks
+				if r.filePath != "" {
+					if storedPath == "" {
+						if storedPath, err = r.storedFile(set.Header); err != nil {
+							return err
+						}
+					}
+					if chunks, err = OpenFileChunks(storedPath, int(set.Header.ChunkSize)); err != nil {
+						return err
+					}
+					defer chunks.Close()
+				}
+				err = r.repairAddress(cmd.Context(), cartridgeAddr, set, chunks)
+			}
+			if r.control != nil {
+				r.control.Close()
+			}
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case r.pending == 0:
+			case !r.fix:
+				statusf("\n💡 Run again with --fix to send them\n")
+			default:
+				statusf("\n✓ Sent %d corrective transactions\n", r.accounting.ToCartridge)
+				r.accounting.PrintReport()
+				if r.sentCART {
+					statusf("💡 If the upload also never sent its CENT entry, run upload-cartridge again with the same arguments; it picks up the transactions on chain and only sends what is left\n")
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&cartridgeAddr, "cartridge-addr", "", "Cartridge address to analyze (required)")
+	cmd.Flags().StringVar(&r.publisher, "publisher", "", "Only consider transactions from this address, like loaders of curated catalogs")
+	cmd.Flags().StringVar(&r.filePath, "file", "", "Original file, to take the canonical chunks from (needed when chunks are missing or garbled, or the CART header is)")
+	cmd.Flags().BoolVar(&r.fix, "fix", false, "Send corrective DATA transactions for the chunks loaders get wrong, and a missing CART header")
+	cmd.Flags().StringVar(&r.sender, "sender", "", "Sender address for --fix (must have sent the CART header; defaults to ADDRESS from account_credentials.txt)")
+	cmd.Flags().StringVar(&r.rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().Int64Var(&r.fee, "fee", 0, "Transaction fee in Luna (default: 0, minimum)")
+	cmd.Flags().Float64Var(&r.rateLimit, "rate", 25, "Transaction rate limit for --fix (tx/s)")
+	cmd.Flags().Uint32Var(&r.cartridgeID, "cartridge-id", 0, "Without a CART header: cartridge id of the upload (default: from the DATA on the address)")
+	cmd.Flags().Uint8Var(&r.chunkSize, "chunk-size", 0, "Without a CART header: chunk size of the upload in bytes (default: from the DATA on the address)")
+	cmd.Flags().Uint8Var(&r.platform, "platform", 0, "Without a CART header: platform code of the upload (0=DOS, 1=GB, 2=GBC, 3=NES)")
+	cmd.Flags().StringVar(&compress, "compress", "none", "Without a CART header: compression of the upload (none, gzip or zstd)")
+
+	return cmd
+}
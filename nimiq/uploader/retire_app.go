@@ -15,6 +15,7 @@ func newRetireAppCmd() *cobra.Command {
 		catalogAddr string
 		sender      string
 		dryRun      bool
+		forceUnlock bool
 		rateLimit   float64
 		rpcURL      string
 		fee         int64
@@ -59,6 +60,7 @@ The command will:
 
 			// Initialize RPC
 			rpc := NewNimiqRPC(rpcURL)
+			warnNetworkMismatch(rpc, catalogAddr)
 
 			// Find the latest version of this app
 			normalizedPublisher := normalizeAddress(sender)
@@ -124,29 +126,14 @@ The command will:
 
 				// Check if this is the latest version
 				if latestEntry == nil || height > latestHeight {
-					// Extract cartridge address
-					var cartAddr [20]byte
-					copy(cartAddr[:], data[14:34])
-
-					// Extract title
-					titleBytes := data[34:50]
-					title := ""
-					for i := 0; i < 16; i++ {
-						if titleBytes[i] == 0 {
-							break
-						}
-						title += string(titleBytes[i])
-					}
-
-					latestEntry = &CENTEntry{
-						Schema:        data[4],
-						Platform:      data[5],
-						Flags:         flags | FlagRetired, // Set retired flag
-						AppID:         appID,
-						Semver:        semver,
-						CartridgeAddr: cartAddr,
-						TitleShort:    title,
+					// Copied as decoded, so a CENT v2 entry keeps pointing
+					// at its metadata records
+					entry, err := DecodeCENT(data)
+					if err != nil {
+						continue
 					}
+					entry.Flags |= FlagRetired
+					latestEntry = entry
 					latestHeight = height
 					latestSemver = semver
 				}
@@ -169,6 +156,12 @@ The command will:
 				return nil
 			}
 
+			catalogLock, err := AcquireLock(catalogLockPath(catalogAddr), "retire-app", forceUnlock)
+			if err != nil {
+				return err
+			}
+			defer catalogLock.Release()
+
 			// Encode CENT entry with retired flag
 			centPayload, err := EncodeCENT(*latestEntry)
 			if err != nil {
@@ -191,7 +184,8 @@ The command will:
 				return fmt.Errorf("failed to send CENT entry: %w", err)
 			}
 
-			fmt.Printf("✓ CENT entry sent with retired flag: %s\n", txHash)
+			statusf("✓ CENT entry sent with retired flag: %s\n", txHash)
+			printExplorerLink("  ", networkForCatalog(catalogAddr), LinkTx, txHash)
 			fmt.Printf("\nApp ID %d is now retired and will be filtered out from catalog listings.\n", appID)
 
 			return nil
@@ -199,9 +193,10 @@ The command will:
 	}
 
 	cmd.Flags().Uint32Var(&appID, "app-id", 0, "App ID to retire (required)")
-	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address (NQ..., 'main', 'test', required)")
+	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address or alias (NQ..., 'main', 'test', see catalog-alias list; required)")
 	cmd.Flags().StringVar(&sender, "sender", "", "Sender address (defaults to ADDRESS from account_credentials.txt)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Dry-run mode (show what would be sent)")
+	cmd.Flags().BoolVar(&forceUnlock, "force-unlock", false, "Take over the catalog lock left by another run")
 	cmd.Flags().Float64Var(&rateLimit, "rate", 25.0, "Transaction rate limit (tx/s, default: 25)")
 	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
 	cmd.Flags().Int64Var(&fee, "fee", 0, "Transaction fee in Luna (default: 0, minimum)")
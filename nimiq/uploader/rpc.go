@@ -17,10 +17,8 @@ type NimiqRPC struct {
 
 func NewNimiqRPC(url string) *NimiqRPC {
 	return &NimiqRPC{
-		url: url,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		url:    url,
+		client: newHTTPClient(30 * time.Second),
 	}
 }
 
@@ -413,6 +411,34 @@ func (rpc *NimiqRPC) GetBlockNumber() (int64, error) {
 	return 0, fmt.Errorf("failed to parse block number: unexpected format: %s", string(result))
 }
 
+// GetNetworkID returns the network the node is connected to, as reported in
+// the "network" field of the latest block (e.g. "MainAlbatross")
+func (rpc *NimiqRPC) GetNetworkID() (string, error) {
+	result, err := rpc.Call("getLatestBlock", map[string]interface{}{
+		"includeBody": false,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest block: %w", err)
+	}
+
+	var responseObj map[string]interface{}
+	if err := json.Unmarshal(result, &responseObj); err != nil {
+		return "", fmt.Errorf("failed to parse block response: %w", err)
+	}
+
+	// Try to get block from "data" field first (Nimiq RPC format)
+	blockObj := responseObj
+	if data, ok := responseObj["data"].(map[string]interface{}); ok {
+		blockObj = data
+	}
+
+	network, ok := blockObj["network"].(string)
+	if !ok || network == "" {
+		return "", fmt.Errorf("network field not found in block response: %s", string(result))
+	}
+	return network, nil
+}
+
 // SendBasicTransactionWithData sends a transaction with data field
 func (rpc *NimiqRPC) SendBasicTransactionWithData(wallet, recipient, data string, value, fee, validityStartHeight int64) (string, error) {
 	// Try with object params first
@@ -498,6 +524,28 @@ func (rpc *NimiqRPC) SendBasicTransactionWithData(wallet, recipient, data string
 	return "", fmt.Errorf("no transaction hash found in response: %s", string(result))
 }
 
+// SendRawTransaction broadcasts a signed, serialized transaction (hex)
+func (rpc *NimiqRPC) SendRawTransaction(rawTx string) (string, error) {
+	result, err := rpc.Call("sendRawTransaction", map[string]interface{}{
+		"rawTx": rawTx,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var hash string
+	if err := json.Unmarshal(result, &hash); err == nil && hash != "" {
+		return hash, nil
+	}
+	var response struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(result, &response); err == nil && response.Data != "" {
+		return response.Data, nil
+	}
+	return "", fmt.Errorf("no transaction hash found in response: %s", string(result))
+}
+
 // parseHexInt64 parses a hex string to int64
 func parseHexInt64(hexStr string) (int64, error) {
 	// Remove 0x prefix if present
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/retro-crypto/shared/selfupdate"
+	"github.com/spf13/cobra"
+)
+
+// Self-update reads releases from the GitHub release feed and installs them
+// with the selfupdate package shared with catalogctl (signed checksums.txt
+// naming the release, see its package comment).
+const (
+	// releaseTagPrefix starts the tags of uploader releases in the feed
+	releaseTagPrefix = "nimiq-uploader-v"
+
+	releaseBinary = "nimiq-uploader"
+)
+
+func newSelfUpdateCmd() *cobra.Command {
+	var (
+		check      bool
+		version    string
+		prerelease bool
+		force      bool
+		publicKey  string
+		feed       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update nimiq-uploader to the latest release",
+		Long: `Check the release feed for a newer nimiq-uploader, download the binary for
+this platform and replace the running executable with it.
+
+The release's checksums.txt must carry a valid signature (checksums.txt.sig,
+made with cosign sign-blob or an Ed25519 key) from the release public key
+built into nimiq-uploader, name the release's tag, and the binary must match
+its checksum; otherwise nothing is replaced. The new binary is renamed over
+the old one, so an interrupted update leaves the old one working.
+
+Only newer releases are installed: reinstalling the current version or
+downgrading with --version needs --force.
+
+Development builds have no release key built in; pass the key with
+--public-key. They are only replaced with --force or --version.`,
+		Example: `  nimiq-uploader self-update --check
+  nimiq-uploader self-update
+  nimiq-uploader self-update --version 1.4.0`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if feed == "" {
+				feed = os.Getenv("NIMIQ_UPLOADER_UPDATE_FEED")
+			}
+			if feed == "" {
+				feed = selfupdate.DefaultFeed
+			}
+			client := newHTTPClient(5 * time.Minute)
+
+			release, err := selfupdate.FindRelease(client, feed, releaseTagPrefix, version, prerelease)
+			if err != nil {
+				return err
+			}
+			newer := selfupdate.CompareVersions(release.Version, Version) > 0
+
+			resultf("Current version: %s\n", Version)
+			resultf("Latest release:  %s (%s)\n", release.Version, release.Tag)
+			if version != "" {
+				resultf("Requested:       %s\n", version)
+			}
+			if check {
+				if newer {
+					statusln("💡 Run nimiq-uploader self-update to install it")
+				} else if selfupdate.IsRelease(Version) {
+					statusln("✓ nimiq-uploader is up to date")
+				}
+				return nil
+			}
+
+			if version == "" && !force {
+				if !selfupdate.IsRelease(Version) {
+					return fmt.Errorf("this is a development build (%s); pass --force to replace it with %s", Version, release.Version)
+				}
+				if !newer {
+					statusln("✓ nimiq-uploader is up to date")
+					return nil
+				}
+			}
+			if err := selfupdate.CheckTarget(release.Version, Version, force); err != nil {
+				return err
+			}
+
+			key, err := loadReleaseKey(publicKey)
+			if err != nil {
+				return err
+			}
+			path, err := selfupdate.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to locate the running executable: %w", err)
+			}
+
+			statusf("Downloading %s...\n", selfupdate.AssetName(releaseBinary))
+			bin, err := release.Download(client, releaseBinary, key)
+			if err != nil {
+				return err
+			}
+			statusf("✓ Signature, release %s and checksum verified\n", release.Tag)
+			if err := selfupdate.Replace(path, bin); err != nil {
+				return fmt.Errorf("failed to replace %s: %w", path, err)
+			}
+			resultf("✓ Updated %s: %s → %s\n", path, Version, release.Version)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "Only report whether a newer release exists")
+	cmd.Flags().StringVar(&version, "version", "", "Install this release instead of the latest (older ones need --force)")
+	cmd.Flags().BoolVar(&prerelease, "prerelease", false, "Consider prereleases as the latest release")
+	cmd.Flags().BoolVar(&force, "force", false, "Install even if the release isn't newer (reinstall or downgrade) or this is a development build")
+	cmd.Flags().StringVar(&publicKey, "public-key", "", "PEM file with the release public key (default: the key built in)")
+	cmd.Flags().StringVar(&feed, "feed", "", "Release feed URL (default: $NIMIQ_UPLOADER_UPDATE_FEED or the GitHub releases of this repository)")
+
+	return cmd
+}
+
+// loadReleaseKey reads the key in file, or the one built in
+func loadReleaseKey(file string) (selfupdate.PublicKey, error) {
+	s := ReleasePublicKey
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return selfupdate.PublicKey{}, fmt.Errorf("failed to read public key: %w", err)
+		}
+		s = string(data)
+	} else if s == "" {
+		return selfupdate.PublicKey{}, fmt.Errorf("this build has no release public key to verify downloads with; pass --public-key")
+	}
+	return selfupdate.ParsePublicKey(s)
+}
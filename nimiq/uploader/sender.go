@@ -25,9 +25,14 @@ type RPCSender struct {
 	senderAddress   string
 	receiverAddress string
 	fee             int64
+	// signer signs transactions locally (--signer local); nil uses the
+	// node's wallet
+	signer *LocalSigner
 }
 
-// NewRPCSender creates a new RPC sender and verifies account status
+// NewRPCSender creates a new RPC sender and verifies account status. With
+// --signer local it loads the sender's key instead, and the node needs no
+// wallet access.
 func NewRPCSender(rpcURL, senderAddress, receiverAddress string, fee int64) (*RPCSender, error) {
 	rpc := NewNimiqRPC(rpcURL)
 
@@ -43,6 +48,15 @@ func NewRPCSender(rpcURL, senderAddress, receiverAddress string, fee int64) (*RP
 		fee:             fee,
 	}
 
+	if signerMode == signerLocal {
+		signer, err := NewLocalSigner(rpc, senderAddress)
+		if err != nil {
+			return nil, err
+		}
+		sender.signer = signer
+		return sender, nil
+	}
+
 	// Check if account is imported
 	imported, err := rpc.IsAccountImported(senderAddress)
 	if err != nil {
@@ -58,13 +72,25 @@ func NewRPCSender(rpcURL, senderAddress, receiverAddress string, fee int64) (*RP
 		return nil, fmt.Errorf("failed to check if account is unlocked: %w", err)
 	}
 	if !unlocked {
-		return nil, fmt.Errorf("account %s is locked. Please unlock it first", senderAddress)
+		ok, err := sessionUnlock(rpc, senderAddress)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("account %s is locked. Please unlock it first (or set PASSPHRASE in credentials.json or NIMIQ_PASSPHRASE)", senderAddress)
+		}
 	}
 
 	return sender, nil
 }
 
 func (r *RPCSender) SendTransaction(payload []byte) (string, error) {
+	if r.signer == nil {
+		if err := renewSessionUnlock(r.senderAddress); err != nil {
+			return "", err
+		}
+	}
+
 	// Check consensus before sending transaction
 	consensus, err := r.rpc.IsConsensusEstablished()
 	if err != nil {
@@ -80,20 +106,26 @@ func (r *RPCSender) SendTransaction(payload []byte) (string, error) {
 		return "", fmt.Errorf("failed to get block height: %w", err)
 	}
 
-	// Encode payload as hex string
-	dataHex := hex.EncodeToString(payload)
-
-	// Send transaction with data
 	// Value must be > 0 for transactions with data (RPC requirement: "value must be zero for signaling transactions and cannot be zero for others")
 	// Use 1 Luna (smallest unit) as the value
-	txHash, err := r.rpc.SendBasicTransactionWithData(
-		r.senderAddress,   // wallet (sender)
-		r.receiverAddress, // recipient (receiver address)
-		dataHex,           // data (hex-encoded payload)
-		1,                 // value (1 Luna - minimum required for data transactions)
-		r.fee,             // fee (configurable)
-		blockHeight,       // validityStartHeight
-	)
+	var txHash string
+	if r.signer != nil {
+		rawTx, signErr := r.signer.SignBasicTransaction(r.receiverAddress, payload, 1, r.fee, blockHeight)
+		if signErr != nil {
+			return "", fmt.Errorf("failed to sign transaction: %w", signErr)
+		}
+		txHash, err = r.rpc.SendRawTransaction(rawTx)
+	} else {
+		// Send transaction with data (hex-encoded payload) from the node's wallet
+		txHash, err = r.rpc.SendBasicTransactionWithData(
+			r.senderAddress,             // wallet (sender)
+			r.receiverAddress,           // recipient (receiver address)
+			hex.EncodeToString(payload), // data (hex-encoded payload)
+			1,                           // value (1 Luna - minimum required for data transactions)
+			r.fee,                       // fee (configurable)
+			blockHeight,                 // validityStartHeight
+		)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to send transaction: %w", err)
 	}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================================
+// Session unlock
+// ============================================================================
+
+// DefaultUnlockDuration bounds how long an account stays unlocked on the
+// node, in seconds, unless a duration is given explicitly
+const DefaultUnlockDuration = 3600
+
+// sessionRenewMargin is how long before a session unlock expires it is
+// renewed, so long uploads never hit a locked account
+const sessionRenewMargin = 5 * time.Minute
+
+// sessionAccount is an account the uploader unlocked itself and relocks on exit
+type sessionAccount struct {
+	rpc        *NimiqRPC
+	passphrase string
+	expires    time.Time
+}
+
+var (
+	sessionMu       sync.Mutex
+	sessionAccounts = make(map[string]*sessionAccount)
+	sessionSignals  sync.Once
+)
+
+// unlockPassphrase returns the passphrase from credentials.json or
+// NIMIQ_PASSPHRASE
+func unlockPassphrase() string {
+	if passphrase := GetDefaultPassphrase(); passphrase != "" {
+		return passphrase
+	}
+	return os.Getenv("NIMIQ_PASSPHRASE")
+}
+
+// sessionUnlock unlocks a locked account for DefaultUnlockDuration and
+// records it so relockSessionAccounts locks it again when the uploader
+// exits. It reports false if no passphrase is available.
+func sessionUnlock(rpc *NimiqRPC, address string) (bool, error) {
+	passphrase := unlockPassphrase()
+	if passphrase == "" {
+		return false, nil
+	}
+	unlocked, err := rpc.UnlockAccount(address, passphrase, DefaultUnlockDuration)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock account %s: %w", address, err)
+	}
+	if !unlocked {
+		return false, fmt.Errorf("failed to unlock account %s: wrong passphrase?", address)
+	}
+
+	sessionMu.Lock()
+	sessionAccounts[normalizeAddress(address)] = &sessionAccount{
+		rpc:        rpc,
+		passphrase: passphrase,
+		expires:    time.Now().Add(DefaultUnlockDuration * time.Second),
+	}
+	sessionMu.Unlock()
+	fmt.Printf("Unlocked %s for this run (relocked on exit)\n", FormatAddressNQ(address))
+
+	// Relock on Ctrl-C too; deferred calls don't run on signals
+	sessionSignals.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			relockSessionAccounts()
+			os.Exit(130)
+		}()
+	})
+	return true, nil
+}
+
+// renewSessionUnlock extends the unlock of an account unlocked by
+// sessionUnlock shortly before it expires
+func renewSessionUnlock(address string) error {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	session := sessionAccounts[normalizeAddress(address)]
+	if session == nil || time.Until(session.expires) > sessionRenewMargin {
+		return nil
+	}
+	if _, err := session.rpc.UnlockAccount(address, session.passphrase, DefaultUnlockDuration); err != nil {
+		return fmt.Errorf("failed to renew unlock of %s: %w", address, err)
+	}
+	session.expires = time.Now().Add(DefaultUnlockDuration * time.Second)
+	return nil
+}
+
+// relockSessionAccounts locks every account sessionUnlock unlocked
+func relockSessionAccounts() {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	for address, session := range sessionAccounts {
+		if err := session.rpc.LockAccount(address); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Failed to relock %s: %v (it locks itself within %d seconds)\n", FormatAddressNQ(address), err, DefaultUnlockDuration)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Relocked %s\n", FormatAddressNQ(address))
+	}
+	sessionAccounts = make(map[string]*sessionAccount)
+}
+
+// isRemoteRPC reports whether an RPC URL points at another machine, where an
+// unlocked account can be used by anyone who can reach the node
+func isRemoteRPC(rpcURL string) bool {
+	u, err := url.Parse(rpcURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); ip != nil {
+		return !ip.IsLoopback()
+	}
+	return host != "localhost"
+}
+
+// warnRemoteUnlock warns that an account stays unlocked on a remote node
+func warnRemoteUnlock(rpcURL, address string, duration int) {
+	if !isRemoteRPC(rpcURL) {
+		return
+	}
+	until := "until it is locked again ('account lock')"
+	if duration > 0 {
+		until = fmt.Sprintf("for %d seconds", duration)
+	}
+	statusf("⚠️  %s stays unlocked on the remote node %s %s: anyone who can reach its RPC can send from it\n", FormatAddressNQ(address), rpcURL, until)
+}
@@ -0,0 +1,585 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Sharded cartridges
+//
+// A file larger than --max-size is split into shards. Each shard is an
+// ordinary cartridge (DATA chunks + CART header) at its own address. The
+// primary cartridge address, which the CENT entry points to, holds no DATA:
+// it receives one SHRD record per shard and then a CART header for the whole
+// file with CARTFlagSharded set. Loaders read the shards in index order and
+// check the reassembled file against the primary CART's SHA256.
+//
+// SHRD layout (64 bytes):
+//   - Magic: "SHRD" (4 bytes)
+//   - Version (1 byte), reserved (1 byte)
+//   - Cartridge ID of the whole file (u32)
+//   - Shard index, shard count (u16 each)
+//   - Shard cartridge address (20 bytes)
+//   - Offset of the shard in the file (u64)
+//   - Shard size (u32)
+//   - Reserved (14 bytes)
+
+const (
+	MagicSHRD   = "SHRD"
+	SHRDVersion = 1
+
+	// CART flags
+	CARTFlagSharded = 0x01 // Bit 0: data is in the shard cartridges linked by SHRD records
+)
+
+// SHRDLink links a primary cartridge to one of its shards
+type SHRDLink struct {
+	Version     uint8
+	CartridgeID uint32
+	ShardIndex  uint16
+	ShardCount  uint16
+	ShardAddr   [20]byte
+	Offset      uint64
+	Size        uint32
+}
+
+// EncodeSHRD encodes a shard link into a 64-byte payload
+func EncodeSHRD(link SHRDLink) ([]byte, error) {
+	payload := make([]byte, 64)
+	copy(payload[0:4], MagicSHRD)
+	payload[4] = link.Version
+	binary.LittleEndian.PutUint32(payload[6:10], link.CartridgeID)
+	binary.LittleEndian.PutUint16(payload[10:12], link.ShardIndex)
+	binary.LittleEndian.PutUint16(payload[12:14], link.ShardCount)
+	copy(payload[14:34], link.ShardAddr[:])
+	binary.LittleEndian.PutUint64(payload[34:42], link.Offset)
+	binary.LittleEndian.PutUint32(payload[42:46], link.Size)
+	return payload, nil
+}
+
+// DecodeSHRD decodes a 64-byte SHRD payload
+func DecodeSHRD(payload []byte) (*SHRDLink, error) {
+	if len(payload) < 64 {
+		return nil, fmt.Errorf("SHRD payload too short: %d bytes", len(payload))
+	}
+	if string(payload[0:4]) != MagicSHRD {
+		return nil, fmt.Errorf("not a SHRD payload")
+	}
+	link := &SHRDLink{
+		Version:     payload[4],
+		CartridgeID: binary.LittleEndian.Uint32(payload[6:10]),
+		ShardIndex:  binary.LittleEndian.Uint16(payload[10:12]),
+		ShardCount:  binary.LittleEndian.Uint16(payload[12:14]),
+		Offset:      binary.LittleEndian.Uint64(payload[34:42]),
+		Size:        binary.LittleEndian.Uint32(payload[42:46]),
+	}
+	copy(link.ShardAddr[:], payload[14:34])
+	return link, nil
+}
+
+// ParseByteSize parses a size such as 6291456, 512KB, 6MB or 1GB (units are
+// powers of 1024)
+func ParseByteSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		value  int64
+	}{
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1},
+	} {
+		if strings.HasSuffix(str, unit.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			multiplier = unit.value
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q (use bytes or a KB/MB/GB suffix, e.g. 6MB)", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// ShardSpan is the part of a file stored in one shard
+type ShardSpan struct {
+	Index  int
+	Offset int64
+	Size   int64
+}
+
+// PlanShards splits a file of size bytes into shards of at most maxSize
+// bytes. Shard boundaries fall on chunk boundaries so every shard's chunks
+// are full except the last one of the file.
+func PlanShards(size, maxSize int64, chunkSize int) ([]ShardSpan, error) {
+	shardSize := maxSize - maxSize%int64(chunkSize)
+	if shardSize <= 0 {
+		return nil, fmt.Errorf("--max-size (%d bytes) is smaller than the chunk size (%d bytes)", maxSize, chunkSize)
+	}
+	var shards []ShardSpan
+	for offset := int64(0); offset < size; offset += shardSize {
+		n := shardSize
+		if offset+n > size {
+			n = size - offset
+		}
+		shards = append(shards, ShardSpan{Index: len(shards), Offset: offset, Size: n})
+	}
+	if len(shards) > 0xFFFF {
+		return nil, fmt.Errorf("file needs %d shards (max %d); raise --max-size", len(shards), 0xFFFF)
+	}
+	return shards, nil
+}
+
+// ShardedUploadProgress tracks a sharded upload across runs. Each shard's DATA
+// chunks and CART header are tracked in its own CartridgeUploadProgress file.
+type ShardedUploadProgress struct {
+	AppID       uint32          `json:"app_id"`
+	CartridgeID uint32          `json:"cartridge_id"`
+	SHA256      string          `json:"sha256"`
+	TotalSize   uint64          `json:"total_size"`
+	ChunkSize   uint8           `json:"chunk_size,omitempty"`
+	PrimaryAddr string          `json:"primary_addr"`
+	Shards      []ShardProgress `json:"shards"`
+	CARTTxHash  string          `json:"cart_tx_hash,omitempty"`
+	// CMETTxHashes are the metadata records of a CENT v2 entry
+	CMETTxHashes []string `json:"cmet_tx_hashes,omitempty"`
+	CENTTxHash   string   `json:"cent_tx_hash,omitempty"`
+}
+
+// ShardProgress is one shard of a sharded upload
+type ShardProgress struct {
+	Index      int    `json:"index"`
+	Addr       string `json:"addr"`
+	Offset     int64  `json:"offset"`
+	Size       int64  `json:"size"`
+	SHA256     string `json:"sha256"`
+	SHRDTxHash string `json:"shrd_tx_hash,omitempty"`
+}
+
+// shardedUpload holds the resolved inputs of upload-cartridge for a file
+// larger than --max-size
+type shardedUpload struct {
+	rpc          *NimiqRPC
+	rpcURL       string
+	sender       string
+	catalogAddr  string
+	primaryAddr  string
+	generateAddr bool
+	appID        uint32
+	cartridgeID  uint32
+	title        string
+	meta         CENTMetadata // extended metadata (CENT v2), if any
+	semver       string
+	semverBytes  [3]uint8
+	platform     uint8
+	schema       uint8
+	chunkSize    uint8
+	channel      string
+	fee          int64
+	rateLimit    float64
+	concurrency  int
+	dryRun       bool
+	runDir       string
+	filePath     string
+	maxSize      int64
+	compression  CARTCompression // recorded in the primary CART header only
+}
+
+// run uploads the shards, links them from the primary address and registers
+// the primary cartridge in the catalog. It resumes from the progress files of
+// an earlier run of the same file.
+func (u *shardedUpload) run(ctx context.Context) error {
+	sha256Hash, size, err := CalculateFileSHA256(u.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	sha256Hex := hex.EncodeToString(sha256Hash[:])
+	spans, err := PlanShards(size, u.maxSize, int(u.chunkSize))
+	if err != nil {
+		return err
+	}
+	chunks, err := OpenFileChunks(u.filePath, int(u.chunkSize))
+	if err != nil {
+		return err
+	}
+	defer chunks.Close()
+
+	// Shard plan: DATA + CART per shard, then SHRD per shard, primary CART and CENT
+	totalTxs := len(spans) + 2
+	resultf("\n=== Sharded Upload Plan ===\n")
+	resultf("File: %s\n", u.filePath)
+	resultf("Size: %d bytes (over --max-size %d bytes)\n", size, u.maxSize)
+	resultf("SHA256: %s\n", sha256Hex)
+	resultf("Shards: %d\n", len(spans))
+	for _, span := range spans {
+		shardChunks := chunks.Section(span.Offset, span.Size).Count()
+		totalTxs += shardChunks + 1
+		resultf("  Shard %d: bytes %d-%d (%d bytes, %d chunks)\n", span.Index, span.Offset, span.Offset+span.Size-1, span.Size, shardChunks)
+	}
+	cost := int64(totalTxs) * (u.fee + TxValueLuna)
+	resultf("Transactions: %d (%d Luna, %s)\n", totalTxs, cost, formatNIM(cost))
+	resultf("===========================\n")
+
+	if u.dryRun {
+		resultln("\nDry-run complete. Shard cartridge addresses are generated when the upload runs.")
+		return nil
+	}
+
+	progressFile := filepath.Join(u.runDir, fmt.Sprintf("upload_shards_%d_%d.json", u.appID, u.cartridgeID))
+	progress, err := u.loadProgress(progressFile, sha256Hex, uint64(size), spans)
+	if err != nil {
+		return err
+	}
+	for i := range progress.Shards {
+		if progress.Shards[i].SHA256 == "" {
+			hash, err := chunks.Section(progress.Shards[i].Offset, progress.Shards[i].Size).SHA256()
+			if err != nil {
+				return fmt.Errorf("failed to hash shard %d: %w", i, err)
+			}
+			progress.Shards[i].SHA256 = hex.EncodeToString(hash[:])
+		}
+	}
+	saveShardedProgress(progressFile, progress)
+
+	consensus, err := u.rpc.IsConsensusEstablished()
+	if err != nil {
+		return fmt.Errorf("failed to check consensus: %w", err)
+	}
+	if !consensus {
+		return fmt.Errorf("node does not have consensus with the network - cannot upload. Wait for sync or use --dry-run")
+	}
+
+	concurrency := u.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > 10 {
+		concurrency = 10
+	}
+	control := StartUploadControl(u.runDir, "upload-cartridge", u.rateLimit, concurrency)
+	defer control.Close()
+	control.Expect(totalTxs)
+	accounting := &UploadAccounting{FeeLuna: u.fee}
+	network := networkForCatalog(u.catalogAddr)
+
+	// Step 1: every shard is a complete cartridge of its own
+	complete := true
+	for _, shard := range progress.Shards {
+		done, err := u.uploadShard(ctx, shard, chunks, control, concurrency, accounting)
+		if err != nil {
+			return err
+		}
+		complete = complete && done
+	}
+	if !complete {
+		saveShardedProgress(progressFile, progress)
+		accounting.PrintReport()
+		return fmt.Errorf("not all shards uploaded yet - run again to retry before the shards are linked")
+	}
+
+	// Step 2: link the shards from the primary address, then its CART header
+	// (sent last so it is the newest transaction there)
+	rpcSender, err := NewRPCSender(u.rpcURL, u.sender, progress.PrimaryAddr, u.fee)
+	if err != nil {
+		return fmt.Errorf("failed to initialize RPC sender: %w", err)
+	}
+	primarySender := control.Track(rpcSender)
+	statusf("\n=== Linking %d shards from %s ===\n", len(progress.Shards), progress.PrimaryAddr)
+	for i := range progress.Shards {
+		shard := &progress.Shards[i]
+		if shard.SHRDTxHash != "" {
+			statusf("SHRD %d already sent: %s\n", shard.Index, shard.SHRDTxHash)
+			continue
+		}
+		shardAddrBytes, err := AddressNQToBytes(shard.Addr)
+		if err != nil {
+			return fmt.Errorf("failed to convert shard address: %w", err)
+		}
+		payload, err := EncodeSHRD(SHRDLink{
+			Version:     SHRDVersion,
+			CartridgeID: u.cartridgeID,
+			ShardIndex:  uint16(shard.Index),
+			ShardCount:  uint16(len(progress.Shards)),
+			ShardAddr:   shardAddrBytes,
+			Offset:      uint64(shard.Offset),
+			Size:        uint32(shard.Size),
+		})
+		if err != nil {
+			return err
+		}
+		if err := control.Wait(ctx); err != nil {
+			return err
+		}
+		txHash, err := primarySender.SendTransaction(payload)
+		if err != nil {
+			return fmt.Errorf("failed to send SHRD %d: %w", shard.Index, err)
+		}
+		shard.SHRDTxHash = txHash
+		accounting.ToCartridge++
+		saveShardedProgress(progressFile, progress)
+		statusf("✓ SHRD %d sent: %s\n", shard.Index, txHash)
+	}
+
+	if progress.CARTTxHash == "" {
+		header := CARTHeader{
+			Schema:      u.schema,
+			Platform:    u.platform,
+			ChunkSize:   u.chunkSize,
+			Flags:       CARTFlagSharded,
+			CartridgeID: u.cartridgeID,
+			TotalSize:   uint64(size),
+			SHA256:      sha256Hash,
+		}
+		u.compression.Apply(&header)
+		cartPayload, err := EncodeCART(header)
+		if err != nil {
+			return fmt.Errorf("failed to encode CART header: %w", err)
+		}
+		if err := control.Wait(ctx); err != nil {
+			return err
+		}
+		txHash, err := primarySender.SendTransaction(cartPayload)
+		if err != nil {
+			return fmt.Errorf("failed to send CART header: %w", err)
+		}
+		progress.CARTTxHash = txHash
+		accounting.ToCartridge++
+		saveShardedProgress(progressFile, progress)
+		statusf("✓ Sharded CART header sent: %s\n", txHash)
+		printExplorerLink("  ", network, LinkTx, txHash)
+		logCartridgeUpload(fmt.Sprintf("Sharded CART header sent: %s", txHash))
+	} else {
+		statusf("CART header already sent: %s\n", progress.CARTTxHash)
+	}
+
+	// Step 3: register the primary cartridge in the catalog
+	if progress.CENTTxHash == "" {
+		statusln("\n=== Registering cartridge in catalog (CENT) ===")
+		primaryAddrBytes, err := AddressNQToBytes(progress.PrimaryAddr)
+		if err != nil {
+			return fmt.Errorf("failed to convert cartridge address: %w", err)
+		}
+		centPayload, metaRecords, err := EncodeCENT2(CENTEntry{
+			Schema:        u.schema,
+			Platform:      u.platform,
+			Flags:         ChannelFlags(u.channel),
+			AppID:         u.appID,
+			Semver:        u.semverBytes,
+			CartridgeAddr: primaryAddrBytes,
+			TitleShort:    u.title,
+		}, u.meta)
+		if err != nil {
+			return fmt.Errorf("failed to encode CENT entry: %w", err)
+		}
+		if err := control.Wait(ctx); err != nil {
+			return err
+		}
+		catalogRPCSender, err := NewRPCSender(u.rpcURL, u.sender, u.catalogAddr, u.fee)
+		if err != nil {
+			return fmt.Errorf("failed to initialize catalog RPC sender: %w", err)
+		}
+		catalogSender := control.Track(catalogRPCSender)
+		sent, err := sendMetadataRecords(ctx, control, catalogSender, metaRecords, &progress.CMETTxHashes, true, func() {
+			saveShardedProgress(progressFile, progress)
+		})
+		accounting.ToCatalog += sent
+		if err != nil {
+			return err
+		}
+		txHash, err := catalogSender.SendTransaction(centPayload)
+		if err != nil {
+			return fmt.Errorf("failed to send CENT entry: %w", err)
+		}
+		progress.CENTTxHash = txHash
+		accounting.ToCatalog++
+		saveShardedProgress(progressFile, progress)
+		statusf("✓ CENT entry sent to catalog: %s\n", txHash)
+		printExplorerLink("  ", network, LinkTx, txHash)
+		logCartridgeUpload(fmt.Sprintf("CENT entry sent to catalog: %s", txHash))
+	} else {
+		statusf("CENT entry already sent: %s\n", progress.CENTTxHash)
+	}
+
+	RecordCartridge(u.catalogAddr, u.title, u.appID, ProjectCartridge{
+		CartridgeID:   u.cartridgeID,
+		CartridgeAddr: progress.PrimaryAddr,
+		Semver:        u.semver,
+		File:          u.filePath,
+		SHA256:        sha256Hex,
+		CENTTxHash:    progress.CENTTxHash,
+	})
+
+	statusf("\n✓ Upload complete!\n")
+	resultf("  Cartridge address: %s (%d shards)\n", progress.PrimaryAddr, len(progress.Shards))
+	printExplorerLink("    ", network, LinkAddress, progress.PrimaryAddr)
+	for _, shard := range progress.Shards {
+		resultf("  Shard %d: %s\n", shard.Index, shard.Addr)
+	}
+	resultf("  CART header: %s\n", progress.CARTTxHash)
+	resultf("  CENT entry: %s\n", progress.CENTTxHash)
+	logCartridgeUpload("=== Sharded Upload Complete ===")
+
+	// Shard addresses are generated by the node, like the primary one
+	accounting.CartridgeSweepable, _ = u.rpc.IsAccountImported(progress.PrimaryAddr)
+	accounting.PrintReport()
+	RecordSpend(u.catalogAddr, u.title, u.appID, accounting)
+	return nil
+}
+
+// uploadShard sends a shard's DATA chunks and CART header to its address. It
+// reports whether the shard is complete.
+func (u *shardedUpload) uploadShard(ctx context.Context, shard ShardProgress, file *FileChunks, control *UploadControl, concurrency int, accounting *UploadAccounting) (bool, error) {
+	chunks := file.Section(shard.Offset, shard.Size)
+	statusf("\n=== Shard %d: %s (%d bytes) ===\n", shard.Index, shard.Addr, shard.Size)
+
+	progressFile := filepath.Join(u.runDir, fmt.Sprintf("upload_cartridge_%d_%d_shard%d.json", u.appID, u.cartridgeID, shard.Index))
+	progress := &CartridgeUploadProgress{
+		AppID:         u.appID,
+		CartridgeID:   u.cartridgeID,
+		CartridgeAddr: shard.Addr,
+		TotalChunks:   chunks.Count(),
+	}
+	if data, err := os.ReadFile(progressFile); err == nil {
+		var loaded CartridgeUploadProgress
+		if json.Unmarshal(data, &loaded) == nil && loaded.CartridgeAddr == shard.Addr && loaded.TotalChunks == progress.TotalChunks {
+			progress = &loaded
+		}
+	}
+	if progress.CARTTxHash != "" {
+		statusf("Shard already uploaded (CART %s)\n", progress.CARTTxHash)
+		return true, nil
+	}
+
+	shardHash, err := hex.DecodeString(shard.SHA256)
+	if err != nil || len(shardHash) != 32 {
+		return false, fmt.Errorf("invalid SHA256 of shard %d in progress file", shard.Index)
+	}
+	header := CARTHeader{
+		Schema:      u.schema,
+		Platform:    u.platform,
+		ChunkSize:   u.chunkSize,
+		CartridgeID: u.cartridgeID,
+		TotalSize:   uint64(shard.Size),
+	}
+	copy(header.SHA256[:], shardHash)
+	cartPayload, err := EncodeCART(header)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode CART header: %w", err)
+	}
+
+	reconcileAndSave(NewNimiqRPC(u.rpcURL), progress, progressFile, reconcileTarget{
+		Senders: []string{u.sender},
+		Chunk: func(index uint32) ([]byte, error) {
+			return encodeChunk(chunks, u.cartridgeID, index)
+		},
+		CART: cartPayload,
+	})
+	if progress.CARTTxHash != "" {
+		statusf("Shard already uploaded (CART %s)\n", progress.CARTTxHash)
+		return true, nil
+	}
+
+	rpcSender, err := NewRPCSender(u.rpcURL, u.sender, shard.Addr, u.fee)
+	if err != nil {
+		return false, fmt.Errorf("failed to initialize RPC sender: %w", err)
+	}
+	txSender := control.Track(rpcSender)
+	accounting.ToCartridge += int(sendDataChunks(ctx, txSender, chunks, u.cartridgeID, progress, progressFile, control, concurrency))
+	saveCartridgeProgress(progressFile, progress)
+	if progress.SentChunks != progress.TotalChunks {
+		statusf("⚠️  Shard %d: %d/%d chunks sent\n", shard.Index, progress.SentChunks, progress.TotalChunks)
+		return false, nil
+	}
+
+	if err := control.Wait(ctx); err != nil {
+		return false, err
+	}
+	txHash, err := txSender.SendTransaction(cartPayload)
+	if err != nil {
+		return false, fmt.Errorf("failed to send CART header of shard %d: %w", shard.Index, err)
+	}
+	progress.CARTTxHash = txHash
+	accounting.ToCartridge++
+	saveCartridgeProgress(progressFile, progress)
+	statusf("✓ Shard %d CART header sent: %s\n", shard.Index, txHash)
+	logCartridgeUpload(fmt.Sprintf("Shard %d CART header sent: %s", shard.Index, txHash))
+	return true, nil
+}
+
+// loadProgress resumes the sharded upload of the same file, or starts a new
+// one with a fresh address for every shard
+func (u *shardedUpload) loadProgress(progressFile, sha256Hex string, size uint64, spans []ShardSpan) (*ShardedUploadProgress, error) {
+	if data, err := os.ReadFile(progressFile); err == nil {
+		var loaded ShardedUploadProgress
+		if json.Unmarshal(data, &loaded) == nil && loaded.SHA256 == sha256Hex && len(loaded.Shards) == len(spans) {
+			matches := true
+			for i, span := range spans {
+				if loaded.Shards[i].Offset != span.Offset || loaded.Shards[i].Size != span.Size {
+					matches = false
+				}
+			}
+			if matches {
+				statusf("Resuming from progress file: %s\n", progressFile)
+				return &loaded, nil
+			}
+		}
+		statusf("Progress file exists but doesn't match current upload. Starting fresh.\n")
+	}
+
+	progress := &ShardedUploadProgress{
+		AppID:       u.appID,
+		CartridgeID: u.cartridgeID,
+		SHA256:      sha256Hex,
+		TotalSize:   size,
+		ChunkSize:   u.chunkSize,
+		PrimaryAddr: u.primaryAddr,
+	}
+	if progress.PrimaryAddr == "" {
+		if !u.generateAddr {
+			return nil, fmt.Errorf("cartridge address is required (--cartridge-addr or --generate-cartridge-addr)")
+		}
+		account, err := u.rpc.CreateAccount()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cartridge account: %w", err)
+		}
+		progress.PrimaryAddr = account.Address
+		statusf("Generated cartridge address: %s\n", progress.PrimaryAddr)
+	}
+	if err := ValidateAddressNQ(progress.PrimaryAddr); err != nil {
+		return nil, fmt.Errorf("invalid cartridge address %s: %w", progress.PrimaryAddr, err)
+	}
+
+	for _, span := range spans {
+		account, err := u.rpc.CreateAccount()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create address for shard %d: %w", span.Index, err)
+		}
+		statusf("Generated shard %d address: %s\n", span.Index, account.Address)
+		progress.Shards = append(progress.Shards, ShardProgress{
+			Index:  span.Index,
+			Addr:   account.Address,
+			Offset: span.Offset,
+			Size:   span.Size,
+		})
+	}
+	logCartridgeUpload(fmt.Sprintf("Sharded upload: %d shards, primary address %s", len(spans), progress.PrimaryAddr))
+	return progress, nil
+}
+
+func saveShardedProgress(filename string, progress *ShardedUploadProgress) {
+	data, err := json.MarshalIndent(progress, "", "  ")
+	if err != nil {
+		warnf("failed to marshal progress: %v", err)
+		return
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		warnf("failed to save progress: %v", err)
+	}
+}
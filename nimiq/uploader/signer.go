@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ============================================================================
+// Local signing (--signer local)
+// ============================================================================
+
+const (
+	// signerNode sends transactions through the node's wallet, which must
+	// hold the sender's key unlocked
+	signerNode = "node"
+	// signerLocal signs transactions with the private key from
+	// credentials.json and broadcasts them with sendRawTransaction, so
+	// remote or public nodes without wallet methods work
+	signerLocal = "local"
+)
+
+// signerMode is the effective --signer
+var signerMode = signerNode
+
+// setupSigner picks the signer from --signer or NIMIQ_UPLOADER_SIGNER
+func setupSigner(signer string) error {
+	if signer == "" {
+		signer = os.Getenv("NIMIQ_UPLOADER_SIGNER")
+	}
+	switch signer {
+	case "", signerNode:
+		signerMode = signerNode
+	case signerLocal:
+		signerMode = signerLocal
+	default:
+		return fmt.Errorf("unknown --signer %q (use %s or %s)", signer, signerNode, signerLocal)
+	}
+	return nil
+}
+
+// LocalSigner signs basic transactions with data for one sender
+type LocalSigner struct {
+	key       ed25519.PrivateKey
+	address   [20]byte
+	networkID uint8
+}
+
+// NewLocalSigner loads the private key from credentials.json or its
+// key_source (or NIMIQ_PRIVATE_KEY) and checks that it belongs to sender. The network ID is
+// read from the node, since it is part of what is signed.
+func NewLocalSigner(rpc *NimiqRPC, sender string) (*LocalSigner, error) {
+	creds, err := LoadCredentials("")
+	if err != nil {
+		creds = map[string]string{}
+	}
+	privateKey, err := credentialSecret(creds, "PRIVATE_KEY")
+	if err != nil {
+		return nil, err
+	}
+	if privateKey == "" {
+		privateKey = os.Getenv("NIMIQ_PRIVATE_KEY")
+	}
+	if privateKey == "" {
+		return nil, fmt.Errorf("--signer local needs private_key in credentials.json (or NIMIQ_PRIVATE_KEY)")
+	}
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := &LocalSigner{key: key}
+	copy(signer.address[:], PublicKeyToAddress(key.Public().(ed25519.PublicKey)))
+	want, err := AddressNQToBytes(sender)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %s: %w", sender, err)
+	}
+	if want != signer.address {
+		return nil, fmt.Errorf("private key in credentials belongs to %s, not to sender %s", BytesToAddressNQ(signer.address), FormatAddressNQ(sender))
+	}
+
+	network, err := rpc.GetNetworkID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network: %w", err)
+	}
+	id, ok := albatrossNetworkIDs[network]
+	if !ok {
+		return nil, fmt.Errorf("unknown network %q", network)
+	}
+	signer.networkID = id
+	return signer, nil
+}
+
+// parsePrivateKey accepts a hex ed25519 seed (32 bytes, as the node exports
+// it) or a full private key (64 bytes)
+func parsePrivateKey(privateKey string) (ed25519.PrivateKey, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(privateKey), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("private key is not valid hex: %w", err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		key := ed25519.NewKeyFromSeed(raw[:ed25519.SeedSize])
+		if !key.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(raw[ed25519.SeedSize:])) {
+			return nil, fmt.Errorf("private key does not match its public key")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("private key must be 32 or 64 bytes, got %d", len(raw))
+	}
+}
+
+// PublicKeyToAddress returns the 20-byte address of an ed25519 public key:
+// the first 20 bytes of its BLAKE2b-256 hash
+func PublicKeyToAddress(pub ed25519.PublicKey) []byte {
+	sum := blake2b.Sum256(pub)
+	return sum[:20]
+}
+
+// SignBasicTransaction returns a signed extended basic transaction (hex) to
+// recipient, ready for sendRawTransaction
+func (s *LocalSigner) SignBasicTransaction(recipient string, data []byte, value, fee, validityStartHeight int64) (string, error) {
+	to, err := AddressNQToBytes(recipient)
+	if err != nil {
+		return "", fmt.Errorf("invalid recipient %s: %w", recipient, err)
+	}
+	content := serializeTxContent(s.address, to, data, value, fee, uint32(validityStartHeight), s.networkID)
+	signature := ed25519.Sign(s.key, content)
+	return hex.EncodeToString(serializeSignedTx(content, s.key.Public().(ed25519.PublicKey), signature)), nil
+}
+
+// serializeSignedTx appends the signature proof to the transaction content:
+// a 0x01 type byte (extended), the content and the u16-length-prefixed
+// proof. The proof is the algorithm byte (0, ed25519), the public key, an
+// empty merkle path (single signer) and the signature.
+func serializeSignedTx(content []byte, pub ed25519.PublicKey, signature []byte) []byte {
+	const extendedTx, ed25519Proof = 1, 0
+	proof := make([]byte, 0, 1+len(pub)+1+len(signature))
+	proof = append(proof, ed25519Proof)
+	proof = append(proof, pub...)
+	proof = append(proof, 0) // merkle path length
+	proof = append(proof, signature...)
+
+	buf := make([]byte, 0, 1+len(content)+2+len(proof))
+	buf = append(buf, extendedTx)
+	buf = append(buf, content...)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(proof)))
+	buf = append(buf, proof...)
+	return buf
+}
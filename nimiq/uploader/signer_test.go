@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// RFC 8032 section 7.1, test 1
+const (
+	rfc8032Seed   = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+	rfc8032Public = "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"
+)
+
+func TestParsePrivateKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr string
+	}{
+		{name: "seed", key: rfc8032Seed},
+		{name: "seed with 0x", key: "0x" + rfc8032Seed},
+		{name: "seed and public key", key: rfc8032Seed + rfc8032Public},
+		{name: "wrong public key", key: rfc8032Seed + strings.Repeat("00", 32), wantErr: "does not match"},
+		{name: "short", key: rfc8032Seed[:62], wantErr: "32 or 64 bytes"},
+		{name: "not hex", key: "xyz", wantErr: "not valid hex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := parsePrivateKey(tt.key)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parsePrivateKey error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePrivateKey: %v", err)
+			}
+			if got := hex.EncodeToString(key.Public().(ed25519.PublicKey)); got != rfc8032Public {
+				t.Errorf("public key = %s, want %s", got, rfc8032Public)
+			}
+		})
+	}
+}
+
+func TestSignBasicTransaction(t *testing.T) {
+	key, err := parsePrivateKey(rfc8032Seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := &LocalSigner{key: key, networkID: 24}
+	copy(signer.address[:], PublicKeyToAddress(key.Public().(ed25519.PublicKey)))
+	if signer.address != testTxFrom {
+		t.Fatalf("address = %x, want %x", signer.address, testTxFrom)
+	}
+
+	got, err := signer.SignBasicTransaction(BytesToAddressNQ(testTxTo), testTxData, 1, 138, 123456)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The expected signature comes from the RFC 8032 section 6 reference
+	// implementation (Python), checked against test 1 of section 7.1 first,
+	// not from Go's crypto/ed25519. The framing around it follows
+	// serializeSignedTx and, like the content, was not checked against a
+	// Nimiq node or the official libraries.
+	content := "0008" + "4341525400010203" +
+		"7849ac3049680be1ef762efe0d36e01733c3464e" + "00" +
+		"0102030405060708090a0b0c0d0e0f1011121314" + "00" +
+		"0000000000000001" + "000000000000008a" +
+		"0001e240" + "18" + "00" + "0000"
+	want := "01" + content + // extended transaction, content
+		"0062" + "00" + rfc8032Public + "00" + // proof length, ed25519, public key, merkle path
+		"fdc33f5506a641191ebd999f6a6c2db86a515638d12ce1f6e2963c26ed8c0c13" +
+		"fbee759004a783fbc0f196d7e0cfc3b6f17a379945a913ae85e0051bbd96c805"
+	if got != want {
+		t.Errorf("SignBasicTransaction =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ProjectStateFileName is the per-project file recording generated IDs
+const ProjectStateFileName = "nimiq-project.json"
+
+// ProjectState records app-ids and cartridge addresses generated for the
+// games uploaded from a project directory, so they outlive the terminal output
+type ProjectState struct {
+	Apps []ProjectApp `json:"apps"`
+}
+
+// ProjectApp is one app (game) in the project state, keyed by catalog and title
+type ProjectApp struct {
+	Title       string             `json:"title"`
+	AppID       uint32             `json:"app_id"`
+	CatalogAddr string             `json:"catalog_addr"`
+	Cartridges  []ProjectCartridge `json:"cartridges"`
+	Spend       ProjectSpend       `json:"spend"`
+}
+
+// ProjectCartridge is one uploaded (or in-flight) cartridge version
+type ProjectCartridge struct {
+	CartridgeID   uint32 `json:"cartridge_id"`
+	CartridgeAddr string `json:"cartridge_addr"`
+	Semver        string `json:"semver"`
+	File          string `json:"file,omitempty"`
+	SHA256        string `json:"sha256,omitempty"`
+	CENTTxHash    string `json:"cent_tx_hash,omitempty"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+// LoadProjectState reads the project state file (empty state if it doesn't exist)
+func LoadProjectState(filename string) (*ProjectState, error) {
+	state := &ProjectState{}
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return state, nil
+}
+
+// SaveProjectState writes the project state file
+func SaveProjectState(filename string, state *ProjectState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// findOrAddApp returns the app with the given catalog and app-id, adding it if needed
+func (s *ProjectState) findOrAddApp(catalogAddr, title string, appID uint32) *ProjectApp {
+	normalizedCatalog := normalizeAddress(catalogAddr)
+	for i := range s.Apps {
+		if normalizeAddress(s.Apps[i].CatalogAddr) == normalizedCatalog && s.Apps[i].AppID == appID {
+			return &s.Apps[i]
+		}
+	}
+	s.Apps = append(s.Apps, ProjectApp{
+		Title:       title,
+		AppID:       appID,
+		CatalogAddr: catalogAddr,
+	})
+	return &s.Apps[len(s.Apps)-1]
+}
+
+// RecordCartridge upserts an app and cartridge in the project state file.
+// Failures are reported as warnings so they never interrupt an upload.
+func RecordCartridge(catalogAddr, title string, appID uint32, cart ProjectCartridge) {
+	state, err := LoadProjectState(ProjectStateFileName)
+	if err != nil {
+		warnf("failed to load project state: %v", err)
+		return
+	}
+
+	app := state.findOrAddApp(catalogAddr, title, appID)
+
+	cart.UpdatedAt = time.Now().Format(time.RFC3339)
+	replaced := false
+	for i := range app.Cartridges {
+		if app.Cartridges[i].CartridgeID == cart.CartridgeID {
+			app.Cartridges[i] = cart
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		app.Cartridges = append(app.Cartridges, cart)
+	}
+
+	if err := SaveProjectState(ProjectStateFileName, state); err != nil {
+		warnf("failed to save project state: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// GetStateBaseDir returns the base directory for run artifacts
+// On Linux/Mac: $XDG_STATE_HOME/nimiq-uploader or ~/.local/state/nimiq-uploader
+// Falls back to current directory if home is not available
+func GetStateBaseDir() string {
+	if xdgState := os.Getenv("XDG_STATE_HOME"); xdgState != "" {
+		return filepath.Join(xdgState, ConfigDirName)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+
+	return filepath.Join(homeDir, ".local", "state", ConfigDirName)
+}
+
+// ResolveRunDir returns (and creates) the directory for a run's progress
+// files. An explicit --state-dir is used as-is; otherwise the directory is
+// <state base>/<catalog address>/<app-id>.
+func ResolveRunDir(stateDir, catalogAddr string, appID uint32) (string, error) {
+	dir := stateDir
+	if dir == "" {
+		dir = filepath.Join(GetStateBaseDir(), normalizeAddress(catalogAddr), fmt.Sprintf("%d", appID))
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create state directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// runIsComplete reports whether every cartridge progress file in dir has
+// its CENT entry sent (and there is at least one)
+func runIsComplete(dir string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, "upload_cartridge_*.json"))
+	if len(matches) == 0 {
+		return false
+	}
+	for _, match := range matches {
+		data, err := os.ReadFile(match)
+		if err != nil {
+			return false
+		}
+		var progress CartridgeUploadProgress
+		if err := json.Unmarshal(data, &progress); err != nil || progress.CENTTxHash == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// runIsLocked reports whether a live run holds a lock in dir
+func runIsLocked(dir string) bool {
+	locks, _ := filepath.Glob(filepath.Join(dir, "*.lock"))
+	for _, lockPath := range locks {
+		if info, err := readLockInfo(lockPath); err != nil || !lockIsStale(info) {
+			return true
+		}
+	}
+	return false
+}
+
+// newPruneCmd creates the prune command for cleaning up the state directory
+func newPruneCmd() *cobra.Command {
+	var (
+		all    bool
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove state directories of completed uploads",
+		Long: `Remove run directories (progress files) from the state directory.
+
+By default only runs whose cartridges were fully registered in the catalog
+(CENT entry sent) are removed. Use --all to remove every run, including
+unfinished uploads that could otherwise be resumed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir := GetStateBaseDir()
+			runDirs, _ := filepath.Glob(filepath.Join(baseDir, "*", "*"))
+
+			removed := 0
+			for _, dir := range runDirs {
+				if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+					continue
+				}
+				if runIsLocked(dir) {
+					continue
+				}
+				if !all && !runIsComplete(dir) {
+					continue
+				}
+
+				rel := strings.TrimPrefix(dir, baseDir+string(filepath.Separator))
+				if dryRun {
+					resultf("Would remove: %s\n", rel)
+				} else {
+					if err := os.RemoveAll(dir); err != nil {
+						warnf("failed to remove %s: %v", rel, err)
+						continue
+					}
+					resultf("Removed: %s\n", rel)
+				}
+				removed++
+			}
+
+			// Drop catalog directories left empty
+			if !dryRun {
+				catalogDirs, _ := filepath.Glob(filepath.Join(baseDir, "*"))
+				for _, dir := range catalogDirs {
+					if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+						os.Remove(dir)
+					}
+				}
+			}
+
+			if removed == 0 {
+				statusf("Nothing to prune in %s\n", baseDir)
+			} else if dryRun {
+				statusf("\n%d run(s) would be removed from %s\n", removed, baseDir)
+			} else {
+				statusf("\n✓ Removed %d run(s) from %s\n", removed, baseDir)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Also remove unfinished uploads (their progress is lost)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be removed without deleting anything")
+
+	return cmd
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ============================================================================
+// Anonymous usage reporting (telemetry, opt-in)
+// ============================================================================
+
+// Nothing is reported until `telemetry enable` wrote telemetry.json with a
+// random installation ID. An event names the command, the flags that were
+// set (names only), how long it ran and a coarse error category; never
+// arguments, addresses, file names or error messages.
+const (
+	// TelemetryFileName is the settings file in the config directory
+	TelemetryFileName = "telemetry.json"
+
+	// telemetryEnv set to 0/off/false turns reporting off for a run
+	telemetryEnv = "NIMIQ_UPLOADER_TELEMETRY"
+	// telemetryEndpointEnv overrides the endpoint
+	telemetryEndpointEnv = "NIMIQ_UPLOADER_TELEMETRY_ENDPOINT"
+	// telemetryDebugEnv prints each event to stderr as it is sent
+	telemetryDebugEnv = "NIMIQ_UPLOADER_TELEMETRY_DEBUG"
+	// telemetryTimeout bounds the report at the end of a command
+	telemetryTimeout = 2 * time.Second
+)
+
+// TelemetryEndpoint receives the reports of users who opted in (set by
+// ldflags in release builds)
+var TelemetryEndpoint = ""
+
+// TelemetrySettings is the opt-in state kept in telemetry.json
+type TelemetrySettings struct {
+	Enabled bool `json:"enabled"`
+	// ID is random, derived from nothing, and replaced on every enable
+	ID        string `json:"id,omitempty"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	EnabledAt string `json:"enabled_at,omitempty"`
+}
+
+// TelemetryEvent is one command run, as posted to the endpoint
+type TelemetryEvent struct {
+	ID      string `json:"id"`
+	Tool    string `json:"tool"`
+	Version string `json:"version"`
+	OS      string `json:"os"`
+	Arch    string `json:"arch"`
+	// Command is the command path without the tool, e.g. "account status"
+	Command       string   `json:"command"`
+	Flags         []string `json:"flags,omitempty"`
+	DurationMS    int64    `json:"duration_ms"`
+	Success       bool     `json:"success"`
+	ErrorCategory string   `json:"error_category,omitempty"`
+	CI            bool     `json:"ci"`
+}
+
+func telemetryPath() string {
+	return filepath.Join(GetConfigDir(), TelemetryFileName)
+}
+
+// loadTelemetrySettings reads telemetry.json; a missing file means off
+func loadTelemetrySettings() (*TelemetrySettings, error) {
+	data, err := os.ReadFile(telemetryPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return &TelemetrySettings{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s TelemetrySettings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", telemetryPath(), err)
+	}
+	return &s, nil
+}
+
+func (s *TelemetrySettings) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := EnsureConfigDir(); err != nil {
+		return err
+	}
+	return os.WriteFile(telemetryPath(), append(data, '\n'), 0600)
+}
+
+// endpoint returns $NIMIQ_UPLOADER_TELEMETRY_ENDPOINT, the endpoint given
+// to telemetry enable, else the one built in
+func (s *TelemetrySettings) endpoint() string {
+	if endpoint := os.Getenv(telemetryEndpointEnv); endpoint != "" {
+		return endpoint
+	}
+	if s.Endpoint != "" {
+		return s.Endpoint
+	}
+	return TelemetryEndpoint
+}
+
+// telemetryOff returns why reporting is off for this run despite being
+// enabled, or ""
+func telemetryOff() string {
+	switch v := strings.ToLower(os.Getenv("DO_NOT_TRACK")); v {
+	case "", "0", "false":
+	default:
+		return "DO_NOT_TRACK is set"
+	}
+	switch strings.ToLower(os.Getenv(telemetryEnv)) {
+	case "0", "off", "false", "no":
+		return telemetryEnv + " is off"
+	}
+	return ""
+}
+
+// commandEvent describes a finished run of cmd
+func commandEvent(s *TelemetrySettings, cmd *cobra.Command, duration time.Duration, err error) TelemetryEvent {
+	var flags []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		flags = append(flags, f.Name)
+	})
+	sort.Strings(flags)
+	return TelemetryEvent{
+		ID:            s.ID,
+		Tool:          cmd.Root().Name(),
+		Version:       Version,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		Command:       strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name())),
+		Flags:         flags,
+		DurationMS:    duration.Milliseconds(),
+		Success:       err == nil,
+		ErrorCategory: telemetryErrorCategory(err),
+		CI:            os.Getenv("CI") != "",
+	}
+}
+
+// telemetryErrorCategory maps an error to a coarse category, so the message
+// itself (which may name files, addresses or URLs) is never reported
+func telemetryErrorCategory(err error) string {
+	if err == nil {
+		return ""
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &opErr), errors.As(err, &dnsErr), errors.As(err, &urlErr):
+		return "network"
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrPermission):
+		return "file"
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, c := range []struct {
+		category string
+		words    []string
+	}{
+		{"usage", []string{"unknown command", "unknown flag", "unknown shorthand", "required flag", "accepts ", "arg(s)", "flag needs an argument"}},
+		{"auth", []string{"passphrase", "locked", "unauthorized", "forbidden", "signature", "private key"}},
+		{"funds", []string{"insufficient", "balance"}},
+		{"timeout", []string{"timeout", "timed out", "deadline"}},
+		{"network", []string{"connection refused", "no such host", "connection reset", "eof", "consensus"}},
+		{"node", []string{"rpc error", "status 5", "429"}},
+		{"not_found", []string{"not found", "does not exist", "no such"}},
+		{"invalid_input", []string{"invalid", "must be", "must not", "is required"}},
+	} {
+		for _, w := range c.words {
+			if strings.Contains(msg, w) {
+				return c.category
+			}
+		}
+	}
+	return "other"
+}
+
+// reportUsage sends the event of a finished command if the user opted in.
+// It never fails the command: a failed send is only reported with -v or
+// $NIMIQ_UPLOADER_TELEMETRY_DEBUG.
+func reportUsage(cmd *cobra.Command, duration time.Duration, err error) {
+	if cmd == nil || telemetryOff() != "" {
+		return
+	}
+	s, loadErr := loadTelemetrySettings()
+	if loadErr != nil || !s.Enabled || s.ID == "" || s.endpoint() == "" {
+		return
+	}
+	debug := os.Getenv(telemetryDebugEnv) != ""
+	event := commandEvent(s, cmd, duration, err)
+	body, _ := json.Marshal(event)
+	if debug {
+		fmt.Fprintf(os.Stderr, "telemetry: %s -> %s\n", body, s.endpoint())
+	}
+	if sendErr := sendTelemetry(s.endpoint(), body); sendErr != nil && (debug || verbose(levelVerbose)) {
+		fmt.Fprintf(os.Stderr, "telemetry: %v\n", sendErr)
+	}
+}
+
+func sendTelemetry(endpoint string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nimiq-uploader/"+Version)
+	client := &http.Client{Timeout: telemetryTimeout, Transport: httpTransport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+func newTelemetryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Opt in to anonymous usage reporting",
+		Long: `Anonymous usage reporting helps the maintainers see which commands and
+features are used. It is off until you run telemetry enable.
+
+Each run then posts one event: the command (e.g. "upload-cartridge"), the
+names of the flags that were set, how long it took, whether it failed and a
+coarse error category (network, timeout, usage, ...), plus the uploader
+version, OS, architecture, whether it ran in CI and a random installation
+ID. Flag values, arguments, addresses, file names, URLs and error messages
+are never sent.
+
+DO_NOT_TRACK=1 or NIMIQ_UPLOADER_TELEMETRY=0 turn reporting off for a run.
+NIMIQ_UPLOADER_TELEMETRY_DEBUG=1 prints every event to stderr.`,
+		Example: `  nimiq-uploader telemetry status
+  nimiq-uploader telemetry enable
+  nimiq-uploader telemetry disable`,
+	}
+	cmd.AddCommand(newTelemetryEnableCmd())
+	cmd.AddCommand(newTelemetryDisableCmd())
+	cmd.AddCommand(newTelemetryStatusCmd())
+	return cmd
+}
+
+func newTelemetryEnableCmd() *cobra.Command {
+	var endpoint string
+
+	cmd := &cobra.Command{
+		Use:   "enable",
+		Short: "Start reporting anonymous usage",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := loadTelemetrySettings()
+			if err != nil {
+				return err
+			}
+			if endpoint != "" {
+				u, err := url.Parse(endpoint)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("--endpoint must be an http(s) URL, got %q", endpoint)
+				}
+				s.Endpoint = endpoint
+			} else if s.endpoint() == "" {
+				return fmt.Errorf("this build has no telemetry endpoint built in; pass --endpoint")
+			}
+			id := make([]byte, 16)
+			if _, err := rand.Read(id); err != nil {
+				return fmt.Errorf("failed to create an installation ID: %w", err)
+			}
+			s.Enabled = true
+			s.ID = hex.EncodeToString(id)
+			s.EnabledAt = time.Now().UTC().Format(time.RFC3339)
+			if err := s.save(); err != nil {
+				return fmt.Errorf("failed to save %s: %w", telemetryPath(), err)
+			}
+
+			statusf("✓ Anonymous usage reporting enabled (installation ID %s)\n", s.ID)
+			fmt.Printf("  Events go to %s\n", s.endpoint())
+			statusln("💡 See what is sent with: nimiq-uploader telemetry status; stop with: nimiq-uploader telemetry disable")
+			if reason := telemetryOff(); reason != "" {
+				statusf("⚠️  Nothing is sent while %s\n", reason)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&endpoint, "endpoint", "", "URL events are posted to (default: the built-in endpoint)")
+
+	return cmd
+}
+
+func newTelemetryDisableCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "disable",
+		Short: "Stop reporting and forget the installation ID",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := loadTelemetrySettings()
+			if err != nil {
+				return err
+			}
+			s.Enabled = false
+			s.ID = ""
+			s.EnabledAt = ""
+			if err := s.save(); err != nil {
+				return fmt.Errorf("failed to save %s: %w", telemetryPath(), err)
+			}
+			statusln("✓ Anonymous usage reporting disabled")
+			return nil
+		},
+	}
+}
+
+func newTelemetryStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether usage is reported and an example event",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := loadTelemetrySettings()
+			if err != nil {
+				return err
+			}
+			switch {
+			case !s.Enabled:
+				fmt.Println("Telemetry: disabled (enable with: nimiq-uploader telemetry enable)")
+			case telemetryOff() != "":
+				fmt.Printf("Telemetry: enabled, but off while %s\n", telemetryOff())
+			default:
+				fmt.Println("Telemetry: enabled")
+			}
+			if s.ID != "" {
+				fmt.Printf("Installation ID: %s\n", s.ID)
+			}
+			endpoint := s.endpoint()
+			if endpoint == "" {
+				endpoint = "(none built in)"
+			}
+			fmt.Printf("Endpoint:        %s\n", endpoint)
+			fmt.Printf("Settings:        %s\n", telemetryPath())
+			data, err := json.MarshalIndent(commandEvent(s, cmd, 0, nil), "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\nExample event (this command):\n%s\n", data)
+			return nil
+		},
+	}
+}
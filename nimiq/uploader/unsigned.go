@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+)
+
+// unsignedValidityWindow is roughly how long Albatross transactions stay
+// valid after their validity start height
+const unsignedValidityWindow = 2 * time.Hour
+
+// Albatross network IDs as serialized in transactions
+var albatrossNetworkIDs = map[string]uint8{
+	"TestAlbatross": 5,
+	"DevAlbatross":  6,
+	"UnitAlbatross": 7,
+	"MainAlbatross": 24,
+}
+
+// UnsignedTx is one transaction for an external wallet to sign. The fields
+// mirror the Nimiq Hub signTransaction request; Content is the serialized
+// transaction content (the bytes the Keyguard signs).
+type UnsignedTx struct {
+	Kind                string  `json:"kind"`            // CART, DATA or CENT
+	Index               *uint32 `json:"index,omitempty"` // chunk index of DATA transactions
+	Sender              string  `json:"sender"`
+	Recipient           string  `json:"recipient"`
+	Value               int64   `json:"value"`
+	Fee                 int64   `json:"fee"`
+	ExtraData           string  `json:"extraData"`
+	ValidityStartHeight int64   `json:"validityStartHeight"`
+	NetworkID           uint8   `json:"networkId"`
+	Content             string  `json:"content"`
+}
+
+// UnsignedBatch is the --unsigned-out file. Transactions must be signed and
+// submitted in order: DATA chunks, then CART, then CENT.
+type UnsignedBatch struct {
+	Network      string       `json:"network"`
+	Transactions []UnsignedTx `json:"transactions"`
+}
+
+// newUnsignedBatch reads the network and validity start height from the node.
+// Albatross transactions are valid for about two hours from that height.
+func newUnsignedBatch(rpc *NimiqRPC) (*UnsignedBatch, int64, error) {
+	network, err := rpc.GetNetworkID()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get network: %w", err)
+	}
+	if _, ok := albatrossNetworkIDs[network]; !ok {
+		return nil, 0, fmt.Errorf("unknown network %q", network)
+	}
+	height, err := rpc.GetBlockNumber()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get block height: %w", err)
+	}
+	return &UnsignedBatch{Network: network}, height, nil
+}
+
+// Add appends a basic transaction with data from sender to recipient. Like
+// RPCSender it sends 1 Luna, the minimum for a transaction with data.
+func (b *UnsignedBatch) Add(sender, recipient string, payload []byte, fee, validityStartHeight int64) error {
+	from, err := AddressNQToBytes(sender)
+	if err != nil {
+		return fmt.Errorf("invalid sender %s: %w", sender, err)
+	}
+	to, err := AddressNQToBytes(recipient)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %s: %w", recipient, err)
+	}
+
+	tx := UnsignedTx{
+		Kind:                string(payload[:4]),
+		Sender:              FormatAddressNQ(sender),
+		Recipient:           FormatAddressNQ(recipient),
+		Value:               1,
+		Fee:                 fee,
+		ExtraData:           hex.EncodeToString(payload),
+		ValidityStartHeight: validityStartHeight,
+		NetworkID:           albatrossNetworkIDs[b.Network],
+	}
+	if tx.Kind == MagicDATA {
+		index := binary.LittleEndian.Uint32(payload[8:12])
+		tx.Index = &index
+	}
+	tx.Content = hex.EncodeToString(serializeTxContent(from, to, payload, tx.Value, tx.Fee, uint32(validityStartHeight), tx.NetworkID))
+	b.Transactions = append(b.Transactions, tx)
+	return nil
+}
+
+// Write saves the batch as indented JSON and explains how to continue
+func (b *UnsignedBatch) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned transactions: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	statusf("\n✓ %d unsigned transactions written to %s (%s)\n", len(b.Transactions), path, b.Network)
+	if !quiet() {
+		fmt.Printf("💡 Sign them in order with the sender's wallet (Nimiq Hub / Keyguard) within about %.0f hours,\n", unsignedValidityWindow.Hours())
+		fmt.Println("   save the serialized transactions one per line and broadcast them with:")
+		fmt.Println("   nimiq-uploader submit-signed signed.txt")
+	}
+	return nil
+}
+
+// serializeTxContent serializes an extended basic-to-basic transaction the
+// way Albatross signs it: u16 data length, data, sender, sender type,
+// recipient, recipient type, value, fee, validity start height, network ID,
+// flags and u16 sender data length (all big-endian)
+func serializeTxContent(from, to [20]byte, data []byte, value, fee int64, validityStartHeight uint32, networkID uint8) []byte {
+	const basicAccount = 0
+	buf := make([]byte, 0, 2+len(data)+20+1+20+1+8+8+4+1+1+2)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(data)))
+	buf = append(buf, data...)
+	buf = append(buf, from[:]...)
+	buf = append(buf, basicAccount)
+	buf = append(buf, to[:]...)
+	buf = append(buf, basicAccount)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(value))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(fee))
+	buf = binary.BigEndian.AppendUint32(buf, validityStartHeight)
+	buf = append(buf, networkID)
+	buf = append(buf, 0) // flags
+	buf = binary.BigEndian.AppendUint16(buf, 0)
+	return buf
+}
+
+func newSubmitSignedCmd() *cobra.Command {
+	var (
+		rpcURL    string
+		rateLimit float64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "submit-signed FILE",
+		Short: "Broadcast transactions signed by an external wallet",
+		Long: `Broadcasts transactions that were written with --unsigned-out and signed
+in the Nimiq Hub / Keyguard. FILE holds the signed transactions in the order
+they were emitted, either one serialized transaction (hex) per line or a JSON
+array of hex strings or of Hub results with a "serializedTx" field.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rpcURL == "" {
+				rpcURL = GetDefaultRPCURL()
+			}
+
+			signed, err := loadSignedTransactions(args[0])
+			if err != nil {
+				return err
+			}
+			if len(signed) == 0 {
+				return fmt.Errorf("no signed transactions in %s", args[0])
+			}
+
+			rpc := NewNimiqRPC(rpcURL)
+			consensus, err := rpc.IsConsensusEstablished()
+			if err != nil {
+				return fmt.Errorf("failed to check consensus: %w", err)
+			}
+			if !consensus {
+				return fmt.Errorf("node does not have consensus with the network - cannot send transactions")
+			}
+
+			network := GetDefaultNetwork()
+			limiter := rate.NewLimiter(rate.Limit(rateLimit), 1)
+			var last string
+			for i, rawTx := range signed {
+				if err := limiter.Wait(cmd.Context()); err != nil {
+					return err
+				}
+				txHash, err := rpc.SendRawTransaction(rawTx)
+				if err != nil {
+					return fmt.Errorf("failed to send transaction %d/%d: %w (run again with the remaining transactions to resume)", i+1, len(signed), err)
+				}
+				statusf("✓ Sent %d/%d: %s\n", i+1, len(signed), txHash)
+				last = txHash
+			}
+			printExplorerLink("  ", network, LinkTx, last)
+
+			statusf("\n✓ Submitted %d transactions\n", len(signed))
+			newGHSummary("Submitted signed Nimiq transactions").
+				row("Transactions", fmt.Sprintf("%d", len(signed))).
+				row("Last transaction", last).
+				output("tx_hash", last).
+				write()
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
+	cmd.Flags().Float64Var(&rateLimit, "rate", 25.0, "Transaction rate limit (tx/s, default: 25)")
+
+	return cmd
+}
+
+// loadSignedTransactions reads hex transactions from a text or JSON file
+func loadSignedTransactions(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signed transactions: %w", err)
+	}
+
+	var raw []string
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		for i, item := range items {
+			var hexTx string
+			if err := json.Unmarshal(item, &hexTx); err != nil {
+				var result struct {
+					SerializedTx string `json:"serializedTx"`
+				}
+				if err := json.Unmarshal(item, &result); err != nil || result.SerializedTx == "" {
+					return nil, fmt.Errorf("entry %d of %s is neither a hex string nor a Hub result", i, path)
+				}
+				hexTx = result.SerializedTx
+			}
+			raw = append(raw, hexTx)
+		}
+	} else {
+		scanner := bufio.NewScanner(strings.NewReader(string(data)))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				raw = append(raw, line)
+			}
+		}
+	}
+
+	for i, hexTx := range raw {
+		raw[i] = strings.TrimPrefix(hexTx, "0x")
+		if _, err := hex.DecodeString(raw[i]); err != nil {
+			return nil, fmt.Errorf("transaction %d is not valid hex: %w", i+1, err)
+		}
+	}
+	return raw, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// There is no Nimiq library in the module cache to check these against, so
+// the expected bytes were assembled field by field with Python's struct
+// module from the layout in serializeTxContent (core-rs-albatross's
+// Transaction::serialize_content). They pin the encoding; they were not
+// produced by a Nimiq node or the official libraries.
+
+// testTxFrom is the address of the RFC 8032 test 1 key (see signer_test.go)
+var testTxFrom = [20]byte{
+	0x78, 0x49, 0xac, 0x30, 0x49, 0x68, 0x0b, 0xe1, 0xef, 0x76,
+	0x2e, 0xfe, 0x0d, 0x36, 0xe0, 0x17, 0x33, 0xc3, 0x46, 0x4e,
+}
+
+// testTxTo is 0x01..0x14
+var testTxTo = [20]byte{
+	1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20,
+}
+
+var testTxData = []byte("CART\x00\x01\x02\x03")
+
+func TestAlbatrossNetworkIDs(t *testing.T) {
+	want := map[string]uint8{
+		"TestAlbatross": 5,
+		"DevAlbatross":  6,
+		"UnitAlbatross": 7,
+		"MainAlbatross": 24,
+	}
+	if len(albatrossNetworkIDs) != len(want) {
+		t.Errorf("albatrossNetworkIDs has %d networks, want %d", len(albatrossNetworkIDs), len(want))
+	}
+	for network, id := range want {
+		if got := albatrossNetworkIDs[network]; got != id {
+			t.Errorf("albatrossNetworkIDs[%s] = %d, want %d", network, got, id)
+		}
+	}
+}
+
+func TestSerializeTxContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		networkID uint8
+		want      string
+	}{
+		{
+			name:      "testnet",
+			networkID: 5,
+			want: "0008" + "4341525400010203" + // data length, data
+				"7849ac3049680be1ef762efe0d36e01733c3464e" + "00" + // sender, basic
+				"0102030405060708090a0b0c0d0e0f1011121314" + "00" + // recipient, basic
+				"0000000000000001" + "000000000000008a" + // value 1, fee 138
+				"0001e240" + "05" + "00" + "0000", // validity start 123456, network, flags, sender data
+		},
+		{
+			name:      "mainnet",
+			networkID: 24,
+			want: "0008" + "4341525400010203" +
+				"7849ac3049680be1ef762efe0d36e01733c3464e" + "00" +
+				"0102030405060708090a0b0c0d0e0f1011121314" + "00" +
+				"0000000000000001" + "000000000000008a" +
+				"0001e240" + "18" + "00" + "0000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hex.EncodeToString(serializeTxContent(testTxFrom, testTxTo, testTxData, 1, 138, 123456, tt.networkID))
+			if got != tt.want {
+				t.Errorf("serializeTxContent =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,7 +12,6 @@ package main
 // ==============================================================================
 
 import (
-	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -51,6 +50,7 @@ func newUploadCmd() *cobra.Command {
 		network          string
 		title            string // Display title of the game
 		platform         string // Platform (e.g., "DOS", "Windows")
+		maxSize          string
 	)
 
 	cmd := &cobra.Command{
@@ -76,28 +76,33 @@ func newUploadCmd() *cobra.Command {
 				receiver = "NQ27 21G6 9BG1 JBHJ NUFA YVJS 1R6C D2X0 QAES"
 			}
 
-			// Check file size limit (6MB)
-			const maxFileSize = 6 * 1024 * 1024 // 6MB
+			// Check file size limit (--max-size)
+			maxFileSize, err := ParseByteSize(maxSize)
+			if err != nil {
+				return err
+			}
 			fileInfo, err := os.Stat(filePath)
 			if err != nil {
 				return fmt.Errorf("failed to get file info: %w", err)
 			}
 			if fileInfo.Size() > maxFileSize {
-				return fmt.Errorf("file size (%d bytes) exceeds maximum allowed size of 6MB (%d bytes)", fileInfo.Size(), maxFileSize)
+				return fmt.Errorf("file size (%d bytes) exceeds --max-size (%d bytes)", fileInfo.Size(), maxFileSize)
 			}
 
-			chunks, err := ChunkFile(filePath, gameID)
+			// Chunks are read from the file as they are sent
+			chunks, err := OpenFileChunks(filePath, ChunkSize)
 			if err != nil {
 				return fmt.Errorf("failed to chunk file: %w", err)
 			}
+			defer chunks.Close()
 
-			fmt.Printf("Created %d chunks for game_id=%d\n", len(chunks), gameID)
+			fmt.Printf("Created %d chunks for game_id=%d\n", chunks.Count(), gameID)
 
 			progress := &UploadProgress{
 				GameID:      gameID,
-				TotalChunks: len(chunks),
+				TotalChunks: chunks.Count(),
 				SentChunks:  0,
-				Plan:        make([]UploadPlan, 0, len(chunks)),
+				Plan:        make([]UploadPlan, 0, chunks.Count()),
 			}
 
 			// Load existing progress if available
@@ -131,24 +136,29 @@ func newUploadCmd() *cobra.Command {
 
 			limiter := rate.NewLimiter(rate.Limit(rateLimit), 1)
 
-			for i, chunk := range chunks {
+			for i := 0; i < chunks.Count(); i++ {
 				// Check if already sent
 				var existingPlan *UploadPlan
 				for j := range progress.Plan {
-					if progress.Plan[j].Index == chunk.Index {
+					if progress.Plan[j].Index == uint32(i) {
 						existingPlan = &progress.Plan[j]
 						break
 					}
 				}
 				if existingPlan != nil {
 					if existingPlan.TxHash != "" {
-						fmt.Printf("Skipping chunk %d (already sent in tx: %s)\n", chunk.Index, existingPlan.TxHash)
+						fmt.Printf("Skipping chunk %d (already sent in tx: %s)\n", i, existingPlan.TxHash)
 					} else {
-						fmt.Printf("Skipping chunk %d (already sent)\n", chunk.Index)
+						fmt.Printf("Skipping chunk %d (already sent)\n", i)
 					}
 					continue
 				}
 
+				chunk, err := ReadChunk(chunks, gameID, uint32(i))
+				if err != nil {
+					return err
+				}
+
 				// Rate limit
 				if err := limiter.Wait(cmd.Context()); err != nil {
 					return err
@@ -173,7 +183,7 @@ func newUploadCmd() *cobra.Command {
 				})
 				progress.SentChunks++
 
-				fmt.Printf("Sent chunk %d/%d (tx: %s)\n", i+1, len(chunks), txHash)
+				fmt.Printf("Sent chunk %d/%d (tx: %s)\n", i+1, chunks.Count(), txHash)
 
 				// Save progress periodically
 				if (i+1)%10 == 0 {
@@ -211,9 +221,9 @@ func newUploadCmd() *cobra.Command {
 				// Generate manifest automatically after successful upload
 				if generateManifest {
 					if err := generateManifestAfterUpload(filePath, gameID, sender, network, manifestOutput, progressFile, title, platform); err != nil {
-						fmt.Printf("Warning: Failed to generate manifest: %v\n", err)
+						warnf("Failed to generate manifest: %v", err)
 					} else {
-						fmt.Printf("\n✓ Manifest generated: %s\n", manifestOutput)
+						statusf("\n✓ Manifest generated: %s\n", manifestOutput)
 					}
 				}
 			}
@@ -227,6 +237,7 @@ func newUploadCmd() *cobra.Command {
 	cmd.Flags().StringVar(&sender, "sender", "", "Sender address (defaults to ADDRESS from account_credentials.txt)")
 	cmd.Flags().StringVar(&receiver, "receiver", "NQ27 21G6 9BG1 JBHJ NUFA YVJS 1R6C D2X0 QAES", "Receiver address for data transactions")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Dry-run mode (output plan file only)")
+	cmd.Flags().StringVar(&maxSize, "max-size", "6MB", "Largest file accepted (bytes or KB/MB/GB)")
 	cmd.Flags().Float64Var(&rateLimit, "rate", 1.0, "Transaction rate limit (tx/s)")
 	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
 	cmd.Flags().Int64Var(&fee, "fee", 0, "Transaction fee in Luna (default: 0, minimum)")
@@ -245,11 +256,11 @@ func newUploadCmd() *cobra.Command {
 func saveProgress(filename string, progress *UploadProgress) {
 	data, err := json.MarshalIndent(progress, "", "  ")
 	if err != nil {
-		fmt.Printf("Warning: failed to marshal progress: %v\n", err)
+		warnf("failed to marshal progress: %v", err)
 		return
 	}
 	if err := os.WriteFile(filename, data, 0644); err != nil {
-		fmt.Printf("Warning: failed to save progress: %v\n", err)
+		warnf("failed to save progress: %v", err)
 	}
 }
 
@@ -262,14 +273,11 @@ func generateManifestAfterUpload(filePath string, gameID uint32, sender string,
 		}
 	}
 
-	// Read file
-	data, err := os.ReadFile(filePath)
+	// Hash the file as a stream; it never needs to be in memory
+	hash, size, err := CalculateFileSHA256(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
-
-	// Calculate SHA256
-	hash := sha256.Sum256(data)
 	sha256Hex := fmt.Sprintf("%x", hash)
 
 	// Get filename
@@ -284,7 +292,7 @@ func generateManifestAfterUpload(filePath string, gameID uint32, sender string,
 		Platform:      platform,
 		Filename:      filename,
 		Executable:    "", // Can be set manually after manifest generation
-		TotalSize:     uint64(len(data)),
+		TotalSize:     uint64(size),
 		ChunkSize:     51,
 		SHA256:        sha256Hex,
 		SenderAddress: sender,
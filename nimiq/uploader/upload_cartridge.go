@@ -1,19 +1,20 @@
 package main
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/retro-crypto/shared/logging"
 	"github.com/spf13/cobra"
-	"golang.org/x/time/rate"
 )
 
 type CartridgeUploadProgress struct {
@@ -21,9 +22,11 @@ type CartridgeUploadProgress struct {
 	CartridgeID   uint32       `json:"cartridge_id"`
 	CartridgeAddr string       `json:"cartridge_addr"`
 	TotalChunks   int          `json:"total_chunks"`
+	ChunkSize     uint8        `json:"chunk_size,omitempty"`
 	SentChunks    int          `json:"sent_chunks"`
 	FailedChunks  []int        `json:"failed_chunks,omitempty"`
 	CARTTxHash    string       `json:"cart_tx_hash,omitempty"`
+	CMETTxHashes  []string     `json:"cmet_tx_hashes,omitempty"`
 	CENTTxHash    string       `json:"cent_tx_hash,omitempty"`
 	Plan          []UploadPlan `json:"plan"`
 }
@@ -45,8 +48,21 @@ func newUploadCartridgeCmd() *cobra.Command {
 		fee              int64
 		generateCartAddr bool
 		schema           uint8
-		chunkSize        uint8
+		chunkSizeFlag    string
 		concurrency      int
+		stateDir         string
+		compress         string
+		forceUnlock      bool
+		planOut          string
+		unsignedOut      string
+		unsignedChunks   bool
+		chunkSender      string
+		channel          string
+		maxSize          string
+		genre            string
+		year             uint16
+		publisherName    string
+		description      string
 	)
 
 	cmd := &cobra.Command{
@@ -56,7 +72,22 @@ func newUploadCartridgeCmd() *cobra.Command {
 - Generates or uses a cartridge address
 - Uploads CART header transaction
 - Uploads DATA chunk transactions
-- Registers cartridge in catalog with CENT entry`,
+- Registers cartridge in catalog with CENT entry
+
+Files larger than --max-size (default 6MB) are sharded: each shard is uploaded
+as a cartridge at its own generated address, and the cartridge address in the
+catalog links the shards with SHRD records and carries the CART header of the
+whole file. Loaders reassemble the shards and verify the file's SHA256.
+
+With --compress gzip or zstd the file is compressed before it is chunked,
+which cuts the number of transactions for most ROMs. The CART header (schema
+2) then carries the compression and the uncompressed size and SHA256, and
+loaders decompress the file after verifying it.
+
+Titles longer than 15 bytes and --genre, --year, --publisher-name and
+--description are stored as extended metadata (CENT v2): CMET records sent to
+the catalog before the CENT entry, which keeps the shortened title for
+readers that don't know them.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Get RPC URL from env, credentials file, or default
 			if rpcURL == "" {
@@ -76,67 +107,114 @@ func newUploadCartridgeCmd() *cobra.Command {
 				return fmt.Errorf("catalog address is required (--catalog-addr)")
 			}
 
+			if unsignedOut != "" && dryRun {
+				return fmt.Errorf("--unsigned-out and --dry-run can't be combined")
+			}
+
+			if _, err := ParseChannel(channel); err != nil {
+				return err
+			}
+			compressFlag, err := ParseCompression(compress)
+			if err != nil {
+				return err
+			}
+			if compressFlag == CARTFlagZstd {
+				statusln("⚠️  The web player only decompresses gzip cartridges; zstd ones can only be read with download-cartridge")
+			}
+
 			// Resolve catalog address shortcuts
 			catalogAddr = resolveCatalogAddress(catalogAddr)
 
 			// Initialize RPC for catalog queries
 			rpc := NewNimiqRPC(rpcURL)
+			warnNetworkMismatch(rpc, catalogAddr)
+
+			// Allocating IDs is only safe while no other run is allocating from
+			// the same catalog. The new IDs are taken once our CENT entry lands,
+			// so the catalog lock is held for the whole run in that case.
+			allocatesIDs := appID == 0 || cartridgeID == 0
+			if allocatesIDs && !dryRun {
+				catalogLock, err := AcquireLock(catalogLockPath(catalogAddr), "upload-cartridge", forceUnlock)
+				if err != nil {
+					return err
+				}
+				defer catalogLock.Release()
+			}
+
+			// App-ids and cartridge-ids are allocated per (publisher, catalog):
+			// the sender's entries in this catalog, ignoring other catalogs
+			namespace := AppNamespace{Catalog: catalogAddr, Publisher: sender}
 
 			// Auto-generate app-id if not provided
 			// Note: Even in dry-run, we query the catalog to get correct IDs
 			if appID == 0 {
-				publisherAddr := sender // Use sender as publisher for filtering
 				// Try to find existing app-id by title first (for new versions)
 				if title != "" {
-					foundAppID, err := FindAppIDByTitle(rpc, catalogAddr, publisherAddr, title)
+					foundAppID, err := FindAppIDByTitle(rpc, namespace, title)
 					if err != nil {
-						fmt.Printf("Warning: failed to search for existing app-id by title: %v\n", err)
+						warnf("failed to search for existing app-id by title: %v", err)
 					} else if foundAppID > 0 {
 						appID = foundAppID
 						if dryRun {
-							fmt.Printf("Found existing app-id %d for title \"%s\" (new version, dry-run)\n", appID, title)
+							statusf("Found existing app-id %d for title \"%s\" (new version, dry-run)\n", appID, title)
 						} else {
-							fmt.Printf("Found existing app-id %d for title \"%s\" (new version)\n", appID, title)
-							logCartridgeUpload(fmt.Sprintf("Found existing app-id %d for title \"%s\"", appID, title))
+							statusf("Found existing app-id %d for title \"%s\" (new version)\n", appID, title)
 						}
 					} else {
-						fmt.Printf("No existing app-id found for title \"%s\" (will create new game)\n", title)
+						statusf("No existing app-id found for title \"%s\" (will create new game)\n", title)
 					}
 				}
 
 				// If not found by title, generate new app-id
 				if appID == 0 {
-					fmt.Println("Auto-generating new app-id...")
+					statusf("Auto-generating new app-id in %s...\n", namespace)
 					var err error
-					appID, err = GetMaxAppID(rpc, catalogAddr, publisherAddr)
+					appID, err = GetMaxAppID(rpc, namespace)
 					if err != nil {
 						return fmt.Errorf("failed to auto-generate app-id: %w", err)
 					}
 					if dryRun {
-						fmt.Printf("Auto-generated app-id: %d (new game, dry-run)\n", appID)
+						statusf("Auto-generated app-id: %d (new game, dry-run)\n", appID)
 					} else {
-						fmt.Printf("Auto-generated app-id: %d (new game)\n", appID)
+						statusf("Auto-generated app-id: %d (new game)\n", appID)
 					}
 				}
 			} else {
-				fmt.Printf("Using provided app-id: %d\n", appID)
+				statusf("Using provided app-id: %d\n", appID)
 			}
 
+			// All progress files for this run live in the state directory
+			runDir, err := ResolveRunDir(stateDir, catalogAddr, appID)
+			if err != nil {
+				return err
+			}
+			uploadRunDir = runDir
+			statusf("State directory: %s\n", runDir)
+			logCartridgeUpload(fmt.Sprintf("Using app-id %d for title \"%s\"", appID, title))
+
 			// Auto-generate cartridge-id if not provided
 			// Note: Even in dry-run, we query the catalog to get correct IDs
 			if cartridgeID == 0 {
-				fmt.Println("Auto-generating cartridge-id...")
-				publisherAddr := sender // Use sender as publisher for filtering
+				statusln("Auto-generating cartridge-id...")
 				var err error
-				cartridgeID, err = GetMaxCartridgeID(rpc, catalogAddr, publisherAddr, appID)
+				cartridgeID, err = GetMaxCartridgeID(rpc, namespace, appID)
 				if err != nil {
 					return fmt.Errorf("failed to auto-generate cartridge-id: %w", err)
 				}
 				if dryRun {
-					fmt.Printf("Auto-generated cartridge-id: %d (dry-run)\n", cartridgeID)
+					statusf("Auto-generated cartridge-id: %d (dry-run)\n", cartridgeID)
 				} else {
-					fmt.Printf("Auto-generated cartridge-id: %d\n", cartridgeID)
+					statusf("Auto-generated cartridge-id: %d\n", cartridgeID)
+				}
+			}
+
+			// Only one run may write a cartridge's progress file at a time
+			if !dryRun {
+				cartridgeLock, err := AcquireLock(filepath.Join(runDir, fmt.Sprintf("upload_cartridge_%d_%d.lock", appID, cartridgeID)), "upload-cartridge", forceUnlock)
+				if err != nil {
+					return err
 				}
+				defer cartridgeLock.Release()
 			}
 
 			// Validate semver format
@@ -153,28 +231,90 @@ func newUploadCartridgeCmd() *cobra.Command {
 				semverBytes[i] = uint8(val)
 			}
 
-			// Validate title length
-			if len(title) > 16 {
-				return fmt.Errorf("title must be <= 16 characters (got %d)", len(title))
+			// The CENT entry holds 15 bytes of the title; longer titles are
+			// stored in full in the extended metadata (CENT v2)
+			meta := CENTMetadata{Genre: genre, Year: year, Publisher: publisherName, Description: description}
+			if len(title) > 15 {
+				meta.Title = title
 			}
 
 			// Defaults
 			if schema == 0 {
 				schema = 1
 			}
-			if chunkSize == 0 {
-				chunkSize = 51
+			chunkSize, autoChunkSize, err := ParseChunkSize(chunkSizeFlag)
+			if err != nil {
+				return err
+			}
+			if autoChunkSize {
+				// A resumed upload keeps the size its chunks were cut at
+				if chunkSize = progressChunkSize(runDir, appID, cartridgeID); chunkSize != 0 {
+					statusf("Chunk size: %d bytes (from the upload being resumed)\n", chunkSize)
+				} else {
+					chunkSize = ResolveAutoChunkSize(rpcURL, sender, fee, !dryRun && unsignedOut == "")
+				}
+			}
+
+			// Files over --max-size are split across shard cartridges
+			maxSizeBytes, err := ParseByteSize(maxSize)
+			if err != nil {
+				return err
+			}
+
+			// With --compress the compressed file is what gets chunked (and
+			// sharded); the CART header records how to get the original back
+			var compression CARTCompression
+			if compressFlag != 0 {
+				if filePath, compression, err = compressCartridgeFile(filePath, runDir, cartridgeID, compressFlag); err != nil {
+					return err
+				}
+			}
+			fileInfo, err := os.Stat(filePath)
+			if err != nil {
+				return fmt.Errorf("failed to get file info: %w", err)
+			}
+			if fileInfo.Size() > maxSizeBytes {
+				if unsignedOut != "" || planOut != "" {
+					return fmt.Errorf("file size (%d bytes) exceeds --max-size (%d bytes): sharded uploads don't support --unsigned-out or --plan-out", fileInfo.Size(), maxSizeBytes)
+				}
+				sharded := &shardedUpload{
+					rpc:          rpc,
+					rpcURL:       rpcURL,
+					sender:       sender,
+					catalogAddr:  catalogAddr,
+					primaryAddr:  cartridgeAddr,
+					generateAddr: generateCartAddr,
+					appID:        appID,
+					cartridgeID:  cartridgeID,
+					title:        title,
+					meta:         meta,
+					semver:       semver,
+					semverBytes:  semverBytes,
+					platform:     platform,
+					schema:       schema,
+					chunkSize:    chunkSize,
+					channel:      channel,
+					fee:          fee,
+					rateLimit:    rateLimit,
+					concurrency:  concurrency,
+					dryRun:       dryRun,
+					runDir:       runDir,
+					filePath:     filePath,
+					maxSize:      maxSizeBytes,
+					compression:  compression,
+				}
+				return sharded.run(cmd.Context())
 			}
 
 			// Generate or use cartridge address
 			if generateCartAddr {
-				fmt.Println("Generating new cartridge address...")
+				statusln("Generating new cartridge address...")
 				account, err := rpc.CreateAccount()
 				if err != nil {
 					return fmt.Errorf("failed to create cartridge account: %w", err)
 				}
 				cartridgeAddr = account.Address
-				fmt.Printf("Generated cartridge address: %s\n", cartridgeAddr)
+				statusf("Generated cartridge address: %s\n", cartridgeAddr)
 				logCartridgeUpload(fmt.Sprintf("Generated new cartridge address: %s", cartridgeAddr))
 			}
 
@@ -182,45 +322,52 @@ func newUploadCartridgeCmd() *cobra.Command {
 				return fmt.Errorf("cartridge address is required (--cartridge-addr or --generate-cartridge-addr)")
 			}
 
-			// Validate cartridge address format
-			if !strings.HasPrefix(cartridgeAddr, "NQ") || len(cartridgeAddr) < 42 {
-				return fmt.Errorf("invalid cartridge address format: %s", cartridgeAddr)
+			// Validate cartridge address format and checksum
+			if err := ValidateAddressNQ(cartridgeAddr); err != nil {
+				return fmt.Errorf("invalid cartridge address %s: %w", cartridgeAddr, err)
 			}
 
-			// Check file size limit (6MB)
-			const maxFileSize = 6 * 1024 * 1024 // 6MB
-			fileInfo, err := os.Stat(filePath)
+			// Hash the file as a stream; the chunks are read from it on
+			// demand, so the file is never held in memory
+			sha256Hash, _, err := CalculateFileSHA256(filePath)
 			if err != nil {
-				return fmt.Errorf("failed to get file info: %w", err)
-			}
-			if fileInfo.Size() > maxFileSize {
-				return fmt.Errorf("file size (%d bytes) exceeds maximum allowed size of 6MB (%d bytes)", fileInfo.Size(), maxFileSize)
+				return fmt.Errorf("failed to read file: %w", err)
 			}
-
-			// Read file and calculate SHA256
-			fileData, err := os.ReadFile(filePath)
+			chunks, err := OpenFileChunks(filePath, int(chunkSize))
 			if err != nil {
-				return fmt.Errorf("failed to read file: %w", err)
+				return err
 			}
+			defer chunks.Close()
 
-			sha256Hash, err := CalculateFileSHA256(filePath)
-			if err != nil {
-				return fmt.Errorf("failed to calculate SHA256: %w", err)
+			// Record generated IDs in the project state file (not in dry-run)
+			projectCart := ProjectCartridge{
+				CartridgeID:   cartridgeID,
+				CartridgeAddr: cartridgeAddr,
+				Semver:        semver,
+				File:          filePath,
+				SHA256:        hex.EncodeToString(sha256Hash[:]),
+			}
+			if !dryRun {
+				RecordCartridge(catalogAddr, title, appID, projectCart)
 			}
 
-			totalSize := uint64(len(fileData))
-			expectedChunks := int((totalSize + uint64(chunkSize) - 1) / uint64(chunkSize))
+			totalSize := uint64(chunks.Size())
+			expectedChunks := chunks.Count()
 
-			fmt.Printf("\n=== Upload Configuration ===\n")
-			fmt.Printf("File: %s\n", filePath)
-			fmt.Printf("Size: %d bytes\n", totalSize)
-			fmt.Printf("SHA256: %s\n", hex.EncodeToString(sha256Hash[:]))
-			fmt.Printf("Expected chunks: %d\n", expectedChunks)
-			fmt.Printf("App ID: %d\n", appID)
-			fmt.Printf("Cartridge ID: %d\n", cartridgeID)
-			fmt.Printf("Cartridge Address: %s\n", cartridgeAddr)
-			fmt.Printf("Catalog Address: %s\n", catalogAddr)
-			fmt.Printf("===========================\n\n")
+			statusf("\n=== Upload Configuration ===\n")
+			statusf("File: %s\n", filePath)
+			statusf("Size: %d bytes\n", totalSize)
+			statusf("SHA256: %s\n", hex.EncodeToString(sha256Hash[:]))
+			if compression.Flag != 0 {
+				statusf("Compression: %s (uncompressed %d bytes)\n", CompressionName(compression.Flag), compression.UncompressedSize)
+			}
+			statusf("Expected chunks: %d\n", expectedChunks)
+			statusf("App ID: %d\n", appID)
+			statusf("Channel: %s\n", channel)
+			statusf("Cartridge ID: %d\n", cartridgeID)
+			statusf("Cartridge Address: %s\n", cartridgeAddr)
+			statusf("Catalog Address: %s\n", catalogAddr)
+			statusf("===========================\n\n")
 
 			// Log upload start
 			logCartridgeUpload("=== Upload Started ===")
@@ -239,33 +386,137 @@ func newUploadCartridgeCmd() *cobra.Command {
 			logCartridgeUpload(fmt.Sprintf("Expected chunks: %d", expectedChunks))
 
 			// Load or create progress (include app-id in filename to avoid conflicts)
-			progressFile := fmt.Sprintf("upload_cartridge_%d_%d.json", appID, cartridgeID)
+			progressName := fmt.Sprintf("upload_cartridge_%d_%d.json", appID, cartridgeID)
+			progressFile := filepath.Join(runDir, progressName)
 			progress := &CartridgeUploadProgress{
 				AppID:         appID,
 				CartridgeID:   cartridgeID,
 				CartridgeAddr: cartridgeAddr,
 				TotalChunks:   expectedChunks,
+				ChunkSize:     chunkSize,
 				SentChunks:    0,
 				Plan:          make([]UploadPlan, 0, expectedChunks),
 			}
 
+			// Progress files used to live in the current directory; pick those up
+			// so uploads started before the state directory existed still resume
+			progressData, err := os.ReadFile(progressFile)
+			if os.IsNotExist(err) {
+				if legacyData, legacyErr := os.ReadFile(progressName); legacyErr == nil {
+					statusf("Found progress file %s in current directory, continuing in %s\n", progressName, runDir)
+					progressData, err = legacyData, nil
+				}
+			}
+
 			// Try to load existing progress, but validate it matches current upload
-			if data, err := os.ReadFile(progressFile); err == nil {
+			if data := progressData; err == nil {
 				var loadedProgress CartridgeUploadProgress
 				if err := json.Unmarshal(data, &loadedProgress); err == nil {
 					// Only use loaded progress if it matches current upload
 					if loadedProgress.AppID == appID && loadedProgress.CartridgeID == cartridgeID &&
-						loadedProgress.CartridgeAddr == cartridgeAddr && loadedProgress.TotalChunks == expectedChunks {
+						loadedProgress.CartridgeAddr == cartridgeAddr && loadedProgress.TotalChunks == expectedChunks &&
+						(loadedProgress.ChunkSize == chunkSize || loadedProgress.ChunkSize == 0 && chunkSize == DefaultChunkSize) {
+						loadedProgress.ChunkSize = chunkSize
 						progress = &loadedProgress
-						fmt.Printf("Resuming from progress file: %s\n", progressFile)
+						statusf("Resuming from progress file: %s\n", progressFile)
 					} else {
-						fmt.Printf("Progress file exists but doesn't match current upload. Starting fresh.\n")
+						statusf("Progress file exists but doesn't match current upload. Starting fresh.\n")
+					}
+				}
+			}
+
+			// CART header and CENT entry are fixed by the inputs; build them up
+			// front so the dry-run plan describes exactly what will be sent
+			cartHeader := CARTHeader{
+				Schema:      schema,
+				Platform:    platform,
+				ChunkSize:   chunkSize,
+				Flags:       0,
+				CartridgeID: cartridgeID,
+				TotalSize:   totalSize,
+				SHA256:      sha256Hash,
+			}
+			compression.Apply(&cartHeader)
+
+			cartAddrBytes, err := AddressNQToBytes(cartridgeAddr)
+			if err != nil {
+				return fmt.Errorf("failed to convert cartridge address: %w", err)
+			}
+
+			centEntry := CENTEntry{
+				Schema:        schema,
+				Platform:      platform,
+				Flags:         ChannelFlags(channel),
+				AppID:         appID,
+				Semver:        semverBytes,
+				CartridgeAddr: cartAddrBytes,
+				TitleShort:    title,
+			}
+			centPayload, metaRecords, err := EncodeCENT2(centEntry, meta)
+			if err != nil {
+				return fmt.Errorf("failed to encode CENT entry: %w", err)
+			}
+
+			// With --unsigned-out --unsigned-chunks nothing is sent: every
+			// transaction goes to the file for the sender's wallet to sign
+			if unsignedOut != "" && unsignedChunks {
+				batch, height, err := newUnsignedBatch(rpc)
+				if err != nil {
+					return err
+				}
+				for i := 0; i < chunks.Count(); i++ {
+					data, err := chunks.Chunk(uint32(i))
+					if err != nil {
+						return err
+					}
+					encoded, err := EncodeDATA(DATAPayload{
+						CartridgeID: cartridgeID,
+						ChunkIndex:  uint32(i),
+						Length:      uint8(len(data)),
+						Data:        data,
+					})
+					if err != nil {
+						return fmt.Errorf("failed to encode chunk %d: %w", i, err)
+					}
+					if err := batch.Add(sender, cartridgeAddr, encoded, fee, height); err != nil {
+						return err
 					}
 				}
+				if err := addUnsignedHeaders(batch, sender, cartridgeAddr, catalogAddr, cartHeader, centPayload, metaRecords, fee, height, true); err != nil {
+					return err
+				}
+				return batch.Write(unsignedOut)
 			}
 
 			var txSender TxSender
 			if dryRun {
+				plan, err := BuildCartridgePlan(CartridgePlan{
+					File:          filePath,
+					Size:          totalSize,
+					SHA256:        hex.EncodeToString(sha256Hash[:]),
+					AppID:         appID,
+					CartridgeID:   cartridgeID,
+					CartridgeAddr: cartridgeAddr,
+					CatalogAddr:   catalogAddr,
+					Title:         title,
+					Semver:        semver,
+					Platform:      platform,
+					Schema:        schema,
+					ChunkSize:     chunkSize,
+					Fee:           fee,
+					Rate:          rateLimit,
+				}, chunks, cartHeader, centPayload, metaRecords)
+				if err != nil {
+					return fmt.Errorf("failed to build upload plan: %w", err)
+				}
+				PrintPlanSummary(plan)
+				if planOut != "" {
+					if err := SaveCartridgePlan(planOut, plan); err != nil {
+						return err
+					}
+					fmt.Printf("Plan written to %s\n", planOut)
+				}
+
 				txSender = &DryRunSender{}
 			} else {
 				// Check consensus before proceeding
@@ -277,13 +528,41 @@ func newUploadCartridgeCmd() *cobra.Command {
 					return fmt.Errorf("node does not have consensus with the network - cannot upload. Wait for sync or use --dry-run")
 				}
 
+				// With --unsigned-out only the chunks are sent here, from the
+				// node's account; the wallet signs CART and CENT
+				from := sender
+				if unsignedOut != "" {
+					from = chunkSender
+					if from == "" {
+						from = GetDefaultAddress()
+					}
+					if from == "" {
+						return fmt.Errorf("--chunk-sender is required to upload chunks with --unsigned-out (or use --unsigned-chunks)")
+					}
+				}
+
 				// Create RPC sender for cartridge address (will be used for CART and DATA)
-				fmt.Printf("Sending transactions from %s\n", sender)
-				rpcSender, err := NewRPCSender(rpcURL, sender, cartridgeAddr, fee)
+				statusf("Sending transactions from %s\n", from)
+				rpcSender, err := NewRPCSender(rpcURL, from, cartridgeAddr, fee)
 				if err != nil {
 					return fmt.Errorf("failed to initialize RPC sender: %w", err)
 				}
 				txSender = rpcSender
+
+				// Adopt what an interrupted run sent but didn't get to save
+				cartPayload, err := EncodeCART(cartHeader)
+				if err != nil {
+					return fmt.Errorf("failed to encode CART header: %w", err)
+				}
+				reconcileAndSave(rpc, progress, progressFile, reconcileTarget{
+					Senders: []string{from, sender},
+					Chunk: func(index uint32) ([]byte, error) {
+						return encodeChunk(chunks, cartridgeID, index)
+					},
+					CART:        cartPayload,
+					CatalogAddr: catalogAddr,
+					CENT:        centPayload,
+				})
 			}
 
 			// Validate and cap concurrency
@@ -295,168 +574,51 @@ func newUploadCartridgeCmd() *cobra.Command {
 			}
 
 			// Use burst size equal to concurrency for smoother parallel uploads
-			limiter := rate.NewLimiter(rate.Limit(rateLimit), concurrency)
+			controlDir := runDir
+			if dryRun {
+				controlDir = ""
+			}
+			control := StartUploadControl(controlDir, "upload-cartridge", rateLimit, concurrency)
+			defer control.Close()
+			control.Expect(progress.TotalChunks - progress.SentChunks + 2 + len(metaRecords) - len(progress.CMETTxHashes))
+			txSender = control.Track(txSender)
+			accounting := &UploadAccounting{FeeLuna: fee}
 
 			// Step 1: Send DATA chunks FIRST
 			// (CART header is sent AFTER all chunks so it appears in newest transactions for faster loading)
-			fmt.Printf("\n=== Step 1: Uploading DATA chunks (concurrency: %d) ===\n", concurrency)
+			statusf("\n=== Step 1: Uploading DATA chunks (concurrency: %d) ===\n", concurrency)
 
-			// fileData was already read earlier for SHA256 calculation - reuse it
-			// Build list of chunks to upload (skip already sent)
-			type chunkWork struct {
-				index uint32
-				data  []byte
-			}
-			var chunksToUpload []chunkWork
-			sentHashes := make(map[uint32]string) // index -> txHash for already sent
+			accounting.ToCartridge += int(sendDataChunks(cmd.Context(), txSender, chunks, cartridgeID, progress, progressFile, control, concurrency))
 
-			for _, plan := range progress.Plan {
-				if plan.TxHash != "" {
-					sentHashes[plan.Index] = plan.TxHash
-				}
-			}
+			// Final save
+			saveCartridgeProgress(progressFile, progress)
 
-			for i := 0; i < len(fileData); i += int(chunkSize) {
-				end := i + int(chunkSize)
-				if end > len(fileData) {
-					end = len(fileData)
+			if unsignedOut != "" {
+				if progress.SentChunks != progress.TotalChunks {
+					return fmt.Errorf("not all chunks uploaded yet (%d/%d) - run again to retry before the CART and CENT transactions are written", progress.SentChunks, progress.TotalChunks)
 				}
-				chunkIdx := uint32(i / int(chunkSize))
-
-				if txHash, ok := sentHashes[chunkIdx]; ok {
-					fmt.Printf("Skipping chunk %d (already sent: %s)\n", chunkIdx, txHash[:16])
-					continue
+				batch, height, err := newUnsignedBatch(rpc)
+				if err != nil {
+					return err
 				}
-
-				chunkData := make([]byte, end-i)
-				copy(chunkData, fileData[i:end])
-				chunksToUpload = append(chunksToUpload, chunkWork{index: chunkIdx, data: chunkData})
-			}
-
-			fmt.Printf("Chunks to upload: %d (already sent: %d)\n", len(chunksToUpload), len(sentHashes))
-
-			if len(chunksToUpload) > 0 {
-				// Create worker pool for parallel uploads
-				var wg sync.WaitGroup
-				var mu sync.Mutex
-				var sentCount int64
-				var failedCount int64
-				startTime := time.Now()
-
-				// Create work channel
-				workChan := make(chan chunkWork, len(chunksToUpload))
-				for _, chunk := range chunksToUpload {
-					workChan <- chunk
-				}
-				close(workChan)
-
-				// Start workers
-				for w := 0; w < concurrency; w++ {
-					wg.Add(1)
-					go func(workerID int) {
-						defer wg.Done()
-
-						for chunk := range workChan {
-							// Rate limit
-							if err := limiter.Wait(cmd.Context()); err != nil {
-								return
-							}
-
-							dataPayload := DATAPayload{
-								CartridgeID: cartridgeID,
-								ChunkIndex:  chunk.index,
-								Length:      uint8(len(chunk.data)),
-								Data:        chunk.data,
-							}
-
-							encoded, err := EncodeDATA(dataPayload)
-							if err != nil {
-								fmt.Printf("[W%d] Failed to encode chunk %d: %v\n", workerID, chunk.index, err)
-								atomic.AddInt64(&failedCount, 1)
-								mu.Lock()
-								progress.FailedChunks = append(progress.FailedChunks, int(chunk.index))
-								mu.Unlock()
-								continue
-							}
-
-							txHash, err := txSender.SendTransaction(encoded)
-							if err != nil {
-								fmt.Printf("[W%d] Failed to send chunk %d: %v\n", workerID, chunk.index, err)
-								atomic.AddInt64(&failedCount, 1)
-								mu.Lock()
-								progress.FailedChunks = append(progress.FailedChunks, int(chunk.index))
-								mu.Unlock()
-								continue
-							}
-
-							// Update progress (thread-safe)
-							mu.Lock()
-							progress.Plan = append(progress.Plan, UploadPlan{
-								Index:   chunk.index,
-								Payload: hex.EncodeToString(encoded),
-								TxHash:  txHash,
-							})
-							progress.SentChunks++
-							currentSent := progress.SentChunks
-							mu.Unlock()
-
-							sent := atomic.AddInt64(&sentCount, 1)
-							elapsed := time.Since(startTime).Seconds()
-							rate := float64(sent) / elapsed
-							remaining := float64(len(chunksToUpload)-int(sent)) / rate
-
-							fmt.Printf("[W%d] Sent chunk %d/%d (%.1f tx/s, ETA: %.0fs)\n",
-								workerID, currentSent, expectedChunks, rate, remaining)
-
-							// Save progress periodically (every 10 successful sends across all workers)
-							if sent%10 == 0 {
-								mu.Lock()
-								saveCartridgeProgress(progressFile, progress)
-								mu.Unlock()
-							}
-
-							// Log every 100 chunks
-							if sent%100 == 0 {
-								logCartridgeUpload(fmt.Sprintf("Progress: %d/%d chunks sent (%.1f tx/s)", currentSent, expectedChunks, rate))
-							}
-						}
-					}(w)
+				if progress.CARTTxHash != "" {
+					statusf("CART header already sent: %s\n", progress.CARTTxHash)
 				}
-
-				// Wait for all workers to complete
-				wg.Wait()
-
-				elapsed := time.Since(startTime).Seconds()
-				finalRate := float64(sentCount) / elapsed
-				fmt.Printf("\n✓ Uploaded %d chunks in %.1fs (%.1f tx/s avg)\n", sentCount, elapsed, finalRate)
-
-				if failedCount > 0 {
-					fmt.Printf("⚠️  %d chunks failed - run again to retry\n", failedCount)
+				if err := addUnsignedHeaders(batch, sender, cartridgeAddr, catalogAddr, cartHeader, centPayload, metaRecords, fee, height, progress.CARTTxHash == ""); err != nil {
+					return err
 				}
+				return batch.Write(unsignedOut)
 			}
 
-			// Final save
-			saveCartridgeProgress(progressFile, progress)
-
 			// Step 2: Send CART header AFTER all chunks (so it's in newest transactions for faster loading)
 			if progress.SentChunks == progress.TotalChunks && progress.CARTTxHash == "" {
-				fmt.Println("\n=== Step 2: Uploading CART header ===")
-				cartHeader := CARTHeader{
-					Schema:      schema,
-					Platform:    platform,
-					ChunkSize:   chunkSize,
-					Flags:       0,
-					CartridgeID: cartridgeID,
-					TotalSize:   totalSize,
-					SHA256:      sha256Hash,
-				}
-
+				statusln("\n=== Step 2: Uploading CART header ===")
 				cartPayload, err := EncodeCART(cartHeader)
 				if err != nil {
 					return fmt.Errorf("failed to encode CART header: %w", err)
 				}
 
-				if err := limiter.Wait(cmd.Context()); err != nil {
+				if err := control.Wait(cmd.Context()); err != nil {
 					return err
 				}
 
@@ -466,44 +628,25 @@ func newUploadCartridgeCmd() *cobra.Command {
 				}
 
 				progress.CARTTxHash = txHash
-				fmt.Printf("✓ CART header sent: %s\n", txHash)
+				accounting.ToCartridge++
+				statusf("✓ CART header sent: %s\n", txHash)
+				printExplorerLink("  ", networkForCatalog(catalogAddr), LinkTx, txHash)
 				saveCartridgeProgress(progressFile, progress)
 				logCartridgeUpload(fmt.Sprintf("CART header sent: %s", txHash))
 			} else if progress.CARTTxHash != "" {
-				fmt.Printf("CART header already sent: %s\n", progress.CARTTxHash)
+				statusf("CART header already sent: %s\n", progress.CARTTxHash)
 			}
 
 			// Step 3: Send CENT entry to catalog if all chunks AND CART header are uploaded
 			if progress.SentChunks == progress.TotalChunks && progress.CARTTxHash != "" && progress.CENTTxHash == "" {
-				fmt.Println("\n=== Step 3: Registering cartridge in catalog (CENT) ===")
-
-				// Convert cartridge address to bytes
-				cartAddrBytes, err := AddressNQToBytes(cartridgeAddr)
-				if err != nil {
-					return fmt.Errorf("failed to convert cartridge address: %w", err)
-				}
-
-				centEntry := CENTEntry{
-					Schema:        schema,
-					Platform:      platform,
-					Flags:         0,
-					AppID:         appID,
-					Semver:        semverBytes,
-					CartridgeAddr: cartAddrBytes,
-					TitleShort:    title,
-				}
-
-				centPayload, err := EncodeCENT(centEntry)
-				if err != nil {
-					return fmt.Errorf("failed to encode CENT entry: %w", err)
-				}
+				statusln("\n=== Step 3: Registering cartridge in catalog (CENT) ===")
 
 				// Create sender for catalog address
 				var catalogSender TxSender
 				if dryRun {
 					catalogSender = &DryRunSender{}
 				} else {
-					if err := limiter.Wait(cmd.Context()); err != nil {
+					if err := control.Wait(cmd.Context()); err != nil {
 						return err
 					}
 
@@ -511,7 +654,17 @@ func newUploadCartridgeCmd() *cobra.Command {
 					if err != nil {
 						return fmt.Errorf("failed to initialize catalog RPC sender: %w", err)
 					}
-					catalogSender = catalogRpcSender
+					catalogSender = control.Track(catalogRpcSender)
+				}
+
+				// Extended metadata goes first, so the entry is complete
+				// once it is visible
+				sent, err := sendMetadataRecords(cmd.Context(), control, catalogSender, metaRecords, &progress.CMETTxHashes, !dryRun, func() {
+					saveCartridgeProgress(progressFile, progress)
+				})
+				accounting.ToCatalog += sent
+				if err != nil {
+					return err
 				}
 
 				txHash, err := catalogSender.SendTransaction(centPayload)
@@ -520,23 +673,34 @@ func newUploadCartridgeCmd() *cobra.Command {
 				}
 
 				progress.CENTTxHash = txHash
-				fmt.Printf("✓ CENT entry sent to catalog: %s\n", txHash)
+				accounting.ToCatalog++
+				statusf("✓ CENT entry sent to catalog: %s\n", txHash)
+				printExplorerLink("  ", networkForCatalog(catalogAddr), LinkTx, txHash)
 				saveCartridgeProgress(progressFile, progress)
 				logCartridgeUpload(fmt.Sprintf("CENT entry sent to catalog: %s", txHash))
+				if !dryRun {
+					projectCart.CENTTxHash = txHash
+					RecordCartridge(catalogAddr, title, appID, projectCart)
+				}
 			} else if progress.CENTTxHash != "" {
-				fmt.Printf("CENT entry already sent: %s\n", progress.CENTTxHash)
+				statusf("CENT entry already sent: %s\n", progress.CENTTxHash)
 			} else {
-				fmt.Printf("\n⚠️  Not all chunks uploaded yet (%d/%d). CENT entry will be sent when complete.\n", progress.SentChunks, progress.TotalChunks)
+				statusf("\n⚠️  Not all chunks uploaded yet (%d/%d). CENT entry will be sent when complete.\n", progress.SentChunks, progress.TotalChunks)
 			}
 
 			if dryRun {
 				fmt.Printf("\nDry-run complete. Upload plan saved to %s\n", progressFile)
 			} else {
-				fmt.Printf("\n✓ Upload complete!\n")
+				network := networkForCatalog(catalogAddr)
+				statusf("\n✓ Upload complete!\n")
+				fmt.Printf("  Cartridge address: %s\n", cartridgeAddr)
+				printExplorerLink("    ", network, LinkAddress, cartridgeAddr)
 				fmt.Printf("  CART header: %s\n", progress.CARTTxHash)
+				printExplorerLink("    ", network, LinkTx, progress.CARTTxHash)
 				fmt.Printf("  DATA chunks: %d/%d\n", progress.SentChunks, progress.TotalChunks)
 				if progress.CENTTxHash != "" {
 					fmt.Printf("  CENT entry: %s\n", progress.CENTTxHash)
+					printExplorerLink("    ", network, LinkTx, progress.CENTTxHash)
 				}
 				if len(progress.FailedChunks) > 0 {
 					fmt.Printf("  Failed chunks: %v\n", progress.FailedChunks)
@@ -552,7 +716,12 @@ func newUploadCartridgeCmd() *cobra.Command {
 				if len(progress.FailedChunks) > 0 {
 					logCartridgeUpload(fmt.Sprintf("Failed chunks: %v", progress.FailedChunks))
 				}
-				logCartridgeUpload("") // Empty line for readability
+
+				accounting.CartridgeSweepable, _ = rpc.IsAccountImported(cartridgeAddr)
+				accounting.PrintReport()
+				RecordSpend(catalogAddr, title, appID, accounting)
+
+				cartridgeSummary(title, progress, catalogAddr, semver, hex.EncodeToString(sha256Hash[:]), fee).write()
 			}
 
 			return nil
@@ -562,20 +731,33 @@ func newUploadCartridgeCmd() *cobra.Command {
 	cmd.Flags().StringVar(&filePath, "file", "", "Path to file to upload (required)")
 	cmd.Flags().Uint32Var(&appID, "app-id", 0, "App ID (uint32, auto-generated if not provided)")
 	cmd.Flags().Uint32Var(&cartridgeID, "cartridge-id", 0, "Cartridge ID (uint32, auto-generated if not provided)")
-	cmd.Flags().StringVar(&title, "title", "", "Short title (max 16 chars, required)")
+	cmd.Flags().StringVar(&title, "title", "", "Title (required; titles over 15 bytes are stored in full as extended metadata)")
 	cmd.Flags().StringVar(&semver, "semver", "", "Semantic version (e.g., 1.0.0, required)")
 	cmd.Flags().Uint8Var(&platform, "platform", 0, "Platform code: 0=DOS, 1=GB, 2=GBC, 3=NES (default: 0)")
 	cmd.Flags().StringVar(&cartridgeAddr, "cartridge-addr", "", "Cartridge address (NQ..., or use --generate-cartridge-addr)")
 	cmd.Flags().BoolVar(&generateCartAddr, "generate-cartridge-addr", false, "Generate a new cartridge address")
-	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address (NQ..., 'main', 'test', required)")
+	cmd.Flags().StringVar(&catalogAddr, "catalog-addr", "", "Catalog address or alias (NQ..., 'main', 'test', see catalog-alias list; required)")
 	cmd.Flags().StringVar(&sender, "sender", "", "Sender address (defaults to ADDRESS from account_credentials.txt)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Dry-run mode (output plan file only)")
+	cmd.Flags().StringVar(&unsignedOut, "unsigned-out", "", "Don't sign CART/CENT with the node: write them as unsigned transactions for the --sender wallet to sign (see submit-signed)")
+	cmd.Flags().BoolVar(&unsignedChunks, "unsigned-chunks", false, "With --unsigned-out: write the DATA chunks unsigned too instead of sending them from the node")
+	cmd.Flags().StringVar(&chunkSender, "chunk-sender", "", "With --unsigned-out: node account that sends the DATA chunks (defaults to ADDRESS from credentials)")
+	cmd.Flags().StringVar(&channel, "channel", ChannelStable, "Release channel: stable or beta (beta versions are hidden from default listings, see promote-channel)")
+	cmd.Flags().StringVar(&compress, "compress", "none", "Compress the file before chunking: none, gzip or zstd (stored as a schema 2 CART header; the web player reads gzip only)")
+	cmd.Flags().StringVar(&maxSize, "max-size", "6MB", "Largest file stored in one cartridge; bigger files are split across shard cartridges linked by SHRD records")
+	cmd.Flags().StringVar(&planOut, "plan-out", "", "With --dry-run: write the machine-readable upload plan (operations, fees, duration) to this file")
 	cmd.Flags().Float64Var(&rateLimit, "rate", 25.0, "Transaction rate limit (tx/s, default: 25)")
 	cmd.Flags().StringVar(&rpcURL, "rpc-url", "", "Nimiq RPC URL (default: from credentials or localhost:8648)")
 	cmd.Flags().Int64Var(&fee, "fee", 0, "Transaction fee in Luna (default: 0, minimum)")
 	cmd.Flags().Uint8Var(&schema, "schema", 1, "Schema version (default: 1)")
-	cmd.Flags().Uint8Var(&chunkSize, "chunk-size", 51, "Chunk size in bytes (default: 51)")
+	cmd.Flags().StringVar(&genre, "genre", "", "Genre, stored as extended metadata (CENT v2)")
+	cmd.Flags().Uint16Var(&year, "year", 0, "Release year, stored as extended metadata (CENT v2)")
+	cmd.Flags().StringVar(&publisherName, "publisher-name", "", "Publisher or developer name, stored as extended metadata (CENT v2)")
+	cmd.Flags().StringVar(&description, "description", "", "Description, stored as extended metadata (CENT v2)")
+	cmd.Flags().StringVar(&chunkSizeFlag, "chunk-size", "51", "Chunk size in bytes (1-255), or auto to use the largest the node accepts (probed once per RPC URL)")
 	cmd.Flags().IntVar(&concurrency, "concurrency", 1, "Number of parallel upload workers (default: 1, max: 10)")
+	cmd.Flags().BoolVar(&forceUnlock, "force-unlock", false, "Take over lock files left by another run (only if it is no longer running)")
+	cmd.Flags().StringVar(&stateDir, "state-dir", "", "Directory for progress and log files (default: $XDG_STATE_HOME/nimiq-uploader/<catalog>/<app-id>)")
 
 	cmd.MarkFlagRequired("file")
 	cmd.MarkFlagRequired("title")
@@ -588,38 +770,225 @@ func newUploadCartridgeCmd() *cobra.Command {
 func saveCartridgeProgress(filename string, progress *CartridgeUploadProgress) {
 	data, err := json.MarshalIndent(progress, "", "  ")
 	if err != nil {
-		fmt.Printf("Warning: failed to marshal progress: %v\n", err)
+		warnf("failed to marshal progress: %v", err)
 		return
 	}
 	if err := os.WriteFile(filename, data, 0644); err != nil {
-		fmt.Printf("Warning: failed to save progress: %v\n", err)
+		warnf("failed to save progress: %v", err)
 	}
 }
 
-// logCartridgeUpload writes upload information to upload_cartridge.log
+// sendDataChunks sends the DATA chunks not yet recorded in progress with
+// concurrency workers, saving progress every 10 sends. It returns the number
+// of chunks sent; failed chunks are recorded in progress.FailedChunks.
+func sendDataChunks(ctx context.Context, txSender TxSender, chunks *FileChunks, cartridgeID uint32, progress *CartridgeUploadProgress, progressFile string, control *UploadControl, concurrency int) int64 {
+	// Build list of chunks to upload (skip already sent); workers read
+	// each chunk from the file when they send it
+	type chunkWork struct {
+		index uint32
+	}
+	var chunksToUpload []chunkWork
+	sentHashes := make(map[uint32]string) // index -> txHash for already sent
+
+	for _, plan := range progress.Plan {
+		if plan.TxHash != "" {
+			sentHashes[plan.Index] = plan.TxHash
+		}
+	}
+
+	for i := 0; i < chunks.Count(); i++ {
+		chunkIdx := uint32(i)
+
+		if txHash, ok := sentHashes[chunkIdx]; ok {
+			statusf("Skipping chunk %d (already sent: %s)\n", chunkIdx, txHash[:16])
+			continue
+		}
+
+		chunksToUpload = append(chunksToUpload, chunkWork{index: chunkIdx})
+	}
+
+	statusf("Chunks to upload: %d (already sent: %d)\n", len(chunksToUpload), len(sentHashes))
+
+	if len(chunksToUpload) == 0 {
+		return 0
+	}
+
+	// Create worker pool for parallel uploads
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var sentCount int64
+	var failedCount int64
+	startTime := time.Now()
+
+	// Create work channel
+	workChan := make(chan chunkWork, len(chunksToUpload))
+	for _, chunk := range chunksToUpload {
+		workChan <- chunk
+	}
+	close(workChan)
+
+	// Start workers
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+
+			for chunk := range workChan {
+				// Rate limit (and hold while paused)
+				if err := control.Wait(ctx); err != nil {
+					return
+				}
+
+				data, err := chunks.Chunk(chunk.index)
+				if err != nil {
+					fmt.Printf("[W%d] Failed to read chunk %d: %v\n", workerID, chunk.index, err)
+					atomic.AddInt64(&failedCount, 1)
+					mu.Lock()
+					progress.FailedChunks = append(progress.FailedChunks, int(chunk.index))
+					mu.Unlock()
+					continue
+				}
+
+				dataPayload := DATAPayload{
+					CartridgeID: cartridgeID,
+					ChunkIndex:  chunk.index,
+					Length:      uint8(len(data)),
+					Data:        data,
+				}
+
+				encoded, err := EncodeDATA(dataPayload)
+				if err != nil {
+					fmt.Printf("[W%d] Failed to encode chunk %d: %v\n", workerID, chunk.index, err)
+					atomic.AddInt64(&failedCount, 1)
+					mu.Lock()
+					progress.FailedChunks = append(progress.FailedChunks, int(chunk.index))
+					mu.Unlock()
+					continue
+				}
+
+				txHash, err := txSender.SendTransaction(encoded)
+				if err != nil {
+					fmt.Printf("[W%d] Failed to send chunk %d: %v\n", workerID, chunk.index, err)
+					atomic.AddInt64(&failedCount, 1)
+					mu.Lock()
+					progress.FailedChunks = append(progress.FailedChunks, int(chunk.index))
+					mu.Unlock()
+					continue
+				}
+
+				// Update progress (thread-safe)
+				mu.Lock()
+				progress.Plan = append(progress.Plan, UploadPlan{
+					Index:   chunk.index,
+					Payload: hex.EncodeToString(encoded),
+					TxHash:  txHash,
+				})
+				progress.SentChunks++
+				currentSent := progress.SentChunks
+				mu.Unlock()
+
+				sent := atomic.AddInt64(&sentCount, 1)
+				elapsed := time.Since(startTime).Seconds()
+				rate := float64(sent) / elapsed
+				remaining := float64(len(chunksToUpload)-int(sent)) / rate
+
+				statusf("[W%d] Sent chunk %d/%d (%.1f tx/s, ETA: %.0fs)\n",
+					workerID, currentSent, progress.TotalChunks, rate, remaining)
+
+				// Save progress periodically (every 10 successful sends across all workers)
+				if sent%10 == 0 {
+					mu.Lock()
+					saveCartridgeProgress(progressFile, progress)
+					mu.Unlock()
+				}
+
+				// Log every 100 chunks
+				if sent%100 == 0 {
+					logCartridgeUpload(fmt.Sprintf("Progress: %d/%d chunks sent (%.1f tx/s)", currentSent, progress.TotalChunks, rate))
+				}
+			}
+		}(w)
+	}
+
+	// Wait for all workers to complete
+	wg.Wait()
+
+	elapsed := time.Since(startTime).Seconds()
+	finalRate := float64(sentCount) / elapsed
+	statusf("\n✓ Uploaded %d chunks in %.1fs (%.1f tx/s avg)\n", sentCount, elapsed, finalRate)
+
+	if failedCount > 0 {
+		statusf("⚠️  %d chunks failed - run again to retry\n", failedCount)
+	}
+
+	return sentCount
+}
+
+// uploadRunDir is the state directory of the current run, recorded with
+// each upload log record
+var uploadRunDir string
+
+// logCartridgeUpload records upload information in the log file, marked
+// log=upload together with the run's state directory
 func logCartridgeUpload(message string) {
-	logFile := "upload_cartridge.log"
-	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		// Silently fail - don't interrupt upload if logging fails
-		return
+	logging.File().Info(message, "log", "upload", "run", uploadRunDir)
+}
+
+// addUnsignedHeaders appends the CART header (unless it was already sent),
+// the CMET metadata records and the CENT entry, all signed by the
+// publisher's wallet
+func addUnsignedHeaders(batch *UnsignedBatch, sender, cartridgeAddr, catalogAddr string, header CARTHeader, centPayload []byte, metaRecords [][]byte, fee, height int64, withCART bool) error {
+	if withCART {
+		cartPayload, err := EncodeCART(header)
+		if err != nil {
+			return fmt.Errorf("failed to encode CART header: %w", err)
+		}
+		if err := batch.Add(sender, cartridgeAddr, cartPayload, fee, height); err != nil {
+			return err
+		}
 	}
-	defer file.Close()
+	for _, record := range metaRecords {
+		if err := batch.Add(sender, catalogAddr, record, fee, height); err != nil {
+			return err
+		}
+	}
+	return batch.Add(sender, catalogAddr, centPayload, fee, height)
+}
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	logger := log.New(file, "", 0)
-	logger.Printf("[%s] %s", timestamp, message)
+// sendMetadataRecords sends the CMET records of a CENT v2 entry that aren't
+// in sent yet, appending each transaction hash to sent and calling save
+// after it. The caller has waited for the first transaction; with wait, the
+// rate limit is waited for after each record, ahead of the next transaction
+// (the CENT entry after the last). It returns how many records it sent.
+func sendMetadataRecords(ctx context.Context, control *UploadControl, sender TxSender, records [][]byte, sent *[]string, wait bool, save func()) (int, error) {
+	count := 0
+	for i := len(*sent); i < len(records); i++ {
+		txHash, err := sender.SendTransaction(records[i])
+		if err != nil {
+			return count, fmt.Errorf("failed to send metadata record %d/%d: %w", i+1, len(records), err)
+		}
+		*sent = append(*sent, txHash)
+		count++
+		save()
+		if wait {
+			if err := control.Wait(ctx); err != nil {
+				return count, err
+			}
+		}
+	}
+	if count > 0 {
+		statusf("✓ %d metadata record(s) (CMET) sent to catalog\n", count)
+		logCartridgeUpload(fmt.Sprintf("%d metadata record(s) sent to catalog", count))
+	}
+	return count, nil
 }
 
-// resolveCatalogAddress resolves catalog address shortcuts to actual addresses
+// resolveCatalogAddress resolves catalog aliases ('main', 'test' and any
+// added with catalog-alias add) to actual addresses
 func resolveCatalogAddress(addr string) string {
-	switch strings.ToLower(addr) {
-	case "main":
-		return "NQ15 NXMP 11A0 TMKP G1Q8 4ABD U16C XD6Q D948"
-	case "test":
-		return "NQ32 0VD4 26TR 1394 KXBJ 862C NFKG 61M5 GFJ0"
-	default:
-		// Return as-is (assumed to be a full NQ address)
-		return addr
+	if alias, ok := LoadCatalogAliases()[strings.ToLower(addr)]; ok {
+		return alias.Address
 	}
+	// Return as-is (assumed to be a full NQ address)
+	return addr
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// flagValidators maps address flag names to checks run before any command executes
+var flagValidators = map[string]func(string) error{
+	"address":        ValidateAddressNQ,
+	"sender":         ValidateAddressNQ,
+	"receiver":       ValidateAddressNQ,
+	"cartridge-addr": ValidateAddressNQ,
+	"catalog-addr":   validateCatalogAddress,
+}
+
+// validateFlags checks every set address flag on cmd so typos fail instantly
+// with the offending flag named, instead of as a mid-upload RPC error
+func validateFlags(cmd *cobra.Command) error {
+	names := make([]string, 0, len(flagValidators))
+	for name := range flagValidators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || !flag.Changed || flag.Value.String() == "" {
+			continue
+		}
+		if err := flagValidators[name](flag.Value.String()); err != nil {
+			return fmt.Errorf("invalid --%s %q: %w", name, flag.Value.String(), err)
+		}
+	}
+	return nil
+}
+
+// validateCatalogAddress accepts catalog aliases ('main', 'test', ...) or a full address
+func validateCatalogAddress(addr string) error {
+	return ValidateAddressNQ(resolveCatalogAddress(addr))
+}
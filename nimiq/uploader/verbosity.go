@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/retro-crypto/shared/logging"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Verbosity (-q/--quiet, -v/-vv)
+// ============================================================================
+
+// Verbosity levels. Quiet prints only results (tables, hashes, JSON) and
+// errors; normal adds status lines, tips, warnings and progress; verbose
+// adds one log record per RPC request on stderr and debug adds their
+// bodies. The log file (logging.go) follows the same levels from info on.
+const (
+	levelQuiet   = -1
+	levelNormal  = 0
+	levelVerbose = 1
+	levelDebug   = 2
+)
+
+// traceBodyLimit bounds how much of an RPC body is printed at -vv
+const traceBodyLimit = 4096
+
+var (
+	quietFlag    bool
+	verboseCount int
+	verbosity    = levelNormal
+)
+
+func addVerbosityFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "Print only results and errors: no status lines, tips, warnings or progress")
+	cmd.PersistentFlags().CountVarP(&verboseCount, "verbose", "v", "Print more detail: every RPC request on stderr (-v), with request and response bodies (-vv)")
+}
+
+// setupVerbosity applies -q and -v before any RPC client is created
+func setupVerbosity() error {
+	if quietFlag && verboseCount > 0 {
+		return fmt.Errorf("--quiet and --verbose cannot be combined")
+	}
+	switch {
+	case quietFlag:
+		verbosity = levelQuiet
+	case verboseCount >= levelDebug:
+		verbosity = levelDebug
+	default:
+		verbosity = verboseCount
+	}
+	return nil
+}
+
+// quiet reports whether only results are printed
+func quiet() bool {
+	return verbosity <= levelQuiet
+}
+
+// verbose reports whether detail of the given level is printed
+func verbose(level int) bool {
+	return verbosity >= level
+}
+
+// statusf prints a status line (progress, success banner, tip or warning)
+// unless --quiet is set; the log file keeps it either way
+func statusf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if !quiet() {
+		fmt.Print(msg)
+	}
+	if msg := logging.Message(msg); msg != "" {
+		logging.File().Info(msg)
+	}
+}
+
+// statusln is statusf for a message without arguments
+func statusln(msg string) {
+	if !quiet() {
+		fmt.Println(msg)
+	}
+	if msg := logging.Message(msg); msg != "" {
+		logging.File().Info(msg)
+	}
+}
+
+// resultf prints command output (reports, addresses, transaction hashes)
+// on stdout, also with --quiet; the log file keeps it
+func resultf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	fmt.Print(msg)
+	if msg := logging.Message(msg); msg != "" {
+		logging.File().Info(msg, "output", "result")
+	}
+}
+
+// resultln is resultf for a message without arguments
+func resultln(msg string) {
+	resultf("%s\n", msg)
+}
+
+// secretf prints output that must never reach the log file, such as a new
+// private key or passphrase
+func secretf(format string, args ...interface{}) {
+	fmt.Printf(format, args...)
+}
+
+// warnf logs a warning, printed on stderr unless --quiet is set
+func warnf(format string, args ...interface{}) {
+	logging.Logger().Warn(fmt.Sprintf(format, args...))
+}
+
+// debugf logs detail, printed on stderr from the given level on
+func debugf(level int, format string, args ...interface{}) {
+	logging.Logger().Log(context.Background(), slogLevel(level), fmt.Sprintf(format, args...))
+}
+
+// traced wraps rt so -v logs every request and -vv its bodies
+func traced(rt http.RoundTripper) http.RoundTripper {
+	if !verbose(levelVerbose) {
+		return rt
+	}
+	return &traceTransport{next: rt}
+}
+
+// traceMu keeps the lines of concurrent requests (upload workers) apart
+var traceMu sync.Mutex
+
+type traceTransport struct {
+	next http.RoundTripper
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(body, traceBodyLimit+1))
+			body.Close()
+		}
+	}
+	log := logging.Logger().With("method", req.Method, "url", req.URL.Redacted())
+	var call struct {
+		Method string `json:"method"`
+	}
+	if json.Unmarshal(reqBody, &call) == nil && call.Method != "" {
+		log = log.With("rpc", call.Method)
+	}
+	if verbose(levelDebug) && len(reqBody) > 0 {
+		log.Log(req.Context(), logging.LevelTrace, "rpc request body", "body", clipBody(reqBody))
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		log.Debug("rpc request failed", "duration", elapsed, "error", err)
+		return nil, err
+	}
+	log.Debug("rpc request", "status", resp.StatusCode, "duration", elapsed)
+	if verbose(levelDebug) {
+		head, _ := io.ReadAll(io.LimitReader(resp.Body, traceBodyLimit+1))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+		log.Log(req.Context(), logging.LevelTrace, "rpc response body", "body", clipBody(head))
+	}
+	return resp, nil
+}
+
+func clipBody(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) > traceBodyLimit {
+		return s[:traceBodyLimit] + "…"
+	}
+	return s
+}
@@ -0,0 +1,5 @@
+module github.com/retro-crypto/shared
+
+go 1.21
+
+require golang.org/x/crypto v0.21.0
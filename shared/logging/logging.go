@@ -0,0 +1,250 @@
+// Package logging is the structured log of catalogctl and nimiq-uploader,
+// built on log/slog.
+//
+// Records go to two places: the console (stderr) at the level the user
+// picked with -q/-v, and a rotating log file that keeps every run's status
+// lines, warnings and errors (and more with -v). Console records read like
+// the CLI's other messages ("Warning: ..."); with JSON output both sinks
+// write one JSON object per record.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// LevelTrace is below slog.LevelDebug: request and response bodies (-vv)
+const LevelTrace = slog.LevelDebug - 4
+
+// Defaults of Options
+const (
+	DefaultMaxSize = 10 << 20
+	DefaultBackups = 3
+)
+
+// Options configure Setup
+type Options struct {
+	// Console receives records from ConsoleLevel on (usually stderr)
+	Console      io.Writer
+	ConsoleLevel slog.Level
+	// JSON writes records as JSON objects instead of text
+	JSON bool
+	// File is the log file ("" for none); it is rotated to File.1, .2, ...
+	// once it grows past MaxSize, keeping Backups old files
+	File      string
+	FileLevel slog.Level
+	MaxSize   int64
+	Backups   int
+	// Prefixes are printed before console text records of a level, e.g.
+	// "Warning" for slog.LevelWarn (translated by the caller)
+	Prefixes map[slog.Level]string
+	// ConsoleLock, if set, is held while a console record is written, so
+	// other writers of Console holding it don't interleave with records
+	ConsoleLock sync.Locker
+}
+
+var (
+	mu      sync.Mutex
+	logger  = slog.New(discard{})
+	fileLog = slog.New(discard{})
+	file    *RotatingFile
+)
+
+// Setup replaces the loggers returned by Logger and File. A log file that
+// can't be opened is an error; the console logger is set up regardless.
+func Setup(opts Options) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		file.Close()
+		file = nil
+	}
+
+	var console slog.Handler = discard{}
+	if opts.Console != nil {
+		if opts.JSON {
+			console = slog.NewJSONHandler(opts.Console, &slog.HandlerOptions{Level: opts.ConsoleLevel, ReplaceAttr: levelNames})
+		} else {
+			lock := opts.ConsoleLock
+			if lock == nil {
+				lock = &sync.Mutex{}
+			}
+			console = &consoleHandler{out: opts.Console, level: opts.ConsoleLevel, prefixes: opts.Prefixes, mu: lock}
+		}
+	}
+
+	var fileHandler slog.Handler = discard{}
+	var err error
+	if opts.File != "" {
+		if err = os.MkdirAll(filepath.Dir(opts.File), 0700); err == nil {
+			file, err = OpenRotating(opts.File, opts.MaxSize, opts.Backups)
+		}
+		if err == nil {
+			handlerOpts := &slog.HandlerOptions{Level: opts.FileLevel, ReplaceAttr: levelNames}
+			if opts.JSON {
+				fileHandler = slog.NewJSONHandler(file, handlerOpts)
+			} else {
+				fileHandler = slog.NewTextHandler(file, handlerOpts)
+			}
+		}
+	}
+
+	logger = slog.New(fanout{console, fileHandler})
+	fileLog = slog.New(fileHandler)
+	return err
+}
+
+// Logger logs to the console and the log file
+func Logger() *slog.Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	return logger
+}
+
+// File logs to the log file only, for messages already printed on stdout
+func File() *slog.Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	return fileLog
+}
+
+// Close closes the log file
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		file.Close()
+		file = nil
+	}
+}
+
+// Message turns a printed line into a log message: without surrounding
+// whitespace and newlines
+func Message(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// levelNames names LevelTrace TRACE instead of DEBUG-4
+func levelNames(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if level, ok := a.Value.Any().(slog.Level); ok && level == LevelTrace {
+			a.Value = slog.StringValue("TRACE")
+		}
+	}
+	return a
+}
+
+// consoleHandler prints text records as the CLI's messages: the level
+// prefix, the message and its attributes as key=value
+type consoleHandler struct {
+	out      io.Writer
+	level    slog.Level
+	prefixes map[slog.Level]string
+	attrs    []slog.Attr
+	mu       sync.Locker
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	if prefix := h.prefixes[r.Level]; prefix != "" {
+		b.WriteString(prefix + ": ")
+	}
+	b.WriteString(r.Message)
+	write := func(a slog.Attr) bool {
+		if !a.Equal(slog.Attr{}) {
+			fmt.Fprintf(&b, " %s=%s", a.Key, quote(a.Value.String()))
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		write(a)
+	}
+	r.Attrs(write)
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.out, b.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &consoleHandler{
+		out:      h.out,
+		level:    h.level,
+		prefixes: h.prefixes,
+		attrs:    append(append([]slog.Attr{}, h.attrs...), attrs...),
+		mu:       h.mu,
+	}
+}
+
+// WithGroup is not used by the CLIs; groups are flattened
+func (h *consoleHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// quote quotes values that contain spaces
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// fanout passes records to every handler that is enabled for them
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var first error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanout, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	handlers := make(fanout, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+// discard drops every record
+type discard struct{}
+
+func (discard) Enabled(context.Context, slog.Level) bool  { return false }
+func (discard) Handle(context.Context, slog.Record) error { return nil }
+func (d discard) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discard) WithGroup(string) slog.Handler           { return d }
@@ -0,0 +1,137 @@
+// Package seal encrypts small secrets at rest with a passphrase: AES-256-GCM
+// under a key derived with scrypt (RFC 7914). It is the format of keystore
+// files, encrypted catalogctl config files and sealed uploader credentials.
+//
+// The scrypt parameters are stored with the ciphertext so the work factor
+// can grow; Open refuses parameters below MinParams, so a modified file
+// can't make a guessed passphrase cheap to check, and above 1 GiB of work
+// area, so it can't exhaust memory either.
+package seal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// KDF names the key derivation of a Box
+const KDF = "scrypt"
+
+// Params are scrypt work factors
+type Params struct {
+	N, R, P int
+}
+
+var (
+	// DefaultParams seal new boxes: 32 MiB of memory, about 0.1s
+	DefaultParams = Params{N: 1 << 15, R: 8, P: 1}
+	// MinParams is the least work Open accepts
+	MinParams = Params{N: 1 << 15, R: 8, P: 1}
+)
+
+// ErrWrongPassphrase is returned when a box can't be opened
+var ErrWrongPassphrase = errors.New("wrong passphrase (or the file was modified)")
+
+// Box is a sealed secret as stored in JSON files. Files embed it next to
+// their own format marker, which is passed to Seal and Open as additional
+// data so a box can't be moved to a file of another kind.
+type Box struct {
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// Seal encrypts plaintext with passphrase under a fresh salt and nonce
+func Seal(plaintext []byte, passphrase string, additionalData []byte) (*Box, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	params := DefaultParams
+	gcm, err := newGCM(passphrase, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &Box{
+		KDF:        KDF,
+		N:          params.N,
+		R:          params.R,
+		P:          params.P,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plaintext, additionalData)),
+	}, nil
+}
+
+// Open decrypts the box with passphrase
+func (b *Box) Open(passphrase string, additionalData []byte) ([]byte, error) {
+	if b.KDF != KDF {
+		return nil, fmt.Errorf("unsupported key derivation %q", b.KDF)
+	}
+	params := Params{N: b.N, R: b.R, P: b.P}
+	if params.N < MinParams.N || params.R < MinParams.R || params.P < MinParams.P {
+		return nil, fmt.Errorf("scrypt parameters N=%d r=%d p=%d are below the minimum N=%d r=%d p=%d",
+			params.N, params.R, params.P, MinParams.N, MinParams.R, MinParams.P)
+	}
+	salt, err1 := base64.StdEncoding.DecodeString(b.Salt)
+	nonce, err2 := base64.StdEncoding.DecodeString(b.Nonce)
+	ciphertext, err3 := base64.StdEncoding.DecodeString(b.Ciphertext)
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return nil, fmt.Errorf("corrupt sealed data: %w", err)
+	}
+	if len(salt) < 16 {
+		return nil, fmt.Errorf("corrupt sealed data: short salt")
+	}
+	gcm, err := newGCM(passphrase, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("corrupt sealed data: bad nonce")
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+// Key derives a key with scrypt. The parameters may come from a file, so
+// the work area of 128*r*N bytes is limited to 1 GiB.
+func Key(password, salt []byte, n, r, p, keyLen int) ([]byte, error) {
+	if n <= 1 || n&(n-1) != 0 {
+		return nil, fmt.Errorf("scrypt N must be a power of two greater than 1")
+	}
+	if r < 1 || p < 1 || uint64(r)*uint64(p) >= 1<<30 || n > 1<<23/r {
+		return nil, fmt.Errorf("scrypt parameters N=%d r=%d p=%d are out of range", n, r, p)
+	}
+	return scrypt.Key(password, salt, n, r, p, keyLen)
+}
+
+func newGCM(passphrase string, salt []byte, params Params) (cipher.AEAD, error) {
+	key, err := Key([]byte(passphrase), salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,84 @@
+package seal
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	// RFC 7914 section 12
+	tests := []struct {
+		password, salt string
+		n, r, p        int
+		want           string
+	}{
+		{"", "", 16, 1, 1, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+		{"password", "NaCl", 1024, 8, 16, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+	}
+	for _, tt := range tests {
+		key, err := Key([]byte(tt.password), []byte(tt.salt), tt.n, tt.r, tt.p, 64)
+		if err != nil {
+			t.Fatalf("Key(N=%d): %v", tt.n, err)
+		}
+		if got := hex.EncodeToString(key); got != tt.want {
+			t.Errorf("Key(%q, %q, N=%d) = %s, want %s", tt.password, tt.salt, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestKeyLimits(t *testing.T) {
+	tests := []struct{ n, r, p int }{
+		{1, 8, 1},       // N must be over 1
+		{1000, 8, 1},    // and a power of two
+		{1 << 21, 8, 1}, // 2 GiB work area
+		{16, 0, 1},
+		{16, 1, 0},
+	}
+	for _, tt := range tests {
+		if _, err := Key([]byte("x"), []byte("y"), tt.n, tt.r, tt.p, 32); err == nil {
+			t.Errorf("Key(N=%d r=%d p=%d) accepted out-of-range parameters", tt.n, tt.r, tt.p)
+		}
+	}
+}
+
+func TestSealRoundTrip(t *testing.T) {
+	aad := []byte("test-format")
+	box, err := Seal([]byte("secret"), "correct horse", aad)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	plaintext, err := box.Open("correct horse", aad)
+	if err != nil || string(plaintext) != "secret" {
+		t.Fatalf("Open = %q, %v", plaintext, err)
+	}
+	if _, err := box.Open("wrong", aad); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Open with a wrong passphrase = %v", err)
+	}
+	if _, err := box.Open("correct horse", []byte("other-format")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Open of a box moved to another format = %v", err)
+	}
+	if _, err := Seal([]byte("secret"), "", aad); err == nil {
+		t.Error("Seal accepted an empty passphrase")
+	}
+}
+
+func TestOpenRefusesWeakParams(t *testing.T) {
+	box, err := Seal([]byte("secret"), "pw", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, weak := range []Params{{N: 16, R: 8, P: 1}, {N: 1 << 15, R: 1, P: 1}, {N: 1 << 15, R: 8, P: 0}} {
+		b := *box
+		b.N, b.R, b.P = weak.N, weak.R, weak.P
+		if _, err := b.Open("pw", nil); err == nil || !strings.Contains(err.Error(), "below the minimum") {
+			t.Errorf("Open(N=%d r=%d p=%d) = %v, want a minimum error", weak.N, weak.R, weak.P, err)
+		}
+	}
+	b := *box
+	b.KDF = "pbkdf2-sha256"
+	if _, err := b.Open("pw", nil); err == nil {
+		t.Error("Open accepted another key derivation")
+	}
+}
@@ -0,0 +1,430 @@
+// Package selfupdate replaces the running binary with a signed release.
+//
+// Releases are read from a GitHub-style release feed. Every release carries
+// the binaries named <binary>-<os>-<arch>[.exe], a checksums.txt in
+// sha256sum format that starts with a "# release <tag>" comment line, and
+// checksums.txt.sig, a detached signature of checksums.txt. The signature
+// is either a cosign signature (cosign sign-blob: ECDSA P-256 over the
+// SHA256 of the file, base64) or an Ed25519 signature (openssl pkeyutl
+// -sign -rawin, raw or base64). A binary is only installed once the
+// signature verifies against the release public key, the signed tag is the
+// release's and its SHA256 matches checksums.txt. The tag in the feed alone
+// isn't trusted: an old signed release can't pass as a newer one.
+package selfupdate
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// DefaultFeed lists the releases of this repository
+const DefaultFeed = "https://api.github.com/repos/maestroi/retro-crypto/releases"
+
+const (
+	// ChecksumsFile is the checksum list of a release
+	ChecksumsFile = "checksums.txt"
+	// SignatureFile is the detached signature of ChecksumsFile
+	SignatureFile = "checksums.txt.sig"
+	// maxBinarySize bounds downloads, so a bad feed can't fill the disk
+	maxBinarySize = 256 << 20
+	// maxMetadataSize bounds the feed, checksums and signature
+	maxMetadataSize = 4 << 20
+	// releaseLine names the release in ChecksumsFile; sha256sum -c skips it
+	releaseLine = "# release "
+)
+
+// Release is one release of a component in the feed
+type Release struct {
+	Tag        string `json:"tag"`
+	Version    string `json:"version"`
+	Prerelease bool   `json:"prerelease"`
+	URL        string `json:"url,omitempty"`
+	// Assets maps file names to download URLs
+	Assets map[string]string `json:"-"`
+}
+
+// feedRelease is a release as the GitHub API returns it
+type feedRelease struct {
+	TagName    string `json:"tag_name"`
+	HTMLURL    string `json:"html_url"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	Assets     []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// AssetName returns the release file of binary for this platform, e.g.
+// catalogctl-linux-amd64 or catalogctl-windows-amd64.exe
+func AssetName(binary string) string {
+	name := binary + "-" + runtime.GOOS + "-" + runtime.GOARCH
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// FindRelease reads the feed and returns the release of the component whose
+// tags start with tagPrefix (e.g. "catalogctl-v"): the given version, or the
+// newest one if version is empty. Prereleases are only picked as the newest
+// with prerelease set.
+func FindRelease(client *http.Client, feed, tagPrefix, version string, prerelease bool) (*Release, error) {
+	data, err := fetch(client, feed, maxMetadataSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release feed: %w", err)
+	}
+	var releases []feedRelease
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse release feed: %w", err)
+	}
+
+	version = strings.TrimPrefix(version, "v")
+	var found *Release
+	for _, r := range releases {
+		if r.Draft || !strings.HasPrefix(r.TagName, tagPrefix) {
+			continue
+		}
+		release := &Release{
+			Tag:        r.TagName,
+			Version:    strings.TrimPrefix(r.TagName, tagPrefix),
+			Prerelease: r.Prerelease,
+			URL:        r.HTMLURL,
+			Assets:     make(map[string]string, len(r.Assets)),
+		}
+		for _, asset := range r.Assets {
+			release.Assets[asset.Name] = asset.URL
+		}
+		if version != "" {
+			if release.Version == version {
+				return release, nil
+			}
+			continue
+		}
+		if release.Prerelease && !prerelease {
+			continue
+		}
+		if _, ok := parseVersion(release.Version); !ok {
+			continue
+		}
+		if found == nil || CompareVersions(release.Version, found.Version) > 0 {
+			found = release
+		}
+	}
+	if version != "" {
+		return nil, fmt.Errorf("no release %s%s in the feed", tagPrefix, version)
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no %s* release in the feed", tagPrefix)
+	}
+	return found, nil
+}
+
+// Download fetches the asset of binary from the release and returns it once
+// the signature of the checksum list verifies with key and the asset's
+// SHA256 matches it
+func (r *Release) Download(client *http.Client, binary string, key PublicKey) ([]byte, error) {
+	name := AssetName(binary)
+	binURL, ok := r.Assets[name]
+	if !ok {
+		return nil, fmt.Errorf("release %s has no binary for %s/%s (%s)", r.Tag, runtime.GOOS, runtime.GOARCH, name)
+	}
+	sumsURL, ok := r.Assets[ChecksumsFile]
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s", r.Tag, ChecksumsFile)
+	}
+	sigURL, ok := r.Assets[SignatureFile]
+	if !ok {
+		return nil, fmt.Errorf("release %s is not signed (no %s)", r.Tag, SignatureFile)
+	}
+
+	sums, err := fetch(client, sumsURL, maxMetadataSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", ChecksumsFile, err)
+	}
+	sig, err := fetch(client, sigURL, maxMetadataSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", SignatureFile, err)
+	}
+	if err := key.Verify(sums, sig); err != nil {
+		return nil, fmt.Errorf("release %s: %w", r.Tag, err)
+	}
+	tag, err := SignedTag(sums)
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", r.Tag, err)
+	}
+	if tag != r.Tag {
+		return nil, fmt.Errorf("release %s: its signed %s belongs to release %s", r.Tag, ChecksumsFile, tag)
+	}
+	want, err := Checksum(sums, name)
+	if err != nil {
+		return nil, err
+	}
+
+	bin, err := fetch(client, binURL, maxBinarySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	if got := sha256.Sum256(bin); !bytes.Equal(got[:], want) {
+		return nil, fmt.Errorf("%s doesn't match its checksum (got %x, want %x)", name, got, want)
+	}
+	return bin, nil
+}
+
+// Checksum returns the SHA256 of name from a sha256sum list
+func Checksum(sums []byte, name string) ([]byte, error) {
+	for _, line := range strings.Split(string(sums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum, err := hex.DecodeString(fields[0])
+		if err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("invalid checksum of %s in %s", name, ChecksumsFile)
+		}
+		return sum, nil
+	}
+	return nil, fmt.Errorf("%s has no checksum of %s", ChecksumsFile, name)
+}
+
+// SignedTag returns the release tag a checksum list names in its
+// "# release <tag>" line
+func SignedTag(sums []byte) (string, error) {
+	for _, line := range strings.Split(string(sums), "\n") {
+		if tag, ok := strings.CutPrefix(strings.TrimSpace(line), releaseLine); ok && strings.TrimSpace(tag) != "" {
+			return strings.TrimSpace(tag), nil
+		}
+	}
+	return "", fmt.Errorf("%s doesn't name its release (no %q line)", ChecksumsFile, strings.TrimSpace(releaseLine))
+}
+
+// CheckTarget refuses to replace the current version with target unless
+// target is newer; force allows reinstalls and downgrades. Development
+// builds sort before every release, so any release replaces them.
+func CheckTarget(target, current string, force bool) error {
+	if force || CompareVersions(target, current) > 0 {
+		return nil
+	}
+	if CompareVersions(target, current) == 0 {
+		return fmt.Errorf("%s is already installed; pass --force to reinstall it", current)
+	}
+	return fmt.Errorf("%s is older than the installed %s; pass --force to downgrade", target, current)
+}
+
+// PublicKey verifies release signatures
+type PublicKey struct {
+	key interface{}
+}
+
+// ParsePublicKey reads a release public key: a PEM "PUBLIC KEY" block (as
+// cosign generate-key-pair or openssl pkey -pubout write it) or its base64
+// DER body on one line, as it is passed to the build with -ldflags
+func ParsePublicKey(s string) (PublicKey, error) {
+	s = strings.TrimSpace(s)
+	var der []byte
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		der = block.Bytes
+	} else {
+		var err error
+		if der, err = base64.StdEncoding.DecodeString(s); err != nil {
+			return PublicKey{}, fmt.Errorf("public key is neither PEM nor base64")
+		}
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key: %w", err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		return PublicKey{key: key}, nil
+	}
+	return PublicKey{}, fmt.Errorf("unsupported public key type %T (want ECDSA or Ed25519)", key)
+}
+
+// Verify checks a detached signature of data. cosign writes base64, openssl
+// raw bytes; both are accepted.
+func (k PublicKey) Verify(data, sig []byte) error {
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err == nil {
+		sig = decoded
+	}
+	ok := false
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		ok = ecdsa.VerifyASN1(key, digest[:], sig)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(key, data, sig)
+	default:
+		return fmt.Errorf("no public key to verify the signature with")
+	}
+	if !ok {
+		return fmt.Errorf("signature of %s doesn't verify with the release public key", ChecksumsFile)
+	}
+	return nil
+}
+
+// Replace atomically replaces the executable at path with data: the new
+// binary is written next to it and renamed over it, so a failed update
+// leaves the old one in place. Windows can't overwrite a running
+// executable, so there the old one is moved to <path>.old first.
+func Replace(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return fmt.Errorf("failed to write to %s (permission denied? run with sudo or reinstall): %w", dir, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+		if err := os.Rename(tmpPath, path); err != nil {
+			os.Rename(old, path)
+			return err
+		}
+		return nil
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// Executable returns the path of the running binary with symlinks resolved,
+// so a symlinked install replaces its target
+func Executable() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(path)
+}
+
+// CompareVersions compares two semantic versions (with or without a leading
+// "v") and returns -1, 0 or 1. A prerelease sorts before its release.
+// Versions that don't parse sort before every valid one.
+func CompareVersions(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := 0; i < 3; i++ {
+		if va.core[i] != vb.core[i] {
+			if va.core[i] < vb.core[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case va.pre == vb.pre:
+		return 0
+	case va.pre == "":
+		return 1
+	case vb.pre == "":
+		return -1
+	case va.pre < vb.pre:
+		return -1
+	}
+	return 1
+}
+
+// IsRelease reports whether version is a release version rather than a
+// development build (e.g. "dev" or "dev-abc1234")
+func IsRelease(version string) bool {
+	_, ok := parseVersion(version)
+	return ok
+}
+
+type semver struct {
+	core [3]uint64
+	pre  string
+}
+
+func parseVersion(s string) (semver, bool) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	var v semver
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s, v.pre = s[:i], s[i+1:]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return v, false
+		}
+		v.core[i] = n
+	}
+	return v, true
+}
+
+func fetch(client *http.Client, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json, application/octet-stream")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: HTTP %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s: larger than %d bytes", url, limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,174 @@
+package selfupdate
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ed25519Key returns a release signing key and its public key, read from PEM
+func ed25519Key(t *testing.T) (ed25519.PrivateKey, PublicKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, parsePEM(t, pub)
+}
+
+func parsePEM(t *testing.T, pub interface{}) PublicKey {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParsePublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	if err != nil {
+		t.Fatalf("ParsePublicKey: %v", err)
+	}
+	return key
+}
+
+func TestVerify(t *testing.T) {
+	data := []byte("checksums")
+	priv, key := ed25519Key(t)
+	sig := ed25519.Sign(priv, data)
+
+	if err := key.Verify(data, sig); err != nil {
+		t.Errorf("raw Ed25519 signature: %v", err)
+	}
+	if err := key.Verify(data, []byte(base64.StdEncoding.EncodeToString(sig)+"\n")); err != nil {
+		t.Errorf("base64 Ed25519 signature: %v", err)
+	}
+	if err := key.Verify([]byte("checksumz"), sig); err == nil {
+		t.Error("signature verified for other data")
+	}
+	_, other := ed25519Key(t)
+	if err := other.Verify(data, sig); err == nil {
+		t.Error("signature verified with another key")
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(data)
+	ecSig, err := ecdsa.SignASN1(rand.Reader, ecKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPub := parsePEM(t, &ecKey.PublicKey)
+	if err := ecPub.Verify(data, []byte(base64.StdEncoding.EncodeToString(ecSig))); err != nil {
+		t.Errorf("cosign signature: %v", err)
+	}
+	if err := ecPub.Verify(data, sig); err == nil {
+		t.Error("Ed25519 signature verified with an ECDSA key")
+	}
+	if err := (PublicKey{}).Verify(data, sig); err == nil {
+		t.Error("empty key verified a signature")
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("bin"))
+	sums := []byte(fmt.Sprintf("%x  other-linux-amd64\n%x *catalogctl-linux-amd64\n", sha256.Sum256(nil), sum))
+
+	got, err := Checksum(sums, "catalogctl-linux-amd64")
+	if err != nil || string(got) != string(sum[:]) {
+		t.Fatalf("Checksum = %x, %v; want %x", got, err, sum)
+	}
+	if _, err := Checksum(sums, "catalogctl-darwin-arm64"); err == nil || !strings.Contains(err.Error(), "has no checksum") {
+		t.Errorf("missing line: error = %v", err)
+	}
+	if _, err := Checksum([]byte("abcd  catalogctl-linux-amd64\n"), "catalogctl-linux-amd64"); err == nil || !strings.Contains(err.Error(), "invalid checksum") {
+		t.Errorf("short hash: error = %v", err)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	priv, key := ed25519Key(t)
+	bin := []byte("new binary")
+	name := AssetName("catalogctl")
+	goodSums := []byte(fmt.Sprintf("# release catalogctl-v1.2.3\n%x  %s\n", sha256.Sum256(bin), name))
+
+	tests := []struct {
+		name    string
+		sums    []byte
+		sig     []byte
+		bin     []byte
+		wantErr string
+	}{
+		{name: "ok", sums: goodSums, bin: bin},
+		{name: "bad signature", sums: goodSums, sig: ed25519.Sign(priv, []byte("other")), bin: bin, wantErr: "doesn't verify"},
+		{name: "missing checksum", sums: []byte(fmt.Sprintf("# release catalogctl-v1.2.3\n%x  other\n", sha256.Sum256(bin))), bin: bin, wantErr: "has no checksum of " + name},
+		// An older signed release published under a newer tag
+		{name: "other release", sums: []byte(fmt.Sprintf("# release catalogctl-v1.0.0\n%x  %s\n", sha256.Sum256(bin), name)), bin: bin, wantErr: "belongs to release catalogctl-v1.0.0"},
+		{name: "unnamed release", sums: []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256(bin), name)), bin: bin, wantErr: "doesn't name its release"},
+		{name: "hash mismatch", sums: goodSums, bin: []byte("tampered"), wantErr: "doesn't match its checksum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := tt.sig
+			if sig == nil {
+				sig = ed25519.Sign(priv, tt.sums)
+			}
+			files := map[string][]byte{"/" + name: tt.bin, "/" + ChecksumsFile: tt.sums, "/" + SignatureFile: sig}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(files[r.URL.Path])
+			}))
+			defer srv.Close()
+
+			release := &Release{Tag: "catalogctl-v1.2.3", Assets: map[string]string{}}
+			for path := range files {
+				release.Assets[path[1:]] = srv.URL + path
+			}
+			got, err := release.Download(srv.Client(), "catalogctl", key)
+			if tt.wantErr == "" {
+				if err != nil || string(got) != string(bin) {
+					t.Fatalf("Download = %q, %v", got, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Download error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	unsigned := &Release{Tag: "catalogctl-v1.2.3", Assets: map[string]string{name: "x", ChecksumsFile: "x"}}
+	if _, err := unsigned.Download(http.DefaultClient, "catalogctl", key); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("unsigned release: error = %v", err)
+	}
+}
+
+func TestCheckTarget(t *testing.T) {
+	tests := []struct {
+		target, current string
+		force           bool
+		wantErr         string
+	}{
+		{target: "1.3.0", current: "1.2.3"},
+		{target: "1.2.3", current: "dev-abc1234"},
+		{target: "1.2.3", current: "1.2.3", wantErr: "already installed"},
+		{target: "1.2.3", current: "1.2.3", force: true},
+		{target: "1.2.3-rc.1", current: "1.2.3", wantErr: "older than"},
+		{target: "1.0.0", current: "1.2.3", wantErr: "pass --force to downgrade"},
+		{target: "1.0.0", current: "1.2.3", force: true},
+	}
+	for _, tt := range tests {
+		err := CheckTarget(tt.target, tt.current, tt.force)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("CheckTarget(%s, %s, %v) = %v, want %q", tt.target, tt.current, tt.force, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+)
+
+// ============================================================================
+// serve: /admin operator console
+// ============================================================================
+
+//go:embed admin.html
+var adminPage []byte
+
+// adminServer serves the operator console and its JSON API
+type adminServer struct {
+	token string
+
+	// txMu serializes admin transactions; the sui and walrus CLIs share
+	// the operator's gas coins
+	txMu sync.Mutex
+}
+
+func newAdminServer(token string) *adminServer {
+	return &adminServer{token: token}
+}
+
+func (a *adminServer) mount(mux *http.ServeMux) {
+	mux.HandleFunc("/admin", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(adminPage)
+	})
+	mux.HandleFunc("/admin/api/entries", a.requireToken(a.handleEntries))
+	mux.HandleFunc("/admin/api/history", a.requireToken(a.handleHistory))
+	mux.HandleFunc("/admin/api/retire", a.requireToken(a.handleRetire))
+	mux.HandleFunc("/admin/api/rollback", a.requireToken(a.handleRollback))
+	mux.HandleFunc("/admin/api/extend", a.requireToken(a.handleExtend))
+}
+
+// operations describes the admin API for the OpenAPI document
+func (a *adminServer) operations() []apiOperation {
+	catalogParam := apiParam{Name: "catalog", Description: "Catalog ID or alias (default: catalog_id)"}
+	return []apiOperation{
+		{Method: http.MethodGet, Path: "/admin/api/entries", Summary: "List the entries of a catalog", Tag: "admin", Auth: true,
+			Query: []apiParam{catalogParam}, Response: adminEntriesResponse{}},
+		{Method: http.MethodGet, Path: "/admin/api/history", Summary: "List the cartridges an entry pointed to, newest first", Tag: "admin", Auth: true,
+			Query: []apiParam{catalogParam, {Name: "slug", Description: "Entry slug", Required: true}}, Response: entryHistoryResponse{}},
+		{Method: http.MethodPost, Path: "/admin/api/retire", Summary: "Remove an entry from a catalog", Tag: "admin", Auth: true,
+			Request: retireRequest{}, Response: txResponse{}},
+		{Method: http.MethodPost, Path: "/admin/api/rollback", Summary: "Point an entry back to an earlier cartridge", Tag: "admin", Auth: true,
+			Request: rollbackRequest{}, Response: txResponse{}},
+		{Method: http.MethodPost, Path: "/admin/api/extend", Summary: "Extend the Walrus storage of a blob", Tag: "admin", Auth: true,
+			Request: extendRequest{}, Response: extendResponse{}},
+	}
+}
+
+// requireToken rejects API requests without the admin bearer token
+func (a *adminServer) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid admin token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// adminEntry is a catalog entry with the Walrus blob of its cartridge
+type adminEntry struct {
+	catalogEntry
+	BlobID string `json:"blob_id,omitempty"`
+}
+
+func (a *adminServer) handleEntries(w http.ResponseWriter, r *http.Request) {
+	catalogID, err := requestCatalogID(r.URL.Query().Get("catalog"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	result := make([]adminEntry, 0, len(entries))
+	for _, entry := range entries {
+		item := adminEntry{catalogEntry: entry}
+		if cartridge, err := fetchCartridge(client, entry.CartridgeID); err == nil {
+			item.BlobID = cartridge.BlobID
+		}
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Slug < result[j].Slug })
+
+	writeJSON(w, http.StatusOK, adminEntriesResponse{CatalogID: catalogID, Network: cfg.SuiNetwork, Entries: result})
+}
+
+// adminEntriesResponse is returned by GET /admin/api/entries
+type adminEntriesResponse struct {
+	CatalogID string       `json:"catalog_id"`
+	Network   string       `json:"network"`
+	Entries   []adminEntry `json:"entries"`
+}
+
+// entryVersion is one cartridge an entry pointed to, from the catalog events
+type entryVersion struct {
+	CartridgeID string `json:"cartridge_id"`
+	Event       string `json:"event"`
+	TxDigest    string `json:"tx_digest"`
+	TimestampMs string `json:"timestamp_ms"`
+	Title       string `json:"title,omitempty"`
+	Version     uint16 `json:"version,omitempty"`
+	BlobID      string `json:"blob_id,omitempty"`
+}
+
+func (a *adminServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	catalogID, err := requestCatalogID(r.URL.Query().Get("catalog"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	slug := r.URL.Query().Get("slug")
+	if slug == "" {
+		writeError(w, http.StatusBadRequest, "slug is required")
+		return
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	versions, err := fetchEntryHistory(client, catalogID, slug)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, entryHistoryResponse{Versions: versions})
+}
+
+// entryHistoryResponse is returned by GET /admin/api/history
+type entryHistoryResponse struct {
+	Versions []entryVersion `json:"versions"`
+}
+
+// fetchEntryHistory lists the cartridges an entry pointed to, newest first,
+// from the EntryAdded and EntryUpdated events of the catalog package
+func fetchEntryHistory(client *sui.Client, catalogID, slug string) ([]entryVersion, error) {
+	if cfg.PackageID == "" {
+		return nil, fmt.Errorf("package_id is required in config file")
+	}
+
+	var versions []entryVersion
+	for _, kind := range []struct{ event, field, name string }{
+		{"EntryAdded", "cartridge_id", "added"},
+		{"EntryUpdated", "new_cartridge_id", "updated"},
+	} {
+		events, err := client.QueryEvents(cfg.TypePackageID() + "::catalog::" + kind.event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s events: %w", kind.event, err)
+		}
+		for _, ev := range events {
+			if ev.ParsedJSON["catalog_id"] != catalogID || ev.ParsedJSON["slug"] != slug {
+				continue
+			}
+			cartridgeID, _ := ev.ParsedJSON[kind.field].(string)
+			versions = append(versions, entryVersion{
+				CartridgeID: cartridgeID,
+				Event:       kind.name,
+				TxDigest:    ev.ID.TxDigest,
+				TimestampMs: ev.TimestampMs,
+			})
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		ti, _ := new(big.Int).SetString(versions[i].TimestampMs, 10)
+		tj, _ := new(big.Int).SetString(versions[j].TimestampMs, 10)
+		if ti == nil || tj == nil {
+			return false
+		}
+		return ti.Cmp(tj) > 0
+	})
+
+	for i := range versions {
+		if cartridge, err := fetchCartridge(client, versions[i].CartridgeID); err == nil {
+			versions[i].Title = cartridge.Title
+			versions[i].Version = cartridge.Version
+			versions[i].BlobID = cartridge.BlobID
+		}
+	}
+	return versions, nil
+}
+
+// retireRequest is the body of POST /admin/api/retire
+type retireRequest struct {
+	// Catalog ID or alias (empty uses catalog_id)
+	Catalog string `json:"catalog,omitempty"`
+	Slug    string `json:"slug"`
+}
+
+// rollbackRequest is the body of POST /admin/api/rollback
+type rollbackRequest struct {
+	// Catalog ID or alias (empty uses catalog_id)
+	Catalog     string `json:"catalog,omitempty"`
+	Slug        string `json:"slug"`
+	CartridgeID string `json:"cartridge_id"`
+}
+
+// extendRequest is the body of POST /admin/api/extend
+type extendRequest struct {
+	BlobID string `json:"blob_id"`
+	Epochs int    `json:"epochs"`
+}
+
+// txResponse is returned by endpoints that send a Sui transaction
+type txResponse struct {
+	Digest string `json:"digest"`
+}
+
+// extendResponse is returned by POST /admin/api/extend
+type extendResponse struct {
+	BlobObjectID string `json:"blob_object_id"`
+	Output       string `json:"output"`
+}
+
+// decodeBody parses the JSON body of a POST endpoint into v
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "POST required")
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func (a *adminServer) handleRetire(w http.ResponseWriter, r *http.Request) {
+	var action retireRequest
+	if !decodeBody(w, r, &action) {
+		return
+	}
+	catalogID, err := requestCatalogID(action.Catalog)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if action.Slug == "" {
+		writeError(w, http.StatusBadRequest, "slug is required")
+		return
+	}
+	if cfg.PackageID == "" {
+		writeError(w, http.StatusBadRequest, "package_id is required in config file")
+		return
+	}
+
+	a.txMu.Lock()
+	defer a.txMu.Unlock()
+
+	capID, err := resolveCuratorCap(catalogID, "")
+	if err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	digest, err := removeCatalogEntry(catalogID, capID, action.Slug)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	fmt.Fprintf(stdout, "admin: retired %s from %s (tx %s)\n", action.Slug, catalogID, digest)
+	writeJSON(w, http.StatusOK, txResponse{Digest: digest})
+}
+
+func (a *adminServer) handleRollback(w http.ResponseWriter, r *http.Request) {
+	var action rollbackRequest
+	if !decodeBody(w, r, &action) {
+		return
+	}
+	catalogID, err := requestCatalogID(action.Catalog)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if action.Slug == "" {
+		writeError(w, http.StatusBadRequest, "slug is required")
+		return
+	}
+	if err := validate.ObjectID(action.CartridgeID); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid cartridge_id: %v", err))
+		return
+	}
+	if cfg.PackageID == "" {
+		writeError(w, http.StatusBadRequest, "package_id is required in config file")
+		return
+	}
+
+	a.txMu.Lock()
+	defer a.txMu.Unlock()
+
+	// update_entry is owner-only in the Move package
+	capID, err := resolveCuratorCap(catalogID, "")
+	if err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if capID != "" {
+		writeError(w, http.StatusForbidden, "rolling back requires the catalog owner; curators can only add and remove entries")
+		return
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	cartridge, err := fetchCartridge(client, action.CartridgeID)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	// Keep the entry's current cover image
+	cover := ""
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	found := false
+	for _, entry := range entries {
+		if entry.Slug == action.Slug {
+			found = true
+			if entry.CoverBlobID != "" {
+				cover = "0x" + entry.CoverBlobID
+			}
+		}
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("entry %q not found in catalog", action.Slug))
+		return
+	}
+
+	digest, err := updateCatalogEntry(catalogID, action.Slug, cartridge, cover)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	fmt.Fprintf(stdout, "admin: rolled %s back to cartridge %s (tx %s)\n", action.Slug, action.CartridgeID, digest)
+	writeJSON(w, http.StatusOK, txResponse{Digest: digest})
+}
+
+func (a *adminServer) handleExtend(w http.ResponseWriter, r *http.Request) {
+	var action extendRequest
+	if !decodeBody(w, r, &action) {
+		return
+	}
+	if err := validate.BlobID(action.BlobID); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid blob_id: %v", err))
+		return
+	}
+	if action.Epochs < 1 {
+		writeError(w, http.StatusBadRequest, "epochs must be at least 1")
+		return
+	}
+
+	a.txMu.Lock()
+	defer a.txMu.Unlock()
+
+	objectID, err := findBlobObject(action.BlobID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	backend, err := storageBackend()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	output, err := backend.Extend(action.BlobID, action.Epochs)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	fmt.Fprintf(stdout, "admin: extended blob %s by %d epoch(s)\n", action.BlobID, action.Epochs)
+	writeJSON(w, http.StatusOK, extendResponse{BlobObjectID: objectID, Output: output})
+}
+
+// adminCartridge holds the cartridge fields the console needs
+type adminCartridge struct {
+	ID           string
+	Title        string
+	Platform     model.Platform
+	EmulatorCore string
+	Version      uint16
+	SizeBytes    uint64
+	BlobID       string
+}
+
+// fetchCartridge reads a cartridge object; the blob ID is returned in base58
+func fetchCartridge(client *sui.Client, cartridgeID string) (*adminCartridge, error) {
+	resp, err := client.GetObject(cartridgeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cartridge: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("cartridge %s not found", cartridgeID)
+	}
+
+	fields := sui.ParseCatalog(resp.Data)
+	cartridge := &adminCartridge{ID: resp.Data.ObjectID, Version: 1}
+	cartridge.Title, _ = fields["title"].(string)
+	cartridge.EmulatorCore, _ = fields["emulator_core"].(string)
+	cartridge.SizeBytes = parseU64(fields["size_bytes"])
+	if p, ok := fields["platform"].(float64); ok {
+		cartridge.Platform = model.Platform(p)
+	}
+	if v, ok := fields["version"].(float64); ok {
+		cartridge.Version = uint16(v)
+	}
+	if blobID, err := hex.DecodeString(sui.BytesArrayToHex(fields["blob_id"])); err == nil && len(blobID) > 0 {
+		cartridge.BlobID = base58.Encode(blobID)
+	}
+	return cartridge, nil
+}
+
+// entryArgs returns the catalog entry fields pointing key at the cartridge.
+// cover is the cover blob ID as 0x-hex, or empty for none.
+func (c *adminCartridge) entryArgs(key, cover string) sui.EntryArgs {
+	emulator := c.EmulatorCore
+	if emulator == "" {
+		emulator = model.EmulatorCoreForPlatform(c.Platform)
+	}
+	return sui.EntryArgs{
+		Key:          key,
+		CartridgeID:  c.ID,
+		Title:        c.Title,
+		Platform:     uint8(c.Platform),
+		SizeBytes:    c.SizeBytes,
+		EmulatorCore: emulator,
+		Version:      c.Version,
+		CoverBlobID:  cover,
+	}
+}
+
+// updateCatalogEntry points an entry at a cartridge with update_entry
+// (owner only) and returns the transaction digest
+func updateCatalogEntry(catalogID, slug string, cartridge *adminCartridge, cover string) (string, error) {
+	output, err := executeMoveCall(sui.UpdateEntry(cfg.PackageID, catalogID, cartridge.entryArgs(slug, cover)))
+	if err != nil {
+		return "", fmt.Errorf("failed to update entry: %w", err)
+	}
+	return sui.TransactionDigest(output), nil
+}
+
+// findBlobObject finds the Walrus Blob object owned by the active address
+// that stores blobID. Blob objects store the ID as a little-endian u256.
+func findBlobObject(blobID string) (string, error) {
+	raw, err := base58.Decode(blobID)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode blob ID: %w", err)
+	}
+	reversed := make([]byte, len(raw))
+	for i, b := range raw {
+		reversed[len(raw)-1-i] = b
+	}
+	want := new(big.Int).SetBytes(reversed).String()
+
+	signer, err := activeAddress()
+	if err != nil {
+		return "", err
+	}
+	client := sui.NewClient(cfg.SuiRPCURL)
+	objects, err := client.GetOwnedObjects(signer, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to list owned objects: %w", err)
+	}
+	for i := range objects {
+		if !strings.HasSuffix(objects[i].Type, "::blob::Blob") {
+			continue
+		}
+		if id, _ := sui.ParseCatalog(&objects[i])["blob_id"].(string); id == want {
+			return objects[i].ObjectID, nil
+		}
+	}
+	return "", fmt.Errorf("%s owns no Blob object for %s; only the uploader's address can extend it", signer, blobID)
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/agent"
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/fido2"
+	"github.com/retro-crypto/sui/internal/keystore"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Signing agent
+// ============================================================================
+
+// agentAddressEnv picks one of the agent's keys when it holds several
+const agentAddressEnv = "CATALOGCTL_AGENT_ADDRESS"
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run a signing agent that holds the key for other catalogctl runs",
+	Long: `The signing agent works like ssh-agent: it loads the signing key once
+(private_key, mnemonic or the sui CLI keystore; the config passphrase is asked
+for once if the config is encrypted) and signs transactions for catalogctl
+runs over a unix socket. Commands started with CATALOGCTL_AGENT_SOCK set never
+load the key themselves, so long batch runs and scripts don't hold it.
+
+With --share-passphrase the agent also hands the passphrases of the encrypted
+config and keystore files it was unlocked with to catalogctl runs using it,
+so they don't ask again. Those runs can then read everything in the files,
+including keys kept there.
+
+With --confirm the agent dry-runs every transaction, shows its Move calls and
+balance changes, and asks before signing.
+
+'agent fido2-enroll' binds the encrypted keystore or config file holding the
+key to a FIDO2 security key (hmac-secret, through the libfido2 tools): the
+file is sealed again under a key derived from the key's secret and the
+passphrase, so every run opening it needs a touch. With --fido2 the agent
+refuses to start unless the key was unlocked that way, and --fido2-every N
+asks for another touch every N signatures.
+
+Example:
+  catalogctl agent start --confirm &
+  export CATALOGCTL_AGENT_SOCK=$XDG_RUNTIME_DIR/catalogctl-agent.sock
+  catalogctl publish-batch --dir ./games
+  catalogctl agent stop`,
+}
+
+var agentStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the agent in the foreground",
+	RunE:  runAgentStart,
+}
+
+var agentListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the addresses the running agent signs for",
+	RunE:  runAgentList,
+}
+
+var agentFido2EnrollCmd = &cobra.Command{
+	Use:   "fido2-enroll",
+	Short: "Bind the encrypted file holding the signing key to a FIDO2 security key",
+	RunE:  runAgentFido2Enroll,
+}
+
+var agentStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Make the running agent forget its keys and exit",
+	RunE:  runAgentStop,
+}
+
+var (
+	agentSocket     string
+	agentConfirm    bool
+	agentLifetime   time.Duration
+	agentFido2      bool
+	agentFido2Every int
+	agentFido2Dev   string
+	agentShare      bool
+)
+
+func init() {
+	agentCmd.PersistentFlags().StringVar(&agentSocket, "socket", "", "Agent socket (default: $CATALOGCTL_AGENT_SOCK, then $XDG_RUNTIME_DIR/catalogctl-agent.sock)")
+	agentStartCmd.Flags().BoolVar(&agentConfirm, "confirm", false, "Show every transaction and ask before signing it")
+	agentStartCmd.Flags().BoolVar(&agentShare, "share-passphrase", false, "Hand the passphrases of the encrypted config and keystore to catalogctl runs using the agent")
+	agentStartCmd.Flags().DurationVar(&agentLifetime, "lifetime", 0, "Forget the key and exit after this long (e.g. 8h; default: run until stopped)")
+	agentStartCmd.Flags().BoolVar(&agentFido2, "fido2", false, "Require the signing key to be unlocked with the enrolled security key")
+	agentStartCmd.Flags().IntVar(&agentFido2Every, "fido2-every", 0, "With --fido2, require another touch every N signatures (default: only at startup)")
+	agentFido2EnrollCmd.Flags().StringVar(&agentFido2Dev, "device", "", "Security key device, e.g. /dev/hidraw0 (see fido2-token -L)")
+	agentFido2EnrollCmd.MarkFlagRequired("device")
+	agentCmd.AddCommand(agentStartCmd)
+	reportsResult(agentStartCmd)
+	agentCmd.AddCommand(agentFido2EnrollCmd)
+	agentCmd.AddCommand(agentListCmd)
+	reportsResult(agentListCmd)
+	agentCmd.AddCommand(agentStopCmd)
+	reportsResult(agentStopCmd)
+	rootCmd.AddCommand(agentCmd)
+}
+
+// agentSocketPath returns --socket, $CATALOGCTL_AGENT_SOCK or the default
+func agentSocketPath() string {
+	if agentSocket != "" {
+		return agentSocket
+	}
+	if socket := os.Getenv(agent.SocketEnv); socket != "" {
+		return socket
+	}
+	return agent.DefaultSocket()
+}
+
+func runAgentStart(cmd *cobra.Command, args []string) error {
+	if agentConfirm && !stdinIsTerminal() {
+		return fmt.Errorf("--confirm needs a terminal to ask in")
+	}
+	if agentFido2Every > 0 && !agentFido2 {
+		return fmt.Errorf("--fido2-every needs --fido2")
+	}
+	if agentFido2 {
+		if _, err := fido2.Load(fido2CredentialPath()); err != nil {
+			return err
+		}
+	}
+	signer, err := localSigner()
+	if err != nil {
+		return err
+	}
+	if agentFido2 && fido2Unlocked.kek == nil {
+		return fmt.Errorf("the signing key wasn't unlocked with the security key (it isn't in %s); enroll again with agent fido2-enroll", fido2Unlocked.file)
+	}
+
+	server := agent.NewServer(signer)
+	if agentShare {
+		server.Passphrases = givenPassphrases
+	}
+	if agentConfirm {
+		server.Confirm = confirmSignature
+	}
+	if agentFido2 && agentFido2Every > 0 {
+		server.ReauthEvery = agentFido2Every
+		server.Reauth = fido2Reauth
+	}
+	socket := agentSocketPath()
+	if err := server.Listen(socket); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+	defer os.Remove(socket)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		server.Close()
+	}()
+	if agentLifetime > 0 {
+		time.AfterFunc(agentLifetime, func() {
+			fmt.Fprintf(os.Stderr, "Lifetime of %s is over; forgetting the key\n", agentLifetime)
+			server.Close()
+		})
+	}
+
+	// In json and yaml mode the result is printed once the agent stops
+	setResult(map[string]interface{}{"address": signer.Address, "socket": socket, "share_passphrase": agentShare, "fido2": agentFido2})
+	statusf("✓ Signing agent for %s listening on %s\n", signer.Address, socket)
+	fmt.Fprintf(stdout, "\nexport %s=%s\n\n", agent.SocketEnv, socket)
+	if err := server.Serve(); err != nil {
+		return fmt.Errorf("agent stopped: %w", err)
+	}
+	statusln("✓ Agent stopped")
+	return nil
+}
+
+// confirmSignature shows what a transaction does and asks on the agent's
+// terminal whether to sign it
+func confirmSignature(address, txBytes string) bool {
+	fmt.Fprintf(os.Stderr, "\nSignature requested for %s\n", address)
+	dry, err := sui.NewClient(cfg.SuiRPCURL).DryRunTransactionBlock(txBytes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  ⚠️  Dry run failed, the transaction can't be shown: %v\n", err)
+	} else {
+		for _, command := range dry.Commands {
+			fmt.Fprintf(os.Stderr, "  %s\n", command)
+		}
+		for _, bc := range dry.BalanceChanges {
+			fmt.Fprintf(os.Stderr, "  balance %s %s: %s\n", bc.Owner, bc.CoinType, bc.Amount)
+		}
+		if dry.Status != "success" {
+			fmt.Fprintf(os.Stderr, "  ⚠️  Dry run status %s: %s\n", dry.Status, dry.Error)
+		}
+	}
+	fmt.Fprint(os.Stderr, "Sign? [y/N] ")
+	answer, _ := stdinLines.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// fido2CredentialPath is where the enrolled security key is recorded
+func fido2CredentialPath() string {
+	return filepath.Join(config.GetConfigDir(), "fido2.json")
+}
+
+// fido2Unlocked is the file bound to the security key once a touch unlocked
+// it in this run, with its passphrase and KEK; later touches asked by agent
+// start --fido2-every must derive the same KEK
+var fido2Unlocked struct {
+	cred       *fido2.Credential
+	file       string
+	passphrase string
+	kek        []byte
+}
+
+// fido2Passphrase returns what the file is sealed under: for the file bound
+// to the enrolled security key, the hex KEK derived from a touch and the
+// passphrase; for other files the passphrase itself
+func fido2Passphrase(filename, passphrase string) (string, error) {
+	path := fido2CredentialPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return passphrase, nil
+	}
+	cred, err := fido2.Load(path)
+	if err != nil {
+		return "", err
+	}
+	fido2Unlocked.file = cred.File
+	if abs, err := filepath.Abs(filename); err != nil || abs != cred.File {
+		return passphrase, nil
+	}
+	fmt.Fprintf(os.Stderr, "Touch the security key on %s to unlock %s...\n", cred.Device, filename)
+	kek, err := cred.KEK(passphrase)
+	if err != nil {
+		return "", err
+	}
+	fido2Unlocked.cred, fido2Unlocked.passphrase, fido2Unlocked.kek = cred, passphrase, kek
+	return hex.EncodeToString(kek), nil
+}
+
+// fido2Reauth asks for another touch and checks it derives the KEK the key
+// was unlocked with
+func fido2Reauth() error {
+	cred := fido2Unlocked.cred
+	fmt.Fprintf(os.Stderr, "Touch the security key on %s to keep signing...\n", cred.Device)
+	kek, err := cred.KEK(fido2Unlocked.passphrase)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(kek, fido2Unlocked.kek) != 1 {
+		return fmt.Errorf("the security key on %s is not the enrolled one", cred.Device)
+	}
+	return nil
+}
+
+// fido2BindTarget returns the encrypted file holding the signing key, which
+// enrolling binds to the security key, and its passphrase
+func fido2BindTarget() (file, passphrase string, err error) {
+	if cfg.UsesKeystore() {
+		src, err := keystore.ParseSource(cfg.KeySource)
+		if err != nil {
+			return "", "", err
+		}
+		if src.Kind != keystore.KindFile {
+			return "", "", fmt.Errorf("key_source %s can't be bound to a security key; keep the key in an encrypted keystore file (key_source file) or an encrypted config", src)
+		}
+		store, name, err := openKeystore(cfg.KeySource)
+		if err != nil {
+			return "", "", err
+		}
+		// Opening the file checks the passphrase before the key is touched
+		if _, err := store.Get(name); err != nil {
+			return "", "", err
+		}
+		file = store.String()
+	} else {
+		if cfg.PrivateKey == "" && cfg.Mnemonic == "" {
+			return "", "", fmt.Errorf("no private_key or mnemonic in the config; only keys in an encrypted config or keystore file can be bound to a security key")
+		}
+		if !cfg.Encrypted {
+			return "", "", fmt.Errorf("the signing key is stored in plain text in %s; encrypt it first (catalogctl config encrypt) or move it to a keystore file (catalogctl key import --to file)", cfg.Source)
+		}
+		file = cfg.Source
+	}
+	if file, err = filepath.Abs(file); err != nil {
+		return "", "", err
+	}
+	passphrase, ok := givenPassphrases[file]
+	if !ok {
+		return "", "", fmt.Errorf("the passphrase of %s is unknown", file)
+	}
+	return file, passphrase, nil
+}
+
+func runAgentFido2Enroll(cmd *cobra.Command, args []string) error {
+	path := fido2CredentialPath()
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("a security key is already enrolled (%s); remove it first to enroll another", path)
+	}
+	file, passphrase, err := fido2BindTarget()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Touch the security key twice when it blinks (once to make the credential, once to derive the key-encryption key)\n")
+	cred, err := fido2.Enroll(agentFido2Dev, file)
+	if err != nil {
+		return fmt.Errorf("failed to enroll security key: %w", err)
+	}
+	kek, err := cred.KEK(passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to enroll security key: %w", err)
+	}
+	// The credential is saved first: without it the sealed file can't be
+	// opened again
+	if err := cred.Save(path); err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	wrapped := hex.EncodeToString(kek)
+	if !cfg.UsesKeystore() {
+		err = config.SetPassphrase(cfg.Source, wrapped)
+	} else {
+		err = keystore.Rekey(file, passphrase, wrapped)
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to seal %s under the security key: %w", file, err)
+	}
+	statusf("✓ Security key enrolled (%s)\n", path)
+	statusf("✓ %s now needs the passphrase and a touch of the security key\n", file)
+	statusf("💡 Start the agent with: catalogctl agent start --fido2\n")
+	return nil
+}
+
+func runAgentList(cmd *cobra.Command, args []string) error {
+	addresses, err := agent.NewClient(agentSocketPath()).Addresses()
+	if err != nil {
+		return err
+	}
+	setResult(addresses)
+	for _, address := range addresses {
+		fmt.Fprintln(stdout, address)
+	}
+	return nil
+}
+
+func runAgentStop(cmd *cobra.Command, args []string) error {
+	socket := agentSocketPath()
+	if err := agent.NewClient(socket).Stop(); err != nil {
+		return err
+	}
+	statusf("✓ Stopped the agent on %s\n", socket)
+	setResult(map[string]string{"socket": socket})
+	return nil
+}
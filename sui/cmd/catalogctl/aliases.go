@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// catalog-alias command group
+// ============================================================================
+
+var catalogAliasCmd = &cobra.Command{
+	Use:   "catalog-alias",
+	Short: "Manage named shortcuts for catalog IDs",
+	Long: `Manage named shortcuts for catalog object IDs. An alias can be passed
+anywhere --catalog is accepted. Aliases are stored in
+~/.config/catalogctl/catalogs.json and are bound to a network, so a mainnet
+alias can't be used by accident while sui_network is testnet.`,
+}
+
+var catalogAliasAddCmd = &cobra.Command{
+	Use:   "add NAME CATALOG_ID",
+	Short: "Add or replace a catalog alias",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runCatalogAliasAdd,
+}
+
+var catalogAliasListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List catalog aliases",
+	RunE:  runCatalogAliasList,
+}
+
+var catalogAliasRmCmd = &cobra.Command{
+	Use:   "rm NAME",
+	Short: "Remove a catalog alias",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCatalogAliasRm,
+}
+
+var catalogAliasNetwork string
+
+func init() {
+	catalogAliasAddCmd.Flags().StringVar(&catalogAliasNetwork, "network", "", "Network the catalog is on (default: sui_network from config)")
+
+	catalogAliasCmd.AddCommand(catalogAliasAddCmd, catalogAliasListCmd, catalogAliasRmCmd)
+	reportsResult(catalogAliasAddCmd, catalogAliasListCmd, catalogAliasRmCmd)
+	rootCmd.AddCommand(catalogAliasCmd)
+}
+
+func runCatalogAliasAdd(cmd *cobra.Command, args []string) error {
+	name, catalogID := strings.ToLower(args[0]), args[1]
+	if err := validate.AliasName(name); err != nil {
+		return err
+	}
+	if err := validate.ObjectID(catalogID); err != nil {
+		return fmt.Errorf("invalid catalog ID %s: %w", catalogID, err)
+	}
+
+	network := strings.ToLower(catalogAliasNetwork)
+	if network == "" {
+		network = strings.ToLower(cfg.SuiNetwork)
+	}
+
+	aliases, err := config.LoadCatalogAliases()
+	if err != nil {
+		return err
+	}
+	aliases[name] = config.CatalogAlias{ID: catalogID, Network: network}
+	if err := config.SaveCatalogAliases(aliases); err != nil {
+		return fmt.Errorf("failed to save aliases: %w", err)
+	}
+
+	statusf("✓ Added alias '%s' → %s (%s)\n", name, catalogID, network)
+	setResult(map[string]string{"name": name, "catalog_id": catalogID, "network": network})
+	return nil
+}
+
+func runCatalogAliasList(cmd *cobra.Command, args []string) error {
+	aliases, err := config.LoadCatalogAliases()
+	if err != nil {
+		return err
+	}
+	setResult(aliases)
+	if len(aliases) == 0 {
+		fmt.Fprintln(stdout, "No catalog aliases. Add one with: catalogctl catalog-alias add NAME 0xCATALOG_ID")
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "%-20s %-10s %s\n", "NAME", "NETWORK", "CATALOG_ID")
+	for _, name := range config.AliasNames(aliases) {
+		alias := aliases[name]
+		fmt.Fprintf(stdout, "%-20s %-10s %s\n", name, alias.Network, alias.ID)
+	}
+	return nil
+}
+
+func runCatalogAliasRm(cmd *cobra.Command, args []string) error {
+	name := strings.ToLower(args[0])
+
+	aliases, err := config.LoadCatalogAliases()
+	if err != nil {
+		return err
+	}
+	if _, ok := aliases[name]; !ok {
+		return fmt.Errorf("no alias named '%s'", name)
+	}
+	removed := aliases[name]
+	delete(aliases, name)
+	if err := config.SaveCatalogAliases(aliases); err != nil {
+		return fmt.Errorf("failed to save aliases: %w", err)
+	}
+
+	statusf("✓ Removed alias '%s'\n", name)
+	setResult(map[string]string{"name": name, "catalog_id": removed.ID, "network": removed.Network})
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/approval"
+	"github.com/retro-crypto/sui/internal/plan"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Approve Command
+// ============================================================================
+
+var approveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "Approve a pending mainnet publish request",
+	Long: `Sign a pending publish request as a second operator.
+
+When approvers are configured, publish-game on mainnet doesn't send anything;
+it writes a request file signed by the requester. Another operator listed in
+approvers reviews the plan and approves it with this command. Afterwards either
+party submits it with:
+
+  catalogctl execute-plan --request FILE
+
+Approvals are Ed25519 signatures made with private_key from the config. They
+cover the plan, the request's nonce and its expiry: expired requests can't be
+approved or executed, and each request is executed once.`,
+	RunE: runApprove,
+}
+
+var (
+	approveRequest string
+	approveShowKey bool
+)
+
+func init() {
+	approveCmd.Flags().StringVar(&approveRequest, "request", "", "Path to the request file")
+	approveCmd.Flags().BoolVar(&approveShowKey, "show-key", false, "Print your approver public key (for the approvers list) and exit")
+	rootCmd.AddCommand(approveCmd)
+	reportsResult(approveCmd)
+}
+
+// signingKey returns the operator's approval key from the config (or
+// key_source)
+func signingKey() (ed25519.PrivateKey, string, error) {
+	privateKey, _, err := configuredKey()
+	if err != nil {
+		return nil, "", err
+	}
+	if privateKey == "" {
+		return nil, "", fmt.Errorf("approvals are signed with private_key; set it in the config or key_source (hex encoded Ed25519 seed)")
+	}
+	k, err := approval.KeyFromHex(privateKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid private_key: %w", err)
+	}
+	return k, approval.PublicKeyHex(k), nil
+}
+
+func runApprove(cmd *cobra.Command, args []string) error {
+	key, pub, err := signingKey()
+	if err != nil {
+		return err
+	}
+
+	if approveShowKey {
+		setResult(map[string]string{"public_key": pub})
+		fmt.Fprintln(stdout, pub)
+		return nil
+	}
+	if approveRequest == "" {
+		return fmt.Errorf("--request is required")
+	}
+
+	req, err := approval.Load(approveRequest)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Request: %s\n", approveRequest)
+	fmt.Fprintf(stdout, "Requested by: %s at %s\n", req.RequestedBy, req.RequestedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(stdout, "Plan digest: %s\n", req.PlanDigest)
+	fmt.Fprintf(stdout, "Expires: %s\n\n", req.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+	printPlan(req.Plan)
+	fmt.Fprintln(stdout)
+
+	if err := req.Approve(key); err != nil {
+		return err
+	}
+	if err := req.Save(approveRequest); err != nil {
+		return err
+	}
+
+	statusf("✓ Approved as %s\n", pub)
+	result := map[string]interface{}{
+		"request_file": approveRequest,
+		"plan_digest":  req.PlanDigest,
+		"approver":     pub,
+		"expires_at":   req.ExpiresAt.Format(time.RFC3339),
+		"executable":   true,
+	}
+	setResult(result)
+	if err := req.Verify(cfg.Approvers); err != nil {
+		result["executable"] = false
+		statusf("⚠️  Request is not yet executable: %v\n", err)
+		return nil
+	}
+	statusf("\n💡 Submit it with: catalogctl execute-plan --request %s\n", approveRequest)
+	return nil
+}
+
+// createApprovalRequest writes a signed request for the plan instead of
+// executing it
+func createApprovalRequest(pl *plan.Plan, filename string, lifetime time.Duration) error {
+	key, pub, err := signingKey()
+	if err != nil {
+		return fmt.Errorf("mainnet publishes require approval: %w", err)
+	}
+
+	req, err := approval.NewRequest(pl, key, lifetime)
+	if err != nil {
+		return err
+	}
+	if err := req.Save(filename); err != nil {
+		return err
+	}
+
+	printPlan(pl)
+	statusf("\n✓ Mainnet publish requires approval. Request written to %s\n", filename)
+	fmt.Fprintf(stdout, "  Requested by: %s\n", pub)
+	fmt.Fprintf(stdout, "  Plan digest: %s\n", req.PlanDigest)
+	fmt.Fprintf(stdout, "  Expires: %s\n", req.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+	statusf("\n💡 Ask a second operator to run: catalogctl approve --request %s\n", filename)
+	fmt.Fprintf(stdout, "   Then submit with: catalogctl execute-plan --request %s\n", filename)
+
+	newGHSummary("Publish pending approval").
+		row("Request file", "`"+filename+"`").
+		row("Requested by", "`"+pub+"`").
+		row("Plan digest", "`"+req.PlanDigest+"`").
+		row("Expires", req.ExpiresAt.Format(time.RFC3339)).
+		row("Max gas", formatSUI(int64(pl.Estimate.MaxGasMist))).
+		output("request_file", filename).
+		output("plan_digest", req.PlanDigest).
+		output("expires_at", req.ExpiresAt.Format(time.RFC3339)).
+		write()
+	return nil
+}
+
+// approvedWrites is set while execute-plan runs the plan of an approved
+// request
+var approvedWrites bool
+
+// requireApproval refuses a transaction while mainnet approvals are required,
+// unless it belongs to an approved request. Every transaction passes through
+// it (see executeSuiCommand and submit), so commands can't skip approvals.
+func requireApproval() error {
+	if cfg.ApprovalRequired() && !approvedWrites {
+		return fmt.Errorf("mainnet approvals are required: transactions are only sent for approved requests (publish-game, approve, then execute-plan --request)")
+	}
+	return nil
+}
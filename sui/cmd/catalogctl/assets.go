@@ -0,0 +1,217 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Cartridge assets
+// ============================================================================
+
+// fetchCartridgeAssets reads the named assets attached to a cartridge with
+// cartridge::add_asset, sorted by name
+func fetchCartridgeAssets(client *sui.Client, cartridgeID string) ([]model.Asset, error) {
+	dynamicFields, err := client.GetAllDynamicFields(cartridgeID, 50)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assets: %w", err)
+	}
+
+	assets := []model.Asset{}
+	for _, field := range dynamicFields {
+		if !strings.HasSuffix(field.Name.Type, "::cartridge::AssetKey") {
+			continue
+		}
+		fieldObj, err := client.GetDynamicFieldObject(cartridgeID, field.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get asset: %w", err)
+		}
+		assetFields := sui.ParseCatalogEntry(fieldObj.Data)
+		if assetFields == nil {
+			continue
+		}
+
+		asset := model.Asset{
+			BlobID:    sui.BytesArrayToHex(assetFields["blob_id"]),
+			SHA256:    sui.BytesArrayToHex(assetFields["sha256"]),
+			SizeBytes: parseU64(assetFields["size_bytes"]),
+		}
+		asset.Name, _ = assetFields["name"].(string)
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Name < assets[j].Name })
+	return assets, nil
+}
+
+// ============================================================================
+// download-game command
+// ============================================================================
+
+var downloadGameCmd = &cobra.Command{
+	Use:   "download-game",
+	Short: "Download a game or one of its assets and verify its SHA256",
+	Long: `Download the game file of a cartridge, or with --asset one of the extra
+files published with it (manual, soundtrack, ...), from Walrus.
+
+The cartridge is given with --id, or looked up in a catalog with --slug.
+The download is checked against the size and SHA256 recorded on chain.
+
+Delta updates (published with publish-game --delta-from) are rebuilt by
+downloading the base version and applying the patch. With --base-file, a
+local copy of an earlier version is used instead of downloading it.`,
+	RunE: runDownloadGame,
+}
+
+var (
+	downloadGameID        string
+	downloadGameSlug      string
+	downloadGameCatalogID string
+	downloadGameAsset     string
+	downloadGameOutput    string
+	downloadGameBaseFile  string
+)
+
+func init() {
+	downloadGameCmd.Flags().StringVar(&downloadGameID, "id", "", "Cartridge object ID")
+	validateAs(downloadGameCmd.Flags(), "id", formatObjectID)
+	downloadGameCmd.Flags().StringVar(&downloadGameSlug, "slug", "", "Catalog entry slug (instead of --id)")
+	downloadGameCmd.Flags().StringVar(&downloadGameCatalogID, "catalog", "", "Catalog object ID or alias for --slug (optional, uses config.catalog_id if not set)")
+	validateAs(downloadGameCmd.Flags(), "catalog", formatCatalogRef)
+	downloadGameCmd.Flags().StringVar(&downloadGameAsset, "asset", "", "Download this asset instead of the game file (see get-cartridge)")
+	outFileFlag(downloadGameCmd, &downloadGameOutput, "File to write (default: <slug>.zip, or <slug>-<asset>)")
+	downloadGameCmd.Flags().StringVar(&downloadGameBaseFile, "base-file", "", "Local copy of an earlier version, used when a delta update needs it")
+	rootCmd.AddCommand(downloadGameCmd)
+	reportsResult(downloadGameCmd)
+}
+
+func runDownloadGame(cmd *cobra.Command, args []string) error {
+	if (downloadGameID == "") == (downloadGameSlug == "") {
+		return fmt.Errorf("pass either --id or --slug")
+	}
+	client := sui.NewClient(cfg.SuiRPCURL)
+
+	cartridgeID := downloadGameID
+	if downloadGameSlug != "" {
+		catalogID := downloadGameCatalogID
+		if catalogID == "" {
+			catalogID = cfg.CatalogID
+		}
+		if catalogID == "" {
+			return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+		}
+		catalogID, err := cfg.ResolveCatalogID(catalogID)
+		if err != nil {
+			return err
+		}
+		if cartridgeID, err = entryCartridgeID(client, catalogID, downloadGameSlug); err != nil {
+			return err
+		}
+	}
+	if err := validate.ObjectID(cartridgeID); err != nil {
+		return fmt.Errorf("invalid cartridge ID %s: %w", cartridgeID, err)
+	}
+
+	resp, err := client.GetObject(cartridgeID)
+	if err != nil {
+		return fmt.Errorf("failed to get cartridge: %w", err)
+	}
+	if resp.Data == nil {
+		return fmt.Errorf("cartridge %s not found", cartridgeID)
+	}
+	slug, _ := sui.ParseCatalog(resp.Data)["slug"].(string)
+
+	if downloadGameAsset == "" {
+		output := slug + ".zip"
+		if downloadGameOutput != "" {
+			output = downloadGameOutput
+		}
+		data, err := fetchGameFile(client, cartridgeID, downloadGameBaseFile, 0)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(output, data, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		hash := sha256.Sum256(data)
+		statusf("✓ Downloaded %d bytes to %s\n", len(data), output)
+		fmt.Fprintf(stdout, "  SHA256: %s (verified)\n", hex.EncodeToString(hash[:]))
+		setResult(map[string]interface{}{
+			"cartridge_id": cartridgeID,
+			"path":         output,
+			"sha256":       hex.EncodeToString(hash[:]),
+			"size_bytes":   len(data),
+		})
+		return nil
+	}
+
+	assets, err := fetchCartridgeAssets(client, cartridgeID)
+	if err != nil {
+		return err
+	}
+	var (
+		target model.Asset
+		names  []string
+		found  bool
+	)
+	for _, asset := range assets {
+		names = append(names, asset.Name)
+		if asset.Name == downloadGameAsset {
+			target, found = asset, true
+		}
+	}
+	if !found {
+		if len(names) == 0 {
+			return fmt.Errorf("cartridge %s has no assets", cartridgeID)
+		}
+		return fmt.Errorf("cartridge %s has no asset %q (available: %s)", cartridgeID, downloadGameAsset, strings.Join(names, ", "))
+	}
+	output := slug + "-" + downloadGameAsset
+	if downloadGameOutput != "" {
+		output = downloadGameOutput
+	}
+
+	blobIDBytes, err := hex.DecodeString(target.BlobID)
+	if err != nil || len(blobIDBytes) == 0 {
+		return fmt.Errorf("asset %s has no valid blob ID", target.Name)
+	}
+	blobID := base58.Encode(blobIDBytes)
+
+	statusf("Downloading blob %s...\n", blobID)
+	data, err := readBlob(blobID)
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+
+	hash := sha256.Sum256(data)
+	sha256Hex := hex.EncodeToString(hash[:])
+	if uint64(len(data)) != target.SizeBytes || sha256Hex != target.SHA256 {
+		return fmt.Errorf("downloaded blob doesn't match the asset (got %d bytes, SHA256 %s; expected %d bytes, SHA256 %s)",
+			len(data), sha256Hex, target.SizeBytes, target.SHA256)
+	}
+
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	statusf("✓ Downloaded %d bytes to %s\n", len(data), output)
+	fmt.Fprintf(stdout, "  SHA256: %s (verified)\n", sha256Hex)
+	setResult(map[string]interface{}{
+		"cartridge_id": cartridgeID,
+		"asset":        target.Name,
+		"blob_id":      blobID,
+		"path":         output,
+		"sha256":       sha256Hex,
+		"size_bytes":   len(data),
+	})
+	return nil
+}
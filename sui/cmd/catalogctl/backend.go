@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/memchain"
+)
+
+// ============================================================================
+// Simulation backend (--backend memory)
+// ============================================================================
+
+const (
+	backendSui    = "sui"
+	backendMemory = "memory"
+)
+
+var (
+	backendName  string
+	memoryDBPath string
+
+	// memoryChain is set when --backend memory is active; sui CLI commands
+	// and config edits then go to the simulated chain
+	memoryChain *memchain.Chain
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&backendName, "backend", "", "Chain backend: sui or memory (offline simulation; default: $CATALOGCTL_BACKEND or sui)")
+	rootCmd.PersistentFlags().StringVar(&memoryDBPath, "memory-db", "", "Database of the memory backend (default: ~/.config/catalogctl/memory.json)")
+}
+
+// setupBackend points cfg at the selected backend. For the memory backend it
+// opens the database, starts the local Sui RPC / Walrus endpoints and
+// overrides the network settings so nothing reaches a real network.
+func setupBackend() error {
+	if backendName == "" {
+		backendName = os.Getenv("CATALOGCTL_BACKEND")
+	}
+	switch backendName {
+	case "", backendSui:
+		return nil
+	case backendMemory:
+	default:
+		return fmt.Errorf("unknown --backend %q (use %s or %s)", backendName, backendSui, backendMemory)
+	}
+
+	if memoryDBPath == "" {
+		memoryDBPath = filepath.Join(config.GetConfigDir(), "memory.json")
+	}
+	chain, err := memchain.Open(memoryDBPath)
+	if err != nil {
+		return err
+	}
+	url, err := chain.Serve()
+	if err != nil {
+		return err
+	}
+
+	memoryChain = chain
+	cfg.SuiNetwork = "localnet"
+	cfg.WalrusNetwork = "localnet"
+	cfg.SuiRPCURL = url
+	cfg.WalrusAggregatorURL = url
+	cfg.WalrusPublisherURL = url
+	cfg.SuiRPCURLs = nil
+	cfg.WalrusAggregatorURLs = nil
+	cfg.WalrusAggregatorRegions = nil
+	cfg.WalrusPublisherURLs = nil
+	cfg.PackageID = chain.PackageID()
+	cfg.OriginalPackageID = ""
+	cfg.CatalogID = chain.Setting("catalog_id")
+	cfg.RegistryID = chain.Setting("registry_id")
+	if !quiet() {
+		fmt.Fprintf(os.Stderr, "Using memory backend (%s)\n", memoryDBPath)
+	}
+	return nil
+}
@@ -0,0 +1,608 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/plan"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// publish-batch command
+// ============================================================================
+
+var publishBatchCmd = &cobra.Command{
+	Use:   "publish-batch",
+	Short: "Publish every game of a manifest or directory, tracking the status of each",
+	Long: `Publishes the games listed in a JSON or CSV manifest one after another, each
+exactly like publish-game (with its own resumable journal). The status of
+every game is kept in a state file, so a later run only publishes what isn't
+done yet:
+
+  [
+    {"file": "doom.zip", "slug": "doom", "title": "DOOM", "platform": "dos", "version": 1},
+    {"file": "tetris.gb", "slug": "tetris", "title": "Tetris", "platform": "gb",
+     "assets": {"manual": "tetris-manual.pdf"}}
+  ]
+
+A manifest ending in .csv has a header row naming its columns: file, slug,
+title, platform, version, emulator, channel and assets (name=path pairs
+separated by ";"):
+
+  file,slug,title,platform,version,assets
+  doom.zip,doom,DOOM,dos,1,
+  tetris.gb,tetris,Tetris,gb,,manual=tetris-manual.pdf
+
+With --dir, every game file of a directory is published as version 1. The
+platform comes from the extension (.zip/.jsdos: dos, .gb, .gbc, .nes,
+.sfc/.smc: snes), the slug and title from the file name; other files are
+ignored. State and report are kept in the directory.
+
+By default the batch stops at the first failure; --continue-on-error keeps
+going. --retry-failed only publishes the games that failed in earlier runs.
+Every run writes a JSON report listing each game as succeeded, skipped or
+failed, with the reason.
+
+Example:
+  catalogctl publish-batch --manifest games.json --continue-on-error
+  catalogctl publish-batch --manifest games.csv
+  catalogctl publish-batch --dir ./roms
+  catalogctl publish-batch --manifest games.json --retry-failed`,
+	RunE: runPublishBatch,
+}
+
+var (
+	publishBatchManifest        string
+	publishBatchDir             string
+	publishBatchCatalogID       string
+	publishBatchCapID           string
+	publishBatchEpochs          int
+	publishBatchState           string
+	publishBatchReport          string
+	publishBatchContinueOnError bool
+	publishBatchRetryFailed     bool
+)
+
+func init() {
+	publishBatchCmd.Flags().StringVar(&publishBatchManifest, "manifest", "", "JSON or CSV manifest of games to publish")
+	publishBatchCmd.Flags().StringVar(&publishBatchDir, "dir", "", "Publish every game file in this directory (instead of --manifest)")
+	publishBatchCmd.Flags().StringVar(&publishBatchCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(publishBatchCmd.Flags(), "catalog", formatCatalogRef)
+	publishBatchCmd.Flags().StringVar(&publishBatchCapID, "cap", "", "CuratorCap object ID (auto-selected if the signer isn't the catalog owner)")
+	publishBatchCmd.Flags().IntVar(&publishBatchEpochs, "epochs", 5, "Number of storage epochs for Walrus")
+	publishBatchCmd.Flags().StringVar(&publishBatchState, "state", "", "State file with the status of every game (default: <manifest>.state.json or <dir>/publish-batch.state.json)")
+	publishBatchCmd.Flags().StringVar(&publishBatchReport, "report", "", "Report file written at the end of every run (default: <manifest>.report.json or <dir>/publish-batch.report.json)")
+	publishBatchCmd.Flags().BoolVar(&publishBatchContinueOnError, "continue-on-error", false, "Keep publishing the remaining games after a failure")
+	publishBatchCmd.Flags().BoolVar(&publishBatchRetryFailed, "retry-failed", false, "Only publish games that failed in an earlier run")
+	rootCmd.AddCommand(publishBatchCmd)
+	reportsResult(publishBatchCmd)
+}
+
+// batchItem is one game of a publish-batch manifest
+type batchItem struct {
+	File     string `json:"file"`
+	Slug     string `json:"slug"`
+	Title    string `json:"title"`
+	Platform string `json:"platform,omitempty"`
+	Emulator string `json:"emulator,omitempty"`
+	Version  uint16 `json:"version,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+	// Assets maps asset names to files, like publish-game --asset
+	Assets map[string]string `json:"assets,omitempty"`
+}
+
+// Key identifies the item in the state file and report
+func (it batchItem) Key() string {
+	return fmt.Sprintf("%s@v%d", model.ChannelKey(it.Slug, it.Channel), it.Version)
+}
+
+// Statuses of a batch item
+const (
+	batchPending   = "pending"
+	batchSucceeded = "succeeded"
+	batchSkipped   = "skipped"
+	batchFailed    = "failed"
+)
+
+// batchItemState is the recorded outcome of one item across runs
+type batchItemState struct {
+	Status      string `json:"status"`
+	Reason      string `json:"reason,omitempty"`
+	Attempts    int    `json:"attempts"`
+	BlobID      string `json:"blob_id,omitempty"`
+	CartridgeID string `json:"cartridge_id,omitempty"`
+	Journal     string `json:"journal,omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+}
+
+// batchState is the state file of a manifest
+type batchState struct {
+	Manifest string                     `json:"manifest"`
+	Items    map[string]*batchItemState `json:"items"`
+}
+
+// batchReportItem is one line of the report of a run
+type batchReportItem struct {
+	Key         string `json:"key"`
+	Slug        string `json:"slug"`
+	Version     uint16 `json:"version"`
+	File        string `json:"file"`
+	Status      string `json:"status"`
+	Reason      string `json:"reason,omitempty"`
+	BlobID      string `json:"blob_id,omitempty"`
+	CartridgeID string `json:"cartridge_id,omitempty"`
+	Journal     string `json:"journal,omitempty"`
+}
+
+// batchReport is the machine-readable result of a publish-batch run
+type batchReport struct {
+	Manifest  string            `json:"manifest"`
+	CatalogID string            `json:"catalog_id"`
+	Network   string            `json:"network"`
+	Succeeded int               `json:"succeeded"`
+	Skipped   int               `json:"skipped"`
+	Failed    int               `json:"failed"`
+	Items     []batchReportItem `json:"items"`
+}
+
+func runPublishBatch(cmd *cobra.Command, args []string) error {
+	if (publishBatchManifest == "") == (publishBatchDir == "") {
+		return fmt.Errorf("pass either --manifest or --dir")
+	}
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+	if cfg.ApprovalRequired() {
+		return fmt.Errorf("publish-batch can't be used while mainnet approvals are required; publish games one by one with publish-game")
+	}
+
+	catalogID := publishBatchCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
+	if err := validate.ObjectID(catalogID); err != nil {
+		return fmt.Errorf("invalid catalog ID %s: %w", catalogID, err)
+	}
+
+	// source names the batch in the state file and report; journals are
+	// written next to it
+	var (
+		items      []batchItem
+		source     string
+		journalDir string
+		statePath  = publishBatchState
+		reportPath = publishBatchReport
+	)
+	if publishBatchDir != "" {
+		items, err = loadBatchDir(publishBatchDir)
+		source, journalDir = publishBatchDir, publishBatchDir
+		if statePath == "" {
+			statePath = filepath.Join(publishBatchDir, "publish-batch.state.json")
+		}
+		if reportPath == "" {
+			reportPath = filepath.Join(publishBatchDir, "publish-batch.report.json")
+		}
+	} else {
+		items, err = loadBatchManifest(publishBatchManifest)
+		source, journalDir = publishBatchManifest, filepath.Dir(publishBatchManifest)
+		if statePath == "" {
+			statePath = publishBatchManifest + ".state.json"
+		}
+		if reportPath == "" {
+			reportPath = publishBatchManifest + ".report.json"
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	state, err := loadBatchState(statePath, source)
+	if err != nil {
+		return err
+	}
+
+	capID, err := resolveCuratorCap(catalogID, publishBatchCapID)
+	if err != nil {
+		return err
+	}
+
+	report := &batchReport{Manifest: source, CatalogID: catalogID, Network: cfg.SuiNetwork}
+	stopped := false
+	for i, item := range items {
+		key := item.Key()
+		st := state.Items[key]
+		if st == nil {
+			st = &batchItemState{Status: batchPending}
+			state.Items[key] = st
+		}
+
+		// reason is set for items this run doesn't publish
+		reason := ""
+		switch {
+		case st.Status == batchSucceeded:
+			reason = "already published"
+		case publishBatchRetryFailed && st.Status != batchFailed:
+			reason = "not a failed item (--retry-failed)"
+		case stopped:
+			reason = "not attempted after an earlier failure (use --continue-on-error)"
+		}
+		if reason != "" {
+			report.add(item, key, st, batchSkipped, reason)
+			continue
+		}
+
+		fmt.Fprintf(stdout, "\n=== [%d/%d] %s ===\n", i+1, len(items), key)
+		st.Attempts++
+		prog, journal, err := publishBatchItem(item, catalogID, capID, journalDir)
+		st.Journal = journal
+		st.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+		if err != nil {
+			st.Status, st.Reason = batchFailed, err.Error()
+			fmt.Fprintf(stdout, "✗ %s failed: %v\n", key, err)
+			stopped = !publishBatchContinueOnError
+		} else {
+			st.Status, st.Reason = batchSucceeded, ""
+			st.BlobID = prog.Outputs["blob_id"]
+			st.CartridgeID = prog.Outputs["cartridge_id"]
+			fmt.Fprintf(stdout, "✓ %s published: cartridge %s\n", key, st.CartridgeID)
+		}
+		report.add(item, key, st, st.Status, st.Reason)
+		if err := state.save(statePath); err != nil {
+			return err
+		}
+	}
+
+	if err := state.save(statePath); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if err := os.WriteFile(reportPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	fmt.Fprintf(stdout, "\nBatch: %d succeeded, %d skipped, %d failed (of %d)\n", report.Succeeded, report.Skipped, report.Failed, len(items))
+	for _, it := range report.Items {
+		if it.Status == batchFailed {
+			fmt.Fprintf(stdout, "  ✗ %s: %s\n", it.Key, it.Reason)
+		}
+	}
+	fmt.Fprintf(stdout, "  State: %s\n", statePath)
+	fmt.Fprintf(stdout, "  Report: %s\n", reportPath)
+
+	newGHSummary("Publish batch").
+		row("Catalog", catalogID).
+		row("Succeeded", fmt.Sprintf("%d", report.Succeeded)).
+		row("Skipped", fmt.Sprintf("%d", report.Skipped)).
+		row("Failed", fmt.Sprintf("%d", report.Failed)).
+		output("report", reportPath).
+		write()
+
+	if report.Failed > 0 {
+		retry := "--manifest " + publishBatchManifest
+		if publishBatchDir != "" {
+			retry = "--dir " + publishBatchDir
+		}
+		statusln("\n💡 Publish only the failed games with: catalogctl publish-batch " + retry + " --retry-failed")
+		return fmt.Errorf("%d of %d games failed", report.Failed, len(items))
+	}
+	return nil
+}
+
+// publishBatchItem publishes one game through the publish-game plan and
+// returns its progress and journal, which is kept in journalDir
+func publishBatchItem(item batchItem, catalogID, capID, journalDir string) (*plan.Progress, string, error) {
+	platform, err := model.ParsePlatform(item.Platform)
+	if err != nil {
+		return nil, "", err
+	}
+	channel, err := model.ParseChannel(item.Channel)
+	if err != nil {
+		return nil, "", err
+	}
+	emulator := item.Emulator
+	if emulator == "" {
+		emulator = model.EmulatorCoreForPlatform(platform)
+	}
+
+	sha256Hex, size, err := fileSHA256(item.File)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	var specs []string
+	for name, path := range item.Assets {
+		specs = append(specs, name+"="+path)
+	}
+	sort.Strings(specs)
+	assets, err := parseAssets(specs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	params := publishGameParams{
+		FilePath:  item.File,
+		Size:      size,
+		SHA256Hex: sha256Hex,
+		Slug:      item.Slug,
+		Title:     item.Title,
+		Platform:  platform,
+		Emulator:  emulator,
+		Version:   item.Version,
+		Epochs:    publishBatchEpochs,
+		CatalogID: catalogID,
+		CapID:     capID,
+		Channel:   channel,
+		Assets:    assets,
+	}
+	pl := buildPublishGamePlan(params)
+
+	// Same journal name as publish-game, so either command can resume it
+	journal := filepath.Join(journalDir, fmt.Sprintf("publish-%s-v%d.journal.json", item.Slug, item.Version))
+	prog, err := plan.LoadProgress(journal, pl)
+	if err != nil {
+		return nil, journal, err
+	}
+	if err := executePlan(pl, prog, journal); err != nil {
+		return nil, journal, err
+	}
+	return prog, journal, nil
+}
+
+// loadBatchManifest reads and checks a JSON or CSV manifest. Relative file
+// paths are relative to the manifest.
+func loadBatchManifest(path string) ([]batchItem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var items []batchItem
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		items, err = parseBatchCSV(string(data))
+	} else {
+		err = json.Unmarshal(data, &items)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("manifest %s lists no games", path)
+	}
+
+	seen := make(map[string]bool)
+	for i := range items {
+		it := &items[i]
+		if it.File == "" || it.Slug == "" || it.Title == "" {
+			return nil, fmt.Errorf("manifest item %d: file, slug and title are required", i+1)
+		}
+		if it.Platform == "" {
+			it.Platform = "dos"
+		}
+		if it.Version == 0 {
+			it.Version = 1
+		}
+		if !filepath.IsAbs(it.File) {
+			it.File = filepath.Join(filepath.Dir(path), it.File)
+		}
+		for name, file := range it.Assets {
+			if !filepath.IsAbs(file) {
+				it.Assets[name] = filepath.Join(filepath.Dir(path), file)
+			}
+		}
+		if seen[it.Key()] {
+			return nil, fmt.Errorf("manifest item %d: %s is listed twice", i+1, it.Key())
+		}
+		seen[it.Key()] = true
+	}
+	return items, nil
+}
+
+// batchCSVColumns are the columns a CSV manifest may have
+var batchCSVColumns = []string{"file", "slug", "title", "platform", "version", "emulator", "channel", "assets"}
+
+// parseBatchCSV reads a CSV manifest; the first row names the columns
+func parseBatchCSV(data string) ([]batchItem, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		known := false
+		for _, c := range batchCSVColumns {
+			known = known || c == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q (expected %s)", name, strings.Join(batchCSVColumns, ", "))
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"file", "slug", "title"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("the header row must name the %s column", required)
+		}
+	}
+
+	var items []batchItem
+	for n, row := range rows[1:] {
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		item := batchItem{
+			File:     get("file"),
+			Slug:     get("slug"),
+			Title:    get("title"),
+			Platform: get("platform"),
+			Emulator: get("emulator"),
+			Channel:  get("channel"),
+		}
+		if v := get("version"); v != "" {
+			version, err := strconv.ParseUint(v, 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid version %q", n+2, v)
+			}
+			item.Version = uint16(version)
+		}
+		if v := get("assets"); v != "" {
+			item.Assets = make(map[string]string)
+			for _, spec := range strings.Split(v, ";") {
+				name, file, ok := strings.Cut(strings.TrimSpace(spec), "=")
+				if !ok || name == "" || file == "" {
+					return nil, fmt.Errorf("row %d: asset %q must be NAME=PATH", n+2, spec)
+				}
+				item.Assets[name] = file
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// batchDirPlatforms maps the file extensions --dir publishes to platforms
+var batchDirPlatforms = map[string]string{
+	".zip":   "dos",
+	".jsdos": "dos",
+	".gb":    "gb",
+	".gbc":   "gbc",
+	".nes":   "nes",
+	".sfc":   "snes",
+	".smc":   "snes",
+}
+
+// loadBatchDir lists the game files of a directory as batch items, deriving
+// the platform from the extension and the slug and title from the name
+func loadBatchDir(dir string) ([]batchItem, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var items []batchItem
+	seen := make(map[string]string)
+	for _, f := range files {
+		ext := strings.ToLower(filepath.Ext(f.Name()))
+		platform, ok := batchDirPlatforms[ext]
+		if f.IsDir() || !ok || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		slug := slugFromName(name)
+		if slug == "" {
+			return nil, fmt.Errorf("can't derive a slug from %s; use a manifest", f.Name())
+		}
+		if other, ok := seen[slug]; ok {
+			return nil, fmt.Errorf("%s and %s both map to slug %s; use a manifest", other, f.Name(), slug)
+		}
+		seen[slug] = f.Name()
+		items = append(items, batchItem{
+			File:     filepath.Join(dir, f.Name()),
+			Slug:     slug,
+			Title:    name,
+			Platform: platform,
+			Version:  1,
+		})
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("directory %s has no game files", dir)
+	}
+	return items, nil
+}
+
+// slugFromName lowercases a file name and turns everything but letters and
+// digits into single dashes
+func slugFromName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// loadBatchState reads the state file, returning empty state if it doesn't exist
+func loadBatchState(path, manifest string) (*batchState, error) {
+	state := &batchState{Manifest: manifest, Items: make(map[string]*batchItemState)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if state.Items == nil {
+		state.Items = make(map[string]*batchItemState)
+	}
+	return state, nil
+}
+
+func (s *batchState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// add records an item's outcome for this run
+func (r *batchReport) add(item batchItem, key string, st *batchItemState, status, reason string) {
+	switch status {
+	case batchSucceeded:
+		r.Succeeded++
+	case batchFailed:
+		r.Failed++
+	default:
+		r.Skipped++
+	}
+	r.Items = append(r.Items, batchReportItem{
+		Key:         key,
+		Slug:        item.Slug,
+		Version:     item.Version,
+		File:        item.File,
+		Status:      status,
+		Reason:      reason,
+		BlobID:      st.BlobID,
+		CartridgeID: st.CartridgeID,
+		Journal:     st.Journal,
+	})
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/walrus"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// benchmark-endpoints command
+// ============================================================================
+
+var benchmarkEndpointsCmd = &cobra.Command{
+	Use:   "benchmark-endpoints",
+	Short: "Measure Sui RPC and Walrus endpoint latency and rank them in the config",
+	Long: `Probes every configured Sui RPC endpoint (sui_rpc_url and sui_rpc_urls),
+Walrus aggregator (walrus_aggregator_url and walrus_aggregator_urls) and
+Walrus publisher (walrus_publisher_url and walrus_publisher_urls), and ranks
+them by error rate, then median latency.
+
+The ranking is written to the config file: the best endpoint becomes the
+single-URL field and the others are stored in the list field, fastest first.
+Probes are read-only (latest checkpoint for Sui, API description for Walrus),
+so nothing is uploaded.
+
+Example:
+  catalogctl config set walrus_publisher_urls https://a.example,https://b.example
+  catalogctl benchmark-endpoints`,
+	RunE: runBenchmarkEndpoints,
+}
+
+var (
+	benchmarkSamples int
+	benchmarkNoSave  bool
+)
+
+// endpointPingTimeout bounds a single Walrus probe
+const endpointPingTimeout = 10 * time.Second
+
+func init() {
+	benchmarkEndpointsCmd.Flags().IntVar(&benchmarkSamples, "samples", 5, "Probes per endpoint")
+	benchmarkEndpointsCmd.Flags().BoolVar(&benchmarkNoSave, "no-save", false, "Only print the ranking, don't write it to the config file")
+	rootCmd.AddCommand(benchmarkEndpointsCmd)
+	reportsResult(benchmarkEndpointsCmd)
+}
+
+// endpointResult holds the probe measurements for one endpoint
+type endpointResult struct {
+	URL       string
+	Samples   int
+	Errors    int
+	LastError error
+	Latencies []time.Duration
+}
+
+// Median returns the median latency of successful probes
+func (r *endpointResult) Median() time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), r.Latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// ErrorRate returns the fraction of failed probes
+func (r *endpointResult) ErrorRate() float64 {
+	if r.Samples == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Samples)
+}
+
+// measureEndpoint runs probe samples times and records latencies and errors
+func measureEndpoint(url string, samples int, probe func() error) *endpointResult {
+	result := &endpointResult{URL: url, Samples: samples}
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		if err := probe(); err != nil {
+			result.Errors++
+			result.LastError = err
+			continue
+		}
+		result.Latencies = append(result.Latencies, time.Since(start))
+	}
+	return result
+}
+
+// rankEndpoints orders results by error rate, then median latency
+func rankEndpoints(results []*endpointResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].ErrorRate() != results[j].ErrorRate() {
+			return results[i].ErrorRate() < results[j].ErrorRate()
+		}
+		return results[i].Median() < results[j].Median()
+	})
+}
+
+func runBenchmarkEndpoints(cmd *cobra.Command, args []string) error {
+	if benchmarkSamples < 1 {
+		return fmt.Errorf("--samples must be at least 1")
+	}
+
+	groups := []struct {
+		title     string
+		field     string
+		listField string
+		urls      []string
+		probe     func(url string) func() error
+	}{
+		{
+			title: "Sui RPC", field: "sui_rpc_url", listField: "sui_rpc_urls",
+			urls: cfg.SuiRPCEndpoints(),
+			probe: func(url string) func() error {
+				client := sui.NewClient(url)
+				return func() error {
+					_, err := client.GetLatestCheckpoint()
+					return err
+				}
+			},
+		},
+		{
+			title: "Walrus aggregators", field: "walrus_aggregator_url", listField: "walrus_aggregator_urls",
+			urls: cfg.WalrusAggregatorEndpoints(),
+			probe: func(url string) func() error {
+				client := walrus.NewClient(url, "")
+				return func() error { return client.PingAggregator(endpointPingTimeout) }
+			},
+		},
+		{
+			title: "Walrus publishers", field: "walrus_publisher_url", listField: "walrus_publisher_urls",
+			urls: cfg.WalrusPublisherEndpoints(),
+			probe: func(url string) func() error {
+				client := walrus.NewClient("", url)
+				if err := setPublisherSigner(client); err != nil {
+					return func() error { return err }
+				}
+				return func() error { return client.PingPublisher(endpointPingTimeout) }
+			},
+		},
+	}
+
+	// result lists the ranked endpoints of each group by config field
+	result := map[string][]map[string]interface{}{}
+	setResult(result)
+	for _, g := range groups {
+		fmt.Fprintf(stdout, "%s (%d endpoint(s), %d probes each):\n", g.title, len(g.urls), benchmarkSamples)
+		if len(g.urls) == 0 {
+			fmt.Fprintln(stdout, "  (none configured)")
+			continue
+		}
+
+		var results []*endpointResult
+		for _, url := range g.urls {
+			results = append(results, measureEndpoint(url, benchmarkSamples, g.probe(url)))
+		}
+		rankEndpoints(results)
+
+		ranked := []map[string]interface{}{}
+		for i, r := range results {
+			entry := map[string]interface{}{"url": r.URL, "samples": r.Samples, "errors": r.Errors}
+			if len(r.Latencies) > 0 {
+				entry["median_ms"] = r.Median().Milliseconds()
+			}
+			if r.LastError != nil {
+				entry["last_error"] = r.LastError.Error()
+			}
+			ranked = append(ranked, entry)
+
+			median := "-"
+			if len(r.Latencies) > 0 {
+				median = r.Median().Round(time.Millisecond).String()
+			}
+			fmt.Fprintf(stdout, "  %d. %-55s p50 %-8s errors %.0f%%\n", i+1, r.URL, median, r.ErrorRate()*100)
+			if r.LastError != nil {
+				fmt.Fprintf(stdout, "     last error: %v\n", r.LastError)
+			}
+		}
+		result[g.listField] = ranked
+
+		if results[0].Errors == results[0].Samples {
+			statusf("  ⚠️  No %s endpoint answered; ranking not saved\n\n", g.title)
+			continue
+		}
+		if benchmarkNoSave || len(results) < 2 {
+			fmt.Fprintln(stdout)
+			continue
+		}
+
+		var rest []string
+		for _, r := range results[1:] {
+			// Region-tagged aggregators stay in walrus_aggregator_regions
+			if g.listField == "walrus_aggregator_urls" && cfg.WalrusAggregatorRegion(r.URL) != "" {
+				continue
+			}
+			rest = append(rest, r.URL)
+		}
+		restJSON, _ := json.Marshal(rest)
+		if err := saveConfigValue(g.field, results[0].URL); err != nil {
+			return err
+		}
+		if err := saveConfigValue(g.listField, string(restJSON)); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout)
+	}
+
+	return nil
+}
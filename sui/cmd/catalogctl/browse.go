@@ -0,0 +1,671 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// browse command (terminal UI)
+// ============================================================================
+
+var browseCmd = &cobra.Command{
+	Use:   "browse",
+	Short: "Browse a catalog in an interactive terminal UI",
+	Long: `Lists the entries of a catalog in a full-screen terminal UI with the
+cartridge of the selected entry in a side pane.
+
+Keys:
+  ↑/↓ j/k, PgUp/PgDn, Home/End   move
+  /                              search slugs and titles (Esc clears)
+  p                              filter by platform (cycles)
+  d                              download the game file to <slug>.zip
+  x                              remove the entry (asks first)
+  o                              open the cartridge in the explorer
+  r                              reload the catalog
+  q                              quit`,
+	RunE: runBrowse,
+}
+
+var browseCatalogID string
+
+func init() {
+	browseCmd.Flags().StringVar(&browseCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(browseCmd.Flags(), "catalog", formatCatalogRef)
+	rootCmd.AddCommand(browseCmd)
+}
+
+// cartridgeDetails is what the side pane shows about a cartridge
+type cartridgeDetails struct {
+	BlobID    string
+	SHA256    string
+	Publisher string
+	Created   string
+	Err       error
+}
+
+// browser is the state of the terminal UI
+type browser struct {
+	client      *sui.Client
+	catalogID   string
+	catalogName string
+
+	entries  []catalogEntry
+	shown    []int // indexes into entries after filtering
+	cursor   int   // index into shown
+	offset   int   // first row of shown on screen
+	search   string
+	platform int // -1 for all platforms, else a model.Platform
+	details  map[string]*cartridgeDetails
+
+	searching  bool
+	confirming bool
+	status     string
+
+	keys      chan byte
+	termState string
+}
+
+func runBrowse(cmd *cobra.Command, args []string) error {
+	catalogID := browseCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
+	if !stdinIsTerminal() {
+		return fmt.Errorf("browse needs a terminal; use list-catalog in scripts")
+	}
+
+	b := &browser{
+		client:    sui.NewClient(cfg.SuiRPCURL),
+		catalogID: catalogID,
+		platform:  -1,
+		details:   make(map[string]*cartridgeDetails),
+		keys:      make(chan byte, 64),
+	}
+	fmt.Fprintln(stdout, "Loading catalog...")
+	if err := b.load(); err != nil {
+		return err
+	}
+
+	go func() {
+		in := bufio.NewReader(os.Stdin)
+		for {
+			c, err := in.ReadByte()
+			if err != nil {
+				close(b.keys)
+				return
+			}
+			b.keys <- c
+		}
+	}()
+
+	if err := b.enterScreen(); err != nil {
+		return err
+	}
+	defer b.leaveScreen()
+	return b.run()
+}
+
+// load (re)reads the catalog entries
+func (b *browser) load() error {
+	resp, err := b.client.GetObject(b.catalogID)
+	if err != nil {
+		return fmt.Errorf("failed to get catalog: %w", err)
+	}
+	if resp.Data == nil {
+		return fmt.Errorf("catalog not found")
+	}
+	b.catalogName, _ = sui.ParseCatalog(resp.Data)["name"].(string)
+
+	entries, err := fetchCatalogEntries(b.client, b.catalogID)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Slug < entries[j].Slug })
+	b.entries = entries
+	b.filter()
+	return nil
+}
+
+// filter applies the search and platform filter, keeping the selected entry
+// selected if it is still shown
+func (b *browser) filter() {
+	selected := b.selected()
+	query := strings.ToLower(b.search)
+	b.shown = b.shown[:0]
+	b.cursor = 0
+	for i, e := range b.entries {
+		if b.platform >= 0 && int(e.Platform) != b.platform {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(e.Slug), query) && !strings.Contains(strings.ToLower(e.Title), query) {
+			continue
+		}
+		if selected != nil && e.Slug == selected.Slug {
+			b.cursor = len(b.shown)
+		}
+		b.shown = append(b.shown, i)
+	}
+}
+
+func (b *browser) selected() *catalogEntry {
+	if b.cursor < 0 || b.cursor >= len(b.shown) {
+		return nil
+	}
+	return &b.entries[b.shown[b.cursor]]
+}
+
+// nextPlatform cycles the platform filter through the platforms in the catalog
+func (b *browser) nextPlatform() {
+	var platforms []int
+	seen := map[int]bool{}
+	for _, e := range b.entries {
+		if !seen[int(e.Platform)] {
+			seen[int(e.Platform)] = true
+			platforms = append(platforms, int(e.Platform))
+		}
+	}
+	sort.Ints(platforms)
+	next := -1
+	for _, p := range platforms {
+		if p > b.platform {
+			next = p
+			break
+		}
+	}
+	b.platform = next
+	b.filter()
+}
+
+// run handles keys until the user quits
+func (b *browser) run() error {
+	for {
+		b.draw()
+		b.loadDetails()
+
+		key, ok := b.readKey()
+		if !ok {
+			return nil
+		}
+		b.status = ""
+
+		if b.searching {
+			switch key {
+			case "enter":
+				b.searching = false
+			case "esc":
+				b.searching = false
+				b.search = ""
+			case "backspace":
+				if b.search != "" {
+					_, size := utf8.DecodeLastRuneInString(b.search)
+					b.search = b.search[:len(b.search)-size]
+				}
+			default:
+				if utf8.RuneCountInString(key) == 1 && key[0] >= ' ' {
+					b.search += key
+				}
+			}
+			b.filter()
+			continue
+		}
+
+		if b.confirming {
+			b.confirming = false
+			if key == "y" || key == "Y" {
+				b.remove()
+			} else {
+				b.status = "Not removed."
+			}
+			continue
+		}
+
+		switch key {
+		case "q", "ctrl-c":
+			return nil
+		case "up", "k":
+			b.move(-1)
+		case "down", "j":
+			b.move(1)
+		case "pgup":
+			b.move(-b.listHeight())
+		case "pgdown":
+			b.move(b.listHeight())
+		case "home", "g":
+			b.move(-len(b.shown))
+		case "end", "G":
+			b.move(len(b.shown))
+		case "/":
+			b.searching = true
+		case "esc":
+			b.search = ""
+			b.platform = -1
+			b.filter()
+		case "p":
+			b.nextPlatform()
+		case "r":
+			b.status = "Reloading..."
+			b.draw()
+			if err := b.load(); err != nil {
+				b.status = "✗ " + err.Error()
+			} else {
+				b.details = make(map[string]*cartridgeDetails)
+				b.status = fmt.Sprintf("✓ Reloaded %d entries", len(b.entries))
+			}
+		case "d":
+			b.download()
+		case "x":
+			if e := b.selected(); e != nil {
+				b.confirming = true
+				b.status = fmt.Sprintf("Remove %s from the catalog? [y/N]", e.Slug)
+			}
+		case "o":
+			b.openExplorer()
+		}
+	}
+}
+
+func (b *browser) move(delta int) {
+	b.cursor += delta
+	if b.cursor >= len(b.shown) {
+		b.cursor = len(b.shown) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+}
+
+// readKey returns the next key: a character, or a name such as "up",
+// "enter" or "esc". A lone Esc is told apart from the start of an escape
+// sequence by the pause after it.
+func (b *browser) readKey() (string, bool) {
+	c, ok := <-b.keys
+	if !ok {
+		return "", false
+	}
+	switch c {
+	case 3:
+		return "ctrl-c", true
+	case '\r', '\n':
+		return "enter", true
+	case 127, 8:
+		return "backspace", true
+	case 27:
+	default:
+		if c < utf8.RuneSelf {
+			return string(c), true
+		}
+		// Collect the rest of a UTF-8 character
+		buf := []byte{c}
+		for !utf8.FullRune(buf) {
+			next, ok := <-b.keys
+			if !ok {
+				break
+			}
+			buf = append(buf, next)
+		}
+		return string(buf), true
+	}
+
+	var seq []byte
+	for {
+		select {
+		case next, ok := <-b.keys:
+			if !ok {
+				return "esc", true
+			}
+			seq = append(seq, next)
+			// Sequences end with a letter or ~ after "[" or "O"
+			if len(seq) > 1 && (next == '~' || (next >= 'A' && next <= 'Z') || (next >= 'a' && next <= 'z')) {
+				return escapeKey(string(seq)), true
+			}
+		case <-time.After(50 * time.Millisecond):
+			return "esc", true
+		}
+	}
+}
+
+func escapeKey(seq string) string {
+	switch seq {
+	case "[A", "OA":
+		return "up"
+	case "[B", "OB":
+		return "down"
+	case "[5~":
+		return "pgup"
+	case "[6~":
+		return "pgdown"
+	case "[H", "OH", "[1~", "[7~":
+		return "home"
+	case "[F", "OF", "[4~", "[8~":
+		return "end"
+	}
+	return ""
+}
+
+// ----------------------------------------------------------------------------
+// Actions
+// ----------------------------------------------------------------------------
+
+// loadDetails fetches the cartridge of the selected entry for the side pane
+func (b *browser) loadDetails() {
+	e := b.selected()
+	if e == nil || e.CartridgeID == "" || b.details[e.CartridgeID] != nil {
+		return
+	}
+	d := &cartridgeDetails{}
+	b.details[e.CartridgeID] = d
+	resp, err := b.client.GetObject(e.CartridgeID)
+	switch {
+	case err != nil:
+		d.Err = err
+	case resp.Data == nil:
+		d.Err = fmt.Errorf("cartridge not found")
+	default:
+		fields := sui.ParseCatalog(resp.Data)
+		d.BlobID = sui.BytesArrayToHex(fields["blob_id"])
+		d.SHA256 = sui.BytesArrayToHex(fields["sha256"])
+		d.Publisher, _ = fields["publisher"].(string)
+		if ms := parseU64(fields["created_at_ms"]); ms > 0 {
+			d.Created = time.UnixMilli(int64(ms)).UTC().Format("2006-01-02 15:04 MST")
+		}
+	}
+	b.draw()
+}
+
+// suspend leaves the UI for an action that prints, then waits for a key
+func (b *browser) suspend(action func() error) {
+	b.leaveScreen()
+	if err := action(); err != nil {
+		fmt.Fprintf(stdout, "✗ %v\n", err)
+	}
+	fmt.Fprint(stdout, "\nPress any key to return...")
+	stty("raw", "-echo")
+	b.readKey()
+	if err := b.enterScreen(); err != nil {
+		b.status = "✗ " + err.Error()
+	}
+}
+
+func (b *browser) download() {
+	e := b.selected()
+	if e == nil {
+		return
+	}
+	output := e.Slug + ".zip"
+	b.suspend(func() error {
+		fmt.Fprintf(stdout, "Downloading %s (cartridge %s)...\n", e.Slug, e.CartridgeID)
+		data, err := fetchGameFile(b.client, e.CartridgeID, "", 0)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(output, data, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		hash := sha256.Sum256(data)
+		fmt.Fprintf(stdout, "✓ Downloaded %d bytes to %s\n", len(data), output)
+		fmt.Fprintf(stdout, "  SHA256: %s (verified)\n", hex.EncodeToString(hash[:]))
+		b.status = fmt.Sprintf("✓ Downloaded %s", output)
+		return nil
+	})
+}
+
+func (b *browser) remove() {
+	e := b.selected()
+	if e == nil {
+		return
+	}
+	slug := e.Slug
+	b.suspend(func() error {
+		capID, err := resolveCuratorCap(b.catalogID, "")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Removing entry '%s' from catalog %s...\n", slug, b.catalogID)
+		digest, err := removeCatalogEntry(b.catalogID, capID, slug)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "✓ Entry removed (transaction %s)\n", digest)
+		printExplorerLink("  ", config.LinkTx, digest)
+
+		for i := range b.entries {
+			if b.entries[i].Slug == slug {
+				b.entries = append(b.entries[:i], b.entries[i+1:]...)
+				break
+			}
+		}
+		b.filter()
+		b.status = fmt.Sprintf("✓ Removed %s", slug)
+		return nil
+	})
+}
+
+func (b *browser) openExplorer() {
+	e := b.selected()
+	if e == nil {
+		return
+	}
+	url := explorerURL(config.LinkObject, e.CartridgeID)
+	if url == "" {
+		b.status = "No explorer for this network"
+		return
+	}
+	if err := openURL(url); err != nil {
+		b.status = url
+		return
+	}
+	b.status = "Opened " + url
+}
+
+// openURL opens url in the system's default browser
+func openURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+// ----------------------------------------------------------------------------
+// Drawing
+// ----------------------------------------------------------------------------
+
+// enterScreen switches to the alternate screen with the terminal in raw
+// mode, remembering the mode to restore the first time
+func (b *browser) enterScreen() error {
+	if b.termState == "" {
+		cmd := exec.Command("stty", "-g")
+		cmd.Stdin = os.Stdin
+		state, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("failed to read terminal mode: %w", err)
+		}
+		b.termState = strings.TrimSpace(string(state))
+	}
+	if err := stty("raw", "-echo"); err != nil {
+		return fmt.Errorf("failed to set terminal mode: %w", err)
+	}
+	fmt.Fprint(stdout, "\x1b[?1049h\x1b[?25l")
+	return nil
+}
+
+// leaveScreen restores the terminal
+func (b *browser) leaveScreen() {
+	fmt.Fprint(stdout, "\x1b[?25h\x1b[?1049l")
+	if b.termState != "" {
+		stty(b.termState)
+	}
+}
+
+// termSize returns the terminal's rows and columns
+func termSize() (int, int) {
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	if err == nil {
+		if f := strings.Fields(string(out)); len(f) == 2 {
+			rows, err1 := strconv.Atoi(f[0])
+			cols, err2 := strconv.Atoi(f[1])
+			if err1 == nil && err2 == nil && rows > 0 && cols > 0 {
+				return rows, cols
+			}
+		}
+	}
+	return 24, 80
+}
+
+// listHeight is the number of entry rows on screen
+func (b *browser) listHeight() int {
+	rows, _ := termSize()
+	return max(rows-5, 1)
+}
+
+func (b *browser) draw() {
+	rows, cols := termSize()
+	height := max(rows-5, 1)
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+height {
+		b.offset = b.cursor - height + 1
+	}
+
+	listWidth := cols
+	if cols >= 90 {
+		listWidth = cols * 11 / 20
+	}
+	paneWidth := cols - listWidth - 3
+
+	var w strings.Builder
+	w.WriteString("\x1b[H\x1b[2J")
+	line := func(row int, s string) {
+		fmt.Fprintf(&w, "\x1b[%d;1H%s", row, s)
+	}
+
+	platform := "all"
+	if b.platform >= 0 {
+		platform = model.Platform(b.platform).String()
+	}
+	header := fmt.Sprintf(" %s  %d/%d entries  platform: %s", b.catalogName, len(b.shown), len(b.entries), platform)
+	if b.search != "" || b.searching {
+		header += fmt.Sprintf("  search: %s", b.search)
+	}
+	line(1, "\x1b[7m"+fit(header, cols)+"\x1b[0m")
+	line(2, "\x1b[1m"+fit(fmt.Sprintf(" %-20s %-8s %-4s %s", "SLUG", "PLATFORM", "VER", "TITLE"), listWidth)+"\x1b[0m")
+
+	for i := 0; i < height && b.offset+i < len(b.shown); i++ {
+		e := b.entries[b.shown[b.offset+i]]
+		row := fit(fmt.Sprintf(" %-20s %-8s v%-3d %s", fit(e.Slug, 20), e.Platform.String(), e.Version, e.Title), listWidth)
+		if b.offset+i == b.cursor {
+			row = "\x1b[7m" + row + "\x1b[0m"
+		}
+		line(3+i, row)
+	}
+	if len(b.shown) == 0 {
+		line(3, " No entries match.")
+	}
+
+	if paneWidth > 20 {
+		for i, text := range b.paneLines() {
+			if i >= height+1 {
+				break
+			}
+			text = fit(text, paneWidth)
+			if i == 0 {
+				text = "\x1b[1m" + text + "\x1b[0m"
+			}
+			fmt.Fprintf(&w, "\x1b[%d;%dH│ %s", 2+i, listWidth+1, text)
+		}
+	}
+
+	switch {
+	case b.searching:
+		line(rows-1, fit(" Search: "+b.search+"▏  (Enter to keep, Esc to clear)", cols))
+	case b.status != "":
+		line(rows-1, fit(" "+b.status, cols))
+	}
+	line(rows, "\x1b[2m"+fit(" ↑↓ move  / search  p platform  d download  x remove  o explorer  r reload  q quit", cols)+"\x1b[0m")
+	io.WriteString(stdout, w.String())
+}
+
+// paneLines describes the selected entry and its cartridge; the first line
+// is the title
+func (b *browser) paneLines() []string {
+	e := b.selected()
+	if e == nil {
+		return nil
+	}
+	lines := []string{
+		e.Title,
+		"",
+		"Slug:       " + e.Slug,
+		"Channel:    " + e.Channel,
+		"Platform:   " + e.Platform.String(),
+		fmt.Sprintf("Version:    %d", e.Version),
+		"Emulator:   " + e.EmulatorCore,
+		fmt.Sprintf("Size:       %d bytes", e.SizeBytes),
+		"Key type:   " + e.KeyType,
+		"",
+		"Cartridge:  " + e.CartridgeID,
+	}
+	d := b.details[e.CartridgeID]
+	switch {
+	case d == nil:
+		lines = append(lines, "Loading...")
+	case d.Err != nil:
+		lines = append(lines, "✗ "+d.Err.Error())
+	default:
+		lines = append(lines,
+			"Blob ID:    "+d.BlobID,
+			"SHA256:     "+d.SHA256,
+			"Publisher:  "+d.Publisher,
+			"Created:    "+d.Created,
+		)
+	}
+	if e.CoverBlobID != "" {
+		lines = append(lines, "Cover blob: "+walrusBlobID(e.CoverBlobID))
+	}
+	return lines
+}
+
+// fit cuts or pads s to width columns (counting runes)
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	n := utf8.RuneCountInString(s)
+	if n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// version command (build info)
+// ============================================================================
+
+// buildInfo identifies the exact build of catalogctl: the ldflags version and
+// build time plus what the Go toolchain embeds (VCS revision, Go version,
+// build settings and the checksums of every module linked in)
+type buildInfo struct {
+	Version      string            `json:"version"`
+	Built        string            `json:"built"`
+	GoVersion    string            `json:"go_version"`
+	Platform     string            `json:"platform"`
+	Module       string            `json:"module,omitempty"`
+	Revision     string            `json:"vcs_revision,omitempty"`
+	RevisionTime string            `json:"vcs_time,omitempty"`
+	Modified     bool              `json:"vcs_modified"`
+	Settings     map[string]string `json:"settings,omitempty"`
+	Deps         []buildDep        `json:"deps,omitempty"`
+}
+
+// buildDep is a module linked into the binary
+type buildDep struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+	// Replace is the module it was replaced with (path@version or a local path)
+	Replace string `json:"replace,omitempty"`
+}
+
+var versionJSON bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version information",
+	Long: `Print the version and build time of catalogctl.
+
+-v/--verbose adds what the Go toolchain embedded at build time: the VCS revision
+and whether the tree had local changes, the Go version, the build settings
+and every dependency with its go.sum checksum. --json prints all of it as JSON,
+so publisher machines can be audited for the exact build they run.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := readBuildInfo()
+		setResult(info)
+		if versionJSON {
+			data, _ := json.MarshalIndent(info, "", "  ")
+			fmt.Fprintln(stdout, string(data))
+			return nil
+		}
+
+		fmt.Fprintf(stdout, "catalogctl %s\n", info.Version)
+		fmt.Fprintf(stdout, "Built: %s\n", info.Built)
+		if info.Revision != "" {
+			fmt.Fprintf(stdout, "Commit: %s%s\n", info.Revision, modifiedSuffix(info.Modified))
+		}
+		if !verbose(levelVerbose) {
+			return nil
+		}
+		if info.RevisionTime != "" {
+			fmt.Fprintf(stdout, "Commit time: %s\n", info.RevisionTime)
+		}
+		fmt.Fprintf(stdout, "Go: %s %s\n", info.GoVersion, info.Platform)
+		if info.Module != "" {
+			fmt.Fprintf(stdout, "Module: %s\n", info.Module)
+		}
+		if len(info.Settings) > 0 {
+			fmt.Fprintln(stdout, "Build settings:")
+			keys := make([]string, 0, len(info.Settings))
+			for key := range info.Settings {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				fmt.Fprintf(stdout, "  %s=%s\n", key, info.Settings[key])
+			}
+		}
+		if len(info.Deps) > 0 {
+			fmt.Fprintln(stdout, "Dependencies:")
+			for _, dep := range info.Deps {
+				line := fmt.Sprintf("  %s %s", dep.Path, dep.Version)
+				if dep.Replace != "" {
+					line += " => " + dep.Replace
+				}
+				if dep.Sum != "" {
+					line += " " + dep.Sum
+				}
+				fmt.Fprintln(stdout, line)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Print the full build info as JSON")
+	rootCmd.AddCommand(versionCmd)
+	reportsResult(versionCmd)
+}
+
+// readBuildInfo combines the ldflags variables with the build info embedded
+// by the Go toolchain. Binaries built without VCS info (e.g. -buildvcs=false
+// or from a source archive) have no revision.
+func readBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   Version,
+		Built:     BuildTime,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = bi.GoVersion
+	info.Module = bi.Main.Path
+	// go install module@version sets the module version instead of ldflags
+	if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+
+	info.Settings = make(map[string]string)
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.RevisionTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		default:
+			info.Settings[setting.Key] = setting.Value
+		}
+	}
+
+	for _, mod := range bi.Deps {
+		dep := buildDep{Path: mod.Path, Version: mod.Version, Sum: mod.Sum}
+		if r := mod.Replace; r != nil {
+			dep.Replace = r.Path
+			if r.Version != "" {
+				dep.Replace += "@" + r.Version
+			}
+			dep.Sum = r.Sum
+		}
+		info.Deps = append(info.Deps, dep)
+	}
+	return info
+}
+
+// modifiedSuffix marks builds made from a tree with uncommitted changes,
+// which can't be reproduced from the revision alone
+func modifiedSuffix(modified bool) string {
+	if modified {
+		return " (modified)"
+	}
+	return ""
+}
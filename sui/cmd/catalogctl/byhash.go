@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/identity"
+	"github.com/retro-crypto/sui/internal/nimiq"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+)
+
+// ============================================================================
+// serve: digest-addressed retrieval
+// ============================================================================
+
+// byHashServer serves game files by their SHA256, from whichever chain holds
+// them. Copies are looked up in the games registry (see `games scan`), which
+// is re-read on every request so a running server picks up new scans.
+type byHashServer struct {
+	nimiqRPCURL string
+}
+
+// gameSource is one place a file can be read from
+type gameSource struct {
+	name string
+	read func() ([]byte, error)
+}
+
+func (s *byHashServer) mount(mux *http.ServeMux) {
+	mux.HandleFunc("/by-hash/", gatewayTokens.require(s.handleByHash))
+}
+
+func (s *byHashServer) operations() []apiOperation {
+	return []apiOperation{{
+		Method:  http.MethodGet,
+		Path:    "/by-hash/{sha256}",
+		Summary: "Download a game file by its SHA256 from Walrus or a Nimiq cartridge",
+		Tag:     "games",
+		Auth:    len(gatewayTokens) > 0,
+		PathParams: []apiParam{
+			{Name: "sha256", Description: "SHA256 of the game file (64 hex digits)"},
+		},
+		Query: []apiParam{
+			{Name: "source", Description: "Only read from this source: walrus or nimiq"},
+		},
+		Binary: true,
+	}}
+}
+
+func (s *byHashServer) handleByHash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	sha256Hex := strings.ToLower(strings.TrimPrefix(r.URL.Path, "/by-hash/"))
+	if err := validate.SHA256Hex(sha256Hex); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	etag := `"` + sha256Hex + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	only := r.URL.Query().Get("source")
+	if only != "" && only != "walrus" && only != "nimiq" {
+		writeError(w, http.StatusBadRequest, "source must be walrus or nimiq")
+		return
+	}
+
+	registry, err := identity.Load(gamesRegistryPath())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	game := registry.Games[sha256Hex]
+	if game == nil {
+		writeError(w, http.StatusNotFound, "unknown game "+sha256Hex)
+		return
+	}
+	sources := s.sources(game, only)
+	if len(sources) == 0 {
+		writeError(w, http.StatusNotFound, "no readable copy of "+sha256Hex)
+		return
+	}
+
+	var failures []string
+	for _, src := range sources {
+		data, err := src.read()
+		if err == nil && hex.EncodeToString(sha256Sum(data)) != sha256Hex {
+			err = fmt.Errorf("SHA256 mismatch")
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", src.name, err))
+			continue
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("X-Game-Source", src.name)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+		return
+	}
+	writeError(w, http.StatusBadGateway, "no source served the file: "+strings.Join(failures, "; "))
+}
+
+// sources lists where a game can be read from, healthiest first: Walrus
+// unless the best aggregator failed its last probe, then Nimiq cartridges
+func (s *byHashServer) sources(game *identity.Game, only string) []gameSource {
+	var walrusSources, nimiqSources []gameSource
+	if only != "nimiq" {
+		for _, c := range game.Sui {
+			if c.Network != "" && !strings.EqualFold(c.Network, cfg.SuiNetwork) {
+				continue
+			}
+			cartridgeID := c.CartridgeID
+			walrusSources = append(walrusSources, gameSource{
+				name: "walrus:" + cartridgeID,
+				read: func() ([]byte, error) {
+					return fetchGameFile(sui.NewClient(cfg.SuiRPCURL), cartridgeID, "", 0)
+				},
+			})
+		}
+	}
+	if only != "walrus" && s.nimiqRPCURL != "" {
+		client := nimiq.NewClient(s.nimiqRPCURL)
+		for _, c := range game.Nimiq {
+			c := c
+			nimiqSources = append(nimiqSources, gameSource{
+				name: "nimiq:" + nimiq.NormalizeAddress(c.CartridgeAddr),
+				read: func() ([]byte, error) {
+					data, _, err := client.ReadCartridge(c.CartridgeAddr, c.Publisher)
+					return data, err
+				},
+			})
+		}
+	}
+	if len(walrusSources) > 0 && !aggregatorMirrors().Healthy() {
+		return append(nimiqSources, walrusSources...)
+	}
+	return append(walrusSources, nimiqSources...)
+}
+
+func sha256Sum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/sui"
+)
+
+// ============================================================================
+// Object cache
+// ============================================================================
+
+var noCache bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Read every Sui object from the RPC, bypassing the object cache")
+}
+
+// setupObjectCache makes Sui object reads go through the object cache,
+// kept per network in the config directory. The memory backend's chain
+// changes between runs without catalogctl seeing it, so its cache lives in
+// memory only.
+func setupObjectCache() error {
+	if noCache {
+		sui.SetObjectCache(nil)
+		return nil
+	}
+	ttl, err := cfg.ObjectCacheTTLDuration()
+	if err != nil {
+		return err
+	}
+	dir := ""
+	if memoryChain == nil {
+		dir = filepath.Join(config.GetConfigDir(), "cache", objectCacheNamespace())
+	}
+	cache, err := sui.NewObjectCache(dir, ttl)
+	if err != nil {
+		// The cache is an optimization; fall back to memory only
+		warnf("%v; caching in memory only", err)
+		cache, _ = sui.NewObjectCache("", ttl)
+	}
+	sui.SetObjectCache(cache)
+	return nil
+}
+
+// objectCacheNamespace names the cache directory of the configured RPC
+// endpoint, so objects of different networks never mix
+func objectCacheNamespace() string {
+	sum := sha256.Sum256([]byte(cfg.SuiRPCURL))
+	return cfg.SuiNetwork + "-" + hex.EncodeToString(sum[:6])
+}
+
+// suiWriteCommand reports whether sui CLI arguments submit a transaction
+func suiWriteCommand(args []string) bool {
+	if len(args) < 2 || args[0] != "client" {
+		return false
+	}
+	switch args[1] {
+	case "call", "ptb", "transfer", "transfer-sui", "pay", "pay-sui", "pay-all-sui",
+		"publish", "upgrade", "merge-coin", "split-coin", "execute-signed-tx":
+		return true
+	}
+	return false
+}
+
+// invalidateObjectCache forgets the cached versions of the objects a
+// transaction changed. A failed command may still have executed, and output
+// without object changes can't say what it touched, so both forget every
+// cached version.
+func invalidateObjectCache(output string, err error) {
+	if err != nil {
+		output = ""
+	}
+	sui.InvalidateTransaction([]byte(output))
+}
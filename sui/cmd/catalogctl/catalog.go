@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/retro-crypto/sui/internal/chain"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// catalog command group (any chain)
+// ============================================================================
+
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "List and publish catalog entries on Sui or Nimiq",
+	Long: `The same catalog operations on every supported chain, selected with --chain:
+
+  sui    catalogs are Sui objects (--catalog ID or alias, default catalog_id);
+         entries are keyed by slug (slug@beta on the beta channel)
+  nimiq  catalogs are Nimiq addresses (--catalog-addr NQ... or alias); entries
+         are keyed by app ID. Writes go through nimiq-uploader, which holds the
+         node credentials (--nimiq-uploader, --nimiq-arg)
+
+publish adds a new entry, or a new version if the key already exists.
+remove takes the entry out of the catalog (on Nimiq, the app is retired).
+
+Example:
+  catalogctl catalog list
+  catalogctl catalog list --chain nimiq --catalog-addr main
+  catalogctl catalog publish --file doom.zip --key doom --title DOOM --version 2
+  catalogctl catalog publish --chain nimiq --catalog-addr main --file doom.zip --title DOOM --version 1.0.0
+  catalogctl catalog remove --chain nimiq --catalog-addr main 42`,
+}
+
+var catalogListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the current entries of a catalog",
+	RunE:  runCatalogList,
+}
+
+var catalogGetCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print one entry as JSON",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCatalogGet,
+}
+
+var catalogPublishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Publish a game file as a new entry or a new version of an entry",
+	RunE:  runCatalogPublish,
+}
+
+var catalogRemoveCmd = &cobra.Command{
+	Use:   "remove KEY",
+	Short: "Remove an entry (retire the app on Nimiq)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCatalogRemove,
+}
+
+var catalogCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an empty catalog",
+	RunE:  runCatalogCreate,
+}
+
+var (
+	catalogChain   string
+	catalogRef     string
+	catalogAddr    string
+	catalogOptions chainOptions
+	catalogJSON    bool
+
+	catalogFile     string
+	catalogKey      string
+	catalogTitle    string
+	catalogPlatform string
+	catalogVersion  string
+	catalogChannel  string
+	catalogEpochs   int
+
+	catalogName        string
+	catalogDescription string
+)
+
+func init() {
+	pf := catalogCmd.PersistentFlags()
+	pf.StringVar(&catalogChain, "chain", chainSui, "Chain the catalog lives on: sui or nimiq")
+	pf.StringVar(&catalogRef, "catalog", "", "Sui catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(pf, "catalog", formatCatalogRef)
+	pf.StringVar(&catalogAddr, "catalog-addr", "", "Nimiq catalog address or alias (NQ..., 'main', 'test')")
+	pf.StringVar(&catalogOptions.CapID, "cap", "", "Sui CuratorCap object ID (auto-selected if the signer isn't the catalog owner)")
+	pf.StringVar(&catalogOptions.NimiqRPCURL, "nimiq-rpc-url", "", "Nimiq RPC URL (default: $NIMIQ_RPC_URL or http://127.0.0.1:8648)")
+	pf.StringVar(&catalogOptions.Publisher, "publisher", "", "Only list Nimiq entries sent by this address")
+	pf.StringVar(&catalogOptions.Uploader, "nimiq-uploader", "nimiq-uploader", "nimiq-uploader binary used for Nimiq writes")
+	pf.StringArrayVar(&catalogOptions.UploaderArgs, "nimiq-arg", nil, "Extra argument for nimiq-uploader (repeatable, e.g. --nimiq-arg=--sender=NQ...)")
+
+	catalogListCmd.Flags().BoolVar(&catalogJSON, "json", false, "Print the catalog and entries as JSON")
+
+	catalogPublishCmd.Flags().StringVar(&catalogFile, "file", "", "Game file to publish (required)")
+	catalogPublishCmd.Flags().StringVar(&catalogKey, "key", "", "Entry key: slug on Sui (required), app ID on Nimiq (empty for a new app)")
+	catalogPublishCmd.Flags().StringVar(&catalogTitle, "title", "", "Game title (required; at most 16 bytes on Nimiq)")
+	catalogPublishCmd.Flags().StringVar(&catalogPlatform, "platform", "dos", "Platform: dos, gb, gbc, nes or snes")
+	catalogPublishCmd.Flags().StringVar(&catalogVersion, "version", "", "Version: a number on Sui, a semver on Nimiq (default: 1 / 1.0.0)")
+	catalogPublishCmd.Flags().StringVar(&catalogChannel, "channel", "", "Release channel: stable or beta (default: stable)")
+	catalogPublishCmd.Flags().IntVar(&catalogEpochs, "epochs", 5, "Number of storage epochs (Sui)")
+	catalogPublishCmd.MarkFlagRequired("file")
+	catalogPublishCmd.MarkFlagRequired("title")
+
+	catalogCreateCmd.Flags().StringVar(&catalogName, "name", "", "Catalog name (required)")
+	catalogCreateCmd.Flags().StringVar(&catalogDescription, "description", "", "Catalog description")
+	catalogCreateCmd.MarkFlagRequired("name")
+
+	catalogCmd.AddCommand(catalogListCmd)
+	reportsResult(catalogListCmd)
+	catalogCmd.AddCommand(catalogGetCmd)
+	reportsResult(catalogGetCmd)
+	catalogCmd.AddCommand(catalogPublishCmd)
+	reportsResult(catalogPublishCmd)
+	catalogCmd.AddCommand(catalogRemoveCmd)
+	reportsResult(catalogRemoveCmd)
+	catalogCmd.AddCommand(catalogCreateCmd)
+	reportsResult(catalogCreateCmd)
+	rootCmd.AddCommand(catalogCmd)
+}
+
+// catalogTarget returns the selected chain backend and catalog
+func catalogTarget() (chain.Backend, string, error) {
+	backend, err := chainBackend(catalogChain, catalogOptions)
+	if err != nil {
+		return nil, "", err
+	}
+	if backend.Name() == chainNimiq {
+		if catalogAddr == "" {
+			return nil, "", fmt.Errorf("--catalog-addr is required for Nimiq catalogs")
+		}
+		return backend, catalogAddr, nil
+	}
+	id := catalogRef
+	if id == "" {
+		id = cfg.CatalogID
+	}
+	if id == "" {
+		return nil, "", fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	return backend, id, nil
+}
+
+func runCatalogList(cmd *cobra.Command, args []string) error {
+	backend, id, err := catalogTarget()
+	if err != nil {
+		return err
+	}
+	catalog, err := backend.GetCatalog(id)
+	if err != nil {
+		return err
+	}
+	entries, err := backend.List(id)
+	if err != nil {
+		return err
+	}
+
+	listing := struct {
+		*chain.Catalog
+		Items []chain.Entry `json:"items"`
+	}{catalog, entries}
+	setResult(listing)
+	if catalogJSON {
+		data, _ := json.MarshalIndent(listing, "", "  ")
+		fmt.Fprintln(stdout, string(data))
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "Catalog: %s (%s)\n", catalog.ID, catalog.Chain)
+	if catalog.Name != "" {
+		fmt.Fprintf(stdout, "Name: %s\n", catalog.Name)
+	}
+	if catalog.Owner != "" {
+		fmt.Fprintf(stdout, "Owner: %s\n", catalog.Owner)
+	}
+	fmt.Fprintf(stdout, "Entries: %d\n\n", len(entries))
+	if len(entries) == 0 {
+		fmt.Fprintln(stdout, "No games in catalog.")
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "%-20s %-30s %-8s %-8s %-7s %s\n", "KEY", "TITLE", "PLATFORM", "VERSION", "CHANNEL", "CARTRIDGE")
+	fmt.Fprintln(stdout, "------------------------------------------------------------------------------------------------")
+	for _, e := range entries {
+		fmt.Fprintf(stdout, "%-20s %-30s %-8s %-8s %-7s %s\n",
+			truncate(e.Key, 20),
+			truncate(e.Title, 30),
+			e.Platform.String(),
+			e.Version,
+			e.Channel,
+			truncate(e.Cartridge, 24),
+		)
+	}
+	return nil
+}
+
+func runCatalogGet(cmd *cobra.Command, args []string) error {
+	backend, id, err := catalogTarget()
+	if err != nil {
+		return err
+	}
+	entry, err := backend.GetEntry(id, args[0])
+	if err != nil {
+		return err
+	}
+	setResult(entry)
+	data, _ := json.MarshalIndent(entry, "", "  ")
+	fmt.Fprintln(stdout, string(data))
+	return nil
+}
+
+func runCatalogPublish(cmd *cobra.Command, args []string) error {
+	backend, id, err := catalogTarget()
+	if err != nil {
+		return err
+	}
+	platform, err := model.ParsePlatform(catalogPlatform)
+	if err != nil {
+		return err
+	}
+	file, err := filepath.Abs(catalogFile)
+	if err != nil {
+		return fmt.Errorf("invalid file path: %w", err)
+	}
+	game := chain.Game{
+		File:     file,
+		Key:      catalogKey,
+		Title:    catalogTitle,
+		Platform: platform,
+		Version:  catalogVersion,
+		Channel:  catalogChannel,
+		Epochs:   catalogEpochs,
+	}
+
+	// An existing key gets a new version
+	update := false
+	if catalogKey != "" {
+		key := catalogKey
+		if catalogChannel != "" {
+			slug, _ := model.SplitChannelKey(catalogKey)
+			key = model.ChannelKey(slug, catalogChannel)
+		}
+		if _, err := backend.GetEntry(id, key); err == nil {
+			update = true
+		}
+	}
+
+	var entry *chain.Entry
+	if update {
+		statusf("Publishing a new version of %s on %s...\n", catalogKey, backend.Name())
+		entry, err = backend.UpdateEntry(id, game)
+	} else {
+		statusf("Publishing %s to %s...\n", catalogTitle, backend.Name())
+		entry, err = backend.AddEntry(id, game)
+	}
+	if err != nil {
+		return err
+	}
+	setResult(entry)
+
+	fmt.Fprintf(stdout, "\n✓ Published %q v%s", entry.Title, entry.Version)
+	if entry.Key != "" {
+		fmt.Fprintf(stdout, " as %s", entry.Key)
+	}
+	fmt.Fprintln(stdout)
+	if entry.Cartridge != "" {
+		fmt.Fprintf(stdout, "  Cartridge: %s\n", entry.Cartridge)
+	} else {
+		statusf("💡 The entry isn't visible yet; check it with: catalogctl catalog list --chain nimiq --catalog-addr '%s'\n", id)
+	}
+	return nil
+}
+
+func runCatalogRemove(cmd *cobra.Command, args []string) error {
+	backend, id, err := catalogTarget()
+	if err != nil {
+		return err
+	}
+	if err := backend.RemoveEntry(id, args[0]); err != nil {
+		return err
+	}
+	statusf("✓ Removed %s from %s catalog %s\n", args[0], backend.Name(), id)
+	setResult(map[string]string{"chain": backend.Name(), "catalog_id": id, "removed": args[0]})
+	return nil
+}
+
+func runCatalogCreate(cmd *cobra.Command, args []string) error {
+	backend, err := chainBackend(catalogChain, catalogOptions)
+	if err != nil {
+		return err
+	}
+	id, err := backend.CreateCatalog(catalogName, catalogDescription)
+	if err != nil {
+		return err
+	}
+	statusf("✓ Created %s catalog %s\n", backend.Name(), id)
+	setResult(map[string]string{"chain": backend.Name(), "catalog_id": id})
+	if backend.Name() == chainSui && cfg.CatalogID == "" {
+		statusf("\n💡 Save it as the default catalog with: catalogctl config set catalog_id %s\n", id)
+	}
+	return nil
+}
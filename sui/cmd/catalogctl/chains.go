@@ -0,0 +1,485 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/chain"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/nimiq"
+	"github.com/retro-crypto/sui/internal/plan"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+)
+
+// ============================================================================
+// Chain backends
+// ============================================================================
+
+// Chains catalogs can live on
+const (
+	chainSui   = "sui"
+	chainNimiq = "nimiq"
+)
+
+// chainOptions holds the settings a chain backend may need beyond the config
+type chainOptions struct {
+	// CapID is the CuratorCap Sui entries are added with ("" picks one)
+	CapID string
+	// NimiqRPCURL is the Nimiq node entries are read from and sent through
+	NimiqRPCURL string
+	// Publisher only lists Nimiq entries sent by this address
+	Publisher string
+	// Uploader is the nimiq-uploader binary and UploaderArgs extra
+	// arguments for it (credentials, fees)
+	Uploader     string
+	UploaderArgs []string
+}
+
+// chainBackend returns the backend of a chain
+func chainBackend(name string, opts chainOptions) (chain.Backend, error) {
+	switch strings.ToLower(name) {
+	case "", chainSui:
+		return &suiChain{client: sui.NewClient(cfg.SuiRPCURL), capID: opts.CapID}, nil
+	case chainNimiq:
+		rpcURL := defaultNimiqRPCURL(opts.NimiqRPCURL)
+		uploader := opts.Uploader
+		if uploader == "" {
+			uploader = "nimiq-uploader"
+		}
+		return &nimiqChain{
+			client:    nimiq.NewClient(rpcURL),
+			rpcURL:    rpcURL,
+			publisher: opts.Publisher,
+			uploader:  uploader,
+			args:      opts.UploaderArgs,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown chain %q (use %s or %s)", name, chainSui, chainNimiq)
+}
+
+// ----------------------------------------------------------------------------
+// Sui
+// ----------------------------------------------------------------------------
+
+// suiChain keeps catalogs as Sui objects and game files on the storage
+// backend, publishing through the publish-game plan
+type suiChain struct {
+	client *sui.Client
+	capID  string
+}
+
+func (s *suiChain) Name() string { return chainSui }
+
+func (s *suiChain) resolve(catalogID string) (string, error) {
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return "", err
+	}
+	if err := validate.ObjectID(catalogID); err != nil {
+		return "", fmt.Errorf("invalid catalog ID %s: %w", catalogID, err)
+	}
+	return catalogID, nil
+}
+
+func (s *suiChain) CreateCatalog(name, description string) (string, error) {
+	if cfg.PackageID == "" {
+		return "", fmt.Errorf("package_id is required in config file")
+	}
+	output, err := executeMoveCall(sui.CreateCatalog(cfg.PackageID, name, description))
+	if err != nil {
+		return "", fmt.Errorf("failed to create catalog: %w", err)
+	}
+	id := sui.CreatedObjectID(output, "::catalog::Catalog")
+	if id == "" {
+		return "", fmt.Errorf("no catalog in transaction %s", sui.TransactionDigest(output))
+	}
+	return id, nil
+}
+
+func (s *suiChain) GetCatalog(catalogID string) (*chain.Catalog, error) {
+	catalogID, err := s.resolve(catalogID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.GetObject(catalogID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("catalog %s not found", catalogID)
+	}
+	fields := sui.ParseCatalog(resp.Data)
+	c := &chain.Catalog{Chain: chainSui, ID: catalogID, Entries: int(parseU64(fields["count"]))}
+	c.Name, _ = fields["name"].(string)
+	c.Description, _ = fields["description"].(string)
+	c.Owner, _ = fields["owner"].(string)
+	return c, nil
+}
+
+func (s *suiChain) List(catalogID string) ([]chain.Entry, error) {
+	catalogID, err := s.resolve(catalogID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fetchCatalogEntries(s.client, catalogID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]chain.Entry, len(entries))
+	for i, e := range entries {
+		result[i] = chain.Entry{
+			Key:       e.Slug,
+			Title:     e.Title,
+			Platform:  e.Platform,
+			Version:   strconv.Itoa(int(e.Version)),
+			Channel:   e.Channel,
+			Cartridge: e.CartridgeID,
+			SizeBytes: e.SizeBytes,
+		}
+	}
+	return result, nil
+}
+
+func (s *suiChain) GetEntry(catalogID, key string) (*chain.Entry, error) {
+	entries, err := s.List(catalogID)
+	if err != nil {
+		return nil, err
+	}
+	if e := chain.Find(entries, key); e != nil {
+		return e, nil
+	}
+	return nil, fmt.Errorf("entry %s not found in catalog %s", key, catalogID)
+}
+
+func (s *suiChain) AddEntry(catalogID string, game chain.Game) (*chain.Entry, error) {
+	return s.publish(catalogID, game, false)
+}
+
+func (s *suiChain) UpdateEntry(catalogID string, game chain.Game) (*chain.Entry, error) {
+	return s.publish(catalogID, game, true)
+}
+
+// publish runs the publish-game plan for a game. An update creates the
+// cartridge the same way but points the existing entry at it with
+// update_entry instead of adding one.
+func (s *suiChain) publish(catalogID string, game chain.Game, update bool) (*chain.Entry, error) {
+	if cfg.PackageID == "" {
+		return nil, fmt.Errorf("package_id is required in config file")
+	}
+	if cfg.ApprovalRequired() {
+		return nil, fmt.Errorf("mainnet approvals are required; publish with publish-game --plan-out and execute-plan")
+	}
+	catalogID, err := s.resolve(catalogID)
+	if err != nil {
+		return nil, err
+	}
+	// The channel may be given as part of the key (slug@beta)
+	slug, channel := model.SplitChannelKey(game.Key)
+	if game.Channel != "" {
+		channel = game.Channel
+	}
+	channel, err = model.ParseChannel(channel)
+	if err != nil {
+		return nil, err
+	}
+	if slug == "" {
+		return nil, fmt.Errorf("a slug is required")
+	}
+	version := uint16(1)
+	if game.Version != "" {
+		v, err := strconv.ParseUint(game.Version, 10, 16)
+		if err != nil || v == 0 {
+			return nil, fmt.Errorf("invalid Sui version %q: expected a number from 1 to 65535", game.Version)
+		}
+		version = uint16(v)
+	}
+	key := model.ChannelKey(slug, channel)
+
+	cover := ""
+	if update {
+		entries, err := fetchCatalogEntries(s.client, catalogID)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, e := range entries {
+			if e.Slug == key {
+				found = true
+				if e.CoverBlobID != "" {
+					cover = "0x" + e.CoverBlobID
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("entry %s not found in catalog %s", key, catalogID)
+		}
+	}
+
+	sha256Hex, size, err := fileSHA256(game.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	capID := ""
+	if !update {
+		if capID, err = resolveCuratorCap(catalogID, s.capID); err != nil {
+			return nil, err
+		}
+	}
+	epochs := game.Epochs
+	if epochs == 0 {
+		epochs = 5
+	}
+	pl := buildPublishGamePlan(publishGameParams{
+		FilePath:  game.File,
+		Size:      size,
+		SHA256Hex: sha256Hex,
+		Slug:      slug,
+		Title:     game.Title,
+		Platform:  game.Platform,
+		Emulator:  model.EmulatorCoreForPlatform(game.Platform),
+		Version:   version,
+		Epochs:    epochs,
+		CatalogID: catalogID,
+		CapID:     capID,
+		Channel:   channel,
+	})
+	journal := fmt.Sprintf("publish-%s-v%d.journal.json", slug, version)
+	if update {
+		// The last operation adds the entry; updates point it elsewhere
+		pl.Operations = pl.Operations[:len(pl.Operations)-1]
+		pl.ComputeEstimate()
+		journal = fmt.Sprintf("update-%s-v%d.journal.json", slug, version)
+	}
+
+	prog, err := plan.LoadProgress(journal, pl)
+	if err != nil {
+		return nil, err
+	}
+	if err := executePlan(pl, prog, journal); err != nil {
+		return nil, err
+	}
+	cartridgeID := prog.Outputs["cartridge_id"]
+	if update {
+		cartridge, err := fetchCartridge(s.client, cartridgeID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := updateCatalogEntry(catalogID, key, cartridge, cover); err != nil {
+			return nil, err
+		}
+	}
+
+	return &chain.Entry{
+		Key:       key,
+		Title:     game.Title,
+		Platform:  game.Platform,
+		Version:   strconv.Itoa(int(version)),
+		Channel:   channel,
+		Cartridge: cartridgeID,
+		SizeBytes: uint64(size),
+	}, nil
+}
+
+func (s *suiChain) RemoveEntry(catalogID, key string) error {
+	catalogID, err := s.resolve(catalogID)
+	if err != nil {
+		return err
+	}
+	capID, err := resolveCuratorCap(catalogID, s.capID)
+	if err != nil {
+		return err
+	}
+	_, err = removeCatalogEntry(catalogID, capID, key)
+	return err
+}
+
+// ----------------------------------------------------------------------------
+// Nimiq
+// ----------------------------------------------------------------------------
+
+// nimiqChain reads Nimiq catalogs over RPC and writes them with
+// nimiq-uploader, which holds the node credentials. A catalog is an address;
+// entries are keyed by app ID.
+type nimiqChain struct {
+	client    *nimiq.Client
+	rpcURL    string
+	publisher string
+	uploader  string
+	args      []string
+}
+
+func (n *nimiqChain) Name() string { return chainNimiq }
+
+func (n *nimiqChain) resolve(catalogID string) (string, error) {
+	addr := nimiq.ResolveCatalog(catalogID)
+	if err := nimiq.ValidateAddress(addr); err != nil {
+		return "", err
+	}
+	return addr, nil
+}
+
+func (n *nimiqChain) CreateCatalog(name, description string) (string, error) {
+	return "", &chain.UnsupportedError{
+		Chain:     chainNimiq,
+		Operation: "creating a catalog",
+		Hint:      "a Nimiq catalog is any address entries are sent to; create one with nimiq-uploader account create",
+	}
+}
+
+func (n *nimiqChain) GetCatalog(catalogID string) (*chain.Catalog, error) {
+	entries, err := n.List(catalogID)
+	if err != nil {
+		return nil, err
+	}
+	addr, _ := n.resolve(catalogID)
+	return &chain.Catalog{Chain: chainNimiq, ID: nimiq.FormatAddress(addr), Entries: len(entries)}, nil
+}
+
+// List returns the newest entry of every app and channel, leaving out apps
+// whose newest entry retires them
+func (n *nimiqChain) List(catalogID string) ([]chain.Entry, error) {
+	addr, err := n.resolve(catalogID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := n.client.Catalog(addr, n.publisher)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries come newest first
+	retired := make(map[string]bool)
+	seen := make(map[string]bool)
+	var result []chain.Entry
+	for i := range entries {
+		e := &entries[i]
+		app := fmt.Sprintf("%s/%d", nimiq.NormalizeAddress(e.Publisher), e.AppID)
+		if _, ok := retired[app]; !ok {
+			retired[app] = e.Retired()
+		}
+		key := model.ChannelKey(strconv.FormatUint(uint64(e.AppID), 10), e.Channel())
+		if retired[app] || e.Retired() || seen[app+"/"+key] {
+			continue
+		}
+		seen[app+"/"+key] = true
+		result = append(result, chain.Entry{
+			Key:       key,
+			Title:     e.Title,
+			Platform:  model.Platform(e.Platform),
+			Version:   e.Version(),
+			Channel:   e.Channel(),
+			Cartridge: e.CartridgeAddr,
+			Publisher: e.Publisher,
+		})
+	}
+	return result, nil
+}
+
+func (n *nimiqChain) GetEntry(catalogID, key string) (*chain.Entry, error) {
+	entries, err := n.List(catalogID)
+	if err != nil {
+		return nil, err
+	}
+	if e := chain.Find(entries, key); e != nil {
+		return e, nil
+	}
+	return nil, fmt.Errorf("app %s not found in catalog %s", key, catalogID)
+}
+
+func (n *nimiqChain) AddEntry(catalogID string, game chain.Game) (*chain.Entry, error) {
+	return n.upload(catalogID, game)
+}
+
+func (n *nimiqChain) UpdateEntry(catalogID string, game chain.Game) (*chain.Entry, error) {
+	if game.Key == "" {
+		return nil, fmt.Errorf("an update needs the app ID of the entry")
+	}
+	return n.upload(catalogID, game)
+}
+
+// upload sends a game with upload-cartridge to a new cartridge address; a
+// game with a key becomes a new version of that app
+func (n *nimiqChain) upload(catalogID string, game chain.Game) (*chain.Entry, error) {
+	addr, err := n.resolve(catalogID)
+	if err != nil {
+		return nil, err
+	}
+	if len(game.Title) > nimiqTitleMax {
+		return nil, fmt.Errorf("title %q is longer than %d bytes", game.Title, nimiqTitleMax)
+	}
+	if game.Platform > model.PlatformNES {
+		return nil, fmt.Errorf("platform %s isn't supported by Nimiq cartridges", game.Platform)
+	}
+	semver := game.Version
+	if semver == "" {
+		semver = "1"
+	}
+	if !strings.Contains(semver, ".") {
+		semver += ".0.0"
+	}
+	channel, err := model.ParseChannel(game.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"upload-cartridge",
+		"--file", game.File,
+		"--catalog-addr", addr,
+		"--title", game.Title,
+		"--semver", semver,
+		"--platform", strconv.Itoa(int(game.Platform)),
+		"--channel", channel,
+		"--generate-cartridge-addr",
+		"--rpc-url", n.rpcURL,
+	}
+	if game.Key != "" {
+		appID, _ := model.SplitChannelKey(game.Key)
+		if _, err := strconv.ParseUint(appID, 10, 32); err != nil {
+			return nil, fmt.Errorf("invalid app ID %q", appID)
+		}
+		args = append(args, "--app-id", appID)
+	}
+	if err := n.run(args); err != nil {
+		return nil, fmt.Errorf("failed to upload to Nimiq: %w", err)
+	}
+
+	// The uploader picks the app ID and cartridge address; they are known
+	// once the entry is visible
+	entry := &chain.Entry{Key: game.Key, Title: game.Title, Platform: game.Platform, Version: semver, Channel: channel}
+	if entries, err := n.List(addr); err == nil {
+		for _, e := range entries {
+			if e.Title == game.Title && e.Version == semver && e.Channel == channel {
+				return &e, nil
+			}
+		}
+	}
+	return entry, nil
+}
+
+func (n *nimiqChain) RemoveEntry(catalogID, key string) error {
+	addr, err := n.resolve(catalogID)
+	if err != nil {
+		return err
+	}
+	appID, _ := model.SplitChannelKey(key)
+	if _, err := strconv.ParseUint(appID, 10, 32); err != nil {
+		return fmt.Errorf("invalid app ID %q", appID)
+	}
+	if err := n.run([]string{"retire-app", "--app-id", appID, "--catalog-addr", addr, "--rpc-url", n.rpcURL}); err != nil {
+		return fmt.Errorf("failed to retire app %s: %w", appID, err)
+	}
+	return nil
+}
+
+// run executes nimiq-uploader with the extra arguments appended
+func (n *nimiqChain) run(args []string) error {
+	cmd := exec.Command(n.uploader, append(args, n.args...)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
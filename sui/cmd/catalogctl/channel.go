@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// promote-channel command
+// ============================================================================
+
+var promoteChannelCmd = &cobra.Command{
+	Use:   "promote-channel",
+	Short: "Move an entry from one release channel to another",
+	Long: `Points the entry of a slug on the --to channel at the cartridge currently on
+the --from channel, then removes the --from entry. Entries on channels other
+than stable are keyed "<slug>@<channel>" and hidden from default listings.
+
+Example:
+  catalogctl publish-game --file doom.zip --slug doom --title "DOOM" --version 2 --channel beta
+  catalogctl promote-channel --slug doom --from beta --to stable
+
+Replacing an existing entry uses update_entry, which only the catalog owner
+may call; curators can promote slugs that aren't on the target channel yet.`,
+	RunE: runPromoteChannel,
+}
+
+var (
+	promoteSlug      string
+	promoteFrom      string
+	promoteTo        string
+	promoteCatalogID string
+	promoteCapID     string
+)
+
+func init() {
+	promoteChannelCmd.Flags().StringVar(&promoteSlug, "slug", "", "Game slug (required)")
+	promoteChannelCmd.Flags().StringVar(&promoteFrom, "from", model.ChannelBeta, "Channel to promote from")
+	promoteChannelCmd.Flags().StringVar(&promoteTo, "to", model.ChannelStable, "Channel to promote to")
+	promoteChannelCmd.Flags().StringVar(&promoteCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(promoteChannelCmd.Flags(), "catalog", formatCatalogRef)
+	promoteChannelCmd.Flags().StringVar(&promoteCapID, "cap", "", "CuratorCap object ID (auto-selected if the signer isn't the catalog owner)")
+	promoteChannelCmd.MarkFlagRequired("slug")
+	rootCmd.AddCommand(promoteChannelCmd)
+	reportsResult(promoteChannelCmd)
+}
+
+func runPromoteChannel(cmd *cobra.Command, args []string) error {
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+	from, err := model.ParseChannel(promoteFrom)
+	if err != nil {
+		return err
+	}
+	to, err := model.ParseChannel(promoteTo)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("--from and --to are both %s", from)
+	}
+
+	catalogID := promoteCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	catalogID, err = cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
+
+	fromKey, toKey := model.ChannelKey(promoteSlug, from), model.ChannelKey(promoteSlug, to)
+	client := sui.NewClient(cfg.SuiRPCURL)
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		return err
+	}
+	var source, target *catalogEntry
+	for i := range entries {
+		switch entries[i].Slug {
+		case fromKey:
+			source = &entries[i]
+		case toKey:
+			target = &entries[i]
+		}
+	}
+	if source == nil {
+		return fmt.Errorf("%s has no %s entry (%s) in catalog %s", promoteSlug, from, fromKey, catalogID)
+	}
+
+	capID, err := resolveCuratorCap(catalogID, promoteCapID)
+	if err != nil {
+		return err
+	}
+
+	cartridge := &adminCartridge{
+		ID:           source.CartridgeID,
+		Title:        source.Title,
+		Platform:     source.Platform,
+		EmulatorCore: source.EmulatorCore,
+		Version:      source.Version,
+		SizeBytes:    source.SizeBytes,
+	}
+	cover := ""
+	if source.CoverBlobID != "" {
+		cover = "0x" + source.CoverBlobID
+	}
+
+	statusf("Promoting %s v%d from %s to %s...\n", promoteSlug, source.Version, from, to)
+	var digest string
+	if target != nil {
+		if capID != "" {
+			return fmt.Errorf("%s already has a %s entry; replacing it requires the catalog owner (curators can only add and remove entries)", promoteSlug, to)
+		}
+		fmt.Fprintf(stdout, "  Replacing %s v%d\n", to, target.Version)
+		digest, err = updateCatalogEntry(catalogID, toKey, cartridge, cover)
+	} else {
+		digest, err = addCatalogEntry(catalogID, capID, toKey, cartridge, cover)
+	}
+	if err != nil {
+		return err
+	}
+	statusf("  ✓ %s → %s: %s\n", toKey, cartridge.ID, digest)
+	printExplorerLink("    ", config.LinkTx, digest)
+
+	removeDigest, err := removeCatalogEntry(catalogID, capID, fromKey)
+	if err != nil {
+		return fmt.Errorf("promoted, but failed to remove %s (remove it with remove-entry --slug %s): %w", fromKey, fromKey, err)
+	}
+	statusf("  ✓ Removed %s: %s\n", fromKey, removeDigest)
+	printExplorerLink("    ", config.LinkTx, removeDigest)
+
+	statusf("\n✓ %s v%d is now on %s\n", promoteSlug, source.Version, to)
+	newGHSummary(fmt.Sprintf("Promoted %s to %s", promoteSlug, to)).
+		row("Cartridge", mdLink(cartridge.ID, explorerURL(config.LinkObject, cartridge.ID))).
+		row("Version", fmt.Sprintf("%d", source.Version)).
+		row("Transaction", mdLink(digest, explorerURL(config.LinkTx, digest))).
+		output("digest", digest).
+		write()
+	return nil
+}
+
+// addCatalogEntry adds an entry pointing at a cartridge and returns the
+// transaction digest. Owners call add_entry; curators add_entry_with_cap.
+func addCatalogEntry(catalogID, capID, slug string, cartridge *adminCartridge, cover string) (string, error) {
+	output, err := executeMoveCall(sui.AddEntry(cfg.PackageID, catalogID, capID, cartridge.entryArgs(slug, cover)))
+	if err != nil {
+		return "", fmt.Errorf("failed to add entry: %w", err)
+	}
+	return sui.TransactionDigest(output), nil
+}
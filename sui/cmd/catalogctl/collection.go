@@ -0,0 +1,432 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// collection commands
+// ============================================================================
+
+var collectionCmd = &cobra.Command{
+	Use:   "collection",
+	Short: "Manage player collections of cartridges (favorites, shelves)",
+	Long: `A collection is a player's own list of cartridges: favorites, a "couch
+co-op" shelf, a list to share. Collections are objects owned by the address
+that created them; only the owner can change one, anyone can read it, and
+frontends render them next to the catalogs.
+
+Cartridges are given as object IDs (0x...) or as catalog entry slugs, looked
+up in --catalog (config.catalog_id if not set) on --channel. A collection
+holds at most 500 cartridges. Packages deployed before the collection module
+need upgrade-package first.
+
+Example:
+  catalogctl collection create "Favorites" --description "Games I keep coming back to"
+  catalogctl collection add --collection 0xabc... doom zelda 0x456...
+  catalogctl collection remove --collection 0xabc... zelda
+  catalogctl collection list
+  catalogctl collection list --collection 0xabc...`,
+}
+
+var collectionCreateCmd = &cobra.Command{
+	Use:   "create NAME",
+	Short: "Create an empty collection owned by the active address",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCollectionCreate,
+}
+
+var collectionAddCmd = &cobra.Command{
+	Use:   "add CARTRIDGE...",
+	Short: "Add cartridges to a collection",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runCollectionAdd,
+}
+
+var collectionRemoveCmd = &cobra.Command{
+	Use:   "remove CARTRIDGE...",
+	Short: "Remove cartridges from a collection",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runCollectionRemove,
+}
+
+var collectionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the collections of an address, or the cartridges of one collection",
+	Args:  cobra.NoArgs,
+	RunE:  runCollectionList,
+}
+
+var (
+	collectionID          string
+	collectionDescription string
+	collectionCatalogID   string
+	collectionChannel     string
+	collectionOwner       string
+)
+
+// maxCollectionCartridges is MAX_CARTRIDGES of the collection Move module
+const maxCollectionCartridges = 500
+
+func init() {
+	collectionCreateCmd.Flags().StringVar(&collectionDescription, "description", "", "Collection description")
+
+	for _, c := range []*cobra.Command{collectionAddCmd, collectionRemoveCmd} {
+		c.Flags().StringVar(&collectionID, "collection", "", "Collection object ID (required)")
+		c.Flags().StringVar(&collectionCatalogID, "catalog", "", "Catalog to look up entry slugs in (optional, uses config.catalog_id if not set)")
+		validateAs(c.Flags(), "catalog", formatCatalogRef)
+		c.Flags().StringVar(&collectionChannel, "channel", model.ChannelStable, "Release channel of entry slugs: stable or beta")
+		c.MarkFlagRequired("collection")
+	}
+
+	collectionListCmd.Flags().StringVar(&collectionOwner, "owner", "", "Address to list collections for (default: active sui address)")
+	collectionListCmd.Flags().StringVar(&collectionID, "collection", "", "List the cartridges of this collection")
+
+	collectionCmd.AddCommand(collectionCreateCmd, collectionAddCmd, collectionRemoveCmd, collectionListCmd)
+	reportsResult(collectionCreateCmd, collectionAddCmd, collectionRemoveCmd, collectionListCmd)
+	rootCmd.AddCommand(collectionCmd)
+}
+
+// collection is a Collection object as read from chain
+type collection struct {
+	ID          string   `json:"collection_id"`
+	Owner       string   `json:"owner"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Cartridges  []string `json:"cartridges"`
+}
+
+// isCollection reports whether an object is a Collection. Matched by
+// suffix: the module may come from an upgrade, under another package ID.
+func isCollection(obj *sui.ObjectData) bool {
+	return strings.HasSuffix(obj.Type, "::collection::Collection")
+}
+
+// parseCollection reads the fields of a Collection object
+func parseCollection(obj *sui.ObjectData) *collection {
+	fields := sui.ParseCatalog(obj)
+	c := &collection{ID: obj.ObjectID, Cartridges: []string{}}
+	c.Name, _ = fields["name"].(string)
+	c.Description, _ = fields["description"].(string)
+	if owner, ok := obj.Owner.(map[string]interface{}); ok {
+		c.Owner, _ = owner["AddressOwner"].(string)
+	}
+	ids, _ := fields["cartridges"].([]interface{})
+	for _, id := range ids {
+		if s, ok := id.(string); ok {
+			c.Cartridges = append(c.Cartridges, s)
+		}
+	}
+	return c
+}
+
+// fetchCollection reads a collection by object ID
+func fetchCollection(client *sui.Client, id string) (*collection, error) {
+	resp, err := client.GetObject(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("collection %s not found", id)
+	}
+	if !isCollection(resp.Data) {
+		return nil, fmt.Errorf("%s is not a collection (it is a %s)", id, resp.Data.Type)
+	}
+	return parseCollection(resp.Data), nil
+}
+
+func runCollectionCreate(cmd *cobra.Command, args []string) error {
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+	name := strings.TrimSpace(args[0])
+	if name == "" {
+		return fmt.Errorf("collection name can't be empty")
+	}
+
+	statusf("Creating collection %q...\n", name)
+	output, err := executeMoveCall(sui.CreateCollection(cfg.PackageID, name, collectionDescription))
+	if err != nil {
+		return fmt.Errorf("failed to create collection (packages deployed before the collection module need upgrade-package first): %w", err)
+	}
+
+	id := sui.CreatedObjectID(output, "::collection::Collection")
+	digest := sui.TransactionDigest(output)
+	setResult(map[string]interface{}{"collection_id": id, "name": name, "digest": digest})
+	statusf("\n✓ Collection created!\n")
+	fmt.Fprintf(stdout, "Collection ID: %s\n", id)
+	printExplorerLink("  ", config.LinkObject, id)
+	fmt.Fprintf(stdout, "Transaction: %s\n", digest)
+	printExplorerLink("  ", config.LinkTx, digest)
+	newGHSummary("Collection created").
+		row("Collection", mdLink(id, explorerURL(config.LinkObject, id))).
+		row("Name", name).
+		row("Transaction", mdLink(digest, explorerURL(config.LinkTx, digest))).
+		output("collection_id", id).
+		output("digest", digest).
+		write()
+	return nil
+}
+
+// resolveCollectionCartridges turns command line arguments into cartridge
+// IDs: 0x... arguments are taken as-is, anything else is an entry slug of
+// the catalog
+func resolveCollectionCartridges(client *sui.Client, args []string) ([]string, error) {
+	var entries []catalogEntry
+	var ids []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "0x") {
+			ids = append(ids, arg)
+			continue
+		}
+		if entries == nil {
+			catalogID := collectionCatalogID
+			if catalogID == "" {
+				catalogID = cfg.CatalogID
+			}
+			if catalogID == "" {
+				return nil, fmt.Errorf("%s is not a cartridge ID; to add an entry by slug, set --catalog flag or catalog_id in config file", arg)
+			}
+			catalogID, err := cfg.ResolveCatalogID(catalogID)
+			if err != nil {
+				return nil, err
+			}
+			if entries, err = fetchCatalogEntries(client, catalogID); err != nil {
+				return nil, err
+			}
+		}
+		channel, err := model.ParseChannel(collectionChannel)
+		if err != nil {
+			return nil, err
+		}
+		key := model.ChannelKey(arg, channel)
+		id := ""
+		for _, entry := range entries {
+			if entry.Slug == key {
+				id = entry.CartridgeID
+				break
+			}
+		}
+		if id == "" {
+			return nil, fmt.Errorf("entry %s not found in the catalog", key)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func runCollectionAdd(cmd *cobra.Command, args []string) error {
+	return updateCollection(args, true)
+}
+
+func runCollectionRemove(cmd *cobra.Command, args []string) error {
+	return updateCollection(args, false)
+}
+
+// updateCollection adds (add_cartridge) or removes (remove_cartridge) the
+// cartridges args name, one transaction each, skipping those already in
+// (or not in) the collection
+func updateCollection(args []string, adding bool) error {
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+	client := sui.NewClient(cfg.SuiRPCURL)
+	coll, err := fetchCollection(client, collectionID)
+	if err != nil {
+		return err
+	}
+	ids, err := resolveCollectionCartridges(client, args)
+	if err != nil {
+		return err
+	}
+
+	var todo []string
+	for _, id := range ids {
+		in := containsString(coll.Cartridges, id)
+		switch {
+		case containsString(todo, id):
+			// Given twice
+		case adding && in:
+			warnf("%s is already in %s", id, coll.Name)
+		case !adding && !in:
+			warnf("%s is not in %s", id, coll.Name)
+		default:
+			todo = append(todo, id)
+		}
+	}
+	if adding && len(todo) > 0 {
+		if len(coll.Cartridges)+len(todo) > maxCollectionCartridges {
+			return fmt.Errorf("a collection can hold at most %d cartridges, %s would have %d", maxCollectionCartridges, coll.Name, len(coll.Cartridges)+len(todo))
+		}
+		// Catch typos before they end up in the collection
+		resps, err := client.MultiGetObjects(todo)
+		if err != nil {
+			return fmt.Errorf("failed to get cartridges: %w", err)
+		}
+		for i, resp := range resps {
+			if resp.Data == nil || !strings.HasSuffix(resp.Data.Type, "::cartridge::Cartridge") {
+				return fmt.Errorf("%s is not a cartridge", todo[i])
+			}
+		}
+	}
+
+	digests := []string{}
+	defer func() {
+		setResult(map[string]interface{}{"collection_id": coll.ID, "cartridges": todo, "digests": digests})
+	}()
+	if len(todo) == 0 {
+		statusf("✓ Nothing to do\n")
+		return nil
+	}
+
+	verb, done := "Adding %d cartridge(s) to", "added to"
+	if !adding {
+		verb, done = "Removing %d cartridge(s) from", "removed from"
+	}
+	statusf(verb+" collection %s (%s)...\n", len(todo), coll.Name, coll.ID)
+	for i, id := range todo {
+		call := sui.AddToCollection(cfg.PackageID, coll.ID, id)
+		if !adding {
+			call = sui.RemoveFromCollection(cfg.PackageID, coll.ID, id)
+		}
+		output, err := executeMoveCall(call)
+		if err != nil {
+			return fmt.Errorf("failed to update collection at %s (%d of %d done): %w", id, i, len(todo), err)
+		}
+		digest := sui.TransactionDigest(output)
+		digests = append(digests, digest)
+		statusf("✓ [%d/%d] %s\n", i+1, len(todo), id)
+		debugf(levelVerbose, "transaction %s", digest)
+	}
+
+	fmt.Fprintf(stdout, "\n%d cartridge(s) %s %s\n", len(todo), done, coll.Name)
+	newGHSummary(fmt.Sprintf("%d cartridge(s) %s collection", len(todo), done)).
+		row("Collection", mdLink(coll.ID, explorerURL(config.LinkObject, coll.ID))).
+		row("Cartridges", strings.Join(todo, ", ")).
+		output("digests", strings.Join(digests, ",")).
+		write()
+	return nil
+}
+
+func runCollectionList(cmd *cobra.Command, args []string) error {
+	client := sui.NewClient(cfg.SuiRPCURL)
+	if collectionID != "" {
+		return listCollectionCartridges(client, collectionID)
+	}
+
+	owner := collectionOwner
+	if owner == "" {
+		var err error
+		if owner, err = activeAddress(); err != nil {
+			return err
+		}
+	}
+	objects, err := client.GetOwnedObjects(owner, "")
+	if err != nil {
+		return fmt.Errorf("failed to list owned objects: %w", err)
+	}
+	collections := []*collection{}
+	for i := range objects {
+		if isCollection(&objects[i]) {
+			collections = append(collections, parseCollection(&objects[i]))
+		}
+	}
+	setResult(map[string]interface{}{"owner": owner, "collections": collections})
+
+	if len(collections) == 0 {
+		fmt.Fprintf(stdout, "No collections owned by %s\n", owner)
+		return nil
+	}
+	fmt.Fprintf(stdout, "Collections owned by %s:\n\n", owner)
+	fmt.Fprintf(stdout, "%-66s  %-30s  %s\n", "COLLECTION ID", "NAME", "CARTRIDGES")
+	fmt.Fprintln(stdout, strings.Repeat("-", 110))
+	for _, c := range collections {
+		fmt.Fprintf(stdout, "%-66s  %-30s  %d\n", c.ID, truncate(c.Name, 30), len(c.Cartridges))
+	}
+	return nil
+}
+
+// collectionCartridge is a cartridge of a collection, as listed
+type collectionCartridge struct {
+	ID       string `json:"cartridge_id"`
+	Slug     string `json:"slug"`
+	Title    string `json:"title"`
+	Platform string `json:"platform"`
+	Version  uint16 `json:"version"`
+	// Missing is set for cartridges that no longer exist (e.g. pruned)
+	Missing bool `json:"missing,omitempty"`
+}
+
+// collectionDetails is a collection with its cartridges, as listed by
+// collection list --collection and served by the gateway
+type collectionDetails struct {
+	Collection *collection           `json:"collection"`
+	Cartridges []collectionCartridge `json:"cartridges"`
+}
+
+// readCollection reads a collection and the cartridges it lists, in order
+func readCollection(client *sui.Client, id string) (*collectionDetails, error) {
+	coll, err := fetchCollection(client, id)
+	if err != nil {
+		return nil, err
+	}
+	resps, err := client.MultiGetObjects(coll.Cartridges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cartridges: %w", err)
+	}
+	details := &collectionDetails{Collection: coll, Cartridges: make([]collectionCartridge, 0, len(coll.Cartridges))}
+	for i, resp := range resps {
+		c := collectionCartridge{ID: coll.Cartridges[i]}
+		if resp.Data == nil {
+			c.Missing = true
+		} else {
+			fields := sui.ParseCatalog(resp.Data)
+			c.Slug, _ = fields["slug"].(string)
+			c.Title, _ = fields["title"].(string)
+			if p, ok := fields["platform"].(float64); ok {
+				c.Platform = model.Platform(p).String()
+			}
+			if v, ok := fields["version"].(float64); ok {
+				c.Version = uint16(v)
+			}
+		}
+		details.Cartridges = append(details.Cartridges, c)
+	}
+	return details, nil
+}
+
+// listCollectionCartridges prints the cartridges of one collection, in order
+func listCollectionCartridges(client *sui.Client, id string) error {
+	details, err := readCollection(client, id)
+	if err != nil {
+		return err
+	}
+	setResult(details)
+	coll, cartridges := details.Collection, details.Cartridges
+
+	fmt.Fprintf(stdout, "%s (%s)\n", coll.Name, coll.ID)
+	if coll.Description != "" {
+		fmt.Fprintf(stdout, "%s\n", coll.Description)
+	}
+	fmt.Fprintf(stdout, "Owner: %s\n\n", coll.Owner)
+	if len(cartridges) == 0 {
+		fmt.Fprintln(stdout, "The collection is empty.")
+		return nil
+	}
+	fmt.Fprintf(stdout, "%-20s %-30s %-8s %-8s %s\n", "SLUG", "TITLE", "PLATFORM", "VERSION", "CARTRIDGE_ID")
+	fmt.Fprintln(stdout, "-----------------------------------------------------------------------------------------------")
+	for _, c := range cartridges {
+		if c.Missing {
+			fmt.Fprintf(stdout, "%-20s %-30s %-8s %-8s %s\n", "-", "(cartridge not found)", "-", "-", c.ID)
+			continue
+		}
+		fmt.Fprintf(stdout, "%-20s %-30s %-8s v%-7d %s\n", truncate(c.Slug, 20), truncate(c.Title, 30), c.Platform, c.Version, c.ID)
+	}
+	return nil
+}
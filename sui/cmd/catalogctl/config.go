@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// config command group
+// ============================================================================
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect, edit and validate configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration for unknown fields and contradictory settings",
+	Long: `Validates the loaded configuration and reports every problem found:
+  - unknown fields in config.json (usually typos)
+  - unknown network names
+  - RPC/Walrus URLs whose host names a different network than configured
+  - malformed object IDs and private keys
+
+Exits with an error if any problem is fatal.`,
+	RunE: runConfigValidate,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print an effective configuration value (dot-path keys supported)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Set a configuration value in the config file",
+	Long: `Sets a value in the config file without hand-editing it. The existing
+field order is kept and the file is written atomically.
+
+Example:
+  catalogctl config set catalog_id 0x1234...`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Show which config file is used and where catalogctl looks for one",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Fprintln(stdout, "Configuration Paths:")
+		fmt.Fprintf(stdout, "  Config directory: %s\n", config.GetConfigDir())
+		if cfg.Source != "" && cfg.Encrypted {
+			fmt.Fprintf(stdout, "  Loaded from: %s (encrypted)\n", cfg.Source)
+		} else if cfg.Source != "" {
+			fmt.Fprintf(stdout, "  Loaded from: %s\n", cfg.Source)
+		} else {
+			fmt.Fprintln(stdout, "  Loaded from: (no config file; .env and environment only)")
+		}
+		fmt.Fprintln(stdout, "\nSearch order:")
+		fmt.Fprintln(stdout, "  1. --config flag")
+		for i, p := range config.SearchPaths() {
+			fmt.Fprintf(stdout, "  %d. %s\n", i+2, p)
+		}
+		setResult(map[string]interface{}{
+			"config_dir":   config.GetConfigDir(),
+			"loaded_from":  cfg.Source,
+			"encrypted":    cfg.Encrypted,
+			"search_paths": config.SearchPaths(),
+		})
+	},
+}
+
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the whole config file with a master passphrase",
+	Long: `Encrypts the config file in place (AES-256-GCM, key derived from a master
+passphrase with scrypt, like the keystore file), so keys, RPC tokens and
+webhook URLs aren't readable by other users of the machine. Running it on an
+encrypted file changes the passphrase.
+
+Every command then needs the passphrase, taken from (in order):
+  $CATALOGCTL_PASSPHRASE           the passphrase itself
+  $CATALOGCTL_PASSPHRASE_COMMAND   a command printing it (password manager)
+  $CATALOGCTL_AGENT_SOCK           a signing agent started with --share-passphrase
+  a prompt                         when running in a terminal
+
+config set and --save-config keep the file encrypted.
+
+Example:
+  catalogctl config encrypt
+  CATALOGCTL_PASSPHRASE_COMMAND="pass show catalogctl" catalogctl list-catalog`,
+	RunE: runConfigEncrypt,
+}
+
+var configDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt the config file back to plain JSON",
+	RunE:  runConfigDecrypt,
+}
+
+func init() {
+	configCmd.AddCommand(configPathCmd)
+	reportsResult(configPathCmd)
+	configCmd.AddCommand(configValidateCmd)
+	reportsResult(configValidateCmd)
+	configCmd.AddCommand(configGetCmd)
+	reportsResult(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	reportsResult(configSetCmd)
+	configCmd.AddCommand(configEncryptCmd)
+	configCmd.AddCommand(configDecryptCmd)
+	reportsResult(configEncryptCmd, configDecryptCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	value, err := cfg.GetValue(args[0])
+	if err != nil {
+		return err
+	}
+	setResult(map[string]interface{}{"key": args[0], "value": value})
+	if s, ok := value.(string); ok {
+		fmt.Fprintln(stdout, s)
+		return nil
+	}
+	jsonBytes, _ := json.MarshalIndent(value, "", "  ")
+	fmt.Fprintln(stdout, string(jsonBytes))
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+	if strings.HasSuffix(key, "_id") && value != "" {
+		if err := validate.ObjectID(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+
+	if err := config.SetValue(cfg.Path(), key, value); err != nil {
+		return fmt.Errorf("failed to update config: %w", err)
+	}
+	statusf("✓ Set %s in %s\n", key, cfg.Path())
+	setResult(map[string]string{"key": key, "value": value, "file": cfg.Path()})
+	return nil
+}
+
+// saveConfigValue writes a single value to the config file and reports it
+func saveConfigValue(key, value string) error {
+	if memoryChain != nil {
+		if err := memoryChain.SetSetting(key, value); err != nil {
+			return fmt.Errorf("failed to save %s: %w", key, err)
+		}
+		statusf("✓ Saved %s to %s\n", key, memoryChain.Path())
+		return nil
+	}
+	if err := config.SetValue(cfg.Path(), key, value); err != nil {
+		return fmt.Errorf("failed to save %s to %s: %w", key, cfg.Path(), err)
+	}
+	statusf("✓ Saved %s to %s\n", key, cfg.Path())
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	source := cfg.Source
+	if source == "" {
+		source = "(environment / .env only)"
+	}
+	fmt.Fprintf(stdout, "Config: %s\n\n", source)
+
+	problems := cfg.Check()
+	reported, valid := make([]map[string]interface{}, 0, len(problems)), true
+	for _, p := range problems {
+		reported = append(reported, map[string]interface{}{"field": p.Field, "message": p.Message, "fatal": p.Fatal})
+		valid = valid && !p.Fatal
+	}
+	setResult(map[string]interface{}{"config": cfg.Source, "valid": valid, "problems": reported})
+	if len(problems) == 0 {
+		fmt.Fprintln(stdout, "✓ Configuration is valid")
+		return nil
+	}
+
+	errors := 0
+	for _, p := range problems {
+		marker := "⚠️ "
+		if p.Fatal {
+			marker = "✗"
+			errors++
+		}
+		if p.Field != "" {
+			fmt.Fprintf(stdout, "%s %s: %s\n", marker, p.Field, p.Message)
+		} else {
+			fmt.Fprintf(stdout, "%s %s\n", marker, p.Message)
+		}
+	}
+
+	fmt.Fprintf(stdout, "\n%d error(s), %d warning(s)\n", errors, len(problems)-errors)
+	if errors > 0 {
+		return fmt.Errorf("configuration is invalid")
+	}
+	return nil
+}
+
+func runConfigEncrypt(cmd *cobra.Command, args []string) error {
+	if cfg.Source == "" {
+		return fmt.Errorf("no config file loaded; create one first (catalogctl config set ...)")
+	}
+
+	// A plain file can be encrypted non-interactively with $CATALOGCTL_PASSPHRASE;
+	// a new passphrase for an encrypted file is always typed twice
+	passphrase := os.Getenv(config.PassphraseEnv)
+	if passphrase == "" || cfg.Encrypted {
+		if !stdinIsTerminal() {
+			return fmt.Errorf("a terminal is needed to enter the new passphrase (or set %s to encrypt a plain file)", config.PassphraseEnv)
+		}
+		var err error
+		if passphrase, err = readPassphrase("New passphrase: "); err != nil {
+			return err
+		}
+		if passphrase == "" {
+			return fmt.Errorf("passphrase must not be empty")
+		}
+		again, err := readPassphrase("Repeat passphrase: ")
+		if err != nil {
+			return err
+		}
+		if again != passphrase {
+			return fmt.Errorf("passphrases don't match")
+		}
+	}
+
+	// A file bound to a security key stays bound under the new passphrase
+	sealed, err := fido2Passphrase(cfg.Source, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := config.SetPassphrase(cfg.Source, sealed); err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", cfg.Source, err)
+	}
+	setResult(map[string]interface{}{"file": cfg.Source, "encrypted": true, "passphrase_changed": cfg.Encrypted})
+	if cfg.Encrypted {
+		statusf("✓ Changed the passphrase of %s\n", cfg.Source)
+	} else {
+		statusf("✓ Encrypted %s\n", cfg.Source)
+	}
+	statusf("💡 Commands now need the passphrase: set %s or %s, or type it when asked\n", config.PassphraseEnv, passphraseCommandEnv)
+	return nil
+}
+
+func runConfigDecrypt(cmd *cobra.Command, args []string) error {
+	if cfg.Source == "" || !cfg.Encrypted {
+		return fmt.Errorf("config file %s is not encrypted", cfg.Path())
+	}
+	if err := config.SetPassphrase(cfg.Source, ""); err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", cfg.Source, err)
+	}
+	statusf("✓ Decrypted %s\n", cfg.Source)
+	setResult(map[string]interface{}{"file": cfg.Source, "encrypted": false})
+	if fido2Unlocked.kek != nil {
+		warnf("%s is no longer bound to the security key; remove %s and enroll again after encrypting it", cfg.Source, fido2CredentialPath())
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Object version conflicts
+// ============================================================================
+
+// conflictRetryBackoff is the first wait before resending a transaction that
+// lost a race for an object; later waits double, plus jitter so two
+// curators retrying together don't collide again
+const conflictRetryBackoff = 500 * time.Millisecond
+
+var conflictRetries int
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&conflictRetries, "conflict-retries", 3, "Times a transaction is rebuilt and resent when another transaction changed one of its objects first")
+}
+
+// versionConflictMarkers are the errors validators and the node return when
+// a transaction was built against object versions another transaction
+// consumed first, or when its objects are locked by a concurrent one
+var versionConflictMarkers = []string{
+	"objectversionunavailableforconsumption",
+	"is not available for consumption",
+	"objectlockconflict",
+	"objects double used",
+	"objectsdoubleused",
+	"equivocat",
+	"toomanytransactionspendingonobject",
+	"sharedobjectcongestion",
+}
+
+// versionConflict reports whether err says the transaction lost a race for
+// one of its objects. Such transactions never executed, so they are safe to
+// rebuild and send again; Move aborts are not conflicts.
+func versionConflict(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range versionConflictMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryOnConflict runs a transaction, rebuilding and resending it up to
+// conflictRetries times while it fails with a version conflict. Before each
+// retry every cached object version is forgotten, so the shared catalog is
+// read again and the transaction is built against its current version.
+func retryOnConflict(run func() (string, error)) (string, error) {
+	for attempt := 0; ; attempt++ {
+		output, err := run()
+		if !versionConflict(err) || attempt >= conflictRetries {
+			if err != nil && attempt > 0 {
+				err = fmt.Errorf("%w (after %d attempts, retrying on version conflicts)", err, attempt+1)
+			}
+			return output, err
+		}
+		invalidateObjectCache("", err)
+		wait := conflictRetryBackoff<<attempt + time.Duration(rand.Int63n(int64(conflictRetryBackoff)))
+		statusf("⚠️  Another transaction changed an object of this one first; retrying in %s (%d/%d)\n",
+			wait.Round(100*time.Millisecond), attempt+1, conflictRetries)
+		time.Sleep(wait)
+	}
+}
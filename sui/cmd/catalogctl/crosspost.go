@@ -0,0 +1,399 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/retro-crypto/sui/internal/chain"
+	"github.com/retro-crypto/sui/internal/identity"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/nimiq"
+	"github.com/retro-crypto/sui/internal/plan"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// crosspost command
+// ============================================================================
+
+var crosspostCmd = &cobra.Command{
+	Use:   "crosspost",
+	Short: "Mirror a game between a Sui catalog and a Nimiq catalog",
+	Long: `Copies a published game from one chain's catalog to the other's, so both
+ecosystems carry the same release.
+
+--from sui --to nimiq downloads the game of a Sui catalog entry from Walrus,
+checks it against the cartridge's SHA256 and uploads it with
+'nimiq-uploader upload-cartridge'. The title (cut to 16 bytes), platform and
+channel are kept; Sui version N becomes Nimiq semver N.0.0. Signing and
+sending are left to the nimiq-uploader, so its credentials apply; pass extra
+flags such as --sender with --nimiq-arg.
+
+--from nimiq --to sui reads a cartridge of a Nimiq catalog, checks it against
+its CART header and publishes it like publish-game under --slug. Nimiq semver
+N.0.0 becomes Sui version N; other semvers need --version.
+
+Games whose target entry already holds the same file are skipped.
+
+Example:
+  catalogctl crosspost --from sui --slug doom --to nimiq --catalog-addr main
+  catalogctl crosspost --from nimiq --catalog-addr main --app-id 3 --to sui --slug doom`,
+	RunE: runCrosspost,
+}
+
+var (
+	crosspostFrom        string
+	crosspostTo          string
+	crosspostSlug        string
+	crosspostCatalogID   string
+	crosspostCapID       string
+	crosspostCatalogAddr string
+	crosspostAppID       uint32
+	crosspostSemver      string
+	crosspostPublisher   string
+	crosspostTitle       string
+	crosspostVersion     uint16
+	crosspostChannel     string
+	crosspostEpochs      int
+	crosspostNimiqRPCURL string
+	crosspostUploader    string
+	crosspostNimiqArgs   []string
+	crosspostWorkDir     string
+	crosspostDryRun      bool
+)
+
+func init() {
+	crosspostCmd.Flags().StringVar(&crosspostFrom, "from", "", "Source chain: sui or nimiq (required)")
+	crosspostCmd.Flags().StringVar(&crosspostTo, "to", "", "Target chain: sui or nimiq (required)")
+	crosspostCmd.Flags().StringVar(&crosspostSlug, "slug", "", "Slug of the Sui entry (required)")
+	crosspostCmd.Flags().StringVar(&crosspostCatalogID, "catalog", "", "Sui catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(crosspostCmd.Flags(), "catalog", formatCatalogRef)
+	crosspostCmd.Flags().StringVar(&crosspostCapID, "cap", "", "CuratorCap object ID when publishing to Sui (auto-selected if the signer isn't the catalog owner)")
+	crosspostCmd.Flags().StringVar(&crosspostCatalogAddr, "catalog-addr", "", "Nimiq catalog address or alias: main, test (required)")
+	crosspostCmd.Flags().Uint32Var(&crosspostAppID, "app-id", 0, "App ID of the Nimiq entry (required with --from nimiq)")
+	crosspostCmd.Flags().StringVar(&crosspostSemver, "semver", "", "Semver of the Nimiq entry (default: latest on the channel)")
+	crosspostCmd.Flags().StringVar(&crosspostPublisher, "publisher", "", "Only read Nimiq entries of this publisher")
+	crosspostCmd.Flags().StringVar(&crosspostTitle, "title", "", "Title on the target chain (default: the source title)")
+	crosspostCmd.Flags().Uint16Var(&crosspostVersion, "version", 0, "Sui version when publishing to Sui (default: the Nimiq major version)")
+	crosspostCmd.Flags().StringVar(&crosspostChannel, "channel", model.ChannelStable, "Release channel: stable or beta")
+	crosspostCmd.Flags().IntVar(&crosspostEpochs, "epochs", 5, "Number of storage epochs for Walrus")
+	crosspostCmd.Flags().StringVar(&crosspostNimiqRPCURL, "nimiq-rpc-url", "", "Nimiq RPC URL (default: $NIMIQ_RPC_URL or http://127.0.0.1:8648)")
+	crosspostCmd.Flags().StringVar(&crosspostUploader, "nimiq-uploader", "nimiq-uploader", "nimiq-uploader binary used to publish to Nimiq")
+	crosspostCmd.Flags().StringArrayVar(&crosspostNimiqArgs, "nimiq-arg", nil, "Extra flag passed to nimiq-uploader upload-cartridge (repeatable)")
+	crosspostCmd.Flags().StringVar(&crosspostWorkDir, "work-dir", "", "Directory for the game file and journal (default: a temporary directory)")
+	crosspostCmd.Flags().BoolVar(&crosspostDryRun, "dry-run", false, "Check and show the mapped metadata without publishing")
+	crosspostCmd.MarkFlagRequired("from")
+	crosspostCmd.MarkFlagRequired("to")
+	crosspostCmd.MarkFlagRequired("slug")
+	crosspostCmd.MarkFlagRequired("catalog-addr")
+	rootCmd.AddCommand(crosspostCmd)
+	reportsResult(crosspostCmd)
+}
+
+// nimiqTitleMax is the size of the title field of a CENT entry
+const nimiqTitleMax = 16
+
+func runCrosspost(cmd *cobra.Command, args []string) error {
+	from, to := strings.ToLower(crosspostFrom), strings.ToLower(crosspostTo)
+	if from+">"+to != "sui>nimiq" && from+">"+to != "nimiq>sui" {
+		return fmt.Errorf("--from and --to must be sui and nimiq (either way round), got %q and %q", crosspostFrom, crosspostTo)
+	}
+	channel, err := model.ParseChannel(crosspostChannel)
+	if err != nil {
+		return err
+	}
+
+	catalogID := crosspostCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	if catalogID, err = cfg.ResolveCatalogID(catalogID); err != nil {
+		return err
+	}
+	if err := validate.ObjectID(catalogID); err != nil {
+		return fmt.Errorf("invalid catalog ID %s: %w", catalogID, err)
+	}
+
+	catalogAddr := nimiq.ResolveCatalog(crosspostCatalogAddr)
+	if err := nimiq.ValidateAddress(catalogAddr); err != nil {
+		return err
+	}
+	rpcURL := defaultNimiqRPCURL(crosspostNimiqRPCURL)
+
+	workDir := crosspostWorkDir
+	if workDir == "" {
+		if workDir, err = os.MkdirTemp("", "crosspost-"); err != nil {
+			return fmt.Errorf("failed to create work directory: %w", err)
+		}
+		defer os.RemoveAll(workDir)
+	} else if err := os.MkdirAll(workDir, 0755); err != nil {
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	nimiqClient := nimiq.NewClient(rpcURL)
+	if from == "sui" {
+		return crosspostToNimiq(client, nimiqClient, rpcURL, catalogID, catalogAddr, channel, workDir)
+	}
+	return crosspostToSui(client, nimiqClient, catalogID, catalogAddr, channel, workDir)
+}
+
+// crosspostToNimiq uploads the game of a Sui entry as a Nimiq cartridge
+func crosspostToNimiq(client *sui.Client, nimiqClient *nimiq.Client, rpcURL, catalogID, catalogAddr, channel, workDir string) error {
+	key := model.ChannelKey(crosspostSlug, channel)
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		return err
+	}
+	var entry *catalogEntry
+	for i := range entries {
+		if entries[i].Slug == key {
+			entry = &entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		return fmt.Errorf("entry %s not found in catalog %s", key, catalogID)
+	}
+
+	// Map the metadata
+	title := crosspostTitle
+	if title == "" {
+		title = entry.Title
+	}
+	if len(title) > nimiqTitleMax {
+		cut := nimiqTitleMax
+		for cut > 0 && !utf8.RuneStart(title[cut]) {
+			cut--
+		}
+		statusf("💡 Title %q is cut to %d bytes for Nimiq (set --title to choose)\n", title, nimiqTitleMax)
+		title = title[:cut]
+	}
+	if entry.Version > 255 {
+		return fmt.Errorf("version %d doesn't fit a Nimiq semver major (max 255)", entry.Version)
+	}
+	semver := fmt.Sprintf("%d.0.0", entry.Version)
+	if entry.Platform > model.PlatformNES {
+		return fmt.Errorf("platform %s isn't supported by Nimiq cartridges", entry.Platform)
+	}
+
+	fmt.Fprintf(stdout, "Sui entry %s (v%d, cartridge %s)\n", key, entry.Version, entry.CartridgeID)
+	fmt.Fprintf(stdout, "  → Nimiq catalog %s: title %q, semver %s, platform %d, channel %s\n", nimiq.FormatAddress(catalogAddr), title, semver, entry.Platform, channel)
+
+	// Skip if the Nimiq catalog already has this version with the same file
+	file, err := fetchCartridgeFile(client, entry.CartridgeID)
+	if err != nil {
+		return err
+	}
+	result := map[string]interface{}{
+		"from": chainSui, "to": chainNimiq, "status": "dry_run",
+		"sui_catalog_id": catalogID, "sui_key": key, "sui_cartridge_id": entry.CartridgeID,
+		"nimiq_catalog": nimiq.FormatAddress(catalogAddr), "title": title, "version": semver, "sha256": file.SHA256Hex,
+	}
+	setResult(result)
+	suiSide := suiCopy(catalogID, key, entry.Version, entry.CartridgeID, file.BlobID)
+	nimiqEntries, err := nimiqClient.Catalog(catalogAddr, crosspostPublisher)
+	if err != nil {
+		return err
+	}
+	for _, ne := range nimiqEntries {
+		if ne.Title != title || ne.Version() != semver || ne.Channel() != channel || ne.Retired() {
+			continue
+		}
+		header, err := nimiqClient.CartridgeHeader(ne.CartridgeAddr, ne.Publisher)
+		if err == nil && hex.EncodeToString(header.SHA256[:]) == file.SHA256Hex {
+			result["status"], result["nimiq_app_id"], result["nimiq_cartridge"] = "exists", ne.AppID, ne.CartridgeAddr
+			statusf("✓ Already on Nimiq: app %d v%s at %s\n", ne.AppID, semver, ne.CartridgeAddr)
+			recordGames(func(r *identity.Registry) {
+				r.AddSui(file.SHA256Hex, file.Size, entry.Title, entry.Platform, suiSide)
+				r.AddNimiq(file.SHA256Hex, file.Size, entry.Title, entry.Platform, nimiqCopy(catalogAddr, &ne))
+			})
+			return nil
+		}
+	}
+	if crosspostDryRun {
+		fmt.Fprintln(stdout, "Dry run: nothing uploaded")
+		return nil
+	}
+
+	data, err := fetchGameFile(client, entry.CartridgeID, "", 0)
+	if err != nil {
+		return err
+	}
+	gamePath := filepath.Join(workDir, crosspostSlug+".bin")
+	if err := os.WriteFile(gamePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write game file: %w", err)
+	}
+	statusf("✓ Downloaded and verified %d bytes\n", len(data))
+
+	nimiqChain, err := chainBackend(chainNimiq, chainOptions{NimiqRPCURL: rpcURL, Uploader: crosspostUploader, UploaderArgs: crosspostNimiqArgs})
+	if err != nil {
+		return err
+	}
+	game := chain.Game{File: gamePath, Title: title, Platform: entry.Platform, Version: semver, Channel: channel}
+	added, err := nimiqChain.AddEntry(catalogAddr, game)
+	if err != nil {
+		return err
+	}
+	result["status"], result["nimiq_app_id"], result["nimiq_cartridge"] = "crossposted", added.Key, added.Cartridge
+	statusf("✓ Crossposted %s to Nimiq catalog %s\n", key, nimiq.FormatAddress(catalogAddr))
+
+	// The new CENT entry is only known once its transaction is visible
+	recordGames(func(r *identity.Registry) {
+		r.AddSui(file.SHA256Hex, file.Size, entry.Title, entry.Platform, suiSide)
+		if nimiqEntries, err := nimiqClient.Catalog(catalogAddr, ""); err == nil {
+			for i := range nimiqEntries {
+				ne := &nimiqEntries[i]
+				if ne.Title != title || ne.Version() != semver || ne.Channel() != channel {
+					continue
+				}
+				if header, err := nimiqClient.CartridgeHeader(ne.CartridgeAddr, ne.Publisher); err == nil && hex.EncodeToString(header.SHA256[:]) == file.SHA256Hex {
+					r.AddNimiq(file.SHA256Hex, file.Size, entry.Title, entry.Platform, nimiqCopy(catalogAddr, ne))
+					return
+				}
+			}
+		}
+		statusln("💡 Record the Nimiq copy once it is confirmed with: catalogctl games scan --catalog-addr " + crosspostCatalogAddr)
+	})
+	return nil
+}
+
+// crosspostToSui publishes the cartridge of a Nimiq entry to a Sui catalog
+func crosspostToSui(client *sui.Client, nimiqClient *nimiq.Client, catalogID, catalogAddr, channel, workDir string) error {
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+	if crosspostAppID == 0 {
+		return fmt.Errorf("--app-id is required with --from nimiq")
+	}
+	nimiqEntries, err := nimiqClient.Catalog(catalogAddr, crosspostPublisher)
+	if err != nil {
+		return err
+	}
+
+	// Entries are newest first; the first match is the latest version
+	var entry *nimiq.Entry
+	publishers := make(map[string]bool)
+	for i := range nimiqEntries {
+		ne := &nimiqEntries[i]
+		if ne.AppID != crosspostAppID {
+			continue
+		}
+		publishers[nimiq.NormalizeAddress(ne.Publisher)] = true
+		if entry != nil || ne.Channel() != channel || ne.Retired() {
+			continue
+		}
+		if crosspostSemver == "" || ne.Version() == crosspostSemver {
+			entry = ne
+		}
+	}
+	if len(publishers) > 1 {
+		return fmt.Errorf("app %d is published by %d senders; choose one with --publisher", crosspostAppID, len(publishers))
+	}
+	if entry == nil {
+		return fmt.Errorf("no %s entry of app %d found in Nimiq catalog %s", channel, crosspostAppID, nimiq.FormatAddress(catalogAddr))
+	}
+
+	// Map the metadata
+	version := crosspostVersion
+	if version == 0 {
+		if entry.Semver[1] != 0 || entry.Semver[2] != 0 || entry.Semver[0] == 0 {
+			return fmt.Errorf("semver %s has no Sui version equivalent; set --version", entry.Version())
+		}
+		version = uint16(entry.Semver[0])
+	}
+	title := crosspostTitle
+	if title == "" {
+		title = entry.Title
+	}
+	platform := model.Platform(entry.Platform)
+	key := model.ChannelKey(crosspostSlug, channel)
+
+	fmt.Fprintf(stdout, "Nimiq app %d %q v%s (cartridge %s, publisher %s)\n", entry.AppID, entry.Title, entry.Version(), entry.CartridgeAddr, entry.Publisher)
+	fmt.Fprintf(stdout, "  → Sui catalog %s: %s, title %q, version %d, platform %s\n", catalogID, key, title, version, platform)
+
+	// Skip if the Sui entry already holds the same file
+	header, err := nimiqClient.CartridgeHeader(entry.CartridgeAddr, entry.Publisher)
+	if err != nil {
+		return err
+	}
+	sha256Hex := hex.EncodeToString(header.SHA256[:])
+	result := map[string]interface{}{
+		"from": chainNimiq, "to": chainSui, "status": "dry_run",
+		"nimiq_catalog": nimiq.FormatAddress(catalogAddr), "nimiq_app_id": entry.AppID, "nimiq_cartridge": entry.CartridgeAddr,
+		"sui_catalog_id": catalogID, "sui_key": key, "title": title, "version": version, "sha256": sha256Hex,
+	}
+	setResult(result)
+	if cartridgeID, err := entryCartridgeID(client, catalogID, key); err == nil && cartridgeID != "" {
+		if f, err := fetchCartridgeFile(client, cartridgeID); err == nil && f.SHA256Hex == sha256Hex {
+			result["status"], result["sui_cartridge_id"] = "exists", cartridgeID
+			statusf("✓ Already on Sui: %s (cartridge %s)\n", key, cartridgeID)
+			recordGames(func(r *identity.Registry) {
+				r.AddNimiq(sha256Hex, header.TotalSize, entry.Title, platform, nimiqCopy(catalogAddr, entry))
+				r.AddSui(sha256Hex, header.TotalSize, title, platform, suiCopy(catalogID, key, version, cartridgeID, f.BlobID))
+			})
+			return nil
+		}
+	}
+	if crosspostDryRun {
+		fmt.Fprintln(stdout, "Dry run: nothing published")
+		return nil
+	}
+	if cfg.ApprovalRequired() {
+		return fmt.Errorf("crosspost can't publish while mainnet approvals are required; publish the game with publish-game")
+	}
+
+	data, _, err := nimiqClient.ReadCartridge(entry.CartridgeAddr, entry.Publisher)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(workDir, crosspostSlug+".bin")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("failed to write game file: %w", err)
+	}
+	statusf("✓ Read and verified %d bytes from Nimiq\n", len(data))
+
+	capID, err := resolveCuratorCap(catalogID, crosspostCapID)
+	if err != nil {
+		return err
+	}
+	params := publishGameParams{
+		FilePath:  file,
+		Size:      int64(len(data)),
+		SHA256Hex: sha256Hex,
+		Slug:      crosspostSlug,
+		Title:     title,
+		Platform:  platform,
+		Emulator:  model.EmulatorCoreForPlatform(platform),
+		Version:   version,
+		Epochs:    crosspostEpochs,
+		CatalogID: catalogID,
+		CapID:     capID,
+		Channel:   channel,
+	}
+	pl := buildPublishGamePlan(params)
+	journal := filepath.Join(workDir, fmt.Sprintf("publish-%s-v%d.journal.json", crosspostSlug, version))
+	prog, err := plan.LoadProgress(journal, pl)
+	if err != nil {
+		return err
+	}
+	if err := executePlan(pl, prog, journal); err != nil {
+		return err
+	}
+	result["status"], result["sui_cartridge_id"] = "crossposted", prog.Outputs["cartridge_id"]
+	statusf("✓ Crossposted app %d to Sui: %s (cartridge %s)\n", entry.AppID, key, prog.Outputs["cartridge_id"])
+	recordGames(func(r *identity.Registry) {
+		r.AddNimiq(sha256Hex, header.TotalSize, entry.Title, platform, nimiqCopy(catalogAddr, entry))
+		r.AddSui(sha256Hex, header.TotalSize, title, platform, suiCopy(catalogID, key, version, prog.Outputs["cartridge_id"], prog.Outputs["blob_id"]))
+	})
+	return nil
+}
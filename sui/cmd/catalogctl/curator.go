@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Curator Commands
+// ============================================================================
+
+var curatorCmd = &cobra.Command{
+	Use:   "curator",
+	Short: "Manage curator capabilities of a shared catalog",
+	Long: `Manage CuratorCap objects, which let addresses other than the catalog owner
+add and remove entries. The owner mints caps, holders can transfer them, and
+the owner can revoke them at any time.
+
+add-entry, remove-entry and publish-game automatically use a CuratorCap owned
+by the active address when it isn't the catalog owner.`,
+}
+
+var curatorMintCmd = &cobra.Command{
+	Use:   "mint",
+	Short: "Mint a CuratorCap and send it to an address (owner only)",
+	RunE:  runCuratorMint,
+}
+
+var curatorTransferCmd = &cobra.Command{
+	Use:   "transfer",
+	Short: "Transfer a CuratorCap you hold to another address",
+	RunE:  runCuratorTransfer,
+}
+
+var curatorRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke a CuratorCap (owner only)",
+	RunE:  runCuratorRevoke,
+}
+
+var curatorListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List CuratorCaps owned by an address",
+	RunE:  runCuratorList,
+}
+
+var (
+	curatorCatalogID string
+	curatorRecipient string
+	curatorCapID     string
+	curatorOwner     string
+)
+
+func init() {
+	curatorMintCmd.Flags().StringVar(&curatorCatalogID, "catalog", "", "Catalog object ID or alias (uses config.catalog_id if not set)")
+	validateAs(curatorMintCmd.Flags(), "catalog", formatCatalogRef)
+	curatorMintCmd.Flags().StringVar(&curatorRecipient, "recipient", "", "Address to receive the cap (required)")
+	curatorMintCmd.MarkFlagRequired("recipient")
+
+	curatorTransferCmd.Flags().StringVar(&curatorCapID, "cap", "", "CuratorCap object ID (required)")
+	curatorTransferCmd.Flags().StringVar(&curatorRecipient, "recipient", "", "Address to receive the cap (required)")
+	curatorTransferCmd.MarkFlagRequired("cap")
+	curatorTransferCmd.MarkFlagRequired("recipient")
+
+	curatorRevokeCmd.Flags().StringVar(&curatorCatalogID, "catalog", "", "Catalog object ID or alias (uses config.catalog_id if not set)")
+	validateAs(curatorRevokeCmd.Flags(), "catalog", formatCatalogRef)
+	curatorRevokeCmd.Flags().StringVar(&curatorCapID, "cap", "", "CuratorCap object ID to revoke (required)")
+	curatorRevokeCmd.MarkFlagRequired("cap")
+
+	curatorListCmd.Flags().StringVar(&curatorOwner, "owner", "", "Address to list caps for (default: active sui address)")
+
+	curatorCmd.AddCommand(curatorMintCmd, curatorTransferCmd, curatorRevokeCmd, curatorListCmd)
+	reportsResult(curatorMintCmd, curatorTransferCmd, curatorRevokeCmd, curatorListCmd)
+	rootCmd.AddCommand(curatorCmd)
+}
+
+func runCuratorMint(cmd *cobra.Command, args []string) error {
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+	catalogID := curatorCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
+
+	statusf("Minting CuratorCap for catalog %s to %s...\n", catalogID, curatorRecipient)
+
+	output, err := executeMoveCall(sui.MintCuratorCap(cfg.PackageID, catalogID, curatorRecipient))
+	if err != nil {
+		return fmt.Errorf("failed to mint curator cap: %w", err)
+	}
+
+	statusf("\n✓ CuratorCap minted!\n")
+	capID := sui.CreatedObjectID(output, "CuratorCap")
+	if capID != "" {
+		fmt.Fprintf(stdout, "Cap ID: %s\n", capID)
+		printExplorerLink("  ", config.LinkObject, capID)
+	}
+	digest := sui.TransactionDigest(output)
+	setResult(map[string]string{"catalog_id": catalogID, "cap_id": capID, "recipient": curatorRecipient, "digest": digest})
+	fmt.Fprintf(stdout, "Transaction: %s\n", digest)
+	printExplorerLink("  ", config.LinkTx, digest)
+	return nil
+}
+
+func runCuratorTransfer(cmd *cobra.Command, args []string) error {
+	statusf("Transferring CuratorCap %s to %s...\n", curatorCapID, curatorRecipient)
+
+	output, err := executeTransfer(curatorCapID, curatorRecipient)
+	if err != nil {
+		return fmt.Errorf("failed to transfer curator cap: %w", err)
+	}
+
+	statusf("\n✓ CuratorCap transferred!\n")
+	digest := sui.TransactionDigest(output)
+	setResult(map[string]string{"cap_id": curatorCapID, "recipient": curatorRecipient, "digest": digest})
+	fmt.Fprintf(stdout, "Transaction: %s\n", digest)
+	printExplorerLink("  ", config.LinkTx, digest)
+	return nil
+}
+
+func runCuratorRevoke(cmd *cobra.Command, args []string) error {
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+	catalogID := curatorCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
+
+	statusf("Revoking CuratorCap %s for catalog %s...\n", curatorCapID, catalogID)
+
+	output, err := executeMoveCall(sui.RevokeCuratorCap(cfg.PackageID, catalogID, curatorCapID))
+	if err != nil {
+		return fmt.Errorf("failed to revoke curator cap: %w", err)
+	}
+
+	statusf("\n✓ CuratorCap revoked!\n")
+	digest := sui.TransactionDigest(output)
+	setResult(map[string]string{"catalog_id": catalogID, "cap_id": curatorCapID, "digest": digest})
+	fmt.Fprintf(stdout, "Transaction: %s\n", digest)
+	printExplorerLink("  ", config.LinkTx, digest)
+	return nil
+}
+
+func runCuratorList(cmd *cobra.Command, args []string) error {
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+
+	owner := curatorOwner
+	if owner == "" {
+		var err error
+		if owner, err = activeAddress(); err != nil {
+			return err
+		}
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	caps, err := client.GetOwnedObjects(owner, sui.CuratorCapType(cfg.TypePackageID()))
+	if err != nil {
+		return fmt.Errorf("failed to list curator caps: %w", err)
+	}
+
+	type capStatus struct {
+		CapID     string `json:"cap_id"`
+		CatalogID string `json:"catalog_id"`
+		Status    string `json:"status"`
+	}
+	result := []capStatus{}
+	defer func() { setResult(map[string]interface{}{"owner": owner, "caps": result}) }()
+	if len(caps) == 0 {
+		fmt.Fprintf(stdout, "No CuratorCaps owned by %s\n", owner)
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "CuratorCaps owned by %s:\n\n", owner)
+	fmt.Fprintf(stdout, "%-66s  %-66s  %s\n", "CAP ID", "CATALOG ID", "STATUS")
+	fmt.Fprintln(stdout, strings.Repeat("-", 142))
+	active := map[string]map[string]bool{}
+	for _, c := range caps {
+		catalogID := sui.CuratorCapCatalogID(&c)
+		if _, ok := active[catalogID]; !ok {
+			active[catalogID], _ = client.ActiveCuratorCaps(cfg.TypePackageID(), catalogID)
+		}
+		status := "active"
+		if !active[catalogID][c.ObjectID] {
+			status = "revoked"
+		}
+		fmt.Fprintf(stdout, "%-66s  %-66s  %s\n", c.ObjectID, catalogID, status)
+		result = append(result, capStatus{c.ObjectID, catalogID, status})
+	}
+	return nil
+}
+
+// activeAddress returns the address the sui CLI signs with
+func activeAddress() (string, error) {
+	output, err := executeSuiCommand([]string{"client", "active-address"})
+	if err != nil {
+		return "", fmt.Errorf("failed to get active address: %w", err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// resolveCuratorCap decides how the active address may modify a catalog.
+// It returns "" if the address owns the catalog, otherwise the ID of an
+// active CuratorCap it holds for the catalog. A non-empty explicit cap is
+// returned as-is. It fails if catalogID isn't a shared object.
+func resolveCuratorCap(catalogID, explicitCap string) (string, error) {
+	client := sui.NewClient(cfg.SuiRPCURL)
+	owner, err := client.CatalogOwner(catalogID)
+	if err != nil {
+		return "", err
+	}
+	if explicitCap != "" {
+		return explicitCap, nil
+	}
+
+	signer, err := txSigner()
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(owner, signer) {
+		return "", nil
+	}
+
+	capID, revoked, err := client.CuratorCap(cfg.TypePackageID(), catalogID, signer)
+	if err != nil {
+		return "", err
+	}
+	if capID != "" {
+		statusf("Using CuratorCap %s (signer %s is not the catalog owner)\n", capID, signer)
+		return capID, nil
+	}
+	if revoked > 0 {
+		return "", fmt.Errorf("all CuratorCaps of %s for this catalog were revoked; ask the owner (%s) for a new one", signer, owner)
+	}
+	return "", fmt.Errorf("%s is neither the catalog owner (%s) nor holds a CuratorCap for it; ask the owner to run: catalogctl curator mint --recipient %s", signer, owner, signer)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/delta"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+)
+
+// ============================================================================
+// Delta updates
+// ============================================================================
+
+// maxDeltaChain bounds how many patches are applied to rebuild one file
+const maxDeltaChain = 32
+
+// deltaParam describes the patch a delta update uploads instead of the file
+type deltaParam struct {
+	BaseCartridgeID string
+	PatchPath       string
+	PatchSize       int64
+	PatchSHA256Hex  string
+}
+
+// entryCartridgeID returns the cartridge a catalog entry points to
+func entryCartridgeID(client *sui.Client, catalogID, key string) (string, error) {
+	fieldObj, err := client.GetDynamicFieldObject(catalogID, sui.DynamicFieldName{Type: "0x1::string::String", Value: key})
+	if err != nil {
+		return "", fmt.Errorf("failed to get entry: %w", err)
+	}
+	entryFields := sui.ParseCatalogEntry(fieldObj.Data)
+	if entryFields == nil {
+		return "", fmt.Errorf("entry %s not found in catalog %s", key, catalogID)
+	}
+	cartridgeID, _ := entryFields["cartridge_id"].(string)
+	return cartridgeID, nil
+}
+
+// fetchCartridgeDelta reads the delta of a cartridge; nil means the
+// cartridge's blob is the complete file
+func fetchCartridgeDelta(client *sui.Client, cartridgeID string) (*model.Delta, error) {
+	dynamicFields, err := client.GetAllDynamicFields(cartridgeID, 50)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cartridge fields: %w", err)
+	}
+	for _, field := range dynamicFields {
+		if !strings.HasSuffix(field.Name.Type, "::cartridge::DeltaKey") {
+			continue
+		}
+		fieldObj, err := client.GetDynamicFieldObject(cartridgeID, field.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get delta: %w", err)
+		}
+		deltaFields := sui.ParseCatalogEntry(fieldObj.Data)
+		if deltaFields == nil {
+			break
+		}
+		d := &model.Delta{
+			PatchSHA256:    sui.BytesArrayToHex(deltaFields["patch_sha256"]),
+			PatchSizeBytes: parseU64(deltaFields["patch_size_bytes"]),
+		}
+		d.BaseCartridgeID, _ = deltaFields["base_cartridge"].(string)
+		d.Format, _ = deltaFields["format"].(string)
+		return d, nil
+	}
+	return nil, nil
+}
+
+// fetchGameFile returns the game file of a cartridge, verified against its
+// SHA256. Delta updates are rebuilt by patching their base, recursively. If
+// haveFile holds the file of a cartridge on the way, it is read instead of
+// downloading that cartridge.
+func fetchGameFile(client *sui.Client, cartridgeID, haveFile string, depth int) ([]byte, error) {
+	if depth > maxDeltaChain {
+		return nil, fmt.Errorf("delta chain is longer than %d cartridges", maxDeltaChain)
+	}
+
+	resp, err := client.GetObject(cartridgeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cartridge: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("cartridge %s not found", cartridgeID)
+	}
+	fields := sui.ParseCatalog(resp.Data)
+	expectedSHA := sui.BytesArrayToHex(fields["sha256"])
+	expectedSize := parseU64(fields["size_bytes"])
+
+	if haveFile != "" {
+		if haveSHA, _, err := fileSHA256(haveFile); err == nil && haveSHA == expectedSHA {
+			fmt.Fprintf(stdout, "  Using %s for cartridge %s\n", haveFile, cartridgeID)
+			return os.ReadFile(haveFile)
+		}
+	}
+
+	blobIDBytes, err := hex.DecodeString(sui.BytesArrayToHex(fields["blob_id"]))
+	if err != nil || len(blobIDBytes) == 0 {
+		return nil, fmt.Errorf("cartridge %s has no valid blob ID", cartridgeID)
+	}
+	blobID := base58.Encode(blobIDBytes)
+
+	d, err := fetchCartridgeDelta(client, cartridgeID)
+	if err != nil {
+		return nil, err
+	}
+
+	statusf("Downloading blob %s...\n", blobID)
+	data, err := readBlob(blobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+
+	if d != nil {
+		if d.Format != delta.Format {
+			return nil, fmt.Errorf("cartridge %s uses unsupported patch format %q", cartridgeID, d.Format)
+		}
+		patchHash := sha256.Sum256(data)
+		if hex.EncodeToString(patchHash[:]) != d.PatchSHA256 {
+			return nil, fmt.Errorf("patch blob %s doesn't match its SHA256 %s", blobID, d.PatchSHA256)
+		}
+		fmt.Fprintf(stdout, "  Patch of %d bytes against cartridge %s\n", len(data), d.BaseCartridgeID)
+
+		base, err := fetchGameFile(client, d.BaseCartridgeID, haveFile, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get base of %s: %w", cartridgeID, err)
+		}
+		if data, err = delta.Apply(base, data); err != nil {
+			return nil, fmt.Errorf("failed to apply patch of %s: %w", cartridgeID, err)
+		}
+	}
+
+	hash := sha256.Sum256(data)
+	sha256Hex := hex.EncodeToString(hash[:])
+	if uint64(len(data)) != expectedSize || sha256Hex != expectedSHA {
+		return nil, fmt.Errorf("file of cartridge %s doesn't match the chain (got %d bytes, SHA256 %s; expected %d bytes, SHA256 %s)",
+			cartridgeID, len(data), sha256Hex, expectedSize, expectedSHA)
+	}
+	return data, nil
+}
+
+// prepareDelta diffs filePath against the game file of baseID and writes the
+// patch next to it. It returns nil when the patch wouldn't save much, in
+// which case the full file is uploaded.
+func prepareDelta(client *sui.Client, baseID, baseFile, filePath string, size int64) (*deltaParam, error) {
+	if err := validate.ObjectID(baseID); err != nil {
+		return nil, fmt.Errorf("invalid base cartridge ID %s: %w", baseID, err)
+	}
+
+	statusf("Computing patch against cartridge %s...\n", baseID)
+	base, err := fetchGameFile(client, baseID, baseFile, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get base version: %w", err)
+	}
+	target, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	patch, err := delta.Diff(base, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute patch: %w", err)
+	}
+
+	// A patch of most of the file isn't worth a dependency on the base
+	if int64(len(patch)) >= size*9/10 {
+		statusf("⚠️  Patch is %d bytes for a %d-byte file; uploading the full file instead\n\n", len(patch), size)
+		return nil, nil
+	}
+
+	patchPath := fmt.Sprintf("%s.%s.%s", filePath, baseID[2:10], delta.Format)
+	if err := os.WriteFile(patchPath, patch, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write patch: %w", err)
+	}
+	hash := sha256.Sum256(patch)
+	statusf("✓ Patch: %d bytes (%.1f%% of %d bytes), written to %s\n\n", len(patch), float64(len(patch))*100/float64(size), size, patchPath)
+
+	return &deltaParam{
+		BaseCartridgeID: baseID,
+		PatchPath:       patchPath,
+		PatchSize:       int64(len(patch)),
+		PatchSHA256Hex:  hex.EncodeToString(hash[:]),
+	}, nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Package Deployment Commands
+// ============================================================================
+
+// publishGasBudget covers publishing or upgrading the cartridge_storage package
+const publishGasBudget = 500000000
+
+var deployPackageCmd = &cobra.Command{
+	Use:   "deploy-package",
+	Short: "Build and publish the Move package with the active sui address",
+	Long: `Builds and publishes the cartridge_storage Move package with 'sui client publish'
+and records the new package ID and its UpgradeCap in the config file, so the
+package can later be upgraded with upgrade-package.
+
+Example:
+  catalogctl deploy-package --path ./contracts`,
+	RunE: runDeployPackage,
+}
+
+var upgradePackageCmd = &cobra.Command{
+	Use:   "upgrade-package",
+	Short: "Upgrade the deployed Move package using its UpgradeCap",
+	Long: `Upgrades the package with 'sui client upgrade' using the UpgradeCap recorded by
+deploy-package (or --upgrade-cap). The config then points package_id at the new
+version and keeps the first version in original_package_id, which Move types
+and events are still named after.
+
+Example:
+  catalogctl upgrade-package --path ./contracts`,
+	RunE: runUpgradePackage,
+}
+
+var (
+	deployPath       string
+	deployGasBudget  int
+	deploySave       bool
+	deployUpgradeCap string
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{deployPackageCmd, upgradePackageCmd} {
+		cmd.Flags().StringVar(&deployPath, "path", "contracts", "Path to the Move package")
+		cmd.Flags().IntVar(&deployGasBudget, "gas-budget", publishGasBudget, "Gas budget in MIST")
+		cmd.Flags().BoolVar(&deploySave, "save-config", true, "Record the package ID and UpgradeCap in the active config file")
+	}
+	upgradePackageCmd.Flags().StringVar(&deployUpgradeCap, "upgrade-cap", "", "UpgradeCap object ID (default: upgrade_cap_id from config)")
+
+	rootCmd.AddCommand(deployPackageCmd, upgradePackageCmd)
+	reportsResult(deployPackageCmd, upgradePackageCmd)
+}
+
+func runDeployPackage(cmd *cobra.Command, args []string) error {
+	if memoryChain != nil {
+		return fmt.Errorf("the memory backend has its package preinstalled; nothing to deploy")
+	}
+
+	statusf("Publishing %s...\n", deployPath)
+	published, err := publishMovePackage(deployPath, deployGasBudget)
+	if err != nil {
+		return err
+	}
+	printPublishedPackage("Package published", published)
+
+	newGHSummary("Deployed Move package").
+		row("Package", mdLink(published.PackageID, explorerURL(config.LinkObject, published.PackageID))).
+		row("UpgradeCap", published.UpgradeCap).
+		row("Transaction", mdLink(published.Digest, explorerURL(config.LinkTx, published.Digest))).
+		row("Gas used", formatSUI(published.GasCostMist)).
+		output("package_id", published.PackageID).
+		output("upgrade_cap_id", published.UpgradeCap).
+		output("digest", published.Digest).
+		write()
+
+	if !deploySave {
+		if !quiet() {
+			statusf("\n💡 Tip: Record it in the config with:\n")
+			fmt.Fprintf(stdout, "  catalogctl config set package_id %s\n", published.PackageID)
+			fmt.Fprintf(stdout, "  catalogctl config set upgrade_cap_id %s\n", published.UpgradeCap)
+		}
+		return nil
+	}
+	if err := saveConfigValue("package_id", published.PackageID); err != nil {
+		return err
+	}
+	if err := saveConfigValue("upgrade_cap_id", published.UpgradeCap); err != nil {
+		return err
+	}
+	// A fresh package has no earlier version
+	if cfg.OriginalPackageID != "" {
+		return saveConfigValue("original_package_id", "")
+	}
+	return nil
+}
+
+func runUpgradePackage(cmd *cobra.Command, args []string) error {
+	if memoryChain != nil {
+		return fmt.Errorf("the memory backend doesn't support package upgrades")
+	}
+	if deployUpgradeCap == "" {
+		deployUpgradeCap = cfg.UpgradeCapID
+	}
+	if deployUpgradeCap == "" {
+		return fmt.Errorf("no UpgradeCap: set --upgrade-cap or upgrade_cap_id in config (deploy-package records it)")
+	}
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+	original := cfg.TypePackageID()
+
+	statusf("Upgrading %s from %s...\n", cfg.PackageID, deployPath)
+	output, err := executeSuiCommand([]string{
+		"client", "upgrade", deployPath,
+		"--upgrade-capability", deployUpgradeCap,
+		"--gas-budget", fmt.Sprintf("%d", deployGasBudget),
+		"--json",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upgrade package: %w", err)
+	}
+	upgraded := &publishedPackage{
+		PackageID:   extractPackageID(output),
+		UpgradeCap:  deployUpgradeCap,
+		Digest:      sui.TransactionDigest(output),
+		GasCostMist: sui.TransactionGasCost(output),
+	}
+	if upgraded.PackageID == "" {
+		return fmt.Errorf("failed to extract package ID from transaction %s", upgraded.Digest)
+	}
+	printPublishedPackage("Package upgraded", upgraded)
+	fmt.Fprintf(stdout, "Original package: %s\n", original)
+
+	newGHSummary("Upgraded Move package").
+		row("Package", mdLink(upgraded.PackageID, explorerURL(config.LinkObject, upgraded.PackageID))).
+		row("Original package", original).
+		row("Transaction", mdLink(upgraded.Digest, explorerURL(config.LinkTx, upgraded.Digest))).
+		row("Gas used", formatSUI(upgraded.GasCostMist)).
+		output("package_id", upgraded.PackageID).
+		output("original_package_id", original).
+		output("digest", upgraded.Digest).
+		write()
+
+	if !deploySave {
+		if !quiet() {
+			statusf("\n💡 Tip: Record it in the config with:\n")
+			fmt.Fprintf(stdout, "  catalogctl config set package_id %s\n", upgraded.PackageID)
+			fmt.Fprintf(stdout, "  catalogctl config set original_package_id %s\n", original)
+		}
+		return nil
+	}
+	if err := saveConfigValue("package_id", upgraded.PackageID); err != nil {
+		return err
+	}
+	if err := saveConfigValue("original_package_id", original); err != nil {
+		return err
+	}
+	if cfg.UpgradeCapID != deployUpgradeCap {
+		return saveConfigValue("upgrade_cap_id", deployUpgradeCap)
+	}
+	return nil
+}
+
+func printPublishedPackage(title string, p *publishedPackage) {
+	statusf("\n✓ %s!\n", title)
+	fmt.Fprintf(stdout, "Package ID: %s\n", p.PackageID)
+	printExplorerLink("  ", config.LinkObject, p.PackageID)
+	fmt.Fprintf(stdout, "UpgradeCap: %s\n", p.UpgradeCap)
+	fmt.Fprintf(stdout, "Transaction: %s\n", p.Digest)
+	printExplorerLink("  ", config.LinkTx, p.Digest)
+	fmt.Fprintf(stdout, "Gas used: %s\n", formatSUI(p.GasCostMist))
+}
+
+// publishedPackage is what `sui client publish` created
+type publishedPackage struct {
+	PackageID   string
+	UpgradeCap  string
+	Digest      string
+	GasCostMist int64
+}
+
+// publishMovePackage builds and publishes the Move package at path with the
+// active sui address
+func publishMovePackage(path string, gasBudget int) (*publishedPackage, error) {
+	output, err := executeSuiCommand([]string{
+		"client", "publish", path,
+		"--gas-budget", fmt.Sprintf("%d", gasBudget),
+		"--json",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish package: %w", err)
+	}
+
+	published := &publishedPackage{
+		PackageID:   extractPackageID(output),
+		UpgradeCap:  sui.CreatedObjectID(output, "::package::UpgradeCap"),
+		Digest:      sui.TransactionDigest(output),
+		GasCostMist: sui.TransactionGasCost(output),
+	}
+	if published.PackageID == "" {
+		return nil, fmt.Errorf("failed to extract package ID from transaction %s", published.Digest)
+	}
+	return published, nil
+}
+
+// extractPackageID returns the packageId of the "published" object change
+func extractPackageID(jsonOutput string) string {
+	var result struct {
+		ObjectChanges []struct {
+			Type      string `json:"type"`
+			PackageID string `json:"packageId"`
+		} `json:"objectChanges"`
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &result); err != nil {
+		return ""
+	}
+	for _, change := range result.ObjectChanges {
+		if change.Type == "published" {
+			return change.PackageID
+		}
+	}
+	return ""
+}
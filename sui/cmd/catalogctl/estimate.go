@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/plan"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/walrus"
+)
+
+// ============================================================================
+// Cost estimation (publish-game --estimate)
+// ============================================================================
+
+// placeholderBlobID stands in for blob IDs in the dry-run transaction, since
+// nothing is uploaded before the estimate
+var placeholderBlobID = strings.Repeat("00", 32)
+
+// publishCost is the expected cost of a publish. Either part is missing when
+// it couldn't be estimated; Warnings says why.
+type publishCost struct {
+	Walrus *walrusCost `json:"walrus,omitempty"`
+	Gas    *gasCost    `json:"gas,omitempty"`
+	// MaxGasMist is the sum of the gas budgets of the plan's Move calls
+	MaxGasMist uint64   `json:"max_gas_mist"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// walrusCost is the storage cost of the plan's blobs, in FROST
+type walrusCost struct {
+	SystemObject string        `json:"system_object"`
+	Prices       walrus.Prices `json:"prices"`
+	Epochs       int           `json:"epochs"`
+	Blobs        []blobCost    `json:"blobs"`
+	StorageFrost uint64        `json:"storage_frost"`
+	WriteFrost   uint64        `json:"write_frost"`
+	TotalFrost   uint64        `json:"total_frost"`
+}
+
+// blobCost is the storage cost of one walrus_store step
+type blobCost struct {
+	Description  string `json:"description"`
+	Size         int64  `json:"size"`
+	EncodedSize  int64  `json:"encoded_size"`
+	Units        uint64 `json:"units"`
+	StorageFrost uint64 `json:"storage_frost"`
+	WriteFrost   uint64 `json:"write_frost"`
+}
+
+// gasCost is the gas of the dry-run publish transaction, in MIST
+type gasCost struct {
+	ComputationMist int64 `json:"computation_mist"`
+	StorageMist     int64 `json:"storage_mist"`
+	RebateMist      int64 `json:"rebate_mist"`
+	NetMist         int64 `json:"net_mist"`
+}
+
+// estimatePublishCost prices the plan's Walrus uploads with the current
+// storage prices and dry-runs its Sui calls, without uploading or sending
+// anything
+func estimatePublishCost(pl *plan.Plan, p publishGameParams) *publishCost {
+	cost := &publishCost{MaxGasMist: pl.Estimate.MaxGasMist}
+	client := sui.NewClient(cfg.SuiRPCURL)
+
+	systemID, prices, err := readWalrusPrices(client)
+	if err != nil {
+		cost.Warnings = append(cost.Warnings, fmt.Sprintf("Walrus storage cost not estimated: %v", err))
+	} else {
+		wc := &walrusCost{SystemObject: systemID, Prices: *prices}
+		for _, op := range pl.Operations {
+			if op.Type != plan.OpWalrusStore {
+				continue
+			}
+			encoded := walrus.EncodedLength(op.PayloadSize, prices.NShards)
+			storage, write := prices.Cost(op.PayloadSize, op.Epochs)
+			wc.Epochs = op.Epochs
+			wc.Blobs = append(wc.Blobs, blobCost{
+				Description:  op.Description,
+				Size:         op.PayloadSize,
+				EncodedSize:  encoded,
+				Units:        walrus.StorageUnits(encoded),
+				StorageFrost: storage,
+				WriteFrost:   write,
+			})
+			wc.StorageFrost += storage
+			wc.WriteFrost += write
+		}
+		wc.TotalFrost = wc.StorageFrost + wc.WriteFrost
+		cost.Walrus = wc
+	}
+
+	gas, err := dryRunPublish(client, pl, p)
+	if err != nil {
+		cost.Warnings = append(cost.Warnings, fmt.Sprintf("Sui gas not estimated: %v", err))
+	} else {
+		cost.Gas = gas
+	}
+	return cost
+}
+
+// readWalrusPrices reads the storage prices from the Walrus system object
+// (walrus_system_object, or the known object of walrus_network). The prices
+// live in the system state, a dynamic field of the system object.
+func readWalrusPrices(client *sui.Client) (string, *walrus.Prices, error) {
+	systemID := cfg.WalrusSystemObject
+	if systemID == "" {
+		systemID = walrus.SystemObjects[cfg.WalrusNetwork]
+	}
+	if systemID == "" {
+		return "", nil, fmt.Errorf("no Walrus system object is known for %s; set walrus_system_object in config", cfg.WalrusNetwork)
+	}
+
+	fields, err := client.GetDynamicFields(systemID, nil, 10)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read Walrus system object %s: %w", systemID, err)
+	}
+	for _, field := range fields.Data {
+		if !strings.Contains(field.ObjectType, "SystemStateInner") {
+			continue
+		}
+		resp, err := client.GetObject(field.ObjectID)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read Walrus system state: %w", err)
+		}
+		if resp.Data == nil {
+			break
+		}
+		// Field<u64, SystemStateInnerV1>: the state is the field's value
+		content, _ := resp.Data.Content["fields"].(map[string]interface{})
+		value, _ := content["value"].(map[string]interface{})
+		state, _ := value["fields"].(map[string]interface{})
+		if state == nil {
+			break
+		}
+		prices, err := walrus.ParsePrices(state)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read Walrus prices: %w", err)
+		}
+		return systemID, prices, nil
+	}
+	return "", nil, fmt.Errorf("%s has no Walrus system state (check walrus_system_object)", systemID)
+}
+
+// dryRunPublish builds the publish as the single transaction --unsigned-out
+// produces, with placeholder blob IDs, and dry-runs it. Executed step by
+// step, the plan's Move calls cost about the same computation plus a little
+// per extra transaction.
+func dryRunPublish(client *sui.Client, pl *plan.Plan, p publishGameParams) (*gasCost, error) {
+	if memoryChain != nil {
+		return nil, fmt.Errorf("the memory backend doesn't simulate transactions")
+	}
+	sender, err := txSigner()
+	if err != nil {
+		return nil, err
+	}
+	blobArg, err := ptbBytes(placeholderBlobID)
+	if err != nil {
+		return nil, err
+	}
+	var blobArgs []string
+	for _, op := range pl.Operations {
+		if op.Type == plan.OpWalrusStore {
+			blobArgs = append(blobArgs, blobArg)
+		}
+	}
+	tx, err := publishPTB(p, blobArgs, sender)
+	if err != nil {
+		return nil, err
+	}
+	txBytes, err := tx.serialize(sender)
+	if err != nil {
+		return nil, err
+	}
+	dry, err := client.DryRunTransactionBlock(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dry-run the publish transaction: %w", err)
+	}
+	if dry.Status != "success" {
+		return nil, fmt.Errorf("the publish transaction would fail: %s", dry.Error)
+	}
+	return &gasCost{
+		ComputationMist: dry.GasUsed.ComputationCost,
+		StorageMist:     dry.GasUsed.StorageCost,
+		RebateMist:      dry.GasUsed.StorageRebate,
+		NetMist:         dry.GasUsed.Net(),
+	}, nil
+}
+
+// printPublishCost prints the cost breakdown of --estimate
+func printPublishCost(cost *publishCost) {
+	fmt.Fprintln(stdout, "\nCost estimate:")
+	if wc := cost.Walrus; wc != nil {
+		fmt.Fprintf(stdout, "  Walrus storage (epoch %d, %d shards, %d epochs):\n", wc.Prices.Epoch, wc.Prices.NShards, wc.Epochs)
+		for _, blob := range wc.Blobs {
+			fmt.Fprintf(stdout, "    %s: %s → %s encoded (%d units): %s storage + %s write\n",
+				blob.Description, formatBytes(blob.Size), formatBytes(blob.EncodedSize), blob.Units,
+				formatWAL(blob.StorageFrost), formatWAL(blob.WriteFrost))
+		}
+		fmt.Fprintf(stdout, "    Total: %s\n", formatWAL(wc.TotalFrost))
+	}
+	if gas := cost.Gas; gas != nil {
+		fmt.Fprintln(stdout, "  Sui gas (dry run):")
+		fmt.Fprintf(stdout, "    Computation: %s\n", formatSUI(gas.ComputationMist))
+		fmt.Fprintf(stdout, "    Storage: %s (rebate %s)\n", formatSUI(gas.StorageMist), formatSUI(gas.RebateMist))
+		fmt.Fprintf(stdout, "    Total: %s\n", formatSUI(gas.NetMist))
+	}
+	fmt.Fprintf(stdout, "  Max gas (sum of budgets): %s\n", formatSUI(int64(cost.MaxGasMist)))
+	for _, warning := range cost.Warnings {
+		statusf("⚠️  %s\n", warning)
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import "fmt"
+
+// explorerURL links to a transaction, object or account on the configured explorer
+func explorerURL(kind, id string) string {
+	if id == "unknown" {
+		return ""
+	}
+	return cfg.ExplorerURL(kind, id)
+}
+
+// printExplorerLink prints an explorer link for a transaction or object, if any
+func printExplorerLink(indent, kind, id string) {
+	if url := explorerURL(kind, id); url != "" {
+		fmt.Fprintf(stdout, "%s🔗 %s\n", indent, url)
+	}
+}
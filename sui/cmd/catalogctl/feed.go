@@ -0,0 +1,444 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/nimiq"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+)
+
+// ============================================================================
+// serve: release feeds (/feed.rss, /feed.json)
+// ============================================================================
+
+// releaseFeed lists the newest additions and updates of the configured Sui
+// and Nimiq catalogs. Feeds are cached like the other gateway responses.
+type releaseFeed struct {
+	gateway       *gatewayServer
+	suiCatalogs   []string
+	nimiqCatalogs []string
+	nimiqRPCURL   string
+	limit         int
+}
+
+// feedRelease is the catalog entry behind a feed item
+type feedRelease struct {
+	// Chain is sui or nimiq
+	Chain   string `json:"chain"`
+	Catalog string `json:"catalog"`
+	// Key is the Sui entry slug or the Nimiq app ID
+	Key string `json:"key"`
+	// Event is added or updated
+	Event    string `json:"event"`
+	Version  string `json:"version"`
+	Platform string `json:"platform"`
+	Channel  string `json:"channel,omitempty"`
+	// Cartridge is the Sui cartridge object or the Nimiq cartridge address
+	Cartridge string `json:"cartridge"`
+	Publisher string `json:"publisher,omitempty"`
+}
+
+// feedItem is a release with the fields both feed formats share
+type feedItem struct {
+	id        string
+	title     string
+	published time.Time
+	release   feedRelease
+}
+
+// jsonFeed is returned by GET /feed.json (JSON Feed 1.1)
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+// jsonFeedItem is one release; _retro carries the catalog entry
+type jsonFeedItem struct {
+	ID            string      `json:"id"`
+	URL           string      `json:"url,omitempty"`
+	Title         string      `json:"title"`
+	ContentText   string      `json:"content_text"`
+	DatePublished *time.Time  `json:"date_published,omitempty"`
+	Tags          []string    `json:"tags"`
+	Release       feedRelease `json:"_retro"`
+}
+
+// rssFeed is returned by GET /feed.rss (RSS 2.0)
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link,omitempty"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// newReleaseFeed resolves the feed catalogs. Without any, the feeds list
+// catalog_id when the package is known, and aren't served otherwise (nil).
+func newReleaseFeed(gateway *gatewayServer, suiCatalogs, nimiqCatalogs []string, nimiqRPCURL string, limit int) (*releaseFeed, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("--feed-limit must be at least 1")
+	}
+	if len(suiCatalogs) == 0 && len(nimiqCatalogs) == 0 {
+		if cfg.CatalogID == "" || cfg.PackageID == "" {
+			return nil, nil
+		}
+		suiCatalogs = []string{cfg.CatalogID}
+	}
+	if len(suiCatalogs) > 0 && cfg.PackageID == "" {
+		return nil, fmt.Errorf("package_id is required in config file for --feed-catalog")
+	}
+
+	f := &releaseFeed{gateway: gateway, nimiqRPCURL: nimiqRPCURL, limit: limit}
+	for _, value := range suiCatalogs {
+		catalogID, err := cfg.ResolveCatalogID(value)
+		if err == nil {
+			err = validate.ObjectID(catalogID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid --feed-catalog %s: %w", value, err)
+		}
+		f.suiCatalogs = append(f.suiCatalogs, catalogID)
+	}
+	for _, value := range nimiqCatalogs {
+		catalogAddr := nimiq.ResolveCatalog(value)
+		if err := nimiq.ValidateAddress(catalogAddr); err != nil {
+			return nil, fmt.Errorf("invalid --feed-nimiq-catalog %s: %w", value, err)
+		}
+		f.nimiqCatalogs = append(f.nimiqCatalogs, nimiq.FormatAddress(catalogAddr))
+	}
+	return f, nil
+}
+
+func (f *releaseFeed) mount(mux *http.ServeMux) {
+	mux.HandleFunc("/feed.rss", f.gateway.cors(f.handleRSS))
+	mux.HandleFunc("/feed.json", f.gateway.cors(f.handleJSON))
+}
+
+func (f *releaseFeed) operations() []apiOperation {
+	return []apiOperation{
+		{
+			Method:      http.MethodGet,
+			Path:        "/feed.rss",
+			Summary:     "RSS 2.0 feed of the newest catalog additions and updates",
+			Tag:         "catalog",
+			ContentType: "application/rss+xml",
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/feed.json",
+			Summary:  "JSON Feed 1.1 of the newest catalog additions and updates",
+			Tag:      "catalog",
+			Response: jsonFeed{},
+		},
+	}
+}
+
+// catalogCount is the number of catalogs the feeds cover
+func (f *releaseFeed) catalogCount() int {
+	return len(f.suiCatalogs) + len(f.nimiqCatalogs)
+}
+
+func (f *releaseFeed) title() string {
+	return fmt.Sprintf("Retro releases (%s)", cfg.SuiNetwork)
+}
+
+func (f *releaseFeed) handleJSON(w http.ResponseWriter, r *http.Request) {
+	if !readMethod(w, r) {
+		return
+	}
+	base := requestBaseURL(r)
+	f.gateway.serveCached(w, r, "feed.json:"+base, "application/feed+json", func() ([]byte, error) {
+		items, err := f.items()
+		if err != nil {
+			return nil, err
+		}
+		feed := jsonFeed{
+			Version:     "https://jsonfeed.org/version/1.1",
+			Title:       f.title(),
+			HomePageURL: base + "/",
+			FeedURL:     base + "/feed.json",
+			Items:       []jsonFeedItem{},
+		}
+		for _, item := range items {
+			entry := jsonFeedItem{
+				ID:          item.id,
+				URL:         item.url(base),
+				Title:       item.title,
+				ContentText: item.summary(),
+				Tags:        item.tags(),
+				Release:     item.release,
+			}
+			if !item.published.IsZero() {
+				published := item.published
+				entry.DatePublished = &published
+			}
+			feed.Items = append(feed.Items, entry)
+		}
+		body, err := json.MarshalIndent(feed, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(body, '\n'), nil
+	})
+}
+
+func (f *releaseFeed) handleRSS(w http.ResponseWriter, r *http.Request) {
+	if !readMethod(w, r) {
+		return
+	}
+	base := requestBaseURL(r)
+	f.gateway.serveCached(w, r, "feed.rss:"+base, "application/rss+xml; charset=utf-8", func() ([]byte, error) {
+		items, err := f.items()
+		if err != nil {
+			return nil, err
+		}
+		channel := rssChannel{
+			Title:       f.title(),
+			Link:        base + "/",
+			Description: fmt.Sprintf("Newest games added to or updated in %d catalogs", f.catalogCount()),
+		}
+		for _, item := range items {
+			entry := rssItem{
+				Title:       item.title,
+				Link:        item.url(base),
+				Description: item.summary(),
+				GUID:        rssGUID{Value: item.id},
+				Categories:  item.tags(),
+			}
+			if !item.published.IsZero() {
+				entry.PubDate = item.published.UTC().Format(time.RFC1123Z)
+				if channel.LastBuildDate == "" {
+					channel.LastBuildDate = entry.PubDate
+				}
+			}
+			channel.Items = append(channel.Items, entry)
+		}
+		body, err := xml.MarshalIndent(rssFeed{Version: "2.0", Channel: channel}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(xml.Header), append(body, '\n')...), nil
+	})
+}
+
+// items collects the releases of every feed catalog, newest first. A chain
+// that can't be read is left out, unless nothing could be read.
+func (f *releaseFeed) items() ([]feedItem, error) {
+	var items []feedItem
+	var failed error
+	read := 0
+	if len(f.suiCatalogs) > 0 {
+		suiItems, err := f.suiReleases()
+		if err != nil {
+			warnf("feed: Sui catalogs: %v", err)
+			failed = err
+		} else {
+			items = append(items, suiItems...)
+			read++
+		}
+	}
+	for _, catalogAddr := range f.nimiqCatalogs {
+		nimiqItems, err := f.nimiqReleases(catalogAddr)
+		if err != nil {
+			warnf("feed: Nimiq catalog %s: %v", catalogAddr, err)
+			failed = err
+			continue
+		}
+		items = append(items, nimiqItems...)
+		read++
+	}
+	if read == 0 && failed != nil {
+		return nil, failed
+	}
+
+	// Releases without a block time go last
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].published.IsZero() || items[j].published.IsZero() {
+			return !items[i].published.IsZero()
+		}
+		return items[i].published.After(items[j].published)
+	})
+	if len(items) > f.limit {
+		items = items[:f.limit]
+	}
+
+	// Events only name the cartridge, so read the listed ones
+	client := sui.NewClient(cfg.SuiRPCURL)
+	for i := range items {
+		if items[i].release.Chain != chainSui {
+			continue
+		}
+		cartridge, err := fetchCartridge(client, items[i].release.Cartridge)
+		if err != nil {
+			continue
+		}
+		items[i].title = cartridge.Title
+		items[i].release.Version = fmt.Sprintf("v%d", cartridge.Version)
+		items[i].release.Platform = cartridge.Platform.String()
+	}
+	return items, nil
+}
+
+// suiReleases reads the EntryAdded and EntryUpdated events of the Sui feed
+// catalogs
+func (f *releaseFeed) suiReleases() ([]feedItem, error) {
+	wanted := make(map[string]bool)
+	for _, catalogID := range f.suiCatalogs {
+		wanted[catalogID] = true
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	var items []feedItem
+	for _, kind := range []struct{ event, field, name string }{
+		{"EntryAdded", "cartridge_id", "added"},
+		{"EntryUpdated", "new_cartridge_id", "updated"},
+	} {
+		events, err := client.QueryEvents(cfg.TypePackageID() + "::catalog::" + kind.event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s events: %w", kind.event, err)
+		}
+		for _, ev := range events {
+			catalogID, _ := ev.ParsedJSON["catalog_id"].(string)
+			if !wanted[catalogID] {
+				continue
+			}
+			slug, _ := ev.ParsedJSON["slug"].(string)
+			cartridgeID, _ := ev.ParsedJSON[kind.field].(string)
+			item := feedItem{
+				id:    "sui:" + ev.ID.TxDigest + ":" + ev.ID.EventSeq,
+				title: slug,
+				release: feedRelease{
+					Chain:     chainSui,
+					Catalog:   catalogID,
+					Key:       slug,
+					Event:     kind.name,
+					Cartridge: cartridgeID,
+				},
+			}
+			if ms, err := strconv.ParseInt(ev.TimestampMs, 10, 64); err == nil && ms > 0 {
+				item.published = time.UnixMilli(ms).UTC()
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// nimiqReleases reads the CENT entries of a Nimiq catalog. An app's oldest
+// entry added it and later ones updated it; retirements aren't releases.
+func (f *releaseFeed) nimiqReleases(catalogAddr string) ([]feedItem, error) {
+	entries, err := nimiq.NewClient(f.nimiqRPCURL).Catalog(catalogAddr, "")
+	if err != nil {
+		return nil, err
+	}
+	oldest := make(map[string]int)
+	for i, e := range entries {
+		oldest[fmt.Sprintf("%s/%d", nimiq.NormalizeAddress(e.Publisher), e.AppID)] = i
+	}
+
+	var items []feedItem
+	for i, e := range entries {
+		if e.Retired() {
+			continue
+		}
+		event := "updated"
+		if oldest[fmt.Sprintf("%s/%d", nimiq.NormalizeAddress(e.Publisher), e.AppID)] == i {
+			event = "added"
+		}
+		item := feedItem{
+			id:    "nimiq:" + e.TxHash,
+			title: e.Title,
+			release: feedRelease{
+				Chain:     chainNimiq,
+				Catalog:   catalogAddr,
+				Key:       strconv.FormatUint(uint64(e.AppID), 10),
+				Event:     event,
+				Version:   e.Version(),
+				Platform:  model.Platform(e.Platform).String(),
+				Channel:   e.Channel(),
+				Cartridge: nimiq.FormatAddress(e.CartridgeAddr),
+				Publisher: e.Publisher,
+			},
+		}
+		if e.Timestamp > 0 {
+			item.published = time.UnixMilli(e.Timestamp).UTC()
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// url links Sui releases to their cartridge on the gateway
+func (item feedItem) url(base string) string {
+	if item.release.Chain != chainSui {
+		return ""
+	}
+	return base + "/cartridges/" + item.release.Cartridge
+}
+
+// summary describes the release in one sentence
+func (item feedItem) summary() string {
+	r := item.release
+	name := strings.TrimSpace(item.title + " " + r.Version)
+	if r.Platform != "" {
+		name += " (" + r.Platform + ")"
+	}
+	event := r.Event + " in"
+	if r.Event == "added" {
+		event = "added to"
+	}
+	return fmt.Sprintf("%s %s %s catalog %s", name, event, r.Chain, r.Catalog)
+}
+
+func (item feedItem) tags() []string {
+	tags := []string{item.release.Chain}
+	if item.release.Platform != "" {
+		tags = append(tags, item.release.Platform)
+	}
+	if item.release.Channel != "" {
+		tags = append(tags, item.release.Channel)
+	}
+	return tags
+}
+
+// requestBaseURL is the URL the client reached the server under, for the
+// links in feeds
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || (serveTrustProxy && r.Header.Get("X-Forwarded-Proto") == "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/identity"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/nimiq"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// games command group (content hash registry)
+// ============================================================================
+
+var gamesCmd = &cobra.Command{
+	Use:   "games",
+	Short: "Manage the registry of where each game lives across chains",
+	Long: `The games registry records, per game file SHA256, every Sui cartridge
+(with its Walrus blob) and every Nimiq cartridge that holds the file, so the
+same release on different chains is one game. It is stored in
+~/.config/catalogctl/games.json (or --registry FILE).
+
+scan indexes Sui and Nimiq catalogs into the registry; crosspost records the
+copies it makes. export and import move registries between machines.
+
+Example:
+  catalogctl games scan --catalog main-games --catalog-addr main
+  catalogctl games list
+  catalogctl games show 3f2a9c1e`,
+}
+
+var gamesScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Index the games of a Sui catalog and/or a Nimiq catalog",
+	RunE:  runGamesScan,
+}
+
+var gamesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the games in the registry",
+	RunE:  runGamesList,
+}
+
+var gamesShowCmd = &cobra.Command{
+	Use:   "show SHA256",
+	Short: "Show where a game lives (a unique SHA256 prefix is enough)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGamesShow,
+}
+
+var gamesExportCmd = &cobra.Command{
+	Use:   "export FILE",
+	Short: "Write the registry to FILE (- for stdout)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGamesExport,
+}
+
+var gamesImportCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Merge an exported registry into the registry",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGamesImport,
+}
+
+var (
+	gamesRegistry       string
+	gamesCatalogID      string
+	gamesCatalogAddr    string
+	gamesPublisher      string
+	gamesNimiqRPCURL    string
+	gamesListJSON       bool
+	gamesShowJSON       bool
+	gamesIncludeRetired bool
+)
+
+func init() {
+	gamesCmd.PersistentFlags().StringVar(&gamesRegistry, "registry", "", "Registry file (default: ~/.config/catalogctl/games.json)")
+	gamesScanCmd.Flags().StringVar(&gamesCatalogID, "catalog", "", "Sui catalog object ID or alias (default: config.catalog_id if --catalog-addr isn't set)")
+	validateAs(gamesScanCmd.Flags(), "catalog", formatCatalogRef)
+	gamesScanCmd.Flags().StringVar(&gamesCatalogAddr, "catalog-addr", "", "Nimiq catalog address or alias: main, test")
+	gamesScanCmd.Flags().StringVar(&gamesPublisher, "publisher", "", "Only index Nimiq entries of this publisher")
+	gamesScanCmd.Flags().StringVar(&gamesNimiqRPCURL, "nimiq-rpc-url", "", "Nimiq RPC URL (default: $NIMIQ_RPC_URL or http://127.0.0.1:8648)")
+	gamesScanCmd.Flags().BoolVar(&gamesIncludeRetired, "include-retired", false, "Also index Nimiq versions whose app has been retired")
+	gamesListCmd.Flags().BoolVar(&gamesListJSON, "json", false, "Print the games as JSON")
+	gamesShowCmd.Flags().BoolVar(&gamesShowJSON, "json", false, "Print the game as JSON")
+
+	gamesCmd.AddCommand(gamesScanCmd, gamesListCmd, gamesShowCmd, gamesExportCmd, gamesImportCmd)
+	reportsResult(gamesScanCmd, gamesListCmd, gamesShowCmd, gamesExportCmd, gamesImportCmd)
+	rootCmd.AddCommand(gamesCmd)
+}
+
+// gamesRegistryPath returns the registry file in use
+func gamesRegistryPath() string {
+	if gamesRegistry != "" {
+		return gamesRegistry
+	}
+	return filepath.Join(config.GetConfigDir(), identity.DefaultFile)
+}
+
+// recordGames applies add to the registry and saves it. Failures are only
+// reported: the registry is an index and never blocks a publish.
+func recordGames(add func(r *identity.Registry)) {
+	path := gamesRegistryPath()
+	r, err := identity.Load(path)
+	if err == nil {
+		add(r)
+		err = r.Save(path)
+	}
+	if err != nil {
+		statusf("⚠️  Failed to update the games registry %s: %v\n", path, err)
+	}
+}
+
+// defaultNimiqRPCURL returns flag, $NIMIQ_RPC_URL or a local node
+func defaultNimiqRPCURL(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	if url := os.Getenv("NIMIQ_RPC_URL"); url != "" {
+		return url
+	}
+	return "http://127.0.0.1:8648"
+}
+
+// cartridgeFile is the game file a Sui cartridge points to
+type cartridgeFile struct {
+	SHA256Hex string
+	BlobID    string
+	Size      uint64
+}
+
+// fetchCartridgeFile reads the file SHA256, blob ID and size of a cartridge
+func fetchCartridgeFile(client *sui.Client, cartridgeID string) (*cartridgeFile, error) {
+	resp, err := client.GetObject(cartridgeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cartridge: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("cartridge %s not found", cartridgeID)
+	}
+	fields := sui.ParseCatalog(resp.Data)
+	f := &cartridgeFile{
+		SHA256Hex: sui.BytesArrayToHex(fields["sha256"]),
+		Size:      parseU64(fields["size_bytes"]),
+	}
+	if blobIDBytes, err := hex.DecodeString(sui.BytesArrayToHex(fields["blob_id"])); err == nil && len(blobIDBytes) > 0 {
+		f.BlobID = base58.Encode(blobIDBytes)
+	}
+	return f, nil
+}
+
+// suiCopy describes a Sui catalog entry for the registry
+func suiCopy(catalogID, key string, version uint16, cartridgeID, blobID string) identity.SuiCopy {
+	return identity.SuiCopy{
+		Network:     cfg.SuiNetwork,
+		CatalogID:   catalogID,
+		Key:         key,
+		Version:     version,
+		CartridgeID: cartridgeID,
+		BlobID:      blobID,
+	}
+}
+
+// nimiqCopy describes a Nimiq catalog entry for the registry
+func nimiqCopy(catalogAddr string, e *nimiq.Entry) identity.NimiqCopy {
+	return identity.NimiqCopy{
+		CatalogAddr:   nimiq.FormatAddress(catalogAddr),
+		Publisher:     nimiq.FormatAddress(e.Publisher),
+		AppID:         e.AppID,
+		Semver:        e.Version(),
+		CartridgeAddr: nimiq.FormatAddress(e.CartridgeAddr),
+	}
+}
+
+func runGamesScan(cmd *cobra.Command, args []string) error {
+	catalogID := gamesCatalogID
+	if catalogID == "" && gamesCatalogAddr == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" && gamesCatalogAddr == "" {
+		return fmt.Errorf("nothing to scan: set --catalog, --catalog-addr or catalog_id in config file")
+	}
+
+	path := gamesRegistryPath()
+	r, err := identity.Load(path)
+	if err != nil {
+		return err
+	}
+	result := map[string]interface{}{"registry": path}
+	setResult(result)
+
+	if catalogID != "" {
+		if catalogID, err = cfg.ResolveCatalogID(catalogID); err != nil {
+			return err
+		}
+		if err := validate.ObjectID(catalogID); err != nil {
+			return fmt.Errorf("invalid catalog ID %s: %w", catalogID, err)
+		}
+		client := sui.NewClient(cfg.SuiRPCURL)
+		entries, err := fetchCatalogEntries(client, catalogID)
+		if err != nil {
+			return err
+		}
+		added := 0
+		for _, e := range entries {
+			f, err := fetchCartridgeFile(client, e.CartridgeID)
+			if err != nil {
+				statusf("⚠️  %s: %v\n", e.Slug, err)
+				continue
+			}
+			if r.AddSui(f.SHA256Hex, f.Size, e.Title, e.Platform, suiCopy(catalogID, e.Slug, e.Version, e.CartridgeID, f.BlobID)) {
+				added++
+			}
+		}
+		result["sui"] = map[string]interface{}{"catalog_id": catalogID, "entries": len(entries), "new_copies": added}
+		statusf("✓ Sui catalog %s: %d entries, %d new copies\n", catalogID, len(entries), added)
+	}
+
+	if gamesCatalogAddr != "" {
+		catalogAddr := nimiq.ResolveCatalog(gamesCatalogAddr)
+		if err := nimiq.ValidateAddress(catalogAddr); err != nil {
+			return err
+		}
+		client := nimiq.NewClient(defaultNimiqRPCURL(gamesNimiqRPCURL))
+		entries, err := client.Catalog(catalogAddr, gamesPublisher)
+		if err != nil {
+			return err
+		}
+		// Retiring an app doesn't remove its older entries, so skip apps whose
+		// newest entry retires them
+		retired := make(map[string]bool)
+		seen := make(map[string]bool)
+		for _, e := range entries {
+			app := fmt.Sprintf("%s/%d", nimiq.NormalizeAddress(e.Publisher), e.AppID)
+			if !seen[app] {
+				seen[app] = true
+				retired[app] = e.Retired()
+			}
+		}
+		headers := make(map[string]*nimiq.Header)
+		added, indexed := 0, 0
+		for i := range entries {
+			e := &entries[i]
+			app := fmt.Sprintf("%s/%d", nimiq.NormalizeAddress(e.Publisher), e.AppID)
+			if e.Retired() || (retired[app] && !gamesIncludeRetired) {
+				continue
+			}
+			key := nimiq.NormalizeAddress(e.CartridgeAddr) + "/" + nimiq.NormalizeAddress(e.Publisher)
+			header, ok := headers[key]
+			if !ok {
+				if header, err = client.CartridgeHeader(e.CartridgeAddr, e.Publisher); err != nil {
+					statusf("⚠️  app %d v%s: %v\n", e.AppID, e.Version(), err)
+				}
+				headers[key] = header
+			}
+			if header == nil {
+				continue
+			}
+			indexed++
+			if r.AddNimiq(hex.EncodeToString(header.SHA256[:]), header.TotalSize, e.Title, model.Platform(e.Platform), nimiqCopy(catalogAddr, e)) {
+				added++
+			}
+		}
+		result["nimiq"] = map[string]interface{}{"catalog": nimiq.FormatAddress(catalogAddr), "entries": indexed, "new_copies": added}
+		statusf("✓ Nimiq catalog %s: %d entries, %d new copies\n", nimiq.FormatAddress(catalogAddr), indexed, added)
+	}
+
+	if err := r.Save(path); err != nil {
+		return fmt.Errorf("failed to save registry: %w", err)
+	}
+	result["games"] = len(r.Games)
+	fmt.Fprintf(stdout, "  Registry: %s (%d games)\n", path, len(r.Games))
+	return nil
+}
+
+func runGamesList(cmd *cobra.Command, args []string) error {
+	r, err := identity.Load(gamesRegistryPath())
+	if err != nil {
+		return err
+	}
+	games := r.Sorted()
+	setResult(games)
+	if gamesListJSON {
+		data, err := json.MarshalIndent(games, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(data))
+		return nil
+	}
+	if len(games) == 0 {
+		fmt.Fprintln(stdout, "No games in the registry. Index a catalog with: catalogctl games scan")
+		return nil
+	}
+	fmt.Fprintf(stdout, "%-14s %-24s %-8s %10s  %s\n", "SHA256", "TITLE", "PLATFORM", "SIZE", "COPIES")
+	fmt.Fprintln(stdout, strings.Repeat("-", 76))
+	for _, g := range games {
+		fmt.Fprintf(stdout, "%-14s %-24s %-8s %10d  %d sui, %d nimiq\n", g.SHA256[:12], truncate(g.Title, 24), g.Platform, g.Size, len(g.Sui), len(g.Nimiq))
+	}
+	return nil
+}
+
+func runGamesShow(cmd *cobra.Command, args []string) error {
+	r, err := identity.Load(gamesRegistryPath())
+	if err != nil {
+		return err
+	}
+	g := r.Lookup(args[0])
+	if g == nil {
+		return fmt.Errorf("no game (or more than one) matches %s", args[0])
+	}
+	setResult(g)
+	if gamesShowJSON {
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(data))
+		return nil
+	}
+	fmt.Fprintf(stdout, "%s (%s, %d bytes)\n", g.Title, g.Platform, g.Size)
+	fmt.Fprintf(stdout, "  SHA256: %s\n", g.SHA256)
+	for _, c := range g.Sui {
+		fmt.Fprintf(stdout, "  Sui %s: catalog %s, %s v%d\n", c.Network, c.CatalogID, c.Key, c.Version)
+		fmt.Fprintf(stdout, "    Cartridge: %s\n", c.CartridgeID)
+		if c.BlobID != "" {
+			fmt.Fprintf(stdout, "    Blob: %s\n", c.BlobID)
+		}
+	}
+	for _, c := range g.Nimiq {
+		fmt.Fprintf(stdout, "  Nimiq: catalog %s, app %d v%s\n", c.CatalogAddr, c.AppID, c.Semver)
+		fmt.Fprintf(stdout, "    Cartridge: %s (publisher %s)\n", c.CartridgeAddr, c.Publisher)
+	}
+	return nil
+}
+
+func runGamesExport(cmd *cobra.Command, args []string) error {
+	r, err := identity.Load(gamesRegistryPath())
+	if err != nil {
+		return err
+	}
+	if args[0] == "-" {
+		setResult(r)
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(data))
+		return nil
+	}
+	if err := r.Save(args[0]); err != nil {
+		return fmt.Errorf("failed to write %s: %w", args[0], err)
+	}
+	setResult(map[string]interface{}{"file": args[0], "games": len(r.Games)})
+	statusf("✓ Exported %d games to %s\n", len(r.Games), args[0])
+	return nil
+}
+
+func runGamesImport(cmd *cobra.Command, args []string) error {
+	if _, err := os.Stat(args[0]); err != nil {
+		return fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+	other, err := identity.Load(args[0])
+	if err != nil {
+		return err
+	}
+	path := gamesRegistryPath()
+	r, err := identity.Load(path)
+	if err != nil {
+		return err
+	}
+	added := r.Merge(other)
+	if err := r.Save(path); err != nil {
+		return fmt.Errorf("failed to save registry: %w", err)
+	}
+	setResult(map[string]interface{}{"file": args[0], "registry": path, "new_copies": added, "games": len(r.Games)})
+	statusf("✓ Imported %d new copies from %s (%d games in %s)\n", added, args[0], len(r.Games), path)
+	return nil
+}
@@ -0,0 +1,546 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/search"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/retro-crypto/sui/internal/walrus"
+)
+
+// ============================================================================
+// serve: read-only catalog gateway
+// ============================================================================
+
+// gatewayCacheMax bounds the JSON responses kept in memory
+const gatewayCacheMax = 1024
+
+// errCatalogNotFound is returned for a catalog ID without an object
+var errCatalogNotFound = errors.New("catalog not found")
+
+// gatewayServer serves catalog data to web frontends so they don't need to
+// speak Sui RPC and Walrus themselves. JSON responses are cached in memory
+// for ttl and carry an ETag for If-None-Match; blobs are streamed from the
+// aggregators and, being content-addressed, cached by clients forever.
+type gatewayServer struct {
+	ttl            time.Duration
+	allowedOrigins []string
+
+	mu    sync.Mutex
+	cache map[string]gatewayResponse
+	// indexes are the search indexes of /search, by catalog and channel
+	indexes map[string]gatewayIndex
+}
+
+// gatewayIndex is a cached search index
+type gatewayIndex struct {
+	index   *search.Index
+	expires time.Time
+}
+
+// gatewaySearch is returned by GET /search
+type gatewaySearch struct {
+	Query     string          `json:"query"`
+	CatalogID string          `json:"catalog_id"`
+	Results   []search.Result `json:"results"`
+}
+
+// Result counts of GET /search
+const (
+	gatewaySearchLimit    = 20
+	gatewaySearchLimitMax = 100
+)
+
+// gatewayResponse is a cached response body
+type gatewayResponse struct {
+	body        []byte
+	contentType string
+	etag        string
+	expires     time.Time
+}
+
+// gatewayCatalog is returned by GET /catalogs/{id}
+type gatewayCatalog struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Owner       string `json:"owner"`
+	Entries     uint64 `json:"entries"`
+}
+
+func newGatewayServer(ttl time.Duration, allowedOrigins []string) *gatewayServer {
+	return &gatewayServer{
+		ttl:            ttl,
+		allowedOrigins: allowedOrigins,
+		cache:          make(map[string]gatewayResponse),
+		indexes:        make(map[string]gatewayIndex),
+	}
+}
+
+func (g *gatewayServer) mount(mux *http.ServeMux) {
+	mux.HandleFunc("/catalogs/", g.cors(g.handleCatalog))
+	mux.HandleFunc("/search", g.cors(g.handleSearch))
+	mux.HandleFunc("/cartridges/", g.cors(g.handleCartridge))
+	mux.HandleFunc("/collections/", g.cors(g.handleCollection))
+	mux.HandleFunc("/blobs/", g.cors(gatewayTokens.require(g.handleBlob)))
+}
+
+func (g *gatewayServer) operations() []apiOperation {
+	catalogParam := []apiParam{{Name: "id", Description: "Catalog object ID or alias"}}
+	return []apiOperation{
+		{
+			Method:     http.MethodGet,
+			Path:       "/catalogs/{id}",
+			Summary:    "Get a catalog's name, description, owner and entry count",
+			Tag:        "catalog",
+			PathParams: catalogParam,
+			Response:   gatewayCatalog{},
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/catalogs/{id}/entries",
+			Summary:    "List the entries of a catalog, sorted by key",
+			Tag:        "catalog",
+			PathParams: catalogParam,
+			Query: []apiParam{
+				{Name: "channel", Description: "Only entries of this release channel (stable, beta or nightly)"},
+				{Name: "tag", Description: "Only entries with this tag (repeatable or comma-separated; entries must have all)"},
+			},
+			Response: []catalogEntry{},
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/catalogs/{id}/search-index",
+			Summary:    "Get a full-text index of a catalog's entries for client-side search (the search.json of export-site)",
+			Tag:        "catalog",
+			PathParams: catalogParam,
+			Query: []apiParam{
+				{Name: "channel", Description: "Only entries of this release channel (stable, beta or nightly)"},
+			},
+			Response: search.Index{},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/search",
+			Summary: "Search a catalog's entries by title, key and tags, best match first",
+			Tag:     "catalog",
+			Query: []apiParam{
+				{Name: "q", Description: "Words every result contains (prefixes match too)", Required: true},
+				{Name: "catalog", Description: "Catalog object ID or alias (default: catalog_id)"},
+				{Name: "channel", Description: "Only entries of this release channel (stable, beta or nightly)"},
+				{Name: "limit", Description: fmt.Sprintf("Maximum results (default %d, at most %d)", gatewaySearchLimit, gatewaySearchLimitMax)},
+			},
+			Response: gatewaySearch{},
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/cartridges/{id}",
+			Summary:    "Get a cartridge with its assets and delta, as printed by get-cartridge",
+			Tag:        "catalog",
+			PathParams: []apiParam{{Name: "id", Description: "Cartridge object ID"}},
+			Response:   map[string]interface{}{},
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/collections/{id}",
+			Summary:    "Get a player's collection with the slug, title and platform of its cartridges, in order",
+			Tag:        "catalog",
+			PathParams: []apiParam{{Name: "id", Description: "Collection object ID"}},
+			Response:   collectionDetails{},
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/blobs/{id}",
+			Summary:    "Stream a blob from Walrus (chunked uploads are assembled)",
+			Tag:        "catalog",
+			Auth:       len(gatewayTokens) > 0,
+			PathParams: []apiParam{{Name: "id", Description: "Walrus blob ID (base58, or the hex form cartridges store)"}},
+			Binary:     true,
+		},
+	}
+}
+
+// cors lets the configured frontend origins read the gateway from a browser
+func (g *gatewayServer) cors(next http.HandlerFunc) http.HandlerFunc {
+	return allowOrigins(g.allowedOrigins, "GET, HEAD, OPTIONS", next)
+}
+
+func (g *gatewayServer) handleCatalog(w http.ResponseWriter, r *http.Request) {
+	if !readMethod(w, r) {
+		return
+	}
+	rest := strings.TrimPrefix(r.URL.Path, "/catalogs/")
+	id, sub, _ := strings.Cut(rest, "/")
+	if id == "" || (sub != "" && sub != "entries" && sub != "search-index") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	catalogID, err := cfg.ResolveCatalogID(id)
+	if err == nil {
+		err = validate.ObjectID(catalogID)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if sub == "" {
+		g.serveJSON(w, r, "catalog:"+catalogID, func() (interface{}, error) {
+			return readGatewayCatalog(catalogID)
+		})
+		return
+	}
+
+	channel := r.URL.Query().Get("channel")
+	if channel != "" {
+		if channel, err = model.ParseChannel(channel); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if sub == "search-index" {
+		g.serveJSON(w, r, "search-index:"+catalogID+":"+channel, func() (interface{}, error) {
+			return g.searchIndex(catalogID, channel)
+		})
+		return
+	}
+	var tags []string
+	for _, value := range r.URL.Query()["tag"] {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	g.serveJSON(w, r, "entries:"+catalogID+":"+channel+":"+strings.Join(tags, ","), func() (interface{}, error) {
+		entries, err := fetchCatalogEntries(sui.NewClient(cfg.SuiRPCURL), catalogID)
+		if err != nil {
+			return nil, err
+		}
+		shown := []catalogEntry{}
+		for _, entry := range entries {
+			if (channel == "" || entry.Channel == channel) && entry.HasTags(tags) {
+				shown = append(shown, entry)
+			}
+		}
+		sort.Slice(shown, func(i, j int) bool { return shown[i].Slug < shown[j].Slug })
+		return shown, nil
+	})
+}
+
+func (g *gatewayServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if !readMethod(w, r) {
+		return
+	}
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	id := query.Get("catalog")
+	if id == "" {
+		id = cfg.CatalogID
+	}
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "catalog is required (no catalog_id configured)")
+		return
+	}
+	catalogID, err := cfg.ResolveCatalogID(id)
+	if err == nil {
+		err = validate.ObjectID(catalogID)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	channel := query.Get("channel")
+	if channel != "" {
+		if channel, err = model.ParseChannel(channel); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	limit := gatewaySearchLimit
+	if s := query.Get("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 || limit > gatewaySearchLimitMax {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be 1 to %d", gatewaySearchLimitMax))
+			return
+		}
+	}
+
+	index, err := g.searchIndex(catalogID, channel)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, gatewaySearch{Query: q, CatalogID: catalogID, Results: index.Search(q, limit)})
+}
+
+// searchIndex returns the search index of a catalog's entries (of one
+// channel, or all for ""), kept for the cache TTL
+func (g *gatewayServer) searchIndex(catalogID, channel string) (*search.Index, error) {
+	key := catalogID + ":" + channel
+	g.mu.Lock()
+	cached, ok := g.indexes[key]
+	g.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.index, nil
+	}
+
+	index, err := buildCatalogSearchIndex(sui.NewClient(cfg.SuiRPCURL), catalogID, channel)
+	if err != nil {
+		return nil, err
+	}
+	if g.ttl > 0 {
+		g.mu.Lock()
+		if len(g.indexes) >= gatewayCacheMax {
+			g.indexes = make(map[string]gatewayIndex)
+		}
+		g.indexes[key] = gatewayIndex{index: index, expires: time.Now().Add(g.ttl)}
+		g.mu.Unlock()
+	}
+	return index, nil
+}
+
+func (g *gatewayServer) handleCartridge(w http.ResponseWriter, r *http.Request) {
+	if !readMethod(w, r) {
+		return
+	}
+	cartridgeID := strings.TrimPrefix(r.URL.Path, "/cartridges/")
+	if err := validate.ObjectID(cartridgeID); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	g.serveJSON(w, r, "cartridge:"+cartridgeID, func() (interface{}, error) {
+		return readCartridgeDetails(sui.NewClient(cfg.SuiRPCURL), cartridgeID)
+	})
+}
+
+func (g *gatewayServer) handleCollection(w http.ResponseWriter, r *http.Request) {
+	if !readMethod(w, r) {
+		return
+	}
+	collectionID := strings.TrimPrefix(r.URL.Path, "/collections/")
+	if err := validate.ObjectID(collectionID); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	g.serveJSON(w, r, "collection:"+collectionID, func() (interface{}, error) {
+		return readCollection(sui.NewClient(cfg.SuiRPCURL), collectionID)
+	})
+}
+
+func (g *gatewayServer) handleBlob(w http.ResponseWriter, r *http.Request) {
+	if !readMethod(w, r) {
+		return
+	}
+	blobID := strings.TrimPrefix(r.URL.Path, "/blobs/")
+	// Cartridges hold the blob ID as hex
+	if raw, err := hex.DecodeString(blobID); err == nil && len(raw) == validate.BlobIDLength {
+		blobID = base58.Encode(raw)
+	}
+	if err := validate.BlobID(blobID); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Blob IDs are content hashes, so a cached copy never goes stale
+	etag := `"` + blobID + `"`
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	mirrors := aggregatorMirrors()
+	resp, aggregator, err := mirrors.OpenRange(blobID, 0, "")
+	if err != nil {
+		var status *walrus.StatusError
+		if errors.As(err, &status) && status.StatusCode == http.StatusNotFound {
+			writeError(w, http.StatusNotFound, "unknown blob "+blobID)
+			return
+		}
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	header.Set("X-Walrus-Aggregator", aggregator)
+
+	// Small blobs may be the manifest of a chunked upload
+	if resp.Size >= 0 && resp.Size <= walrus.MaxManifestSize {
+		data, err := io.ReadAll(io.LimitReader(resp.Body, walrus.MaxManifestSize+1))
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		manifest := walrus.ParseChunkManifest(data)
+		if manifest == nil {
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+			return
+		}
+		header.Set("Content-Length", strconv.FormatInt(manifest.Size, 10))
+		if r.Method == http.MethodHead {
+			return
+		}
+		// A failing chunk cuts the response short of Content-Length
+		manifest.Assemble(w, func(chunkID string) ([]byte, error) {
+			data, _, err := mirrors.ReadWithRetry(chunkID, downloadMaxStalls)
+			return data, err
+		})
+		return
+	}
+
+	if resp.Size > 0 {
+		header.Set("Content-Length", strconv.FormatInt(resp.Size, 10))
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	io.Copy(w, resp.Body)
+}
+
+// serveJSON answers from the cache, or caches what load returns
+func (g *gatewayServer) serveJSON(w http.ResponseWriter, r *http.Request, key string, load func() (interface{}, error)) {
+	g.serveCached(w, r, key, "application/json", func() ([]byte, error) {
+		v, err := load()
+		if err != nil {
+			return nil, err
+		}
+		body, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return append(body, '\n'), nil
+	})
+}
+
+// serveCached serves the body render produces, from the cache while it's
+// fresh, with an ETag over the body
+func (g *gatewayServer) serveCached(w http.ResponseWriter, r *http.Request, key, contentType string, render func() ([]byte, error)) {
+	cached, ok := g.cached(key)
+	if !ok {
+		body, err := render()
+		switch {
+		case errors.Is(err, errCatalogNotFound), errors.Is(err, errCartridgeNotFound):
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		case err != nil:
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		sum := sha256.Sum256(body)
+		cached = gatewayResponse{
+			body:        body,
+			contentType: contentType,
+			etag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+			expires:     time.Now().Add(g.ttl),
+		}
+		g.store(key, cached)
+	}
+
+	header := w.Header()
+	header.Set("ETag", cached.etag)
+	if g.ttl > 0 {
+		header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(g.ttl.Seconds())))
+	} else {
+		header.Set("Cache-Control", "no-cache")
+	}
+	if etagMatches(r.Header.Get("If-None-Match"), cached.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	header.Set("Content-Type", cached.contentType)
+	header.Set("Content-Length", strconv.Itoa(len(cached.body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(cached.body)
+	}
+}
+
+func (g *gatewayServer) cached(key string) (gatewayResponse, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	resp, ok := g.cache[key]
+	if !ok || time.Now().After(resp.expires) {
+		return gatewayResponse{}, false
+	}
+	return resp, true
+}
+
+func (g *gatewayServer) store(key string, resp gatewayResponse) {
+	if g.ttl <= 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.cache) >= gatewayCacheMax {
+		now := time.Now()
+		for k, v := range g.cache {
+			if now.After(v.expires) {
+				delete(g.cache, k)
+			}
+		}
+		if len(g.cache) >= gatewayCacheMax {
+			g.cache = make(map[string]gatewayResponse)
+		}
+	}
+	g.cache[key] = resp
+}
+
+// readGatewayCatalog reads the fields of a catalog object
+func readGatewayCatalog(catalogID string) (*gatewayCatalog, error) {
+	resp, err := sui.NewClient(cfg.SuiRPCURL).GetObject(catalogID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, errCatalogNotFound
+	}
+	fields := sui.ParseCatalog(resp.Data)
+	catalog := &gatewayCatalog{ID: resp.Data.ObjectID, Entries: parseU64(fields["count"])}
+	catalog.Name, _ = fields["name"].(string)
+	catalog.Description, _ = fields["description"].(string)
+	catalog.Owner, _ = fields["owner"].(string)
+	return catalog, nil
+}
+
+// readMethod rejects everything but GET and HEAD
+func readMethod(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return false
+	}
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header lists etag (weak
+// comparison, as for GET)
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/httpclient"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Outbound HTTP settings
+// ============================================================================
+
+var (
+	httpTimeout         time.Duration
+	httpMaxIdleConns    int
+	httpMaxConnsPerHost int
+)
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&httpTimeout, "http-timeout", 0, "Timeout of every Sui, Walrus and Nimiq request (default: http_timeout, else 30s for RPC calls and 5m for blob transfers)")
+	rootCmd.PersistentFlags().IntVar(&httpMaxIdleConns, "http-max-idle-conns", 0, fmt.Sprintf("Keep-alive connections kept open across hosts (default: http_max_idle_conns or %d)", httpclient.DefaultMaxIdleConns))
+	rootCmd.PersistentFlags().IntVar(&httpMaxConnsPerHost, "http-max-conns-per-host", 0, fmt.Sprintf("Concurrent connections per host (default: http_max_conns_per_host or %d)", httpclient.DefaultMaxConnsPerHost))
+}
+
+// setupHTTP configures the transport and timeouts every Sui, Walrus and
+// Nimiq client is built with, from the config and the --http-* flags
+func setupHTTP(cmd *cobra.Command) error {
+	settings, err := cfg.HTTPSettings()
+	if err != nil {
+		return fmt.Errorf("invalid HTTP configuration: %w", err)
+	}
+	flags := cmd.Flags()
+	if flags.Changed("http-timeout") {
+		settings.Timeout = httpTimeout
+	}
+	if flags.Changed("http-max-idle-conns") {
+		settings.MaxIdleConns = httpMaxIdleConns
+	}
+	if flags.Changed("http-max-conns-per-host") {
+		settings.MaxConnsPerHost = httpMaxConnsPerHost
+	}
+	if settings.TLS, err = tlsSettings(); err != nil {
+		return err
+	}
+	if err := httpclient.Configure(settings); err != nil {
+		return fmt.Errorf("invalid HTTP configuration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/plan"
+)
+
+// ============================================================================
+// Cover image and screenshots
+// ============================================================================
+
+const (
+	// coverAssetName is the cartridge asset holding the cover image
+	coverAssetName = plan.CoverAsset
+	// screenshotAssetPrefix names screenshot assets: screenshot-1, screenshot-2, ...
+	screenshotAssetPrefix = "screenshot-"
+	// webpQuality is the cwebp quality used by --webp
+	webpQuality = "85"
+)
+
+// walrusBlobID returns the Walrus blob ID of a blob ID stored on chain as
+// hex bytes, or the hex itself if it isn't valid hex
+func walrusBlobID(hexID string) string {
+	raw, err := hex.DecodeString(strings.TrimPrefix(hexID, "0x"))
+	if err != nil || len(raw) == 0 {
+		return hexID
+	}
+	return base58.Encode(raw)
+}
+
+// imageOptions are the local conversions applied to images before upload
+type imageOptions struct {
+	// MaxSize shrinks images whose longest side is larger (0 keeps the size)
+	MaxSize int
+	// WebP converts PNG and JPEG images to WebP with cwebp
+	WebP bool
+}
+
+// prepareImages turns --cover and --screenshots into cartridge assets,
+// converting them first as opts asks
+func prepareImages(cover string, screenshots []string, opts imageOptions) ([]assetParam, error) {
+	var images []assetParam
+	if cover != "" {
+		asset, err := prepareImage(coverAssetName, cover, opts)
+		if err != nil {
+			return nil, fmt.Errorf("cover: %w", err)
+		}
+		images = append(images, asset)
+	}
+	for i, path := range screenshots {
+		name := fmt.Sprintf("%s%d", screenshotAssetPrefix, i+1)
+		asset, err := prepareImage(name, path, opts)
+		if err != nil {
+			return nil, fmt.Errorf("screenshot %s: %w", path, err)
+		}
+		images = append(images, asset)
+	}
+	return images, nil
+}
+
+// mergeAssets appends the image assets to the --asset ones, rejecting names
+// given twice (e.g. --cover together with --asset cover=...)
+func mergeAssets(assets, images []assetParam) ([]assetParam, error) {
+	seen := make(map[string]bool)
+	for _, asset := range assets {
+		seen[asset.Name] = true
+	}
+	for _, image := range images {
+		if seen[image.Name] {
+			return nil, fmt.Errorf("asset %q given more than once (--cover and --screenshots use cover and screenshot-N)", image.Name)
+		}
+		seen[image.Name] = true
+	}
+	return append(assets, images...), nil
+}
+
+// prepareImage checks that path is an image, resizes and converts it as opts
+// asks and hashes the file to upload. Converted files are written next to
+// the original (like delta patches), so a resumed publish finds them again.
+func prepareImage(name, path string, opts imageOptions) (assetParam, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return assetParam{}, fmt.Errorf("invalid path: %w", err)
+	}
+	contentType, err := detectImageType(absPath)
+	if err != nil {
+		return assetParam{}, err
+	}
+
+	out := absPath
+	if opts.MaxSize > 0 {
+		if out, err = resizeImage(absPath, contentType, opts.MaxSize); err != nil {
+			return assetParam{}, err
+		}
+	}
+	if opts.WebP {
+		switch contentType {
+		case "image/webp":
+		case "image/png", "image/jpeg":
+			if out, err = convertToWebP(out); err != nil {
+				return assetParam{}, err
+			}
+		default:
+			statusf("⚠️  %s is a %s; cwebp only converts PNG and JPEG, uploading it as is\n", filepath.Base(absPath), contentType)
+		}
+	}
+
+	sha256Hex, size, err := fileSHA256(out)
+	if err != nil {
+		return assetParam{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	if out != absPath {
+		statusf("✓ %s: %s (%d bytes)\n", name, out, size)
+	}
+	return assetParam{Name: name, FilePath: out, Size: size, SHA256Hex: sha256Hex}, nil
+}
+
+// detectImageType returns the MIME type of an image file, which must be a
+// PNG, JPEG, GIF or WebP image
+func detectImageType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	contentType := http.DetectContentType(head[:n])
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return contentType, nil
+	}
+	return "", fmt.Errorf("%s is not a PNG, JPEG, GIF or WebP image (detected %s)", filepath.Base(path), contentType)
+}
+
+// resizeImage shrinks the image at path so its longest side is maxSize and
+// returns the path of the resized copy (PNG, or JPEG for JPEG sources). Images
+// that already fit are returned unchanged.
+func resizeImage(path, contentType string, maxSize int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	defer f.Close()
+
+	if contentType == "image/webp" {
+		// The standard library has no WebP decoder
+		config, err := webpDimensions(f)
+		if err != nil {
+			return "", err
+		}
+		if max(config.Width, config.Height) > maxSize {
+			return "", fmt.Errorf("%s is %dx%d and WebP images can't be resized here; resize it before publishing or pass a PNG/JPEG", filepath.Base(path), config.Width, config.Height)
+		}
+		return path, nil
+	}
+
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if max(config.Width, config.Height) <= maxSize {
+		return path, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	resized := downscale(img, maxSize)
+
+	ext := "png"
+	if contentType == "image/jpeg" {
+		ext = "jpg"
+	}
+	out := fmt.Sprintf("%s.%dpx.%s", path, maxSize, ext)
+	w, err := os.Create(out)
+	if err != nil {
+		return "", fmt.Errorf("failed to write resized image: %w", err)
+	}
+	if ext == "jpg" {
+		err = jpeg.Encode(w, resized, &jpeg.Options{Quality: 90})
+	} else {
+		err = png.Encode(w, resized)
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out)
+		return "", fmt.Errorf("failed to write resized image: %w", err)
+	}
+	return out, nil
+}
+
+// downscale shrinks img so its longest side is maxSize. Each output pixel is
+// the average of the source pixels it covers, which keeps pixel-art
+// screenshots readable where nearest-neighbour sampling would drop lines.
+func downscale(img image.Image, maxSize int) *image.RGBA64 {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := maxSize, maxSize
+	if w >= h {
+		dh = max(1, (h*maxSize+w/2)/w)
+	} else {
+		dw = max(1, (w*maxSize+h/2)/h)
+	}
+
+	dst := image.NewRGBA64(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := y * h / dh
+		y1 := max((y+1)*h/dh, y0+1)
+		for x := 0; x < dw; x++ {
+			x0 := x * w / dw
+			x1 := max((x+1)*w/dw, x0+1)
+
+			// Premultiplied channels average correctly across transparency
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(b.Min.X+sx, b.Min.Y+sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// webpDimensions reads the canvas size from a WebP header (VP8, VP8L or VP8X)
+func webpDimensions(r io.Reader) (image.Config, error) {
+	head := make([]byte, 30)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return image.Config{}, fmt.Errorf("failed to read WebP header: %w", err)
+	}
+	le24 := func(b []byte) int { return int(b[0]) | int(b[1])<<8 | int(b[2])<<16 }
+	switch string(head[12:16]) {
+	case "VP8 ":
+		return image.Config{Width: (int(head[26]) | int(head[27])<<8) & 0x3fff, Height: (int(head[28]) | int(head[29])<<8) & 0x3fff}, nil
+	case "VP8L":
+		bits := uint32(head[21]) | uint32(head[22])<<8 | uint32(head[23])<<16 | uint32(head[24])<<24
+		return image.Config{Width: int(bits&0x3fff) + 1, Height: int(bits>>14&0x3fff) + 1}, nil
+	case "VP8X":
+		return image.Config{Width: le24(head[24:27]) + 1, Height: le24(head[27:30]) + 1}, nil
+	}
+	return image.Config{}, fmt.Errorf("unsupported WebP image")
+}
+
+// convertToWebP converts a PNG or JPEG image with cwebp (libwebp), which has
+// to be installed, and returns the path of the .webp file
+func convertToWebP(path string) (string, error) {
+	cwebp, err := exec.LookPath("cwebp")
+	if err != nil {
+		return "", fmt.Errorf("--webp needs cwebp on PATH (install libwebp, e.g. apt install webp or brew install webp)")
+	}
+	out := path + ".webp"
+	if output, err := exec.Command(cwebp, "-quiet", "-q", webpQuality, path, "-o", out).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("cwebp failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return out, nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/approval"
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/keystore"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Keystore (key_source) and the key command group
+// ============================================================================
+
+// keystoreFileName is the encrypted keystore of a plain "file" key_source,
+// in the config directory
+const keystoreFileName = "keystore.json"
+
+// openKeystore opens the store key_source names and returns the name of the
+// signing key in it
+func openKeystore(source string) (keystore.Store, string, error) {
+	src, err := keystore.ParseSource(source)
+	if err != nil {
+		return nil, "", err
+	}
+	return keystore.Open(src, keystore.Options{
+		Service:    "catalogctl",
+		EnvVar:     "SUI_PRIVATE_KEY",
+		File:       filepath.Join(config.GetConfigDir(), keystoreFileName),
+		Passphrase: keystorePassphrase,
+	})
+}
+
+// keystorePassphrase finds the passphrase of a keystore file like that of
+// an encrypted config; a new file's passphrase is typed twice
+func keystorePassphrase(path string, create bool) (string, error) {
+	if !create || os.Getenv(config.PassphraseEnv) != "" || os.Getenv(passphraseCommandEnv) != "" {
+		return configPassphrase(path)
+	}
+	if !stdinIsTerminal() {
+		return "", fmt.Errorf("a terminal is needed to choose the passphrase of %s (or set %s)", path, config.PassphraseEnv)
+	}
+	passphrase, err := readPassphrase(fmt.Sprintf("New passphrase for %s: ", path))
+	if err != nil {
+		return "", err
+	}
+	again, err := readPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", fmt.Errorf("passphrases don't match")
+	}
+	return fido2Passphrase(path, passphrase)
+}
+
+// configuredKey returns the signing key: private_key or mnemonic from the
+// config, or the secret key_source points at. A keystore secret with
+// spaces is a mnemonic, anything else a hex private key.
+func configuredKey() (privateKey, mnemonic string, err error) {
+	if !cfg.UsesKeystore() {
+		return cfg.PrivateKey, cfg.Mnemonic, nil
+	}
+	store, name, err := openKeystore(cfg.KeySource)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := store.Get(name)
+	if err != nil {
+		return "", "", fmt.Errorf("key_source %s: %w", cfg.KeySource, err)
+	}
+	if isMnemonic(secret) {
+		return "", secret, nil
+	}
+	return secret, "", nil
+}
+
+func isMnemonic(secret string) bool {
+	return len(strings.Fields(secret)) > 1
+}
+
+// keySigner checks a secret and returns its signer
+func keySigner(secret string) (*sui.Signer, error) {
+	if isMnemonic(secret) {
+		return sui.SignerFromMnemonic(secret, "")
+	}
+	key, err := approval.KeyFromHex(secret)
+	if err != nil {
+		return nil, err
+	}
+	return sui.NewSigner(key), nil
+}
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manage the signing key in the OS keychain or an encrypted keystore",
+	Long: `The signing key is read from where key_source points:
+
+  config            private_key / mnemonic in the config file (default)
+  env[:VAR]         an environment variable ($SUI_PRIVATE_KEY by default)
+  keychain[:NAME]   the OS keychain: macOS Keychain, Secret Service
+                    (secret-tool) or Windows Credential Manager
+  file[:PATH]       an encrypted keystore file (scrypt + AES-256-GCM,
+                    ~/.config/catalogctl/keystore.json by default)
+
+The key is a hex private key or a mnemonic. $CATALOGCTL_KEY_SOURCE
+overrides key_source. Keystore files are unlocked with the same passphrase
+sources as encrypted config files ($CATALOGCTL_PASSPHRASE,
+$CATALOGCTL_PASSPHRASE_COMMAND or a prompt).
+
+Example:
+  catalogctl key import --to keychain   # move private_key out of config.json
+  catalogctl key status`,
+}
+
+var keyStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show where the signing key comes from and its address",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := keystore.ParseSource(cfg.KeySource)
+		if err != nil {
+			return err
+		}
+		result := map[string]interface{}{"key_source": src.String()}
+		setResult(result)
+		fmt.Fprintf(stdout, "Key source: %s\n", src)
+		if src.Kind != keystore.KindConfig {
+			store, name, err := openKeystore(cfg.KeySource)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "Keystore:   %s (%s)\n", store, name)
+			result["keystore"] = store.String()
+			result["name"] = name
+		}
+
+		privateKey, mnemonic, err := configuredKey()
+		if err != nil {
+			return err
+		}
+		secret := privateKey
+		if secret == "" {
+			secret = mnemonic
+		}
+		if secret == "" {
+			statusln("⚠️  No key configured; transactions are signed with the sui CLI keystore's active address")
+			return nil
+		}
+		signer, err := keySigner(secret)
+		if err != nil {
+			return fmt.Errorf("the configured key is invalid: %w", err)
+		}
+		result["address"] = signer.SuiAddress()
+		fmt.Fprintf(stdout, "Address:    %s\n", signer.SuiAddress())
+		if src.Kind == keystore.KindConfig && cfg.Source != "" && !cfg.Encrypted {
+			statusf("💡 The key is stored in plain text in %s; move it with: catalogctl key import --to keychain\n", cfg.Source)
+		}
+		return nil
+	},
+}
+
+var (
+	keySetStdin bool
+	keyImportTo string
+	keyDelete   bool
+)
+
+var keySetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Store a signing key in the keystore key_source points at",
+	Long: `Asks for a hex private key or a mnemonic (or reads it from stdin with
+--stdin) and stores it in the keychain or keystore file key_source points
+at. --delete removes it instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cfg.UsesKeystore() {
+			return fmt.Errorf("key_source is config; set key_source to keychain or file first (or use key import --to)")
+		}
+		store, name, err := openKeystore(cfg.KeySource)
+		if err != nil {
+			return err
+		}
+		if keyDelete {
+			if err := store.Delete(name); err != nil {
+				return err
+			}
+			statusf("✓ Deleted %s from %s\n", name, store)
+			return nil
+		}
+
+		var secret string
+		if keySetStdin || !stdinIsTerminal() {
+			data, err := io.ReadAll(stdinLines)
+			if err != nil {
+				return fmt.Errorf("failed to read the key: %w", err)
+			}
+			secret = strings.TrimSpace(string(data))
+		} else if secret, err = readPassphrase("Private key (hex) or mnemonic: "); err != nil {
+			return err
+		}
+		signer, err := keySigner(strings.TrimSpace(secret))
+		if err != nil {
+			return fmt.Errorf("invalid key: %w", err)
+		}
+		if err := store.Set(name, strings.TrimSpace(secret)); err != nil {
+			return fmt.Errorf("failed to store the key in %s: %w", store, err)
+		}
+		statusf("✓ Stored the key of %s in %s (%s)\n", signer.SuiAddress(), store, name)
+		setResult(map[string]string{"address": signer.SuiAddress(), "keystore": store.String(), "name": name})
+		return nil
+	},
+}
+
+var keyImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Move private_key / mnemonic from the config file into a keystore",
+	Long: `Copies the private_key or mnemonic of the config file into the keystore
+--to names (default: the configured key_source), checks that it reads back,
+then sets key_source in the config file and clears the plain text key.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := keyImportTo
+		if target == "" {
+			target = cfg.KeySource
+		}
+		src, err := keystore.ParseSource(target)
+		if err != nil {
+			return err
+		}
+		if src.Kind == keystore.KindConfig || src.Kind == keystore.KindEnv {
+			return fmt.Errorf("--to must be a keychain or file key source, got %s", src)
+		}
+		if cfg.Source == "" {
+			return fmt.Errorf("no config file loaded")
+		}
+		secret := cfg.PrivateKey
+		if secret == "" {
+			secret = cfg.Mnemonic
+		}
+		if secret == "" {
+			return fmt.Errorf("%s has no private_key or mnemonic to import", cfg.Source)
+		}
+		signer, err := keySigner(secret)
+		if err != nil {
+			return fmt.Errorf("the key in %s is invalid: %w", cfg.Source, err)
+		}
+
+		store, name, err := openKeystore(src.String())
+		if err != nil {
+			return err
+		}
+		if err := store.Set(name, secret); err != nil {
+			return fmt.Errorf("failed to store the key in %s: %w", store, err)
+		}
+		if stored, err := store.Get(name); err != nil || stored != secret {
+			return fmt.Errorf("the key doesn't read back from %s (%v); the config file was left unchanged", store, err)
+		}
+		statusf("✓ Stored the key of %s in %s (%s)\n", signer.SuiAddress(), store, name)
+
+		if err := config.SetValue(cfg.Source, "key_source", src.String()); err != nil {
+			return fmt.Errorf("failed to set key_source in %s: %w", cfg.Source, err)
+		}
+		for field, value := range map[string]string{"private_key": cfg.PrivateKey, "mnemonic": cfg.Mnemonic} {
+			if value == "" {
+				continue
+			}
+			if err := config.SetValue(cfg.Source, field, ""); err != nil {
+				return fmt.Errorf("failed to clear %s in %s: %w", field, cfg.Source, err)
+			}
+		}
+		statusf("✓ Set key_source to %s and removed the key from %s\n", src, cfg.Source)
+		setResult(map[string]string{"address": signer.SuiAddress(), "key_source": src.String(), "keystore": store.String()})
+		return nil
+	},
+}
+
+func init() {
+	keySetCmd.Flags().BoolVar(&keySetStdin, "stdin", false, "Read the key from stdin instead of prompting")
+	keySetCmd.Flags().BoolVar(&keyDelete, "delete", false, "Remove the key from the keystore")
+	keyImportCmd.Flags().StringVar(&keyImportTo, "to", "", "Key source to move the key to, e.g. keychain or file (default: key_source)")
+	keyCmd.AddCommand(keyStatusCmd)
+	reportsResult(keyStatusCmd)
+	keyCmd.AddCommand(keySetCmd)
+	reportsResult(keySetCmd)
+	keyCmd.AddCommand(keyImportCmd)
+	reportsResult(keyImportCmd)
+	rootCmd.AddCommand(keyCmd)
+}
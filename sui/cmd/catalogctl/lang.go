@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/i18n"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ============================================================================
+// Message language (--lang, LC_ALL / LC_MESSAGES / LANG)
+// ============================================================================
+
+var langFlag string
+
+// usageHeadings are the English headings of cobra's usage template
+var usageHeadings = []string{
+	`Use "{{.CommandPath}} [command] --help" for more information about a command.`,
+	"Additional help topics:",
+	"Available Commands:",
+	"Additional Commands:",
+	"Global Flags:",
+	"Examples:",
+	"Aliases:",
+	"Usage:",
+	"Flags:",
+}
+
+// langFromArgs finds --lang in the raw arguments. The language has to be
+// known before cobra parses anything, as it also prints --help and usage
+// errors.
+func langFromArgs(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return ""
+		case arg == "--lang" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "--lang="):
+			return strings.TrimPrefix(arg, "--lang=")
+		}
+	}
+	return ""
+}
+
+// setupLanguage selects the message language and translates the help of
+// every command
+func setupLanguage(args []string) error {
+	lang, err := i18n.Detect(langFromArgs(args))
+	if err != nil {
+		return err
+	}
+	if err := i18n.SetLocale(lang); err != nil {
+		return err
+	}
+	if lang == i18n.Default {
+		return nil
+	}
+
+	tmpl := rootCmd.UsageTemplate()
+	for _, heading := range usageHeadings {
+		tmpl = strings.Replace(tmpl, heading, i18n.T(heading), 1)
+	}
+	rootCmd.SetUsageTemplate(tmpl)
+
+	rootCmd.InitDefaultHelpCmd()
+	rootCmd.InitDefaultCompletionCmd()
+	localizeCommand(rootCmd)
+	return nil
+}
+
+func localizeCommand(cmd *cobra.Command) {
+	cmd.InitDefaultHelpFlag()
+	cmd.Short = i18n.T(cmd.Short)
+	translate := func(f *pflag.Flag) {
+		if f.Name == "help" {
+			f.Usage = i18n.Sprintf("help for %s", cmd.Name())
+			return
+		}
+		f.Usage = i18n.T(f.Usage)
+	}
+	cmd.LocalFlags().VisitAll(translate)
+	cmd.PersistentFlags().VisitAll(translate)
+	for _, child := range cmd.Commands() {
+		localizeCommand(child)
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&langFlag, "lang", "", "Message language, e.g. en or es (default: $LC_ALL, $LC_MESSAGES or $LANG)")
+}
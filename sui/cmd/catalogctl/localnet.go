@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Localnet Commands
+// ============================================================================
+
+// Defaults of a network started with `sui start --with-faucet`
+const (
+	localnetFaucetURL = "http://127.0.0.1:9123"
+	localnetEnvAlias  = "localnet"
+)
+
+var localnetCmd = &cobra.Command{
+	Use:   "localnet",
+	Short: "Run catalogctl against a local Sui network",
+}
+
+var localnetUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Start a local Sui network, publish the Move package and write a localnet profile",
+	Long: `Starts a local Sui network with 'sui start --with-faucet' (or connects to one
+that is already running), switches the sui CLI to it, funds the active address
+from the faucet, publishes the cartridge_storage Move package and writes a
+config profile for it:
+
+  catalogctl localnet up --seed-catalog
+  catalogctl --config ~/.config/catalogctl/localnet.json list-catalog
+
+The network started here keeps running in the background; its output goes to
+localnet.log next to the profile. Running 'up' again republishes the package
+into the existing network.`,
+	RunE: runLocalnetUp,
+}
+
+var (
+	localnetRPCURL      string
+	localnetFaucet      string
+	localnetPackagePath string
+	localnetProfile     string
+	localnetNoStart     bool
+	localnetRegenesis   bool
+	localnetSeedCatalog bool
+	localnetTimeout     time.Duration
+)
+
+func init() {
+	localnetUpCmd.Flags().StringVar(&localnetRPCURL, "rpc-url", config.DefaultSuiRPCLocalnet, "RPC URL of the local network")
+	localnetUpCmd.Flags().StringVar(&localnetFaucet, "faucet-url", localnetFaucetURL, "Faucet URL of the local network")
+	localnetUpCmd.Flags().StringVar(&localnetPackagePath, "package-path", "contracts", "Path to the cartridge_storage Move package")
+	localnetUpCmd.Flags().StringVar(&localnetProfile, "profile", "", "Config file to write (default: ~/.config/catalogctl/localnet.json)")
+	localnetUpCmd.Flags().BoolVar(&localnetNoStart, "no-start", false, "Only connect to a running network, never start one")
+	localnetUpCmd.Flags().BoolVar(&localnetRegenesis, "force-regenesis", true, "Start from a fresh genesis (state is discarded when the network stops)")
+	localnetUpCmd.Flags().BoolVar(&localnetSeedCatalog, "seed-catalog", false, "Create a demo catalog and save it as catalog_id in the profile")
+	localnetUpCmd.Flags().DurationVar(&localnetTimeout, "timeout", 2*time.Minute, "How long to wait for a started network to respond")
+
+	localnetCmd.AddCommand(localnetUpCmd)
+	rootCmd.AddCommand(localnetCmd)
+}
+
+func runLocalnetUp(cmd *cobra.Command, args []string) error {
+	if memoryChain != nil {
+		return fmt.Errorf("localnet up needs a real sui network; drop --backend memory")
+	}
+	if _, err := os.Stat(filepath.Join(localnetPackagePath, "Move.toml")); err != nil {
+		return fmt.Errorf("no Move package at %s (set --package-path): %w", localnetPackagePath, err)
+	}
+	if localnetProfile == "" {
+		localnetProfile = filepath.Join(config.GetConfigDir(), "localnet.json")
+	}
+	if err := os.MkdirAll(filepath.Dir(localnetProfile), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(localnetProfile), err)
+	}
+
+	// 1. Network
+	client := sui.NewClient(localnetRPCURL)
+	if _, err := client.GetLatestCheckpoint(); err == nil {
+		statusf("✓ Local network is running at %s\n", localnetRPCURL)
+	} else if localnetNoStart {
+		return fmt.Errorf("no local network at %s: %w", localnetRPCURL, err)
+	} else {
+		logPath := filepath.Join(filepath.Dir(localnetProfile), "localnet.log")
+		pid, err := startLocalnet(logPath)
+		if err != nil {
+			return err
+		}
+		statusf("Starting local network (pid %d, log %s)...\n", pid, logPath)
+		if err := waitForLocalnet(client, localnetTimeout); err != nil {
+			return fmt.Errorf("%w (see %s)", err, logPath)
+		}
+		statusf("✓ Local network is up at %s\n", localnetRPCURL)
+	}
+
+	// 2. sui CLI environment and gas
+	if err := switchSuiEnv(localnetEnvAlias, localnetRPCURL); err != nil {
+		return err
+	}
+	address, err := activeAddress()
+	if err != nil {
+		return err
+	}
+	if _, err := executeSuiCommand([]string{"client", "faucet", "--url", strings.TrimSuffix(localnetFaucet, "/") + "/gas"}); err != nil {
+		return fmt.Errorf("failed to request gas from the faucet: %w", err)
+	}
+	statusf("✓ Funded %s from the faucet\n", address)
+
+	// 3. Package
+	statusf("Publishing %s...\n", localnetPackagePath)
+	published, err := publishMovePackage(localnetPackagePath, publishGasBudget)
+	if err != nil {
+		return err
+	}
+	printPublishedPackage("Package published", published)
+
+	// 4. Profile
+	values := [][2]string{
+		{"sui_network", "localnet"},
+		{"sui_rpc_url", localnetRPCURL},
+		{"package_id", published.PackageID},
+		{"upgrade_cap_id", published.UpgradeCap},
+	}
+	for _, kv := range values {
+		if err := config.SetValue(localnetProfile, kv[0], kv[1]); err != nil {
+			return fmt.Errorf("failed to write %s: %w", localnetProfile, err)
+		}
+	}
+	statusf("✓ Wrote profile %s\n", localnetProfile)
+
+	// 5. Demo catalog
+	if localnetSeedCatalog {
+		output, err := executeMoveCall(sui.CreateCatalog(published.PackageID, "Localnet Demo", "Demo catalog created by catalogctl localnet up"))
+		if err != nil {
+			return fmt.Errorf("failed to create demo catalog: %w", err)
+		}
+		catalogID := sui.CreatedObjectID(output, "Catalog")
+		if catalogID == "" {
+			return fmt.Errorf("failed to extract catalog ID from transaction")
+		}
+		if err := config.SetValue(localnetProfile, "catalog_id", catalogID); err != nil {
+			return fmt.Errorf("failed to write %s: %w", localnetProfile, err)
+		}
+		statusf("✓ Demo catalog created: %s\n", catalogID)
+	}
+
+	statusf("\n💡 Use the profile with: catalogctl --config %s <command>\n", localnetProfile)
+	fmt.Fprintln(stdout, "   Walrus isn't part of the local network; uploads use the configured Walrus network.")
+	return nil
+}
+
+// startLocalnet starts `sui start --with-faucet` in the background with its
+// output going to logPath and returns its process ID
+func startLocalnet(logPath string) (int, error) {
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", logPath, err)
+	}
+	defer logFile.Close()
+
+	args := []string{"start", "--with-faucet"}
+	if localnetRegenesis {
+		args = append(args, "--force-regenesis")
+	}
+	cmd := exec.Command("sui", args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start local network: %w", err)
+	}
+	// The network outlives this command
+	go cmd.Wait()
+	return cmd.Process.Pid, nil
+}
+
+// waitForLocalnet polls the RPC until it answers or the timeout expires
+func waitForLocalnet(client *sui.Client, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := client.GetLatestCheckpoint()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("local network did not respond within %s: %w", timeout, err)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// switchSuiEnv makes the sui CLI use the given RPC, adding the environment
+// if it doesn't exist yet
+func switchSuiEnv(alias, rpcURL string) error {
+	if _, err := executeSuiCommand([]string{"client", "switch", "--env", alias}); err != nil {
+		if _, err := executeSuiCommand([]string{"client", "new-env", "--alias", alias, "--rpc", rpcURL}); err != nil {
+			return fmt.Errorf("failed to add sui environment %s: %w", alias, err)
+		}
+		if _, err := executeSuiCommand([]string{"client", "switch", "--env", alias}); err != nil {
+			return fmt.Errorf("failed to switch sui environment to %s: %w", alias, err)
+		}
+	}
+	statusf("✓ sui CLI environment: %s (%s)\n", alias, rpcURL)
+	return nil
+}
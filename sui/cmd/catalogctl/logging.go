@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/retro-crypto/shared/logging"
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/httpclient"
+	"github.com/retro-crypto/sui/internal/i18n"
+)
+
+// ============================================================================
+// Log (--log-format, --log-file)
+// ============================================================================
+
+var (
+	logFormat  string
+	logFile    string
+	logMaxMB   int64
+	logBackups int
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Format of warnings and debug output on stderr and of the log file: text or json")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Log file, rotated by size (default: ~/.config/catalogctl/logs/catalogctl.log; off disables it)")
+	rootCmd.PersistentFlags().Int64Var(&logMaxMB, "log-max-mb", logging.DefaultMaxSize>>20, "Rotate the log file when it exceeds this size in MB")
+	rootCmd.PersistentFlags().IntVar(&logBackups, "log-backups", logging.DefaultBackups, "Rotated log files to keep")
+}
+
+// defaultLogFile is logs/catalogctl.log in the config directory
+func defaultLogFile() string {
+	return filepath.Join(config.GetConfigDir(), "logs", "catalogctl.log")
+}
+
+// setupLogging starts the log at the verbosity set by setupVerbosity. The
+// console shows errors with -q, warnings by default and debug detail with
+// -v/-vv; the log file also keeps status lines.
+func setupLogging() error {
+	if logFormat != "text" && logFormat != "json" {
+		return fmt.Errorf("--log-format must be text or json, got %q", logFormat)
+	}
+	if logMaxMB < 1 {
+		return fmt.Errorf("--log-max-mb must be at least 1")
+	}
+
+	consoleLevel := slog.LevelWarn
+	switch {
+	case quiet():
+		consoleLevel = slog.LevelError
+	case verbose(levelDebug):
+		consoleLevel = logging.LevelTrace
+	case verbose(levelVerbose):
+		consoleLevel = slog.LevelDebug
+	}
+	fileLevel := slog.LevelInfo
+	if consoleLevel < fileLevel {
+		fileLevel = consoleLevel
+	}
+
+	path := logFile
+	switch path {
+	case "":
+		path = defaultLogFile()
+	case "off":
+		path = ""
+	}
+	err := logging.Setup(logging.Options{
+		Console:      os.Stderr,
+		ConsoleLevel: consoleLevel,
+		JSON:         logFormat == "json",
+		File:         path,
+		FileLevel:    fileLevel,
+		MaxSize:      logMaxMB << 20,
+		Backups:      logBackups,
+		Prefixes: map[slog.Level]string{
+			slog.LevelWarn:  i18n.Sprintf("Warning"),
+			slog.LevelError: i18n.Sprintf("Error"),
+		},
+	})
+	httpclient.SetTrace(logging.Logger())
+	if err != nil {
+		// A missing log file never stops a command
+		warnf("log file disabled: %v", err)
+	}
+	return nil
+}
+
+// slogLevel maps -v levels to log levels
+func slogLevel(level int) slog.Level {
+	if level >= levelDebug {
+		return logging.LevelTrace
+	}
+	return slog.LevelDebug
+}
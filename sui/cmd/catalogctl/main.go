@@ -6,33 +6,61 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/shared/logging"
+	"github.com/retro-crypto/sui/internal/approval"
 	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/i18n"
 	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/plan"
+	"github.com/retro-crypto/sui/internal/storage"
 	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
 	"github.com/retro-crypto/sui/internal/walrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	cfg *config.Config
+	cfg        *config.Config
+	configPath string
 	// Version information (set by ldflags during build)
 	Version   = "dev"
 	BuildTime = "unknown"
+	// ReleasePublicKey verifies self-update downloads (base64 DER, set by
+	// ldflags in release builds)
+	ReleasePublicKey = ""
+	// TelemetryEndpoint receives anonymous usage reports once a user ran
+	// telemetry enable (set by ldflags in release builds)
+	TelemetryEndpoint = ""
 )
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	start := time.Now()
+	if err := setupLanguage(os.Args[1:]); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	cmd, err := rootCmd.ExecuteC()
+	writeCommandOutput(cmd, err)
+	reportUsage(cmd, time.Since(start), err)
+	if err != nil {
+		logging.File().Error("command failed", "command", cmd.CommandPath(), "error", err, "duration", time.Since(start))
+		logging.Close()
+		i18n.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	logging.File().Info("command done", "command", cmd.CommandPath(), "duration", time.Since(start))
+	logging.Close()
 }
 
 var rootCmd = &cobra.Command{
@@ -42,9 +70,11 @@ var rootCmd = &cobra.Command{
 with game data stored on Walrus decentralized storage.
 
 Configuration (priority order):
-  1. config.json (recommended)
-  2. .env file (legacy)
-  3. Environment variables
+  1. --config FILE
+  2. ./config.json
+  3. ~/.config/catalogctl/config.json (or $XDG_CONFIG_HOME/catalogctl/config.json)
+  4. .env file (legacy, only if no config file is found)
+  5. Environment variables
 
 Required config fields:
   - package_id: Deployed cartridge_storage package ID
@@ -59,22 +89,97 @@ This tool helps with:
   - Reading catalog/cartridge data
   - Managing game metadata`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupVerbosity(); err != nil {
+			return err
+		}
+		if err := setupLogging(); err != nil {
+			return err
+		}
+		if err := setupOutput(cmd); err != nil {
+			return err
+		}
+		if err := validateFlags(cmd); err != nil {
+			return err
+		}
 		var err error
-		cfg, err = config.Load()
-		return err
+		cfg, err = config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		if cfg.Source != "" {
+			debugf(levelVerbose, "[config] %s", cfg.Source)
+		}
+		// config validate reports warnings itself
+		if cmd != configValidateCmd {
+			for _, warning := range cfg.Warnings {
+				warnf("%s", warning)
+			}
+		}
+		// config commands stay usable to fix broken HTTP or TLS settings
+		if cmd.Parent() != configCmd {
+			if err := setupHTTP(cmd); err != nil {
+				return err
+			}
+		}
+		if err := setupBackend(); err != nil {
+			return err
+		}
+		return setupObjectCache()
 	},
 }
 
-func init() {
-	// Add version command
-	rootCmd.AddCommand(&cobra.Command{
-		Use:   "version",
-		Short: "Print version information",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Printf("catalogctl %s\n", Version)
-			fmt.Printf("Built: %s\n", BuildTime)
-		},
+// Formats of ID flags, checked before the command runs (see validateAs)
+const (
+	formatCatalogRef = "catalog-ref"
+	formatObjectID   = "object-id"
+	formatBlobID     = "blob-id"
+)
+
+// flagFormatAnnotation is the flag annotation holding a flag's format
+const flagFormatAnnotation = "catalogctl/format"
+
+// flagFormats maps formats to their checks
+var flagFormats = map[string]func(string) error{
+	formatCatalogRef: validateCatalogRef,
+	formatObjectID:   validate.ObjectID,
+	formatBlobID:     validate.BlobID,
+}
+
+// validateAs marks the flag name of flags to be checked against format
+// before the command runs, so typos fail before any RPC call
+func validateAs(flags *pflag.FlagSet, name, format string) {
+	if _, ok := flagFormats[format]; !ok {
+		panic("unknown flag format " + format)
+	}
+	flags.SetAnnotation(name, flagFormatAnnotation, []string{format})
+}
+
+// validateCatalogRef accepts a catalog object ID or an alias name
+func validateCatalogRef(value string) error {
+	if strings.HasPrefix(value, "0x") {
+		return validate.ObjectID(value)
+	}
+	return validate.AliasName(value)
+}
+
+// validateFlags checks the set flags of cmd that were marked with validateAs
+func validateFlags(cmd *cobra.Command) error {
+	var err error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		formats := flag.Annotations[flagFormatAnnotation]
+		if err != nil || len(formats) == 0 || !flag.Changed || flag.Value.String() == "" {
+			return
+		}
+		if verr := flagFormats[formats[0]](flag.Value.String()); verr != nil {
+			err = fmt.Errorf("invalid --%s %q: %w", flag.Name, flag.Value.String(), verr)
+		}
 	})
+	return err
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&ghSummaryEnabled, "gh-summary", false, "Write a GitHub Actions job summary ($GITHUB_STEP_SUMMARY) and step outputs ($GITHUB_OUTPUT)")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Path to config file (default: ./config.json, then ~/.config/catalogctl/config.json)")
 }
 
 // ============================================================================
@@ -85,20 +190,33 @@ var uploadBlobCmd = &cobra.Command{
 	Use:   "upload-blob",
 	Short: "Upload a file to Walrus and get blob ID",
 	Long: `Uploads a file to Walrus storage and returns the blob ID.
-This blob ID can then be used when creating a Cartridge on Sui.`,
+This blob ID can then be used when creating a Cartridge on Sui.
+
+With --resumable the file is uploaded through the publisher in chunks of
+--chunk-size MiB, each retried on its own, followed by a small manifest blob
+whose ID is returned. Progress is kept in <file>.upload.json, so running the
+same command again after a failure only sends the missing chunks. catalogctl
+(download-blob, download-game, serve, ...) reads such a blob as the whole file.`,
 	RunE: runUploadBlob,
 }
 
 var (
-	uploadFilePath string
-	uploadEpochs   int
+	uploadFilePath     string
+	uploadEpochs       int
+	uploadResumable    bool
+	uploadChunkSize    int
+	uploadChunkRetries int
 )
 
 func init() {
 	uploadBlobCmd.Flags().StringVar(&uploadFilePath, "file", "", "Path to file to upload (required)")
 	uploadBlobCmd.Flags().IntVar(&uploadEpochs, "epochs", 5, "Number of storage epochs")
+	uploadBlobCmd.Flags().BoolVar(&uploadResumable, "resumable", false, "Upload in chunks that survive an interrupted upload")
+	uploadBlobCmd.Flags().IntVar(&uploadChunkSize, "chunk-size", walrus.DefaultChunkSize>>20, "Chunk size in MiB for --resumable")
+	uploadBlobCmd.Flags().IntVar(&uploadChunkRetries, "chunk-retries", walrus.DefaultChunkRetries, "Retries per chunk for --resumable")
 	uploadBlobCmd.MarkFlagRequired("file")
 	rootCmd.AddCommand(uploadBlobCmd)
+	reportsResult(uploadBlobCmd)
 }
 
 func runUploadBlob(cmd *cobra.Command, args []string) error {
@@ -108,44 +226,63 @@ func runUploadBlob(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid file path: %w", err)
 	}
 
-	data, err := os.ReadFile(filePath)
+	// Compute SHA256
+	sha256Hex, size, err := fileSHA256(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// Compute SHA256
-	hash := sha256.Sum256(data)
-	sha256Hex := hex.EncodeToString(hash[:])
-
-	fmt.Printf("Uploading %s (%d bytes)...\n", filepath.Base(filePath), len(data))
-	fmt.Printf("SHA256: %s\n", sha256Hex)
+	statusf("Uploading %s (%d bytes)...\n", filepath.Base(filePath), size)
+	statusf("SHA256: %s\n", sha256Hex)
 
 	// Upload to Walrus
-	walrusClient := walrus.NewClient(cfg.WalrusAggregatorURL, cfg.WalrusPublisherURL)
-	storeResp, err := walrusClient.Store(data, uploadEpochs)
+	backend, err := storageBackend()
 	if err != nil {
-		return fmt.Errorf("failed to upload: %w", err)
+		return err
 	}
-
-	blobID := storeResp.GetBlobID()
-	if blobID == "" {
-		return fmt.Errorf("no blob ID in response")
+	var stored *storage.Stored
+	var manifest *walrus.ChunkManifest
+	if uploadResumable {
+		stored, manifest, err = storeFileResumable(backend, filePath)
+	} else {
+		stored, err = backend.StoreFile(filePath, storage.StoreOptions{Epochs: uploadEpochs})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload: %w", err)
 	}
+	blobID := stored.BlobID
 
 	result := map[string]interface{}{
 		"blob_id":    blobID,
 		"sha256":     sha256Hex,
-		"size_bytes": len(data),
+		"size_bytes": size,
 		"epochs":     uploadEpochs,
 	}
+	if manifest != nil {
+		result["chunks"] = len(manifest.Chunks)
+	}
+	setResult(result)
 
 	jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-	fmt.Println("\n✓ Upload successful!")
-	fmt.Println(string(jsonBytes))
+	statusln("\n✓ Upload successful!")
+	fmt.Fprintln(stdout, string(jsonBytes))
+
+	newGHSummary(fmt.Sprintf("Uploaded %s to Walrus", filepath.Base(filePath))).
+		row("Blob ID", "`"+blobID+"`").
+		row("SHA256", "`"+sha256Hex+"`").
+		row("Size", fmt.Sprintf("%d bytes", size)).
+		row("Epochs", fmt.Sprintf("%d", uploadEpochs)).
+		output("blob_id", blobID).
+		output("sha256", sha256Hex).
+		write()
+
+	if quiet() {
+		return nil
+	}
 
 	// Print sui command helper
-	fmt.Println("\nTo create a Cartridge on Sui, run:")
-	fmt.Printf(`sui client call \
+	i18n.Fprintln(stdout, "\nTo create a Cartridge on Sui, run:")
+	fmt.Fprintf(stdout, `sui client call \
   --package %s \
   --module cartridge \
   --function create_cartridge \
@@ -160,7 +297,7 @@ func runUploadBlob(cmd *cobra.Command, args []string) error {
     %d \
     $(date +%%s)000 \
   --gas-budget 10000000
-`, cfg.PackageID, blobID, sha256Hex, len(data))
+`, cfg.PackageID, blobID, sha256Hex, size)
 
 	return nil
 }
@@ -175,14 +312,32 @@ var listCatalogCmd = &cobra.Command{
 	RunE:  runListCatalog,
 }
 
-var listCatalogID string
+var (
+	listCatalogID             string
+	listCatalogChannel        string
+	listCatalogWithCartridges bool
+	listCatalogTags           []string
+)
 
 func init() {
-	listCatalogCmd.Flags().StringVar(&listCatalogID, "catalog", "", "Catalog object ID (optional, uses config.catalog_id if not set)")
+	listCatalogCmd.Flags().StringVar(&listCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(listCatalogCmd.Flags(), "catalog", formatCatalogRef)
+	listCatalogCmd.Flags().StringVar(&listCatalogChannel, "channel", model.ChannelStable, "Release channel to list: stable, beta or all")
+	listCatalogCmd.Flags().BoolVar(&listCatalogWithCartridges, "with-cartridges", false, "Also read each entry's cartridge (blob ID, SHA256, publisher)")
+	listCatalogCmd.Flags().StringSliceVar(&listCatalogTags, "tag", nil, "Only list entries with this tag (repeatable; entries must have all)")
 	rootCmd.AddCommand(listCatalogCmd)
+	reportsResult(listCatalogCmd)
 }
 
 func runListCatalog(cmd *cobra.Command, args []string) error {
+	channel := listCatalogChannel
+	if channel != "all" {
+		var err error
+		if channel, err = model.ParseChannel(channel); err != nil {
+			return err
+		}
+	}
+
 	// Use flag value or fall back to config
 	catalogID := listCatalogID
 	if catalogID == "" {
@@ -191,6 +346,10 @@ func runListCatalog(cmd *cobra.Command, args []string) error {
 	if catalogID == "" {
 		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
 	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
 
 	client := sui.NewClient(cfg.SuiRPCURL)
 
@@ -209,97 +368,250 @@ func runListCatalog(cmd *cobra.Command, args []string) error {
 	name, _ := fields["name"].(string)
 	description, _ := fields["description"].(string)
 	owner, _ := fields["owner"].(string)
-	count := int64(0)
-	if c, ok := fields["count"].(float64); ok {
-		count = int64(c)
-	}
+	count := parseU64(fields["count"])
 
-	fmt.Printf("Catalog: %s\n", name)
-	fmt.Printf("Description: %s\n", description)
-	fmt.Printf("Owner: %s\n", owner)
-	fmt.Printf("Entries: %d\n\n", count)
+	i18n.Fprintf(stdout, "Catalog: %s\n", name)
+	i18n.Fprintf(stdout, "Description: %s\n", description)
+	i18n.Fprintf(stdout, "Owner: %s\n", owner)
+	i18n.Fprintf(stdout, "Entries: %d\n\n", count)
 
-	// Get dynamic fields (catalog entries)
-	var cursor *string
-	entries := []map[string]interface{}{}
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		return err
+	}
 
-	for {
-		fieldsResp, err := client.GetDynamicFields(catalogID, cursor, 50)
-		if err != nil {
-			return fmt.Errorf("failed to get entries: %w", err)
+	// Other channels are hidden unless asked for
+	if channel != "all" {
+		shown := entries[:0]
+		for _, entry := range entries {
+			if entry.Channel == channel {
+				shown = append(shown, entry)
+			}
 		}
-
-		for _, field := range fieldsResp.Data {
-			// Get dynamic field object
-			fieldObj, err := client.GetDynamicFieldObject(catalogID, field.Name)
-			if err != nil {
-				continue
+		if hidden := len(entries) - len(shown); hidden > 0 {
+			statusf("(%d entries on other channels hidden; use --channel all)\n\n", hidden)
+		}
+		entries = shown
+	}
+	if len(listCatalogTags) > 0 {
+		shown := entries[:0]
+		for _, entry := range entries {
+			if entry.HasTags(listCatalogTags) {
+				shown = append(shown, entry)
 			}
+		}
+		entries = shown
+	}
+	if listCatalogWithCartridges {
+		if err := hydrateCartridges(client, entries); err != nil {
+			return err
+		}
+	}
+	setResult(map[string]interface{}{
+		"catalog_id":  catalogID,
+		"name":        name,
+		"description": description,
+		"owner":       owner,
+		"count":       count,
+		"entries":     entries,
+	})
 
-			if fieldObj.Data == nil {
-				continue
-			}
+	if len(entries) == 0 {
+		i18n.Fprintln(stdout, "No games in catalog.")
+		return nil
+	}
 
-			entryFields := sui.ParseCatalogEntry(fieldObj.Data)
-			if entryFields == nil {
-				continue
-			}
+	// Print key types so numeric or address keys are not mistaken for slugs
+	keyTypes := []string{}
+	seenKeyTypes := map[string]bool{}
+	for _, entry := range entries {
+		if !seenKeyTypes[entry.KeyType] {
+			seenKeyTypes[entry.KeyType] = true
+			keyTypes = append(keyTypes, entry.KeyType)
+		}
+	}
+	i18n.Fprintf(stdout, "Key type: %s\n\n", strings.Join(keyTypes, ", "))
 
-			slug := ""
-			if s, ok := field.Name.Value.(string); ok {
-				slug = s
-			}
+	if listCatalogWithCartridges {
+		fmt.Fprintf(stdout, "%-20s %-30s %-8s %-8s %-20s %-20s %s\n", "SLUG", "TITLE", "PLATFORM", "VERSION", "CARTRIDGE_ID", "BLOB_ID", "PUBLISHER")
+		fmt.Fprintln(stdout, "----------------------------------------------------------------------------------------------------------------------------")
+	} else {
+		fmt.Fprintf(stdout, "%-20s %-30s %-8s %-8s %s\n", "SLUG", "TITLE", "PLATFORM", "VERSION", "CARTRIDGE_ID")
+		fmt.Fprintln(stdout, "----------------------------------------------------------------------------------------")
+	}
 
-			entry := map[string]interface{}{
-				"slug":         slug,
-				"cartridge_id": entryFields["cartridge_id"],
-				"title":        entryFields["title"],
-				"platform":     entryFields["platform"],
-				"size_bytes":   entryFields["size_bytes"],
-				"version":      entryFields["version"],
-			}
-			entries = append(entries, entry)
+	for _, entry := range entries {
+		fmt.Fprintf(stdout, "%-20s %-30s %-8s v%-7d %s",
+			truncate(entry.Slug, 20),
+			truncate(entry.Title, 30),
+			entry.Platform.String(),
+			entry.Version,
+			truncate(entry.CartridgeID, 20),
+		)
+		if c := entry.Cartridge; c != nil {
+			fmt.Fprintf(stdout, " %-20s %s", truncate(c.BlobID, 20), truncate(c.Publisher, 20))
+		} else if listCatalogWithCartridges {
+			fmt.Fprintf(stdout, " %-20s", "(cartridge missing)")
+		}
+		fmt.Fprintln(stdout)
+		if entry.CoverBlobID != "" {
+			fmt.Fprintf(stdout, "%-20s cover: %s\n", "", walrusBlobID(entry.CoverBlobID))
 		}
+		if len(entry.Tags) > 0 {
+			fmt.Fprintf(stdout, "%-20s tags: %s\n", "", strings.Join(entry.Tags, ", "))
+		}
+	}
+
+	return nil
+}
 
-		if !fieldsResp.HasNextPage || fieldsResp.NextCursor == nil {
-			break
+// entryCartridge is the cartridge of an entry as list-catalog --with-cartridges shows it
+type entryCartridge struct {
+	BlobID      string `json:"blob_id"`
+	SHA256      string `json:"sha256"`
+	SizeBytes   uint64 `json:"size_bytes"`
+	Publisher   string `json:"publisher"`
+	CreatedAtMs uint64 `json:"created_at_ms"`
+}
+
+// hydrateCartridges reads the cartridges of entries with batched
+// sui_multiGetObjects calls instead of one call per entry
+func hydrateCartridges(client *sui.Client, entries []catalogEntry) error {
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.CartridgeID
+	}
+	resps, err := client.MultiGetObjects(ids)
+	if err != nil {
+		return fmt.Errorf("failed to get cartridges: %w", err)
+	}
+	for i, resp := range resps {
+		if resp.Data == nil {
+			continue
+		}
+		fields := sui.ParseCatalog(resp.Data)
+		c := &entryCartridge{
+			BlobID:      sui.BytesArrayToHex(fields["blob_id"]),
+			SHA256:      sui.BytesArrayToHex(fields["sha256"]),
+			SizeBytes:   parseU64(fields["size_bytes"]),
+			CreatedAtMs: parseU64(fields["created_at_ms"]),
 		}
-		cursor = fieldsResp.NextCursor
+		c.Publisher, _ = fields["publisher"].(string)
+		entries[i].Cartridge = c
 	}
+	return nil
+}
 
-	if len(entries) == 0 {
-		fmt.Println("No games in catalog.")
-		return nil
+// catalogEntry is one game entry of a catalog and the type of its key
+type catalogEntry struct {
+	model.CatalogEntry
+	KeyType string `json:"key_type"`
+	// Cartridge is only read with --with-cartridges
+	Cartridge *entryCartridge `json:"cartridge,omitempty"`
+}
+
+// fetchCatalogEntries reads every game entry of a catalog
+func fetchCatalogEntries(client *sui.Client, catalogID string) ([]catalogEntry, error) {
+	// Get dynamic fields (catalog entries)
+	dynamicFields, err := client.GetAllDynamicFields(catalogID, 50)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entries: %w", err)
 	}
 
-	fmt.Printf("%-20s %-30s %-8s %-8s %s\n", "SLUG", "TITLE", "PLATFORM", "VERSION", "CARTRIDGE_ID")
-	fmt.Println("----------------------------------------------------------------------------------------")
+	entries := []catalogEntry{}
+	tags := map[string][]string{}
+	for _, field := range dynamicFields {
+		// The set of curator caps is stored next to the entries; it's not a game
+		if strings.HasSuffix(field.Name.Type, "::catalog::CuratorsKey") {
+			continue
+		}
+		// So is the extended metadata of each entry
+		if isEntryMetaKey(field.Name) {
+			slug, entryTags, err := fetchEntryMeta(client, catalogID, field.Name)
+			if err == nil {
+				tags[slug] = entryTags
+			}
+			continue
+		}
 
-	for _, entry := range entries {
-		slug, _ := entry["slug"].(string)
-		title, _ := entry["title"].(string)
-		cartridgeID, _ := entry["cartridge_id"].(string)
+		// Get dynamic field object
+		fieldObj, err := client.GetDynamicFieldObject(catalogID, field.Name)
+		if err != nil {
+			continue
+		}
 
-		platform := uint8(0)
-		if p, ok := entry["platform"].(float64); ok {
-			platform = uint8(p)
+		if fieldObj.Data == nil {
+			continue
 		}
 
-		version := uint16(1)
-		if v, ok := entry["version"].(float64); ok {
-			version = uint16(v)
+		entryFields := sui.ParseCatalogEntry(fieldObj.Data)
+		if entryFields == nil {
+			continue
 		}
 
-		fmt.Printf("%-20s %-30s %-8s v%-7d %s\n",
-			truncate(slug, 20),
-			truncate(title, 30),
-			model.Platform(platform).String(),
-			version,
-			truncate(cartridgeID, 20),
-		)
+		entry := catalogEntry{KeyType: field.Name.KeyType()}
+		entry.Slug = field.Name.KeyString()
+		_, entry.Channel = model.SplitChannelKey(entry.Slug)
+		entry.Version = 1
+		entry.CartridgeID, _ = entryFields["cartridge_id"].(string)
+		entry.Title, _ = entryFields["title"].(string)
+		entry.EmulatorCore, _ = entryFields["emulator_core"].(string)
+		entry.SizeBytes = parseU64(entryFields["size_bytes"])
+		entry.CoverBlobID = sui.BytesArrayToHex(entryFields["cover_blob_id"])
+		if p, ok := entryFields["platform"].(float64); ok {
+			entry.Platform = model.Platform(p)
+		}
+		if v, ok := entryFields["version"].(float64); ok {
+			entry.Version = uint16(v)
+		}
+		entries = append(entries, entry)
+	}
+	for i := range entries {
+		entries[i].Tags = tags[entries[i].Slug]
 	}
+	return entries, nil
+}
 
-	return nil
+// isEntryMetaKey reports whether a catalog dynamic field holds the extended
+// metadata of an entry rather than an entry
+func isEntryMetaKey(name sui.DynamicFieldName) bool {
+	return strings.HasSuffix(name.Type, "::catalog::EntryMetaKey")
+}
+
+// fetchEntryMeta reads an EntryMeta field and returns the slug of its entry
+// and the entry's tags
+func fetchEntryMeta(client *sui.Client, catalogID string, name sui.DynamicFieldName) (string, []string, error) {
+	key, _ := name.Value.(map[string]interface{})
+	slug, _ := key["slug"].(string)
+	fieldObj, err := client.GetDynamicFieldObject(catalogID, name)
+	if err != nil {
+		return "", nil, err
+	}
+	meta := sui.ParseCatalogEntry(fieldObj.Data)
+	if meta == nil {
+		return "", nil, fmt.Errorf("metadata of %s not found", slug)
+	}
+	var tags []string
+	if list, ok := meta["tags"].([]interface{}); ok {
+		for _, tag := range list {
+			if s, ok := tag.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+	}
+	return slug, tags, nil
+}
+
+// parseU64 reads a u64 Move field; the RPC serializes u64 values as strings
+func parseU64(v interface{}) uint64 {
+	switch n := v.(type) {
+	case string:
+		u, _ := strconv.ParseUint(n, 10, 64)
+		return u
+	case float64:
+		return uint64(n)
+	}
+	return 0
 }
 
 // ============================================================================
@@ -316,20 +628,38 @@ var getCartridgeID string
 
 func init() {
 	getCartridgeCmd.Flags().StringVar(&getCartridgeID, "id", "", "Cartridge object ID (required)")
+	validateAs(getCartridgeCmd.Flags(), "id", formatObjectID)
 	getCartridgeCmd.MarkFlagRequired("id")
 	rootCmd.AddCommand(getCartridgeCmd)
+	reportsResult(getCartridgeCmd)
 }
 
 func runGetCartridge(cmd *cobra.Command, args []string) error {
-	client := sui.NewClient(cfg.SuiRPCURL)
+	result, err := readCartridgeDetails(sui.NewClient(cfg.SuiRPCURL), getCartridgeID)
+	if err != nil {
+		return err
+	}
+	setResult(result)
+
+	jsonBytes, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Fprintln(stdout, string(jsonBytes))
 
-	resp, err := client.GetObject(getCartridgeID)
+	return nil
+}
+
+// errCartridgeNotFound is returned by readCartridgeDetails for a missing object
+var errCartridgeNotFound = errors.New("cartridge not found")
+
+// readCartridgeDetails reads a cartridge with its assets and delta, as printed
+// by get-cartridge and served by the gateway
+func readCartridgeDetails(client *sui.Client, cartridgeID string) (map[string]interface{}, error) {
+	resp, err := client.GetObject(cartridgeID)
 	if err != nil {
-		return fmt.Errorf("failed to get cartridge: %w", err)
+		return nil, fmt.Errorf("failed to get cartridge: %w", err)
 	}
 
 	if resp.Data == nil {
-		return fmt.Errorf("cartridge not found")
+		return nil, errCartridgeNotFound
 	}
 
 	fields := sui.ParseCatalog(resp.Data)
@@ -352,10 +682,34 @@ func runGetCartridge(cmd *cobra.Command, args []string) error {
 		"created_at_ms": fields["created_at_ms"],
 	}
 
-	jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-	fmt.Println(string(jsonBytes))
-
-	return nil
+	// Extra files published with the game (manual, soundtrack, ...)
+	assets, err := fetchCartridgeAssets(client, resp.Data.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(assets) > 0 {
+		result["assets"] = assets
+	}
+	// The cover and screenshots are assets with reserved names
+	var screenshots []string
+	for _, asset := range assets {
+		if asset.Name == coverAssetName {
+			result["cover_blob_id"] = asset.BlobID
+		} else if strings.HasPrefix(asset.Name, screenshotAssetPrefix) {
+			screenshots = append(screenshots, asset.BlobID)
+		}
+	}
+	if len(screenshots) > 0 {
+		result["screenshot_blob_ids"] = screenshots
+	}
+	d, err := fetchCartridgeDelta(client, resp.Data.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if d != nil {
+		result["delta"] = d
+	}
+	return result, nil
 }
 
 // ============================================================================
@@ -365,43 +719,68 @@ func runGetCartridge(cmd *cobra.Command, args []string) error {
 var downloadBlobCmd = &cobra.Command{
 	Use:   "download-blob",
 	Short: "Download a blob from Walrus",
-	RunE:  runDownloadBlob,
+	Long: `Downloads a blob to --out-file through a partial file (<file>.part) with
+its state in <file>.part.json. If the download is interrupted, running the
+same command again resumes it with Range requests. With --sha256 the file is
+checked before it is moved into place.`,
+	RunE: runDownloadBlob,
 }
 
 var (
-	downloadBlobID string
-	downloadOutput string
+	downloadBlobID     string
+	downloadOutput     string
+	downloadBlobSHA256 string
 )
 
 func init() {
 	downloadBlobCmd.Flags().StringVar(&downloadBlobID, "blob-id", "", "Walrus blob ID (required)")
-	downloadBlobCmd.Flags().StringVar(&downloadOutput, "output", "", "Output file path (required)")
+	validateAs(downloadBlobCmd.Flags(), "blob-id", formatBlobID)
+	outFileFlag(downloadBlobCmd, &downloadOutput, "File to write (required)")
+	downloadBlobCmd.Flags().StringVar(&downloadBlobSHA256, "sha256", "", "Expected SHA256 (hex) of the blob")
 	downloadBlobCmd.MarkFlagRequired("blob-id")
-	downloadBlobCmd.MarkFlagRequired("output")
 	rootCmd.AddCommand(downloadBlobCmd)
+	reportsResult(downloadBlobCmd)
 }
 
 func runDownloadBlob(cmd *cobra.Command, args []string) error {
-	walrusClient := walrus.NewClient(cfg.WalrusAggregatorURL, cfg.WalrusPublisherURL)
+	if downloadOutput == "" {
+		return fmt.Errorf("--out-file is required")
+	}
+	expectedSHA := strings.ToLower(strings.TrimPrefix(downloadBlobSHA256, "0x"))
+	if expectedSHA != "" {
+		if b, err := hex.DecodeString(expectedSHA); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("invalid --sha256: expected 64 hex characters")
+		}
+	}
 
-	fmt.Printf("Downloading blob %s...\n", downloadBlobID)
+	statusf("Downloading blob %s...\n", downloadBlobID)
 
-	data, err := walrusClient.ReadWithRetry(downloadBlobID, 3)
+	var sha256Hex, aggregator string
+	var size int64
+	var err error
+	if manifest := fetchChunkManifest(downloadBlobID); manifest != nil {
+		i18n.Fprintf(stdout, "  Chunked upload: %d chunks, %d bytes\n", len(manifest.Chunks), manifest.Size)
+		sha256Hex, size, aggregator, err = downloadChunkedBlob(manifest, downloadOutput, expectedSHA)
+	} else {
+		sha256Hex, size, aggregator, err = downloadBlobResumable(downloadBlobID, downloadOutput, expectedSHA)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
 	}
 
-	// Compute SHA256 of downloaded data
-	hash := sha256.Sum256(data)
-	sha256Hex := hex.EncodeToString(hash[:])
-
-	// Write to file
-	if err := os.WriteFile(downloadOutput, data, 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+	statusf("✓ Downloaded %d bytes to %s\n", size, downloadOutput)
+	i18n.Fprintf(stdout, "  SHA256: %s\n", sha256Hex)
+	if expectedSHA != "" {
+		statusf("  ✓ Matches the expected SHA256\n")
 	}
-
-	fmt.Printf("✓ Downloaded %d bytes to %s\n", len(data), downloadOutput)
-	fmt.Printf("  SHA256: %s\n", sha256Hex)
+	i18n.Fprintf(stdout, "  Aggregator: %s\n", aggregator)
+	setResult(map[string]interface{}{
+		"blob_id":    downloadBlobID,
+		"path":       downloadOutput,
+		"sha256":     sha256Hex,
+		"size_bytes": size,
+		"aggregator": aggregator,
+	})
 
 	return nil
 }
@@ -420,13 +799,19 @@ var createCatalogCmd = &cobra.Command{
 var (
 	createCatalogName string
 	createCatalogDesc string
+	createCatalogSave bool
 )
 
 func init() {
 	createCatalogCmd.Flags().StringVar(&createCatalogName, "name", "", "Catalog name (required)")
 	createCatalogCmd.Flags().StringVar(&createCatalogDesc, "description", "", "Catalog description")
+	createCatalogCmd.Flags().BoolVar(&createCatalogSave, "save-config", false, "Save the new catalog ID as catalog_id in the active config file")
+	createCatalogCmd.Flags().BoolVar(&createCatalogSave, "save", false, "Alias for --save-config")
+	createCatalogCmd.Flags().MarkHidden("save")
+	addUnsignedFlags(createCatalogCmd)
 	createCatalogCmd.MarkFlagRequired("name")
 	rootCmd.AddCommand(createCatalogCmd)
+	reportsResult(createCatalogCmd)
 }
 
 func runCreateCatalog(cmd *cobra.Command, args []string) error {
@@ -434,20 +819,19 @@ func runCreateCatalog(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("package_id is required in config file")
 	}
 
-	fmt.Printf("Creating catalog '%s'...\n", createCatalogName)
-
-	// Execute sui client call
-	cmdArgs := []string{
-		"client", "call",
-		"--package", cfg.PackageID,
-		"--module", "catalog",
-		"--function", "create_catalog",
-		"--args", createCatalogName, createCatalogDesc,
-		"--gas-budget", "10000000",
-		"--json",
+	if unsignedOut != "" {
+		sender, err := txSigner()
+		if err != nil {
+			return err
+		}
+		statusf("Building unsigned transaction to create catalog '%s'...\n", createCatalogName)
+		tx := (&ptb{}).moveCall("catalog", "create_catalog", ptbString(createCatalogName), ptbString(createCatalogDesc))
+		return writeUnsignedTx(tx, sender)
 	}
 
-	output, err := executeSuiCommand(cmdArgs)
+	statusf("Creating catalog '%s'...\n", createCatalogName)
+
+	output, err := executeMoveCall(sui.CreateCatalog(cfg.PackageID, createCatalogName, createCatalogDesc))
 	if err != nil {
 		return fmt.Errorf("failed to create catalog: %w", err)
 	}
@@ -462,14 +846,28 @@ func runCreateCatalog(cmd *cobra.Command, args []string) error {
 						if objectType, ok := changeMap["objectType"].(string); ok {
 							if strings.Contains(objectType, "Catalog") {
 								if objectId, ok := changeMap["objectId"].(string); ok {
-									fmt.Printf("\n✓ Catalog created successfully!\n")
-									fmt.Printf("Catalog ID: %s\n", objectId)
-									fmt.Printf("Transaction: %s\n", result["digest"])
+									statusf("\n✓ Catalog created successfully!\n")
+									digest, _ := result["digest"].(string)
+									i18n.Fprintf(stdout, "Catalog ID: %s\n", objectId)
+									printExplorerLink("  ", config.LinkObject, objectId)
+									i18n.Fprintf(stdout, "Transaction: %s\n", digest)
+									printExplorerLink("  ", config.LinkTx, digest)
+
+									newGHSummary(fmt.Sprintf("Created catalog %s", createCatalogName)).
+										row("Catalog", mdLink(objectId, explorerURL(config.LinkObject, objectId))).
+										row("Transaction", mdLink(digest, explorerURL(config.LinkTx, digest))).
+										row("Gas used", formatSUI(sui.TransactionGasCost(output))).
+										output("catalog_id", objectId).
+										output("digest", digest).
+										write()
 									
-									// Update config if catalog_id is empty
-									if cfg.CatalogID == "" {
-										fmt.Printf("\n💡 Tip: Add this to your config.json:\n")
-										fmt.Printf("  \"catalog_id\": \"%s\"\n", objectId)
+									// Save to config, or suggest it if catalog_id is empty
+									if createCatalogSave {
+										return saveConfigValue("catalog_id", objectId)
+									}
+									if cfg.CatalogID == "" && !quiet() {
+										statusf("\n💡 Tip: Save it as the default catalog with --save-config next time, or run:\n")
+										fmt.Fprintf(stdout, "  catalogctl config set catalog_id %s\n", objectId)
 									}
 									return nil
 								}
@@ -482,7 +880,7 @@ func runCreateCatalog(cmd *cobra.Command, args []string) error {
 	}
 
 	// Fallback: just print the output
-	fmt.Println(output)
+	fmt.Fprintln(stdout, output)
 	return nil
 }
 
@@ -506,6 +904,7 @@ func init() {
 	genCreateCatalogCmd.Flags().StringVar(&genCatalogDesc, "description", "", "Catalog description")
 	genCreateCatalogCmd.MarkFlagRequired("name")
 	rootCmd.AddCommand(genCreateCatalogCmd)
+	reportsResult(genCreateCatalogCmd)
 }
 
 func runGenCreateCatalog(cmd *cobra.Command, args []string) error {
@@ -513,9 +912,10 @@ func runGenCreateCatalog(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("package_id is required in config file")
 	}
 
-	fmt.Println("Run this command to create the catalog:")
-	fmt.Println()
-	fmt.Printf(`sui client call \
+	setResult(suiCallResult("create_catalog", genCatalogName, genCatalogDesc))
+	i18n.Fprintln(stdout, "Run this command to create the catalog:")
+	fmt.Fprintln(stdout)
+	fmt.Fprintf(stdout, `sui client call \
   --package %s \
   --module catalog \
   --function create_catalog \
@@ -526,6 +926,18 @@ func runGenCreateCatalog(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// suiCallResult is the result of the gen-* commands: the catalog module
+// call they print as a sui client command
+func suiCallResult(function string, args ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"package":    cfg.PackageID,
+		"module":     "catalog",
+		"function":   function,
+		"args":       args,
+		"gas_budget": 10000000,
+	}
+}
+
 // ============================================================================
 // add-entry command (executes transaction)
 // ============================================================================
@@ -546,23 +958,31 @@ var (
 	addEntrySizeBytes   uint64
 	addEntryEmulator    string
 	addEntryVersion     uint16
+	addEntryCapID       string
+	addEntryChannel     string
 )
 
 func init() {
-	addEntryCmd.Flags().StringVar(&addEntryCatalogID, "catalog", "", "Catalog object ID (optional, uses config.catalog_id if not set)")
+	addEntryCmd.Flags().StringVar(&addEntryCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(addEntryCmd.Flags(), "catalog", formatCatalogRef)
 	addEntryCmd.Flags().StringVar(&addEntrySlug, "slug", "", "Entry slug (required)")
 	addEntryCmd.Flags().StringVar(&addEntryCartridgeID, "cartridge", "", "Cartridge object ID (required)")
+	validateAs(addEntryCmd.Flags(), "cartridge", formatObjectID)
 	addEntryCmd.Flags().StringVar(&addEntryTitle, "title", "", "Game title (required)")
 	addEntryCmd.Flags().StringVar(&addEntryPlatform, "platform", "dos", "Platform: dos, gb, gbc, nes, snes")
 	addEntryCmd.Flags().Uint64Var(&addEntrySizeBytes, "size", 0, "Size in bytes (required)")
 	addEntryCmd.Flags().StringVar(&addEntryEmulator, "emulator", "", "Emulator core (auto-detected if empty)")
 	addEntryCmd.Flags().Uint16Var(&addEntryVersion, "version", 1, "Version number")
+	addEntryCmd.Flags().StringVar(&addEntryCapID, "cap", "", "CuratorCap object ID (auto-selected if the signer isn't the catalog owner)")
+	addEntryCmd.Flags().StringVar(&addEntryChannel, "channel", model.ChannelStable, "Release channel: stable, or beta to hide the entry from default listings")
 
 	addEntryCmd.MarkFlagRequired("slug")
 	addEntryCmd.MarkFlagRequired("cartridge")
 	addEntryCmd.MarkFlagRequired("title")
+	addUnsignedFlags(addEntryCmd)
 	addEntryCmd.MarkFlagRequired("size")
 	rootCmd.AddCommand(addEntryCmd)
+	reportsResult(addEntryCmd)
 }
 
 func runAddEntry(cmd *cobra.Command, args []string) error {
@@ -578,49 +998,80 @@ func runAddEntry(cmd *cobra.Command, args []string) error {
 	if catalogID == "" {
 		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
 	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
 
 	platform, err := model.ParsePlatform(addEntryPlatform)
 	if err != nil {
 		return err
 	}
 
+	channel, err := model.ParseChannel(addEntryChannel)
+	if err != nil {
+		return err
+	}
+	slug := model.ChannelKey(addEntrySlug, channel)
+
 	emulator := addEntryEmulator
 	if emulator == "" {
 		emulator = model.EmulatorCoreForPlatform(platform)
 	}
 
-	fmt.Printf("Adding entry '%s' to catalog %s...\n", addEntrySlug, catalogID)
-
-	// Execute sui client call
-	cmdArgs := []string{
-		"client", "call",
-		"--package", cfg.PackageID,
-		"--module", "catalog",
-		"--function", "add_entry",
-		"--args",
-		catalogID,
-		addEntrySlug,
-		addEntryCartridgeID,
-		addEntryTitle,
-		fmt.Sprintf("%d", platform),
-		fmt.Sprintf("%d", addEntrySizeBytes),
-		emulator,
-		fmt.Sprintf("%d", addEntryVersion),
-		"[]",
-		"--gas-budget", "10000000",
-		"--json",
-	}
-
-	output, err := executeSuiCommand(cmdArgs)
+	capID, err := resolveCuratorCap(catalogID, addEntryCapID)
+	if err != nil {
+		return err
+	}
+
+	if unsignedOut != "" {
+		sender, err := txSigner()
+		if err != nil {
+			return err
+		}
+		statusf("Building unsigned transaction to add entry '%s' to catalog %s...\n", slug, catalogID)
+		tx := &ptb{}
+		addEntryCall(tx, catalogID, capID, slug, ptbObject(addEntryCartridgeID), addEntryTitle,
+			platform, addEntrySizeBytes, emulator, addEntryVersion, "vector[]")
+		return writeUnsignedTx(tx, sender)
+	}
+
+	statusf("Adding entry '%s' to catalog %s...\n", slug, catalogID)
+
+	output, err := callAddEntry(catalogID, capID, sui.EntryArgs{
+		Key:          slug,
+		CartridgeID:  addEntryCartridgeID,
+		Title:        addEntryTitle,
+		Platform:     uint8(platform),
+		SizeBytes:    addEntrySizeBytes,
+		EmulatorCore: emulator,
+		Version:      addEntryVersion,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to add entry: %w", err)
 	}
 
-	fmt.Printf("\n✓ Entry added successfully!\n")
-	fmt.Printf("Transaction: %s\n", extractDigest(output))
+	digest := sui.TransactionDigest(output)
+	statusf("\n✓ Entry added successfully!\n")
+	i18n.Fprintf(stdout, "Transaction: %s\n", digest)
+	printExplorerLink("  ", config.LinkTx, digest)
+
+	newGHSummary(fmt.Sprintf("Added %s to catalog", slug)).
+		row("Catalog", mdLink(catalogID, explorerURL(config.LinkObject, catalogID))).
+		row("Cartridge", mdLink(addEntryCartridgeID, explorerURL(config.LinkObject, addEntryCartridgeID))).
+		row("Transaction", mdLink(digest, explorerURL(config.LinkTx, digest))).
+		row("Gas used", formatSUI(sui.TransactionGasCost(output))).
+		output("digest", digest).
+		write()
 	return nil
 }
 
+// callAddEntry adds an entry and returns the transaction's JSON output.
+// Owners call add_entry; curators pass their cap to add_entry_with_cap.
+func callAddEntry(catalogID, capID string, entry sui.EntryArgs) (string, error) {
+	return executeMoveCall(sui.AddEntry(cfg.PackageID, catalogID, capID, entry))
+}
+
 // ============================================================================
 // gen-add-entry command (generates sui CLI command)
 // ============================================================================
@@ -643,9 +1094,11 @@ var (
 )
 
 func init() {
-	genAddEntryCmd.Flags().StringVar(&genEntryCatalogID, "catalog", "", "Catalog object ID (optional, uses config.catalog_id if not set)")
+	genAddEntryCmd.Flags().StringVar(&genEntryCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(genAddEntryCmd.Flags(), "catalog", formatCatalogRef)
 	genAddEntryCmd.Flags().StringVar(&genEntrySlug, "slug", "", "Entry slug (required)")
 	genAddEntryCmd.Flags().StringVar(&genEntryCartridgeID, "cartridge", "", "Cartridge object ID (required)")
+	validateAs(genAddEntryCmd.Flags(), "cartridge", formatObjectID)
 	genAddEntryCmd.Flags().StringVar(&genEntryTitle, "title", "", "Game title (required)")
 	genAddEntryCmd.Flags().StringVar(&genEntryPlatform, "platform", "dos", "Platform: dos, gb, gbc, nes, snes")
 	genAddEntryCmd.Flags().Uint64Var(&genEntrySizeBytes, "size", 0, "Size in bytes (required)")
@@ -657,6 +1110,7 @@ func init() {
 	genAddEntryCmd.MarkFlagRequired("title")
 	genAddEntryCmd.MarkFlagRequired("size")
 	rootCmd.AddCommand(genAddEntryCmd)
+	reportsResult(genAddEntryCmd)
 }
 
 func runGenAddEntry(cmd *cobra.Command, args []string) error {
@@ -672,6 +1126,10 @@ func runGenAddEntry(cmd *cobra.Command, args []string) error {
 	if catalogID == "" {
 		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
 	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
 
 	platform, err := model.ParsePlatform(genEntryPlatform)
 	if err != nil {
@@ -683,9 +1141,11 @@ func runGenAddEntry(cmd *cobra.Command, args []string) error {
 		emulator = model.EmulatorCoreForPlatform(platform)
 	}
 
-	fmt.Println("Run this command to add the entry:")
-	fmt.Println()
-	fmt.Printf(`sui client call \
+	setResult(suiCallResult("add_entry", catalogID, genEntrySlug, genEntryCartridgeID,
+		genEntryTitle, platform, genEntrySizeBytes, emulator, genEntryVersion, []string{}))
+	i18n.Fprintln(stdout, "Run this command to add the entry:")
+	fmt.Fprintln(stdout)
+	fmt.Fprintf(stdout, `sui client call \
   --package %s \
   --module catalog \
   --function add_entry \
@@ -720,13 +1180,17 @@ var removeEntryCmd = &cobra.Command{
 var (
 	removeEntryCatalogID string
 	removeEntrySlug     string
+	removeEntryCapID     string
 )
 
 func init() {
-	removeEntryCmd.Flags().StringVar(&removeEntryCatalogID, "catalog", "", "Catalog object ID (optional, uses config.catalog_id if not set)")
+	removeEntryCmd.Flags().StringVar(&removeEntryCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(removeEntryCmd.Flags(), "catalog", formatCatalogRef)
 	removeEntryCmd.Flags().StringVar(&removeEntrySlug, "slug", "", "Entry slug to remove (required)")
+	removeEntryCmd.Flags().StringVar(&removeEntryCapID, "cap", "", "CuratorCap object ID (auto-selected if the signer isn't the catalog owner)")
 	removeEntryCmd.MarkFlagRequired("slug")
 	rootCmd.AddCommand(removeEntryCmd)
+	reportsResult(removeEntryCmd)
 }
 
 func runRemoveEntry(cmd *cobra.Command, args []string) error {
@@ -742,32 +1206,40 @@ func runRemoveEntry(cmd *cobra.Command, args []string) error {
 	if catalogID == "" {
 		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
 	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
 
-	fmt.Printf("Removing entry '%s' from catalog %s...\n", removeEntrySlug, catalogID)
-
-	// Execute sui client call
-	cmdArgs := []string{
-		"client", "call",
-		"--package", cfg.PackageID,
-		"--module", "catalog",
-		"--function", "remove_entry",
-		"--args",
-		catalogID,
-		removeEntrySlug,
-		"--gas-budget", "10000000",
-		"--json",
+	capID, err := resolveCuratorCap(catalogID, removeEntryCapID)
+	if err != nil {
+		return err
 	}
 
-	output, err := executeSuiCommand(cmdArgs)
+	statusf("Removing entry '%s' from catalog %s...\n", removeEntrySlug, catalogID)
+
+	digest, err := removeCatalogEntry(catalogID, capID, removeEntrySlug)
 	if err != nil {
-		return fmt.Errorf("failed to remove entry: %w", err)
+		return err
 	}
 
-	fmt.Printf("\n✓ Entry removed successfully!\n")
-	fmt.Printf("Transaction: %s\n", extractDigest(output))
+	setResult(map[string]interface{}{"catalog_id": catalogID, "slug": removeEntrySlug, "digest": digest})
+	statusf("\n✓ Entry removed successfully!\n")
+	i18n.Fprintf(stdout, "Transaction: %s\n", digest)
+	printExplorerLink("  ", config.LinkTx, digest)
 	return nil
 }
 
+// removeCatalogEntry removes an entry and returns the transaction digest.
+// Owners call remove_entry; curators pass their cap to remove_entry_with_cap.
+func removeCatalogEntry(catalogID, capID, slug string) (string, error) {
+	output, err := executeMoveCall(sui.RemoveEntry(cfg.PackageID, catalogID, capID, slug))
+	if err != nil {
+		return "", fmt.Errorf("failed to remove entry: %w", err)
+	}
+	return sui.TransactionDigest(output), nil
+}
+
 // ============================================================================
 // gen-remove-entry command (generates sui CLI command)
 // ============================================================================
@@ -784,10 +1256,12 @@ var (
 )
 
 func init() {
-	genRemoveEntryCmd.Flags().StringVar(&genRemoveEntryCatalogID, "catalog", "", "Catalog object ID (optional, uses config.catalog_id if not set)")
+	genRemoveEntryCmd.Flags().StringVar(&genRemoveEntryCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(genRemoveEntryCmd.Flags(), "catalog", formatCatalogRef)
 	genRemoveEntryCmd.Flags().StringVar(&genRemoveEntrySlug, "slug", "", "Entry slug to remove (required)")
 	genRemoveEntryCmd.MarkFlagRequired("slug")
 	rootCmd.AddCommand(genRemoveEntryCmd)
+	reportsResult(genRemoveEntryCmd)
 }
 
 func runGenRemoveEntry(cmd *cobra.Command, args []string) error {
@@ -803,10 +1277,15 @@ func runGenRemoveEntry(cmd *cobra.Command, args []string) error {
 	if catalogID == "" {
 		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
 	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
 
-	fmt.Println("Run this command to remove the entry:")
-	fmt.Println()
-	fmt.Printf(`sui client call \
+	setResult(suiCallResult("remove_entry", catalogID, genRemoveEntrySlug))
+	i18n.Fprintln(stdout, "Run this command to remove the entry:")
+	fmt.Fprintln(stdout)
+	fmt.Fprintf(stdout, `sui client call \
   --package %s \
   --module catalog \
   --function remove_entry \
@@ -826,8 +1305,28 @@ func runGenRemoveEntry(cmd *cobra.Command, args []string) error {
 var publishGameCmd = &cobra.Command{
 	Use:   "publish-game",
 	Short: "Publish a game: upload to Walrus, create cartridge, and add to catalog",
-	Long:  `Complete workflow: uploads file to Walrus, creates cartridge on Sui, and adds entry to catalog.`,
-	RunE:  runPublishGame,
+	Long: `Complete workflow: uploads file to Walrus, creates cartridge on Sui, and adds entry to catalog.
+
+Every step is recorded in a journal (default: publish-<slug>-v<version>.journal.json).
+If a run fails, run the same command again to continue where it stopped.
+With --events, structured step events (step, status, attempt, IDs produced)
+are written as JSON Lines for wrappers and GUIs.
+
+Extra files (manual, soundtrack, ...) are attached with --asset NAME=PATH,
+repeated once per file. Each becomes its own Walrus blob, listed by
+get-cartridge and downloadable with download-game --asset NAME.
+
+With --delta-from, a new version is published as a patch against an earlier
+cartridge (an object ID, or a slug of the catalog): only the changed bytes
+are stored on Walrus, and download-game rebuilds the file from its base.
+The base is downloaded unless --base-file points to a local copy.
+
+--cover attaches a cover image (the asset "cover", whose blob is also stored
+in the catalog entry for listings) and --screenshots attaches screenshots
+(screenshot-1, screenshot-2, ...). With --image-max-size, larger images are
+shrunk locally before upload; --webp converts PNG and JPEG images to WebP
+with cwebp. Converted images are written next to the originals.`,
+	RunE: runPublishGame,
 }
 
 var (
@@ -839,6 +1338,22 @@ var (
 	publishGameVersion   uint16
 	publishGameEpochs    int
 	publishGameCatalogID string
+	publishGameDryRun    bool
+	publishGameEstimate  bool
+	publishGamePlanOut   string
+	publishGameRequest   string
+	publishGameReqTTL    time.Duration
+	publishGameCapID     string
+	publishGameJournal   string
+	publishGameEvents    string
+	publishGameChannel   string
+	publishGameAssets    []string
+	publishGameDeltaFrom string
+	publishGameBaseFile  string
+	publishGameCover     string
+	publishGameShots     []string
+	publishGameImageMax  int
+	publishGameWebP      bool
 )
 
 func init() {
@@ -849,12 +1364,33 @@ func init() {
 	publishGameCmd.Flags().StringVar(&publishGameEmulator, "emulator", "", "Emulator core (auto-detected if empty)")
 	publishGameCmd.Flags().Uint16Var(&publishGameVersion, "version", 1, "Version number")
 	publishGameCmd.Flags().IntVar(&publishGameEpochs, "epochs", 5, "Number of storage epochs for Walrus")
-	publishGameCmd.Flags().StringVar(&publishGameCatalogID, "catalog", "", "Catalog object ID (optional, uses config.catalog_id if not set)")
+	publishGameCmd.Flags().StringVar(&publishGameCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(publishGameCmd.Flags(), "catalog", formatCatalogRef)
+	publishGameCmd.Flags().BoolVar(&publishGameDryRun, "dry-run", false, "Show the publish plan with cost and time estimates without executing it")
+	publishGameCmd.Flags().BoolVar(&publishGameEstimate, "estimate", false, "Like --dry-run, plus the Walrus storage cost at current prices and the Sui gas of a dry-run transaction")
+	publishGameCmd.Flags().StringVar(&publishGamePlanOut, "plan-out", "", "With --dry-run or --estimate: write the machine-readable plan to this file")
+	publishGameCmd.Flags().StringVar(&publishGameCapID, "cap", "", "CuratorCap object ID (auto-selected if the signer isn't the catalog owner)")
+	publishGameCmd.Flags().StringVar(&publishGameRequest, "request-out", "", "Approval request file written on mainnet when approvers are configured (default: publish-<slug>.request.json)")
+	publishGameCmd.Flags().DurationVar(&publishGameReqTTL, "request-ttl", approval.DefaultLifetime, "How long the approval request can be executed")
+
+	publishGameCmd.Flags().StringVar(&publishGameJournal, "journal", "", "Journal file recording completed steps (default: publish-<slug>-v<version>.journal.json)")
+	publishGameCmd.Flags().StringVar(&publishGameEvents, "events", "", "Write step events as JSON Lines to this file (- for stderr)")
+	publishGameCmd.Flags().StringVar(&publishGameChannel, "channel", model.ChannelStable, "Release channel: stable, or beta to hide the entry from default listings")
+	publishGameCmd.Flags().StringArrayVar(&publishGameAssets, "asset", nil, "Extra file attached to the cartridge as NAME=PATH, e.g. manual=manual.pdf (repeatable)")
+	publishGameCmd.Flags().StringVar(&publishGameDeltaFrom, "delta-from", "", "Publish a patch against this cartridge (object ID or catalog slug) instead of the full file")
+	publishGameCmd.Flags().StringVar(&publishGameBaseFile, "base-file", "", "With --delta-from: local copy of the base version (downloaded if not set)")
+	publishGameCmd.Flags().StringVar(&publishGameCover, "cover", "", "Cover image (PNG, JPEG, GIF or WebP) shown in catalog listings")
+	publishGameCmd.Flags().StringSliceVar(&publishGameShots, "screenshots", nil, "Screenshot images attached to the cartridge, in order (comma-separated or repeated)")
+	publishGameCmd.Flags().IntVar(&publishGameImageMax, "image-max-size", 0, "Shrink cover and screenshots whose longest side exceeds this many pixels (0 keeps the size)")
+	publishGameCmd.Flags().BoolVar(&publishGameWebP, "webp", false, "Convert PNG and JPEG cover and screenshots to WebP before upload (needs cwebp)")
+	publishGameCmd.Flags().IntVar(&planConcurrency, "concurrency", 1, "Upload up to this many blobs (game file, assets) to Walrus at once")
+	addUnsignedFlags(publishGameCmd)
 
 	publishGameCmd.MarkFlagRequired("file")
 	publishGameCmd.MarkFlagRequired("slug")
 	publishGameCmd.MarkFlagRequired("title")
 	rootCmd.AddCommand(publishGameCmd)
+	reportsResult(publishGameCmd)
 }
 
 func runPublishGame(cmd *cobra.Command, args []string) error {
@@ -870,150 +1406,184 @@ func runPublishGame(cmd *cobra.Command, args []string) error {
 	if catalogID == "" {
 		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
 	}
-	// Validate catalog ID format (must start with 0x)
-	if !strings.HasPrefix(catalogID, "0x") {
-		return fmt.Errorf("invalid catalog ID format: %s (must start with 0x). Use a valid object ID or omit --catalog to use config.catalog_id", catalogID)
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
+	// Validate catalog ID format (flags are checked up front, config values here)
+	if err := validate.ObjectID(catalogID); err != nil {
+		return fmt.Errorf("invalid catalog ID %s: %w. Use a valid object ID or omit --catalog to use config.catalog_id", catalogID, err)
+	}
+
+	platform, err := model.ParsePlatform(publishGamePlatform)
+	if err != nil {
+		return err
+	}
+	channel, err := model.ParseChannel(publishGameChannel)
+	if err != nil {
+		return err
+	}
+
+	emulator := publishGameEmulator
+	if emulator == "" {
+		emulator = model.EmulatorCoreForPlatform(platform)
 	}
 
-	// Step 1: Read and upload file to Walrus
-	fmt.Println("[1/3] Uploading to Walrus...")
 	filePath, err := filepath.Abs(publishGameFile)
 	if err != nil {
 		return fmt.Errorf("invalid file path: %w", err)
 	}
 
-	data, err := os.ReadFile(filePath)
+	// Compute SHA256
+	sha256Hex, size, err := fileSHA256(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
-
-	// Compute SHA256
-	hash := sha256.Sum256(data)
-	sha256Hex := hex.EncodeToString(hash[:])
-
-	fmt.Printf("  File: %s (%d bytes)\n", filepath.Base(filePath), len(data))
-	fmt.Printf("  SHA256: %s\n", sha256Hex)
-	fmt.Printf("  Publisher URL: %s\n", cfg.WalrusPublisherURL)
-
-	// Upload to Walrus (will fallback to CLI if HTTP fails)
-	walrusClient := walrus.NewClient(cfg.WalrusAggregatorURL, cfg.WalrusPublisherURL)
-	storeResp, err := walrusClient.Store(data, publishGameEpochs)
+	assets, err := parseAssets(publishGameAssets)
 	if err != nil {
-		if strings.Contains(err.Error(), "walrus CLI failed") {
-			return fmt.Errorf("failed to upload to Walrus: %w\n\n"+
-				"All publisher nodes failed. Installing Walrus CLI:\n"+
-				"  cargo install --git https://github.com/MystenLabs/walrus.git walrus\n\n"+
-				"Then run the command again. The CLI uses your own SUI balance.", err)
-		}
-		return fmt.Errorf("failed to upload to Walrus: %w", err)
+		return err
 	}
-
-	blobID := storeResp.GetBlobID()
-	if blobID == "" {
-		return fmt.Errorf("no blob ID in response")
+	if publishGameImageMax < 0 {
+		return fmt.Errorf("--image-max-size must not be negative")
 	}
-
-	fmt.Printf("  ✓ Uploaded! Blob ID: %s\n", blobID)
-
-	// Step 2: Create cartridge on Sui
-	fmt.Println("\n[2/3] Creating cartridge on Sui...")
-
-	platform, err := model.ParsePlatform(publishGamePlatform)
+	images, err := prepareImages(publishGameCover, publishGameShots, imageOptions{MaxSize: publishGameImageMax, WebP: publishGameWebP})
 	if err != nil {
 		return err
 	}
-
-	emulator := publishGameEmulator
-	if emulator == "" {
-		emulator = model.EmulatorCoreForPlatform(platform)
+	if assets, err = mergeAssets(assets, images); err != nil {
+		return err
 	}
 
-	// Get current timestamp in milliseconds
-	now := time.Now().UnixMilli()
+	// Delta updates store a patch against an earlier version
+	var deltaParams *deltaParam
+	if publishGameDeltaFrom != "" {
+		client := sui.NewClient(cfg.SuiRPCURL)
+		baseID := publishGameDeltaFrom
+		if !strings.HasPrefix(baseID, "0x") {
+			if baseID, err = entryCartridgeID(client, catalogID, baseID); err != nil {
+				return err
+			}
+		}
+		if deltaParams, err = prepareDelta(client, baseID, publishGameBaseFile, filePath, size); err != nil {
+			return err
+		}
+	}
 
-	// Decode blob ID from base58 to bytes, then convert to hex
-	// Using akamensky/base58 which supports custom alphabets
-	// Walrus uses the full Base58 alphabet (including lowercase 'l')
-	blobIDBytes, err := base58.Decode(blobID)
-	if err != nil {
-		return fmt.Errorf("failed to decode blob ID from base58: %w", err)
-	}
-	blobIDHex := hex.EncodeToString(blobIDBytes)
-
-	// Execute sui client call to create cartridge
-	createCartridgeArgs := []string{
-		"client", "call",
-		"--package", cfg.PackageID,
-		"--module", "cartridge",
-		"--function", "create_cartridge",
-		"--args",
-		publishGameSlug,
-		publishGameTitle,
-		fmt.Sprintf("%d", platform),
-		emulator,
-		fmt.Sprintf("%d", publishGameVersion),
-		"0x" + blobIDHex,
-		"0x" + sha256Hex,
-		fmt.Sprintf("%d", len(data)),
-		fmt.Sprintf("%d", now),
-		"--gas-budget", "10000000",
-		"--json",
-	}
-
-	createOutput, err := executeSuiCommand(createCartridgeArgs)
+	// Curators add the entry with their cap instead of as the owner
+	capID, err := resolveCuratorCap(catalogID, publishGameCapID)
 	if err != nil {
-		return fmt.Errorf("failed to create cartridge: %w", err)
-	}
+		if !publishGameDryRun && !publishGameEstimate {
+			return err
+		}
+		statusf("⚠️  Could not determine catalog permissions (%v); planning as owner\n\n", err)
+	}
+
+	// publish-game is the execution of its own plan, so a dry-run plan
+	// describes exactly what a real run does
+	params := publishGameParams{
+		FilePath:  filePath,
+		Size:      size,
+		SHA256Hex: sha256Hex,
+		Slug:      publishGameSlug,
+		Title:     publishGameTitle,
+		Platform:  platform,
+		Emulator:  emulator,
+		Version:   publishGameVersion,
+		Epochs:    publishGameEpochs,
+		CatalogID: catalogID,
+		CapID:     capID,
+		Channel:   channel,
+		Assets:    assets,
+		Delta:     deltaParams,
+	}
+	pl := buildPublishGamePlan(params)
+
+	if publishGameDryRun || publishGameEstimate {
+		printPlan(pl)
+		if publishGameEstimate {
+			cost := estimatePublishCost(pl, params)
+			printPublishCost(cost)
+			setResult(map[string]interface{}{"plan": pl, "cost": cost})
+		}
 
-	// Extract cartridge ID from transaction output
-	cartridgeID := extractObjectID(createOutput, "Cartridge")
-	if cartridgeID == "" {
-		return fmt.Errorf("failed to extract cartridge ID from transaction")
+		if publishGamePlanOut != "" {
+			if err := pl.Save(publishGamePlanOut); err != nil {
+				return err
+			}
+			statusf("\n✓ Plan written to %s\n", publishGamePlanOut)
+		}
+		i18n.Fprintln(stdout, "\nDry-run: nothing was uploaded or sent.")
+		return nil
 	}
 
-	fmt.Printf("  ✓ Cartridge created! ID: %s\n", cartridgeID)
+	if unsignedOut != "" {
+		if cfg.ApprovalRequired() {
+			return fmt.Errorf("--unsigned-out can't be used while mainnet approvals are required; use the approval request instead")
+		}
+		return writeUnsignedPublish(pl, params)
+	}
 
-	// Step 3: Add entry to catalog
-	fmt.Println("\n[3/3] Adding entry to catalog...")
+	if cfg.ApprovalRequired() {
+		requestFile := publishGameRequest
+		if requestFile == "" {
+			requestFile = fmt.Sprintf("publish-%s.request.json", publishGameSlug)
+		}
+		return createApprovalRequest(pl, requestFile, publishGameReqTTL)
+	}
 
-	addEntryArgs := []string{
-		"client", "call",
-		"--package", cfg.PackageID,
-		"--module", "catalog",
-		"--function", "add_entry",
-		"--args",
-		catalogID,
-		publishGameSlug,
-		cartridgeID,
-		publishGameTitle,
-		fmt.Sprintf("%d", platform),
-		fmt.Sprintf("%d", len(data)),
-		emulator,
-		fmt.Sprintf("%d", publishGameVersion),
-		"[]",
-		"--gas-budget", "10000000",
-		"--json",
+	journal := publishGameJournal
+	if journal == "" {
+		journal = fmt.Sprintf("publish-%s-v%d.journal.json", publishGameSlug, publishGameVersion)
+	}
+	prog, err := plan.LoadProgress(journal, pl)
+	if err != nil {
+		return err
+	}
+	if len(prog.Completed) > 0 {
+		i18n.Fprintf(stdout, "Resuming: %d of %d steps already done (%s)\n\n", len(prog.Completed), len(pl.Operations), journal)
 	}
 
-	addEntryOutput, err := executeSuiCommand(addEntryArgs)
+	closeEvents, err := openStepEvents(publishGameEvents)
 	if err != nil {
-		return fmt.Errorf("failed to add entry to catalog: %w", err)
+		return err
 	}
+	defer closeEvents()
 
-	fmt.Printf("  ✓ Entry added to catalog!\n")
+	if err := executePlan(pl, prog, journal); err != nil {
+		return err
+	}
 
 	// Print summary
-	fmt.Println("\n✓ Game published successfully!")
-	fmt.Println("\nSummary:")
-	fmt.Printf("  Slug: %s\n", publishGameSlug)
-	fmt.Printf("  Title: %s\n", publishGameTitle)
-	fmt.Printf("  Platform: %s\n", publishGamePlatform)
-	fmt.Printf("  Blob ID: %s\n", blobID)
-	fmt.Printf("  Cartridge ID: %s\n", cartridgeID)
-	fmt.Printf("  Catalog ID: %s\n", catalogID)
-	fmt.Printf("  Transactions:\n")
-	fmt.Printf("    - Create cartridge: %s\n", extractDigest(createOutput))
-	fmt.Printf("    - Add entry: %s\n", extractDigest(addEntryOutput))
+	statusln("\n✓ Game published successfully!")
+	i18n.Fprintln(stdout, "\nSummary:")
+	i18n.Fprintf(stdout, "  Slug: %s\n", publishGameSlug)
+	if channel != model.ChannelStable {
+		i18n.Fprintf(stdout, "  Channel: %s (entry %s, promote with: catalogctl promote-channel --slug %s --from %s --to stable)\n", channel, params.EntryKey(), publishGameSlug, channel)
+	}
+	i18n.Fprintf(stdout, "  Title: %s\n", publishGameTitle)
+	i18n.Fprintf(stdout, "  Platform: %s\n", publishGamePlatform)
+	i18n.Fprintf(stdout, "  Blob ID: %s\n", prog.Outputs["blob_id"])
+	i18n.Fprintf(stdout, "  Cartridge ID: %s\n", prog.Outputs["cartridge_id"])
+	printExplorerLink("    ", config.LinkObject, prog.Outputs["cartridge_id"])
+	i18n.Fprintf(stdout, "  Catalog ID: %s\n", catalogID)
+	printExplorerLink("    ", config.LinkObject, catalogID)
+	for _, asset := range assets {
+		i18n.Fprintf(stdout, "  Asset %s: %s\n", asset.Name, prog.Outputs["asset_"+asset.Name+"_blob_id"])
+	}
+	i18n.Fprintf(stdout, "  Transactions:\n")
+	for _, op := range pl.Operations {
+		if op.Type == plan.OpSuiCall {
+			fmt.Fprintf(stdout, "    - %s: %s\n", op.Description, prog.Completed[op.Step])
+			printExplorerLink("      ", config.LinkTx, prog.Completed[op.Step])
+		}
+	}
+	i18n.Fprintf(stdout, "  Journal: %s\n", journal)
+
+	planSummary(pl, prog, fmt.Sprintf("Published %s", publishGameTitle)).
+		row("Slug", publishGameSlug).
+		row("Platform", publishGamePlatform).
+		output("slug", publishGameSlug).
+		write()
 
 	return nil
 }
@@ -1022,15 +1592,76 @@ func runPublishGame(cmd *cobra.Command, args []string) error {
 // Helpers
 // ============================================================================
 
-// executeSuiCommand executes a sui CLI command and returns the output
-func executeSuiCommand(args []string) (string, error) {
+// fileDigest is a file's SHA256, valid while its size and mtime are unchanged
+type fileDigest struct {
+	size    int64
+	modTime time.Time
+	sha256  string
+}
+
+// fileDigests caches fileSHA256 results so a file that is hashed for the plan
+// and again before its upload is only read once per run
+var fileDigests = make(map[string]fileDigest)
+
+// fileSHA256 returns the hex SHA256 and size of a file, streaming it through
+// the hasher instead of reading it into memory
+func fileSHA256(path string) (string, int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	if d, ok := fileDigests[path]; ok && d.size == info.Size() && d.modTime.Equal(info.ModTime()) {
+		return d.sha256, d.size, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	fileDigests[path] = fileDigest{size: size, modTime: info.ModTime(), sha256: sum}
+	return sum, size, nil
+}
+
+// executeSuiCommand executes a sui CLI command and returns the output.
+// Move calls and transfers are sent with executeMoveCall and
+// executeTransfer, which only come here with --use-cli or the memory chain;
+// other commands run the sui binary.
+func executeSuiCommand(args []string) (output string, err error) {
+	if suiWriteCommand(args) {
+		if err := requireApproval(); err != nil {
+			return "", err
+		}
+		defer func() { invalidateObjectCache(output, err) }()
+		return retryOnConflict(func() (string, error) { return runSuiCommand(args) })
+	}
+	return runSuiCommand(args)
+}
+
+// runSuiCommand runs sui CLI arguments once, on the memory chain, natively or
+// with the sui binary
+func runSuiCommand(args []string) (string, error) {
+	if memoryChain != nil {
+		return memoryChain.Exec(args)
+	}
+	if !useSuiCLI {
+		if output, ok, err := executeSuiNative(args); ok {
+			return output, err
+		}
+	}
 	cmd := exec.Command("sui", args...)
 	var stderr bytes.Buffer
 	var stdout bytes.Buffer
 	cmd.Stderr = &stderr
 	cmd.Stdout = &stdout
-	err := cmd.Run()
-	if err != nil {
+	if err := cmd.Run(); err != nil {
 		errMsg := stderr.String()
 		stdoutMsg := stdout.String()
 		if errMsg == "" {
@@ -1043,44 +1674,6 @@ func executeSuiCommand(args []string) (string, error) {
 	return strings.TrimSpace(stdout.String()), nil
 }
 
-// extractDigest extracts transaction digest from JSON output
-func extractDigest(jsonOutput string) string {
-	var result map[string]interface{}
-	if err := json.Unmarshal([]byte(jsonOutput), &result); err == nil {
-		if digest, ok := result["digest"].(string); ok {
-			return digest
-		}
-	}
-	return "unknown"
-}
-
-// extractObjectID extracts an object ID from transaction output by type name
-func extractObjectID(jsonOutput string, typeName string) string {
-	var result map[string]interface{}
-	if err := json.Unmarshal([]byte(jsonOutput), &result); err != nil {
-		return ""
-	}
-
-	// Look in objectChanges array
-	if objectChanges, ok := result["objectChanges"].([]interface{}); ok {
-		for _, change := range objectChanges {
-			if changeMap, ok := change.(map[string]interface{}); ok {
-				if changeType, ok := changeMap["type"].(string); ok && changeType == "created" {
-					if objectType, ok := changeMap["objectType"].(string); ok {
-						if strings.Contains(objectType, typeName) {
-							if objectId, ok := changeMap["objectId"].(string); ok {
-								return objectId
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-
-	return ""
-}
-
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/walrus"
+)
+
+// aggregatorCacheFile keeps measured aggregator latencies between runs
+const aggregatorCacheFile = "aggregators.json"
+
+// aggregatorMirrors returns the configured aggregators (walrus_aggregator_url,
+// walrus_aggregator_urls and walrus_aggregator_regions) for blob reads,
+// preferring the region config hint
+func aggregatorMirrors() *walrus.Mirrors {
+	var aggregators []walrus.Aggregator
+	for _, url := range cfg.WalrusAggregatorEndpoints() {
+		aggregators = append(aggregators, walrus.Aggregator{URL: url, Region: cfg.WalrusAggregatorRegion(url)})
+	}
+	cachePath := filepath.Join(config.GetConfigDir(), aggregatorCacheFile)
+	if memoryChain != nil {
+		cachePath = ""
+	}
+	return walrus.NewMirrors(aggregators, cfg.Region, cachePath)
+}
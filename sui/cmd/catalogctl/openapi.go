@@ -0,0 +1,308 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/retro-crypto/sui/internal/model"
+)
+
+// ============================================================================
+// serve: OpenAPI document
+// ============================================================================
+
+// apiOperation describes one endpoint of the serve HTTP surface. The OpenAPI
+// document served at /openapi.json is generated from these, with schemas
+// derived from the Go request and response types.
+type apiOperation struct {
+	Method  string
+	Path    string
+	Summary string
+	Tag     string
+	// Auth marks endpoints that need the bearer token
+	Auth bool
+	// PathParams are the {name} segments of Path
+	PathParams []apiParam
+	Query      []apiParam
+	// Request and Response are zero values of the body types (nil: no body)
+	Request  interface{}
+	Response interface{}
+	// Form describes a multipart/form-data request body instead of Request;
+	// FileField names its file part
+	Form      []apiParam
+	FileField string
+	// Binary marks a response body that is the raw file
+	Binary bool
+	// ContentType is the media type of a text response other than JSON
+	ContentType string
+}
+
+// apiParam is a query parameter of an operation
+type apiParam struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// errorResponse is the body of every error response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// openAPIDocument builds an OpenAPI 3 document for the given operations
+func openAPIDocument(ops []apiOperation) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	errorRef := schemaFor(reflect.TypeOf(errorResponse{}), schemas)
+
+	paths := map[string]interface{}{}
+	auth := false
+	for _, op := range ops {
+		operation := map[string]interface{}{
+			"summary":     op.Summary,
+			"operationId": operationID(op),
+		}
+		if op.Tag != "" {
+			operation["tags"] = []string{op.Tag}
+		}
+
+		var params []interface{}
+		for _, p := range op.PathParams {
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"in":          "path",
+				"description": p.Description,
+				"required":    true,
+				"schema":      map[string]string{"type": "string"},
+			})
+		}
+		for _, p := range op.Query {
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"in":          "query",
+				"description": p.Description,
+				"required":    p.Required,
+				"schema":      map[string]string{"type": "string"},
+			})
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+
+		if len(op.Form) > 0 || op.FileField != "" {
+			properties := map[string]interface{}{}
+			var required []string
+			if op.FileField != "" {
+				properties[op.FileField] = map[string]string{"type": "string", "format": "binary"}
+				required = append(required, op.FileField)
+			}
+			for _, p := range op.Form {
+				properties[p.Name] = map[string]string{"type": "string", "description": p.Description}
+				if p.Required {
+					required = append(required, p.Name)
+				}
+			}
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"multipart/form-data": map[string]interface{}{
+						"schema": map[string]interface{}{"type": "object", "properties": properties, "required": required},
+					},
+				},
+			}
+		} else if op.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemaFor(reflect.TypeOf(op.Request), schemas)},
+				},
+			}
+		}
+
+		success := map[string]interface{}{"description": "OK"}
+		if op.Binary {
+			success["content"] = map[string]interface{}{
+				"application/octet-stream": map[string]interface{}{"schema": map[string]string{"type": "string", "format": "binary"}},
+			}
+		} else if op.ContentType != "" {
+			success["content"] = map[string]interface{}{
+				op.ContentType: map[string]interface{}{"schema": map[string]string{"type": "string"}},
+			}
+		} else if op.Response != nil {
+			success["content"] = map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemaFor(reflect.TypeOf(op.Response), schemas)},
+			}
+		}
+		errorContent := map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": errorRef},
+		}
+		responses := map[string]interface{}{
+			"200":     success,
+			"default": map[string]interface{}{"description": "Error", "content": errorContent},
+		}
+		if op.Auth {
+			auth = true
+			operation["security"] = []interface{}{map[string][]string{"bearerAuth": {}}}
+			responses["401"] = map[string]interface{}{"description": "Missing or invalid token", "content": errorContent}
+		}
+		operation["responses"] = responses
+
+		item, _ := paths[op.Path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	components := map[string]interface{}{"schemas": schemas}
+	if auth {
+		components["securitySchemes"] = map[string]interface{}{
+			"bearerAuth": map[string]string{"type": "http", "scheme": "bearer"},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":   "catalogctl",
+			"version": Version,
+		},
+		"paths":      paths,
+		"components": components,
+	}
+}
+
+// operationID derives an operation ID from the method and path,
+// e.g. GET /admin/api/entries -> getAdminApiEntries
+func operationID(op apiOperation) string {
+	id := strings.ToLower(op.Method)
+	for _, part := range strings.FieldsFunc(op.Path, func(r rune) bool { return r == '/' || r == '-' || r == '{' || r == '}' || r == '.' }) {
+		id += exportName(part)
+	}
+	return id
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	platformType = reflect.TypeOf(model.Platform(0))
+)
+
+// schemaFor returns the JSON schema of t. Named structs are added to schemas
+// and referenced, so shared types such as model.CatalogEntry appear once.
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case platformType:
+		return map[string]interface{}{
+			"type":        "integer",
+			"enum":        []int{int(model.PlatformDOS), int(model.PlatformGB), int(model.PlatformGBC), int(model.PlatformNES), int(model.PlatformSNES)},
+			"description": "Platform code: 0 DOS, 1 GB, 2 GBC, 3 NES, 4 SNES",
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		name := exportName(t.Name())
+		if _, ok := schemas[name]; !ok {
+			schemas[name] = map[string]interface{}{} // placeholder for recursive types
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema describes a struct's JSON encoding. Embedded exported
+// structs (e.g. model.CatalogEntry) are referenced with allOf, other
+// embedded structs are flattened like encoding/json does, and omitempty
+// fields are optional.
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	var allOf []interface{}
+
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				if field.IsExported() && field.Type != timeType {
+					allOf = append(allOf, schemaFor(field.Type, schemas))
+				} else {
+					collect(field.Type)
+				}
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaFor(field.Type, schemas)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	collect(t)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	if len(allOf) > 0 {
+		return map[string]interface{}{"allOf": append(allOf, schema)}
+	}
+	return schema
+}
+
+// exportName upper-cases the first letter of a Go or path identifier
+func exportName(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// serveOpenAPI serves the document for ops at /openapi.json
+func serveOpenAPI(mux *http.ServeMux, ops []apiOperation) {
+	doc := openAPIDocument(ops)
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		writeJSON(w, http.StatusOK, doc)
+	})
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Output format (--output json|table|yaml)
+// ============================================================================
+
+// outputEnv sets the output format when --output isn't given
+const outputEnv = "CATALOGCTL_OUTPUT"
+
+var (
+	outputFlag   string
+	outputFormat = output.Table
+	outputReady  bool
+
+	// stdout receives the human output of commands: os.Stdout, or os.Stderr
+	// in json and yaml mode where os.Stdout only gets the result object
+	stdout io.Writer = os.Stdout
+	// commandResult is what the command reported through setResult
+	commandResult interface{}
+)
+
+// commandOutput is printed on stdout in json and yaml mode
+type commandOutput struct {
+	Command string      `json:"command"`
+	OK      bool        `json:"ok"`
+	Error   string      `json:"error,omitempty"`
+	Result  interface{} `json:"result,omitempty"`
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&outputFlag, "output", "", "Output format: table, json or yaml (default: $CATALOGCTL_OUTPUT or table; json/yaml print a result object on stdout and everything else on stderr)")
+}
+
+// resultAnnotation marks commands that report a result object through
+// setResult or their GitHub summary outputs
+const resultAnnotation = "catalogctl/result"
+
+// reportsResult marks cmds as reporting a result object in json and yaml mode
+func reportsResult(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		if cmd.Annotations == nil {
+			cmd.Annotations = map[string]string{}
+		}
+		cmd.Annotations[resultAnnotation] = "true"
+	}
+}
+
+// setupOutput picks the output format. In json and yaml mode everything the
+// command prints goes to stderr, leaving stdout to the result object.
+// --output json|yaml is refused for commands without a result object;
+// $CATALOGCTL_OUTPUT falls back to table for them.
+func setupOutput(cmd *cobra.Command) error {
+	format, explicit := os.Getenv(outputEnv), false
+	if f, ok := formatFlag(cmd); ok {
+		format, explicit = f, true
+	} else if err := useLegacyOutputFile(cmd); err != nil {
+		return err
+	}
+	var err error
+	if outputFormat, err = output.ParseFormat(format); err != nil {
+		return err
+	}
+	if output.Structured(outputFormat) && cmd.Annotations[resultAnnotation] == "" {
+		if explicit {
+			return fmt.Errorf("%s has no %s output; drop --output", cmd.CommandPath(), outputFormat)
+		}
+		outputFormat = output.Table
+	}
+	outputReady = true
+	if output.Structured(outputFormat) {
+		stdout = os.Stderr
+	}
+	return nil
+}
+
+// formatFlag returns the format given with --output, if any. On commands
+// with --out-file, the deprecated local --output is a format only when it
+// names one.
+func formatFlag(cmd *cobra.Command) (string, bool) {
+	flag := cmd.Flags().Lookup("output")
+	if flag == nil || !flag.Changed {
+		return "", false
+	}
+	if cmd.LocalNonPersistentFlags().Lookup("output") == nil {
+		return outputFlag, true
+	}
+	if _, err := output.ParseFormat(flag.Value.String()); err == nil {
+		return flag.Value.String(), true
+	}
+	return "", false
+}
+
+// outFileFlag adds --out-file, the file the command writes, bound to p, and
+// the hidden local --output it replaces. --output naming a format selects
+// the output format as on every other command; any other value is still
+// taken as the file, with a warning.
+func outFileFlag(cmd *cobra.Command, p *string, usage string) {
+	cmd.Flags().StringVar(p, "out-file", "", usage)
+	cmd.Flags().String("output", "", "Deprecated: use --out-file")
+	cmd.Flags().MarkHidden("output")
+}
+
+// useLegacyOutputFile applies a deprecated local --output naming a file to
+// --out-file
+func useLegacyOutputFile(cmd *cobra.Command) error {
+	flag := cmd.LocalNonPersistentFlags().Lookup("output")
+	if flag == nil || !flag.Changed {
+		return nil
+	}
+	if cmd.Flags().Changed("out-file") {
+		return fmt.Errorf("--output %s is neither table, json nor yaml; give the file with --out-file only", flag.Value)
+	}
+	warnf("--output for the file to write is deprecated, use --out-file")
+	return cmd.Flags().Set("out-file", flag.Value.String())
+}
+
+// structuredOutput reports whether a result object is printed
+func structuredOutput() bool {
+	return output.Structured(outputFormat)
+}
+
+// setResult records the command's result for json and yaml mode
+func setResult(v interface{}) {
+	commandResult = v
+}
+
+// writeCommandOutput prints the result object of cmd in json and yaml mode
+func writeCommandOutput(cmd *cobra.Command, err error) {
+	if !outputReady && cmd != nil {
+		// The command failed before setupOutput ran (e.g. a bad flag)
+		format := os.Getenv(outputEnv)
+		if f, ok := formatFlag(cmd); ok {
+			format = f
+		}
+		outputFormat, _ = output.ParseFormat(format)
+		if cmd.Annotations[resultAnnotation] == "" {
+			outputFormat = output.Table
+		}
+	}
+	if !structuredOutput() {
+		return
+	}
+	out := commandOutput{OK: err == nil, Result: commandResult}
+	if cmd != nil {
+		out.Command = strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" ")
+	}
+	if err != nil {
+		out.Error = err.Error()
+	}
+	if werr := output.Write(os.Stdout, outputFormat, out); werr != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write result: %v\n", werr)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/agent"
+	"github.com/retro-crypto/sui/internal/config"
+)
+
+// ============================================================================
+// Master passphrase for encrypted config files
+// ============================================================================
+
+// passphraseCommandEnv names a command that prints the master passphrase,
+// e.g. a password manager or keyring agent ("pass show catalogctl")
+const passphraseCommandEnv = "CATALOGCTL_PASSPHRASE_COMMAND"
+
+// stdinLines reads prompt answers; shared so buffered input isn't lost
+// between prompts
+var stdinLines = bufio.NewReader(os.Stdin)
+
+// givenPassphrases records the passphrase found for each encrypted file,
+// by absolute path, for agent start --share-passphrase
+var givenPassphrases = map[string]string{}
+
+func init() {
+	config.Passphrase = configPassphrase
+}
+
+// configPassphrase finds the passphrase of an encrypted config or keystore
+// file (see findPassphrase), turns it into the KEK the file is sealed under
+// if it is bound to a security key, and records it
+func configPassphrase(filename string) (string, error) {
+	p, fromAgent, err := findPassphrase(filename)
+	if err != nil {
+		return "", err
+	}
+	// The agent hands out what the file is sealed under already
+	if !fromAgent {
+		if p, err = fido2Passphrase(filename, p); err != nil {
+			return "", err
+		}
+	}
+	if abs, err := filepath.Abs(filename); err == nil {
+		givenPassphrases[abs] = p
+	}
+	return p, nil
+}
+
+// findPassphrase reads the passphrase of an encrypted file from
+// $CATALOGCTL_PASSPHRASE, the output of $CATALOGCTL_PASSPHRASE_COMMAND, the
+// signing agent at $CATALOGCTL_AGENT_SOCK if it shares passphrases, or a
+// prompt when running in a terminal
+func findPassphrase(filename string) (p string, fromAgent bool, err error) {
+	if p := os.Getenv(config.PassphraseEnv); p != "" {
+		return p, false, nil
+	}
+	if command := os.Getenv(passphraseCommandEnv); command != "" {
+		out, err := exec.Command("sh", "-c", command).Output()
+		if err != nil {
+			return "", false, fmt.Errorf("%s failed: %w", passphraseCommandEnv, err)
+		}
+		if p := strings.TrimRight(string(out), "\r\n"); p != "" {
+			return p, false, nil
+		}
+		return "", false, fmt.Errorf("%s printed an empty passphrase", passphraseCommandEnv)
+	}
+	var agentErr error
+	if socket := os.Getenv(agent.SocketEnv); socket != "" {
+		p, err := agent.NewClient(socket).Passphrase(filename)
+		if err == nil {
+			return p, true, nil
+		}
+		agentErr = err
+	}
+	if !stdinIsTerminal() {
+		if agentErr != nil {
+			return "", false, fmt.Errorf("%s is encrypted: set %s or %s (%v)", filename, config.PassphraseEnv, passphraseCommandEnv, agentErr)
+		}
+		return "", false, fmt.Errorf("%s is encrypted: set %s or %s", filename, config.PassphraseEnv, passphraseCommandEnv)
+	}
+	p, err = readPassphrase(fmt.Sprintf("Passphrase for %s: ", filename))
+	return p, false, err
+}
+
+// readPassphrase prompts on stderr and reads a line from the terminal
+// without echoing it
+func readPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	if err := stty("-echo"); err == nil {
+		defer func() {
+			stty("echo")
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+	line, err := stdinLines.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// stty changes the terminal mode of stdin
+func stty(args ...string) error {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// stdinIsTerminal reports whether stdin is a terminal (other character
+// devices such as /dev/null aren't)
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0 && stty("-g") == nil
+}
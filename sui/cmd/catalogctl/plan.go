@@ -0,0 +1,617 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/approval"
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/plan"
+	"github.com/retro-crypto/sui/internal/storage"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+// publishGameParams are the inputs of a publish-game run
+type publishGameParams struct {
+	FilePath  string
+	Size      int64
+	SHA256Hex string
+	Slug      string
+	Title     string
+	Platform  model.Platform
+	Emulator  string
+	Version   uint16
+	Epochs    int
+	CatalogID string
+	// CapID is the CuratorCap to add the entry with ("" for the owner)
+	CapID string
+	// Channel is the release channel of the entry
+	Channel string
+	// Assets are extra named files attached to the cartridge
+	Assets []assetParam
+	// Delta uploads a patch against an earlier cartridge instead of the file
+	Delta *deltaParam
+}
+
+// assetParam is an extra file published with a game (manual, soundtrack, ...)
+type assetParam struct {
+	Name      string
+	FilePath  string
+	Size      int64
+	SHA256Hex string
+}
+
+// parseAssets reads NAME=PATH asset specs and hashes each file
+func parseAssets(specs []string) ([]assetParam, error) {
+	var assets []assetParam
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		name, path, ok := strings.Cut(spec, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid asset %q: expected NAME=PATH", spec)
+		}
+		if err := validate.AssetName(name); err != nil {
+			return nil, fmt.Errorf("invalid asset %q: %w", spec, err)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("asset %q given more than once", name)
+		}
+		seen[name] = true
+
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset path: %w", err)
+		}
+		sha256Hex, size, err := fileSHA256(absPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read asset %s: %w", name, err)
+		}
+		assets = append(assets, assetParam{Name: name, FilePath: absPath, Size: size, SHA256Hex: sha256Hex})
+	}
+	return assets, nil
+}
+
+// EntryKey returns the catalog key the entry is added under
+func (p publishGameParams) EntryKey() string {
+	return model.ChannelKey(p.Slug, p.Channel)
+}
+
+// buildPublishGamePlan describes the operations publish-game performs, in order
+func buildPublishGamePlan(p publishGameParams) *plan.Plan {
+	g := plan.PublishGame{
+		Network:   cfg.SuiNetwork,
+		PackageID: cfg.PackageID,
+		File:      plan.FileInfo{Path: p.FilePath, Size: p.Size, SHA256: p.SHA256Hex},
+		Slug:      p.Slug,
+		Title:     p.Title,
+		Platform:  p.Platform,
+		Emulator:  p.Emulator,
+		Version:   p.Version,
+		Epochs:    p.Epochs,
+		CatalogID: p.CatalogID,
+		CapID:     p.CapID,
+		Channel:   p.Channel,
+	}
+	for _, asset := range p.Assets {
+		g.Assets = append(g.Assets, plan.Asset{
+			Name: asset.Name,
+			File: plan.FileInfo{Path: asset.FilePath, Size: asset.Size, SHA256: asset.SHA256Hex},
+		})
+	}
+	if p.Delta != nil {
+		g.Delta = &plan.Delta{
+			BaseCartridgeID: p.Delta.BaseCartridgeID,
+			Patch:           plan.FileInfo{Path: p.Delta.PatchPath, Size: p.Delta.PatchSize, SHA256: p.Delta.PatchSHA256Hex},
+		}
+	}
+	return plan.NewPublishGame(g)
+}
+
+// printPlan prints the operations and estimate of a plan
+func printPlan(pl *plan.Plan) {
+	fmt.Fprintf(stdout, "Plan (%s on %s):\n", pl.Kind, pl.Network)
+	for _, op := range pl.Operations {
+		switch op.Type {
+		case plan.OpWalrusStore:
+			fmt.Fprintf(stdout, "  %d. %s (%d bytes, %d epochs)\n", op.Step, op.Description, op.PayloadSize, op.Epochs)
+		case plan.OpSuiCall:
+			fmt.Fprintf(stdout, "  %d. %s (%s::%s, gas budget %d MIST)\n", op.Step, op.Description, op.Module, op.Function, op.GasBudget)
+		default:
+			fmt.Fprintf(stdout, "  %d. %s\n", op.Step, op.Description)
+		}
+	}
+
+	est := pl.Estimate
+	fmt.Fprintln(stdout, "\nEstimate:")
+	fmt.Fprintf(stdout, "  Sui transactions: %d\n", est.SuiTransactions)
+	fmt.Fprintf(stdout, "  Max gas: %d MIST (%.4f SUI)\n", est.MaxGasMist, float64(est.MaxGasMist)/plan.MistPerSUI)
+	fmt.Fprintf(stdout, "  Walrus storage: %d bytes (~%d bytes encoded) for %d epochs\n", est.WalrusBlobBytes, est.WalrusEncodedBytes, est.WalrusEpochs)
+	fmt.Fprintf(stdout, "  Duration: ~%.0fs\n", est.DurationSeconds)
+}
+
+// executePlan runs the plan's operations in order, skipping steps already
+// recorded in the progress. If progressPath is set, progress is saved after
+// every step so a failed run can be resumed.
+func executePlan(pl *plan.Plan, prog *plan.Progress, progressPath string) error {
+	if pl.Network != "" && pl.Network != cfg.SuiNetwork {
+		return fmt.Errorf("plan targets %s but config uses %s", pl.Network, cfg.SuiNetwork)
+	}
+	if planConcurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	save := func() error {
+		if progressPath == "" {
+			return nil
+		}
+		return prog.Save(progressPath)
+	}
+
+	uploaded, err := uploadPlanBlobs(pl, prog, save)
+	if err != nil {
+		return err
+	}
+
+	total := len(pl.Operations)
+	for _, op := range pl.Operations {
+		if uploaded[op.Step] {
+			continue
+		}
+		if result, done := prog.Completed[op.Step]; done {
+			fmt.Fprintf(stdout, "[%d/%d] %s: already done (%s)\n", op.Step, total, op.Description, result)
+			reportStep(pl, prog, op, plan.StepSkipped, map[string]string{"result": result}, nil)
+			continue
+		}
+
+		statusf("[%d/%d] %s...\n", op.Step, total, op.Description)
+		reportStep(pl, prog, op, plan.StepStarted, nil, nil)
+		if err := save(); err != nil {
+			return err
+		}
+
+		outputs, err := executeOperation(pl, op, prog)
+		if err != nil {
+			reportStep(pl, prog, op, plan.StepFailed, nil, err)
+			if saveErr := save(); saveErr != nil {
+				statusf("⚠️  Failed to save progress: %v\n", saveErr)
+			}
+			return err
+		}
+
+		reportStep(pl, prog, op, plan.StepDone, outputs, nil)
+		if err := save(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// executeOperation runs one operation, records its result in prog and returns
+// the IDs it produced
+func executeOperation(pl *plan.Plan, op plan.Operation, prog *plan.Progress) (map[string]string, error) {
+	switch op.Type {
+	case plan.OpWalrusStore:
+		blobID, cost, err := executeWalrusStore(pl, op)
+		if err != nil {
+			return nil, err
+		}
+		return recordWalrusStore(op, prog, blobID, cost)
+
+	case plan.OpSuiCall:
+		args, err := plan.ResolveArgs(op.Args, prog.Outputs, map[string]string{
+			"now_ms": fmt.Sprintf("%d", time.Now().UnixMilli()),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", op.Step, err)
+		}
+
+		// Plans store arguments as the sui CLI writes them
+		output, err := executeMoveCall(sui.MoveCall{
+			Package:   pl.PackageID,
+			Module:    op.Module,
+			Function:  op.Function,
+			Args:      sui.CLIArgValues(args),
+			GasBudget: op.GasBudget,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to %s: %w", strings.ToLower(op.Description), err)
+		}
+
+		produced := make(map[string]string)
+		if op.ObjectType != "" {
+			objectID := sui.CreatedObjectID(output, op.ObjectType)
+			if objectID == "" {
+				return nil, fmt.Errorf("failed to extract %s ID from transaction", op.ObjectType)
+			}
+			prog.Outputs[op.Output] = objectID
+			produced[op.Output] = objectID
+			statusf("  ✓ %s created! ID: %s\n", op.ObjectType, objectID)
+			printExplorerLink("    ", config.LinkObject, objectID)
+		}
+		digest := sui.TransactionDigest(output)
+		prog.Completed[op.Step] = digest
+		prog.GasMist += sui.TransactionGasCost(output)
+		produced["digest"] = digest
+		statusf("  ✓ Transaction: %s\n", digest)
+		printExplorerLink("    ", config.LinkTx, digest)
+		return produced, nil
+
+	default:
+		return nil, fmt.Errorf("step %d: unknown operation type %q", op.Step, op.Type)
+	}
+}
+
+// stepEventsOut receives step events as JSON Lines (nil unless --events is set)
+var stepEventsOut io.Writer
+
+// openStepEvents directs step events to path ("-" for stderr) and returns a
+// function that closes it
+func openStepEvents(path string) (func(), error) {
+	switch path {
+	case "":
+		return func() {}, nil
+	case "-":
+		stepEventsOut = os.Stderr
+		return func() { stepEventsOut = nil }, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open events file: %w", err)
+	}
+	stepEventsOut = f
+	return func() {
+		f.Close()
+		stepEventsOut = nil
+	}, nil
+}
+
+// reportStep records a step event in the progress log and streams it.
+// Skipped steps are streamed but not logged, since nothing happened.
+func reportStep(pl *plan.Plan, prog *plan.Progress, op plan.Operation, status string, outputs map[string]string, err error) {
+	attempt := prog.Attempts(op.Step)
+	if status == plan.StepStarted {
+		attempt++
+	}
+	ev := plan.StepEvent{
+		Step:        op.Step,
+		Total:       len(pl.Operations),
+		Type:        op.Type,
+		Description: op.Description,
+		Status:      status,
+		Attempt:     attempt,
+		Outputs:     outputs,
+		Time:        time.Now().UTC().Format(time.RFC3339),
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	if status != plan.StepSkipped {
+		prog.Log = append(prog.Log, ev)
+	}
+
+	if stepEventsOut != nil {
+		line, _ := json.Marshal(ev)
+		fmt.Fprintln(stepEventsOut, string(line))
+	}
+}
+
+// executeWalrusStore uploads the operation's file (the plan's file unless the
+// operation names its own) after checking it still matches the size and
+// SHA256 recorded in the plan
+func executeWalrusStore(pl *plan.Plan, op plan.Operation) (string, uint64, error) {
+	file, err := planStoreFile(pl, op)
+	if err != nil {
+		return "", 0, err
+	}
+
+	fmt.Fprintf(stdout, "  File: %s (%d bytes)\n", filepath.Base(file.Path), file.Size)
+	fmt.Fprintf(stdout, "  SHA256: %s\n", file.SHA256)
+	fmt.Fprintf(stdout, "  Publisher URL: %s\n", cfg.WalrusPublisherURL)
+
+	progress := newUploadProgress()
+	blobID, cost, err := storePlanFile(file, op.Epochs, progress.track(file.Path, file.Size))
+	progress.finish()
+	return blobID, cost, err
+}
+
+// planStoreFile returns the file a walrus_store operation uploads, checking
+// it still matches the plan
+func planStoreFile(pl *plan.Plan, op plan.Operation) (plan.FileInfo, error) {
+	file := pl.File
+	if op.File != nil {
+		file = *op.File
+	}
+	sha256Hex, size, err := fileSHA256(file.Path)
+	if err != nil {
+		return file, fmt.Errorf("failed to read file: %w", err)
+	}
+	if size != file.Size || sha256Hex != file.SHA256 {
+		return file, fmt.Errorf("file %s changed since the plan was created (expected %d bytes, SHA256 %s)",
+			file.Path, file.Size, file.SHA256)
+	}
+	return file, nil
+}
+
+// storePlanFile uploads a plan file, reporting the bytes sent to progress
+func storePlanFile(file plan.FileInfo, epochs int, progress func(sent, total int64)) (string, uint64, error) {
+	// Upload to Walrus (will fallback to CLI if HTTP fails)
+	backend, err := storageBackend()
+	if err != nil {
+		return "", 0, err
+	}
+	stored, err := backend.StoreFile(file.Path, storage.StoreOptions{Epochs: epochs, Progress: progress})
+	if err != nil {
+		if strings.Contains(err.Error(), "walrus CLI failed") {
+			return "", 0, fmt.Errorf("failed to upload to Walrus: %w\n\n"+
+				"All publisher nodes failed. Installing Walrus CLI:\n"+
+				"  cargo install --git https://github.com/MystenLabs/walrus.git walrus\n\n"+
+				"Then run the command again. The CLI uses your own SUI balance.", err)
+		}
+		return "", 0, fmt.Errorf("failed to upload to Walrus: %w", err)
+	}
+
+	return stored.BlobID, stored.Cost, nil
+}
+
+// recordWalrusStore records the blob ID of a finished upload in prog
+func recordWalrusStore(op plan.Operation, prog *plan.Progress, blobID string, cost uint64) (map[string]string, error) {
+	blobIDBytes, err := base58.Decode(blobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode blob ID from base58: %w", err)
+	}
+	prog.Outputs[op.Output] = blobID
+	prog.Outputs[op.Output+"_hex"] = "0x" + hex.EncodeToString(blobIDBytes)
+	prog.Completed[op.Step] = blobID
+	prog.WalrusCost += cost
+	statusf("  ✓ Uploaded! Blob ID: %s\n", blobID)
+	return map[string]string{op.Output: blobID}, nil
+}
+
+// planConcurrency is how many of a plan's blobs are uploaded at once
+// (--concurrency)
+var planConcurrency = 1
+
+// uploadPlanBlobs uploads the plan's pending walrus_store operations up to
+// planConcurrency at a time, ahead of the Move calls that use their blob IDs.
+// The publisher API takes each blob in one request, so a game's file, patch
+// and assets are what runs in parallel. It returns the steps it completed;
+// with a concurrency of 1 or a single pending upload it does nothing and the
+// uploads run in plan order.
+func uploadPlanBlobs(pl *plan.Plan, prog *plan.Progress, save func() error) (map[int]bool, error) {
+	var pending []plan.Operation
+	for _, op := range pl.Operations {
+		if _, done := prog.Completed[op.Step]; !done && op.Type == plan.OpWalrusStore {
+			pending = append(pending, op)
+		}
+	}
+	if planConcurrency <= 1 || len(pending) < 2 {
+		return nil, nil
+	}
+
+	workers := planConcurrency
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+	statusf("Uploading %d blobs, %d at a time...\n", len(pending), workers)
+
+	files := make([]plan.FileInfo, len(pending))
+	for i, op := range pending {
+		file, err := planStoreFile(pl, op)
+		if err != nil {
+			return nil, err
+		}
+		files[i] = file
+		fmt.Fprintf(stdout, "[%d/%d] %s (%d bytes)\n", op.Step, len(pl.Operations), op.Description, file.Size)
+		reportStep(pl, prog, op, plan.StepStarted, nil, nil)
+	}
+	if err := save(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		blobID string
+		cost   uint64
+		err    error
+	}
+	results := make([]result, len(pending))
+	progress := newUploadProgress()
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := range pending {
+		track := progress.track(files[i].Path, files[i].Size)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			blobID, cost, err := storePlanFile(files[i], pending[i].Epochs, track)
+			results[i] = result{blobID, cost, err}
+		}(i)
+	}
+	wg.Wait()
+	progress.finish()
+
+	// Record every finished upload before failing, so a rerun only repeats
+	// the failed ones
+	uploaded := make(map[int]bool)
+	var firstErr error
+	for i, op := range pending {
+		r := results[i]
+		err := r.err
+		var outputs map[string]string
+		if err == nil {
+			fmt.Fprintf(stdout, "[%d/%d] %s\n", op.Step, len(pl.Operations), op.Description)
+			outputs, err = recordWalrusStore(op, prog, r.blobID, r.cost)
+		}
+		if err != nil {
+			fmt.Fprintf(stdout, "✗ %s: %v\n", op.Description, err)
+			reportStep(pl, prog, op, plan.StepFailed, nil, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		uploaded[op.Step] = true
+		reportStep(pl, prog, op, plan.StepDone, outputs, nil)
+	}
+	if err := save(); err != nil {
+		return nil, err
+	}
+	return uploaded, firstErr
+}
+
+// ============================================================================
+// Execute Plan Command
+// ============================================================================
+
+var executePlanCmd = &cobra.Command{
+	Use:   "execute-plan [PLAN_FILE]",
+	Short: "Execute a plan written by publish-game --dry-run --plan-out",
+	Long: `Execute exactly the operations of a previously generated plan.
+
+Progress is saved next to the plan (PLAN_FILE.progress.json) after every step,
+together with a log of step events. If a run fails, run the same command again
+to continue where it stopped. With --events, the step events are also written
+as JSON Lines for wrappers and GUIs.
+
+On mainnet with approvers configured, plans must go through the approval
+workflow: pass an approved request with --request instead of a plan file.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExecutePlan,
+}
+
+var (
+	executePlanRequest string
+	executePlanEvents  string
+)
+
+func init() {
+	executePlanCmd.Flags().StringVar(&executePlanRequest, "request", "", "Execute the plan of an approved request file")
+	executePlanCmd.Flags().StringVar(&executePlanEvents, "events", "", "Write step events as JSON Lines to this file (- for stderr)")
+	executePlanCmd.Flags().IntVar(&planConcurrency, "concurrency", 1, "Upload up to this many of the plan's blobs to Walrus at once")
+	rootCmd.AddCommand(executePlanCmd)
+	reportsResult(executePlanCmd)
+}
+
+func runExecutePlan(cmd *cobra.Command, args []string) error {
+	var (
+		pl         *plan.Plan
+		sourceFile string
+		request    *approval.Request
+		ledger     = approval.NewLedger(filepath.Join(config.GetConfigDir(), "approvals"))
+	)
+	switch {
+	case executePlanRequest != "" && len(args) == 0:
+		req, err := approval.Load(executePlanRequest)
+		if err != nil {
+			return err
+		}
+		if err := req.Verify(cfg.Approvers); err != nil {
+			return fmt.Errorf("request %s can't be executed: %w", executePlanRequest, err)
+		}
+		resumed, err := ledger.Begin(req, time.Now())
+		if err != nil {
+			return fmt.Errorf("request %s can't be executed: %w", executePlanRequest, err)
+		}
+		pl = req.Plan
+		sourceFile = executePlanRequest
+		request = req
+		approvedWrites = true
+		statusf("✓ Request approved (%d signatures)\n\n", len(req.Signatures))
+		if resumed {
+			statusln("Resuming an earlier run of this request\n")
+		}
+	case executePlanRequest == "" && len(args) == 1:
+		loaded, err := plan.Load(args[0])
+		if err != nil {
+			return err
+		}
+		if cfg.ApprovalRequired() {
+			return fmt.Errorf("mainnet publishes require approval: run publish-game without --dry-run to create a request, then execute-plan --request FILE")
+		}
+		pl = loaded
+		sourceFile = args[0]
+	default:
+		return fmt.Errorf("pass either a plan file or --request FILE")
+	}
+	if pl.PackageID == "" {
+		return fmt.Errorf("plan has no package_id")
+	}
+
+	progressPath := sourceFile + ".progress.json"
+	prog, err := plan.LoadProgress(progressPath, pl)
+	if err != nil {
+		return err
+	}
+
+	printPlan(pl)
+	if len(prog.Completed) > 0 {
+		fmt.Fprintf(stdout, "\nResuming: %d of %d steps already done (%s)\n", len(prog.Completed), len(pl.Operations), progressPath)
+	}
+	fmt.Fprintln(stdout)
+
+	closeEvents, err := openStepEvents(executePlanEvents)
+	if err != nil {
+		return err
+	}
+	defer closeEvents()
+
+	if err := executePlan(pl, prog, progressPath); err != nil {
+		return err
+	}
+	if request != nil {
+		if err := ledger.Finish(request, time.Now()); err != nil {
+			return fmt.Errorf("plan executed, but recording the request as used failed: %w", err)
+		}
+	}
+
+	statusln("\n✓ Plan executed successfully!")
+	for _, name := range []string{"blob_id", "cartridge_id"} {
+		if value := prog.Outputs[name]; value != "" {
+			fmt.Fprintf(stdout, "  %s: %s\n", name, value)
+		}
+	}
+
+	planSummary(pl, prog, "Plan executed").write()
+	return nil
+}
+
+// planSummary builds the GitHub Actions summary of an executed plan
+func planSummary(pl *plan.Plan, prog *plan.Progress, title string) *ghSummary {
+	summary := newGHSummary(title).
+		row("File", fmt.Sprintf("%s (%d bytes)", filepath.Base(pl.File.Path), pl.File.Size)).
+		row("SHA256", "`"+pl.File.SHA256+"`").
+		row("Network", pl.Network).
+		row("Blob ID", "`"+prog.Outputs["blob_id"]+"`").
+		row("Cartridge", mdLink(prog.Outputs["cartridge_id"], explorerURL(config.LinkObject, prog.Outputs["cartridge_id"]))).
+		row("Catalog", mdLink(pl.CatalogID, explorerURL(config.LinkObject, pl.CatalogID)))
+	for _, op := range pl.Operations {
+		if op.Type == plan.OpSuiCall {
+			digest := prog.Completed[op.Step]
+			summary.row(op.Description, mdLink(digest, explorerURL(config.LinkTx, digest)))
+		}
+	}
+	summary.row("Gas used", formatSUI(prog.GasMist))
+	if prog.WalrusCost > 0 {
+		summary.row("Walrus storage cost", formatWAL(prog.WalrusCost))
+	}
+
+	return summary.
+		output("blob_id", prog.Outputs["blob_id"]).
+		output("cartridge_id", prog.Outputs["cartridge_id"]).
+		output("catalog_id", pl.CatalogID).
+		output("sha256", pl.File.SHA256).
+		output("gas_mist", fmt.Sprintf("%d", prog.GasMist))
+}
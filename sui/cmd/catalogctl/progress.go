@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Upload progress
+// ============================================================================
+
+const (
+	// progressBarWidth is the width of the bar in cells
+	progressBarWidth = 30
+	// progressRedraw bounds how often the bar is redrawn on a terminal
+	progressRedraw = 200 * time.Millisecond
+	// progressLogStep is how often (in percent) progress is logged when
+	// stdout isn't a terminal, e.g. in CI logs
+	progressLogStep = 10
+)
+
+// uploadProgress draws one progress bar (bytes sent, rate and ETA) for all
+// uploads of a step, including uploads running in parallel
+type uploadProgress struct {
+	mu      sync.Mutex
+	tty     bool
+	start   time.Time
+	drawn   time.Time
+	logged  int
+	sizes   map[string]int64
+	sent    map[string]int64
+	started bool
+}
+
+func newUploadProgress() *uploadProgress {
+	tty := false
+	if f, ok := stdout.(*os.File); ok {
+		info, err := f.Stat()
+		tty = err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+	return &uploadProgress{
+		tty:   tty,
+		sizes: make(map[string]int64),
+		sent:  make(map[string]int64),
+	}
+}
+
+// track returns the storage.StoreOptions progress callback of one upload;
+// --quiet draws nothing
+func (p *uploadProgress) track(name string, size int64) func(sent, total int64) {
+	if quiet() {
+		return func(sent, total int64) {}
+	}
+	p.mu.Lock()
+	p.sizes[name] = size
+	p.mu.Unlock()
+	return func(sent, total int64) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if !p.started {
+			p.started = true
+			p.start = time.Now()
+		}
+		p.sizes[name] = total
+		p.sent[name] = sent
+		p.draw(false)
+	}
+}
+
+// finish draws the final state and ends the bar's line
+func (p *uploadProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.started {
+		return
+	}
+	p.draw(true)
+	if p.tty {
+		fmt.Fprintln(stdout)
+	}
+}
+
+// draw renders the bar; p.mu is held
+func (p *uploadProgress) draw(final bool) {
+	var sent, total int64
+	for name, size := range p.sizes {
+		total += size
+		sent += p.sent[name]
+	}
+	if total <= 0 {
+		return
+	}
+	if sent > total {
+		sent = total
+	}
+	percent := int(sent * 100 / total)
+
+	if !p.tty {
+		step := percent / progressLogStep * progressLogStep
+		if step <= p.logged {
+			return
+		}
+		p.logged = step
+		fmt.Fprintf(stdout, "  Uploaded %s of %s (%d%%)%s\n", formatBytes(sent), formatBytes(total), percent, p.rate(sent, total))
+		return
+	}
+	if !final && time.Since(p.drawn) < progressRedraw {
+		return
+	}
+	p.drawn = time.Now()
+
+	filled := int(sent * progressBarWidth / total)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+	fmt.Fprintf(stdout, "\r\033[K  %s %3d%% %s / %s%s", bar, percent, formatBytes(sent), formatBytes(total), p.rate(sent, total))
+}
+
+// rate formats the transfer rate and ETA; p.mu is held
+func (p *uploadProgress) rate(sent, total int64) string {
+	elapsed := time.Since(p.start).Seconds()
+	if elapsed < 0.5 || sent == 0 {
+		return ""
+	}
+	bytesPerSecond := float64(sent) / elapsed
+	if sent == total {
+		return fmt.Sprintf("  %s/s", formatBytes(int64(bytesPerSecond)))
+	}
+	eta := time.Duration(float64(total-sent) / bytesPerSecond * float64(time.Second))
+	return fmt.Sprintf("  %s/s  ETA %s", formatBytes(int64(bytesPerSecond)), eta.Round(time.Second))
+}
+
+// formatBytes formats a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,513 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Catalog registry (registry module): create-registry, register-catalog,
+// list-registry, search-registry
+// ============================================================================
+
+const (
+	// registryMixedPlatform is the primary_platform of catalogs that don't
+	// focus on one platform
+	registryMixedPlatform = 255
+	// registryPageSize is the most dynamic fields the RPC returns per page
+	registryPageSize = 50
+)
+
+// registryEntry is a catalog listed in the registry
+type registryEntry struct {
+	CatalogID    string `json:"catalog_id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Platform     string `json:"platform"`
+	PlatformCode uint8  `json:"platform_code"`
+
+	// fieldID is the dynamic field holding the entry, the RPC's page cursor
+	fieldID string
+}
+
+// registryPlatformName renders a primary_platform value
+func registryPlatformName(code uint8) string {
+	if code == registryMixedPlatform {
+		return "mixed"
+	}
+	return model.Platform(code).String()
+}
+
+// parseRegistryPlatform parses --platform: a platform name or "mixed"
+func parseRegistryPlatform(s string) (uint8, error) {
+	if strings.EqualFold(s, "mixed") {
+		return registryMixedPlatform, nil
+	}
+	p, err := model.ParsePlatform(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w (or mixed)", err)
+	}
+	return uint8(p), nil
+}
+
+// resolveRegistryID returns --registry, else registry_id from the config
+func resolveRegistryID(flag string) (string, error) {
+	registryID := flag
+	if registryID == "" {
+		registryID = cfg.RegistryID
+	}
+	if registryID == "" {
+		return "", fmt.Errorf("registry ID required: set --registry flag or registry_id in config file (or create one with create-registry)")
+	}
+	if err := validate.ObjectID(registryID); err != nil {
+		return "", fmt.Errorf("invalid registry ID %q: %w", registryID, err)
+	}
+	return registryID, nil
+}
+
+// readRegistry returns the admin and catalog count of a registry
+func readRegistry(client *sui.Client, registryID string) (admin string, count uint64, err error) {
+	resp, err := client.GetObject(registryID)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get registry: %w", err)
+	}
+	if resp.Data == nil || !strings.HasSuffix(resp.Data.Type, "::registry::CatalogRegistry") {
+		return "", 0, fmt.Errorf("%s is not a catalog registry", registryID)
+	}
+	fields, _ := resp.Data.Content["fields"].(map[string]interface{})
+	admin, _ = fields["admin"].(string)
+	return admin, parseU64(fields["count"]), nil
+}
+
+// scanRegistry pages through the registry from cursor and returns up to
+// limit entries that match (all of them for limit 0, every entry for a nil
+// match). next is the cursor to continue after the last returned entry, nil
+// at the end of the registry.
+func scanRegistry(client *sui.Client, registryID string, cursor *string, limit int, match func(registryEntry) bool) (entries []registryEntry, next *string, err error) {
+	entries = []registryEntry{}
+	seen := map[string]bool{}
+	for page := 0; ; page++ {
+		if page >= sui.MaxDynamicFieldPages {
+			return nil, nil, fmt.Errorf("registry pagination exceeded %d pages", sui.MaxDynamicFieldPages)
+		}
+		resp, err := client.GetDynamicFields(registryID, cursor, registryPageSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list registry: %w", err)
+		}
+		ids := make([]string, len(resp.Data))
+		for i, field := range resp.Data {
+			ids[i] = field.ObjectID
+		}
+		objects, err := client.MultiGetObjects(ids)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read registry entries: %w", err)
+		}
+
+		for i, obj := range objects {
+			fields := sui.ParseCatalogEntry(obj.Data)
+			if fields == nil {
+				continue
+			}
+			entry := registryEntry{fieldID: ids[i]}
+			entry.CatalogID, _ = fields["catalog_id"].(string)
+			entry.Name, _ = fields["name"].(string)
+			entry.Description, _ = fields["description"].(string)
+			entry.PlatformCode = uint8(parseU64(fields["primary_platform"]))
+			entry.Platform = registryPlatformName(entry.PlatformCode)
+			if match != nil && !match(entry) {
+				continue
+			}
+			entries = append(entries, entry)
+			if limit > 0 && len(entries) == limit {
+				// Only hand out a cursor if something follows it
+				if i < len(objects)-1 || resp.HasNextPage {
+					next = &entry.fieldID
+				}
+				return entries, next, nil
+			}
+		}
+
+		if !resp.HasNextPage || resp.NextCursor == nil {
+			return entries, nil, nil
+		}
+		if seen[*resp.NextCursor] {
+			return nil, nil, fmt.Errorf("registry pagination cycle detected: cursor %s was already visited", *resp.NextCursor)
+		}
+		seen[*resp.NextCursor] = true
+		cursor = resp.NextCursor
+	}
+}
+
+// printRegistryEntries prints a page of registry entries and how to get the
+// next one
+func printRegistryEntries(entries []registryEntry, next *string, nextCommand string) {
+	if len(entries) == 0 {
+		fmt.Fprintln(stdout, "No catalogs found.")
+		return
+	}
+	fmt.Fprintf(stdout, "%-66s  %-30s %-8s %s\n", "CATALOG_ID", "NAME", "PLATFORM", "DESCRIPTION")
+	fmt.Fprintln(stdout, strings.Repeat("-", 150))
+	for _, e := range entries {
+		fmt.Fprintf(stdout, "%-66s  %-30s %-8s %s\n", e.CatalogID, truncate(e.Name, 30), e.Platform, truncate(e.Description, 40))
+	}
+	if next != nil {
+		statusf("\n💡 More catalogs: %s --cursor %s\n", nextCommand, *next)
+	}
+}
+
+// registryResult is the --output of list-registry and search-registry
+type registryResult struct {
+	RegistryID string          `json:"registry_id"`
+	Admin      string          `json:"admin"`
+	Count      uint64          `json:"count"`
+	Query      string          `json:"query,omitempty"`
+	Catalogs   []registryEntry `json:"catalogs"`
+	NextCursor *string         `json:"next_cursor"`
+}
+
+// ----------------------------------------------------------------------------
+// create-registry
+// ----------------------------------------------------------------------------
+
+var createRegistryCmd = &cobra.Command{
+	Use:   "create-registry",
+	Short: "Create a catalog registry on Sui",
+	Long: `Creates a shared CatalogRegistry, through which catalogs can be discovered
+by name and platform. The sender becomes its admin, the only address that
+can register and unregister catalogs.`,
+	Args: cobra.NoArgs,
+	RunE: runCreateRegistry,
+}
+
+var createRegistrySave bool
+
+func init() {
+	createRegistryCmd.Flags().BoolVar(&createRegistrySave, "save-config", false, "Save the new registry ID as registry_id in the active config file")
+	rootCmd.AddCommand(createRegistryCmd)
+	reportsResult(createRegistryCmd)
+}
+
+func runCreateRegistry(cmd *cobra.Command, args []string) error {
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+
+	statusln("Creating catalog registry...")
+	output, err := executeMoveCall(sui.CreateRegistry(cfg.PackageID))
+	if err != nil {
+		return fmt.Errorf("failed to create registry: %w", err)
+	}
+
+	registryID := sui.CreatedObjectID(output, "CatalogRegistry")
+	digest := sui.TransactionDigest(output)
+	if registryID == "" {
+		return fmt.Errorf("registry created in %s, but its ID was not found in the output", digest)
+	}
+	setResult(map[string]string{"registry_id": registryID, "digest": digest})
+	statusf("\n✓ Registry created!\n")
+	fmt.Fprintf(stdout, "Registry ID: %s\n", registryID)
+	printExplorerLink("  ", config.LinkObject, registryID)
+	fmt.Fprintf(stdout, "Transaction: %s\n", digest)
+	printExplorerLink("  ", config.LinkTx, digest)
+
+	if createRegistrySave {
+		return saveConfigValue("registry_id", registryID)
+	}
+	if cfg.RegistryID == "" && !quiet() {
+		statusf("\n💡 Tip: Save it as the default registry with --save-config next time, or run:\n")
+		fmt.Fprintf(stdout, "  catalogctl config set registry_id %s\n", registryID)
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// register-catalog
+// ----------------------------------------------------------------------------
+
+var registerCatalogCmd = &cobra.Command{
+	Use:   "register-catalog",
+	Short: "List a catalog in the registry (registry admin only)",
+	Long: `Adds a catalog to the registry with a name, description and primary
+platform, so it shows up in list-registry and search-registry. Name and
+description default to the catalog's own. The platform defaults to the one
+all entries share, else mixed.
+
+--unregister removes the catalog from the registry instead.`,
+	Example: `  catalogctl register-catalog --catalog nes-classics --platform nes
+  catalogctl register-catalog --catalog 0x123... --unregister`,
+	Args: cobra.NoArgs,
+	RunE: runRegisterCatalog,
+}
+
+var (
+	registerRegistryID  string
+	registerCatalogID   string
+	registerName        string
+	registerDescription string
+	registerPlatform    string
+	registerUnregister  bool
+)
+
+func init() {
+	registerCatalogCmd.Flags().StringVar(&registerRegistryID, "registry", "", "Registry object ID (uses config.registry_id if not set)")
+	registerCatalogCmd.Flags().StringVar(&registerCatalogID, "catalog", "", "Catalog object ID or alias (uses config.catalog_id if not set)")
+	validateAs(registerCatalogCmd.Flags(), "catalog", formatCatalogRef)
+	registerCatalogCmd.Flags().StringVar(&registerName, "name", "", "Name in the registry (default: the catalog's name)")
+	registerCatalogCmd.Flags().StringVar(&registerDescription, "description", "", "Description in the registry (default: the catalog's description)")
+	registerCatalogCmd.Flags().StringVar(&registerPlatform, "platform", "", "Primary platform (dos, gb, gbc, nes, snes) or mixed (default: from the entries)")
+	registerCatalogCmd.Flags().BoolVar(&registerUnregister, "unregister", false, "Remove the catalog from the registry")
+	rootCmd.AddCommand(registerCatalogCmd)
+	reportsResult(registerCatalogCmd)
+}
+
+func runRegisterCatalog(cmd *cobra.Command, args []string) error {
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+	registryID, err := resolveRegistryID(registerRegistryID)
+	if err != nil {
+		return err
+	}
+	catalogID := registerCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	if catalogID, err = cfg.ResolveCatalogID(catalogID); err != nil {
+		return err
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	if _, _, err := readRegistry(client, registryID); err != nil {
+		return err
+	}
+	field, err := client.GetDynamicFieldObject(registryID, sui.DynamicFieldName{Type: "0x2::object::ID", Value: catalogID})
+	if err != nil {
+		return fmt.Errorf("failed to look up the catalog in the registry: %w", err)
+	}
+	registered := field.Data != nil
+
+	if registerUnregister {
+		if !registered {
+			return fmt.Errorf("catalog %s is not in registry %s", catalogID, registryID)
+		}
+		statusf("Unregistering catalog %s...\n", catalogID)
+		output, err := executeMoveCall(sui.UnregisterCatalog(cfg.PackageID, registryID, catalogID))
+		if err != nil {
+			return fmt.Errorf("failed to unregister catalog: %w", err)
+		}
+		digest := sui.TransactionDigest(output)
+		setResult(map[string]interface{}{"registry_id": registryID, "catalog_id": catalogID, "registered": false, "digest": digest})
+		statusf("\n✓ Catalog removed from the registry\n")
+		fmt.Fprintf(stdout, "Transaction: %s\n", digest)
+		printExplorerLink("  ", config.LinkTx, digest)
+		return nil
+	}
+	if registered {
+		return fmt.Errorf("catalog %s is already in registry %s; unregister it first to change its listing", catalogID, registryID)
+	}
+
+	catalogResp, err := client.GetObject(catalogID)
+	if err != nil {
+		return fmt.Errorf("failed to get catalog: %w", err)
+	}
+	if catalogResp.Data == nil {
+		return fmt.Errorf("catalog not found")
+	}
+	catalogFields := sui.ParseCatalog(catalogResp.Data)
+	name, description := registerName, registerDescription
+	if name == "" {
+		name, _ = catalogFields["name"].(string)
+	}
+	if description == "" {
+		description, _ = catalogFields["description"].(string)
+	}
+	if name == "" {
+		return fmt.Errorf("the catalog has no name; set --name")
+	}
+
+	var platform uint8
+	if registerPlatform != "" {
+		if platform, err = parseRegistryPlatform(registerPlatform); err != nil {
+			return err
+		}
+	} else {
+		entries, err := fetchCatalogEntries(client, catalogID)
+		if err != nil {
+			return err
+		}
+		platform = registryMixedPlatform
+		for i, entry := range entries {
+			if i == 0 {
+				platform = uint8(entry.Platform)
+			} else if uint8(entry.Platform) != platform {
+				platform = registryMixedPlatform
+				break
+			}
+		}
+	}
+
+	statusf("Registering catalog '%s' (%s, %s)...\n", name, catalogID, registryPlatformName(platform))
+	output, err := executeMoveCall(sui.RegisterCatalog(cfg.PackageID, registryID, catalogID, name, description, platform))
+	if err != nil {
+		return fmt.Errorf("failed to register catalog: %w", err)
+	}
+	digest := sui.TransactionDigest(output)
+	setResult(map[string]interface{}{
+		"registry_id": registryID,
+		"catalog":     registryEntry{CatalogID: catalogID, Name: name, Description: description, Platform: registryPlatformName(platform), PlatformCode: platform},
+		"registered":  true,
+		"digest":      digest,
+	})
+	statusf("\n✓ Catalog registered!\n")
+	fmt.Fprintf(stdout, "Transaction: %s\n", digest)
+	printExplorerLink("  ", config.LinkTx, digest)
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// list-registry / search-registry
+// ----------------------------------------------------------------------------
+
+var listRegistryCmd = &cobra.Command{
+	Use:   "list-registry",
+	Short: "List the catalogs in a registry",
+	Long: `Lists the catalogs of a registry a page at a time. When more follow, the
+cursor of the next page is printed (and is next_cursor with --output json);
+pass it to --cursor. --limit 0 lists everything.`,
+	Example: `  catalogctl list-registry
+  catalogctl list-registry --limit 20 --cursor 0xabc...
+  catalogctl list-registry --limit 0 --output json`,
+	Args: cobra.NoArgs,
+	RunE: runListRegistry,
+}
+
+var searchRegistryCmd = &cobra.Command{
+	Use:   "search-registry [QUERY]",
+	Short: "Find catalogs in a registry by name, description or platform",
+	Long: `Searches the catalogs of a registry. Every word of QUERY must appear in the
+name, description or catalog ID (case-insensitive); --platform keeps
+catalogs with that primary platform (mixed for catalogs of several
+platforms). Results are paged like list-registry.`,
+	Example: `  catalogctl search-registry mario
+  catalogctl search-registry --platform snes
+  catalogctl search-registry "rpg classics" --platform mixed --output json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSearchRegistry,
+}
+
+var (
+	registryListID         string
+	registryLimit          int
+	registryCursor         string
+	registrySearchPlatform string
+)
+
+func init() {
+	for _, c := range []*cobra.Command{listRegistryCmd, searchRegistryCmd} {
+		c.Flags().StringVar(&registryListID, "registry", "", "Registry object ID (uses config.registry_id if not set)")
+		c.Flags().IntVar(&registryLimit, "limit", 20, "Catalogs per page (0 for all)")
+		c.Flags().StringVar(&registryCursor, "cursor", "", "Continue after this cursor (printed at the end of the previous page)")
+	}
+	searchRegistryCmd.Flags().StringVar(&registrySearchPlatform, "platform", "", "Only catalogs with this primary platform (dos, gb, gbc, nes, snes or mixed)")
+	rootCmd.AddCommand(listRegistryCmd)
+	reportsResult(listRegistryCmd)
+	rootCmd.AddCommand(searchRegistryCmd)
+	reportsResult(searchRegistryCmd)
+}
+
+func runListRegistry(cmd *cobra.Command, args []string) error {
+	return showRegistry("", nil, "catalogctl list-registry")
+}
+
+func runSearchRegistry(cmd *cobra.Command, args []string) error {
+	var query string
+	if len(args) == 1 {
+		query = strings.TrimSpace(args[0])
+	}
+	if query == "" && registrySearchPlatform == "" {
+		return fmt.Errorf("give a search term or --platform (list-registry lists everything)")
+	}
+	platform := -1
+	if registrySearchPlatform != "" {
+		p, err := parseRegistryPlatform(registrySearchPlatform)
+		if err != nil {
+			return err
+		}
+		platform = int(p)
+	}
+	words := strings.Fields(strings.ToLower(query))
+	match := func(e registryEntry) bool {
+		if platform >= 0 && int(e.PlatformCode) != platform {
+			return false
+		}
+		text := strings.ToLower(e.Name + " " + e.Description + " " + e.CatalogID)
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				return false
+			}
+		}
+		return true
+	}
+
+	next := "catalogctl search-registry"
+	if query != "" {
+		next += " " + strconv.Quote(query)
+	}
+	if registrySearchPlatform != "" {
+		next += " --platform " + registrySearchPlatform
+	}
+	return showRegistry(query, match, next)
+}
+
+// showRegistry prints (and sets as the result) one page of matching entries
+func showRegistry(query string, match func(registryEntry) bool, nextCommand string) error {
+	if registryLimit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+	registryID, err := resolveRegistryID(registryListID)
+	if err != nil {
+		return err
+	}
+	var cursor *string
+	if registryCursor != "" {
+		if err := validate.ObjectID(registryCursor); err != nil {
+			return fmt.Errorf("invalid --cursor %q: %w", registryCursor, err)
+		}
+		cursor = &registryCursor
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	admin, count, err := readRegistry(client, registryID)
+	if err != nil {
+		return err
+	}
+	entries, next, err := scanRegistry(client, registryID, cursor, registryLimit, match)
+	if err != nil {
+		return err
+	}
+	setResult(registryResult{
+		RegistryID: registryID,
+		Admin:      admin,
+		Count:      count,
+		Query:      query,
+		Catalogs:   entries,
+		NextCursor: next,
+	})
+
+	fmt.Fprintf(stdout, "Registry: %s (%d catalogs, admin %s)\n\n", registryID, count, admin)
+	if registryLimit != 20 {
+		nextCommand += fmt.Sprintf(" --limit %d", registryLimit)
+	}
+	printRegistryEntries(entries, next, nextCommand)
+	return nil
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/storage"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// renew-blobs command
+// ============================================================================
+
+var renewBlobsCmd = &cobra.Command{
+	Use:   "renew-blobs",
+	Short: "Extend the Walrus storage of catalog blobs that are about to expire",
+	Long: `Checks how many epochs every blob of a catalog (game files, delta patches and
+covers, on all channels) is still stored for, and extends the storage of the
+blobs below --min-epochs with walrus extend. Blobs shared by several entries
+are extended once.
+
+By default each blob is topped up to --min-epochs remaining epochs; --epochs
+extends every renewed blob by a fixed number of epochs instead. The report
+lists the renewed blob IDs with their new end epoch and the storage cost,
+estimated from the current Walrus prices.
+
+Extending needs the walrus CLI, and only the address that owns a blob's Blob
+object (the one that uploaded it) can extend it. Expired blobs can't be
+renewed; publish their files again.
+
+Example:
+  catalogctl renew-blobs --catalog 0x123... --min-epochs 10
+  catalogctl renew-blobs --min-epochs 20 --epochs 26 --dry-run`,
+	RunE: runRenewBlobs,
+}
+
+var (
+	renewCatalogID string
+	renewMinEpochs uint64
+	renewEpochs    int
+	renewDryRun    bool
+)
+
+func init() {
+	renewBlobsCmd.Flags().StringVar(&renewCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(renewBlobsCmd.Flags(), "catalog", formatCatalogRef)
+	renewBlobsCmd.Flags().Uint64Var(&renewMinEpochs, "min-epochs", 10, "Renew blobs stored for fewer than this many more epochs")
+	renewBlobsCmd.Flags().IntVar(&renewEpochs, "epochs", 0, "Epochs to extend each renewed blob by (default: up to --min-epochs)")
+	renewBlobsCmd.Flags().BoolVar(&renewDryRun, "dry-run", false, "Show which blobs would be renewed and the cost without extending them")
+	rootCmd.AddCommand(renewBlobsCmd)
+	reportsResult(renewBlobsCmd)
+}
+
+// States of a blob in the renew report
+const (
+	renewOK         = "ok"
+	renewRenewed    = "renewed"
+	renewWouldRenew = "would_renew"
+	renewExpired    = "expired"
+	renewFailed     = "failed"
+)
+
+// renewReport is the result of renew-blobs
+type renewReport struct {
+	CatalogID string      `json:"catalog_id"`
+	Network   string      `json:"network"`
+	MinEpochs uint64      `json:"min_epochs"`
+	DryRun    bool        `json:"dry_run"`
+	Blobs     []renewBlob `json:"blobs"`
+	// Renewed are the blob IDs extended (or to extend with --dry-run)
+	Renewed    []string `json:"renewed"`
+	TotalFrost uint64   `json:"total_frost"`
+	// CostNote says why the cost is missing or incomplete
+	CostNote string `json:"cost_note,omitempty"`
+}
+
+// renewBlob is one blob of the catalog and what renew-blobs did with it
+type renewBlob struct {
+	BlobID string `json:"blob_id"`
+	// Kind is "game" (a game file or delta patch) or "cover"
+	Kind         string   `json:"kind"`
+	Entries      []string `json:"entries"`
+	Status       string   `json:"status"`
+	EndEpoch     uint64   `json:"end_epoch,omitempty"`
+	CurrentEpoch uint64   `json:"current_epoch,omitempty"`
+	Epochs       int      `json:"epochs_extended,omitempty"`
+	NewEndEpoch  uint64   `json:"new_end_epoch,omitempty"`
+	Size         int64    `json:"size,omitempty"`
+	CostFrost    uint64   `json:"cost_frost,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// remaining is the number of epochs the blob is still stored for
+func (b *renewBlob) remaining() uint64 {
+	if b.EndEpoch <= b.CurrentEpoch {
+		return 0
+	}
+	return b.EndEpoch - b.CurrentEpoch
+}
+
+func runRenewBlobs(cmd *cobra.Command, args []string) error {
+	if renewMinEpochs < 1 {
+		return fmt.Errorf("--min-epochs must be at least 1")
+	}
+	if renewEpochs < 0 {
+		return fmt.Errorf("--epochs can't be negative")
+	}
+	catalogID := renewCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
+	backend, err := storageBackend()
+	if err != nil {
+		return err
+	}
+	expirer, ok := backend.(storage.Expirer)
+	if !ok {
+		return fmt.Errorf("the %s backend doesn't expire blobs; there is nothing to renew", backend.Name())
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		return err
+	}
+	if err := hydrateCartridges(client, entries); err != nil {
+		return err
+	}
+	blobs := catalogBlobs(entries)
+
+	report := &renewReport{
+		CatalogID: catalogID,
+		Network:   cfg.SuiNetwork,
+		MinEpochs: renewMinEpochs,
+		DryRun:    renewDryRun,
+		Blobs:     []renewBlob{},
+		Renewed:   []string{},
+	}
+	_, prices, err := readWalrusPrices(client)
+	if err != nil {
+		report.CostNote = fmt.Sprintf("storage cost not estimated: %v", err)
+	}
+
+	statusf("Checking %d blobs of %d entries in catalog %s...\n\n", len(blobs), len(entries), catalogID)
+	failed := 0
+	for _, blob := range blobs {
+		expiry, err := expirer.Expiry(blob.BlobID)
+		if err != nil {
+			// Without the walrus CLI no blob can be checked
+			return fmt.Errorf("failed to read the storage status of %s: %w", blob.BlobID, err)
+		}
+		blob.EndEpoch, blob.CurrentEpoch = expiry.EndEpoch, expiry.CurrentEpoch
+		switch {
+		case expiry.Expired():
+			blob.Status = renewExpired
+		case blob.remaining() >= renewMinEpochs:
+			blob.Status = renewOK
+		default:
+			blob.Epochs = renewEpochs
+			if blob.Epochs == 0 {
+				blob.Epochs = int(renewMinEpochs - blob.remaining())
+			}
+			blob.NewEndEpoch = blob.EndEpoch + uint64(blob.Epochs)
+			if prices != nil {
+				if info, err := backend.Stat(blob.BlobID); err == nil && info.Size >= 0 {
+					blob.Size = info.Size
+					blob.CostFrost, _ = prices.Cost(info.Size, blob.Epochs)
+				} else if report.CostNote == "" {
+					report.CostNote = "the size of some blobs couldn't be read; their cost is not included"
+				}
+			}
+
+			if renewDryRun {
+				blob.Status = renewWouldRenew
+			} else if _, err := backend.Extend(blob.BlobID, blob.Epochs); err != nil {
+				blob.Status, blob.Error = renewFailed, err.Error()
+				failed++
+			} else {
+				blob.Status = renewRenewed
+				debugf(levelVerbose, "extended blob %s by %d epoch(s)", blob.BlobID, blob.Epochs)
+			}
+			if blob.Status != renewFailed {
+				report.Renewed = append(report.Renewed, blob.BlobID)
+				report.TotalFrost += blob.CostFrost
+			}
+		}
+		report.Blobs = append(report.Blobs, *blob)
+		printRenewBlob(blob)
+	}
+
+	setResult(report)
+	verb := "Renewed"
+	if renewDryRun {
+		verb = "Would renew"
+	}
+	fmt.Fprintf(stdout, "\n%s %d of %d blobs", verb, len(report.Renewed), len(blobs))
+	if prices != nil {
+		fmt.Fprintf(stdout, " for %s", formatWAL(report.TotalFrost))
+	}
+	fmt.Fprintln(stdout)
+	if report.CostNote != "" {
+		statusf("💡 %s\n", report.CostNote)
+	}
+	if renewDryRun && len(report.Renewed) > 0 {
+		statusln("Dry run: rerun without --dry-run to extend them")
+	}
+
+	newGHSummary(fmt.Sprintf("%s %d blobs of catalog", verb, len(report.Renewed))).
+		row("Catalog", mdLink(catalogID, explorerURL(config.LinkObject, catalogID))).
+		row("Minimum epochs", fmt.Sprintf("%d", renewMinEpochs)).
+		row("Storage cost", formatWAL(report.TotalFrost)).
+		row("Failed", fmt.Sprintf("%d", failed)).
+		output("renewed", strings.Join(report.Renewed, ",")).
+		write()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d blobs could not be renewed", failed, len(report.Renewed)+failed)
+	}
+	return nil
+}
+
+// catalogBlobs lists the blobs the entries of a catalog store their game
+// files and covers in, each once, in entry order
+func catalogBlobs(entries []catalogEntry) []*renewBlob {
+	var blobs []*renewBlob
+	byID := make(map[string]*renewBlob)
+	add := func(hexID, kind, key string) {
+		id := walrusBlobID(hexID)
+		blob, ok := byID[id]
+		if !ok {
+			blob = &renewBlob{BlobID: id, Kind: kind}
+			byID[id] = blob
+			blobs = append(blobs, blob)
+		}
+		blob.Entries = append(blob.Entries, key)
+	}
+	for _, entry := range entries {
+		key := model.ChannelKey(entry.Slug, entry.Channel)
+		if entry.Cartridge == nil || entry.Cartridge.BlobID == "" {
+			warnf("%s: cartridge %s can't be read; its blob is not checked", key, entry.CartridgeID)
+		} else {
+			add(entry.Cartridge.BlobID, "game", key)
+		}
+		if entry.CoverBlobID != "" {
+			add(entry.CoverBlobID, "cover", key)
+		}
+	}
+	return blobs
+}
+
+// printRenewBlob prints a line of the renew report
+func printRenewBlob(b *renewBlob) {
+	what := fmt.Sprintf("%s (%s %s)", b.BlobID, b.Kind, strings.Join(b.Entries, ", "))
+	switch b.Status {
+	case renewOK:
+		fmt.Fprintf(stdout, "✓ %s: %d epochs left\n", what, b.remaining())
+	case renewExpired:
+		if b.EndEpoch == 0 {
+			fmt.Fprintf(stdout, "✗ %s: Walrus has no record of it (expired or never stored); publish it again\n", what)
+		} else {
+			fmt.Fprintf(stdout, "✗ %s: expired at epoch %d; publish it again\n", what, b.EndEpoch)
+		}
+	case renewFailed:
+		fmt.Fprintf(stdout, "✗ %s: %s\n", what, b.Error)
+	default:
+		line := fmt.Sprintf("epoch %d → %d (+%d)", b.EndEpoch, b.NewEndEpoch, b.Epochs)
+		if b.CostFrost > 0 {
+			line += ", " + formatWAL(b.CostFrost)
+		}
+		mark := "↻"
+		if b.Status == renewWouldRenew {
+			mark = "~"
+		}
+		fmt.Fprintf(stdout, "%s %s: %s\n", mark, what, line)
+	}
+}
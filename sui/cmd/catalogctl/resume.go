@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/walrus"
+)
+
+// ============================================================================
+// Resumable downloads
+// ============================================================================
+
+// downloadCheckpointBytes is how much is written between saves of the
+// download state; at most this much is downloaded again after a crash
+const downloadCheckpointBytes = 8 << 20
+
+// downloadMaxStalls is how many attempts in a row may fail without getting
+// any data before a download gives up
+const downloadMaxStalls = 3
+
+// downloadState is the sidecar of a partial download (<output>.part.json).
+// Only the first Offset bytes of the .part file are trusted.
+type downloadState struct {
+	BlobID    string    `json:"blob_id"`
+	Offset    int64     `json:"offset"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256,omitempty"`
+	ETag      string    `json:"etag,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func loadDownloadState(path string) *downloadState {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var state downloadState
+	if json.Unmarshal(data, &state) != nil {
+		return nil
+	}
+	return &state
+}
+
+func (s *downloadState) save(path string) error {
+	s.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// downloadBlobResumable downloads a blob to output through <output>.part,
+// resuming an earlier partial download of the same blob with Range
+// requests. The file is renamed into place once complete and, if
+// expectedSHA (or the one recorded by the earlier attempt) is set, verified.
+// It returns the SHA256 and size of the file and the last aggregator used.
+func downloadBlobResumable(blobID, output, expectedSHA string) (string, int64, string, error) {
+	partPath := output + ".part"
+	statePath := partPath + ".json"
+
+	state := loadDownloadState(statePath)
+	var offset int64
+	if state != nil && state.BlobID == blobID && (expectedSHA == "" || state.SHA256 == "" || state.SHA256 == expectedSHA) {
+		if info, err := os.Stat(partPath); err == nil {
+			offset = min(state.Offset, info.Size())
+		}
+		if expectedSHA == "" {
+			expectedSHA = state.SHA256
+		}
+		if offset > 0 {
+			fmt.Fprintf(stdout, "  Resuming at %d of %d bytes\n", offset, state.Size)
+		}
+	} else {
+		state = &downloadState{BlobID: blobID}
+	}
+	state.SHA256 = expectedSHA
+
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("failed to open %s: %w", partPath, err)
+	}
+	defer f.Close()
+	if err := f.Truncate(offset); err != nil {
+		return "", 0, "", fmt.Errorf("failed to truncate %s: %w", partPath, err)
+	}
+
+	mirrors := aggregatorMirrors()
+	var aggregator string
+	for stalls := 0; ; {
+		resp, url, err := mirrors.OpenRange(blobID, offset, state.ETag)
+		if err != nil {
+			if stalls++; stalls >= downloadMaxStalls {
+				return "", 0, "", fmt.Errorf("failed after %d retries: %w", stalls, err)
+			}
+			time.Sleep(time.Duration(stalls) * time.Second)
+			continue
+		}
+		aggregator = url
+
+		if resp.Start != offset {
+			fmt.Fprintf(stdout, "  %s sent the whole blob; starting over\n", url)
+			offset = 0
+			if err := f.Truncate(0); err != nil {
+				resp.Body.Close()
+				return "", 0, "", fmt.Errorf("failed to truncate %s: %w", partPath, err)
+			}
+		}
+		state.ETag, state.Size = resp.ETag, resp.Size
+
+		n, err := copyWithCheckpoints(f, resp.Body, offset, state, statePath)
+		resp.Body.Close()
+		offset += n
+		if err == nil {
+			break
+		}
+
+		state.Offset = offset
+		state.save(statePath)
+		var writeErr *checkpointError
+		if errors.As(err, &writeErr) {
+			return "", 0, "", err
+		}
+		if n > 0 {
+			stalls = 0
+		}
+		if stalls++; stalls >= downloadMaxStalls {
+			return "", 0, "", fmt.Errorf("download interrupted at %d bytes, run again to resume: %w", offset, err)
+		}
+		statusf("⚠️  Download interrupted at %d bytes (%v); resuming\n", offset, err)
+		time.Sleep(time.Duration(stalls) * time.Second)
+	}
+
+	if state.Size >= 0 && offset != state.Size {
+		state.Offset = offset
+		state.save(statePath)
+		return "", 0, "", fmt.Errorf("download ended at %d of %d bytes, run again to resume", offset, state.Size)
+	}
+	if err := f.Close(); err != nil {
+		return "", 0, "", fmt.Errorf("failed to write %s: %w", partPath, err)
+	}
+
+	sha256Hex, size, err := fileSHA256(partPath)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("failed to hash %s: %w", partPath, err)
+	}
+	if expectedSHA != "" && sha256Hex != expectedSHA {
+		// The bytes are wrong somewhere, so resuming would not help
+		os.Remove(partPath)
+		os.Remove(statePath)
+		return "", 0, "", fmt.Errorf("SHA256 mismatch: got %s, expected %s", sha256Hex, expectedSHA)
+	}
+	if err := os.Rename(partPath, output); err != nil {
+		return "", 0, "", fmt.Errorf("failed to write file: %w", err)
+	}
+	os.Remove(statePath)
+	return sha256Hex, size, aggregator, nil
+}
+
+// fetchChunkManifest returns the manifest if blobID was stored by
+// upload-blob --resumable; larger blobs aren't fetched to check
+func fetchChunkManifest(blobID string) *walrus.ChunkManifest {
+	mirrors := aggregatorMirrors()
+	resp, _, err := mirrors.OpenRange(blobID, 0, "")
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.Size > walrus.MaxManifestSize {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, walrus.MaxManifestSize+1))
+	if err != nil {
+		return nil
+	}
+	return walrus.ParseChunkManifest(data)
+}
+
+// downloadChunkedBlob assembles the chunks of a manifest into output through
+// <output>.part, checking every chunk and the whole file. It returns the
+// same values as downloadBlobResumable.
+func downloadChunkedBlob(manifest *walrus.ChunkManifest, output, expectedSHA string) (string, int64, string, error) {
+	if expectedSHA != "" && manifest.SHA256 != expectedSHA {
+		return "", 0, "", fmt.Errorf("SHA256 mismatch: the manifest lists %s, expected %s", manifest.SHA256, expectedSHA)
+	}
+	partPath := output + ".part"
+	f, err := os.Create(partPath)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("failed to open %s: %w", partPath, err)
+	}
+	defer f.Close()
+
+	mirrors := aggregatorMirrors()
+	var aggregator string
+	read := func(blobID string) ([]byte, error) {
+		data, url, err := mirrors.ReadWithRetry(blobID, downloadMaxStalls)
+		aggregator = url
+		return data, err
+	}
+	if err := manifest.Assemble(f, read); err != nil {
+		os.Remove(partPath)
+		return "", 0, "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", 0, "", fmt.Errorf("failed to write %s: %w", partPath, err)
+	}
+	if err := os.Rename(partPath, output); err != nil {
+		return "", 0, "", fmt.Errorf("failed to write file: %w", err)
+	}
+	return manifest.SHA256, manifest.Size, aggregator, nil
+}
+
+// checkpointError is a failure to write the file or its state, as opposed
+// to a failed read from the aggregator
+type checkpointError struct{ err error }
+
+func (e *checkpointError) Error() string { return e.err.Error() }
+func (e *checkpointError) Unwrap() error { return e.err }
+
+// copyWithCheckpoints appends body to f (which holds offset bytes) and saves
+// the state after every downloadCheckpointBytes, once they are synced
+func copyWithCheckpoints(f *os.File, body io.Reader, offset int64, state *downloadState, statePath string) (int64, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, &checkpointError{err}
+	}
+	buf := make([]byte, 1<<20)
+	var written, sinceCheckpoint int64
+	for {
+		n, readErr := body.Read(buf)
+		if n > 0 {
+			if _, err := f.Write(buf[:n]); err != nil {
+				return written, &checkpointError{err}
+			}
+			written += int64(n)
+			sinceCheckpoint += int64(n)
+		}
+		if sinceCheckpoint >= downloadCheckpointBytes {
+			if err := f.Sync(); err != nil {
+				return written, &checkpointError{err}
+			}
+			state.Offset = offset + written
+			if err := state.save(statePath); err != nil {
+				return written, &checkpointError{err}
+			}
+			sinceCheckpoint = 0
+		}
+		if readErr == io.EOF {
+			return written, nil
+		}
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+}
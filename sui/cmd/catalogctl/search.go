@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/i18n"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/search"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// search command
+// ============================================================================
+
+var searchCmd = &cobra.Command{
+	Use:   "search QUERY",
+	Short: "Search the entries of a catalog by title, key and tags",
+	Long: `Finds catalog entries whose title, key or tags (platform, channel,
+emulator core and the entry's tags) contain every word of QUERY. Words also
+match as prefixes, so "zel" finds "Zelda"; title matches rank first.
+
+The index is built from the catalog on chain, or read from the search.json
+written by export-site with --index.
+
+Example:
+  catalogctl search mario
+  catalogctl search "nes beta" --channel all
+  catalogctl search doom --index site/search.json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSearch,
+}
+
+var (
+	searchCatalogID string
+	searchChannel   string
+	searchIndexFile string
+	searchLimit     int
+)
+
+func init() {
+	searchCmd.Flags().StringVar(&searchCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(searchCmd.Flags(), "catalog", formatCatalogRef)
+	searchCmd.Flags().StringVar(&searchChannel, "channel", model.ChannelStable, "Release channel to search: stable, beta or all")
+	searchCmd.Flags().StringVar(&searchIndexFile, "index", "", "Search this search.json (from export-site) instead of the catalog on chain")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "Maximum results (0 for all)")
+	rootCmd.AddCommand(searchCmd)
+	reportsResult(searchCmd)
+}
+
+// searchIndexName is the index export-site writes next to catalog.json
+const searchIndexName = "search.json"
+
+// entrySearchDocuments turns catalog entries into search documents. Tags
+// are the platform, channel, emulator core and the entry's own tags; fields
+// carry what a result links to.
+func entrySearchDocuments(catalogID string, entries []model.CatalogEntry) []search.Document {
+	docs := make([]search.Document, 0, len(entries))
+	for _, entry := range entries {
+		tags := []string{entry.Platform.String(), entry.Channel}
+		if entry.EmulatorCore != "" {
+			tags = append(tags, entry.EmulatorCore)
+		}
+		tags = append(tags, entry.Tags...)
+		docs = append(docs, search.Document{
+			ID:    entry.Slug,
+			Title: entry.Title,
+			Tags:  tags,
+			Fields: map[string]string{
+				"catalog_id":   catalogID,
+				"cartridge_id": entry.CartridgeID,
+				"platform":     entry.Platform.String(),
+				"channel":      entry.Channel,
+				"version":      fmt.Sprintf("%d", entry.Version),
+			},
+		})
+	}
+	return docs
+}
+
+// buildCatalogSearchIndex reads a catalog's entries (of one channel, or
+// all for "") and indexes them
+func buildCatalogSearchIndex(client *sui.Client, catalogID, channel string) (*search.Index, error) {
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		return nil, err
+	}
+	shown := make([]model.CatalogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if channel == "" || entry.Channel == channel {
+			shown = append(shown, entry.CatalogEntry)
+		}
+	}
+	return search.Build(entrySearchDocuments(catalogID, shown)), nil
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	query := strings.Join(args, " ")
+	channel := searchChannel
+	if channel == "all" {
+		channel = ""
+	} else {
+		var err error
+		if channel, err = model.ParseChannel(channel); err != nil {
+			return err
+		}
+	}
+
+	var idx *search.Index
+	if searchIndexFile != "" {
+		data, err := os.ReadFile(searchIndexFile)
+		if err != nil {
+			return fmt.Errorf("failed to read search index: %w", err)
+		}
+		if idx, err = search.Parse(data); err != nil {
+			return err
+		}
+	} else {
+		catalogID := searchCatalogID
+		if catalogID == "" {
+			catalogID = cfg.CatalogID
+		}
+		if catalogID == "" {
+			return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+		}
+		catalogID, err := cfg.ResolveCatalogID(catalogID)
+		if err != nil {
+			return err
+		}
+		if idx, err = buildCatalogSearchIndex(sui.NewClient(cfg.SuiRPCURL), catalogID, channel); err != nil {
+			return err
+		}
+	}
+
+	results := idx.Search(query, 0)
+	// An exported index holds every channel of the export
+	if searchIndexFile != "" && channel != "" {
+		shown := results[:0]
+		for _, r := range results {
+			if r.Fields["channel"] == channel {
+				shown = append(shown, r)
+			}
+		}
+		results = shown
+	}
+	if searchLimit > 0 && len(results) > searchLimit {
+		results = results[:searchLimit]
+	}
+	setResult(map[string]interface{}{
+		"query":   query,
+		"results": results,
+	})
+
+	if len(results) == 0 {
+		i18n.Fprintf(stdout, "No entries match %q.\n", query)
+		return nil
+	}
+	fmt.Fprintf(stdout, "%-20s %-30s %-8s %-8s %-6s %s\n", "SLUG", "TITLE", "PLATFORM", "VERSION", "SCORE", "CARTRIDGE_ID")
+	fmt.Fprintln(stdout, "-----------------------------------------------------------------------------------------------")
+	for _, r := range results {
+		fmt.Fprintf(stdout, "%-20s %-30s %-8s v%-7s %-6d %s\n",
+			truncate(r.ID, 20),
+			truncate(r.Title, 30),
+			r.Fields["platform"],
+			r.Fields["version"],
+			r.Score,
+			r.Fields["cartridge_id"],
+		)
+	}
+	return nil
+}
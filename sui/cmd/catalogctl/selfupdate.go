@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/retro-crypto/shared/selfupdate"
+	"github.com/retro-crypto/sui/internal/httpclient"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// self-update command
+// ============================================================================
+
+// releaseTagPrefix starts the tags of catalogctl releases in the feed
+const releaseTagPrefix = "catalogctl-v"
+
+var (
+	selfUpdateCheck      bool
+	selfUpdateVersion    string
+	selfUpdatePrerelease bool
+	selfUpdateForce      bool
+	selfUpdatePublicKey  string
+	selfUpdateFeed       string
+)
+
+// selfUpdateResult is the --output of self-update
+type selfUpdateResult struct {
+	Current         string              `json:"current"`
+	Release         *selfupdate.Release `json:"release"`
+	UpdateAvailable bool                `json:"update_available"`
+	Updated         bool                `json:"updated"`
+	Path            string              `json:"path,omitempty"`
+}
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update catalogctl to the latest release",
+	Long: `Check the release feed for a newer catalogctl, download the binary for this
+platform and replace the running executable with it.
+
+The release's checksums.txt must carry a valid signature (checksums.txt.sig,
+made with cosign sign-blob or an Ed25519 key) from the release public key
+built into catalogctl, name the release's tag, and the binary must match its
+checksum; otherwise nothing is replaced. The new binary is renamed over the
+old one, so an interrupted update leaves the old one working.
+
+Only newer releases are installed: reinstalling the current version or
+downgrading with --version needs --force.
+
+Development builds have no release key built in; pass the key with
+--public-key. They are only replaced with --force or --version.`,
+	Example: `  catalogctl self-update --check
+  catalogctl self-update
+  catalogctl self-update --version 1.4.0`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		feed := selfUpdateFeed
+		if feed == "" {
+			feed = os.Getenv("CATALOGCTL_UPDATE_FEED")
+		}
+		if feed == "" {
+			feed = selfupdate.DefaultFeed
+		}
+		client := httpclient.New(5 * time.Minute)
+
+		release, err := selfupdate.FindRelease(client, feed, releaseTagPrefix, selfUpdateVersion, selfUpdatePrerelease)
+		if err != nil {
+			return err
+		}
+		result := &selfUpdateResult{
+			Current:         Version,
+			Release:         release,
+			UpdateAvailable: selfupdate.CompareVersions(release.Version, Version) > 0,
+		}
+		setResult(result)
+
+		fmt.Fprintf(stdout, "Current version: %s\n", Version)
+		fmt.Fprintf(stdout, "Latest release:  %s (%s)\n", release.Version, release.Tag)
+		if selfUpdateVersion != "" {
+			fmt.Fprintf(stdout, "Requested:       %s\n", selfUpdateVersion)
+		}
+		if selfUpdateCheck {
+			if result.UpdateAvailable {
+				fmt.Fprintln(stdout, "💡 Run catalogctl self-update to install it")
+			} else if selfupdate.IsRelease(Version) {
+				fmt.Fprintln(stdout, "✓ catalogctl is up to date")
+			}
+			return nil
+		}
+
+		if selfUpdateVersion == "" && !selfUpdateForce {
+			if !selfupdate.IsRelease(Version) {
+				return fmt.Errorf("this is a development build (%s); pass --force to replace it with %s", Version, release.Version)
+			}
+			if !result.UpdateAvailable {
+				fmt.Fprintln(stdout, "✓ catalogctl is up to date")
+				return nil
+			}
+		}
+		if err := selfupdate.CheckTarget(release.Version, Version, selfUpdateForce); err != nil {
+			return err
+		}
+
+		key, err := releaseKey(selfUpdatePublicKey)
+		if err != nil {
+			return err
+		}
+		path, err := selfupdate.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate the running executable: %w", err)
+		}
+		result.Path = path
+
+		statusf("Downloading %s...\n", selfupdate.AssetName("catalogctl"))
+		bin, err := release.Download(client, "catalogctl", key)
+		if err != nil {
+			return err
+		}
+		statusf("✓ Signature, release %s and checksum verified\n", release.Tag)
+		if err := selfupdate.Replace(path, bin); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+		result.Updated = true
+		fmt.Fprintf(stdout, "✓ Updated %s: %s → %s\n", path, Version, release.Version)
+		return nil
+	},
+}
+
+func init() {
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateCheck, "check", false, "Only report whether a newer release exists")
+	selfUpdateCmd.Flags().StringVar(&selfUpdateVersion, "version", "", "Install this release instead of the latest (older ones need --force)")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdatePrerelease, "prerelease", false, "Consider prereleases as the latest release")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateForce, "force", false, "Install even if the release isn't newer (reinstall or downgrade) or this is a development build")
+	selfUpdateCmd.Flags().StringVar(&selfUpdatePublicKey, "public-key", "", "PEM file with the release public key (default: the key built in)")
+	selfUpdateCmd.Flags().StringVar(&selfUpdateFeed, "feed", "", "Release feed URL (default: $CATALOGCTL_UPDATE_FEED or the GitHub releases of this repository)")
+	rootCmd.AddCommand(selfUpdateCmd)
+	reportsResult(selfUpdateCmd)
+}
+
+// releaseKey returns the key release signatures are checked with: the one
+// in file, or the one built in
+func releaseKey(file string) (selfupdate.PublicKey, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return selfupdate.PublicKey{}, fmt.Errorf("failed to read public key: %w", err)
+		}
+		return selfupdate.ParsePublicKey(string(data))
+	}
+	if ReleasePublicKey == "" {
+		return selfupdate.PublicKey{}, fmt.Errorf("this build has no release public key to verify downloads with; pass --public-key")
+	}
+	return selfupdate.ParsePublicKey(ReleasePublicKey)
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/retro-crypto/shared/logging"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// serve command
+// ============================================================================
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the catalogctl HTTP server",
+	Long: `Starts an HTTP server for the configured network. An OpenAPI 3 description
+of the served endpoints is available at /openapi.json.
+
+The read-only gateway spares web frontends Sui RPC and Walrus: GET
+/catalogs/{id} and /catalogs/{id}/entries return a catalog and its entries,
+/cartridges/{id} a cartridge, and /blobs/{id} streams a blob from the
+aggregators. JSON responses are cached in memory for --cache-ttl and carry
+an ETag, so clients revalidate with If-None-Match. /search?q= searches a
+catalog's entries by title, key and tags, and /catalogs/{id}/search-index
+returns the index for client-side search. /feed.rss and /feed.json
+list the newest additions and updates of the --feed-catalog (Sui) and
+--feed-nimiq-catalog catalogs, or of catalog_id when neither is set.
+
+With --admin-token (or CATALOGCTL_ADMIN_TOKEN) it also serves an operator
+console at /admin for listing entries, retiring them, rolling an entry back
+to an earlier cartridge and extending Walrus blob storage. Admin actions send
+transactions with the local sui and walrus CLIs, exactly like the
+corresponding commands, so the server must run as the operator.
+
+Requests are rate limited per client IP and request bodies are capped.
+Write and expensive endpoints require one of the --api-token values (or
+CATALOGCTL_API_TOKENS, comma-separated) as a bearer token when any is set.
+--access-log writes Combined Log Format lines to a file that is rotated by
+size. Slow clients are cut off by --read-timeout and --write-timeout, and
+SIGINT/SIGTERM let running requests finish before the server exits.
+
+--uploads enables POST /api/upload: frontends post a game file, the server
+stores it through the Walrus publisher, reads it back to verify it and
+returns the blob ID, SHA256 and the Move calls that add it to the catalog,
+for the user to sign in their wallet. The server never signs anything.
+--uploads requires --api-token; pass --insecure-uploads to accept uploads
+from anyone who can reach the server.
+
+GET /by-hash/{sha256} serves a game file by its SHA256. The copies of the
+file are looked up in the games registry (see 'catalogctl games scan'); it is
+read from Walrus, or from a Nimiq cartridge (--nimiq-rpc-url) when the
+aggregators are down or Walrus fails, and checked before it is sent.
+
+Example:
+  CATALOGCTL_ADMIN_TOKEN=$(openssl rand -hex 16) catalogctl serve --port 8080
+  catalogctl serve --bind 0.0.0.0 --trust-proxy --rate-limit 5 --access-log access.log`,
+	RunE: runServe,
+}
+
+var (
+	servePort            int
+	serveBind            string
+	serveAdminToken      string
+	serveRateLimit       float64
+	serveRateBurst       int
+	serveTrustProxy      bool
+	serveAPITokens       []string
+	serveMaxBodyMB       int64
+	serveAccessLog       string
+	serveAccessLogMB     int64
+	serveAccessLogKeep   int
+	serveUploads         bool
+	serveInsecureUploads bool
+	serveOrigins         []string
+	serveNimiqRPCURL     string
+	serveCacheTTL        time.Duration
+	serveFeedCatalogs    []string
+	serveFeedNimiq       []string
+	serveFeedLimit       int
+	serveReadTimeout     time.Duration
+	serveWriteTimeout    time.Duration
+)
+
+// Connection limits of the server; the body size and the read and write
+// timeouts are flags
+const (
+	serveReadHeaderTimeout = 10 * time.Second
+	serveIdleTimeout       = 2 * time.Minute
+	serveMaxHeaderBytes    = 64 << 10
+	serveShutdownTimeout   = 30 * time.Second
+)
+
+// gatewayTokens guard write and expensive endpoints (see apiTokens)
+var gatewayTokens apiTokens
+
+func init() {
+	serveCmd.Flags().IntVar(&servePort, "port", 8080, "Port to listen on")
+	serveCmd.Flags().StringVar(&serveBind, "bind", "127.0.0.1", "Address to bind to (use 0.0.0.0 to listen on all interfaces)")
+	serveCmd.Flags().StringVar(&serveAdminToken, "admin-token", "", "Bearer token for the /admin console (or CATALOGCTL_ADMIN_TOKEN; console disabled if empty)")
+	serveCmd.Flags().Float64Var(&serveRateLimit, "rate-limit", 10, "Requests per second allowed per client IP (0 disables rate limiting)")
+	serveCmd.Flags().IntVar(&serveRateBurst, "rate-burst", 20, "Requests a client IP may burst above --rate-limit")
+	serveCmd.Flags().BoolVar(&serveTrustProxy, "trust-proxy", false, "Take client IPs from X-Forwarded-For (only behind a reverse proxy)")
+	serveCmd.Flags().StringSliceVar(&serveAPITokens, "api-token", nil, "Bearer token accepted by write and expensive endpoints (repeatable, or CATALOGCTL_API_TOKENS)")
+	serveCmd.Flags().Int64Var(&serveMaxBodyMB, "max-body-mb", 64, "Largest accepted request body in MB")
+	serveCmd.Flags().DurationVar(&serveReadTimeout, "read-timeout", 2*time.Minute, "Longest time to read a request including its body")
+	serveCmd.Flags().DurationVar(&serveWriteTimeout, "write-timeout", 10*time.Minute, "Longest time to handle a request and write its response (blobs are streamed within it)")
+	serveCmd.Flags().StringVar(&serveAccessLog, "access-log", "", "Write access logs to this file (- for stdout)")
+	serveCmd.Flags().Int64Var(&serveAccessLogMB, "access-log-max-mb", 100, "Rotate the access log when it exceeds this size in MB")
+	serveCmd.Flags().IntVar(&serveAccessLogKeep, "access-log-backups", 5, "Rotated access logs to keep")
+	serveCmd.Flags().BoolVar(&serveUploads, "uploads", false, "Enable POST /api/upload (Walrus upload proxy returning a draft entry to sign)")
+	serveCmd.Flags().BoolVar(&serveInsecureUploads, "insecure-uploads", false, "Allow --uploads without --api-token (anyone who can reach the server can upload)")
+	serveCmd.Flags().StringSliceVar(&serveOrigins, "allowed-origin", nil, "Frontend origin allowed to call the gateway and upload proxy from a browser (repeatable, * for any)")
+	serveCmd.Flags().DurationVar(&serveCacheTTL, "cache-ttl", 30*time.Second, "How long gateway JSON responses are cached in memory (0 disables the cache)")
+	serveCmd.Flags().StringSliceVar(&serveFeedCatalogs, "feed-catalog", nil, "Sui catalog ID or alias listed in /feed.rss and /feed.json (repeatable, default: catalog_id)")
+	serveCmd.Flags().StringSliceVar(&serveFeedNimiq, "feed-nimiq-catalog", nil, "Nimiq catalog address or alias (main, test) listed in the feeds (repeatable)")
+	serveCmd.Flags().IntVar(&serveFeedLimit, "feed-limit", 50, "Releases listed in the feeds")
+	serveCmd.Flags().StringVar(&gamesRegistry, "games-registry", "", "Games registry used by /by-hash (default: ~/.config/catalogctl/games.json)")
+	serveCmd.Flags().StringVar(&serveNimiqRPCURL, "nimiq-rpc-url", "", "Nimiq RPC URL for /by-hash reads of Nimiq cartridges and the Nimiq feed catalogs (default: $NIMIQ_RPC_URL or http://127.0.0.1:8648)")
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	adminToken := serveAdminToken
+	if adminToken == "" {
+		adminToken = os.Getenv("CATALOGCTL_ADMIN_TOKEN")
+	}
+	gatewayTokens = apiTokens(serveAPITokens)
+	if len(gatewayTokens) == 0 {
+		for _, token := range strings.Split(os.Getenv("CATALOGCTL_API_TOKENS"), ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				gatewayTokens = append(gatewayTokens, token)
+			}
+		}
+	}
+	if serveUploads && len(gatewayTokens) == 0 && !serveInsecureUploads {
+		return fmt.Errorf("--uploads needs --api-token (or CATALOGCTL_API_TOKENS); pass --insecure-uploads to allow anonymous uploads")
+	}
+	if serveMaxBodyMB < 1 {
+		return fmt.Errorf("--max-body-mb must be at least 1")
+	}
+	if serveCacheTTL < 0 {
+		return fmt.Errorf("--cache-ttl must not be negative")
+	}
+	if serveReadTimeout <= 0 || serveWriteTimeout <= 0 {
+		return fmt.Errorf("--read-timeout and --write-timeout must be positive")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, healthResponse{Status: "ok", Network: cfg.SuiNetwork})
+	})
+	ops := []apiOperation{
+		{Method: http.MethodGet, Path: "/healthz", Summary: "Health check", Response: healthResponse{}},
+	}
+	gateway := newGatewayServer(serveCacheTTL, serveOrigins)
+	gateway.mount(mux)
+	ops = append(ops, gateway.operations()...)
+	feed, err := newReleaseFeed(gateway, serveFeedCatalogs, serveFeedNimiq, defaultNimiqRPCURL(serveNimiqRPCURL), serveFeedLimit)
+	if err != nil {
+		return err
+	}
+	if feed != nil {
+		feed.mount(mux)
+		ops = append(ops, feed.operations()...)
+	}
+	byHash := &byHashServer{nimiqRPCURL: defaultNimiqRPCURL(serveNimiqRPCURL)}
+	byHash.mount(mux)
+	ops = append(ops, byHash.operations()...)
+	if serveUploads {
+		uploads := &uploadProxy{allowedOrigins: serveOrigins}
+		uploads.mount(mux)
+		ops = append(ops, uploads.operations()...)
+	}
+	if adminToken != "" {
+		admin := newAdminServer(adminToken)
+		admin.mount(mux)
+		ops = append(ops, admin.operations()...)
+	}
+	serveOpenAPI(mux, ops)
+
+	var handler http.Handler = limitBody(serveMaxBodyMB<<20, mux)
+	if serveRateLimit > 0 {
+		handler = newRateLimiter(serveRateLimit, serveRateBurst, serveTrustProxy).middleware(handler)
+	}
+	switch serveAccessLog {
+	case "":
+	case "-":
+		handler = accessLog(stdout, serveTrustProxy, handler)
+	default:
+		logFile, err := logging.OpenRotating(serveAccessLog, serveAccessLogMB<<20, serveAccessLogKeep)
+		if err != nil {
+			return err
+		}
+		handler = accessLog(logFile, serveTrustProxy, handler)
+	}
+
+	addr := net.JoinHostPort(serveBind, strconv.Itoa(servePort))
+	fmt.Fprintf(stdout, "Serving %s on http://%s\n", cfg.SuiNetwork, addr)
+	fmt.Fprintf(stdout, "  OpenAPI document: http://%s/openapi.json\n", addr)
+	fmt.Fprintf(stdout, "  Catalog gateway: http://%s/catalogs/{id}, /cartridges/{id}, /blobs/{id} (cache %s)\n", addr, serveCacheTTL)
+	if feed != nil {
+		fmt.Fprintf(stdout, "  Release feeds: http://%s/feed.rss, /feed.json (%d catalogs)\n", addr, feed.catalogCount())
+	}
+	fmt.Fprintf(stdout, "  Games by hash: http://%s/by-hash/{sha256} (registry %s)\n", addr, gamesRegistryPath())
+	if serveUploads {
+		fmt.Fprintf(stdout, "  Upload proxy: http://%s/api/upload (publisher %s)\n", addr, cfg.WalrusPublisherURL)
+	}
+	if adminToken != "" {
+		fmt.Fprintf(stdout, "  Admin console: http://%s/admin\n", addr)
+	} else {
+		statusln("💡 Set --admin-token or CATALOGCTL_ADMIN_TOKEN to enable the /admin console")
+	}
+
+	if serveRateLimit > 0 {
+		fmt.Fprintf(stdout, "  Rate limit: %g req/s per IP (burst %d)\n", serveRateLimit, serveRateBurst)
+	}
+	if len(gatewayTokens) == 0 && serveBind != "127.0.0.1" && serveBind != "localhost" {
+		statusln("⚠️  No --api-token set: write endpoints are open to anyone who can reach the server")
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: serveReadHeaderTimeout,
+		ReadTimeout:       serveReadTimeout,
+		WriteTimeout:      serveWriteTimeout,
+		IdleTimeout:       serveIdleTimeout,
+		MaxHeaderBytes:    serveMaxHeaderBytes,
+	}
+
+	// Graceful shutdown: stop accepting and let running requests finish
+	shutdown := make(chan error, 1)
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		statusln("Shutting down...")
+		ctx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+		defer cancel()
+		shutdown <- server.Shutdown(ctx)
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve on %s: %w", addr, err)
+	}
+	if err := <-shutdown; err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+	return nil
+}
+
+// requestCatalogID resolves a catalog ID or alias from a request, defaulting
+// to catalog_id
+func requestCatalogID(value string) (string, error) {
+	if value == "" {
+		value = cfg.CatalogID
+	}
+	if value == "" {
+		return "", fmt.Errorf("catalog is required (no catalog_id configured)")
+	}
+	return cfg.ResolveCatalogID(value)
+}
+
+// healthResponse is returned by GET /healthz
+type healthResponse struct {
+	Status  string `json:"status"`
+	Network string `json:"network"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/retro-crypto/sui/internal/agent"
+	"github.com/retro-crypto/sui/internal/approval"
+	"github.com/retro-crypto/sui/internal/sui"
+)
+
+// ============================================================================
+// Native transaction signing
+// ============================================================================
+
+// useSuiCLI sends transactions with the sui CLI instead of signing them here
+var useSuiCLI bool
+
+// nativeKey is the signer resolved on first use
+var nativeKey sui.TxSigner
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&useSuiCLI, "use-cli", false, "Sign and send transactions with the sui CLI instead of natively (needs the sui binary)")
+}
+
+// nativeSigner returns what transactions are signed with: the signing agent
+// at $CATALOGCTL_AGENT_SOCK if set, so no key is loaded here, otherwise the
+// local key (see localSigner)
+func nativeSigner() (sui.TxSigner, error) {
+	if nativeKey != nil {
+		return nativeKey, nil
+	}
+	if socket := os.Getenv(agent.SocketEnv); socket != "" {
+		s, err := agent.NewClient(socket).Signer(os.Getenv(agentAddressEnv))
+		if err != nil {
+			return nil, err
+		}
+		nativeKey = s
+		return nativeKey, nil
+	}
+	s, err := localSigner()
+	if err != nil {
+		return nil, err
+	}
+	nativeKey = s
+	return nativeKey, nil
+}
+
+// localSigner loads the signing key: private_key, then mnemonic (both from
+// key_source if set), then the sui CLI keystore's active address
+func localSigner() (*sui.Signer, error) {
+	privateKey, mnemonic, err := configuredKey()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case privateKey != "":
+		key, err := approval.KeyFromHex(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private_key: %w", err)
+		}
+		return sui.NewSigner(key), nil
+	case mnemonic != "":
+		s, err := sui.SignerFromMnemonic(mnemonic, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid mnemonic: %w", err)
+		}
+		return s, nil
+	default:
+		s, err := sui.SignerFromKeystore(sui.CLIConfigDir())
+		if err != nil {
+			return nil, fmt.Errorf("no signing key: set private_key or mnemonic in the config, or use a sui CLI keystore (%v); --use-cli sends through the sui binary", err)
+		}
+		return s, nil
+	}
+}
+
+// executeMoveCall sends a Move call and returns the JSON the sui CLI prints
+// with --json. It is signed natively with nativeSigner, or sent through the
+// sui CLI with --use-cli; the memory chain takes the CLI arguments.
+func executeMoveCall(call sui.MoveCall) (string, error) {
+	if memoryChain != nil || useSuiCLI {
+		return executeSuiCommand(call.CLIArgs())
+	}
+	return executeNativeTx(func(client *sui.Client, signer string) (string, error) {
+		return client.BuildMoveCall(signer, call)
+	})
+}
+
+// executeTransfer transfers an object the same way executeMoveCall sends
+// calls
+func executeTransfer(objectID, recipient string) (string, error) {
+	if memoryChain != nil || useSuiCLI {
+		return executeSuiCommand([]string{
+			"client", "transfer",
+			"--object-id", objectID,
+			"--to", recipient,
+			"--gas-budget", strconv.Itoa(sui.DefaultGasBudget),
+			"--json",
+		})
+	}
+	return executeNativeTx(func(client *sui.Client, signer string) (string, error) {
+		return client.TransferObject(signer, objectID, recipient, sui.DefaultGasBudget)
+	})
+}
+
+// executeNativeTx builds a transaction with build, signs it with
+// nativeSigner and executes it over JSON-RPC, with the approval check,
+// conflict retries and cache invalidation of executeSuiCommand
+func executeNativeTx(build func(client *sui.Client, signer string) (string, error)) (output string, err error) {
+	if err := requireApproval(); err != nil {
+		return "", err
+	}
+	signer, err := nativeSigner()
+	if err != nil {
+		return "", err
+	}
+	defer func() { invalidateObjectCache(output, err) }()
+	client := sui.NewClient(cfg.SuiRPCURL)
+	return retryOnConflict(func() (string, error) {
+		txBytes, err := build(client, signer.SuiAddress())
+		if err != nil {
+			return "", fmt.Errorf("failed to build transaction: %w", err)
+		}
+		signature, err := signer.SignTransaction(txBytes)
+		if err != nil {
+			return "", err
+		}
+		result, err := client.ExecuteTransactionBlock(txBytes, []string{signature})
+		if err != nil {
+			return "", err
+		}
+		return string(result), nil
+	})
+}
+
+// executeSuiNative answers 'sui client active-address' with nativeSigner.
+// ok is false for other commands, which run the sui binary.
+func executeSuiNative(args []string) (output string, ok bool, err error) {
+	if len(args) != 2 || args[0] != "client" || args[1] != "active-address" {
+		return "", false, nil
+	}
+	signer, err := nativeSigner()
+	if err != nil {
+		return "", true, err
+	}
+	return signer.SuiAddress(), true, nil
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/delta"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/search"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/torrent"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// export-site command
+// ============================================================================
+
+var exportSiteCmd = &cobra.Command{
+	Use:   "export-site",
+	Short: "Export a catalog as static files for hosting",
+	Long: `Writes a catalog with everything a static site or player needs to list and
+download its games: catalog.json with every entry, its cartridge, blob IDs,
+SHA256 hashes and the Walrus aggregator URLs serving each blob, and
+search.json, a full-text index of the entries' titles, keys and tags for
+client-side search (the format 'catalogctl search --index' reads).
+
+With --torrents, BitTorrent v2 metadata is generated for every blob (game
+files, patches and assets) with the aggregator URLs as webseeds, written to
+torrents/<blob_id>.torrent and listed in catalog.json with a magnet link.
+Players can fetch the files over BitTorrent and still verify them against
+the SHA256 recorded on chain. Generating a torrent downloads the blob once;
+torrents from an earlier export in the same directory are reused.
+
+Example:
+  catalogctl export-site --out site --torrents`,
+	RunE: runExportSite,
+}
+
+var (
+	exportSiteOut       string
+	exportSiteCatalogID string
+	exportSiteChannel   string
+	exportSiteTorrents  bool
+)
+
+func init() {
+	exportSiteCmd.Flags().StringVar(&exportSiteOut, "out", "", "Output directory (required)")
+	exportSiteCmd.Flags().StringVar(&exportSiteCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(exportSiteCmd.Flags(), "catalog", formatCatalogRef)
+	exportSiteCmd.Flags().StringVar(&exportSiteChannel, "channel", model.ChannelStable, "Release channel to export: stable, beta or all")
+	exportSiteCmd.Flags().BoolVar(&exportSiteTorrents, "torrents", false, "Generate BitTorrent v2 metadata with Walrus webseeds for every blob")
+	exportSiteCmd.MarkFlagRequired("out")
+	rootCmd.AddCommand(exportSiteCmd)
+	reportsResult(exportSiteCmd)
+}
+
+// siteCatalog is catalog.json of an exported site
+type siteCatalog struct {
+	CatalogID   string      `json:"catalog_id"`
+	Network     string      `json:"network"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Entries     []siteEntry `json:"entries"`
+}
+
+// siteEntry is a catalog entry with the blobs needed to download it
+type siteEntry struct {
+	model.CatalogEntry
+	// SHA256 of the complete game file
+	SHA256 string `json:"sha256"`
+	// Blob holds the game file, or the patch of a delta update
+	Blob   siteBlob            `json:"blob"`
+	Delta  *model.Delta        `json:"delta,omitempty"`
+	Assets map[string]siteBlob `json:"assets,omitempty"`
+}
+
+// siteBlob is one Walrus blob and where to get it
+type siteBlob struct {
+	BlobID    string       `json:"blob_id"`
+	SHA256    string       `json:"sha256"`
+	SizeBytes uint64       `json:"size_bytes"`
+	URLs      []string     `json:"urls"`
+	Torrent   *siteTorrent `json:"torrent,omitempty"`
+}
+
+// siteTorrent points to the BitTorrent metadata of a blob
+type siteTorrent struct {
+	File     string `json:"file"`
+	InfoHash string `json:"info_hash"`
+	Magnet   string `json:"magnet"`
+}
+
+func runExportSite(cmd *cobra.Command, args []string) error {
+	channel := exportSiteChannel
+	if channel != "all" {
+		var err error
+		if channel, err = model.ParseChannel(channel); err != nil {
+			return err
+		}
+	}
+
+	catalogID := exportSiteCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	catalogResp, err := client.GetObject(catalogID)
+	if err != nil {
+		return fmt.Errorf("failed to get catalog: %w", err)
+	}
+	if catalogResp.Data == nil {
+		return fmt.Errorf("catalog not found")
+	}
+	fields := sui.ParseCatalog(catalogResp.Data)
+
+	site := siteCatalog{CatalogID: catalogID, Network: cfg.SuiNetwork, Entries: []siteEntry{}}
+	site.Name, _ = fields["name"].(string)
+	site.Description, _ = fields["description"].(string)
+
+	if err := os.MkdirAll(exportSiteOut, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if exportSiteTorrents {
+		if err := os.MkdirAll(filepath.Join(exportSiteOut, "torrents"), 0755); err != nil {
+			return fmt.Errorf("failed to create torrents directory: %w", err)
+		}
+	}
+	previous := previousSiteTorrents(filepath.Join(exportSiteOut, "catalog.json"))
+
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if channel != "all" && entry.Channel != channel {
+			continue
+		}
+		statusf("Exporting %s...\n", entry.Slug)
+		se, err := exportSiteEntry(client, entry.CatalogEntry, previous)
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", entry.Slug, err)
+		}
+		site.Entries = append(site.Entries, *se)
+	}
+
+	data, err := json.MarshalIndent(site, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal catalog: %w", err)
+	}
+	catalogPath := filepath.Join(exportSiteOut, "catalog.json")
+	if err := os.WriteFile(catalogPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+
+	// Players search the export without a server
+	indexed := make([]model.CatalogEntry, len(site.Entries))
+	for i, entry := range site.Entries {
+		indexed[i] = entry.CatalogEntry
+	}
+	index, err := json.Marshal(search.Build(entrySearchDocuments(catalogID, indexed)))
+	if err != nil {
+		return fmt.Errorf("failed to marshal search index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(exportSiteOut, searchIndexName), append(index, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+
+	slugs := make([]string, len(site.Entries))
+	for i, entry := range site.Entries {
+		slugs[i] = entry.Slug
+	}
+	setResult(map[string]interface{}{
+		"catalog_id":   catalogID,
+		"dir":          exportSiteOut,
+		"catalog_file": catalogPath,
+		"entries":      slugs,
+		"torrents":     exportSiteTorrents,
+	})
+	statusf("\n✓ Exported %d entries to %s\n", len(site.Entries), catalogPath)
+	if exportSiteTorrents {
+		fmt.Fprintf(stdout, "  Torrents: %s\n", filepath.Join(exportSiteOut, "torrents"))
+	}
+	return nil
+}
+
+// exportSiteEntry reads the cartridge, delta and assets of an entry
+func exportSiteEntry(client *sui.Client, entry model.CatalogEntry, previous map[string]*siteTorrent) (*siteEntry, error) {
+	resp, err := client.GetObject(entry.CartridgeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cartridge: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("cartridge %s not found", entry.CartridgeID)
+	}
+	fields := sui.ParseCatalog(resp.Data)
+
+	se := &siteEntry{CatalogEntry: entry, SHA256: sui.BytesArrayToHex(fields["sha256"])}
+	if se.Delta, err = fetchCartridgeDelta(client, entry.CartridgeID); err != nil {
+		return nil, err
+	}
+
+	slug, _ := model.SplitChannelKey(entry.Slug)
+	name := fmt.Sprintf("%s-v%d.zip", slug, entry.Version)
+	blobSHA, blobSize := se.SHA256, parseU64(fields["size_bytes"])
+	if se.Delta != nil {
+		name = fmt.Sprintf("%s-v%d.%s", slug, entry.Version, delta.Format)
+		blobSHA, blobSize = se.Delta.PatchSHA256, se.Delta.PatchSizeBytes
+	}
+	blob, err := exportSiteBlob(sui.BytesArrayToHex(fields["blob_id"]), blobSHA, blobSize, name, entry.CartridgeID, previous)
+	if err != nil {
+		return nil, err
+	}
+	se.Blob = *blob
+
+	assets, err := fetchCartridgeAssets(client, entry.CartridgeID)
+	if err != nil {
+		return nil, err
+	}
+	for _, asset := range assets {
+		if se.Assets == nil {
+			se.Assets = make(map[string]siteBlob)
+		}
+		blob, err := exportSiteBlob(asset.BlobID, asset.SHA256, asset.SizeBytes, slug+"-"+asset.Name, entry.CartridgeID, previous)
+		if err != nil {
+			return nil, fmt.Errorf("asset %s: %w", asset.Name, err)
+		}
+		se.Assets[asset.Name] = *blob
+	}
+	return se, nil
+}
+
+// exportSiteBlob describes a blob (hex ID from chain) with its aggregator
+// URLs and, with --torrents, its BitTorrent metadata
+func exportSiteBlob(blobIDHex, sha256Hex string, size uint64, name, cartridgeID string, previous map[string]*siteTorrent) (*siteBlob, error) {
+	blobIDBytes, err := hex.DecodeString(blobIDHex)
+	if err != nil || len(blobIDBytes) == 0 {
+		return nil, fmt.Errorf("invalid blob ID %q", blobIDHex)
+	}
+	blobID := base58.Encode(blobIDBytes)
+
+	blob := &siteBlob{BlobID: blobID, SHA256: sha256Hex, SizeBytes: size}
+	for _, aggregator := range cfg.WalrusAggregatorEndpoints() {
+		blob.URLs = append(blob.URLs, strings.TrimSuffix(aggregator, "/")+"/v1/blobs/"+blobID)
+	}
+	if !exportSiteTorrents {
+		return blob, nil
+	}
+
+	torrentFile := filepath.Join("torrents", blobID+".torrent")
+	if t := previous[blobID]; t != nil && t.File == torrentFile {
+		if _, err := os.Stat(filepath.Join(exportSiteOut, torrentFile)); err == nil {
+			blob.Torrent = t
+			return blob, nil
+		}
+	}
+
+	statusf("  Building torrent for blob %s...\n", blobID)
+	data, err := readBlob(blobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download blob %s: %w", blobID, err)
+	}
+	hash := sha256.Sum256(data)
+	if uint64(len(data)) != size || hex.EncodeToString(hash[:]) != sha256Hex {
+		return nil, fmt.Errorf("blob %s doesn't match the SHA256 recorded on chain", blobID)
+	}
+
+	comment := fmt.Sprintf("Walrus blob %s of Sui cartridge %s (%s), SHA256 %s", blobID, cartridgeID, cfg.SuiNetwork, sha256Hex)
+	t, err := torrent.New(name, data, blob.URLs, comment)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(exportSiteOut, torrentFile), t.Metainfo, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write torrent: %w", err)
+	}
+	blob.Torrent = &siteTorrent{File: torrentFile, InfoHash: t.InfoHashHex(), Magnet: t.Magnet()}
+	return blob, nil
+}
+
+// previousSiteTorrents maps blob IDs to the torrents of an earlier export
+func previousSiteTorrents(catalogPath string) map[string]*siteTorrent {
+	torrents := make(map[string]*siteTorrent)
+	data, err := os.ReadFile(catalogPath)
+	if err != nil {
+		return torrents
+	}
+	var site siteCatalog
+	if json.Unmarshal(data, &site) != nil {
+		return torrents
+	}
+	for _, entry := range site.Entries {
+		blobs := []siteBlob{entry.Blob}
+		for _, asset := range entry.Assets {
+			blobs = append(blobs, asset)
+		}
+		for _, blob := range blobs {
+			if blob.Torrent != nil {
+				torrents[blob.BlobID] = blob.Torrent
+			}
+		}
+	}
+	return torrents
+}
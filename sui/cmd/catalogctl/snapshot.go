@@ -0,0 +1,541 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/plan"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/retro-crypto/sui/internal/walrus"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// export-catalog / import-catalog commands
+// ============================================================================
+
+var exportCatalogCmd = &cobra.Command{
+	Use:   "export-catalog",
+	Short: "Write a catalog's entries and cartridge metadata to a JSON snapshot",
+	Long: `Reads every entry of a catalog together with its cartridge (blob ID,
+SHA256, size, publisher, delta base) and writes them to a JSON snapshot that
+import-catalog can recreate in another catalog, on this network or another.
+
+Example:
+  catalogctl export-catalog --catalog testnet-main --out-file catalog.json`,
+	RunE: runExportCatalog,
+}
+
+var importCatalogCmd = &cobra.Command{
+	Use:   "import-catalog",
+	Short: "Recreate the missing entries of a catalog snapshot in another catalog",
+	Long: `Compares a snapshot written by export-catalog with a catalog and shows
+what importing it would do; --apply does it.
+
+Entries the catalog doesn't have are added. Cartridges that exist on this
+network with the same file are reused; otherwise the game (and its cover) is
+downloaded from Walrus (the snapshot's aggregator when it comes from another
+network), checked against its SHA256
+and published like publish-game. Entries the catalog already has are never
+changed, even when they hold another file.
+
+Delta updates can only be reused, not copied: publish their full file with
+publish-game. Copies are resumable: rerun with the same --work-dir.
+
+Example:
+  catalogctl --network testnet export-catalog --out-file catalog.json
+  catalogctl --network mainnet import-catalog --input catalog.json --catalog main
+  catalogctl --network mainnet import-catalog --input catalog.json --catalog main --apply`,
+	RunE: runImportCatalog,
+}
+
+var (
+	exportCatalogID     string
+	exportCatalogOutput string
+
+	importCatalogInput      string
+	importCatalogID         string
+	importCatalogCapID      string
+	importCatalogApply      bool
+	importCatalogEpochs     int
+	importCatalogAggregator string
+	importCatalogWorkDir    string
+)
+
+func init() {
+	exportCatalogCmd.Flags().StringVar(&exportCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(exportCatalogCmd.Flags(), "catalog", formatCatalogRef)
+	outFileFlag(exportCatalogCmd, &exportCatalogOutput, "Snapshot file to write, - for stdout (required)")
+	rootCmd.AddCommand(exportCatalogCmd)
+	reportsResult(exportCatalogCmd)
+
+	importCatalogCmd.Flags().StringVar(&importCatalogInput, "input", "", "Snapshot file written by export-catalog (required)")
+	importCatalogCmd.Flags().StringVar(&importCatalogID, "catalog", "", "Catalog object ID or alias to import into (optional, uses config.catalog_id if not set)")
+	validateAs(importCatalogCmd.Flags(), "catalog", formatCatalogRef)
+	importCatalogCmd.Flags().StringVar(&importCatalogCapID, "cap", "", "CuratorCap object ID (auto-selected if the signer isn't the catalog owner)")
+	importCatalogCmd.Flags().BoolVar(&importCatalogApply, "apply", false, "Add the missing entries (default: only show the diff)")
+	importCatalogCmd.Flags().IntVar(&importCatalogEpochs, "epochs", 5, "Number of storage epochs for Walrus when copying games")
+	importCatalogCmd.Flags().StringVar(&importCatalogAggregator, "source-aggregator", "", "Walrus aggregator to download copied games from (default: the configured one, or the snapshot's if it is from another network)")
+	importCatalogCmd.Flags().StringVar(&importCatalogWorkDir, "work-dir", "", "Directory for downloaded games and journals (default: a temporary directory)")
+	importCatalogCmd.MarkFlagRequired("input")
+	rootCmd.AddCommand(importCatalogCmd)
+	reportsResult(importCatalogCmd)
+}
+
+// snapshotFormat and snapshotVersion identify export-catalog files
+const (
+	snapshotFormat  = "catalogctl-catalog"
+	snapshotVersion = 1
+)
+
+// catalogSnapshot is the file written by export-catalog
+type catalogSnapshot struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	ExportedAt string `json:"exported_at"`
+	// Network and WalrusAggregatorURL are where the catalog was read from
+	Network             string          `json:"network"`
+	WalrusAggregatorURL string          `json:"walrus_aggregator_url"`
+	Catalog             snapshotCatalog `json:"catalog"`
+	Entries             []snapshotEntry `json:"entries"`
+}
+
+type snapshotCatalog struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Owner       string `json:"owner"`
+}
+
+// snapshotEntry is a catalog entry with its cartridge ("cartridge" is
+// missing when the cartridge object couldn't be read)
+type snapshotEntry struct {
+	catalogEntry
+	// Delta is set when the cartridge is a patch against another cartridge
+	Delta *model.Delta `json:"delta,omitempty"`
+}
+
+func runExportCatalog(cmd *cobra.Command, args []string) error {
+	if exportCatalogOutput == "" {
+		return fmt.Errorf("--out-file is required")
+	}
+	catalogID := exportCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	catalogResp, err := client.GetObject(catalogID)
+	if err != nil {
+		return fmt.Errorf("failed to get catalog: %w", err)
+	}
+	if catalogResp.Data == nil {
+		return fmt.Errorf("catalog not found")
+	}
+	fields := sui.ParseCatalog(catalogResp.Data)
+
+	snap := catalogSnapshot{
+		Format:              snapshotFormat,
+		Version:             snapshotVersion,
+		ExportedAt:          time.Now().UTC().Format(time.RFC3339),
+		Network:             cfg.SuiNetwork,
+		WalrusAggregatorURL: cfg.WalrusAggregatorURL,
+		Catalog:             snapshotCatalog{ID: catalogID},
+	}
+	snap.Catalog.Name, _ = fields["name"].(string)
+	snap.Catalog.Description, _ = fields["description"].(string)
+	snap.Catalog.Owner, _ = fields["owner"].(string)
+
+	statusf("Reading catalog %s...\n", catalogID)
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		return err
+	}
+	if err := hydrateCartridges(client, entries); err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Slug < entries[j].Slug })
+
+	snap.Entries = make([]snapshotEntry, len(entries))
+	for i, entry := range entries {
+		snap.Entries[i].catalogEntry = entry
+		if entry.Cartridge == nil {
+			warnf("cartridge %s of %s not found; exported without cartridge metadata", entry.CartridgeID, entry.Slug)
+			continue
+		}
+		d, err := fetchCartridgeDelta(client, entry.CartridgeID)
+		if err != nil {
+			return err
+		}
+		snap.Entries[i].Delta = d
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if exportCatalogOutput == "-" {
+		stdout.Write(data)
+	} else if err := os.WriteFile(exportCatalogOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	setResult(map[string]interface{}{
+		"catalog_id": catalogID,
+		"network":    cfg.SuiNetwork,
+		"entries":    len(snap.Entries),
+		"output":     exportCatalogOutput,
+	})
+	if exportCatalogOutput != "-" {
+		statusf("✓ Exported %d entries of %q to %s\n", len(snap.Entries), snap.Catalog.Name, exportCatalogOutput)
+	}
+	return nil
+}
+
+// loadCatalogSnapshot reads and checks an export-catalog file
+func loadCatalogSnapshot(path string) (*catalogSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snap catalogSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	if snap.Format != snapshotFormat {
+		return nil, fmt.Errorf("%s is not a catalog snapshot (format %q)", path, snap.Format)
+	}
+	if snap.Version > snapshotVersion {
+		return nil, fmt.Errorf("snapshot %s has version %d; this catalogctl reads up to %d", path, snap.Version, snapshotVersion)
+	}
+	return &snap, nil
+}
+
+// Import actions, as shown in the diff
+const (
+	importLink    = "link"    // add an entry for a cartridge on this network
+	importCopy    = "copy"    // publish the game again, then add the entry
+	importPresent = "present" // the catalog has the entry with the same file
+	importDiffers = "differs" // the catalog has the entry with another file
+	importSkip    = "skip"    // the entry can't be imported
+)
+
+// importItem is what import-catalog does with one snapshot entry
+type importItem struct {
+	Key    string `json:"key"`
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+	// CartridgeID is the cartridge the entry gets (set once added)
+	CartridgeID string `json:"cartridge_id,omitempty"`
+	Digest      string `json:"digest,omitempty"`
+
+	entry *snapshotEntry
+}
+
+func runImportCatalog(cmd *cobra.Command, args []string) error {
+	snap, err := loadCatalogSnapshot(importCatalogInput)
+	if err != nil {
+		return err
+	}
+
+	catalogID := importCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	if catalogID, err = cfg.ResolveCatalogID(catalogID); err != nil {
+		return err
+	}
+	if err := validate.ObjectID(catalogID); err != nil {
+		return fmt.Errorf("invalid catalog ID %s: %w", catalogID, err)
+	}
+	if catalogID == snap.Catalog.ID && snap.Network == cfg.SuiNetwork {
+		return fmt.Errorf("the snapshot was exported from catalog %s itself", catalogID)
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	existing, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		return err
+	}
+	if err := hydrateCartridges(client, existing); err != nil {
+		return err
+	}
+	byKey := make(map[string]catalogEntry, len(existing))
+	for _, entry := range existing {
+		byKey[entry.Slug] = entry
+	}
+
+	items := make([]importItem, len(snap.Entries))
+	for i := range snap.Entries {
+		items[i] = planImport(client, &snap.Entries[i], byKey)
+	}
+
+	fmt.Fprintf(stdout, "Snapshot of %q (%s on %s, exported %s)\n", snap.Catalog.Name, snap.Catalog.ID, snap.Network, snap.ExportedAt)
+	fmt.Fprintf(stdout, "  → catalog %s on %s\n\n", catalogID, cfg.SuiNetwork)
+	counts := make(map[string]int)
+	for _, item := range items {
+		counts[item.Action]++
+		fmt.Fprintf(stdout, "  %s %-24s %s\n", importMarks[item.Action], truncate(item.Key, 24), importDescription(item))
+	}
+	fmt.Fprintf(stdout, "\n%d to add (%d reused, %d copied), %d present, %d differ, %d skipped\n",
+		counts[importLink]+counts[importCopy], counts[importLink], counts[importCopy],
+		counts[importPresent], counts[importDiffers], counts[importSkip])
+
+	result := map[string]interface{}{
+		"catalog_id": catalogID,
+		"network":    cfg.SuiNetwork,
+		"applied":    importCatalogApply,
+		"entries":    items,
+	}
+	setResult(result)
+
+	toAdd := counts[importLink] + counts[importCopy]
+	if toAdd == 0 {
+		statusln("✓ Nothing to import")
+		return nil
+	}
+	if !importCatalogApply {
+		statusf("\nDry run: rerun with --apply to add %d entries\n", toAdd)
+		return nil
+	}
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+	if counts[importCopy] > 0 && cfg.ApprovalRequired() {
+		return fmt.Errorf("import-catalog can't copy games while mainnet approvals are required; publish them with publish-game")
+	}
+
+	capID, err := resolveCuratorCap(catalogID, importCatalogCapID)
+	if err != nil {
+		return err
+	}
+	workDir := importCatalogWorkDir
+	if counts[importCopy] > 0 {
+		if workDir == "" {
+			if workDir, err = os.MkdirTemp("", "import-catalog-"); err != nil {
+				return fmt.Errorf("failed to create work directory: %w", err)
+			}
+			defer os.RemoveAll(workDir)
+		} else if err := os.MkdirAll(workDir, 0755); err != nil {
+			return fmt.Errorf("failed to create work directory: %w", err)
+		}
+	}
+
+	fmt.Fprintln(stdout)
+	added := 0
+	for i := range items {
+		item := &items[i]
+		switch item.Action {
+		case importLink:
+			err = importByLink(catalogID, capID, item)
+		case importCopy:
+			err = importByCopy(snap, catalogID, capID, workDir, item)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import %s (%d of %d entries added): %w", item.Key, added, toAdd, err)
+		}
+		added++
+		statusf("✓ [%d/%d] %s (cartridge %s)\n", added, toAdd, item.Key, item.CartridgeID)
+	}
+
+	statusf("\n✓ Imported %d entries into catalog %s\n", added, catalogID)
+	newGHSummary(fmt.Sprintf("Imported %d entries into catalog", added)).
+		row("Catalog", mdLink(catalogID, explorerURL(config.LinkObject, catalogID))).
+		row("Snapshot", fmt.Sprintf("%s (%s)", snap.Catalog.Name, snap.Network)).
+		row("Reused cartridges", fmt.Sprintf("%d", counts[importLink])).
+		row("Copied games", fmt.Sprintf("%d", counts[importCopy])).
+		output("imported", fmt.Sprintf("%d", added)).
+		write()
+	return nil
+}
+
+// importMarks prefix the lines of the diff
+var importMarks = map[string]string{
+	importLink:    "+",
+	importCopy:    "+",
+	importPresent: "=",
+	importDiffers: "~",
+	importSkip:    "!",
+}
+
+// importDescription says what happens to an entry in the diff
+func importDescription(item importItem) string {
+	e := item.entry
+	switch item.Action {
+	case importLink:
+		return fmt.Sprintf("add %q v%d, reusing cartridge %s", e.Title, e.Version, e.CartridgeID)
+	case importCopy:
+		return fmt.Sprintf("add %q v%d, copying %d bytes to a new cartridge", e.Title, e.Version, e.Cartridge.SizeBytes)
+	case importPresent:
+		return "already in the catalog"
+	}
+	return item.Reason
+}
+
+// planImport decides how to import an entry into a catalog that has the
+// entries byKey
+func planImport(client *sui.Client, e *snapshotEntry, byKey map[string]catalogEntry) importItem {
+	item := importItem{Key: e.Slug, entry: e}
+	if have, ok := byKey[e.Slug]; ok {
+		switch {
+		case have.CartridgeID == e.CartridgeID,
+			have.Cartridge != nil && e.Cartridge != nil && have.Cartridge.SHA256 == e.Cartridge.SHA256:
+			item.Action = importPresent
+		default:
+			item.Action = importDiffers
+			item.Reason = fmt.Sprintf("the catalog holds another file (cartridge %s); left unchanged", have.CartridgeID)
+		}
+		return item
+	}
+	if e.KeyType != "string" {
+		item.Action, item.Reason = importSkip, fmt.Sprintf("%s keys can't be added with add_entry", e.KeyType)
+		return item
+	}
+	if e.Cartridge == nil {
+		item.Action, item.Reason = importSkip, "the snapshot has no cartridge metadata"
+		return item
+	}
+
+	// A cartridge with the same file on this network is reused
+	if f, err := fetchCartridgeFile(client, e.CartridgeID); err == nil && f.SHA256Hex == e.Cartridge.SHA256 {
+		item.Action = importLink
+		return item
+	}
+	if e.Delta != nil {
+		item.Action, item.Reason = importSkip, fmt.Sprintf("delta update of cartridge %s; publish its full file with publish-game", e.Delta.BaseCartridgeID)
+		return item
+	}
+	item.Action = importCopy
+	return item
+}
+
+// importByLink adds an entry for a cartridge that exists on this network
+func importByLink(catalogID, capID string, item *importItem) error {
+	e := item.entry
+	cover := ""
+	if e.CoverBlobID != "" {
+		cover = "0x" + strings.TrimPrefix(e.CoverBlobID, "0x")
+	}
+	output, err := callAddEntry(catalogID, capID, sui.EntryArgs{
+		Key:          e.Slug,
+		CartridgeID:  e.CartridgeID,
+		Title:        e.Title,
+		Platform:     uint8(e.Platform),
+		SizeBytes:    e.SizeBytes,
+		EmulatorCore: e.EmulatorCore,
+		Version:      e.Version,
+		CoverBlobID:  cover,
+	})
+	if err != nil {
+		return err
+	}
+	item.CartridgeID = e.CartridgeID
+	item.Digest = sui.TransactionDigest(output)
+	return nil
+}
+
+// importByCopy downloads the game (and cover) of an entry from the
+// snapshot's aggregator and publishes it like publish-game
+func importByCopy(snap *catalogSnapshot, catalogID, capID, workDir string, item *importItem) error {
+	e := item.entry
+	slug, channel := model.SplitChannelKey(e.Slug)
+	name := strings.NewReplacer("/", "_", "@", "_").Replace(e.Slug)
+
+	file := filepath.Join(workDir, name+".bin")
+	if err := downloadSnapshotBlob(snap, walrusBlobID(e.Cartridge.BlobID), e.Cartridge.SHA256, file); err != nil {
+		return err
+	}
+	params := publishGameParams{
+		FilePath:  file,
+		Size:      int64(e.Cartridge.SizeBytes),
+		SHA256Hex: e.Cartridge.SHA256,
+		Slug:      slug,
+		Title:     e.Title,
+		Platform:  e.Platform,
+		Emulator:  e.EmulatorCore,
+		Version:   e.Version,
+		Epochs:    importCatalogEpochs,
+		CatalogID: catalogID,
+		CapID:     capID,
+		Channel:   channel,
+	}
+	if e.CoverBlobID != "" {
+		cover := filepath.Join(workDir, name+".cover")
+		if err := downloadSnapshotBlob(snap, walrusBlobID(e.CoverBlobID), "", cover); err != nil {
+			return fmt.Errorf("cover: %w", err)
+		}
+		sha256Hex, size, err := fileSHA256(cover)
+		if err != nil {
+			return err
+		}
+		params.Assets = []assetParam{{Name: coverAssetName, FilePath: cover, Size: size, SHA256Hex: sha256Hex}}
+	}
+
+	pl := buildPublishGamePlan(params)
+	journal := filepath.Join(workDir, name+".journal.json")
+	prog, err := plan.LoadProgress(journal, pl)
+	if err != nil {
+		return err
+	}
+	if err := executePlan(pl, prog, journal); err != nil {
+		return err
+	}
+	item.CartridgeID = prog.Outputs["cartridge_id"]
+	return nil
+}
+
+// downloadSnapshotBlob writes a blob to path, checking it against sha256Hex
+// unless that is empty. Blobs of a snapshot from another network are read
+// from its aggregator (or --source-aggregator) directly.
+func downloadSnapshotBlob(snap *catalogSnapshot, blobID, sha256Hex, path string) error {
+	if sha256Hex != "" {
+		if have, _, err := fileSHA256(path); err == nil && have == sha256Hex {
+			return nil
+		}
+	}
+	aggregator := importCatalogAggregator
+	if aggregator == "" && snap.Network != cfg.SuiNetwork {
+		aggregator = snap.WalrusAggregatorURL
+	}
+	statusf("Downloading blob %s...\n", blobID)
+	var data []byte
+	var err error
+	if aggregator == "" {
+		data, err = readBlob(blobID)
+	} else {
+		data, err = walrus.NewClient(aggregator, "").Read(blobID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download blob %s: %w", blobID, err)
+	}
+	if sha256Hex != "" {
+		hash := sha256.Sum256(data)
+		if got := hex.EncodeToString(hash[:]); got != sha256Hex {
+			return fmt.Errorf("blob %s doesn't match the snapshot (SHA256 %s, expected %s)", blobID, got, sha256Hex)
+		}
+	}
+	return os.WriteFile(path, data, 0644)
+}
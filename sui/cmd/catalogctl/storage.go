@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/retro-crypto/sui/internal/storage"
+	"github.com/retro-crypto/sui/internal/walrus"
+)
+
+// ============================================================================
+// Blob storage backend
+// ============================================================================
+
+// storageBackend returns the backend selected by storage_backend. Commands
+// store, read and extend game files through it rather than a storage
+// client, so adding a backend doesn't touch them.
+func storageBackend() (storage.Backend, error) {
+	switch cfg.StorageBackend {
+	case "", "walrus":
+		client := walrus.NewClient(cfg.WalrusAggregatorURL, cfg.WalrusPublisherURL)
+		client.SetPublishers(cfg.WalrusPublisherEndpoints())
+		if err := setPublisherSigner(client); err != nil {
+			return nil, err
+		}
+		return storage.NewWalrus(client, aggregatorMirrors(), cfg.WalrusNetwork, findBlobObject), nil
+	}
+	return nil, storage.UnknownError(cfg.StorageBackend)
+}
+
+// setPublisherSigner signs the client's publisher requests as configured by
+// walrus_publisher_hmac
+func setPublisherSigner(client *walrus.Client) error {
+	signer, err := cfg.PublisherSigner()
+	if err != nil {
+		return fmt.Errorf("invalid walrus_publisher_hmac: %w", err)
+	}
+	if signer != nil {
+		client.SetSigner(signer)
+	}
+	return nil
+}
+
+// readBlob downloads a blob from the configured backend
+func readBlob(blobID string) ([]byte, error) {
+	backend, err := storageBackend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Read(blobID)
+}
+
+// storeFileResumable uploads a file in chunks with upload-blob's flags,
+// keeping the resume manifest next to the file
+func storeFileResumable(backend storage.Backend, path string) (*storage.Stored, *walrus.ChunkManifest, error) {
+	w, ok := backend.(*storage.Walrus)
+	if !ok {
+		return nil, nil, fmt.Errorf("--resumable is only supported by the walrus storage backend")
+	}
+	if uploadChunkSize < 1 {
+		return nil, nil, fmt.Errorf("--chunk-size must be at least 1 MiB")
+	}
+	statePath := path + ".upload.json"
+	return w.StoreFileResumable(path, uploadEpochs, walrus.ResumableOptions{
+		ChunkSize: int64(uploadChunkSize) << 20,
+		Retries:   uploadChunkRetries,
+		StatePath: statePath,
+		Progress: func(done, total int, resumed bool) {
+			if resumed {
+				fmt.Fprintf(stdout, "  Chunk %d/%d already uploaded (%s)\n", done, total, statePath)
+			} else {
+				fmt.Fprintf(stdout, "  Chunk %d/%d uploaded\n", done, total)
+			}
+		},
+	})
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/taxonomy"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// tags commands
+// ============================================================================
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "Manage the genre and feature tags of catalog entries",
+	Long: `Tags describe an entry's genre and features ("platformer", "multiplayer").
+They are kept in the entry's extended metadata on chain, next to the entry,
+and shown by list-catalog, search and the gateway, which can filter by them.
+
+Tags are lowercase letters, digits and '-'; an entry has at most 16. With
+tag_taxonomy set in the config (or --taxonomy), tags add only accepts the
+tags listed in that file:
+
+  {"genre": ["action", "platformer", "rpg"], "feature": ["multiplayer"]}
+
+Setting tags is a catalog write: the owner or a curator cap holder. Packages
+deployed before set_entry_tags need upgrade-package first.
+
+Example:
+  catalogctl tags add --slug doom shooter multiplayer
+  catalogctl tags remove --slug doom multiplayer
+  catalogctl tags list --slug doom
+  catalogctl tags list`,
+}
+
+var tagsAddCmd = &cobra.Command{
+	Use:   "add TAG...",
+	Short: "Add tags to a catalog entry",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runTagsAdd,
+}
+
+var tagsRemoveCmd = &cobra.Command{
+	Use:   "remove TAG...",
+	Short: "Remove tags from a catalog entry",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runTagsRemove,
+}
+
+var tagsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the tags of an entry, or of every entry in a catalog",
+	Long: `With --slug, lists the tags of one entry. Without, lists every tag used in
+the catalog with the number of entries carrying it, and the unused tags of
+the taxonomy.`,
+	Args: cobra.NoArgs,
+	RunE: runTagsList,
+}
+
+var (
+	tagsCatalogID string
+	tagsSlug      string
+	tagsChannel   string
+	tagsCapID     string
+	tagsTaxonomy  string
+)
+
+// maxEntryTags is MAX_TAGS of the catalog Move module
+const maxEntryTags = 16
+
+func init() {
+	tagsCmd.PersistentFlags().StringVar(&tagsCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(tagsCmd.PersistentFlags(), "catalog", formatCatalogRef)
+	tagsCmd.PersistentFlags().StringVar(&tagsChannel, "channel", model.ChannelStable, "Release channel of the entry: stable or beta (all for list without --slug)")
+	tagsCmd.PersistentFlags().StringVar(&tagsTaxonomy, "taxonomy", "", "Tag taxonomy file (default: config.tag_taxonomy)")
+
+	for _, c := range []*cobra.Command{tagsAddCmd, tagsRemoveCmd} {
+		c.Flags().StringVar(&tagsSlug, "slug", "", "Slug of the entry (required)")
+		c.Flags().StringVar(&tagsCapID, "cap", "", "CuratorCap object ID (auto-selected if the signer isn't the catalog owner)")
+		c.MarkFlagRequired("slug")
+	}
+	tagsListCmd.Flags().StringVar(&tagsSlug, "slug", "", "Only list the tags of this entry")
+
+	tagsCmd.AddCommand(tagsAddCmd, tagsRemoveCmd, tagsListCmd)
+	reportsResult(tagsAddCmd, tagsRemoveCmd, tagsListCmd)
+	rootCmd.AddCommand(tagsCmd)
+}
+
+// loadTaxonomy reads --taxonomy or tag_taxonomy; nil if neither is set
+func loadTaxonomy() (*taxonomy.Taxonomy, error) {
+	path := tagsTaxonomy
+	if path == "" {
+		path = cfg.TagTaxonomy
+	}
+	if path == "" {
+		return nil, nil
+	}
+	return taxonomy.Load(path)
+}
+
+// parseTags lowercases and checks tags given on the command line, dropping
+// duplicates
+func parseTags(args []string) ([]string, error) {
+	var tags []string
+	seen := map[string]bool{}
+	for _, arg := range args {
+		for _, tag := range strings.Split(arg, ",") {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag == "" || seen[tag] {
+				continue
+			}
+			if err := validate.Tag(tag); err != nil {
+				return nil, err
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no tags given")
+	}
+	return tags, nil
+}
+
+// tagsCatalog resolves --catalog or catalog_id
+func tagsCatalog() (string, error) {
+	catalogID := tagsCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return "", fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	return cfg.ResolveCatalogID(catalogID)
+}
+
+// findTagsEntry reads the entry --slug and --channel name
+func findTagsEntry(client *sui.Client, catalogID string) (*catalogEntry, error) {
+	channel, err := model.ParseChannel(tagsChannel)
+	if err != nil {
+		return nil, err
+	}
+	key := model.ChannelKey(tagsSlug, channel)
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].Slug == key {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("entry %s not found in catalog %s", key, catalogID)
+}
+
+func runTagsAdd(cmd *cobra.Command, args []string) error {
+	tags, err := parseTags(args)
+	if err != nil {
+		return err
+	}
+	tax, err := loadTaxonomy()
+	if err != nil {
+		return err
+	}
+	if tax != nil {
+		if err := tax.Check(tags); err != nil {
+			return err
+		}
+	}
+	return updateEntryTags(func(current []string) []string {
+		merged := append([]string{}, current...)
+		for _, tag := range tags {
+			if !containsString(merged, tag) {
+				merged = append(merged, tag)
+			}
+		}
+		return merged
+	})
+}
+
+func runTagsRemove(cmd *cobra.Command, args []string) error {
+	tags, err := parseTags(args)
+	if err != nil {
+		return err
+	}
+	return updateEntryTags(func(current []string) []string {
+		for _, tag := range tags {
+			if !containsString(current, tag) {
+				warnf("%s doesn't have the tag %s", tagsSlug, tag)
+			}
+		}
+		kept := []string{}
+		for _, tag := range current {
+			if !containsString(tags, tag) {
+				kept = append(kept, tag)
+			}
+		}
+		return kept
+	})
+}
+
+// updateEntryTags replaces the tags of the entry --slug with what change
+// returns for its current tags, unless they stay the same
+func updateEntryTags(change func(current []string) []string) error {
+	if cfg.PackageID == "" {
+		return fmt.Errorf("package_id is required in config file")
+	}
+	catalogID, err := tagsCatalog()
+	if err != nil {
+		return err
+	}
+	client := sui.NewClient(cfg.SuiRPCURL)
+	entry, err := findTagsEntry(client, catalogID)
+	if err != nil {
+		return err
+	}
+
+	tags := change(entry.Tags)
+	sort.Strings(tags)
+	current := append([]string{}, entry.Tags...)
+	sort.Strings(current)
+	if strings.Join(tags, ",") == strings.Join(current, ",") {
+		statusf("✓ %s already has these tags: %s\n", entry.Slug, formatTags(tags))
+		setResult(map[string]interface{}{"slug": entry.Slug, "tags": tags, "changed": false})
+		return nil
+	}
+	if len(tags) > maxEntryTags {
+		return fmt.Errorf("an entry can have at most %d tags, %s would have %d", maxEntryTags, entry.Slug, len(tags))
+	}
+
+	capID, err := resolveCuratorCap(catalogID, tagsCapID)
+	if err != nil {
+		return err
+	}
+	statusf("Setting the tags of %s in catalog %s: %s...\n", entry.Slug, catalogID, formatTags(tags))
+	digest, err := setEntryTags(catalogID, capID, entry.Slug, tags)
+	if err != nil {
+		return err
+	}
+
+	setResult(map[string]interface{}{"slug": entry.Slug, "tags": tags, "changed": true, "digest": digest})
+	statusf("\n✓ Tags updated!\n")
+	fmt.Fprintf(stdout, "Tags: %s\n", formatTags(tags))
+	fmt.Fprintf(stdout, "Transaction: %s\n", digest)
+	printExplorerLink("  ", config.LinkTx, digest)
+	newGHSummary("Entry tags updated").
+		row("Catalog", mdLink(catalogID, explorerURL(config.LinkObject, catalogID))).
+		row("Entry", entry.Slug).
+		row("Tags", formatTags(tags)).
+		row("Transaction", mdLink(digest, explorerURL(config.LinkTx, digest))).
+		output("digest", digest).
+		write()
+	return nil
+}
+
+// setEntryTags replaces an entry's tags and returns the transaction digest.
+// Owners call set_entry_tags; curators pass their cap to
+// set_entry_tags_with_cap.
+func setEntryTags(catalogID, capID, key string, tags []string) (string, error) {
+	output, err := executeMoveCall(sui.SetEntryTags(cfg.PackageID, catalogID, capID, key, tags))
+	if err != nil {
+		return "", fmt.Errorf("failed to set tags (packages deployed before set_entry_tags need upgrade-package first): %w", err)
+	}
+	return sui.TransactionDigest(output), nil
+}
+
+// tagCount is a tag and the number of entries carrying it
+type tagCount struct {
+	Tag     string `json:"tag"`
+	Group   string `json:"group,omitempty"`
+	Entries int    `json:"entries"`
+}
+
+func runTagsList(cmd *cobra.Command, args []string) error {
+	catalogID, err := tagsCatalog()
+	if err != nil {
+		return err
+	}
+	tax, err := loadTaxonomy()
+	if err != nil {
+		return err
+	}
+	group := func(tag string) string {
+		if tax == nil {
+			return ""
+		}
+		if g, ok := tax.Group(tag); ok {
+			return g
+		}
+		return "(not in taxonomy)"
+	}
+	client := sui.NewClient(cfg.SuiRPCURL)
+
+	if tagsSlug != "" {
+		entry, err := findTagsEntry(client, catalogID)
+		if err != nil {
+			return err
+		}
+		tags := make([]tagCount, 0, len(entry.Tags))
+		for _, tag := range entry.Tags {
+			tags = append(tags, tagCount{Tag: tag, Group: group(tag), Entries: 1})
+		}
+		setResult(map[string]interface{}{"slug": entry.Slug, "tags": tags})
+		if len(tags) == 0 {
+			fmt.Fprintf(stdout, "%s has no tags.\n", entry.Slug)
+			return nil
+		}
+		fmt.Fprintf(stdout, "%-32s %s\n", "TAG", "GROUP")
+		for _, t := range tags {
+			fmt.Fprintf(stdout, "%-32s %s\n", t.Tag, t.Group)
+		}
+		return nil
+	}
+
+	channel := tagsChannel
+	if channel == "all" {
+		channel = ""
+	} else if channel, err = model.ParseChannel(channel); err != nil {
+		return err
+	}
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		return err
+	}
+	counts := map[string]int{}
+	for _, entry := range entries {
+		if channel != "" && entry.Channel != channel {
+			continue
+		}
+		for _, tag := range entry.Tags {
+			counts[tag]++
+		}
+	}
+	if tax != nil {
+		for _, g := range tax.GroupNames() {
+			for _, tag := range tax.Groups[g] {
+				if _, ok := counts[tag]; !ok {
+					counts[tag] = 0
+				}
+			}
+		}
+	}
+	tags := make([]tagCount, 0, len(counts))
+	for tag, n := range counts {
+		tags = append(tags, tagCount{Tag: tag, Group: group(tag), Entries: n})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Group != tags[j].Group {
+			return tags[i].Group < tags[j].Group
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	setResult(map[string]interface{}{"catalog_id": catalogID, "tags": tags})
+
+	if len(tags) == 0 {
+		fmt.Fprintln(stdout, "No entry has tags.")
+		return nil
+	}
+	fmt.Fprintf(stdout, "%-32s %-20s %s\n", "TAG", "GROUP", "ENTRIES")
+	fmt.Fprintln(stdout, "----------------------------------------------------------------")
+	for _, t := range tags {
+		fmt.Fprintf(stdout, "%-32s %-20s %d\n", t.Tag, t.Group, t.Entries)
+	}
+	return nil
+}
+
+// formatTags joins tags for display
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "(none)"
+	}
+	return strings.Join(tags, ", ")
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/httpclient"
+	"github.com/retro-crypto/sui/internal/telemetry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ============================================================================
+// Anonymous usage reporting (telemetry, opt-in)
+// ============================================================================
+
+const (
+	// telemetryEnv set to 0/off/false turns reporting off for a run
+	telemetryEnv = "CATALOGCTL_TELEMETRY"
+	// telemetryEndpointEnv overrides the endpoint
+	telemetryEndpointEnv = "CATALOGCTL_TELEMETRY_ENDPOINT"
+	// telemetryDebugEnv prints each event to stderr as it is sent
+	telemetryDebugEnv = "CATALOGCTL_TELEMETRY_DEBUG"
+	// telemetryTimeout bounds the report at the end of a command
+	telemetryTimeout = 2 * time.Second
+)
+
+func telemetryPath() string {
+	return filepath.Join(config.GetConfigDir(), telemetry.FileName)
+}
+
+// telemetryEndpoint returns where events go: $CATALOGCTL_TELEMETRY_ENDPOINT,
+// the endpoint given to telemetry enable, else the one built in
+func telemetryEndpoint(s *telemetry.Settings) string {
+	if endpoint := os.Getenv(telemetryEndpointEnv); endpoint != "" {
+		return endpoint
+	}
+	if s.Endpoint != "" {
+		return s.Endpoint
+	}
+	return TelemetryEndpoint
+}
+
+// telemetryOff returns why reporting is off for this run despite being
+// enabled, or ""
+func telemetryOff() string {
+	if telemetry.DoNotTrack() {
+		return "DO_NOT_TRACK is set"
+	}
+	switch strings.ToLower(os.Getenv(telemetryEnv)) {
+	case "0", "off", "false", "no":
+		return telemetryEnv + " is off"
+	}
+	return ""
+}
+
+// commandEvent describes a finished run of cmd
+func commandEvent(s *telemetry.Settings, cmd *cobra.Command, duration time.Duration, err error) telemetry.Event {
+	var flags []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		flags = append(flags, f.Name)
+	})
+	return telemetry.NewEvent(s, rootCmd.Name(), Version, cmd.CommandPath(), flags, duration, err)
+}
+
+// reportUsage sends the event of a finished command if the user opted in.
+// It never fails the command: a failed send is only reported with -v or
+// $CATALOGCTL_TELEMETRY_DEBUG.
+func reportUsage(cmd *cobra.Command, duration time.Duration, err error) {
+	if cmd == nil || telemetryOff() != "" {
+		return
+	}
+	s, loadErr := telemetry.Load(telemetryPath())
+	if loadErr != nil || !s.Enabled || s.ID == "" {
+		return
+	}
+	endpoint := telemetryEndpoint(s)
+	if endpoint == "" {
+		return
+	}
+	event := commandEvent(s, cmd, duration, err)
+	if os.Getenv(telemetryDebugEnv) != "" {
+		data, _ := json.Marshal(event)
+		fmt.Fprintf(os.Stderr, "telemetry: %s -> %s\n", data, endpoint)
+	}
+	if sendErr := telemetry.Send(httpclient.NewFixed(telemetryTimeout), endpoint, "catalogctl/"+Version, event); sendErr != nil && (verbose(levelVerbose) || os.Getenv(telemetryDebugEnv) != "") {
+		fmt.Fprintf(os.Stderr, "telemetry: %v\n", sendErr)
+	}
+}
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Opt in to anonymous usage reporting",
+	Long: `Anonymous usage reporting helps the maintainers see which commands and
+features are used. It is off until you run telemetry enable.
+
+Each run then posts one event: the command (e.g. "publish-game"), the names
+of the flags that were set, how long it took, whether it failed and a coarse
+error category (network, timeout, usage, ...), plus the catalogctl version,
+OS, architecture, whether it ran in CI and a random installation ID. Flag
+values, arguments, addresses, object and blob IDs, file names, URLs and
+error messages are never sent.
+
+DO_NOT_TRACK=1 or CATALOGCTL_TELEMETRY=0 turn reporting off for a run.
+CATALOGCTL_TELEMETRY_DEBUG=1 prints every event to stderr.`,
+	Example: `  catalogctl telemetry status
+  catalogctl telemetry enable
+  catalogctl telemetry disable`,
+}
+
+var telemetryEnableEndpoint string
+
+var telemetryEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Start reporting anonymous usage",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := telemetryPath()
+		s, err := telemetry.Load(path)
+		if err != nil {
+			return err
+		}
+		if telemetryEnableEndpoint != "" {
+			if err := telemetry.ValidateEndpoint(telemetryEnableEndpoint); err != nil {
+				return err
+			}
+		} else if telemetryEndpoint(s) == "" {
+			return fmt.Errorf("this build has no telemetry endpoint built in; pass --endpoint")
+		}
+		if err := s.Enable(telemetryEnableEndpoint); err != nil {
+			return fmt.Errorf("failed to create an installation ID: %w", err)
+		}
+		if err := s.Save(path); err != nil {
+			return fmt.Errorf("failed to save %s: %w", path, err)
+		}
+		setResult(s)
+		statusf("✓ Anonymous usage reporting enabled (installation ID %s)\n", s.ID)
+		fmt.Fprintf(stdout, "  Events go to %s\n", telemetryEndpoint(s))
+		statusln("💡 See what is sent with: catalogctl telemetry status; stop with: catalogctl telemetry disable")
+		if reason := telemetryOff(); reason != "" {
+			statusf("⚠️  Nothing is sent while %s\n", reason)
+		}
+		return nil
+	},
+}
+
+var telemetryDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Stop reporting and forget the installation ID",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := telemetryPath()
+		s, err := telemetry.Load(path)
+		if err != nil {
+			return err
+		}
+		s.Disable()
+		if err := s.Save(path); err != nil {
+			return fmt.Errorf("failed to save %s: %w", path, err)
+		}
+		setResult(s)
+		statusln("✓ Anonymous usage reporting disabled")
+		return nil
+	},
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether usage is reported and an example event",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := telemetry.Load(telemetryPath())
+		if err != nil {
+			return err
+		}
+		endpoint := telemetryEndpoint(s)
+		example := commandEvent(s, cmd, 0, nil)
+		setResult(map[string]interface{}{
+			"enabled":  s.Enabled && telemetryOff() == "",
+			"id":       s.ID,
+			"endpoint": endpoint,
+			"example":  example,
+		})
+
+		switch {
+		case !s.Enabled:
+			fmt.Fprintln(stdout, "Telemetry: disabled (enable with: catalogctl telemetry enable)")
+		case telemetryOff() != "":
+			fmt.Fprintf(stdout, "Telemetry: enabled, but off while %s\n", telemetryOff())
+		default:
+			fmt.Fprintln(stdout, "Telemetry: enabled")
+		}
+		if s.ID != "" {
+			fmt.Fprintf(stdout, "Installation ID: %s\n", s.ID)
+		}
+		if endpoint == "" {
+			endpoint = "(none built in)"
+		}
+		fmt.Fprintf(stdout, "Endpoint:        %s\n", endpoint)
+		fmt.Fprintf(stdout, "Settings:        %s\n", telemetryPath())
+		data, err := json.MarshalIndent(example, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "\nExample event (this command):\n%s\n", data)
+		return nil
+	},
+}
+
+func init() {
+	telemetryEnableCmd.Flags().StringVar(&telemetryEnableEndpoint, "endpoint", "", "URL events are posted to (default: the built-in endpoint)")
+	telemetryCmd.AddCommand(telemetryEnableCmd)
+	reportsResult(telemetryEnableCmd)
+	telemetryCmd.AddCommand(telemetryDisableCmd)
+	reportsResult(telemetryDisableCmd)
+	telemetryCmd.AddCommand(telemetryStatusCmd)
+	reportsResult(telemetryStatusCmd)
+	rootCmd.AddCommand(telemetryCmd)
+}
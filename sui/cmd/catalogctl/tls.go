@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+)
+
+// ============================================================================
+// TLS for self-hosted endpoints
+// ============================================================================
+
+var insecureSkipVerify bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "Do not verify TLS certificates of the Sui, Walrus and Nimiq endpoints (testing only)")
+}
+
+// tlsSettings returns the TLS settings of the tls_* config options and
+// --insecure-skip-verify (nil for the system defaults), warning loudly when
+// certificates aren't verified
+func tlsSettings() (*tls.Config, error) {
+	if insecureSkipVerify {
+		cfg.TLSInsecureSkipVerify = true
+	}
+	tlsConf, err := cfg.TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	if tlsConf != nil && tlsConf.InsecureSkipVerify {
+		fmt.Fprintln(os.Stderr, "⚠️  ============================================================")
+		fmt.Fprintln(os.Stderr, "⚠️  TLS CERTIFICATE VERIFICATION IS DISABLED")
+		fmt.Fprintln(os.Stderr, "⚠️  Anyone on the network path can impersonate the Sui, Walrus")
+		fmt.Fprintln(os.Stderr, "⚠️  and Nimiq endpoints and see or alter what is sent to them.")
+		fmt.Fprintln(os.Stderr, "⚠️  Use tls_ca_file to trust a private CA instead.")
+		fmt.Fprintln(os.Stderr, "⚠️  ============================================================")
+	}
+	return tlsConf, nil
+}
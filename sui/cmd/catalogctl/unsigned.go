@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/delta"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/plan"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// Unsigned transactions for wallet signing
+// ============================================================================
+
+var (
+	// unsignedOut is where --unsigned-out writes base64 TransactionData
+	unsignedOut string
+	// unsignedSender is the wallet address that will sign (--sender)
+	unsignedSender string
+)
+
+// addUnsignedFlags registers --unsigned-out and --sender on a command that
+// sends a transaction
+func addUnsignedFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&unsignedOut, "unsigned-out", "", "Don't send: write the unsigned transaction (base64 TransactionData) to this file for wallet signing")
+	cmd.Flags().StringVar(&unsignedSender, "sender", "", "Wallet address that signs the --unsigned-out transaction and pays gas (default: active address)")
+}
+
+// ptb builds a programmable transaction with `sui client ptb`. Arguments
+// use the PTB syntax: strings are quoted, numbers carry their type suffix
+// and object IDs are prefixed with @.
+type ptb struct {
+	args []string
+}
+
+func (p *ptb) moveCall(module, function string, args ...string) *ptb {
+	p.args = append(p.args, "--move-call", fmt.Sprintf("%s::%s::%s", cfg.PackageID, module, function))
+	p.args = append(p.args, args...)
+	return p
+}
+
+// assign names the result of the previous command
+func (p *ptb) assign(name string) *ptb {
+	p.args = append(p.args, "--assign", name)
+	return p
+}
+
+func (p *ptb) transferObjects(names []string, recipient string) *ptb {
+	p.args = append(p.args, "--transfer-objects", "["+strings.Join(names, ", ")+"]", "@"+recipient)
+	return p
+}
+
+// serialize returns the unsigned transaction as base64 TransactionData
+func (p *ptb) serialize(sender string) (string, error) {
+	cmdArgs := append([]string{"client", "ptb"}, p.args...)
+	cmdArgs = append(cmdArgs,
+		"--sender", "@"+sender,
+		"--gas-budget", "10000000",
+		"--serialize-unsigned-transaction",
+	)
+	output, err := executeSuiCommand(cmdArgs)
+	if err != nil {
+		return "", fmt.Errorf("failed to build transaction: %w", err)
+	}
+
+	// The serialized transaction is the last line of the output
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	txBytes := strings.TrimSpace(lines[len(lines)-1])
+	if _, err := base64.StdEncoding.DecodeString(txBytes); err != nil || txBytes == "" {
+		return "", fmt.Errorf("unexpected output from sui client ptb (no base64 transaction):\n%s", output)
+	}
+	return txBytes, nil
+}
+
+func ptbString(s string) string {
+	return strconv.Quote(s)
+}
+
+func ptbObject(id string) string {
+	return "@" + id
+}
+
+func ptbU8(v uint8) string { return fmt.Sprintf("%du8", v) }
+
+func ptbU16(v uint16) string { return fmt.Sprintf("%du16", v) }
+
+func ptbU64(v uint64) string { return fmt.Sprintf("%du64", v) }
+
+// ptbBytes renders hex (with or without 0x) as a vector<u8> literal
+func ptbBytes(hexStr string) (string, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(hexStr, "0x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid hex %q: %w", hexStr, err)
+	}
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = ptbU8(v)
+	}
+	return "vector[" + strings.Join(parts, ", ") + "]", nil
+}
+
+// txSigner returns the address that signs the command's transaction: the
+// --sender wallet with --unsigned-out, otherwise the active address
+func txSigner() (string, error) {
+	if unsignedOut == "" || unsignedSender == "" {
+		return activeAddress()
+	}
+	if err := validate.ObjectID(unsignedSender); err != nil {
+		return "", fmt.Errorf("invalid --sender: %w", err)
+	}
+	return unsignedSender, nil
+}
+
+// addEntryCall appends add_entry (or add_entry_with_cap for curators) to tx.
+// cartridge is a PTB argument: an @ID or the name of an earlier result, and
+// cover the cover blob ID (vector[] for none).
+func addEntryCall(tx *ptb, catalogID, capID, slug, cartridge, title string, platform model.Platform, size uint64, emulator string, version uint16, cover string) {
+	function, auth := "add_entry", []string{ptbObject(catalogID)}
+	if capID != "" {
+		function, auth = "add_entry_with_cap", []string{ptbObject(catalogID), ptbObject(capID)}
+	}
+	tx.moveCall("catalog", function, append(auth,
+		ptbString(slug),
+		cartridge,
+		ptbString(title),
+		ptbU8(uint8(platform)),
+		ptbU64(size),
+		ptbString(emulator),
+		ptbU16(version),
+		cover,
+	)...)
+}
+
+// writeUnsignedPublish uploads the game (or its patch) and its assets to
+// Walrus, then writes one transaction that creates the cartridge, attaches
+// the delta and assets, adds it to the catalog and transfers the cartridge
+// to the signer
+func writeUnsignedPublish(pl *plan.Plan, p publishGameParams) error {
+	sender, err := txSigner()
+	if err != nil {
+		return err
+	}
+
+	// The walrus_store steps of the plan: the game, then one per asset
+	var blobArgs []string
+	for _, op := range pl.Operations {
+		if op.Type != plan.OpWalrusStore {
+			continue
+		}
+		fmt.Fprintf(stdout, "[1/2] %s\n", op.Description)
+		blobID, _, err := executeWalrusStore(pl, op)
+		if err != nil {
+			return err
+		}
+		blobIDBytes, err := base58.Decode(blobID)
+		if err != nil {
+			return fmt.Errorf("failed to decode blob ID from base58: %w", err)
+		}
+		statusf("  ✓ Uploaded! Blob ID: %s\n", blobID)
+		blobArg, err := ptbBytes(hex.EncodeToString(blobIDBytes))
+		if err != nil {
+			return err
+		}
+		blobArgs = append(blobArgs, blobArg)
+	}
+
+	fmt.Fprintln(stdout, "[2/2] Build the create-cartridge and add-entry transaction")
+	tx, err := publishPTB(p, blobArgs, sender)
+	if err != nil {
+		return err
+	}
+	return writeUnsignedTx(tx, sender)
+}
+
+// publishPTB builds the single transaction that creates the cartridge,
+// attaches the delta and assets, adds it to the catalog and transfers the
+// cartridge to sender. blobArgs are the blob IDs of the plan's walrus_store
+// steps as vector<u8> literals: the game first, then one per asset.
+func publishPTB(p publishGameParams, blobArgs []string, sender string) (*ptb, error) {
+	shaArg, err := ptbBytes(p.SHA256Hex)
+	if err != nil {
+		return nil, err
+	}
+	tx := (&ptb{}).
+		moveCall("cartridge", "create",
+			ptbString(p.Slug),
+			ptbString(p.Title),
+			ptbU8(uint8(p.Platform)),
+			ptbString(p.Emulator),
+			ptbU16(p.Version),
+			blobArgs[0],
+			shaArg,
+			ptbU64(uint64(p.Size)),
+			ptbU64(uint64(time.Now().UnixMilli())),
+		).assign("cartridge")
+	if p.Delta != nil {
+		patchSHA, err := ptbBytes(p.Delta.PatchSHA256Hex)
+		if err != nil {
+			return nil, err
+		}
+		tx.moveCall("cartridge", "set_delta",
+			"cartridge",
+			ptbObject(p.Delta.BaseCartridgeID),
+			ptbString(delta.Format),
+			patchSHA,
+			ptbU64(uint64(p.Delta.PatchSize)),
+		)
+	}
+	cover := "vector[]"
+	for i, asset := range p.Assets {
+		if asset.Name == coverAssetName {
+			cover = blobArgs[i+1]
+		}
+		assetSHA, err := ptbBytes(asset.SHA256Hex)
+		if err != nil {
+			return nil, err
+		}
+		tx.moveCall("cartridge", "add_asset",
+			"cartridge",
+			ptbString(asset.Name),
+			blobArgs[i+1],
+			assetSHA,
+			ptbU64(uint64(asset.Size)),
+		)
+	}
+	tx.moveCall("cartridge", "id", "cartridge").assign("cartridge_id")
+	addEntryCall(tx, p.CatalogID, p.CapID, p.EntryKey(), "cartridge_id", p.Title, p.Platform, uint64(p.Size), p.Emulator, p.Version, cover)
+	tx.transferObjects([]string{"cartridge"}, sender)
+	return tx, nil
+}
+
+// writeUnsignedTx serializes p for sender and writes it to --unsigned-out
+func writeUnsignedTx(p *ptb, sender string) error {
+	txBytes, err := p.serialize(sender)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(unsignedOut, []byte(txBytes+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", unsignedOut, err)
+	}
+	statusf("\n✓ Unsigned transaction written to %s (sender %s)\n", unsignedOut, sender)
+	if !quiet() {
+		statusln("💡 Sign it in a Sui wallet, then broadcast it with:")
+		fmt.Fprintln(stdout, "   catalogctl submit --signed-tx signed.json")
+	}
+	return nil
+}
+
+// ============================================================================
+// submit command
+// ============================================================================
+
+var submitCmd = &cobra.Command{
+	Use:   "submit",
+	Short: "Broadcast a wallet-signed transaction",
+	Long: `Executes a transaction that was created with --unsigned-out and signed in a
+wallet. --signed-tx is either the JSON a wallet returns from
+signTransaction ({"bytes": "...", "signature": "..."}) or a file holding the
+base64 transaction bytes, with the signature passed via --signature.
+
+Example:
+  catalogctl add-entry ... --unsigned-out tx.b64 --sender 0xWALLET
+  catalogctl submit --signed-tx signed.json`,
+	RunE: runSubmit,
+}
+
+var (
+	submitSignedTx  string
+	submitSignature string
+)
+
+func init() {
+	submitCmd.Flags().StringVar(&submitSignedTx, "signed-tx", "", "Signed transaction JSON, or base64 transaction bytes with --signature (required)")
+	submitCmd.Flags().StringVar(&submitSignature, "signature", "", "Base64 serialized signature (if --signed-tx only holds the transaction bytes)")
+	submitCmd.MarkFlagRequired("signed-tx")
+	rootCmd.AddCommand(submitCmd)
+	reportsResult(submitCmd)
+}
+
+// signedTransaction is the signTransaction result of the Sui wallet standard
+type signedTransaction struct {
+	Bytes     string `json:"bytes"`
+	Signature string `json:"signature"`
+}
+
+func runSubmit(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(submitSignedTx)
+	if err != nil {
+		return fmt.Errorf("failed to read signed transaction: %w", err)
+	}
+
+	var signed signedTransaction
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		if err := json.Unmarshal(data, &signed); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", submitSignedTx, err)
+		}
+	} else {
+		signed.Bytes = strings.TrimSpace(string(data))
+	}
+	if submitSignature != "" {
+		signed.Signature = submitSignature
+	}
+	if signed.Bytes == "" || signed.Signature == "" {
+		return fmt.Errorf("both the transaction bytes and the signature are required")
+	}
+	if err := requireApproval(); err != nil {
+		return err
+	}
+
+	statusf("Submitting transaction to %s...\n", cfg.SuiNetwork)
+	client := sui.NewClient(cfg.SuiRPCURL)
+	result, err := client.ExecuteTransactionBlock(signed.Bytes, []string{signed.Signature})
+	if err != nil {
+		if versionConflict(err) {
+			// A signed transaction can't be rebuilt against the new versions
+			return fmt.Errorf("failed to execute transaction: %w\n💡 Another transaction changed one of its objects first; build it again with --unsigned-out and sign the new one", err)
+		}
+		return fmt.Errorf("failed to execute transaction: %w", err)
+	}
+
+	output := string(result)
+	digest := sui.TransactionDigest(output)
+	statusf("\n✓ Transaction executed!\n")
+	fmt.Fprintf(stdout, "Transaction: %s\n", digest)
+	printExplorerLink("  ", config.LinkTx, digest)
+	for _, created := range []struct{ label, typeName string }{
+		{"Catalog", "::catalog::Catalog"},
+		{"Cartridge", "::cartridge::Cartridge"},
+	} {
+		if id := sui.CreatedObjectID(output, created.typeName); id != "" {
+			fmt.Fprintf(stdout, "%s ID: %s\n", created.label, id)
+			printExplorerLink("  ", config.LinkObject, id)
+		}
+	}
+	fmt.Fprintf(stdout, "Gas used: %s\n", formatSUI(sui.TransactionGasCost(output)))
+
+	newGHSummary("Submitted signed transaction").
+		row("Transaction", mdLink(digest, explorerURL(config.LinkTx, digest))).
+		row("Gas used", formatSUI(sui.TransactionGasCost(output))).
+		output("digest", digest).
+		write()
+	return nil
+}
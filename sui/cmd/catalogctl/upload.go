@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/plan"
+	"github.com/retro-crypto/sui/internal/storage"
+)
+
+// ============================================================================
+// serve: blob upload proxy
+// ============================================================================
+
+// uploadMemory is how much of a multipart upload is kept in memory; the rest
+// is spooled to a temp file by net/http
+const uploadMemory = 32 << 20
+
+// uploadProxy accepts game files from trusted frontends, stores them through
+// the Walrus publisher and returns a draft entry for the user's wallet to
+// sign. The gateway holds no Sui keys: the publisher pays for storage and the
+// user signs the Move calls.
+type uploadProxy struct {
+	allowedOrigins []string
+}
+
+func (u *uploadProxy) mount(mux *http.ServeMux) {
+	mux.HandleFunc("/api/upload", u.cors(gatewayTokens.require(u.handleUpload)))
+}
+
+func (u *uploadProxy) operations() []apiOperation {
+	return []apiOperation{{
+		Method:    http.MethodPost,
+		Path:      "/api/upload",
+		Summary:   "Upload a game file to Walrus and get a draft catalog entry to sign",
+		Tag:       "publish",
+		Auth:      len(gatewayTokens) > 0,
+		FileField: "file",
+		Form: []apiParam{
+			{Name: "slug", Description: "Entry slug", Required: true},
+			{Name: "title", Description: "Game title", Required: true},
+			{Name: "platform", Description: "dos, gb, gbc, nes or snes", Required: true},
+			{Name: "version", Description: "Version number (default 1)"},
+			{Name: "emulator", Description: "Emulator core (default for the platform)"},
+			{Name: "catalog", Description: "Catalog ID or alias (default: catalog_id)"},
+			{Name: "epochs", Description: "Walrus storage epochs (default 5)"},
+		},
+		Response: uploadResponse{},
+	}}
+}
+
+// cors lets the configured frontend origins call the endpoint from a browser
+func (u *uploadProxy) cors(next http.HandlerFunc) http.HandlerFunc {
+	return allowOrigins(u.allowedOrigins, "POST, OPTIONS", next)
+}
+
+// uploadResponse is returned by POST /api/upload
+type uploadResponse struct {
+	BlobID    string `json:"blob_id"`
+	BlobIDHex string `json:"blob_id_hex"`
+	SHA256    string `json:"sha256"`
+	SizeBytes uint64 `json:"size_bytes"`
+	// Cost is the storage cost in FROST reported by the publisher (0 if the
+	// blob was already stored)
+	Cost     uint64     `json:"cost"`
+	Verified bool       `json:"verified"`
+	Draft    entryDraft `json:"draft"`
+}
+
+// entryDraft holds the Move calls that publish an uploaded blob. The wallet
+// runs them in order, replacing {{now_ms}} with the current time and
+// {{cartridge_id}} in the second call with the Cartridge the first created.
+type entryDraft struct {
+	Network   string             `json:"network"`
+	PackageID string             `json:"package_id"`
+	CatalogID string             `json:"catalog_id"`
+	Entry     model.CatalogEntry `json:"entry"`
+	Calls     []plan.Operation   `json:"calls"`
+}
+
+func (u *uploadProxy) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+	if err := r.ParseMultipartForm(uploadMemory); err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "too large") {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, fmt.Sprintf("invalid form: %v", err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	params, err := uploadParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "a file is required")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read file: %v", err))
+		return
+	}
+	if len(data) == 0 {
+		writeError(w, http.StatusBadRequest, "file is empty")
+		return
+	}
+	hash := sha256.Sum256(data)
+	params.FilePath = filepath.Base(header.Filename)
+	params.Size = int64(len(data))
+	params.SHA256Hex = hex.EncodeToString(hash[:])
+
+	backend, err := storageBackend()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	stored, err := backend.Store(data, storage.StoreOptions{Epochs: params.Epochs, HostedOnly: true})
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("failed to upload to %s: %v", backend.Name(), err))
+		return
+	}
+	blobID := stored.BlobID
+	blobIDBytes, err := base58.Decode(blobID)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("failed to decode blob ID from base58: %v", err))
+		return
+	}
+
+	// Read the blob back so the draft only ever points at retrievable data
+	readBack, err := backend.Read(blobID)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("blob %s was stored but could not be read back: %v", blobID, err))
+		return
+	}
+	if !bytes.Equal(readBack, data) {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("blob %s read back differs from the upload", blobID))
+		return
+	}
+
+	resp := uploadResponse{
+		BlobID:    blobID,
+		BlobIDHex: "0x" + hex.EncodeToString(blobIDBytes),
+		SHA256:    params.SHA256Hex,
+		SizeBytes: uint64(len(data)),
+		Verified:  true,
+		Cost:      stored.Cost,
+	}
+	resp.Draft = buildEntryDraft(params, resp.BlobIDHex)
+
+	fmt.Fprintf(stdout, "upload: stored %s (%d bytes) as %s\n", params.FilePath, len(data), blobID)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// uploadParams reads and checks the form fields of an upload
+func uploadParams(r *http.Request) (publishGameParams, error) {
+	p := publishGameParams{
+		Slug:    strings.TrimSpace(r.FormValue("slug")),
+		Title:   strings.TrimSpace(r.FormValue("title")),
+		Version: 1,
+		Epochs:  5,
+	}
+	if p.Slug == "" || p.Title == "" {
+		return p, fmt.Errorf("slug and title are required")
+	}
+	platform, err := model.ParsePlatform(r.FormValue("platform"))
+	if err != nil {
+		return p, err
+	}
+	p.Platform = platform
+	p.Emulator = strings.TrimSpace(r.FormValue("emulator"))
+	if p.Emulator == "" {
+		p.Emulator = model.EmulatorCoreForPlatform(platform)
+	}
+	if v := r.FormValue("version"); v != "" {
+		version, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			return p, fmt.Errorf("version must be a number")
+		}
+		p.Version = uint16(version)
+	}
+	if v := r.FormValue("epochs"); v != "" {
+		epochs, err := strconv.Atoi(v)
+		if err != nil || epochs < 1 {
+			return p, fmt.Errorf("epochs must be a positive number")
+		}
+		p.Epochs = epochs
+	}
+	if p.CatalogID, err = requestCatalogID(r.FormValue("catalog")); err != nil {
+		return p, err
+	}
+	if cfg.PackageID == "" {
+		return p, fmt.Errorf("the gateway has no package_id configured")
+	}
+	return p, nil
+}
+
+// buildEntryDraft takes the Move calls of a publish-game plan and fills in
+// the blob ID; the cartridge ID and timestamp are left to the wallet
+func buildEntryDraft(p publishGameParams, blobIDHex string) entryDraft {
+	pl := buildPublishGamePlan(p)
+	draft := entryDraft{
+		Network:   pl.Network,
+		PackageID: pl.PackageID,
+		CatalogID: pl.CatalogID,
+		Entry: model.CatalogEntry{
+			Slug:         p.Slug,
+			CartridgeID:  plan.PlaceholderCartridgeID,
+			Title:        p.Title,
+			Platform:     p.Platform,
+			SizeBytes:    uint64(p.Size),
+			EmulatorCore: p.Emulator,
+			Version:      p.Version,
+		},
+	}
+	for _, op := range pl.Operations {
+		if op.Type != plan.OpSuiCall {
+			continue
+		}
+		args := make([]string, len(op.Args))
+		for i, arg := range op.Args {
+			args[i] = strings.ReplaceAll(arg, plan.PlaceholderBlobIDHex, blobIDHex)
+		}
+		op.Args = args
+		draft.Calls = append(draft.Calls, op)
+	}
+	return draft
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/retro-crypto/shared/logging"
+	"github.com/retro-crypto/sui/internal/i18n"
+)
+
+// ============================================================================
+// Verbosity (-q/--quiet, -v/-vv)
+// ============================================================================
+
+// Verbosity levels. Quiet prints only results (tables, IDs, JSON) and
+// errors; normal adds status lines, tips, warnings and progress; verbose
+// adds one record per HTTP/RPC request on stderr and debug adds their bodies
+// (see setupLogging).
+const (
+	levelQuiet   = -1
+	levelNormal  = 0
+	levelVerbose = 1
+	levelDebug   = 2
+)
+
+var (
+	quietFlag    bool
+	verboseCount int
+	verbosity    = levelNormal
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "Print only results and errors: no status lines, tips, warnings or progress")
+	rootCmd.PersistentFlags().CountVarP(&verboseCount, "verbose", "v", "Print more detail: every HTTP/RPC request on stderr (-v), with request and response bodies (-vv)")
+}
+
+// setupVerbosity applies -q and -v before any client is created
+func setupVerbosity() error {
+	if quietFlag && verboseCount > 0 {
+		return fmt.Errorf("--quiet and --verbose cannot be combined")
+	}
+	switch {
+	case quietFlag:
+		verbosity = levelQuiet
+	case verboseCount >= levelDebug:
+		verbosity = levelDebug
+	default:
+		verbosity = verboseCount
+	}
+	return nil
+}
+
+// quiet reports whether only results are printed
+func quiet() bool {
+	return verbosity <= levelQuiet
+}
+
+// verbose reports whether detail of the given level is printed
+func verbose(level int) bool {
+	return verbosity >= level
+}
+
+// statusf prints a translated status line (progress, success banner, tip or
+// warning) unless --quiet is set. The log file gets it in English either way.
+func statusf(format string, args ...interface{}) {
+	if !quiet() {
+		i18n.Fprintf(stdout, format, args...)
+	}
+	logging.File().Info(logging.Message(fmt.Sprintf(format, args...)))
+}
+
+// statusln is statusf for a message without arguments
+func statusln(msg string) {
+	if !quiet() {
+		i18n.Fprintln(stdout, msg)
+	}
+	logging.File().Info(logging.Message(msg))
+}
+
+// warnf logs a translated warning: on stderr unless --quiet is set, and in
+// the log file
+func warnf(format string, args ...interface{}) {
+	logging.Logger().Warn(i18n.Sprintf(format, args...))
+}
+
+// debugf logs detail, shown on stderr from the given level on
+func debugf(level int, format string, args ...interface{}) {
+	logging.Logger().Log(context.Background(), slogLevel(level), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/storage"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================================
+// verify command
+// ============================================================================
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check every catalog entry's cartridge and blob against the on-chain hash",
+	Long: `Walks every entry of a catalog (all channels), reads its cartridge, downloads
+the blob and recomputes its SHA256 and size against the cartridge. Delta
+cartridges are checked against their patch hash. With the walrus CLI installed,
+the blob's storage end epoch is checked too.
+
+Problems reported per entry:
+  cartridge_unreadable  the cartridge object can't be read or has no blob ID
+  missing_blob          no aggregator serves the blob
+  sha256_mismatch       the blob's SHA256 differs from the on-chain hash
+  size_mismatch         the blob's size differs from the on-chain size
+  expired               the blob's storage has ended
+  expiring_soon         storage ends within --warn-epochs epochs (warning)
+
+The catalog's stored entry count is compared with its actual entries too.
+With --fix-count a drifted count is reconciled with the package's fix_count
+call (catalog owner only).
+
+--json prints the report as JSON, --report writes it to a file. The command
+fails if any entry has an error or the count has drifted.
+
+Example:
+  catalogctl verify --catalog 0x123...
+  catalogctl verify --json --warn-epochs 5 > report.json`,
+	RunE: runVerify,
+}
+
+var (
+	verifyCatalogID  string
+	verifyJSON       bool
+	verifyReportPath string
+	verifyWarnEpochs uint64
+	verifyFixCount   bool
+)
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyCatalogID, "catalog", "", "Catalog object ID or alias (optional, uses config.catalog_id if not set)")
+	validateAs(verifyCmd.Flags(), "catalog", formatCatalogRef)
+	verifyCmd.Flags().BoolVar(&verifyJSON, "json", false, "Print the report as JSON instead of a summary")
+	verifyCmd.Flags().StringVar(&verifyReportPath, "report", "", "Also write the JSON report to this file")
+	verifyCmd.Flags().Uint64Var(&verifyWarnEpochs, "warn-epochs", 2, "Warn about blobs whose storage ends within this many epochs")
+	verifyCmd.Flags().BoolVar(&verifyFixCount, "fix-count", false, "Set the catalog's entry count to the actual number of entries if it has drifted (owner only)")
+	rootCmd.AddCommand(verifyCmd)
+	reportsResult(verifyCmd)
+}
+
+// Problems found by verify
+const (
+	problemCartridgeUnreadable = "cartridge_unreadable"
+	problemMissingBlob         = "missing_blob"
+	problemSHA256Mismatch      = "sha256_mismatch"
+	problemSizeMismatch        = "size_mismatch"
+	problemExpired             = "expired"
+	problemExpiringSoon        = "expiring_soon"
+)
+
+// verifyReport is the machine-readable result of verify
+type verifyReport struct {
+	CatalogID     string         `json:"catalog_id"`
+	Network       string         `json:"network"`
+	CheckedAt     time.Time      `json:"checked_at"`
+	EpochsChecked bool           `json:"epochs_checked"`
+	EpochsNote    string         `json:"epochs_note,omitempty"`
+	Summary       map[string]int `json:"summary"`
+	Count         *countCheck    `json:"count"`
+	Entries       []verifyEntry  `json:"entries"`
+}
+
+// countCheck compares a catalog's stored entry count with the entries it
+// actually has. Fixed is set when --fix-count reconciled them.
+type countCheck struct {
+	Stored uint64 `json:"stored"`
+	Actual uint64 `json:"actual"`
+	Drift  int64  `json:"drift"`
+	Fixed  bool   `json:"fixed,omitempty"`
+	FixTx  string `json:"fix_tx,omitempty"`
+}
+
+// verifyEntry is the result for one catalog entry. Status is "ok",
+// "warning" or "error".
+type verifyEntry struct {
+	Key            string   `json:"key"`
+	CartridgeID    string   `json:"cartridge_id"`
+	BlobID         string   `json:"blob_id,omitempty"`
+	Delta          bool     `json:"delta,omitempty"`
+	Status         string   `json:"status"`
+	Problems       []string `json:"problems,omitempty"`
+	Detail         string   `json:"detail,omitempty"`
+	ExpectedSHA256 string   `json:"expected_sha256,omitempty"`
+	ActualSHA256   string   `json:"actual_sha256,omitempty"`
+	ExpectedSize   uint64   `json:"expected_size,omitempty"`
+	ActualSize     uint64   `json:"actual_size,omitempty"`
+	EndEpoch       uint64   `json:"end_epoch,omitempty"`
+	CurrentEpoch   uint64   `json:"current_epoch,omitempty"`
+}
+
+func (e *verifyEntry) problem(code, format string, args ...interface{}) {
+	e.Problems = append(e.Problems, code)
+	detail := strings.TrimSpace(fmt.Sprintf(format, args...))
+	if e.Detail != "" {
+		detail = e.Detail + "; " + detail
+	}
+	e.Detail = detail
+	if code == problemExpiringSoon {
+		if e.Status == "ok" {
+			e.Status = "warning"
+		}
+		return
+	}
+	e.Status = "error"
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	catalogID := verifyCatalogID
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return fmt.Errorf("catalog ID required: set --catalog flag or catalog_id in config file")
+	}
+	catalogID, err := cfg.ResolveCatalogID(catalogID)
+	if err != nil {
+		return err
+	}
+	backend, err := storageBackend()
+	if err != nil {
+		return err
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	entries, err := fetchCatalogEntries(client, catalogID)
+	if err != nil {
+		return err
+	}
+
+	report := &verifyReport{
+		CatalogID: catalogID,
+		Network:   cfg.SuiNetwork,
+		CheckedAt: time.Now().UTC(),
+		Summary:   map[string]int{"ok": 0, "warning": 0, "error": 0},
+		Entries:   []verifyEntry{},
+	}
+	expirer, _ := backend.(storage.Expirer)
+	report.EpochsChecked = expirer != nil
+	if expirer == nil {
+		report.EpochsNote = fmt.Sprintf("the %s backend doesn't expire blobs", backend.Name())
+	}
+
+	if !verifyJSON {
+		fmt.Fprintf(stdout, "Verifying %d entries of catalog %s...\n\n", len(entries), catalogID)
+	}
+	for _, entry := range entries {
+		result := verifyCatalogEntry(client, backend, entry)
+
+		if report.EpochsChecked && result.BlobID != "" {
+			expiry, err := expirer.Expiry(result.BlobID)
+			if err != nil {
+				// Without the walrus CLI no blob can be checked
+				report.EpochsChecked = false
+				report.EpochsNote = fmt.Sprintf("storage epochs not checked: %v", err)
+			} else {
+				result.EndEpoch, result.CurrentEpoch = expiry.EndEpoch, expiry.CurrentEpoch
+				switch {
+				case !expiry.Exists:
+					result.problem(problemExpired, "Walrus has no record of the blob (expired or never stored)")
+				case expiry.Expired():
+					result.problem(problemExpired, "storage ended at epoch %d (current epoch %d)", expiry.EndEpoch, expiry.CurrentEpoch)
+				case expiry.EndEpoch-expiry.CurrentEpoch <= verifyWarnEpochs:
+					result.problem(problemExpiringSoon, "storage ends at epoch %d (current epoch %d)", expiry.EndEpoch, expiry.CurrentEpoch)
+				}
+			}
+		}
+
+		report.Summary[result.Status]++
+		report.Entries = append(report.Entries, result)
+		if !verifyJSON {
+			switch result.Status {
+			case "ok":
+				fmt.Fprintf(stdout, "✓ %s\n", result.Key)
+			case "warning":
+				fmt.Fprintf(stdout, "⚠️  %s: %s\n", result.Key, result.Detail)
+			default:
+				fmt.Fprintf(stdout, "✗ %s: %s\n", result.Key, result.Detail)
+			}
+		}
+	}
+
+	count, err := checkEntryCount(client, catalogID)
+	if err != nil {
+		return err
+	}
+	report.Count = count
+	if count.Drift != 0 && verifyFixCount {
+		if !verifyJSON {
+			statusf("\nFixing entry count: %d -> %d...\n", count.Stored, count.Actual)
+		}
+		if count.FixTx, err = fixCatalogCount(catalogID, count.Stored, count.Actual); err != nil {
+			return err
+		}
+		count.Fixed = true
+	}
+
+	setResult(report)
+	data, _ := json.MarshalIndent(report, "", "  ")
+	if verifyReportPath != "" {
+		if err := os.WriteFile(verifyReportPath, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	if verifyJSON {
+		fmt.Fprintln(stdout, string(data))
+	} else {
+		switch {
+		case count.Drift == 0:
+			fmt.Fprintf(stdout, "\n✓ Entry count: %d\n", count.Actual)
+		case count.Fixed:
+			fmt.Fprintf(stdout, "✓ Entry count fixed: was %d, now %d (transaction %s)\n", count.Stored, count.Actual, count.FixTx)
+			printExplorerLink("  ", config.LinkTx, count.FixTx)
+		default:
+			fmt.Fprintf(stdout, "\n✗ Entry count: the catalog says %d, it has %d entries\n", count.Stored, count.Actual)
+			statusln("💡 Run verify --fix-count as the catalog owner to reconcile it")
+		}
+		fmt.Fprintf(stdout, "\n%d ok, %d warning(s), %d error(s)\n", report.Summary["ok"], report.Summary["warning"], report.Summary["error"])
+		if !report.EpochsChecked {
+			statusf("💡 %s\n", report.EpochsNote)
+		}
+		if verifyReportPath != "" {
+			fmt.Fprintf(stdout, "Report written to %s\n", verifyReportPath)
+		}
+	}
+
+	if report.Summary["error"] > 0 {
+		return fmt.Errorf("%d of %d entries failed verification", report.Summary["error"], len(entries))
+	}
+	if count.Drift != 0 && !count.Fixed {
+		return fmt.Errorf("catalog entry count is %d but the catalog has %d entries", count.Stored, count.Actual)
+	}
+	return nil
+}
+
+// checkEntryCount compares a catalog's count field with its entry dynamic
+// fields (everything but the curator set and the entries' metadata)
+func checkEntryCount(client *sui.Client, catalogID string) (*countCheck, error) {
+	resp, err := client.GetObject(catalogID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("catalog not found")
+	}
+	fields, err := client.GetAllDynamicFields(catalogID, 50)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entries: %w", err)
+	}
+
+	check := &countCheck{Stored: parseU64(sui.ParseCatalog(resp.Data)["count"])}
+	for _, field := range fields {
+		if !strings.HasSuffix(field.Name.Type, "::catalog::CuratorsKey") && !isEntryMetaKey(field.Name) {
+			check.Actual++
+		}
+	}
+	check.Drift = int64(check.Actual) - int64(check.Stored)
+	return check, nil
+}
+
+// fixCatalogCount sets a catalog's count with fix_count and returns the
+// transaction digest. The call aborts if the count is no longer stored.
+func fixCatalogCount(catalogID string, stored, actual uint64) (string, error) {
+	if cfg.PackageID == "" {
+		return "", fmt.Errorf("package_id is required in config file")
+	}
+	output, err := executeMoveCall(sui.FixCount(cfg.PackageID, catalogID, stored, actual))
+	if err != nil {
+		return "", fmt.Errorf("failed to fix entry count (packages deployed before fix_count need upgrade-package first): %w", err)
+	}
+	return sui.TransactionDigest(output), nil
+}
+
+// verifyCatalogEntry checks an entry's cartridge and blob
+func verifyCatalogEntry(client *sui.Client, backend storage.Backend, entry catalogEntry) verifyEntry {
+	result := verifyEntry{Key: entry.Slug, CartridgeID: entry.CartridgeID, Status: "ok"}
+
+	resp, err := client.GetObject(entry.CartridgeID)
+	if err != nil || resp.Data == nil {
+		if err == nil {
+			err = fmt.Errorf("object not found")
+		}
+		result.problem(problemCartridgeUnreadable, "cartridge %s: %v", entry.CartridgeID, err)
+		return result
+	}
+	fields := sui.ParseCatalog(resp.Data)
+	blobIDBytes, err := hex.DecodeString(sui.BytesArrayToHex(fields["blob_id"]))
+	if err != nil || len(blobIDBytes) == 0 {
+		result.problem(problemCartridgeUnreadable, "cartridge %s has no valid blob ID", entry.CartridgeID)
+		return result
+	}
+	result.BlobID = base58.Encode(blobIDBytes)
+	result.ExpectedSHA256 = sui.BytesArrayToHex(fields["sha256"])
+	result.ExpectedSize = parseU64(fields["size_bytes"])
+
+	// A delta cartridge's blob is the patch, which has its own hash
+	d, err := fetchCartridgeDelta(client, entry.CartridgeID)
+	if err != nil {
+		result.problem(problemCartridgeUnreadable, "%v", err)
+		return result
+	}
+	if d != nil {
+		result.Delta = true
+		result.ExpectedSHA256 = d.PatchSHA256
+		result.ExpectedSize = d.PatchSizeBytes
+	}
+
+	data, err := backend.Read(result.BlobID)
+	if err != nil {
+		result.problem(problemMissingBlob, "blob %s: %v", result.BlobID, err)
+		return result
+	}
+	hash := sha256.Sum256(data)
+	result.ActualSHA256 = hex.EncodeToString(hash[:])
+	result.ActualSize = uint64(len(data))
+	if result.ActualSHA256 != result.ExpectedSHA256 {
+		result.problem(problemSHA256Mismatch, "SHA256 %s, expected %s", result.ActualSHA256, result.ExpectedSHA256)
+	}
+	if result.ActualSize != result.ExpectedSize {
+		result.problem(problemSizeMismatch, "%d bytes, expected %d", result.ActualSize, result.ExpectedSize)
+	}
+	return result
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/retro-crypto/sui/internal/model"
+)
+
+// maxLogLines is how many output lines are kept per chain
+const maxLogLines = 500
+
+// Target statuses
+const (
+	statusPending = "pending"
+	statusRunning = "running"
+	statusDone    = "done"
+	statusFailed  = "failed"
+)
+
+// target is the publish to one chain within a job
+type target struct {
+	Chain   string            `json:"chain"`
+	Status  string            `json:"status"`
+	Step    int               `json:"step"`
+	Total   int               `json:"total"`
+	Current string            `json:"current,omitempty"`
+	Log     []string          `json:"log"`
+	Outputs map[string]string `json:"outputs,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// job publishes one file to the selected chains, one after another
+type job struct {
+	ID string
+
+	mu      sync.Mutex
+	req     publishRequest
+	targets []*target
+}
+
+func newJob(id string, req publishRequest) *job {
+	j := &job{ID: id, req: req}
+	if req.Sui {
+		j.targets = append(j.targets, &target{Chain: "sui", Status: statusPending})
+	}
+	if req.Nimiq {
+		j.targets = append(j.targets, &target{Chain: "nimiq", Status: statusPending})
+	}
+	return j
+}
+
+// snapshot returns a copy of the job state for the API
+func (j *job) snapshot() map[string]interface{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	targets := make([]target, len(j.targets))
+	for i, t := range j.targets {
+		targets[i] = *t
+		targets[i].Log = append([]string{}, t.Log...)
+	}
+	return map[string]interface{}{
+		"id":      j.ID,
+		"dry_run": j.req.DryRun,
+		"targets": targets,
+	}
+}
+
+// update changes a target under the job lock
+func (j *job) update(t *target, fn func(t *target)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn(t)
+}
+
+// log appends a line to the target's log, keeping the last maxLogLines
+func (j *job) log(t *target, line string) {
+	j.update(t, func(t *target) {
+		t.Log = append(t.Log, line)
+		if len(t.Log) > maxLogLines {
+			t.Log = t.Log[len(t.Log)-maxLogLines:]
+		}
+	})
+}
+
+// run publishes to every target; a failed chain doesn't stop the next one
+func (s *server) run(j *job) {
+	for _, t := range j.targets {
+		j.update(t, func(t *target) { t.Status = statusRunning })
+		var outputs map[string]string
+		var err error
+		switch t.Chain {
+		case "sui":
+			outputs, err = s.publishSui(j, t)
+		case "nimiq":
+			outputs, err = runTool(j, t, s.nimiqUploader, nimiqArgs(j.req))
+		}
+		j.update(t, func(t *target) {
+			t.Outputs = outputs
+			if err != nil {
+				t.Status = statusFailed
+				t.Error = err.Error()
+				return
+			}
+			t.Status = statusDone
+			if t.Total > 0 {
+				t.Step = t.Total
+			}
+		})
+	}
+}
+
+// nimiqArgs builds the nimiq-uploader upload-cartridge command line
+func nimiqArgs(req publishRequest) []string {
+	platform, _ := model.ParsePlatform(req.Platform)
+	args := []string{"upload-cartridge",
+		"--file", req.FilePath,
+		"--title", req.Title,
+		"--semver", req.NimiqSemver,
+		"--platform", fmt.Sprintf("%d", platform),
+		"--catalog-addr", req.NimiqCatalog,
+		"--generate-cartridge-addr",
+		"--gh-summary",
+	}
+	if req.DryRun {
+		args = append(args, "--dry-run")
+	}
+	return args
+}
+
+// runTool runs a CLI, streaming its output into the target's log. Outputs are collected through the CLI's
+// GitHub Actions step outputs, written with --gh-summary.
+//
+// nimiq-uploader is the one chain still driven as a subprocess: its upload
+// code is package main of a separate module, so there is no library to
+// build against.
+func runTool(j *job, t *target, tool string, args []string) (map[string]string, error) {
+	outputFile, err := os.CreateTemp("", "retro-publisher-outputs-*")
+	if err != nil {
+		return nil, err
+	}
+	outputFile.Close()
+	defer os.Remove(outputFile.Name())
+	summaryFile, err := os.CreateTemp("", "retro-publisher-summary-*")
+	if err != nil {
+		return nil, err
+	}
+	summaryFile.Close()
+	defer os.Remove(summaryFile.Name())
+
+	cmd := exec.Command(tool, args...)
+	cmd.Env = append(os.Environ(),
+		"GITHUB_OUTPUT="+outputFile.Name(),
+		"GITHUB_STEP_SUMMARY="+summaryFile.Name(),
+	)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", tool, err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		collectOutput(j, t, stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		collectOutput(j, t, stderr)
+	}()
+	wg.Wait()
+	runErr := cmd.Wait()
+
+	outputs := readStepOutputs(outputFile.Name())
+	if runErr != nil {
+		return outputs, fmt.Errorf("%s failed: %w", tool, runErr)
+	}
+	return outputs, nil
+}
+
+// collectOutput appends the lines of r to the log
+func collectOutput(j *job, t *target, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		j.log(t, scanner.Text())
+	}
+}
+
+// readStepOutputs parses name=value lines written to $GITHUB_OUTPUT
+func readStepOutputs(path string) map[string]string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	outputs := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if name, value, ok := strings.Cut(line, "="); ok {
+			outputs[name] = value
+		}
+	}
+	return outputs
+}
@@ -0,0 +1,125 @@
+// Package main provides retro-publisher, a small desktop app for publishing
+// games without the command line. It serves a local page on 127.0.0.1, opens
+// it in the default browser and publishes to Sui with catalogctl's libraries
+// and to Nimiq through nimiq-uploader.
+package main
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+//go:embed static
+var static embed.FS
+
+// Version information (set by ldflags during build)
+var Version = "dev"
+
+// Connection limits of the server. Reads cover a game upload of
+// maxUploadSize from the browser on the same machine.
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 2 * time.Minute
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 2 * time.Minute
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:0", "Address to listen on (default: a free local port)")
+	noBrowser := flag.Bool("no-browser", false, "Don't open the browser, only print the URL")
+	configPath := flag.String("config", "", "catalogctl config file (default: catalogctl's search order)")
+	nimiqUploader := flag.String("nimiq-uploader", findTool("nimiq-uploader"), "Path to the nimiq-uploader binary")
+	flag.Parse()
+
+	loginCode, err := newToken()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	session, err := newToken()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	srv := &server{
+		loginCode:     loginCode,
+		session:       session,
+		configPath:    *configPath,
+		nimiqUploader: *nimiqUploader,
+		jobs:          make(map[string]*job),
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to listen on %s: %v\n", *addr, err)
+		os.Exit(1)
+	}
+
+	url := fmt.Sprintf("http://%s/login?code=%s", listener.Addr(), loginCode)
+	fmt.Printf("retro-publisher %s\n", Version)
+	fmt.Printf("Open %s (the link signs in once)\n", url)
+	fmt.Println("Press Ctrl+C to quit.")
+	if !*noBrowser {
+		if err := openBrowser(url); err != nil {
+			fmt.Printf("⚠️  Could not open a browser (%v); open the URL above manually\n", err)
+		}
+	}
+
+	httpServer := &http.Server{
+		Handler:           srv.routes(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	if err := httpServer.Serve(listener); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// findTool prefers a binary next to this executable, then falls back to PATH
+func findTool(name string) string {
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if exe, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(exe), name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return name
+}
+
+// newToken returns a random token for the login code and the session cookie
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// openBrowser opens url in the system's default browser
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/agent"
+	"github.com/retro-crypto/sui/internal/approval"
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/keystore"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/plan"
+	"github.com/retro-crypto/sui/internal/storage"
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/validate"
+	"github.com/retro-crypto/sui/internal/walrus"
+)
+
+// suiEpochs is how long Walrus stores the game, as publish-game's default
+const suiEpochs = 5
+
+// publishSui runs the publish-game plan of catalogctl in process: the file
+// goes to Walrus, the Move calls are signed with the key catalogctl uses
+// (its agent, key_source, private_key or mnemonic, or the sui CLI keystore)
+func (s *server) publishSui(j *job, t *target) (map[string]string, error) {
+	// Move calls of one signer would fight over its gas coins
+	s.suiMu.Lock()
+	defer s.suiMu.Unlock()
+
+	req := j.req
+	cfg, err := config.Load(s.configPath)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PackageID == "" {
+		return nil, fmt.Errorf("package_id is required in the catalogctl config")
+	}
+	catalogID := req.SuiCatalog
+	if catalogID == "" {
+		catalogID = cfg.CatalogID
+	}
+	if catalogID == "" {
+		return nil, fmt.Errorf("catalog ID required: choose a catalog or set catalog_id in the catalogctl config")
+	}
+	if catalogID, err = cfg.ResolveCatalogID(catalogID); err != nil {
+		return nil, err
+	}
+	if err := validate.ObjectID(catalogID); err != nil {
+		return nil, fmt.Errorf("invalid catalog ID %s: %w", catalogID, err)
+	}
+	if cfg.ApprovalRequired() && !req.DryRun {
+		return nil, fmt.Errorf("mainnet publishes need a second operator's approval; use catalogctl publish-game")
+	}
+
+	platform, err := model.ParsePlatform(req.Platform)
+	if err != nil {
+		return nil, err
+	}
+	version := uint64(1)
+	if req.SuiVersion != "" {
+		if version, err = strconv.ParseUint(req.SuiVersion, 10, 16); err != nil {
+			return nil, fmt.Errorf("invalid version: %w", err)
+		}
+	}
+	file, err := hashFile(req.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	client := sui.NewClient(cfg.SuiRPCURL)
+	signer, err := suiSigner(cfg)
+	var capID string
+	if err == nil {
+		capID, err = suiCuratorCap(client, cfg, catalogID, signer.SuiAddress())
+	}
+	if err != nil {
+		if !req.DryRun {
+			return nil, err
+		}
+		j.log(t, fmt.Sprintf("⚠️  Could not determine catalog permissions (%v); planning as owner", err))
+	}
+
+	pl := plan.NewPublishGame(plan.PublishGame{
+		Network:   cfg.SuiNetwork,
+		PackageID: cfg.PackageID,
+		File:      file,
+		Slug:      req.Slug,
+		Title:     req.Title,
+		Platform:  platform,
+		Emulator:  model.EmulatorCoreForPlatform(platform),
+		Version:   uint16(version),
+		Epochs:    suiEpochs,
+		CatalogID: catalogID,
+		CapID:     capID,
+		Channel:   model.ChannelStable,
+	})
+	j.update(t, func(t *target) { t.Total = len(pl.Operations) })
+	j.log(t, fmt.Sprintf("Plan (%s on %s):", pl.Kind, pl.Network))
+	for _, op := range pl.Operations {
+		j.log(t, fmt.Sprintf("  %d. %s", op.Step, op.Description))
+	}
+	if req.DryRun {
+		j.log(t, fmt.Sprintf("Max gas: %d MIST, Walrus storage: %d bytes for %d epochs", pl.Estimate.MaxGasMist, pl.Estimate.WalrusBlobBytes, pl.Estimate.WalrusEpochs))
+		j.log(t, "Dry-run: nothing was uploaded or sent.")
+		return nil, nil
+	}
+
+	walrusClient := walrus.NewClient(cfg.WalrusAggregatorURL, cfg.WalrusPublisherURL)
+	walrusClient.SetPublishers(cfg.WalrusPublisherEndpoints())
+	hmacSigner, err := cfg.PublisherSigner()
+	if err != nil {
+		return nil, fmt.Errorf("invalid walrus_publisher_hmac: %w", err)
+	}
+	if hmacSigner != nil {
+		walrusClient.SetSigner(hmacSigner)
+	}
+	store := storage.NewWalrus(walrusClient, nil, cfg.WalrusNetwork, nil)
+
+	prog := plan.NewProgress(pl)
+	for _, op := range pl.Operations {
+		j.update(t, func(t *target) { t.Current = op.Description })
+		j.log(t, fmt.Sprintf("[%d/%d] %s...", op.Step, len(pl.Operations), op.Description))
+		if err := runSuiOperation(j, t, client, store, signer, pl, op, prog); err != nil {
+			return suiOutputs(req, pl, prog), err
+		}
+		j.update(t, func(t *target) { t.Step = op.Step })
+	}
+	j.log(t, "✓ Game published successfully!")
+	return suiOutputs(req, pl, prog), nil
+}
+
+// runSuiOperation executes one operation of the plan and records its
+// result in prog
+func runSuiOperation(j *job, t *target, client *sui.Client, store *storage.Walrus, signer sui.TxSigner, pl *plan.Plan, op plan.Operation, prog *plan.Progress) error {
+	switch op.Type {
+	case plan.OpWalrusStore:
+		file := pl.File
+		if op.File != nil {
+			file = *op.File
+		}
+		stored, err := store.StoreFile(file.Path, storage.StoreOptions{Epochs: op.Epochs})
+		if err != nil {
+			return fmt.Errorf("failed to upload to Walrus: %w", err)
+		}
+		blobIDBytes, err := base58.Decode(stored.BlobID)
+		if err != nil {
+			return fmt.Errorf("failed to decode blob ID from base58: %w", err)
+		}
+		prog.Outputs[op.Output] = stored.BlobID
+		prog.Outputs[op.Output+"_hex"] = "0x" + hex.EncodeToString(blobIDBytes)
+		prog.Completed[op.Step] = stored.BlobID
+		prog.WalrusCost += stored.Cost
+		j.log(t, "  ✓ Uploaded! Blob ID: "+stored.BlobID)
+		return nil
+
+	case plan.OpSuiCall:
+		args, err := plan.ResolveArgs(op.Args, prog.Outputs, map[string]string{
+			"now_ms": fmt.Sprintf("%d", time.Now().UnixMilli()),
+		})
+		if err != nil {
+			return fmt.Errorf("step %d: %w", op.Step, err)
+		}
+		txBytes, err := client.BuildMoveCall(signer.SuiAddress(), sui.MoveCall{
+			Package:   pl.PackageID,
+			Module:    op.Module,
+			Function:  op.Function,
+			Args:      sui.CLIArgValues(args),
+			GasBudget: op.GasBudget,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to build transaction: %w", err)
+		}
+		signature, err := signer.SignTransaction(txBytes)
+		if err != nil {
+			return err
+		}
+		result, err := client.ExecuteTransactionBlock(txBytes, []string{signature})
+		if err != nil {
+			return fmt.Errorf("failed to %s: %w", strings.ToLower(op.Description), err)
+		}
+
+		output := string(result)
+		if op.ObjectType != "" {
+			objectID := sui.CreatedObjectID(output, op.ObjectType)
+			if objectID == "" {
+				return fmt.Errorf("failed to extract %s ID from transaction", op.ObjectType)
+			}
+			prog.Outputs[op.Output] = objectID
+			j.log(t, fmt.Sprintf("  ✓ %s created! ID: %s", op.ObjectType, objectID))
+		}
+		digest := sui.TransactionDigest(output)
+		prog.Completed[op.Step] = digest
+		prog.GasMist += sui.TransactionGasCost(output)
+		j.log(t, "  ✓ Transaction: "+digest)
+		return nil
+	}
+	return fmt.Errorf("step %d: unknown operation type %q", op.Step, op.Type)
+}
+
+// suiOutputs are the values publish-game reports as step outputs
+func suiOutputs(req publishRequest, pl *plan.Plan, prog *plan.Progress) map[string]string {
+	outputs := map[string]string{
+		"catalog_id": pl.CatalogID,
+		"slug":       req.Slug,
+		"sha256":     pl.File.SHA256,
+		"gas_mist":   fmt.Sprintf("%d", prog.GasMist),
+	}
+	for _, name := range []string{"blob_id", "cartridge_id"} {
+		if value := prog.Outputs[name]; value != "" {
+			outputs[name] = value
+		}
+	}
+	return outputs
+}
+
+// suiSigner returns the key catalogctl signs with: the signing agent of
+// $CATALOGCTL_AGENT_SOCK, then key_source, private_key or mnemonic from the
+// config, then the active address of the sui CLI keystore. Encrypted
+// keystore files are opened with $CATALOGCTL_PASSPHRASE.
+func suiSigner(cfg *config.Config) (sui.TxSigner, error) {
+	if socket := os.Getenv(agent.SocketEnv); socket != "" {
+		return agent.NewClient(socket).Signer(os.Getenv("CATALOGCTL_AGENT_ADDRESS"))
+	}
+
+	secret := cfg.PrivateKey
+	if secret == "" {
+		secret = cfg.Mnemonic
+	}
+	if cfg.UsesKeystore() {
+		src, err := keystore.ParseSource(cfg.KeySource)
+		if err != nil {
+			return nil, err
+		}
+		store, name, err := keystore.Open(src, keystore.Options{
+			Service: "catalogctl",
+			EnvVar:  "SUI_PRIVATE_KEY",
+			File:    filepath.Join(config.GetConfigDir(), "keystore.json"),
+			Passphrase: func(path string, create bool) (string, error) {
+				return config.Passphrase(path)
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if secret, err = store.Get(name); err != nil {
+			return nil, fmt.Errorf("key_source %s: %w", cfg.KeySource, err)
+		}
+	}
+
+	switch {
+	case len(strings.Fields(secret)) > 1:
+		s, err := sui.SignerFromMnemonic(secret, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid mnemonic: %w", err)
+		}
+		return s, nil
+	case secret != "":
+		key, err := approval.KeyFromHex(secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private_key: %w", err)
+		}
+		return sui.NewSigner(key), nil
+	}
+	s, err := sui.SignerFromKeystore(sui.CLIConfigDir())
+	if err != nil {
+		return nil, fmt.Errorf("no signing key: set private_key or mnemonic in the catalogctl config, start catalogctl agent, or use a sui CLI keystore (%v)", err)
+	}
+	return s, nil
+}
+
+// suiCuratorCap returns "" if signer owns the catalog, otherwise the
+// CuratorCap it adds entries with
+func suiCuratorCap(client *sui.Client, cfg *config.Config, catalogID, signer string) (string, error) {
+	owner, err := client.CatalogOwner(catalogID)
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(owner, signer) {
+		return "", nil
+	}
+	capID, revoked, err := client.CuratorCap(cfg.TypePackageID(), catalogID, signer)
+	if err != nil {
+		return "", err
+	}
+	if capID != "" {
+		return capID, nil
+	}
+	if revoked > 0 {
+		return "", fmt.Errorf("all CuratorCaps of %s for this catalog were revoked; ask the owner (%s) for a new one", signer, owner)
+	}
+	return "", fmt.Errorf("%s is neither the catalog owner (%s) nor holds a CuratorCap for it", signer, owner)
+}
+
+// hashFile returns the path, size and SHA256 of a file for the plan
+func hashFile(path string) (plan.FileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return plan.FileInfo{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return plan.FileInfo{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	return plan.FileInfo{Path: path, Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/retro-crypto/sui/internal/config"
+	"github.com/retro-crypto/sui/internal/model"
+	"github.com/retro-crypto/sui/internal/validate"
+)
+
+// maxUploadSize bounds the game file accepted from the form
+const maxUploadSize = 64 << 20
+
+// nimiqTitleLimit is the longest title a Nimiq catalog entry holds
+const nimiqTitleLimit = 16
+
+// sessionCookie holds the session of the browser that used the login code
+const sessionCookie = "retro_publisher_session"
+
+// apiHeader must be set on API requests. Pages of other local origins can't
+// add it without a CORS preflight, which the server never answers.
+const apiHeader = "X-Retro-Publisher"
+
+// server serves the UI and the JSON API used by it
+type server struct {
+	session       string
+	configPath    string
+	nimiqUploader string
+
+	mu        sync.Mutex
+	loginCode string
+	jobs      map[string]*job
+	next      int
+
+	// suiMu serializes Sui publishes (see publishSui)
+	suiMu sync.Mutex
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	staticFS, _ := fs.Sub(static, "static")
+	mux.Handle("/", http.FileServer(http.FS(staticFS)))
+	mux.HandleFunc("/login", s.handleLogin)
+	mux.HandleFunc("/api/info", s.requireSession(s.handleInfo))
+	mux.HandleFunc("/api/publish", s.requireSession(s.handlePublish))
+	mux.HandleFunc("/api/jobs/", s.requireSession(s.handleJob))
+	return mux
+}
+
+// handleLogin trades the one-time code printed at startup for the session
+// cookie, so the secret in the URL is worthless once the browser opened it
+func (s *server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("code")
+	s.mu.Lock()
+	ok := s.loginCode != "" && subtle.ConstantTimeCompare([]byte(code), []byte(s.loginCode)) == 1
+	if ok {
+		s.loginCode = ""
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "This login link was already used or is invalid; restart retro-publisher for a new one.", http.StatusUnauthorized)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    s.session,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// requireSession rejects API requests without the session cookie and the
+// API header
+func (s *server) requireSession(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+		if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(s.session)) != 1 {
+			writeError(w, http.StatusUnauthorized, "not signed in; restart retro-publisher and open the URL it prints")
+			return
+		}
+		if r.Header.Get(apiHeader) != "1" {
+			writeError(w, http.StatusForbidden, apiHeader+" header required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleInfo returns what the form needs: platforms, the configured Sui
+// network and catalog, and Sui catalog aliases
+func (s *server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	info := map[string]interface{}{
+		"platforms": []string{"dos", "gb", "gbc", "nes", "snes"},
+	}
+
+	cfg, err := config.Load(s.configPath)
+	if err != nil {
+		info["config_error"] = err.Error()
+	} else {
+		info["sui_network"] = cfg.SuiNetwork
+		info["sui_catalog_id"] = cfg.CatalogID
+		info["config_source"] = cfg.Source
+		if aliases, err := config.LoadCatalogAliases(); err == nil {
+			var names []string
+			for _, name := range config.AliasNames(aliases) {
+				if alias := aliases[name]; alias.Network == "" || strings.EqualFold(alias.Network, cfg.SuiNetwork) {
+					names = append(names, name)
+				}
+			}
+			info["sui_aliases"] = names
+		}
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+// handlePublish validates the form, stores the uploaded file and starts a job
+func (s *server) handlePublish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid form: %v", err))
+		return
+	}
+
+	req := publishRequest{
+		Title:        strings.TrimSpace(r.FormValue("title")),
+		Slug:         strings.TrimSpace(r.FormValue("slug")),
+		Platform:     r.FormValue("platform"),
+		DryRun:       r.FormValue("dry_run") == "on",
+		Sui:          r.FormValue("sui") == "on",
+		SuiCatalog:   strings.TrimSpace(r.FormValue("sui_catalog")),
+		SuiVersion:   strings.TrimSpace(r.FormValue("sui_version")),
+		Nimiq:        r.FormValue("nimiq") == "on",
+		NimiqCatalog: strings.TrimSpace(r.FormValue("nimiq_catalog")),
+		NimiqSemver:  strings.TrimSpace(r.FormValue("nimiq_semver")),
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "a game file is required")
+		return
+	}
+	defer file.Close()
+
+	dir, err := os.MkdirTemp("", "retro-publisher-*")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	req.FilePath = filepath.Join(dir, filepath.Base(header.Filename))
+	out, err := os.Create(req.FilePath)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if _, err := io.Copy(out, file); err != nil {
+		out.Close()
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	out.Close()
+
+	s.mu.Lock()
+	s.next++
+	j := newJob(strconv.Itoa(s.next), req)
+	s.jobs[j.ID] = j
+	s.mu.Unlock()
+
+	go func() {
+		s.run(j)
+		os.RemoveAll(dir)
+	}()
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": j.ID})
+}
+
+// handleJob returns the state of a job
+func (s *server) handleJob(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/jobs/")
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no such job")
+		return
+	}
+	writeJSON(w, http.StatusOK, j.snapshot())
+}
+
+// publishRequest is a submitted publish form
+type publishRequest struct {
+	FilePath     string
+	Title        string
+	Slug         string
+	Platform     string
+	DryRun       bool
+	Sui          bool
+	SuiCatalog   string
+	SuiVersion   string
+	Nimiq        bool
+	NimiqCatalog string
+	NimiqSemver  string
+}
+
+// validate checks the form before anything is started
+func (p *publishRequest) validate() error {
+	if !p.Sui && !p.Nimiq {
+		return fmt.Errorf("choose at least one chain")
+	}
+	if p.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if _, err := model.ParsePlatform(p.Platform); err != nil {
+		return err
+	}
+
+	if p.Sui {
+		if p.Slug == "" {
+			return fmt.Errorf("slug is required for Sui")
+		}
+		if p.SuiCatalog != "" {
+			if strings.HasPrefix(p.SuiCatalog, "0x") {
+				if err := validate.ObjectID(p.SuiCatalog); err != nil {
+					return fmt.Errorf("invalid Sui catalog: %w", err)
+				}
+			} else if err := validate.AliasName(p.SuiCatalog); err != nil {
+				return fmt.Errorf("invalid Sui catalog: %w", err)
+			}
+		}
+		if p.SuiVersion != "" {
+			if _, err := strconv.ParseUint(p.SuiVersion, 10, 16); err != nil {
+				return fmt.Errorf("Sui version must be a number")
+			}
+		}
+	}
+
+	if p.Nimiq {
+		if len(p.Title) > nimiqTitleLimit {
+			return fmt.Errorf("Nimiq titles are limited to %d characters", nimiqTitleLimit)
+		}
+		if p.NimiqCatalog == "" {
+			return fmt.Errorf("Nimiq catalog is required")
+		}
+		if p.NimiqSemver == "" {
+			return fmt.Errorf("Nimiq version is required (e.g. 1.0.0)")
+		}
+		if platform, _ := model.ParsePlatform(p.Platform); platform == model.PlatformSNES {
+			return fmt.Errorf("Nimiq catalogs don't support SNES")
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -4,6 +4,7 @@ module cartridge_storage::cartridge {
     use sui::object::{Self, UID, ID};
     use sui::tx_context::TxContext;
     use sui::transfer;
+    use sui::dynamic_field as df;
 
     /// Platform enum values
     const PLATFORM_DOS: u8 = 0;
@@ -14,6 +15,9 @@ module cartridge_storage::cartridge {
 
     /// Error codes
     const E_INVALID_PLATFORM: u64 = 1;
+    const E_ASSET_EXISTS: u64 = 2;
+    const E_ASSET_NOT_FOUND: u64 = 3;
+    const E_DELTA_EXISTS: u64 = 4;
 
     /// A Cartridge represents a game stored on Walrus
     public struct Cartridge has key, store {
@@ -40,6 +44,40 @@ module cartridge_storage::cartridge {
         created_at_ms: u64,
     }
 
+    /// Dynamic field key for an extra asset (manual, soundtrack, ...)
+    /// (a struct key, so asset names never collide with other fields)
+    public struct AssetKey has copy, drop, store {
+        name: String,
+    }
+
+    /// An extra file shipped with the game, stored as its own Walrus blob
+    public struct Asset has copy, drop, store {
+        /// Asset name (e.g., "manual", "soundtrack")
+        name: String,
+        /// Walrus blob ID as bytes (256-bit)
+        blob_id: vector<u8>,
+        /// SHA256 hash of the file (for verification)
+        sha256: vector<u8>,
+        /// Size of the file in bytes
+        size_bytes: u64,
+    }
+
+    /// Dynamic field key marking a cartridge as a delta update
+    public struct DeltaKey has copy, drop, store {}
+
+    /// A delta cartridge's blob_id is a patch against the game file of
+    /// base_cartridge; sha256 and size_bytes still describe the patched file
+    public struct Delta has copy, drop, store {
+        /// Cartridge the patch applies to
+        base_cartridge: ID,
+        /// Patch format (e.g., "rcd1")
+        format: String,
+        /// SHA256 hash of the patch blob
+        patch_sha256: vector<u8>,
+        /// Size of the patch blob in bytes
+        patch_size_bytes: u64,
+    }
+
     /// Create a new Cartridge object
     public fun create(
         slug: String,
@@ -90,6 +128,71 @@ module cartridge_storage::cartridge {
         transfer::public_transfer(cartridge, tx_context::sender(ctx));
     }
 
+    /// Attach a named asset to a cartridge (owner only, as the cartridge is owned)
+    public entry fun add_asset(
+        cartridge: &mut Cartridge,
+        name: String,
+        blob_id: vector<u8>,
+        sha256: vector<u8>,
+        size_bytes: u64,
+    ) {
+        let key = AssetKey { name };
+        assert!(!df::exists_(&cartridge.id, key), E_ASSET_EXISTS);
+        df::add(&mut cartridge.id, key, Asset { name, blob_id, sha256, size_bytes });
+    }
+
+    /// Detach a named asset from a cartridge
+    public entry fun remove_asset(cartridge: &mut Cartridge, name: String) {
+        let key = AssetKey { name };
+        assert!(df::exists_(&cartridge.id, key), E_ASSET_NOT_FOUND);
+        let _: Asset = df::remove(&mut cartridge.id, key);
+    }
+
+    /// Check whether a cartridge has a named asset
+    public fun has_asset(cartridge: &Cartridge, name: String): bool {
+        df::exists_(&cartridge.id, AssetKey { name })
+    }
+
+    /// Get a named asset
+    public fun asset(cartridge: &Cartridge, name: String): &Asset {
+        assert!(df::exists_(&cartridge.id, AssetKey { name }), E_ASSET_NOT_FOUND);
+        df::borrow(&cartridge.id, AssetKey { name })
+    }
+
+    /// Asset getters
+    public fun asset_name(asset: &Asset): &String { &asset.name }
+    public fun asset_blob_id(asset: &Asset): &vector<u8> { &asset.blob_id }
+    public fun asset_sha256(asset: &Asset): &vector<u8> { &asset.sha256 }
+    public fun asset_size_bytes(asset: &Asset): u64 { asset.size_bytes }
+
+    /// Mark a cartridge's blob as a patch against a base cartridge
+    public entry fun set_delta(
+        cartridge: &mut Cartridge,
+        base_cartridge: ID,
+        format: String,
+        patch_sha256: vector<u8>,
+        patch_size_bytes: u64,
+    ) {
+        assert!(!df::exists_(&cartridge.id, DeltaKey {}), E_DELTA_EXISTS);
+        df::add(&mut cartridge.id, DeltaKey {}, Delta { base_cartridge, format, patch_sha256, patch_size_bytes });
+    }
+
+    /// Check whether a cartridge is a delta update
+    public fun is_delta(cartridge: &Cartridge): bool {
+        df::exists_(&cartridge.id, DeltaKey {})
+    }
+
+    /// Get the delta of a delta update
+    public fun delta(cartridge: &Cartridge): &Delta {
+        df::borrow(&cartridge.id, DeltaKey {})
+    }
+
+    /// Delta getters
+    public fun delta_base_cartridge(delta: &Delta): ID { delta.base_cartridge }
+    public fun delta_format(delta: &Delta): &String { &delta.format }
+    public fun delta_patch_sha256(delta: &Delta): &vector<u8> { &delta.patch_sha256 }
+    public fun delta_patch_size_bytes(delta: &Delta): u64 { delta.patch_size_bytes }
+
     /// Get cartridge ID
     public fun id(cartridge: &Cartridge): ID {
         object::uid_to_inner(&cartridge.id)
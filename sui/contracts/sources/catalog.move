@@ -6,11 +6,18 @@ module cartridge_storage::catalog {
     use sui::transfer;
     use sui::dynamic_field as df;
     use sui::event;
+    use sui::vec_set::{Self, VecSet};
 
     /// Error codes
     const E_NOT_OWNER: u64 = 1;
     const E_ENTRY_NOT_FOUND: u64 = 2;
     const E_ENTRY_EXISTS: u64 = 3;
+    const E_NOT_CURATOR: u64 = 4;
+    const E_COUNT_CHANGED: u64 = 5;
+    const E_TOO_MANY_TAGS: u64 = 6;
+
+    /// Maximum number of tags on one entry
+    const MAX_TAGS: u64 = 16;
 
     /// A Catalog is a curated list of game entries
     /// Entries are stored as dynamic fields keyed by slug
@@ -44,6 +51,29 @@ module cartridge_storage::catalog {
         cover_blob_id: vector<u8>,
     }
 
+    /// Capability allowing its holder to manage the entries of one catalog
+    public struct CuratorCap has key, store {
+        id: UID,
+        /// Catalog this capability applies to
+        catalog_id: ID,
+    }
+
+    /// Dynamic field key for the set of active curator caps
+    /// (a distinct type from the String slugs, so it never collides with entries)
+    public struct CuratorsKey has copy, drop, store {}
+
+    /// Dynamic field key for the extended metadata of the entry under slug
+    /// (a struct key, so it never collides with the entries themselves)
+    public struct EntryMetaKey has copy, drop, store {
+        slug: String,
+    }
+
+    /// Extended metadata kept next to a catalog entry
+    public struct EntryMeta has store, copy, drop {
+        /// Genre and feature tags (e.g., "platformer", "multiplayer")
+        tags: vector<String>,
+    }
+
     /// Events
     public struct CatalogCreated has copy, drop {
         catalog_id: ID,
@@ -68,6 +98,29 @@ module cartridge_storage::catalog {
         new_cartridge_id: ID,
     }
 
+    public struct CuratorCapMinted has copy, drop {
+        catalog_id: ID,
+        cap_id: ID,
+        recipient: address,
+    }
+
+    public struct CuratorCapRevoked has copy, drop {
+        catalog_id: ID,
+        cap_id: ID,
+    }
+
+    public struct EntryTagsSet has copy, drop {
+        catalog_id: ID,
+        slug: String,
+        tags: vector<String>,
+    }
+
+    public struct CountFixed has copy, drop {
+        catalog_id: ID,
+        old_count: u64,
+        new_count: u64,
+    }
+
     /// Create a new empty Catalog
     public entry fun create_catalog(
         name: String,
@@ -107,6 +160,37 @@ module cartridge_storage::catalog {
         ctx: &mut TxContext,
     ) {
         assert!(tx_context::sender(ctx) == catalog.owner, E_NOT_OWNER);
+        insert_entry(catalog, slug, cartridge_id, title, platform, size_bytes, emulator_core, version, cover_blob_id);
+    }
+
+    /// Add an entry to the catalog (curator cap holders)
+    public entry fun add_entry_with_cap(
+        catalog: &mut Catalog,
+        cap: &CuratorCap,
+        slug: String,
+        cartridge_id: ID,
+        title: String,
+        platform: u8,
+        size_bytes: u64,
+        emulator_core: String,
+        version: u16,
+        cover_blob_id: vector<u8>,
+    ) {
+        assert_curator(catalog, cap);
+        insert_entry(catalog, slug, cartridge_id, title, platform, size_bytes, emulator_core, version, cover_blob_id);
+    }
+
+    fun insert_entry(
+        catalog: &mut Catalog,
+        slug: String,
+        cartridge_id: ID,
+        title: String,
+        platform: u8,
+        size_bytes: u64,
+        emulator_core: String,
+        version: u16,
+        cover_blob_id: vector<u8>,
+    ) {
         assert!(!df::exists_(&catalog.id, slug), E_ENTRY_EXISTS);
         
         let entry = CatalogEntry {
@@ -174,10 +258,27 @@ module cartridge_storage::catalog {
         ctx: &mut TxContext,
     ) {
         assert!(tx_context::sender(ctx) == catalog.owner, E_NOT_OWNER);
+        delete_entry(catalog, slug);
+    }
+
+    /// Remove an entry from the catalog (curator cap holders)
+    public entry fun remove_entry_with_cap(
+        catalog: &mut Catalog,
+        cap: &CuratorCap,
+        slug: String,
+    ) {
+        assert_curator(catalog, cap);
+        delete_entry(catalog, slug);
+    }
+
+    fun delete_entry(catalog: &mut Catalog, slug: String) {
         assert!(df::exists_(&catalog.id, slug), E_ENTRY_NOT_FOUND);
         
         let _entry: CatalogEntry = df::remove(&mut catalog.id, slug);
         catalog.count = catalog.count - 1;
+        if (df::exists_(&catalog.id, EntryMetaKey { slug })) {
+            let _meta: EntryMeta = df::remove(&mut catalog.id, EntryMetaKey { slug });
+        };
         
         event::emit(EntryRemoved {
             catalog_id: object::uid_to_inner(&catalog.id),
@@ -185,6 +286,97 @@ module cartridge_storage::catalog {
         });
     }
 
+    /// Replace the tags of an entry; an empty list removes them (owner only)
+    public entry fun set_entry_tags(
+        catalog: &mut Catalog,
+        slug: String,
+        tags: vector<String>,
+        ctx: &mut TxContext,
+    ) {
+        assert!(tx_context::sender(ctx) == catalog.owner, E_NOT_OWNER);
+        store_entry_tags(catalog, slug, tags);
+    }
+
+    /// Replace the tags of an entry; an empty list removes them (curator cap holders)
+    public entry fun set_entry_tags_with_cap(
+        catalog: &mut Catalog,
+        cap: &CuratorCap,
+        slug: String,
+        tags: vector<String>,
+    ) {
+        assert_curator(catalog, cap);
+        store_entry_tags(catalog, slug, tags);
+    }
+
+    fun store_entry_tags(catalog: &mut Catalog, slug: String, tags: vector<String>) {
+        assert!(df::exists_(&catalog.id, slug), E_ENTRY_NOT_FOUND);
+        assert!(vector::length(&tags) <= MAX_TAGS, E_TOO_MANY_TAGS);
+        
+        let key = EntryMetaKey { slug };
+        if (df::exists_(&catalog.id, key)) {
+            let _old: EntryMeta = df::remove(&mut catalog.id, key);
+        };
+        if (!vector::is_empty(&tags)) {
+            df::add(&mut catalog.id, key, EntryMeta { tags });
+        };
+        
+        event::emit(EntryTagsSet {
+            catalog_id: object::uid_to_inner(&catalog.id),
+            slug,
+            tags,
+        });
+    }
+
+    /// Mint a curator cap for this catalog and send it to recipient (owner only)
+    public entry fun mint_curator_cap(
+        catalog: &mut Catalog,
+        recipient: address,
+        ctx: &mut TxContext,
+    ) {
+        assert!(tx_context::sender(ctx) == catalog.owner, E_NOT_OWNER);
+        
+        let catalog_id = object::uid_to_inner(&catalog.id);
+        let cap = CuratorCap { id: object::new(ctx), catalog_id };
+        let cap_id = object::id(&cap);
+        
+        if (!df::exists_(&catalog.id, CuratorsKey {})) {
+            df::add(&mut catalog.id, CuratorsKey {}, vec_set::empty<ID>());
+        };
+        let curators: &mut VecSet<ID> = df::borrow_mut(&mut catalog.id, CuratorsKey {});
+        vec_set::insert(curators, cap_id);
+        
+        event::emit(CuratorCapMinted { catalog_id, cap_id, recipient });
+        
+        transfer::public_transfer(cap, recipient);
+    }
+
+    /// Revoke a curator cap; the cap object remains but no longer works (owner only)
+    public entry fun revoke_curator_cap(
+        catalog: &mut Catalog,
+        cap_id: ID,
+        ctx: &mut TxContext,
+    ) {
+        assert!(tx_context::sender(ctx) == catalog.owner, E_NOT_OWNER);
+        assert!(df::exists_(&catalog.id, CuratorsKey {}), E_NOT_CURATOR);
+        
+        let curators: &mut VecSet<ID> = df::borrow_mut(&mut catalog.id, CuratorsKey {});
+        assert!(vec_set::contains(curators, &cap_id), E_NOT_CURATOR);
+        vec_set::remove(curators, &cap_id);
+        
+        event::emit(CuratorCapRevoked {
+            catalog_id: object::uid_to_inner(&catalog.id),
+            cap_id,
+        });
+    }
+
+    /// Abort unless cap is an active curator cap of this catalog
+    fun assert_curator(catalog: &Catalog, cap: &CuratorCap) {
+        assert!(cap.catalog_id == object::uid_to_inner(&catalog.id), E_NOT_CURATOR);
+        assert!(df::exists_(&catalog.id, CuratorsKey {}), E_NOT_CURATOR);
+        let curators: &VecSet<ID> = df::borrow(&catalog.id, CuratorsKey {});
+        assert!(vec_set::contains(curators, &object::id(cap)), E_NOT_CURATOR);
+    }
+
     /// Check if an entry exists
     public fun has_entry(catalog: &Catalog, slug: String): bool {
         df::exists_(&catalog.id, slug)
@@ -196,6 +388,16 @@ module cartridge_storage::catalog {
         df::borrow(&catalog.id, slug)
     }
 
+    /// Get the tags of an entry (empty if it has none)
+    public fun entry_tags(catalog: &Catalog, slug: String): vector<String> {
+        let key = EntryMetaKey { slug };
+        if (!df::exists_(&catalog.id, key)) {
+            return vector::empty()
+        };
+        let meta: &EntryMeta = df::borrow(&catalog.id, key);
+        meta.tags
+    }
+
     /// Getters for Catalog
     public fun id(catalog: &Catalog): ID { object::uid_to_inner(&catalog.id) }
     public fun owner(catalog: &Catalog): address { catalog.owner }
@@ -203,6 +405,9 @@ module cartridge_storage::catalog {
     public fun description(catalog: &Catalog): &String { &catalog.description }
     public fun count(catalog: &Catalog): u64 { catalog.count }
 
+    /// Getters for CuratorCap
+    public fun cap_catalog_id(cap: &CuratorCap): ID { cap.catalog_id }
+
     /// Getters for CatalogEntry
     public fun entry_cartridge_id(entry: &CatalogEntry): ID { entry.cartridge_id }
     public fun entry_title(entry: &CatalogEntry): &String { &entry.title }
@@ -221,5 +426,26 @@ module cartridge_storage::catalog {
         assert!(tx_context::sender(ctx) == catalog.owner, E_NOT_OWNER);
         catalog.owner = new_owner;
     }
+
+    /// Set the entry count to the number of entries counted off-chain (owner
+    /// only). Dynamic fields can't be enumerated on-chain, so the owner passes
+    /// the real number; expected_count must match the stored count, so an
+    /// entry added or removed in the meantime aborts the fix.
+    public entry fun fix_count(
+        catalog: &mut Catalog,
+        expected_count: u64,
+        count: u64,
+        ctx: &mut TxContext,
+    ) {
+        assert!(tx_context::sender(ctx) == catalog.owner, E_NOT_OWNER);
+        assert!(catalog.count == expected_count, E_COUNT_CHANGED);
+        catalog.count = count;
+
+        event::emit(CountFixed {
+            catalog_id: object::uid_to_inner(&catalog.id),
+            old_count: expected_count,
+            new_count: count,
+        });
+    }
 }
 
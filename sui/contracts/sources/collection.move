@@ -0,0 +1,109 @@
+/// Collection module - player-curated lists of cartridges (favorites, shelves)
+module cartridge_storage::collection {
+    use std::string::String;
+    use sui::object::{Self, UID, ID};
+    use sui::tx_context::TxContext;
+    use sui::transfer;
+    use sui::event;
+
+    /// Error codes
+    const E_CARTRIDGE_EXISTS: u64 = 1;
+    const E_CARTRIDGE_NOT_FOUND: u64 = 2;
+    const E_COLLECTION_FULL: u64 = 3;
+
+    /// Maximum cartridges in a collection
+    const MAX_CARTRIDGES: u64 = 500;
+
+    /// A Collection is a player's own list of cartridges. It is an owned
+    /// object: only its owner can change it, and anyone can read it.
+    public struct Collection has key, store {
+        id: UID,
+        /// Collection name (e.g., "Favorites", "Couch co-op")
+        name: String,
+        /// Brief description
+        description: String,
+        /// Cartridge object IDs, in the order they were added
+        cartridges: vector<ID>,
+    }
+
+    /// Events
+    public struct CollectionCreated has copy, drop {
+        collection_id: ID,
+        owner: address,
+        name: String,
+    }
+
+    public struct CartridgeCollected has copy, drop {
+        collection_id: ID,
+        cartridge_id: ID,
+    }
+
+    public struct CartridgeUncollected has copy, drop {
+        collection_id: ID,
+        cartridge_id: ID,
+    }
+
+    /// Create an empty collection owned by the sender
+    public entry fun create_collection(
+        name: String,
+        description: String,
+        ctx: &mut TxContext,
+    ) {
+        let collection = Collection {
+            id: object::new(ctx),
+            name,
+            description,
+            cartridges: vector::empty(),
+        };
+
+        event::emit(CollectionCreated {
+            collection_id: object::uid_to_inner(&collection.id),
+            owner: tx_context::sender(ctx),
+            name: collection.name,
+        });
+
+        transfer::public_transfer(collection, tx_context::sender(ctx));
+    }
+
+    /// Append a cartridge to a collection (owner only, as the collection is owned)
+    public entry fun add_cartridge(
+        collection: &mut Collection,
+        cartridge_id: ID,
+    ) {
+        assert!(!vector::contains(&collection.cartridges, &cartridge_id), E_CARTRIDGE_EXISTS);
+        assert!(vector::length(&collection.cartridges) < MAX_CARTRIDGES, E_COLLECTION_FULL);
+        vector::push_back(&mut collection.cartridges, cartridge_id);
+
+        event::emit(CartridgeCollected {
+            collection_id: object::uid_to_inner(&collection.id),
+            cartridge_id,
+        });
+    }
+
+    /// Remove a cartridge from a collection, keeping the order of the rest
+    public entry fun remove_cartridge(
+        collection: &mut Collection,
+        cartridge_id: ID,
+    ) {
+        let (found, index) = vector::index_of(&collection.cartridges, &cartridge_id);
+        assert!(found, E_CARTRIDGE_NOT_FOUND);
+        vector::remove(&mut collection.cartridges, index);
+
+        event::emit(CartridgeUncollected {
+            collection_id: object::uid_to_inner(&collection.id),
+            cartridge_id,
+        });
+    }
+
+    /// Check if a collection lists a cartridge
+    public fun contains(collection: &Collection, cartridge_id: ID): bool {
+        vector::contains(&collection.cartridges, &cartridge_id)
+    }
+
+    /// Getters
+    public fun id(collection: &Collection): ID { object::uid_to_inner(&collection.id) }
+    public fun name(collection: &Collection): &String { &collection.name }
+    public fun description(collection: &Collection): &String { &collection.description }
+    public fun cartridges(collection: &Collection): &vector<ID> { &collection.cartridges }
+    public fun length(collection: &Collection): u64 { vector::length(&collection.cartridges) }
+}
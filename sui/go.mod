@@ -2,12 +2,19 @@ module github.com/retro-crypto/sui
 
 go 1.21
 
-require github.com/spf13/cobra v1.8.0
+require (
+	github.com/retro-crypto/shared v0.0.0
+	github.com/spf13/cobra v1.8.0
+	github.com/spf13/pflag v1.0.5
+	golang.org/x/crypto v0.21.0
+)
 
 require (
 	github.com/akamensky/base58 v0.0.0-20210829145138-ce8bf8802e8f // indirect
 	github.com/btcsuite/btcutil v1.0.2 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/mr-tron/base58 v1.2.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/sys v0.18.0 // indirect
 )
+
+replace github.com/retro-crypto/shared => ../shared
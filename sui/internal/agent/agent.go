@@ -0,0 +1,320 @@
+// Package agent implements the signing agent: a small process that holds
+// decrypted Sui keys and signs transactions for CLIs over a unix socket, so
+// long runs never load the raw keys themselves (like ssh-agent). Each
+// request is one JSON line answered by one JSON line.
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/sui"
+)
+
+// SocketEnv points CLIs at a running agent
+const SocketEnv = "CATALOGCTL_AGENT_SOCK"
+
+// Request operations
+const (
+	OpAddresses  = "addresses"
+	OpSign       = "sign"
+	OpPassphrase = "passphrase"
+	OpStop       = "stop"
+)
+
+var (
+	// requestTimeout bounds how long a request may take to arrive, and how
+	// long clients wait for answers that need no confirmation
+	requestTimeout = 10 * time.Second
+	// signTimeout bounds how long clients wait for a signature, which may
+	// wait for a confirmation or a touch of the security key on the agent
+	signTimeout = 5 * time.Minute
+)
+
+// Request is sent by clients
+type Request struct {
+	Op      string `json:"op"`
+	Address string `json:"address,omitempty"`
+	TxBytes string `json:"tx_bytes,omitempty"`
+	// File is the encrypted file whose passphrase is asked for
+	File string `json:"file,omitempty"`
+}
+
+// Response answers a Request
+type Response struct {
+	Addresses  []string `json:"addresses,omitempty"`
+	Signature  string   `json:"signature,omitempty"`
+	Passphrase string   `json:"passphrase,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// DefaultSocket returns the socket path used when none is given:
+// $XDG_RUNTIME_DIR/catalogctl-agent.sock, or a per-user directory in the
+// temp dir
+func DefaultSocket() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "catalogctl-agent.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("catalogctl-agent-%d", os.Getuid()), "agent.sock")
+}
+
+// Server answers signing requests with the keys it holds
+type Server struct {
+	keys map[string]*sui.Signer
+	// Passphrases, if set, are handed to clients asking for the passphrase
+	// of an encrypted config or keystore file, by absolute path
+	Passphrases map[string]string
+	// Confirm, if set, is asked before every signature; returning false
+	// refuses the request
+	Confirm func(address, txBytes string) bool
+	// Reauth, if set, is asked after every ReauthEvery signatures, before
+	// the next one; an error refuses the request
+	Reauth      func() error
+	ReauthEvery int
+
+	mu         sync.Mutex // serializes signing (and confirmation prompts)
+	signatures int
+	listener   net.Listener
+	closed     atomic.Bool
+}
+
+// NewServer creates an agent holding signers
+func NewServer(signers ...*sui.Signer) *Server {
+	keys := make(map[string]*sui.Signer, len(signers))
+	for _, s := range signers {
+		keys[s.Address] = s
+	}
+	return &Server{keys: keys}
+}
+
+// Listen creates the socket, usable by the current user only, in a
+// directory only the current user can access. A stale socket left by an
+// agent that died is replaced; a live one is an error.
+func (s *Server) Listen(path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := privateDir(dir); err != nil {
+		return fmt.Errorf("refusing to put the agent socket in %s: %w", dir, err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return fmt.Errorf("an agent is already listening on %s", path)
+		}
+		os.Remove(path)
+	}
+	l, err := listenUnix(path)
+	if err != nil {
+		return err
+	}
+	s.listener = l
+	return nil
+}
+
+// Serve answers requests until Close is called or a client sends stop
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if s.closed.Load() {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops the agent and forgets its keys
+func (s *Server) Close() error {
+	if s.closed.Swap(true) {
+		return nil
+	}
+	s.mu.Lock()
+	s.keys = nil
+	s.Passphrases = nil
+	s.mu.Unlock()
+	return s.listener.Close()
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(requestTimeout))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	var req Request
+	var resp Response
+	if err := json.Unmarshal(line, &req); err != nil {
+		resp.Error = "invalid request: " + err.Error()
+	} else {
+		resp = s.answer(req)
+	}
+	data, _ := json.Marshal(resp)
+	conn.SetWriteDeadline(time.Now().Add(requestTimeout))
+	conn.Write(append(data, '\n'))
+	if req.Op == OpStop && resp.Error == "" {
+		s.Close()
+	}
+}
+
+func (s *Server) answer(req Request) Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch req.Op {
+	case OpAddresses:
+		var addresses []string
+		for addr := range s.keys {
+			addresses = append(addresses, addr)
+		}
+		return Response{Addresses: addresses}
+	case OpSign:
+		key := s.keys[req.Address]
+		if key == nil {
+			return Response{Error: fmt.Sprintf("the agent holds no key for %s", req.Address)}
+		}
+		if s.Confirm != nil && !s.Confirm(req.Address, req.TxBytes) {
+			return Response{Error: "signature refused on the agent"}
+		}
+		if s.Reauth != nil && s.ReauthEvery > 0 && s.signatures > 0 && s.signatures%s.ReauthEvery == 0 {
+			if err := s.Reauth(); err != nil {
+				return Response{Error: err.Error()}
+			}
+		}
+		signature, err := key.SignTransaction(req.TxBytes)
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		s.signatures++
+		return Response{Signature: signature}
+	case OpPassphrase:
+		passphrase, ok := s.Passphrases[req.File]
+		if !ok {
+			return Response{Error: fmt.Sprintf("the agent holds no passphrase for %s", req.File)}
+		}
+		return Response{Passphrase: passphrase}
+	case OpStop:
+		return Response{}
+	}
+	return Response{Error: fmt.Sprintf("unknown op %q", req.Op)}
+}
+
+// Client talks to an agent
+type Client struct {
+	socket string
+}
+
+// NewClient returns a client for the agent listening on socket
+func NewClient(socket string) *Client {
+	return &Client{socket: socket}
+}
+
+// Addresses lists the addresses the agent can sign for
+func (c *Client) Addresses() ([]string, error) {
+	resp, err := c.do(Request{Op: OpAddresses})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Addresses, nil
+}
+
+// Passphrase returns the passphrase of an encrypted file the agent was
+// started with, if it shares passphrases
+func (c *Client) Passphrase(file string) (string, error) {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	resp, err := c.do(Request{Op: OpPassphrase, File: file})
+	if err != nil {
+		return "", err
+	}
+	return resp.Passphrase, nil
+}
+
+// Stop asks the agent to forget its keys and exit
+func (c *Client) Stop() error {
+	_, err := c.do(Request{Op: OpStop})
+	return err
+}
+
+// Signer returns a sui.TxSigner for one of the agent's addresses, or for its
+// only one if address is empty
+func (c *Client) Signer(address string) (*RemoteSigner, error) {
+	addresses, err := c.Addresses()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addresses {
+		if address == "" && len(addresses) == 1 || a == address {
+			return &RemoteSigner{client: c, address: a}, nil
+		}
+	}
+	if address == "" {
+		return nil, fmt.Errorf("the agent holds %d keys; choose one", len(addresses))
+	}
+	return nil, fmt.Errorf("the agent holds no key for %s", address)
+}
+
+func (c *Client) do(req Request) (*Response, error) {
+	conn, err := net.Dial("unix", c.socket)
+	if err != nil {
+		return nil, fmt.Errorf("signing agent not reachable at %s (start it with `catalogctl agent start`): %w", c.socket, err)
+	}
+	defer conn.Close()
+	// A hung agent must not block the command forever
+	timeout := requestTimeout
+	if req.Op == OpSign {
+		timeout = signTimeout
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	data, _ := json.Marshal(req)
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("signing agent: %w", err)
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return nil, fmt.Errorf("signing agent at %s didn't answer within %s", c.socket, timeout)
+		}
+		return nil, fmt.Errorf("signing agent closed the connection: %w", err)
+	}
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response from signing agent: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("signing agent: %s", resp.Error)
+	}
+	return &resp, nil
+}
+
+// RemoteSigner signs through the agent; it implements sui.TxSigner
+type RemoteSigner struct {
+	client  *Client
+	address string
+}
+
+// SuiAddress implements sui.TxSigner
+func (r *RemoteSigner) SuiAddress() string { return r.address }
+
+// SignTransaction implements sui.TxSigner
+func (r *RemoteSigner) SignTransaction(txBytes string) (string, error) {
+	resp, err := r.client.do(Request{Op: OpSign, Address: r.address, TxBytes: txBytes})
+	if err != nil {
+		return "", err
+	}
+	return resp.Signature, nil
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+
+package agent
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// privateDir checks that dir is a real directory owned by the current user
+// and closed to everyone else, so nobody else can replace the socket in it
+// or have created it for us (e.g. /tmp/catalogctl-agent-<uid>)
+func privateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("can't read the owner of %s", dir)
+	}
+	if int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is owned by uid %d, not by you", dir, st.Uid)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s is accessible by other users (mode %o); chmod 700 it", dir, info.Mode().Perm())
+	}
+	return nil
+}
+
+// listenUnix creates the socket with mode 0600 from the start: the umask
+// is narrowed around the bind, so there is no window in which other users
+// could connect
+func listenUnix(path string) (net.Listener, error) {
+	old := syscall.Umask(0177)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,100 @@
+//go:build !windows
+
+package agent
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenPrivate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "agent")
+	path := filepath.Join(dir, "agent.sock")
+	s := NewServer()
+	if err := s.Listen(path); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer s.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket mode = %o, want 600", perm)
+	}
+}
+
+func TestListenRefusesSharedDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	err := NewServer().Listen(filepath.Join(dir, "agent.sock"))
+	if err == nil || !strings.Contains(err.Error(), "accessible by other users") {
+		t.Fatalf("Listen in a 0755 directory: error = %v", err)
+	}
+
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewServer().Listen(filepath.Join(link, "agent.sock")); err == nil {
+		t.Fatal("Listen accepted a symlinked directory")
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "agent")
+	os.Mkdir(dir, 0700)
+	path := filepath.Join(dir, "agent.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	// An agent that accepts but never answers
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	defer func(d time.Duration) { requestTimeout = d }(requestTimeout)
+	requestTimeout = 100 * time.Millisecond
+	start := time.Now()
+	_, err = NewClient(path).Addresses()
+	if err == nil || !strings.Contains(err.Error(), "didn't answer") {
+		t.Fatalf("Addresses error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("client waited %s", elapsed)
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "agent")
+	path := filepath.Join(dir, "agent.sock")
+	s := NewServer()
+	s.Passphrases = map[string]string{filepath.Join(dir, "config.json"): "hunter2"}
+	if err := s.Listen(path); err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve()
+	defer s.Close()
+
+	client := NewClient(path)
+	if p, err := client.Passphrase(filepath.Join(dir, ".", "config.json")); err != nil || p != "hunter2" {
+		t.Errorf("Passphrase = %q, %v", p, err)
+	}
+	if _, err := client.Passphrase(filepath.Join(dir, "keystore.json")); err == nil || !strings.Contains(err.Error(), "no passphrase") {
+		t.Errorf("Passphrase of an unknown file: error = %v", err)
+	}
+}
@@ -0,0 +1,13 @@
+package agent
+
+import "net"
+
+// privateDir accepts any directory; on Windows the socket's access is
+// governed by the directory's ACL, which is the user's profile by default
+func privateDir(dir string) error {
+	return nil
+}
+
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,242 @@
+// Package approval implements two-operator approval of publish plans. A
+// request wraps a plan together with Ed25519 signatures over its digest,
+// a single-use nonce and an expiry time.
+package approval
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/plan"
+)
+
+// Version is the version of the request file format
+const Version = 2
+
+// signingPrefix domain-separates approval signatures from anything else the
+// key might sign
+const signingPrefix = "catalogctl-approval:v2:"
+
+// DefaultLifetime is how long a request can be executed after it was created
+const DefaultLifetime = 48 * time.Hour
+
+// Request is a plan waiting for approval
+type Request struct {
+	Version    int    `json:"version"`
+	PlanDigest string `json:"plan_digest"`
+	// Nonce makes each request unique, so an executed request can be told
+	// apart from a new one for the same plan (see Ledger)
+	Nonce       string      `json:"nonce"`
+	RequestedBy string      `json:"requested_by"`
+	RequestedAt time.Time   `json:"requested_at"`
+	ExpiresAt   time.Time   `json:"expires_at"`
+	Plan        *plan.Plan  `json:"plan"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is one operator's signature over the plan digest
+type Signature struct {
+	PublicKey string    `json:"public_key"`
+	Signature string    `json:"signature"`
+	SignedAt  time.Time `json:"signed_at"`
+}
+
+// KeyFromHex parses an Ed25519 private key given as a hex encoded 32-byte
+// seed (optionally prefixed with 0x or the Sui scheme flag byte 0x00)
+func KeyFromHex(s string) (ed25519.PrivateKey, error) {
+	seed, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("private key must be hex encoded: %w", err)
+	}
+	if len(seed) == ed25519.SeedSize+1 && seed[0] == 0x00 {
+		seed = seed[1:]
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("private key must be a %d-byte Ed25519 seed (got %d bytes)", ed25519.SeedSize, len(seed))
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// PublicKeyHex returns the hex encoded public key of a private key
+func PublicKeyHex(key ed25519.PrivateKey) string {
+	return hex.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+// NewRequest creates a request for the plan, signed by the requester, that
+// expires after lifetime
+func NewRequest(p *plan.Plan, key ed25519.PrivateKey, lifetime time.Duration) (*Request, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	now := time.Now().UTC()
+	req := &Request{
+		Version:     Version,
+		PlanDigest:  p.Digest(),
+		Nonce:       hex.EncodeToString(nonce),
+		RequestedBy: PublicKeyHex(key),
+		RequestedAt: now,
+		ExpiresAt:   now.Add(lifetime).Truncate(time.Second),
+		Plan:        p,
+	}
+	req.sign(key)
+	return req, nil
+}
+
+// Approve adds the key's signature to the request. The requester can't
+// approve their own request, each key signs only once, and expired
+// requests can't be approved.
+func (r *Request) Approve(key ed25519.PrivateKey) error {
+	if err := r.checkDigest(); err != nil {
+		return err
+	}
+	if err := r.CheckFresh(time.Now()); err != nil {
+		return err
+	}
+	pub := PublicKeyHex(key)
+	if pub == normalizeKey(r.RequestedBy) {
+		return fmt.Errorf("the requester can't approve their own request; a second operator must approve it")
+	}
+	for _, sig := range r.Signatures {
+		if normalizeKey(sig.PublicKey) == pub {
+			return fmt.Errorf("request already approved by %s", pub)
+		}
+	}
+	r.sign(key)
+	return nil
+}
+
+// CheckFresh returns an error if the request has expired at now
+func (r *Request) CheckFresh(now time.Time) error {
+	if !now.Before(r.ExpiresAt) {
+		return fmt.Errorf("request expired at %s; create and approve a new one", r.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
+
+// Verify checks that the request's plan is untouched, that the requester
+// signed it and that at least one other operator from approvers approved
+// it. It doesn't check the expiry (see CheckFresh).
+func (r *Request) Verify(approvers []string) error {
+	if err := r.checkDigest(); err != nil {
+		return err
+	}
+
+	allowed := make(map[string]bool)
+	for _, a := range approvers {
+		allowed[normalizeKey(a)] = true
+	}
+
+	requester := normalizeKey(r.RequestedBy)
+	requesterSigned := false
+	approvals := 0
+	for _, sig := range r.Signatures {
+		pub := normalizeKey(sig.PublicKey)
+		if !r.validSignature(sig) {
+			return fmt.Errorf("invalid signature from %s", pub)
+		}
+		if pub == requester {
+			requesterSigned = true
+			continue
+		}
+		if allowed[pub] {
+			approvals++
+		}
+	}
+
+	if !requesterSigned {
+		return fmt.Errorf("request is not signed by its requester %s", requester)
+	}
+	if !allowed[requester] {
+		return fmt.Errorf("requester %s is not in the approvers list", requester)
+	}
+	if approvals == 0 {
+		return fmt.Errorf("request is pending: it needs approval from a second operator (catalogctl approve --request FILE)")
+	}
+	return nil
+}
+
+// Save writes the request as indented JSON
+func (r *Request) Save(filename string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write request file: %w", err)
+	}
+	return nil
+}
+
+// Load reads a request written by Save
+func Load(filename string) (*Request, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request file: %w", err)
+	}
+	var r Request
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse request file: %w", err)
+	}
+	if r.Version != Version {
+		return nil, fmt.Errorf("unsupported request version %d (expected %d)", r.Version, Version)
+	}
+	if r.Plan == nil {
+		return nil, fmt.Errorf("request file contains no plan")
+	}
+	if nonce, err := hex.DecodeString(r.Nonce); err != nil || len(nonce) != 16 {
+		return nil, fmt.Errorf("request file has an invalid nonce %q", r.Nonce)
+	}
+	if r.ExpiresAt.IsZero() {
+		return nil, fmt.Errorf("request file has no expiry")
+	}
+	return &r, nil
+}
+
+// signedMessage is what each signature covers: the plan digest, the nonce
+// and the expiry, so neither can be changed without invalidating them
+func (r *Request) signedMessage() []byte {
+	return []byte(fmt.Sprintf("%s%s:%s:%d", signingPrefix, r.PlanDigest, r.Nonce, r.ExpiresAt.Unix()))
+}
+
+// sign appends the key's signature over the request
+func (r *Request) sign(key ed25519.PrivateKey) {
+	sig := ed25519.Sign(key, r.signedMessage())
+	r.Signatures = append(r.Signatures, Signature{
+		PublicKey: PublicKeyHex(key),
+		Signature: hex.EncodeToString(sig),
+		SignedAt:  time.Now().UTC(),
+	})
+}
+
+// validSignature reports whether sig is a valid signature over the request
+func (r *Request) validSignature(sig Signature) bool {
+	pub, err := hex.DecodeString(normalizeKey(sig.PublicKey))
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	signature, err := hex.DecodeString(sig.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(pub, r.signedMessage(), signature)
+}
+
+// checkDigest makes sure the plan wasn't edited after the request was created
+func (r *Request) checkDigest() error {
+	if r.Plan.Digest() != r.PlanDigest {
+		return fmt.Errorf("plan was modified after the request was created (digest mismatch)")
+	}
+	return nil
+}
+
+// normalizeKey lowercases a hex key and strips a 0x prefix
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.TrimPrefix(key, "0x"))
+}
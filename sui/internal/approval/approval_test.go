@@ -0,0 +1,103 @@
+package approval
+
+import (
+	"crypto/ed25519"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/plan"
+)
+
+func testKey(b byte) ed25519.PrivateKey {
+	seed := make([]byte, ed25519.SeedSize)
+	seed[0] = b
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+// approvedRequest returns a request signed by a requester and approved by a
+// second operator, and the approvers list accepting both
+func approvedRequest(t *testing.T, lifetime time.Duration) (*Request, []string) {
+	t.Helper()
+	requester, approver := testKey(1), testKey(2)
+	req, err := NewRequest(&plan.Plan{Version: 1, Network: "mainnet", PackageID: "0x1"}, requester, lifetime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Approve(approver); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	return req, []string{PublicKeyHex(requester), PublicKeyHex(approver)}
+}
+
+func TestSignaturesCoverNonceAndExpiry(t *testing.T) {
+	req, approvers := approvedRequest(t, time.Hour)
+	if err := req.Verify(approvers); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	extended := *req
+	extended.ExpiresAt = req.ExpiresAt.Add(24 * time.Hour)
+	if err := extended.Verify(approvers); err == nil {
+		t.Error("Verify accepted a request whose expiry was extended")
+	}
+	renonced := *req
+	renonced.Nonce = strings.Repeat("00", 16)
+	if err := renonced.Verify(approvers); err == nil {
+		t.Error("Verify accepted a request whose nonce was replaced")
+	}
+}
+
+func TestRequestExpiry(t *testing.T) {
+	req, _ := approvedRequest(t, time.Hour)
+	if err := req.CheckFresh(time.Now()); err != nil {
+		t.Errorf("CheckFresh: %v", err)
+	}
+	if err := req.CheckFresh(req.ExpiresAt); err == nil {
+		t.Error("CheckFresh accepted an expired request")
+	}
+
+	stale, _ := approvedRequest(t, time.Hour)
+	stale.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := stale.Approve(testKey(3)); err == nil {
+		t.Error("Approve accepted an expired request")
+	}
+}
+
+func TestLedgerSingleUse(t *testing.T) {
+	ledger := NewLedger(filepath.Join(t.TempDir(), "approvals"))
+	req, _ := approvedRequest(t, time.Hour)
+	now := time.Now()
+
+	if resumed, err := ledger.Begin(req, now); err != nil || resumed {
+		t.Fatalf("Begin = %v, %v; want a new run", resumed, err)
+	}
+	// A failed run is resumed, even once the request has expired
+	if resumed, err := ledger.Begin(req, req.ExpiresAt.Add(time.Hour)); err != nil || !resumed {
+		t.Fatalf("Begin after a failed run = %v, %v; want a resumed run", resumed, err)
+	}
+	if err := ledger.Finish(req, now); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if _, err := ledger.Begin(req, now); err == nil || !strings.Contains(err.Error(), "already executed") {
+		t.Errorf("Begin of an executed request = %v, want a replay error", err)
+	}
+
+	// A new request for the same plan gets a new nonce
+	again, _ := approvedRequest(t, time.Hour)
+	if again.Nonce == req.Nonce {
+		t.Fatal("two requests share a nonce")
+	}
+	if _, err := ledger.Begin(again, now); err != nil {
+		t.Errorf("Begin of a new request: %v", err)
+	}
+}
+
+func TestLedgerRefusesStale(t *testing.T) {
+	ledger := NewLedger(t.TempDir())
+	req, _ := approvedRequest(t, time.Hour)
+	if _, err := ledger.Begin(req, req.ExpiresAt.Add(time.Second)); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("Begin of a stale request = %v, want an expiry error", err)
+	}
+}
@@ -0,0 +1,94 @@
+package approval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Ledger records the requests execute-plan has run, one file per nonce, so
+// each approved request executes once. A run that failed half way can be
+// resumed, even after the request expired; a finished one can't run again.
+type Ledger struct {
+	dir string
+}
+
+// ledgerEntry is the file of one request
+type ledgerEntry struct {
+	PlanDigest string     `json:"plan_digest"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// NewLedger returns the ledger kept in dir
+func NewLedger(dir string) *Ledger {
+	return &Ledger{dir: dir}
+}
+
+func (l *Ledger) path(r *Request) string {
+	return filepath.Join(l.dir, r.Nonce+".json")
+}
+
+// Begin claims the request for execution. It refuses requests that were
+// already executed and, unless an earlier run is being resumed, requests
+// that expired at now. resumed reports an earlier unfinished run.
+func (l *Ledger) Begin(r *Request, now time.Time) (resumed bool, err error) {
+	if err := os.MkdirAll(l.dir, 0700); err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(l.path(r))
+	switch {
+	case err == nil:
+		var entry ledgerEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return false, fmt.Errorf("invalid approval ledger entry %s: %w", l.path(r), err)
+		}
+		if entry.PlanDigest != r.PlanDigest {
+			return false, fmt.Errorf("request nonce %s was already used for another plan", r.Nonce)
+		}
+		if entry.FinishedAt != nil {
+			return false, fmt.Errorf("request was already executed at %s; approvals are single-use", entry.FinishedAt.Local().Format("2006-01-02 15:04:05"))
+		}
+		return true, nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return false, err
+	}
+
+	if err := r.CheckFresh(now); err != nil {
+		return false, err
+	}
+	// O_EXCL: of two runs starting the same request, only one gets it
+	f, err := os.OpenFile(l.path(r), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return false, fmt.Errorf("request is already being executed")
+		}
+		return false, err
+	}
+	defer f.Close()
+	data, _ = json.Marshal(ledgerEntry{PlanDigest: r.PlanDigest, StartedAt: now.UTC()})
+	if _, err := f.Write(data); err != nil {
+		return false, fmt.Errorf("failed to write approval ledger: %w", err)
+	}
+	return false, nil
+}
+
+// Finish marks the request as executed
+func (l *Ledger) Finish(r *Request, now time.Time) error {
+	data, err := os.ReadFile(l.path(r))
+	if err != nil {
+		return err
+	}
+	var entry ledgerEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return fmt.Errorf("invalid approval ledger entry %s: %w", l.path(r), err)
+	}
+	finished := now.UTC()
+	entry.FinishedAt = &finished
+	data, _ = json.Marshal(entry)
+	return os.WriteFile(l.path(r), data, 0600)
+}
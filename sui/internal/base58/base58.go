@@ -3,6 +3,7 @@ package base58
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 )
 
@@ -11,10 +12,27 @@ import (
 // Includes lowercase 'l' unlike standard Bitcoin base58
 const WalrusBase58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 
+// MaxDecodeLength bounds the strings Decode accepts. Blob IDs are about 44
+// characters; the bound keeps a malformed response (an HTML error page, a
+// huge body) from turning into unbounded big.Int arithmetic.
+const MaxDecodeLength = 256
+
 var (
 	alphabet = []byte(WalrusBase58Alphabet)
 	bigRadix = big.NewInt(59) // Must match alphabet length
 	zero     = big.NewInt(0)
+
+	// decodeMap maps a character to its alphabet index, or -1
+	decodeMap = func() [256]int8 {
+		var m [256]int8
+		for i := range m {
+			m[i] = -1
+		}
+		for i, b := range alphabet {
+			m[b] = int8(i)
+		}
+		return m
+	}()
 )
 
 // Decode decodes a base58 string to bytes using the full alphabet
@@ -22,23 +40,20 @@ func Decode(s string) ([]byte, error) {
 	if len(s) == 0 {
 		return nil, errors.New("empty string")
 	}
+	if len(s) > MaxDecodeLength {
+		return nil, fmt.Errorf("base58 string too long: %d characters (max %d)", len(s), MaxDecodeLength)
+	}
 
 	// Convert string to big integer
 	bigInt := big.NewInt(0)
+	digit := new(big.Int)
 	for i := 0; i < len(s); i++ {
-		char := s[i]
-		idx := -1
-		for j, b := range alphabet {
-			if b == char {
-				idx = j
-				break
-			}
-		}
-		if idx == -1 {
-			return nil, errors.New("invalid base58 character: " + string(char))
+		idx := decodeMap[s[i]]
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q at position %d", s[i:i+1], i)
 		}
 		bigInt.Mul(bigInt, bigRadix)
-		bigInt.Add(bigInt, big.NewInt(int64(idx)))
+		bigInt.Add(bigInt, digit.SetInt64(int64(idx)))
 	}
 
 	// Convert big integer to bytes
@@ -0,0 +1,134 @@
+package base58
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", "empty string"},
+		{"zero", "abc0", `invalid base58 character "0" at position 3`},
+		{"uppercase O", "O", `invalid base58 character "O" at position 0`},
+		{"uppercase I", "1I", `invalid base58 character "I" at position 1`},
+		{"non-ASCII", "ab\xffc", `invalid base58 character "\xff" at position 2`},
+		{"too long", strings.Repeat("z", MaxDecodeLength+1), "base58 string too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.in)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Decode(%q) error = %v, want %q", tt.in, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeMap(t *testing.T) {
+	for c := 0; c < 256; c++ {
+		want := int8(strings.IndexByte(WalrusBase58Alphabet, byte(c)))
+		if got := decodeMap[c]; got != want {
+			t.Errorf("decodeMap[%q] = %d, want %d", byte(c), got, want)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want string
+	}{
+		{[]byte{0}, "1"},
+		{[]byte{0, 0, 1}, "112"},
+		{[]byte{58}, "z"},
+		{[]byte{59}, "21"},
+		{bytes.Repeat([]byte{0xff}, 32), ""},
+	}
+	for _, tt := range tests {
+		got := Encode(tt.in)
+		if tt.want != "" && got != tt.want {
+			t.Errorf("Encode(%x) = %q, want %q", tt.in, got, tt.want)
+		}
+		back, err := Decode(got)
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", got, err)
+		}
+		if !bytes.Equal(back, tt.in) {
+			t.Errorf("Decode(Encode(%x)) = %x", tt.in, back)
+		}
+	}
+	if Encode(nil) != "" {
+		t.Error("Encode(nil) is not empty")
+	}
+}
+
+func TestDecodeMaxLength(t *testing.T) {
+	if _, err := Decode(strings.Repeat("z", MaxDecodeLength)); err != nil {
+		t.Fatalf("Decode of %d characters: %v", MaxDecodeLength, err)
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add("1")
+	f.Add("112")
+	f.Add("21")
+	f.Add("2Zr1hQhxgZUNwLYwSaSVBKzYCNBvhjNBnqFKSLNPnEcj")
+	f.Add("abc0")
+	f.Add(strings.Repeat("z", MaxDecodeLength+1))
+	f.Fuzz(func(t *testing.T, s string) {
+		b, err := Decode(s)
+		if err != nil {
+			return
+		}
+		if got := Encode(b); got != s {
+			t.Fatalf("Encode(Decode(%q)) = %q", s, got)
+		}
+	})
+}
+
+func FuzzEncodeDecode(f *testing.F) {
+	f.Add([]byte{0})
+	f.Add([]byte{0, 0, 1})
+	f.Add([]byte("walrus blob"))
+	f.Add(bytes.Repeat([]byte{0xff}, 32))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		s := Encode(b)
+		if len(b) == 0 {
+			if s != "" {
+				t.Fatalf("Encode(empty) = %q", s)
+			}
+			return
+		}
+		if len(s) > MaxDecodeLength {
+			return
+		}
+		got, err := Decode(s)
+		if err != nil {
+			t.Fatalf("Decode(Encode(%x)): %v", b, err)
+		}
+		if !bytes.Equal(got, b) {
+			t.Fatalf("Decode(Encode(%x)) = %x", b, got)
+		}
+	})
+}
+
+func BenchmarkEncode(b *testing.B) {
+	id := bytes.Repeat([]byte{0xa5}, 32)
+	for i := 0; i < b.N; i++ {
+		Encode(id)
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	s := Encode(bytes.Repeat([]byte{0xa5}, 32))
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
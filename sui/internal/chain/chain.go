@@ -0,0 +1,97 @@
+// Package chain describes the catalog operations catalogctl needs from a
+// blockchain, so commands can work on a catalog without knowing which chain
+// it lives on. Sui and Nimiq implement it in cmd/catalogctl; another chain
+// only needs another implementation.
+package chain
+
+import (
+	"fmt"
+
+	"github.com/retro-crypto/sui/internal/model"
+)
+
+// Backend is a chain catalogs live on
+type Backend interface {
+	// Name is the chain's --chain value
+	Name() string
+	// CreateCatalog creates an empty catalog and returns its ID
+	CreateCatalog(name, description string) (string, error)
+	// GetCatalog reads a catalog's metadata
+	GetCatalog(catalogID string) (*Catalog, error)
+	// List returns the current entries of a catalog
+	List(catalogID string) ([]Entry, error)
+	// GetEntry returns the entry with the given key
+	GetEntry(catalogID, key string) (*Entry, error)
+	// AddEntry publishes a game file and adds it under a new key
+	AddEntry(catalogID string, game Game) (*Entry, error)
+	// UpdateEntry publishes a new version of a game under an existing key
+	UpdateEntry(catalogID string, game Game) (*Entry, error)
+	// RemoveEntry removes (or retires) an entry
+	RemoveEntry(catalogID, key string) error
+}
+
+// Catalog is the metadata of a catalog
+type Catalog struct {
+	Chain       string `json:"chain"`
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Entries     int    `json:"entries"`
+}
+
+// Entry is a game entry of a catalog
+type Entry struct {
+	// Key identifies the entry: the slug (with @channel) on Sui, the app ID
+	// on Nimiq
+	Key      string         `json:"key"`
+	Title    string         `json:"title"`
+	Platform model.Platform `json:"platform"`
+	// Version is the entry's version as the chain writes it ("3", "1.2.0")
+	Version string `json:"version"`
+	Channel string `json:"channel"`
+	// Cartridge is the cartridge object ID or address
+	Cartridge string `json:"cartridge"`
+	SizeBytes uint64 `json:"size_bytes,omitempty"`
+	// Publisher is the address that added the entry, where the chain
+	// doesn't restrict it to the catalog owner
+	Publisher string `json:"publisher,omitempty"`
+}
+
+// Game is a game file to publish in a catalog
+type Game struct {
+	File string
+	// Key of the entry; for AddEntry on Nimiq, "" picks a new app ID
+	Key      string
+	Title    string
+	Platform model.Platform
+	Version  string
+	Channel  string
+	// Epochs is how long the file is stored, where storage is paid per epoch
+	Epochs int
+}
+
+// Find returns the entry with the given key, or nil
+func Find(entries []Entry, key string) *Entry {
+	for i := range entries {
+		if entries[i].Key == key {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// UnsupportedError is returned for operations a chain has no equivalent of
+type UnsupportedError struct {
+	Chain     string
+	Operation string
+	Hint      string
+}
+
+func (e *UnsupportedError) Error() string {
+	msg := fmt.Sprintf("%s is not supported on %s", e.Operation, e.Chain)
+	if e.Hint != "" {
+		msg += ": " + e.Hint
+	}
+	return msg
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CatalogAliasesFile is the catalog alias registry in the config directory
+const CatalogAliasesFile = "catalogs.json"
+
+// CatalogAlias is a named catalog object ID
+type CatalogAlias struct {
+	// ID is the catalog object ID
+	ID string `json:"id"`
+	// Network the catalog lives on (testnet, devnet, mainnet, localnet)
+	Network string `json:"network,omitempty"`
+}
+
+// CatalogAliasesPath returns the path of the alias registry file
+func CatalogAliasesPath() string {
+	return filepath.Join(GetConfigDir(), CatalogAliasesFile)
+}
+
+// LoadCatalogAliases reads the alias registry (empty if it doesn't exist)
+func LoadCatalogAliases() (map[string]CatalogAlias, error) {
+	aliases := make(map[string]CatalogAlias)
+	data, err := os.ReadFile(CatalogAliasesPath())
+	if os.IsNotExist(err) {
+		return aliases, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", CatalogAliasesPath(), err)
+	}
+	return aliases, nil
+}
+
+// SaveCatalogAliases writes the alias registry
+func SaveCatalogAliases(aliases map[string]CatalogAlias) error {
+	if err := os.MkdirAll(GetConfigDir(), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(aliases, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(CatalogAliasesPath(), append(data, '\n'), 0644)
+}
+
+// AliasNames returns alias names in alphabetical order
+func AliasNames(aliases map[string]CatalogAlias) []string {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveCatalogID returns value unchanged if it is an object ID, or looks it
+// up in the alias registry. Aliases registered for a different network than
+// sui_network are rejected.
+func (c *Config) ResolveCatalogID(value string) (string, error) {
+	if value == "" || strings.HasPrefix(value, "0x") {
+		return value, nil
+	}
+
+	aliases, err := LoadCatalogAliases()
+	if err != nil {
+		return "", err
+	}
+	alias, ok := aliases[strings.ToLower(value)]
+	if !ok {
+		return "", fmt.Errorf("unknown catalog alias %q (see `catalogctl catalog-alias list`)", value)
+	}
+	if alias.Network != "" && !strings.EqualFold(alias.Network, c.SuiNetwork) {
+		return "", fmt.Errorf("catalog alias %q is on %s but sui_network is %s", value, alias.Network, c.SuiNetwork)
+	}
+	return alias.ID, nil
+}
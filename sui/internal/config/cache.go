@@ -0,0 +1,21 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultObjectCacheTTL is how long cached object reads are trusted
+const DefaultObjectCacheTTL = 30 * time.Second
+
+// ObjectCacheTTLDuration returns object_cache_ttl, or the default if unset
+func (c *Config) ObjectCacheTTLDuration() (time.Duration, error) {
+	if c.ObjectCacheTTL == "" {
+		return DefaultObjectCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(c.ObjectCacheTTL)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("object_cache_ttl: %q is not a duration (e.g. 30s or 5m)", c.ObjectCacheTTL)
+	}
+	return ttl, nil
+}
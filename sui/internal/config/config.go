@@ -3,10 +3,21 @@ package config
 
 import (
 	"bufio"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/retro-crypto/sui/internal/keystore"
+	"github.com/retro-crypto/sui/internal/storage"
+	"github.com/retro-crypto/sui/internal/taxonomy"
+	"github.com/retro-crypto/sui/internal/validate"
 )
 
 // Config holds all configuration values
@@ -21,18 +32,94 @@ type Config struct {
 	WalrusAggregatorURL string `json:"walrus_aggregator_url"`
 	// Walrus publisher URL for uploading blobs
 	WalrusPublisherURL string `json:"walrus_publisher_url"`
+	// Optional: more endpoints of each kind, fastest first. Written by
+	// `catalogctl benchmark-endpoints`, which also moves the fastest endpoint
+	// into the single-URL field above.
+	SuiRPCURLs           []string `json:"sui_rpc_urls,omitempty"`
+	WalrusAggregatorURLs []string `json:"walrus_aggregator_urls,omitempty"`
+	WalrusPublisherURLs  []string `json:"walrus_publisher_urls,omitempty"`
+	// Optional: Walrus aggregators by region tag (e.g. {"eu": [...], "us": [...]}).
+	// Reads pick the fastest aggregator, preferring those tagged with region.
+	WalrusAggregatorRegions map[string][]string `json:"walrus_aggregator_regions,omitempty"`
+	// Optional: HMAC signing of requests to a self-hosted Walrus publisher
+	WalrusPublisherHMAC *PublisherHMAC `json:"walrus_publisher_hmac,omitempty"`
+	// Optional: where game files are stored; "walrus" (the default) is the
+	// only backend so far
+	StorageBackend string `json:"storage_backend,omitempty"`
+	// Optional: region hint for aggregator selection (a walrus_aggregator_regions tag)
+	Region string `json:"region,omitempty"`
+	// Optional: Walrus system object read for storage prices by
+	// publish-game --estimate (default: the known object of walrus_network)
+	WalrusSystemObject string `json:"walrus_system_object,omitempty"`
+	// Optional: where the signing key lives instead of private_key /
+	// mnemonic: "config" (default), "env[:VAR]", "keychain[:NAME]" (OS
+	// keychain) or "file[:PATH]" (encrypted keystore file)
+	KeySource string `json:"key_source,omitempty"`
 	// Private key (hex encoded, without 0x prefix)
 	PrivateKey string `json:"private_key"`
 	// Mnemonic phrase (alternative to private key)
 	Mnemonic string `json:"mnemonic"`
 	// Package ID of the deployed cartridge_storage module
 	PackageID string `json:"package_id"`
+	// Optional: ID the package was first published at. Set after an upgrade,
+	// since Move types and events keep the original package ID.
+	OriginalPackageID string `json:"original_package_id,omitempty"`
+	// Optional: UpgradeCap of the package (written by deploy-package)
+	UpgradeCapID string `json:"upgrade_cap_id,omitempty"`
 	// Optional: Default catalog ID for commands
 	CatalogID string `json:"catalog_id"`
 	// Optional: Registry object ID for catalog discovery
 	RegistryID string `json:"registry_id"`
+	// Optional: Ed25519 public keys (hex) of operators allowed to approve
+	// mainnet publishes. Setting it enables the approval workflow on mainnet.
+	Approvers []string `json:"approvers,omitempty"`
+	// Optional: block explorer for printed links ("suiscan", "suivision" or a
+	// URL template using {network}, {kind} and {id}); defaults to suiscan
+	Explorer string `json:"explorer,omitempty"`
+	// Optional: how long object reads are served from the cache before the
+	// RPC is asked for the latest version (duration, default 30s; "0" always
+	// asks). Objects written by catalogctl itself are re-read right away.
+	ObjectCacheTTL string `json:"object_cache_ttl,omitempty"`
+	// Optional: JSON file of the tags entries may carry, by group
+	// ({"genre": ["platformer", ...], "feature": [...]}); `tags add` rejects
+	// others. Without it any well-formed tag is accepted.
+	TagTaxonomy string `json:"tag_taxonomy,omitempty"`
+	// Optional: outbound HTTP settings for Sui, Walrus and Nimiq requests.
+	// http_timeout is a duration ("45s"); unset values use the defaults
+	// (per-client timeouts, 100 idle connections, 32 connections per host).
+	HTTPTimeout         string `json:"http_timeout,omitempty"`
+	HTTPMaxIdleConns    int    `json:"http_max_idle_conns,omitempty"`
+	HTTPMaxConnsPerHost int    `json:"http_max_conns_per_host,omitempty"`
+	// Optional: TLS for self-hosted HTTPS endpoints (Sui RPC, Walrus, Nimiq
+	// RPC). tls_ca_file is a PEM bundle trusted in addition to the system
+	// roots; tls_client_cert and tls_client_key (PEM) enable mutual TLS.
+	TLSCAFile     string `json:"tls_ca_file,omitempty"`
+	TLSClientCert string `json:"tls_client_cert,omitempty"`
+	TLSClientKey  string `json:"tls_client_key,omitempty"`
+	// Optional: accept any server certificate. Only for testing.
+	TLSInsecureSkipVerify bool `json:"tls_insecure_skip_verify,omitempty"`
+
+	// Source is the config file the values were loaded from (empty if none)
+	Source string `json:"-"`
+	// Encrypted is true when Source was encrypted with `config encrypt`
+	Encrypted bool `json:"-"`
+	// Warnings collected while loading (e.g. unknown fields)
+	Warnings []string `json:"-"`
+}
+
+// Problem describes a single issue found by Check
+type Problem struct {
+	// Field is the JSON name of the offending field
+	Field string
+	// Message explains the problem and how to fix it
+	Message string
+	// Fatal is true for errors, false for warnings
+	Fatal bool
 }
 
+// Known networks for sui_network and walrus_network
+var knownNetworks = []string{"testnet", "devnet", "mainnet", "localnet"}
+
 // Default configuration values
 const (
 	DefaultSuiNetwork       = "testnet"
@@ -40,22 +127,77 @@ const (
 	DefaultSuiRPCTestnet    = "https://fullnode.testnet.sui.io:443"
 	DefaultSuiRPCDevnet     = "https://fullnode.devnet.sui.io:443"
 	DefaultSuiRPCMainnet    = "https://fullnode.mainnet.sui.io:443"
+	DefaultSuiRPCLocalnet   = "http://127.0.0.1:9000"
 	DefaultWalrusAggregator = "https://aggregator.walrus-testnet.walrus.space"
 	DefaultWalrusPublisher  = "https://publisher.walrus-testnet.walrus.space"
 )
 
-// Load reads configuration from config.json file or environment variables
-// Priority: config.json > .env > environment variables
-func Load() (*Config, error) {
+// DefaultConfigFile is the config file written when none has been loaded
+const DefaultConfigFile = "config.json"
+
+// ConfigDirName is the name of the per-user config directory
+const ConfigDirName = "catalogctl"
+
+// Path returns the config file that edits (config set, --save-config) are written to
+func (c *Config) Path() string {
+	if c.Source != "" {
+		return c.Source
+	}
+	return DefaultConfigFile
+}
+
+// GetConfigDir returns the per-user config directory
+// On Linux/Mac: $XDG_CONFIG_HOME/catalogctl or ~/.config/catalogctl
+// Falls back to current directory if home is not available
+func GetConfigDir() string {
+	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+		return filepath.Join(xdgConfig, ConfigDirName)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+
+	return filepath.Join(homeDir, ".config", ConfigDirName)
+}
+
+// SearchPaths returns the config files Load looks for, highest priority first
+func SearchPaths() []string {
+	return []string{
+		DefaultConfigFile,
+		filepath.Join(GetConfigDir(), DefaultConfigFile),
+	}
+}
+
+// Load reads configuration from a config file or environment variables
+// Priority:
+//  1. explicit path (--config flag), which must exist
+//  2. ./config.json
+//  3. $XDG_CONFIG_HOME/catalogctl/config.json (~/.config/catalogctl/config.json)
+//  4. .env file (only if no config file was found)
+//  5. environment variables
+func Load(path string) (*Config, error) {
 	cfg := &Config{}
 
-	// Try to load from config.json first
-	if _, err := os.Stat("config.json"); err == nil {
-		if err := loadJSONConfig("config.json", cfg); err != nil {
-			return nil, fmt.Errorf("failed to load config from config.json: %w", err)
+	if path == "" {
+		for _, candidate := range SearchPaths() {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
 		}
+	} else if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	if path != "" {
+		if err := loadJSONConfig(path, cfg); err != nil {
+			return nil, fmt.Errorf("failed to load config from %s: %w", path, err)
+		}
+		cfg.Source = path
 	} else {
-		// Try to load .env file if config.json doesn't exist
+		// Try to load .env file if no config file exists
 		loadEnvFile(".env")
 	}
 
@@ -72,21 +214,82 @@ func Load() (*Config, error) {
 	if cfg.WalrusPublisherURL == "" {
 		cfg.WalrusPublisherURL = getEnv("WALRUS_PUBLISHER_URL", DefaultWalrusPublisher)
 	}
-	if cfg.PrivateKey == "" {
-		cfg.PrivateKey = getEnv("SUI_PRIVATE_KEY", "")
+	// The environment overrides key_source, so CI can inject the key into a
+	// config that normally reads it from the keychain
+	if source := os.Getenv("CATALOGCTL_KEY_SOURCE"); source != "" {
+		cfg.KeySource = source
 	}
-	if cfg.Mnemonic == "" {
-		cfg.Mnemonic = getEnv("SUI_MNEMONIC", "")
+	// A keystore replaces the key variables (key_source env reads them itself)
+	if !cfg.UsesKeystore() {
+		if cfg.PrivateKey == "" {
+			cfg.PrivateKey = getEnv("SUI_PRIVATE_KEY", "")
+		}
+		if cfg.Mnemonic == "" {
+			cfg.Mnemonic = getEnv("SUI_MNEMONIC", "")
+		}
 	}
 	if cfg.PackageID == "" {
 		cfg.PackageID = getEnv("PACKAGE_ID", "")
 	}
+	if cfg.OriginalPackageID == "" {
+		cfg.OriginalPackageID = getEnv("ORIGINAL_PACKAGE_ID", "")
+	}
+	if cfg.UpgradeCapID == "" {
+		cfg.UpgradeCapID = getEnv("UPGRADE_CAP_ID", "")
+	}
 	if cfg.CatalogID == "" {
 		cfg.CatalogID = getEnv("CATALOG_ID", "")
 	}
 	if cfg.RegistryID == "" {
 		cfg.RegistryID = getEnv("REGISTRY_ID", "")
 	}
+	if cfg.Explorer == "" {
+		cfg.Explorer = getEnv("SUI_EXPLORER", "")
+	}
+	if cfg.StorageBackend == "" {
+		cfg.StorageBackend = getEnv("STORAGE_BACKEND", storage.Default)
+	}
+	if cfg.Region == "" {
+		cfg.Region = getEnv("CATALOGCTL_REGION", "")
+	}
+	if cfg.ObjectCacheTTL == "" {
+		cfg.ObjectCacheTTL = getEnv("CATALOGCTL_OBJECT_CACHE_TTL", "")
+	}
+	if cfg.TagTaxonomy == "" {
+		cfg.TagTaxonomy = getEnv("CATALOGCTL_TAG_TAXONOMY", "")
+	}
+	if cfg.HTTPTimeout == "" {
+		cfg.HTTPTimeout = getEnv("CATALOGCTL_HTTP_TIMEOUT", "")
+	}
+	for env, field := range map[string]*int{
+		"CATALOGCTL_HTTP_MAX_IDLE_CONNS":     &cfg.HTTPMaxIdleConns,
+		"CATALOGCTL_HTTP_MAX_CONNS_PER_HOST": &cfg.HTTPMaxConnsPerHost,
+	} {
+		if value := getEnv(env, ""); value != "" && *field == 0 {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %q is not a number", env, value)
+			}
+			*field = n
+		}
+	}
+	if secret := getEnv("CATALOGCTL_WALRUS_HMAC_SECRET", ""); secret != "" {
+		if cfg.WalrusPublisherHMAC == nil {
+			cfg.WalrusPublisherHMAC = &PublisherHMAC{}
+		}
+		if cfg.WalrusPublisherHMAC.Secret == "" {
+			cfg.WalrusPublisherHMAC.Secret = secret
+		}
+	}
+	if cfg.TLSCAFile == "" {
+		cfg.TLSCAFile = getEnv("CATALOGCTL_TLS_CA_FILE", "")
+	}
+	if cfg.TLSClientCert == "" {
+		cfg.TLSClientCert = getEnv("CATALOGCTL_TLS_CLIENT_CERT", "")
+	}
+	if cfg.TLSClientKey == "" {
+		cfg.TLSClientKey = getEnv("CATALOGCTL_TLS_CLIENT_KEY", "")
+	}
 
 	// Set RPC URL based on network if not explicitly set
 	if cfg.SuiRPCURL == "" {
@@ -99,6 +302,8 @@ func Load() (*Config, error) {
 				cfg.SuiRPCURL = DefaultSuiRPCDevnet
 			case "mainnet":
 				cfg.SuiRPCURL = DefaultSuiRPCMainnet
+			case "localnet":
+				cfg.SuiRPCURL = DefaultSuiRPCLocalnet
 			default:
 				cfg.SuiRPCURL = DefaultSuiRPCTestnet
 			}
@@ -109,14 +314,46 @@ func Load() (*Config, error) {
 }
 
 // loadJSONConfig loads configuration from a JSON file
+// Unknown fields are not an error, but are recorded in cfg.Warnings
 func loadJSONConfig(filename string, cfg *Config) error {
-	data, err := os.ReadFile(filename)
+	data, encrypted, err := ReadFile(filename)
 	if err != nil {
 		return err
 	}
+	cfg.Encrypted = encrypted
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	known := fieldNames()
+	unknown := []string{}
+	for key := range raw {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		cfg.Warnings = append(cfg.Warnings, fmt.Sprintf("%s: unknown field %q (ignored)", filename, key))
+	}
+
 	return json.Unmarshal(data, cfg)
 }
 
+// fieldNames returns the set of JSON field names accepted in config files
+func fieldNames() map[string]bool {
+	names := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag != "" && tag != "-" {
+			names[tag] = true
+		}
+	}
+	return names
+}
+
 // loadEnvFile loads environment variables from a .env file
 func loadEnvFile(filename string) {
 	file, err := os.Open(filename)
@@ -157,12 +394,19 @@ func (c *Config) Validate() error {
 	if c.SuiRPCURL == "" {
 		return fmt.Errorf("SUI_RPC_URL is required")
 	}
-	if c.PrivateKey == "" && c.Mnemonic == "" {
-		return fmt.Errorf("either SUI_PRIVATE_KEY or SUI_MNEMONIC is required")
+	if c.PrivateKey == "" && c.Mnemonic == "" && !c.UsesKeystore() {
+		return fmt.Errorf("either SUI_PRIVATE_KEY or SUI_MNEMONIC (or key_source) is required")
 	}
 	return nil
 }
 
+// UsesKeystore reports whether the signing key comes from key_source rather
+// than private_key / mnemonic
+func (c *Config) UsesKeystore() bool {
+	src, err := keystore.ParseSource(c.KeySource)
+	return err == nil && src.Kind != keystore.KindConfig
+}
+
 // ValidateForPublish checks configuration for publish operations
 func (c *Config) ValidateForPublish() error {
 	if err := c.Validate(); err != nil {
@@ -176,3 +420,186 @@ func (c *Config) ValidateForPublish() error {
 	}
 	return nil
 }
+
+// Check runs schema and cross-field validation and returns all problems found.
+// Unlike Validate it never stops at the first issue, so every mistake can be
+// reported at once by `config validate`.
+func (c *Config) Check() []Problem {
+	var problems []Problem
+	add := func(field string, fatal bool, format string, args ...interface{}) {
+		problems = append(problems, Problem{Field: field, Message: fmt.Sprintf(format, args...), Fatal: fatal})
+	}
+
+	for _, w := range c.Warnings {
+		add("", false, "%s", w)
+	}
+
+	suiNetwork := strings.ToLower(c.SuiNetwork)
+	if !isKnownNetwork(suiNetwork) {
+		add("sui_network", true, "unknown network %q (expected one of: %s)", c.SuiNetwork, strings.Join(knownNetworks, ", "))
+	}
+	walrusNetwork := strings.ToLower(c.WalrusNetwork)
+	if !isKnownNetwork(walrusNetwork) {
+		add("walrus_network", true, "unknown network %q (expected one of: %s)", c.WalrusNetwork, strings.Join(knownNetworks, ", "))
+	}
+
+	// URLs must parse, and hosts that name a network must match the configured one
+	checkURL := func(field, value, network string) {
+		if value == "" {
+			return
+		}
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			add(field, true, "%q is not a valid URL (expected e.g. https://host:port)", value)
+			return
+		}
+		if hostNetwork := networkFromHost(u.Hostname()); hostNetwork != "" && network != "" && hostNetwork != network {
+			add(field, true, "host %s points at %s but the network is set to %s; change the URL or the network so they agree", u.Hostname(), hostNetwork, network)
+		}
+	}
+	checkURL("sui_rpc_url", c.SuiRPCURL, suiNetwork)
+	checkURL("walrus_aggregator_url", c.WalrusAggregatorURL, walrusNetwork)
+	checkURL("walrus_publisher_url", c.WalrusPublisherURL, walrusNetwork)
+	for _, u := range c.SuiRPCURLs {
+		checkURL("sui_rpc_urls", u, suiNetwork)
+	}
+	for _, u := range c.WalrusAggregatorURLs {
+		checkURL("walrus_aggregator_urls", u, walrusNetwork)
+	}
+	for _, region := range sortedKeys(c.WalrusAggregatorRegions) {
+		for _, u := range c.WalrusAggregatorRegions[region] {
+			checkURL("walrus_aggregator_regions."+region, u, walrusNetwork)
+		}
+	}
+	if c.Region != "" && len(c.WalrusAggregatorRegions[c.Region]) == 0 {
+		add("region", false, "no aggregators are tagged %q in walrus_aggregator_regions; the hint has no effect", c.Region)
+	}
+	for _, u := range c.WalrusPublisherURLs {
+		checkURL("walrus_publisher_urls", u, walrusNetwork)
+	}
+
+	if !storage.Known(c.StorageBackend) {
+		add("storage_backend", true, "%v", storage.UnknownError(c.StorageBackend))
+	}
+
+	if suiNetwork != walrusNetwork && isKnownNetwork(suiNetwork) && isKnownNetwork(walrusNetwork) {
+		add("walrus_network", false, "walrus_network (%s) differs from sui_network (%s); blobs and catalogs will live on different networks", walrusNetwork, suiNetwork)
+	}
+
+	// Object IDs
+	for field, value := range map[string]string{
+		"package_id":           c.PackageID,
+		"original_package_id":  c.OriginalPackageID,
+		"upgrade_cap_id":       c.UpgradeCapID,
+		"catalog_id":           c.CatalogID,
+		"registry_id":          c.RegistryID,
+		"walrus_system_object": c.WalrusSystemObject,
+	} {
+		if value == "" {
+			continue
+		}
+		if err := validate.ObjectID(value); err != nil {
+			add(field, true, "%v", err)
+		}
+	}
+
+	// Keys
+	if c.PrivateKey != "" {
+		key := strings.TrimPrefix(c.PrivateKey, "0x")
+		if _, err := hex.DecodeString(key); err != nil {
+			add("private_key", true, "private key must be hex encoded (export it with `sui keytool export`)")
+		}
+	}
+	if c.PrivateKey != "" && c.Mnemonic != "" {
+		add("mnemonic", false, "both private_key and mnemonic are set; only one is needed")
+	}
+	if src, err := keystore.ParseSource(c.KeySource); err != nil {
+		add("key_source", true, "%v", err)
+	} else if src.Kind != keystore.KindConfig && (c.PrivateKey != "" || c.Mnemonic != "") {
+		add("key_source", false, "key_source is %s, so private_key and mnemonic in the config file are ignored; remove them (catalogctl key import moves them into the keystore)", src)
+	}
+	for _, approver := range c.Approvers {
+		key, err := hex.DecodeString(strings.TrimPrefix(approver, "0x"))
+		if err != nil || len(key) != 32 {
+			add("approvers", true, "%q is not a hex encoded Ed25519 public key (print yours with `catalogctl approve --show-key`)", approver)
+		}
+	}
+	if _, err := c.ObjectCacheTTLDuration(); err != nil {
+		add("", true, "%v", err)
+	}
+	if c.TagTaxonomy != "" {
+		if _, err := taxonomy.Load(c.TagTaxonomy); err != nil {
+			add("tag_taxonomy", true, "%v", err)
+		}
+	}
+	if _, err := c.HTTPSettings(); err != nil {
+		add("", true, "%v", err)
+	}
+	if c.WalrusPublisherHMAC != nil {
+		if _, err := c.PublisherSigner(); err != nil {
+			add("walrus_publisher_hmac", true, "%v", err)
+		}
+	}
+	if c.HasTLS() {
+		if _, err := c.TLSConfig(); err != nil {
+			// The error names the offending field
+			add("", true, "%v", err)
+		}
+	}
+	if c.TLSInsecureSkipVerify {
+		add("tls_insecure_skip_verify", false, "server certificates are not verified; anyone on the network path can impersonate the endpoints")
+	}
+	if msg := checkExplorer(c.Explorer); msg != "" {
+		add("explorer", true, "%s", msg)
+	}
+	if len(c.Approvers) == 1 {
+		add("approvers", false, "only one approver is listed; the requester can't approve their own publish, so add every operator")
+	}
+	if c.PackageID == "" {
+		add("package_id", false, "package_id is not set; on-chain commands will fail until it is")
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Fatal && !problems[j].Fatal
+	})
+	return problems
+}
+
+// TypePackageID returns the package ID that qualifies Move type and event
+// names: the original package ID once the package has been upgraded
+func (c *Config) TypePackageID() string {
+	if c.OriginalPackageID != "" {
+		return c.OriginalPackageID
+	}
+	return c.PackageID
+}
+
+// ApprovalRequired reports whether publishes need a second operator's approval
+func (c *Config) ApprovalRequired() bool {
+	return strings.ToLower(c.SuiNetwork) == "mainnet" && len(c.Approvers) > 0
+}
+
+// isKnownNetwork reports whether network is a recognized Sui/Walrus network
+func isKnownNetwork(network string) bool {
+	for _, n := range knownNetworks {
+		if n == network {
+			return true
+		}
+	}
+	return false
+}
+
+// networkFromHost guesses the network from a hostname such as
+// fullnode.testnet.sui.io; returns "" when the host doesn't name one
+func networkFromHost(host string) string {
+	host = strings.ToLower(host)
+	if host == "localhost" || host == "127.0.0.1" {
+		return ""
+	}
+	for _, n := range knownNetworks {
+		if strings.Contains(host, n) {
+			return n
+		}
+	}
+	return ""
+}
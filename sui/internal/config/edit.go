@@ -0,0 +1,255 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// orderedObject is a JSON object that remembers its key order, so editing a
+// config file keeps the user's layout instead of re-sorting every field
+type orderedObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// parseOrderedObject parses a JSON object, keeping keys in file order
+func parseOrderedObject(data []byte) (*orderedObject, error) {
+	obj := &orderedObject{values: make(map[string]json.RawMessage)}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return obj, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected an object key")
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		if _, exists := obj.values[key]; !exists {
+			obj.keys = append(obj.keys, key)
+		}
+		obj.values[key] = raw
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// MarshalJSON writes the object with keys in their original order
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keyJSON, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// set stores value at the dot-separated path, creating nested objects as needed
+func (o *orderedObject) set(path []string, value json.RawMessage) error {
+	key := path[0]
+	if len(path) == 1 {
+		if _, exists := o.values[key]; !exists {
+			o.keys = append(o.keys, key)
+		}
+		o.values[key] = value
+		return nil
+	}
+
+	child, err := parseOrderedObject(o.values[key])
+	if err != nil {
+		return fmt.Errorf("%s is not an object: %w", key, err)
+	}
+	if err := child.set(path[1:], value); err != nil {
+		return err
+	}
+	childJSON, err := child.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return o.set(path[:1], childJSON)
+}
+
+// listFields are top-level fields holding a list of strings
+var listFields = map[string]bool{
+	"approvers":              true,
+	"sui_rpc_urls":           true,
+	"walrus_aggregator_urls": true,
+	"walrus_publisher_urls":  true,
+}
+
+// boolFields are top-level fields holding true or false
+var boolFields = map[string]bool{
+	"tls_insecure_skip_verify": true,
+}
+
+// intFields are top-level fields holding a number
+var intFields = map[string]bool{
+	"http_max_idle_conns":     true,
+	"http_max_conns_per_host": true,
+}
+
+// SetValue sets a dot-path key in a JSON config file and writes it atomically.
+// The existing key order is kept, and an encrypted file is encrypted again.
+// Known string fields are always stored as strings.
+// List fields are stored as string arrays.
+// Bool fields are stored as booleans, number fields as numbers.
+// Other values are stored as JSON literals when they parse as one.
+// The file is created if it doesn't exist.
+func SetValue(filename, key, value string) error {
+	path := strings.Split(key, ".")
+	if !fieldNames()[path[0]] {
+		return fmt.Errorf("unknown config field %q (known fields: %s)", path[0], strings.Join(FieldNames(), ", "))
+	}
+
+	data, encrypted, err := ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	obj, err := parseOrderedObject(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	var raw json.RawMessage
+	if len(path) == 1 && listFields[path[0]] {
+		// Lists take a JSON array or a comma separated value
+		var items []string
+		if err := json.Unmarshal([]byte(value), &items); err != nil {
+			items = nil
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		if items == nil {
+			items = []string{}
+		}
+		raw, _ = json.Marshal(items)
+	} else if len(path) == 1 && boolFields[path[0]] {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", path[0], value)
+		}
+		raw, _ = json.Marshal(b)
+	} else if len(path) == 1 && intFields[path[0]] {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a number, got %q", path[0], value)
+		}
+		raw, _ = json.Marshal(n)
+	} else if len(path) == 1 || !json.Valid([]byte(value)) {
+		raw, _ = json.Marshal(value)
+	} else {
+		raw = json.RawMessage(value)
+	}
+	if err := obj.set(path, raw); err != nil {
+		return err
+	}
+
+	compact, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact, "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+
+	return writeConfigFile(filename, out.Bytes(), encrypted)
+}
+
+// GetValue looks up a dot-path key in the effective configuration
+func (c *Config) GetValue(key string) (interface{}, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var current interface{}
+	if err := json.Unmarshal(data, &current); err != nil {
+		return nil, err
+	}
+
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("config key %q not found", key)
+		}
+		current, ok = obj[part]
+		if !ok {
+			return nil, fmt.Errorf("config key %q not found", key)
+		}
+	}
+	return current, nil
+}
+
+// FieldNames returns the sorted JSON field names accepted in config files
+func FieldNames() []string {
+	names := []string{}
+	for name := range fieldNames() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeFileAtomic writes data to a temp file in the same directory and
+// renames it over filename, so a crash never leaves a half-written config
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filename)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, filename)
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/retro-crypto/shared/seal"
+)
+
+// EncryptedFormat marks a config file encrypted with `config encrypt`
+const EncryptedFormat = "catalogctl-encrypted-v2"
+
+// legacyEncryptedFormat marked files sealed under PBKDF2, which are no
+// longer read
+const legacyEncryptedFormat = "catalogctl-encrypted-v1"
+
+// PassphraseEnv holds the master passphrase of an encrypted config file
+const PassphraseEnv = "CATALOGCTL_PASSPHRASE"
+
+// encryptedFile is the on-disk form of an encrypted config: the whole
+// plaintext file sealed like a keystore file, with AES-256-GCM under a key
+// derived from the master passphrase with scrypt
+type encryptedFile struct {
+	Format string `json:"format"`
+	seal.Box
+}
+
+// ErrWrongPassphrase is returned when an encrypted file can't be opened
+var ErrWrongPassphrase = seal.ErrWrongPassphrase
+
+// Passphrase returns the master passphrase for an encrypted config file.
+// It defaults to $CATALOGCTL_PASSPHRASE; commands replace it to add other
+// sources such as a prompt.
+var Passphrase = func(filename string) (string, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return p, nil
+	}
+	return "", fmt.Errorf("%s is encrypted: set %s", filename, PassphraseEnv)
+}
+
+// passphrases caches the passphrase of each file opened, so edits can be
+// sealed again without asking twice
+var passphrases = map[string]string{}
+
+// IsEncrypted reports whether data is an encrypted config file
+func IsEncrypted(data []byte) bool {
+	var f struct {
+		Format string `json:"format"`
+	}
+	return json.Unmarshal(data, &f) == nil && (f.Format == EncryptedFormat || f.Format == legacyEncryptedFormat)
+}
+
+// Encrypt seals a config file's contents with a passphrase
+func Encrypt(plaintext []byte, passphrase string) ([]byte, error) {
+	box, err := seal.Seal(plaintext, passphrase, []byte(EncryptedFormat))
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(encryptedFile{Format: EncryptedFormat, Box: *box}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Decrypt opens an encrypted config file
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	var f encryptedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	switch f.Format {
+	case EncryptedFormat:
+	case legacyEncryptedFormat:
+		return nil, fmt.Errorf("the file was encrypted with PBKDF2 by an older catalogctl; decrypt it with that version and run config encrypt again")
+	default:
+		return nil, fmt.Errorf("not an encrypted config file")
+	}
+	return f.Open(passphrase, []byte(EncryptedFormat))
+}
+
+// ReadFile reads a config file, decrypting it if it is encrypted. The
+// passphrase is asked for once per file.
+func ReadFile(filename string) (data []byte, encrypted bool, err error) {
+	data, err = os.ReadFile(filename)
+	if err != nil || !IsEncrypted(data) {
+		return data, false, err
+	}
+	passphrase, ok := passphrases[filename]
+	if !ok {
+		if passphrase, err = Passphrase(filename); err != nil {
+			return nil, true, err
+		}
+	}
+	plaintext, err := Decrypt(data, passphrase)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to decrypt %s: %w", filename, err)
+	}
+	passphrases[filename] = passphrase
+	return plaintext, true, nil
+}
+
+// SetPassphrase encrypts a config file with passphrase, re-keys it if it is
+// already encrypted, or decrypts it in place when passphrase is empty
+func SetPassphrase(filename, passphrase string) error {
+	data, _, err := ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("%s is not valid JSON", filename)
+	}
+	if passphrase == "" {
+		delete(passphrases, filename)
+		return writeFileAtomic(filename, data, 0600)
+	}
+	return writeEncrypted(filename, data, passphrase)
+}
+
+// writeEncrypted seals plaintext with passphrase and writes it to filename
+// atomically
+func writeEncrypted(filename string, plaintext []byte, passphrase string) error {
+	data, err := Encrypt(plaintext, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filename, data, 0600); err != nil {
+		return err
+	}
+	passphrases[filename] = passphrase
+	return nil
+}
+
+// writeConfigFile writes a config file, sealing it again if it was
+// encrypted when read
+func writeConfigFile(filename string, data []byte, encrypted bool) error {
+	if encrypted {
+		return writeEncrypted(filename, data, passphrases[filename])
+	}
+	return writeFileAtomic(filename, data, 0600)
+}
@@ -0,0 +1,62 @@
+package config
+
+import "sort"
+
+// SuiRPCEndpoints returns sui_rpc_url followed by sui_rpc_urls, without duplicates
+func (c *Config) SuiRPCEndpoints() []string {
+	return endpointList(c.SuiRPCURL, c.SuiRPCURLs)
+}
+
+// WalrusAggregatorEndpoints returns walrus_aggregator_url followed by
+// walrus_aggregator_urls and the walrus_aggregator_regions entries (by region
+// name), without duplicates
+func (c *Config) WalrusAggregatorEndpoints() []string {
+	extra := append([]string(nil), c.WalrusAggregatorURLs...)
+	for _, region := range sortedKeys(c.WalrusAggregatorRegions) {
+		extra = append(extra, c.WalrusAggregatorRegions[region]...)
+	}
+	return endpointList(c.WalrusAggregatorURL, extra)
+}
+
+// WalrusAggregatorRegion returns the region tag of an aggregator, or "" if
+// it has none
+func (c *Config) WalrusAggregatorRegion(url string) string {
+	for _, region := range sortedKeys(c.WalrusAggregatorRegions) {
+		for _, u := range c.WalrusAggregatorRegions[region] {
+			if u == url {
+				return region
+			}
+		}
+	}
+	return ""
+}
+
+// WalrusPublisherEndpoints returns walrus_publisher_url followed by
+// walrus_publisher_urls, without duplicates
+func (c *Config) WalrusPublisherEndpoints() []string {
+	return endpointList(c.WalrusPublisherURL, c.WalrusPublisherURLs)
+}
+
+// endpointList joins the primary endpoint and the extra ones in order
+func endpointList(primary string, extra []string) []string {
+	seen := make(map[string]bool)
+	var urls []string
+	for _, u := range append([]string{primary}, extra...) {
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// sortedKeys returns the keys of a region map in order
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Built-in explorers for the explorer config field
+const (
+	ExplorerSuiscan   = "suiscan"
+	ExplorerSuivision = "suivision"
+)
+
+// DefaultExplorer is used when explorer is not set
+const DefaultExplorer = ExplorerSuiscan
+
+// Link kinds accepted by ExplorerURL
+const (
+	LinkTx      = "tx"
+	LinkObject  = "object"
+	LinkAccount = "account"
+)
+
+// ExplorerURL returns a link to a transaction, object or account on the
+// configured explorer, or "" if there is none for the network (localnet).
+//
+// explorer is either a built-in name ("suiscan", "suivision") or a custom
+// template using {network}, {kind} and {id}, e.g.
+// "https://explorer.example.com/{network}/{kind}/{id}".
+func (c *Config) ExplorerURL(kind, id string) string {
+	if id == "" {
+		return ""
+	}
+	network := strings.ToLower(c.SuiNetwork)
+
+	switch explorer := c.explorer(); explorer {
+	case ExplorerSuiscan:
+		if network == "localnet" {
+			return ""
+		}
+		return fmt.Sprintf("https://suiscan.xyz/%s/%s/%s", network, kind, id)
+
+	case ExplorerSuivision:
+		if network == "localnet" {
+			return ""
+		}
+		host := "suivision.xyz"
+		if network != "mainnet" {
+			host = network + ".suivision.xyz"
+		}
+		path := kind
+		if kind == LinkTx {
+			path = "txblock"
+		}
+		return fmt.Sprintf("https://%s/%s/%s", host, path, id)
+
+	default:
+		return strings.NewReplacer(
+			"{network}", network,
+			"{kind}", kind,
+			"{id}", id,
+		).Replace(explorer)
+	}
+}
+
+// explorer returns the configured explorer, normalizing built-in names
+func (c *Config) explorer() string {
+	explorer := strings.TrimSpace(c.Explorer)
+	if explorer == "" {
+		return DefaultExplorer
+	}
+	if lower := strings.ToLower(explorer); lower == ExplorerSuiscan || lower == ExplorerSuivision {
+		return lower
+	}
+	return explorer
+}
+
+// checkExplorer validates the explorer field for Check
+func checkExplorer(explorer string) string {
+	explorer = strings.TrimSpace(explorer)
+	switch strings.ToLower(explorer) {
+	case "", ExplorerSuiscan, ExplorerSuivision:
+		return ""
+	}
+	if !strings.Contains(explorer, "{id}") {
+		return fmt.Sprintf("%q is neither a known explorer (%s, %s) nor a template containing {id}", explorer, ExplorerSuiscan, ExplorerSuivision)
+	}
+	if !strings.HasPrefix(explorer, "http://") && !strings.HasPrefix(explorer, "https://") {
+		return fmt.Sprintf("explorer template %q must start with http:// or https://", explorer)
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package config
+
+import "github.com/retro-crypto/sui/internal/walrus"
+
+// PublisherHMAC configures HMAC request signing for publishers that
+// authenticate uploads with signed headers. Templates can use {method},
+// {path}, {host}, {timestamp}, {nonce}, {body_sha256} and, in headers,
+// {signature}.
+type PublisherHMAC struct {
+	// Shared secret (or CATALOGCTL_WALRUS_HMAC_SECRET)
+	Secret string `json:"secret,omitempty"`
+	// What is signed; defaults to "{method}\n{path}\n{timestamp}\n{body_sha256}"
+	StringToSign string `json:"string_to_sign,omitempty"`
+	// Header names and value templates; default X-Walrus-Timestamp and
+	// X-Walrus-Signature
+	Headers map[string]string `json:"headers,omitempty"`
+	// Encoding of {signature}: hex (default) or base64
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// PublisherSigner returns the signer for publisher requests configured by
+// walrus_publisher_hmac, or nil if requests aren't signed
+func (c *Config) PublisherSigner() (*walrus.HMACSigner, error) {
+	h := c.WalrusPublisherHMAC
+	if h == nil {
+		return nil, nil
+	}
+	return walrus.NewHMACSigner([]byte(h.Secret), h.StringToSign, h.Headers, h.Encoding)
+}
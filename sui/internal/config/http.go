@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/httpclient"
+)
+
+// HTTPSettings returns the outbound HTTP settings of the http_* options,
+// with defaults for the unset ones. TLS is left to TLSConfig.
+func (c *Config) HTTPSettings() (httpclient.Settings, error) {
+	s := httpclient.DefaultSettings()
+	if c.HTTPTimeout != "" {
+		timeout, err := time.ParseDuration(c.HTTPTimeout)
+		if err != nil || timeout <= 0 {
+			return s, fmt.Errorf("http_timeout: %q is not a positive duration (e.g. 45s or 2m)", c.HTTPTimeout)
+		}
+		s.Timeout = timeout
+	}
+	if c.HTTPMaxIdleConns < 0 || c.HTTPMaxConnsPerHost < 0 {
+		return s, fmt.Errorf("http_max_idle_conns and http_max_conns_per_host must not be negative")
+	}
+	if c.HTTPMaxIdleConns > 0 {
+		s.MaxIdleConns = c.HTTPMaxIdleConns
+		s.MaxIdleConnsPerHost = min(s.MaxIdleConnsPerHost, c.HTTPMaxIdleConns)
+	}
+	if c.HTTPMaxConnsPerHost > 0 {
+		s.MaxConnsPerHost = c.HTTPMaxConnsPerHost
+	}
+	return s, nil
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// HasTLS reports whether any TLS option is set
+func (c *Config) HasTLS() bool {
+	return c.TLSCAFile != "" || c.TLSClientCert != "" || c.TLSClientKey != "" || c.TLSInsecureSkipVerify
+}
+
+// TLSConfig builds the TLS settings for HTTPS endpoints from tls_ca_file,
+// tls_client_cert/tls_client_key and tls_insecure_skip_verify. It returns
+// nil if none is set, so the system defaults apply.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if !c.HasTLS() {
+		return nil, nil
+	}
+	conf := &tls.Config{InsecureSkipVerify: c.TLSInsecureSkipVerify}
+
+	if c.TLSCAFile != "" {
+		pem, err := os.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls_ca_file: %w", err)
+		}
+		// The bundle is trusted in addition to the system roots
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls_ca_file: no PEM certificates in %s", c.TLSCAFile)
+		}
+		conf.RootCAs = pool
+	}
+
+	if c.TLSClientCert != "" || c.TLSClientKey != "" {
+		if c.TLSClientCert == "" || c.TLSClientKey == "" {
+			return nil, fmt.Errorf("tls_client_cert and tls_client_key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.TLSClientCert, c.TLSClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("tls_client_cert/tls_client_key: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
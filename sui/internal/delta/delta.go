@@ -0,0 +1,222 @@
+// Package delta computes and applies binary patches between two versions of
+// a game file, so an update only stores the bytes that changed.
+//
+// A patch is the magic "RCD1" followed by a gzip stream of:
+//
+//	uvarint base size, uvarint target size
+//	ops, each one of:
+//	  0x01 COPY  uvarint offset, uvarint length   (bytes from the base)
+//	  0x02 ADD   uvarint length, length bytes     (new bytes)
+//	0x00 END
+package delta
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Format names the patch format on chain
+const Format = "rcd1"
+
+// Magic starts every patch
+const Magic = "RCD1"
+
+const (
+	opEnd  = 0x00
+	opCopy = 0x01
+	opAdd  = 0x02
+)
+
+// blockSize is the length of the base blocks that are indexed for matching;
+// shorter common runs are sent as new bytes
+const blockSize = 32
+
+// hashBase is the multiplier of the rolling hash
+const hashBase = 16777619
+
+// Diff returns a patch that turns base into target. Both files are held in
+// memory; the index of base takes about 16 bytes per 32 bytes of base.
+func Diff(base, target []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(Magic)
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	w := &opWriter{w: bufio.NewWriter(zw)}
+	w.uvarint(uint64(len(base)))
+	w.uvarint(uint64(len(target)))
+
+	// Index the aligned blocks of base by hash (first occurrence wins)
+	index := make(map[uint32]int, len(base)/blockSize+1)
+	for off := 0; off+blockSize <= len(base); off += blockSize {
+		h := blockHash(base[off : off+blockSize])
+		if _, ok := index[h]; !ok {
+			index[h] = off
+		}
+	}
+
+	// Slide a rolling hash over target; on a verified block match, extend
+	// it both ways and emit the pending new bytes plus a copy
+	var outFactor uint32 = 1
+	for i := 0; i < blockSize; i++ {
+		outFactor *= hashBase
+	}
+	pos, pending := 0, 0
+	var h uint32
+	if len(target) >= blockSize {
+		h = blockHash(target[:blockSize])
+	}
+	for pos+blockSize <= len(target) {
+		if off, ok := index[h]; ok && bytes.Equal(base[off:off+blockSize], target[pos:pos+blockSize]) {
+			start, tstart := off, pos
+			for start > 0 && tstart > pending && base[start-1] == target[tstart-1] {
+				start--
+				tstart--
+			}
+			end, tend := off+blockSize, pos+blockSize
+			for end < len(base) && tend < len(target) && base[end] == target[tend] {
+				end++
+				tend++
+			}
+			w.add(target[pending:tstart])
+			w.copy(start, end-start)
+			pos, pending = tend, tend
+			if pos+blockSize <= len(target) {
+				h = blockHash(target[pos : pos+blockSize])
+			}
+			continue
+		}
+		if pos+blockSize < len(target) {
+			h = h*hashBase + uint32(target[pos+blockSize]) - uint32(target[pos])*outFactor
+		}
+		pos++
+	}
+	w.add(target[pending:])
+	w.byte(opEnd)
+
+	if w.err != nil {
+		return nil, w.err
+	}
+	if err := w.w.Flush(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func blockHash(b []byte) uint32 {
+	var h uint32
+	for _, c := range b {
+		h = h*hashBase + uint32(c)
+	}
+	return h
+}
+
+// opWriter writes patch ops, keeping the first error
+type opWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (o *opWriter) byte(b byte) {
+	if o.err == nil {
+		o.err = o.w.WriteByte(b)
+	}
+}
+
+func (o *opWriter) uvarint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	if o.err == nil {
+		_, o.err = o.w.Write(tmp[:n])
+	}
+}
+
+func (o *opWriter) add(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	o.byte(opAdd)
+	o.uvarint(uint64(len(data)))
+	if o.err == nil {
+		_, o.err = o.w.Write(data)
+	}
+}
+
+func (o *opWriter) copy(offset, length int) {
+	o.byte(opCopy)
+	o.uvarint(uint64(offset))
+	o.uvarint(uint64(length))
+}
+
+// Apply rebuilds the target file from base and a patch written by Diff
+func Apply(base, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, []byte(Magic)) {
+		return nil, fmt.Errorf("not a delta patch (missing %s header)", Magic)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(patch[len(Magic):]))
+	if err != nil {
+		return nil, fmt.Errorf("corrupt delta patch: %w", err)
+	}
+	r := bufio.NewReader(zr)
+
+	baseSize, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt delta patch: %w", err)
+	}
+	targetSize, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt delta patch: %w", err)
+	}
+	if baseSize != uint64(len(base)) {
+		return nil, fmt.Errorf("patch expects a %d-byte base, got %d bytes", baseSize, len(base))
+	}
+
+	out := make([]byte, 0, targetSize)
+	for {
+		op, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("corrupt delta patch: %w", err)
+		}
+		switch op {
+		case opEnd:
+			if uint64(len(out)) != targetSize {
+				return nil, fmt.Errorf("patch produced %d bytes, expected %d", len(out), targetSize)
+			}
+			return out, nil
+		case opCopy:
+			offset, err1 := binary.ReadUvarint(r)
+			length, err2 := binary.ReadUvarint(r)
+			if err := errors.Join(err1, err2); err != nil {
+				return nil, fmt.Errorf("corrupt delta patch: %w", err)
+			}
+			if offset > baseSize || length > baseSize-offset || uint64(len(out))+length > targetSize {
+				return nil, fmt.Errorf("corrupt delta patch: copy out of range")
+			}
+			out = append(out, base[offset:offset+length]...)
+		case opAdd:
+			length, err := binary.ReadUvarint(r)
+			if err != nil {
+				return nil, fmt.Errorf("corrupt delta patch: %w", err)
+			}
+			if uint64(len(out))+length > targetSize {
+				return nil, fmt.Errorf("corrupt delta patch: data past the target size")
+			}
+			start := len(out)
+			out = append(out, make([]byte, length)...)
+			if _, err := io.ReadFull(r, out[start:]); err != nil {
+				return nil, fmt.Errorf("corrupt delta patch: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("corrupt delta patch: unknown op 0x%02x", op)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Package fido2 binds an encrypted file to a FIDO2 security key: a
+// credential with the hmac-secret extension is enrolled once, and the file
+// is then sealed under a key-encryption key derived from the secret the
+// security key computes and the file's passphrase. Neither is enough alone;
+// opening the file needs the passphrase and a touch of the key. The
+// authenticator is driven through the fido2-cred and fido2-assert tools of
+// libfido2, so no cgo is needed.
+package fido2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// RelyingParty is the relying party ID credentials are made for
+const RelyingParty = "catalogctl"
+
+// kekInfo separates the key-encryption key from other uses of the secret
+const kekInfo = "catalogctl fido2 kek v1"
+
+// Credential is an enrolled security key and the file bound to it. It holds
+// nothing derived from the hmac-secret.
+type Credential struct {
+	Device       string `json:"device"`
+	CredentialID string `json:"credential_id"`
+	Salt         string `json:"salt"`
+	// File is the encrypted config or keystore file sealed under the KEK
+	File string `json:"file"`
+}
+
+// Enroll makes a credential with hmac-secret on the key at device (a path
+// such as /dev/hidraw0, see `fido2-token -L`) for binding file. The key is
+// touched once; deriving the KEK takes another touch.
+func Enroll(device, file string) (*Credential, error) {
+	userID, err := randomBase64(32)
+	if err != nil {
+		return nil, err
+	}
+	cdh, err := randomBase64(32)
+	if err != nil {
+		return nil, err
+	}
+	// Input: client data hash, relying party, user name, user id
+	out, err := run("fido2-cred", []string{cdh, RelyingParty, "catalogctl", userID}, "-M", "-h", device)
+	if err != nil {
+		return nil, err
+	}
+	// Output: client data hash, relying party, format, auth data, credential id, ...
+	if len(out) < 5 {
+		return nil, fmt.Errorf("unexpected fido2-cred output (%d lines)", len(out))
+	}
+
+	cred := &Credential{Device: device, CredentialID: out[4], File: file}
+	if cred.Salt, err = randomBase64(32); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// KEK asks for a touch of the enrolled key and derives the key-encryption
+// key of the bound file from its hmac-secret and the file's passphrase. A
+// different security key or passphrase gives a different KEK, which fails
+// to open the file.
+func (c *Credential) KEK(passphrase string) ([]byte, error) {
+	secret, err := c.secret()
+	if err != nil {
+		return nil, err
+	}
+	salt, err := base64.StdEncoding.DecodeString(c.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt in the enrolled credential: %w", err)
+	}
+	return deriveKEK(secret, salt, passphrase)
+}
+
+// deriveKEK is HKDF-SHA256 over the hmac-secret and the passphrase
+func deriveKEK(secret, salt []byte, passphrase string) ([]byte, error) {
+	ikm := append(append([]byte{}, secret...), passphrase...)
+	kek := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, ikm, salt, []byte(kekInfo)), kek); err != nil {
+		return nil, err
+	}
+	return kek, nil
+}
+
+// secret gets an assertion with hmac-secret for the credential's salt
+func (c *Credential) secret() ([]byte, error) {
+	cdh, err := randomBase64(32)
+	if err != nil {
+		return nil, err
+	}
+	// Input: client data hash, relying party, credential id, hmac salt
+	out, err := run("fido2-assert", []string{cdh, RelyingParty, c.CredentialID, c.Salt}, "-G", "-h", c.Device)
+	if err != nil {
+		return nil, err
+	}
+	// The hmac-secret is the last line of the output
+	if len(out) < 5 {
+		return nil, fmt.Errorf("unexpected fido2-assert output (%d lines)", len(out))
+	}
+	secret, err := base64.StdEncoding.DecodeString(out[len(out)-1])
+	if err != nil || len(secret) == 0 {
+		return nil, fmt.Errorf("the security key returned no hmac-secret (does it support the extension?)")
+	}
+	return secret, nil
+}
+
+// Load reads an enrolled credential
+func Load(path string) (*Credential, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no security key enrolled (%s missing; run `catalogctl agent fido2-enroll`)", path)
+		}
+		return nil, err
+	}
+	var cred Credential
+	if err := json.Unmarshal(data, &cred); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if cred.CredentialID == "" || cred.Salt == "" || cred.File == "" {
+		return nil, fmt.Errorf("%s is incomplete or from an older catalogctl; remove it and enroll the key again", path)
+	}
+	return &cred, nil
+}
+
+// Save writes an enrolled credential, readable by the current user only
+func (c *Credential) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(c, "", "  ")
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// run feeds input lines to a libfido2 tool and returns its output lines.
+// The tools prompt for the PIN and the touch on the terminal themselves.
+func run(tool string, input []string, args ...string) ([]string, error) {
+	cmd := exec.Command(tool, args...)
+	cmd.Stdin = strings.NewReader(strings.Join(input, "\n") + "\n")
+	cmd.Stderr = os.Stderr
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		if _, lookErr := exec.LookPath(tool); lookErr != nil {
+			return nil, fmt.Errorf("%s not found: install the libfido2 tools (fido2-tools)", tool)
+		}
+		return nil, fmt.Errorf("%s failed: %w", tool, err)
+	}
+	return strings.Fields(stdout.String()), nil
+}
+
+func randomBase64(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
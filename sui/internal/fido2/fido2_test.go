@@ -0,0 +1,41 @@
+package fido2
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveKEK(t *testing.T) {
+	secret := bytes.Repeat([]byte{1}, 32)
+	salt := bytes.Repeat([]byte{2}, 32)
+	kek, err := deriveKEK(secret, salt, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kek) != 32 {
+		t.Fatalf("KEK is %d bytes, want 32", len(kek))
+	}
+	again, _ := deriveKEK(secret, salt, "passphrase")
+	if !bytes.Equal(kek, again) {
+		t.Error("the KEK isn't deterministic")
+	}
+
+	// Either factor alone changes the KEK
+	otherSecret := bytes.Repeat([]byte{3}, 32)
+	for name, got := range map[string][]byte{
+		"secret":     must(deriveKEK(otherSecret, salt, "passphrase")),
+		"passphrase": must(deriveKEK(secret, salt, "other")),
+		"salt":       must(deriveKEK(secret, otherSecret, "passphrase")),
+	} {
+		if bytes.Equal(kek, got) {
+			t.Errorf("another %s gives the same KEK", name)
+		}
+	}
+}
+
+func must(b []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
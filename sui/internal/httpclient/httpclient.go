@@ -0,0 +1,115 @@
+// Package httpclient builds the HTTP clients catalogctl talks to Sui, Walrus
+// and Nimiq with. They share one transport, so connection limits and TLS
+// settings apply to every endpoint, and a configured timeout overrides each
+// client's default.
+package httpclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults of Settings
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultMaxConnsPerHost     = 32
+)
+
+// Settings apply to every outbound HTTP client
+type Settings struct {
+	// Timeout of a whole request; 0 keeps each client's default (30s for
+	// RPC calls, 5 minutes for blob transfers)
+	Timeout time.Duration
+	// MaxIdleConns bounds the keep-alive connections kept open across hosts
+	MaxIdleConns int
+	// MaxIdleConnsPerHost bounds the keep-alive connections kept per host
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost bounds the connections, and so the requests in
+	// flight, per host; 0 is unlimited
+	MaxConnsPerHost int
+	// TLS for HTTPS endpoints; nil uses the system defaults
+	TLS *tls.Config
+}
+
+// DefaultSettings are used until Configure is called
+func DefaultSettings() Settings {
+	return Settings{
+		MaxIdleConns:        DefaultMaxIdleConns,
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		MaxConnsPerHost:     DefaultMaxConnsPerHost,
+	}
+}
+
+var (
+	mu        sync.RWMutex
+	settings  = DefaultSettings()
+	transport = newTransport(settings)
+)
+
+// Validate checks the limits
+func (s Settings) Validate() error {
+	switch {
+	case s.Timeout < 0:
+		return fmt.Errorf("timeout must not be negative")
+	case s.MaxIdleConns < 0, s.MaxIdleConnsPerHost < 0:
+		return fmt.Errorf("idle connection limits must not be negative")
+	case s.MaxConnsPerHost < 0:
+		return fmt.Errorf("connections per host must not be negative (0 is unlimited)")
+	}
+	return nil
+}
+
+// Configure replaces the settings. Clients created before keep the old ones,
+// so it is called once at startup.
+func Configure(s Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	settings = s
+	transport = newTransport(s)
+	return nil
+}
+
+// Current returns the settings in use
+func Current() Settings {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings
+}
+
+// New returns a client on the shared transport. defaultTimeout applies
+// unless a timeout is configured.
+func New(defaultTimeout time.Duration) *http.Client {
+	mu.RLock()
+	defer mu.RUnlock()
+	timeout := defaultTimeout
+	if settings.Timeout > 0 {
+		timeout = settings.Timeout
+	}
+	return &http.Client{Timeout: timeout, Transport: traced(transport)}
+}
+
+// NewFixed returns a client on the shared transport with a timeout that the
+// settings don't override, for probes whose timeout is part of the check
+func NewFixed(timeout time.Duration) *http.Client {
+	mu.RLock()
+	defer mu.RUnlock()
+	return &http.Client{Timeout: timeout, Transport: traced(transport)}
+}
+
+func newTransport(s Settings) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = s.MaxIdleConns
+	t.MaxIdleConnsPerHost = s.MaxIdleConnsPerHost
+	t.MaxConnsPerHost = s.MaxConnsPerHost
+	if s.TLS != nil {
+		t.TLSClientConfig = s.TLS
+	}
+	return t
+}
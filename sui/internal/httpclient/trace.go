@@ -0,0 +1,120 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/retro-crypto/shared/logging"
+)
+
+// traceBodyLimit bounds how much of a body is read for tracing
+const traceBodyLimit = 4096
+
+var (
+	traceMu     sync.Mutex
+	traceLogger *slog.Logger
+)
+
+// SetTrace logs the requests of clients created afterwards to logger: one
+// record per request at slog.LevelDebug, and the JSON request and response
+// bodies at logging.LevelTrace. Blob transfers are never logged beyond their
+// request record.
+func SetTrace(logger *slog.Logger) {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	traceLogger = logger
+}
+
+// traced wraps rt when the trace logger takes debug records
+func traced(rt http.RoundTripper) http.RoundTripper {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	if traceLogger == nil || !traceLogger.Enabled(context.Background(), slog.LevelDebug) {
+		return rt
+	}
+	return &traceTransport{next: rt, logger: traceLogger}
+}
+
+type traceTransport struct {
+	next   http.RoundTripper
+	logger *slog.Logger
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	bodies := t.logger.Enabled(ctx, logging.LevelTrace)
+	reqBody := peekRequestBody(req)
+	attrs := []interface{}{"method", req.Method, "url", req.URL.Redacted()}
+	if method := rpcMethod(reqBody); method != "" {
+		attrs = append(attrs, "rpc", method)
+	}
+	if bodies && len(reqBody) > 0 {
+		t.logger.Log(ctx, logging.LevelTrace, "http request body", append(attrs, "body", clip(reqBody))...)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.logger.Debug("http request failed", append(attrs, "duration", elapsed, "error", err)...)
+		return nil, err
+	}
+	t.logger.Debug("http request", append(attrs, "status", resp.StatusCode, "duration", elapsed)...)
+	if bodies && isJSON(resp.Header.Get("Content-Type")) {
+		head, _ := io.ReadAll(io.LimitReader(resp.Body, traceBodyLimit+1))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+		t.logger.Log(ctx, logging.LevelTrace, "http response body", append(attrs, "body", clip(head))...)
+	}
+	return resp, nil
+}
+
+// peekRequestBody returns the start of a JSON request body without
+// consuming it; blob uploads and other bodies return nil
+func peekRequestBody(req *http.Request) []byte {
+	if req.GetBody == nil || !isJSON(req.Header.Get("Content-Type")) {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(io.LimitReader(body, traceBodyLimit+1))
+	return data
+}
+
+// rpcMethod extracts the method of a JSON-RPC request
+func rpcMethod(body []byte) string {
+	if len(body) == 0 || len(body) > traceBodyLimit {
+		return ""
+	}
+	var call struct {
+		Method string `json:"method"`
+	}
+	if json.Unmarshal(body, &call) != nil {
+		return ""
+	}
+	return call.Method
+}
+
+func isJSON(contentType string) bool {
+	return strings.Contains(contentType, "json")
+}
+
+func clip(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) > traceBodyLimit {
+		return s[:traceBodyLimit] + "…"
+	}
+	return s
+}
@@ -0,0 +1,207 @@
+// Package i18n translates user-facing CLI messages.
+//
+// Messages are keyed by their English text, so English needs no catalog and
+// a message missing from a locale prints in English. Catalogs live in
+// locales/<lang>.json as {"English": "translation"} and are embedded in the
+// binary. Surrounding whitespace (indentation, newlines) is not part of the
+// key, and a translation whose format verbs differ from the English ones is
+// ignored so a typo in a catalog can never garble output.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Default is the source language of every message
+const Default = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	mu       sync.RWMutex
+	current  = Default
+	catalogs map[string]map[string]string
+	loadErr  error
+	loadOnce sync.Once
+)
+
+// verbPattern matches fmt verbs, including flags, width and precision
+var verbPattern = regexp.MustCompile(`%[-+# 0]*(\*|\d+)?(\.(\*|\d+))?[a-zA-Z%]`)
+
+func load() {
+	catalogs = map[string]map[string]string{}
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		loadErr = err
+		return
+	}
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			loadErr = err
+			return
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			loadErr = fmt.Errorf("invalid locale %s: %w", entry.Name(), err)
+			return
+		}
+		catalog := make(map[string]string, len(messages))
+		for key, msg := range messages {
+			if msg != "" && sameVerbs(key, msg) {
+				catalog[key] = msg
+			}
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = catalog
+	}
+}
+
+func sameVerbs(a, b string) bool {
+	va, vb := verbPattern.FindAllString(a, -1), verbPattern.FindAllString(b, -1)
+	if len(va) != len(vb) {
+		return false
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Locales lists the supported languages, Default first
+func Locales() []string {
+	loadOnce.Do(load)
+	langs := []string{Default}
+	for lang := range catalogs {
+		if lang != Default {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// Normalize reduces a locale such as "es_AR.UTF-8" or "pt-BR" to its
+// language ("es", "pt"); "C" and "POSIX" mean Default
+func Normalize(locale string) string {
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if i := strings.IndexAny(lang, "_-"); i >= 0 {
+		lang = lang[:i]
+	}
+	if lang == "c" || lang == "posix" {
+		return Default
+	}
+	return lang
+}
+
+// Supported reports whether lang (after Normalize) has a catalog
+func Supported(lang string) bool {
+	loadOnce.Do(load)
+	lang = Normalize(lang)
+	_, ok := catalogs[lang]
+	return lang == Default || ok
+}
+
+// Detect picks the language: flag when set, else the first of LC_ALL,
+// LC_MESSAGES and LANG (in POSIX order) that is set. An unsupported
+// environment locale falls back to Default; an unsupported flag is an error.
+func Detect(flag string) (string, error) {
+	if flag != "" {
+		if !Supported(flag) {
+			return "", fmt.Errorf("unsupported language %q (available: %s)", flag, strings.Join(Locales(), ", "))
+		}
+		return Normalize(flag), nil
+	}
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			if Supported(v) {
+				return Normalize(v), nil
+			}
+			return Default, nil
+		}
+	}
+	return Default, nil
+}
+
+// SetLocale switches the language of T and the print helpers
+func SetLocale(lang string) error {
+	if !Supported(lang) {
+		return fmt.Errorf("unsupported language %q (available: %s)", lang, strings.Join(Locales(), ", "))
+	}
+	mu.Lock()
+	current = Normalize(lang)
+	mu.Unlock()
+	return nil
+}
+
+// Locale returns the current language
+func Locale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Err reports a catalog that failed to load; its messages print in English
+func Err() error {
+	loadOnce.Do(load)
+	return loadErr
+}
+
+// T translates msg into the current language, keeping its surrounding
+// whitespace
+func T(msg string) string {
+	loadOnce.Do(load)
+	lang := Locale()
+	if lang == Default {
+		return msg
+	}
+	core := strings.TrimSpace(msg)
+	if core == "" {
+		return msg
+	}
+	translated, ok := catalogs[lang][core]
+	if !ok {
+		return msg
+	}
+	start := strings.Index(msg, core)
+	return msg[:start] + translated + msg[start+len(core):]
+}
+
+// Sprintf formats with the translated format
+func Sprintf(format string, args ...interface{}) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Printf prints with the translated format to stdout
+func Printf(format string, args ...interface{}) {
+	fmt.Printf(T(format), args...)
+}
+
+// Fprintf prints with the translated format to w
+func Fprintf(w io.Writer, format string, args ...interface{}) {
+	fmt.Fprintf(w, T(format), args...)
+}
+
+// Println prints the translated msg and a newline to stdout
+func Println(msg string) {
+	fmt.Println(T(msg))
+}
+
+// Fprintln prints the translated msg and a newline to w
+func Fprintln(w io.Writer, msg string) {
+	fmt.Fprintln(w, T(msg))
+}
@@ -0,0 +1,207 @@
+// Package identity keeps a local registry of where each game lives across
+// chains. Games are keyed by the SHA256 of their file, so the same release on
+// a Sui catalog (cartridge and Walrus blob) and on a Nimiq catalog (app ID and
+// cartridge address) is one record. The registry is a JSON file that can be
+// exported and merged into other registries.
+package identity
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/model"
+)
+
+// DefaultFile is the registry file in the config directory
+const DefaultFile = "games.json"
+
+// SuiCopy is a game published in a Sui catalog
+type SuiCopy struct {
+	Network   string `json:"network,omitempty"`
+	CatalogID string `json:"catalog_id"`
+	// Key is the entry key (slug, or slug@channel)
+	Key         string `json:"key"`
+	Version     uint16 `json:"version,omitempty"`
+	CartridgeID string `json:"cartridge_id"`
+	// BlobID is the Walrus blob ID (base58)
+	BlobID string `json:"blob_id,omitempty"`
+}
+
+// NimiqCopy is a game published in a Nimiq catalog
+type NimiqCopy struct {
+	CatalogAddr   string `json:"catalog_addr"`
+	Publisher     string `json:"publisher"`
+	AppID         uint32 `json:"app_id"`
+	Semver        string `json:"semver"`
+	CartridgeAddr string `json:"cartridge_addr"`
+}
+
+// Game is everything known about one game file
+type Game struct {
+	SHA256    string         `json:"sha256"`
+	Size      uint64         `json:"size"`
+	Title     string         `json:"title,omitempty"`
+	Platform  model.Platform `json:"platform"`
+	Sui       []SuiCopy      `json:"sui,omitempty"`
+	Nimiq     []NimiqCopy    `json:"nimiq,omitempty"`
+	UpdatedAt string         `json:"updated_at,omitempty"`
+}
+
+// Registry maps game SHA256s (lowercase hex) to games
+type Registry struct {
+	Games map[string]*Game `json:"games"`
+}
+
+// Load reads a registry file (empty if it doesn't exist)
+func Load(path string) (*Registry, error) {
+	r := &Registry{Games: make(map[string]*Game)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if r.Games == nil {
+		r.Games = make(map[string]*Game)
+	}
+	return r, nil
+}
+
+// Save writes the registry, replacing the file atomically
+func (r *Registry) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Lookup returns the game with a SHA256, or nil. A unique prefix of at least
+// 8 hex digits is accepted.
+func (r *Registry) Lookup(sha256Hex string) *Game {
+	sha256Hex = strings.ToLower(sha256Hex)
+	if g, ok := r.Games[sha256Hex]; ok {
+		return g
+	}
+	if len(sha256Hex) < 8 {
+		return nil
+	}
+	var found *Game
+	for sha, g := range r.Games {
+		if strings.HasPrefix(sha, sha256Hex) {
+			if found != nil {
+				return nil
+			}
+			found = g
+		}
+	}
+	return found
+}
+
+// Sorted returns the games ordered by title, then SHA256
+func (r *Registry) Sorted() []*Game {
+	games := make([]*Game, 0, len(r.Games))
+	for _, g := range r.Games {
+		games = append(games, g)
+	}
+	sort.Slice(games, func(i, j int) bool {
+		if games[i].Title != games[j].Title {
+			return games[i].Title < games[j].Title
+		}
+		return games[i].SHA256 < games[j].SHA256
+	})
+	return games
+}
+
+// game returns the record of a file, creating it if needed
+func (r *Registry) game(sha256Hex string, size uint64, title string, platform model.Platform) *Game {
+	sha256Hex = strings.ToLower(sha256Hex)
+	g, ok := r.Games[sha256Hex]
+	if !ok {
+		g = &Game{SHA256: sha256Hex, Size: size, Platform: platform}
+		r.Games[sha256Hex] = g
+	}
+	if g.Title == "" {
+		g.Title = title
+	}
+	return g
+}
+
+// AddSui records a Sui copy of a game. It returns false if the copy was
+// already known.
+func (r *Registry) AddSui(sha256Hex string, size uint64, title string, platform model.Platform, c SuiCopy) bool {
+	g := r.game(sha256Hex, size, title, platform)
+	for i, have := range g.Sui {
+		if have.CartridgeID == c.CartridgeID && have.CatalogID == c.CatalogID && have.Key == c.Key {
+			if have == c {
+				return false
+			}
+			g.Sui[i] = c
+			g.touch()
+			return true
+		}
+	}
+	g.Sui = append(g.Sui, c)
+	g.touch()
+	return true
+}
+
+// AddNimiq records a Nimiq copy of a game. It returns false if the copy was
+// already known.
+func (r *Registry) AddNimiq(sha256Hex string, size uint64, title string, platform model.Platform, c NimiqCopy) bool {
+	g := r.game(sha256Hex, size, title, platform)
+	for _, have := range g.Nimiq {
+		if have == c {
+			return false
+		}
+	}
+	g.Nimiq = append(g.Nimiq, c)
+	g.touch()
+	return true
+}
+
+// Merge adds every copy of other to r and returns how many were new
+func (r *Registry) Merge(other *Registry) int {
+	added := 0
+	for _, g := range other.Games {
+		for _, c := range g.Sui {
+			if r.AddSui(g.SHA256, g.Size, g.Title, g.Platform, c) {
+				added++
+			}
+		}
+		for _, c := range g.Nimiq {
+			if r.AddNimiq(g.SHA256, g.Size, g.Title, g.Platform, c) {
+				added++
+			}
+		}
+	}
+	return added
+}
+
+func (g *Game) touch() {
+	g.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,170 @@
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/retro-crypto/shared/seal"
+)
+
+// FileFormat marks an encrypted keystore file
+const FileFormat = "retro-keystore-v1"
+
+// ErrWrongPassphrase is returned when a keystore file can't be opened
+var ErrWrongPassphrase = errors.New("wrong keystore passphrase (or the file was modified)")
+
+// keystoreFile is the on-disk form: the JSON map of secrets sealed with
+// AES-256-GCM under a key derived from the passphrase with scrypt (see
+// package seal)
+type keystoreFile struct {
+	Format string `json:"format"`
+	seal.Box
+	// Names lists the secrets in the file, so they can be listed without
+	// the passphrase
+	Names []string `json:"names"`
+}
+
+// fileStore is an encrypted keystore file. The passphrase is asked for on
+// first use and kept for writes.
+type fileStore struct {
+	path       string
+	passphrase func(path string, create bool) (string, error)
+	key        string
+}
+
+func (f *fileStore) String() string { return f.path }
+
+func (f *fileStore) Get(name string) (string, error) {
+	secrets, err := f.read()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%s has no key %q: %w", f.path, name, ErrNotFound)
+	}
+	return secret, nil
+}
+
+func (f *fileStore) Set(name, secret string) error {
+	secrets, err := f.read()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if secrets == nil {
+		secrets = map[string]string{}
+	}
+	secrets[name] = secret
+	return f.write(secrets)
+}
+
+func (f *fileStore) Delete(name string) error {
+	secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return fmt.Errorf("%s has no key %q: %w", f.path, name, ErrNotFound)
+	}
+	delete(secrets, name)
+	return f.write(secrets)
+}
+
+// Rekey seals a keystore file again under a new passphrase
+func Rekey(path, oldPassphrase, newPassphrase string) error {
+	if newPassphrase == "" {
+		return fmt.Errorf("keystore passphrase must not be empty")
+	}
+	f := &fileStore{path: expandHome(path), key: oldPassphrase}
+	secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+	f.key = newPassphrase
+	return f.write(secrets)
+}
+
+// read opens the file; a missing file returns an os.IsNotExist error
+func (f *fileStore) read() (map[string]string, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	var kf keystoreFile
+	if err := json.Unmarshal(data, &kf); err != nil || kf.Format != FileFormat {
+		return nil, fmt.Errorf("%s is not a keystore file", f.path)
+	}
+	if f.key == "" {
+		if f.key, err = f.passphrase(f.path, false); err != nil {
+			return nil, err
+		}
+	}
+	plaintext, err := kf.Open(f.key, []byte(FileFormat))
+	if errors.Is(err, seal.ErrWrongPassphrase) {
+		f.key = ""
+		return nil, fmt.Errorf("%s: %w", f.path, ErrWrongPassphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.path, err)
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("corrupt keystore file %s: %w", f.path, err)
+	}
+	return secrets, nil
+}
+
+// write seals the secrets with a fresh salt and nonce and replaces the file
+// atomically
+func (f *fileStore) write(secrets map[string]string) error {
+	if f.key == "" {
+		var err error
+		if f.key, err = f.passphrase(f.path, true); err != nil {
+			return err
+		}
+		if f.key == "" {
+			return fmt.Errorf("keystore passphrase must not be empty")
+		}
+	}
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	box, err := seal.Seal(plaintext, f.key, []byte(FileFormat))
+	if err != nil {
+		return err
+	}
+	kf := keystoreFile{Format: FileFormat, Box: *box}
+	for name := range secrets {
+		kf.Names = append(kf.Names, name)
+	}
+	sort.Strings(kf.Names)
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".keystore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
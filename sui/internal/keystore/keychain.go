@@ -0,0 +1,145 @@
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keychain stores secrets in the OS keychain under service/name. macOS and
+// Linux go through the security and secret-tool commands, so secrets are
+// passed on stdin and never show up in process listings; Windows calls the
+// Credential Manager API.
+type keychain struct {
+	service string
+}
+
+func newKeychain(service string) *keychain {
+	return &keychain{service: service}
+}
+
+func (k *keychain) String() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "macOS Keychain"
+	case "windows":
+		return "Windows Credential Manager"
+	}
+	return "Secret Service"
+}
+
+func (k *keychain) Get(name string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := k.security(fmt.Sprintf("find-generic-password -s %s -a %s -w", quote(k.service), quote(name)))
+		if err != nil {
+			return "", k.notFound(name, err)
+		}
+		return strings.TrimRight(out, "\n"), nil
+	case "windows":
+		secret, err := credRead(k.target(name))
+		if err != nil {
+			return "", k.notFound(name, err)
+		}
+		return secret, nil
+	}
+	out, err := k.secretTool(nil, "lookup", "service", k.service, "account", name)
+	if err != nil || out == "" {
+		return "", k.notFound(name, err)
+	}
+	return strings.TrimRight(out, "\n"), nil
+}
+
+func (k *keychain) Set(name, secret string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := k.security(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s", quote(k.service), quote(name), quote(secret)))
+		return err
+	case "windows":
+		return credWrite(k.target(name), name, secret)
+	}
+	_, err := k.secretTool(strings.NewReader(secret), "store", "--label", k.service+" "+name, "service", k.service, "account", name)
+	return err
+}
+
+func (k *keychain) Delete(name string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := k.security(fmt.Sprintf("delete-generic-password -s %s -a %s", quote(k.service), quote(name))); err != nil {
+			return k.notFound(name, err)
+		}
+		return nil
+	case "windows":
+		if err := credDelete(k.target(name)); err != nil {
+			return k.notFound(name, err)
+		}
+		return nil
+	}
+	if _, err := k.Get(name); err != nil {
+		return err
+	}
+	_, err := k.secretTool(nil, "clear", "service", k.service, "account", name)
+	return err
+}
+
+// target is the Credential Manager name of a secret
+func (k *keychain) target(name string) string {
+	return k.service + ":" + name
+}
+
+func (k *keychain) notFound(name string, err error) error {
+	if err == nil || errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%s has no key %q for %s: %w", k, name, k.service, ErrNotFound)
+	}
+	return fmt.Errorf("%s: %w", k, err)
+}
+
+// security runs one command of macOS security in interactive mode, which
+// reads it from stdin
+func (k *keychain) security(command string) (string, error) {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(command + "\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("security failed: %w", err)
+	}
+	// security -i reports failures on stderr but exits 0
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		if strings.Contains(msg, "could not be found") {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("security: %s", msg)
+	}
+	return stdout.String(), nil
+}
+
+// secretTool runs secret-tool (libsecret), the command line client of the
+// Secret Service that GNOME Keyring and KWallet provide
+func (k *keychain) secretTool(stdin *strings.Reader, args ...string) (string, error) {
+	cmd := exec.Command("secret-tool", args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("secret-tool is not installed (install libsecret-tools, or use key_source file)")
+	}
+	if err != nil {
+		if args[0] == "lookup" && stderr.Len() == 0 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("secret-tool %s failed: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// quote quotes an argument for the security -i command line
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package keystore
+
+import "fmt"
+
+// The Credential Manager API only exists on Windows
+
+func credRead(target string) (string, error) {
+	return "", fmt.Errorf("Windows Credential Manager is not available")
+}
+
+func credWrite(target, user, secret string) error {
+	return fmt.Errorf("Windows Credential Manager is not available")
+}
+
+func credDelete(target string) error {
+	return fmt.Errorf("Windows Credential Manager is not available")
+}
@@ -0,0 +1,146 @@
+// Package keystore keeps signing keys out of config files. A key source
+// (config field key_source) names where the key lives:
+//
+//	config              private_key / mnemonic in the config file (default)
+//	env[:VAR]           an environment variable, injected by the caller
+//	keychain[:NAME]     the OS keychain: macOS Keychain, Secret Service
+//	                    (secret-tool) or Windows Credential Manager
+//	file[:PATH]         an encrypted keystore file (scrypt + AES-256-GCM)
+//
+// Every store holds named secrets; NAME picks one, "default" otherwise.
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Kinds of key sources
+const (
+	KindConfig   = "config"
+	KindEnv      = "env"
+	KindKeychain = "keychain"
+	KindFile     = "file"
+)
+
+// DefaultName is the secret used when a source names none
+const DefaultName = "default"
+
+// ErrNotFound is returned when a store has no secret of that name
+var ErrNotFound = errors.New("no such key in the keystore")
+
+// Store reads and writes named secrets
+type Store interface {
+	Get(name string) (string, error)
+	Set(name, secret string) error
+	Delete(name string) error
+	// String describes the store for messages ("macOS Keychain", a path, ...)
+	String() string
+}
+
+// Source is a parsed key_source value
+type Source struct {
+	Kind string
+	// Arg is the variable of env, the secret name of keychain and the path
+	// of file; empty for the defaults
+	Arg string
+}
+
+// ParseSource parses a key_source value; empty means config
+func ParseSource(s string) (Source, error) {
+	kind, arg, _ := strings.Cut(strings.TrimSpace(s), ":")
+	switch kind {
+	case "":
+		return Source{Kind: KindConfig}, nil
+	case KindConfig:
+		if arg != "" {
+			return Source{}, fmt.Errorf("key_source %q: config takes no argument", s)
+		}
+	case KindEnv, KindKeychain, KindFile:
+	default:
+		return Source{}, fmt.Errorf("unknown key_source %q (want config, env[:VAR], keychain[:NAME] or file[:PATH])", s)
+	}
+	return Source{Kind: kind, Arg: arg}, nil
+}
+
+func (s Source) String() string {
+	if s.Arg == "" {
+		return s.Kind
+	}
+	return s.Kind + ":" + s.Arg
+}
+
+// Options configure the stores of one program
+type Options struct {
+	// Service names the program in the OS keychain ("catalogctl")
+	Service string
+	// EnvVar is read by a plain "env" source
+	EnvVar string
+	// File is the keystore file of a plain "file" source
+	File string
+	// Passphrase returns the passphrase of a keystore file; create is set
+	// when the file doesn't exist yet
+	Passphrase func(path string, create bool) (string, error)
+}
+
+// Open returns the store of a source and the name of the secret in it.
+// Config sources have no store.
+func Open(src Source, opts Options) (Store, string, error) {
+	switch src.Kind {
+	case KindEnv:
+		name := src.Arg
+		if name == "" {
+			name = opts.EnvVar
+		}
+		return envStore{}, name, nil
+	case KindKeychain:
+		name := src.Arg
+		if name == "" {
+			name = DefaultName
+		}
+		return newKeychain(opts.Service), name, nil
+	case KindFile:
+		path := src.Arg
+		if path == "" {
+			path = opts.File
+		}
+		if opts.Passphrase == nil {
+			return nil, "", fmt.Errorf("no passphrase source for keystore file %s", path)
+		}
+		return &fileStore{path: expandHome(path), passphrase: opts.Passphrase}, DefaultName, nil
+	}
+	return nil, "", fmt.Errorf("key_source %s has no keystore", src)
+}
+
+// envStore reads secrets from environment variables named like the secret.
+// They are injected by whatever starts the program (CI secrets, systemd
+// credentials, a password manager's run command), so it is read-only.
+type envStore struct{}
+
+func (envStore) Get(name string) (string, error) {
+	if v := os.Getenv(name); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("$%s is not set: %w", name, ErrNotFound)
+}
+
+func (envStore) Set(name, secret string) error {
+	return fmt.Errorf("environment keys are read-only; set $%s where the program is started", name)
+}
+
+func (envStore) Delete(name string) error {
+	return fmt.Errorf("environment keys are read-only; unset $%s where the program is started", name)
+}
+
+func (envStore) String() string { return "environment" }
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + string(os.PathSeparator) + rest
+		}
+	}
+	return path
+}
@@ -0,0 +1,1087 @@
+package memchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/sui"
+)
+
+// Move abort codes of the catalog module
+const (
+	errNotOwner      = 1
+	errEntryExists   = 2
+	errEntryNotFound = 3
+	errNotCurator    = 4
+	errCountChanged  = 5
+	errTooManyTags   = 6
+)
+
+// maxTags is MAX_TAGS of the catalog module
+const maxTags = 16
+
+// Move abort codes of the registry module
+const (
+	errNotAdmin        = 1
+	errCatalogExists   = 2
+	errCatalogNotFound = 3
+)
+
+// Move abort codes of the cartridge module
+const (
+	errAssetExists   = 2
+	errAssetNotFound = 3
+	errDeltaExists   = 4
+)
+
+// Move abort codes of the collection module
+const (
+	errCartridgeCollected    = 1
+	errCartridgeNotCollected = 2
+	errCollectionFull        = 3
+)
+
+// maxCollectionCartridges is MAX_CARTRIDGES of the collection module
+const maxCollectionCartridges = 500
+
+// Exec runs a sui CLI command against the chain and returns what
+// `sui ... --json` would print. Supported: client active-address,
+// client call (catalog, registry, cartridge and collection modules) and client transfer.
+func (c *Chain) Exec(args []string) (string, error) {
+	if len(args) < 2 || args[0] != "client" {
+		return "", fmt.Errorf("memory backend: unsupported sui command: sui %s", strings.Join(args, " "))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	flags, callArgs := parseFlags(args[2:])
+	switch args[1] {
+	case "active-address":
+		return c.state.ActiveAddress, nil
+	case "call":
+		if flags["package"] != c.state.PackageID {
+			return "", fmt.Errorf("memory backend: package %s does not exist (the simulated package is %s)", flags["package"], c.state.PackageID)
+		}
+		return c.call(flags["module"], flags["function"], callArgs)
+	case "transfer":
+		return c.transfer(flags["object-id"], flags["to"])
+	default:
+		return "", fmt.Errorf("memory backend: 'sui client %s' is not supported", args[1])
+	}
+}
+
+// parseFlags splits CLI arguments into --flag values and the values
+// following --args
+func parseFlags(args []string) (map[string]string, []string) {
+	flags := map[string]string{}
+	var callArgs []string
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimPrefix(args[i], "--")
+		if name == args[i] {
+			continue
+		}
+		if name == "args" {
+			for i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+				i++
+				callArgs = append(callArgs, args[i])
+			}
+			continue
+		}
+		if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			i++
+			flags[name] = args[i]
+		} else {
+			flags[name] = "true"
+		}
+	}
+	return flags, callArgs
+}
+
+// tx collects the effects of one simulated transaction
+type tx struct {
+	c       *Chain
+	digest  string
+	changes []map[string]interface{}
+	events  []sui.Event
+}
+
+func (c *Chain) newTx() *tx { return &tx{c: c, digest: c.newDigest()} }
+
+func (t *tx) created(obj *Object) {
+	obj.Version = 1
+	t.c.state.Objects[obj.ID] = obj
+	t.changes = append(t.changes, map[string]interface{}{
+		"type":       "created",
+		"sender":     t.c.state.ActiveAddress,
+		"objectType": obj.Type,
+		"objectId":   obj.ID,
+		"version":    "1",
+	})
+}
+
+func (t *tx) mutated(obj *Object) {
+	obj.Version++
+	t.changes = append(t.changes, map[string]interface{}{
+		"type":       "mutated",
+		"sender":     t.c.state.ActiveAddress,
+		"objectType": obj.Type,
+		"objectId":   obj.ID,
+		"version":    fmt.Sprintf("%d", obj.Version),
+	})
+}
+
+func (t *tx) deleted(obj *Object) {
+	delete(t.c.state.Objects, obj.ID)
+	t.changes = append(t.changes, map[string]interface{}{
+		"type":       "deleted",
+		"sender":     t.c.state.ActiveAddress,
+		"objectType": obj.Type,
+		"objectId":   obj.ID,
+	})
+}
+
+func (t *tx) emit(eventType string, fields map[string]interface{}) {
+	var event sui.Event
+	event.ID.TxDigest = t.digest
+	event.ID.EventSeq = fmt.Sprintf("%d", len(t.events))
+	event.Type = t.c.typeName(eventType)
+	event.ParsedJSON = fields
+	event.TimestampMs = nowMs()
+	t.events = append(t.events, event)
+}
+
+// commit records the events, saves the database and renders the CLI output
+func (t *tx) commit() (string, error) {
+	t.c.state.Events = append(t.c.state.Events, t.events...)
+	if err := t.c.save(); err != nil {
+		return "", err
+	}
+	out, err := json.MarshalIndent(map[string]interface{}{
+		"digest": t.digest,
+		"effects": map[string]interface{}{
+			"status": map[string]string{"status": "success"},
+			"gasUsed": map[string]string{
+				"computationCost": fmt.Sprintf("%d", computationCost),
+				"storageCost":     fmt.Sprintf("%d", storageCost),
+				"storageRebate":   fmt.Sprintf("%d", storageRebate),
+			},
+		},
+		"objectChanges": t.changes,
+		"events":        t.events,
+	}, "", "  ")
+	return string(out), err
+}
+
+// abort is the error of a failed Move assertion
+func abort(module, function string, code int) error {
+	return fmt.Errorf("memory backend: transaction aborted in %s::%s with code %d", module, function, code)
+}
+
+func (c *Chain) call(module, function string, args []string) (string, error) {
+	a := &argReader{args: args}
+	switch module + "::" + function {
+	case "catalog::create_catalog":
+		return c.createCatalog(a.str(), a.str(), a.done())
+	case "catalog::add_entry":
+		catalog, err := c.ownedCatalog(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.insertEntry(catalog, a)
+	case "catalog::add_entry_with_cap":
+		catalog, err := c.curatedCatalog(a.str(), a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.insertEntry(catalog, a)
+	case "catalog::update_entry":
+		catalog, err := c.ownedCatalog(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.updateEntry(catalog, a)
+	case "catalog::remove_entry":
+		catalog, err := c.ownedCatalog(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.deleteEntry(catalog, a.str(), a.done())
+	case "catalog::remove_entry_with_cap":
+		catalog, err := c.curatedCatalog(a.str(), a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.deleteEntry(catalog, a.str(), a.done())
+	case "catalog::set_entry_tags":
+		catalog, err := c.ownedCatalog(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.setEntryTags(catalog, a)
+	case "catalog::set_entry_tags_with_cap":
+		catalog, err := c.curatedCatalog(a.str(), a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.setEntryTags(catalog, a)
+	case "catalog::mint_curator_cap":
+		catalog, err := c.ownedCatalog(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.mintCuratorCap(catalog, a.str(), a.done())
+	case "catalog::revoke_curator_cap":
+		catalog, err := c.ownedCatalog(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.revokeCuratorCap(catalog, a.str(), a.done())
+	case "catalog::transfer_ownership":
+		catalog, err := c.ownedCatalog(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		newOwner := a.str()
+		if err := a.done(); err != nil {
+			return "", err
+		}
+		t := c.newTx()
+		catalog.Fields["owner"] = newOwner
+		t.mutated(catalog)
+		return t.commit()
+	case "catalog::fix_count":
+		catalog, err := c.ownedCatalog(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		expected, count := uint64(a.num()), uint64(a.num())
+		if err := a.done(); err != nil {
+			return "", err
+		}
+		if countOf(catalog) != expected {
+			return "", abort("catalog", function, errCountChanged)
+		}
+		t := c.newTx()
+		catalog.Fields["count"] = fmt.Sprintf("%d", count)
+		t.mutated(catalog)
+		t.emit("catalog::CountFixed", map[string]interface{}{
+			"catalog_id": catalog.ID,
+			"old_count":  fmt.Sprintf("%d", expected),
+			"new_count":  fmt.Sprintf("%d", count),
+		})
+		return t.commit()
+	case "registry::create_registry":
+		return c.createRegistry(a.done())
+	case "registry::register_catalog":
+		registry, err := c.adminRegistry(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.registerCatalog(registry, a)
+	case "registry::unregister_catalog":
+		registry, err := c.adminRegistry(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.unregisterCatalog(registry, a.str(), a.done())
+	case "cartridge::create_cartridge":
+		return c.createCartridge(a)
+	case "cartridge::add_asset":
+		cartridge, err := c.ownedCartridge(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.addAsset(cartridge, a)
+	case "cartridge::remove_asset":
+		cartridge, err := c.ownedCartridge(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.removeAsset(cartridge, a.str(), a.done())
+	case "cartridge::set_delta":
+		cartridge, err := c.ownedCartridge(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.setDelta(cartridge, a)
+	case "collection::create_collection":
+		return c.createCollection(a.str(), a.str(), a.done())
+	case "collection::add_cartridge":
+		collection, err := c.ownedCollection(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.collectCartridge(collection, a.str(), a.done())
+	case "collection::remove_cartridge":
+		collection, err := c.ownedCollection(a.str(), function)
+		if err != nil {
+			return "", err
+		}
+		return c.uncollectCartridge(collection, a.str(), a.done())
+	default:
+		return "", fmt.Errorf("memory backend: %s::%s is not simulated", module, function)
+	}
+}
+
+// argReader consumes Move call arguments in order
+type argReader struct {
+	args []string
+	pos  int
+	err  error
+}
+
+func (a *argReader) str() string {
+	if a.pos >= len(a.args) {
+		if a.err == nil {
+			a.err = fmt.Errorf("memory backend: missing argument %d", a.pos+1)
+		}
+		return ""
+	}
+	a.pos++
+	return a.args[a.pos-1]
+}
+
+func (a *argReader) num() float64 {
+	s := a.str()
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil && a.err == nil {
+		a.err = fmt.Errorf("memory backend: argument %d (%q) is not a number", a.pos, s)
+	}
+	return float64(n)
+}
+
+// bytes parses a vector<u8> argument: 0x-hex, [] or [1,2,3]
+func (a *argReader) bytes() []interface{} {
+	s := a.str()
+	var b []byte
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		var err error
+		if b, err = hex.DecodeString(s[2:]); err != nil && a.err == nil {
+			a.err = fmt.Errorf("memory backend: argument %d is not valid hex", a.pos)
+		}
+	case strings.HasPrefix(s, "["):
+		for _, part := range strings.Split(strings.Trim(s, "[]"), ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			n, err := strconv.ParseUint(part, 10, 8)
+			if err != nil && a.err == nil {
+				a.err = fmt.Errorf("memory backend: argument %d is not a byte vector", a.pos)
+			}
+			b = append(b, byte(n))
+		}
+	default:
+		if a.err == nil {
+			a.err = fmt.Errorf("memory backend: argument %d (%q) is not a byte vector", a.pos, s)
+		}
+	}
+	// The RPC renders vector<u8> as an array of numbers
+	out := make([]interface{}, len(b))
+	for i, v := range b {
+		out[i] = float64(v)
+	}
+	return out
+}
+
+// strs parses a vector<String> argument written as a JSON array
+func (a *argReader) strs() []string {
+	s := a.str()
+	var list []string
+	if err := json.Unmarshal([]byte(s), &list); err != nil && a.err == nil {
+		a.err = fmt.Errorf("memory backend: argument %d (%q) is not a string vector", a.pos, s)
+	}
+	return list
+}
+
+// done returns the first parse error, or an error if arguments are left over
+func (a *argReader) done() error {
+	if a.err != nil {
+		return a.err
+	}
+	if a.pos != len(a.args) {
+		return fmt.Errorf("memory backend: expected %d arguments, got %d", a.pos, len(a.args))
+	}
+	return nil
+}
+
+func (c *Chain) createCatalog(name, description string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	t := c.newTx()
+	catalog := &Object{
+		ID:   c.newID(),
+		Type: c.typeName("catalog::Catalog"),
+		Fields: map[string]interface{}{
+			"owner":       c.state.ActiveAddress,
+			"name":        name,
+			"description": description,
+			"count":       "0",
+		},
+	}
+	t.created(catalog)
+	t.emit("catalog::CatalogCreated", map[string]interface{}{
+		"catalog_id": catalog.ID,
+		"name":       name,
+		"owner":      c.state.ActiveAddress,
+	})
+	return t.commit()
+}
+
+// catalog returns the Catalog object with the given ID
+func (c *Chain) catalog(id string) (*Object, error) {
+	obj, ok := c.state.Objects[id]
+	if !ok || obj.Type != c.typeName("catalog::Catalog") {
+		return nil, fmt.Errorf("memory backend: catalog %s does not exist", id)
+	}
+	return obj, nil
+}
+
+// ownedCatalog returns a catalog the active address owns
+func (c *Chain) ownedCatalog(id, function string) (*Object, error) {
+	catalog, err := c.catalog(id)
+	if err != nil {
+		return nil, err
+	}
+	if catalog.Fields["owner"] != c.state.ActiveAddress {
+		return nil, abort("catalog", function, errNotOwner)
+	}
+	return catalog, nil
+}
+
+// curatedCatalog returns a catalog the active address curates with capID
+func (c *Chain) curatedCatalog(id, capID, function string) (*Object, error) {
+	catalog, err := c.catalog(id)
+	if err != nil {
+		return nil, err
+	}
+	capObj, ok := c.state.Objects[capID]
+	if !ok || capObj.Type != c.typeName("catalog::CuratorCap") || capObj.Owner != c.state.ActiveAddress {
+		return nil, fmt.Errorf("memory backend: curator cap %s is not owned by %s", capID, c.state.ActiveAddress)
+	}
+	if capObj.Fields["catalog_id"] != id || !c.curators(catalog)[capID] {
+		return nil, abort("catalog", function, errNotCurator)
+	}
+	return catalog, nil
+}
+
+func (c *Chain) curatorsKey() sui.DynamicFieldName {
+	return sui.DynamicFieldName{
+		Type:  c.typeName("catalog::CuratorsKey"),
+		Value: map[string]interface{}{"dummy_field": false},
+	}
+}
+
+// curators returns the active curator cap IDs of a catalog
+func (c *Chain) curators(catalog *Object) map[string]bool {
+	active := map[string]bool{}
+	if field := c.dynamicField(catalog.ID, c.curatorsKey()); field != nil {
+		for _, id := range curatorIDs(field) {
+			active[id] = true
+		}
+	}
+	return active
+}
+
+func curatorIDs(field *Object) []string {
+	value, _ := field.Fields["value"].(map[string]interface{})
+	fields, _ := value["fields"].(map[string]interface{})
+	var ids []string
+	switch list := fields["contents"].(type) {
+	case []interface{}:
+		for _, id := range list {
+			if s, ok := id.(string); ok {
+				ids = append(ids, s)
+			}
+		}
+	case []string:
+		ids = list
+	}
+	return ids
+}
+
+func setCuratorIDs(field *Object, ids []string) {
+	field.Fields["value"] = map[string]interface{}{
+		"type":   "0x2::vec_set::VecSet<0x2::object::ID>",
+		"fields": map[string]interface{}{"contents": ids},
+	}
+}
+
+func slugName(slug string) sui.DynamicFieldName {
+	return sui.DynamicFieldName{Type: "0x1::string::String", Value: slug}
+}
+
+// entryValue builds the CatalogEntry stored under a slug
+func (c *Chain) entryValue(a *argReader) (cartridgeID string, value map[string]interface{}) {
+	cartridgeID = a.str()
+	title := a.str()
+	platform := a.num()
+	size := a.num()
+	emulator := a.str()
+	version := a.num()
+	cover := a.bytes()
+	return cartridgeID, map[string]interface{}{
+		"type": c.typeName("catalog::CatalogEntry"),
+		"fields": map[string]interface{}{
+			"cartridge_id":  cartridgeID,
+			"title":         title,
+			"platform":      platform,
+			"size_bytes":    fmt.Sprintf("%d", uint64(size)),
+			"emulator_core": emulator,
+			"version":       version,
+			"cover_blob_id": cover,
+		},
+	}
+}
+
+func (c *Chain) insertEntry(catalog *Object, a *argReader) (string, error) {
+	slug := a.str()
+	cartridgeID, value := c.entryValue(a)
+	if err := a.done(); err != nil {
+		return "", err
+	}
+	if c.dynamicField(catalog.ID, slugName(slug)) != nil {
+		return "", abort("catalog", "insert_entry", errEntryExists)
+	}
+
+	t := c.newTx()
+	name := slugName(slug)
+	t.created(&Object{
+		ID:     c.newID(),
+		Type:   "0x2::dynamic_field::Field<0x1::string::String, " + c.typeName("catalog::CatalogEntry") + ">",
+		Parent: catalog.ID,
+		Name:   &name,
+		Fields: map[string]interface{}{"name": slug, "value": value},
+	})
+	catalog.Fields["count"] = fmt.Sprintf("%d", countOf(catalog)+1)
+	t.mutated(catalog)
+	t.emit("catalog::EntryAdded", map[string]interface{}{
+		"catalog_id":   catalog.ID,
+		"slug":         slug,
+		"cartridge_id": cartridgeID,
+	})
+	return t.commit()
+}
+
+func (c *Chain) updateEntry(catalog *Object, a *argReader) (string, error) {
+	slug := a.str()
+	cartridgeID, value := c.entryValue(a)
+	if err := a.done(); err != nil {
+		return "", err
+	}
+	field := c.dynamicField(catalog.ID, slugName(slug))
+	if field == nil {
+		return "", abort("catalog", "update_entry", errEntryNotFound)
+	}
+
+	t := c.newTx()
+	field.Fields["value"] = value
+	t.mutated(field)
+	t.mutated(catalog)
+	t.emit("catalog::EntryUpdated", map[string]interface{}{
+		"catalog_id":       catalog.ID,
+		"slug":             slug,
+		"new_cartridge_id": cartridgeID,
+	})
+	return t.commit()
+}
+
+func (c *Chain) deleteEntry(catalog *Object, slug string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	field := c.dynamicField(catalog.ID, slugName(slug))
+	if field == nil {
+		return "", abort("catalog", "delete_entry", errEntryNotFound)
+	}
+
+	t := c.newTx()
+	t.deleted(field)
+	if meta := c.dynamicField(catalog.ID, c.entryMetaKey(slug)); meta != nil {
+		t.deleted(meta)
+	}
+	catalog.Fields["count"] = fmt.Sprintf("%d", countOf(catalog)-1)
+	t.mutated(catalog)
+	t.emit("catalog::EntryRemoved", map[string]interface{}{
+		"catalog_id": catalog.ID,
+		"slug":       slug,
+	})
+	return t.commit()
+}
+
+func (c *Chain) entryMetaKey(slug string) sui.DynamicFieldName {
+	return sui.DynamicFieldName{
+		Type:  c.typeName("catalog::EntryMetaKey"),
+		Value: map[string]interface{}{"slug": slug},
+	}
+}
+
+// setEntryTags replaces the EntryMeta of an entry, or removes it for no tags
+func (c *Chain) setEntryTags(catalog *Object, a *argReader) (string, error) {
+	slug := a.str()
+	tags := a.strs()
+	if err := a.done(); err != nil {
+		return "", err
+	}
+	if c.dynamicField(catalog.ID, slugName(slug)) == nil {
+		return "", abort("catalog", "store_entry_tags", errEntryNotFound)
+	}
+	if len(tags) > maxTags {
+		return "", abort("catalog", "store_entry_tags", errTooManyTags)
+	}
+
+	t := c.newTx()
+	key := c.entryMetaKey(slug)
+	if old := c.dynamicField(catalog.ID, key); old != nil {
+		t.deleted(old)
+	}
+	if len(tags) > 0 {
+		t.created(&Object{
+			ID:     c.newID(),
+			Type:   "0x2::dynamic_field::Field<" + key.Type + ", " + c.typeName("catalog::EntryMeta") + ">",
+			Parent: catalog.ID,
+			Name:   &key,
+			Fields: map[string]interface{}{
+				"name": key.Value,
+				"value": map[string]interface{}{
+					"type":   c.typeName("catalog::EntryMeta"),
+					"fields": map[string]interface{}{"tags": tags},
+				},
+			},
+		})
+	}
+	t.mutated(catalog)
+	t.emit("catalog::EntryTagsSet", map[string]interface{}{
+		"catalog_id": catalog.ID,
+		"slug":       slug,
+		"tags":       tags,
+	})
+	return t.commit()
+}
+
+func countOf(catalog *Object) uint64 {
+	s, _ := catalog.Fields["count"].(string)
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
+
+func (c *Chain) mintCuratorCap(catalog *Object, recipient string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	t := c.newTx()
+	capObj := &Object{
+		ID:     c.newID(),
+		Type:   c.typeName("catalog::CuratorCap"),
+		Owner:  recipient,
+		Fields: map[string]interface{}{"catalog_id": catalog.ID},
+	}
+	t.created(capObj)
+
+	field := c.dynamicField(catalog.ID, c.curatorsKey())
+	if field == nil {
+		name := c.curatorsKey()
+		field = &Object{
+			ID:     c.newID(),
+			Type:   "0x2::dynamic_field::Field<" + name.Type + ", 0x2::vec_set::VecSet<0x2::object::ID>>",
+			Parent: catalog.ID,
+			Name:   &name,
+			Fields: map[string]interface{}{"name": name.Value},
+		}
+		setCuratorIDs(field, nil)
+		t.created(field)
+	} else {
+		t.mutated(field)
+	}
+	setCuratorIDs(field, append(curatorIDs(field), capObj.ID))
+	t.mutated(catalog)
+	t.emit("catalog::CuratorCapMinted", map[string]interface{}{
+		"catalog_id": catalog.ID,
+		"cap_id":     capObj.ID,
+		"recipient":  recipient,
+	})
+	return t.commit()
+}
+
+func (c *Chain) revokeCuratorCap(catalog *Object, capID string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	field := c.dynamicField(catalog.ID, c.curatorsKey())
+	if field == nil || !c.curators(catalog)[capID] {
+		return "", abort("catalog", "revoke_curator_cap", errNotCurator)
+	}
+
+	t := c.newTx()
+	var kept []string
+	for _, id := range curatorIDs(field) {
+		if id != capID {
+			kept = append(kept, id)
+		}
+	}
+	setCuratorIDs(field, kept)
+	t.mutated(field)
+	t.mutated(catalog)
+	t.emit("catalog::CuratorCapRevoked", map[string]interface{}{
+		"catalog_id": catalog.ID,
+		"cap_id":     capID,
+	})
+	return t.commit()
+}
+
+func (c *Chain) createRegistry(err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	t := c.newTx()
+	registry := &Object{
+		ID:   c.newID(),
+		Type: c.typeName("registry::CatalogRegistry"),
+		Fields: map[string]interface{}{
+			"admin": c.state.ActiveAddress,
+			"count": "0",
+		},
+	}
+	t.created(registry)
+	t.emit("registry::RegistryCreated", map[string]interface{}{
+		"registry_id": registry.ID,
+		"admin":       c.state.ActiveAddress,
+	})
+	return t.commit()
+}
+
+// adminRegistry returns a CatalogRegistry the active address administers
+func (c *Chain) adminRegistry(id, function string) (*Object, error) {
+	registry, ok := c.state.Objects[id]
+	if !ok || registry.Type != c.typeName("registry::CatalogRegistry") {
+		return nil, fmt.Errorf("memory backend: registry %s does not exist", id)
+	}
+	if registry.Fields["admin"] != c.state.ActiveAddress {
+		return nil, abort("registry", function, errNotAdmin)
+	}
+	return registry, nil
+}
+
+func catalogIDName(catalogID string) sui.DynamicFieldName {
+	return sui.DynamicFieldName{Type: "0x2::object::ID", Value: catalogID}
+}
+
+func (c *Chain) registerCatalog(registry *Object, a *argReader) (string, error) {
+	catalogID := a.str()
+	name := a.str()
+	description := a.str()
+	platform := a.num()
+	if err := a.done(); err != nil {
+		return "", err
+	}
+	if c.dynamicField(registry.ID, catalogIDName(catalogID)) != nil {
+		return "", abort("registry", "register_catalog", errCatalogExists)
+	}
+
+	t := c.newTx()
+	key := catalogIDName(catalogID)
+	t.created(&Object{
+		ID:     c.newID(),
+		Type:   "0x2::dynamic_field::Field<0x2::object::ID, " + c.typeName("registry::RegistryEntry") + ">",
+		Parent: registry.ID,
+		Name:   &key,
+		Fields: map[string]interface{}{
+			"name": catalogID,
+			"value": map[string]interface{}{
+				"type": c.typeName("registry::RegistryEntry"),
+				"fields": map[string]interface{}{
+					"catalog_id":       catalogID,
+					"name":             name,
+					"description":      description,
+					"primary_platform": platform,
+				},
+			},
+		},
+	})
+	registry.Fields["count"] = fmt.Sprintf("%d", countOf(registry)+1)
+	t.mutated(registry)
+	t.emit("registry::CatalogRegistered", map[string]interface{}{
+		"registry_id": registry.ID,
+		"catalog_id":  catalogID,
+		"name":        name,
+	})
+	return t.commit()
+}
+
+func (c *Chain) unregisterCatalog(registry *Object, catalogID string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	field := c.dynamicField(registry.ID, catalogIDName(catalogID))
+	if field == nil {
+		return "", abort("registry", "unregister_catalog", errCatalogNotFound)
+	}
+
+	t := c.newTx()
+	t.deleted(field)
+	registry.Fields["count"] = fmt.Sprintf("%d", countOf(registry)-1)
+	t.mutated(registry)
+	t.emit("registry::CatalogUnregistered", map[string]interface{}{
+		"registry_id": registry.ID,
+		"catalog_id":  catalogID,
+	})
+	return t.commit()
+}
+
+func (c *Chain) createCartridge(a *argReader) (string, error) {
+	slug := a.str()
+	title := a.str()
+	platform := a.num()
+	emulator := a.str()
+	version := a.num()
+	blobID := a.bytes()
+	sha := a.bytes()
+	size := a.num()
+	createdAt := a.num()
+	if err := a.done(); err != nil {
+		return "", err
+	}
+
+	t := c.newTx()
+	cartridge := &Object{
+		ID:    c.newID(),
+		Type:  c.typeName("cartridge::Cartridge"),
+		Owner: c.state.ActiveAddress,
+		Fields: map[string]interface{}{
+			"slug":          slug,
+			"title":         title,
+			"platform":      platform,
+			"emulator_core": emulator,
+			"version":       version,
+			"blob_id":       blobID,
+			"sha256":        sha,
+			"size_bytes":    fmt.Sprintf("%d", uint64(size)),
+			"publisher":     c.state.ActiveAddress,
+			"created_at_ms": fmt.Sprintf("%d", uint64(createdAt)),
+		},
+	}
+	t.created(cartridge)
+	return t.commit()
+}
+
+// ownedCartridge loads a cartridge owned by the active address; cartridges
+// are owned objects, so anyone else cannot even pass them to a call
+func (c *Chain) ownedCartridge(id, function string) (*Object, error) {
+	obj, ok := c.state.Objects[id]
+	if !ok || obj.Type != c.typeName("cartridge::Cartridge") {
+		return nil, fmt.Errorf("memory backend: cartridge %s not found", id)
+	}
+	if obj.Owner != c.state.ActiveAddress {
+		return nil, fmt.Errorf("memory backend: %s: cartridge %s is not owned by %s", function, id, c.state.ActiveAddress)
+	}
+	return obj, nil
+}
+
+func (c *Chain) assetKey(name string) sui.DynamicFieldName {
+	return sui.DynamicFieldName{
+		Type:  c.typeName("cartridge::AssetKey"),
+		Value: map[string]interface{}{"name": name},
+	}
+}
+
+func (c *Chain) addAsset(cartridge *Object, a *argReader) (string, error) {
+	name := a.str()
+	blobID := a.bytes()
+	sha := a.bytes()
+	size := a.num()
+	if err := a.done(); err != nil {
+		return "", err
+	}
+	key := c.assetKey(name)
+	if c.dynamicField(cartridge.ID, key) != nil {
+		return "", abort("cartridge", "add_asset", errAssetExists)
+	}
+
+	t := c.newTx()
+	t.created(&Object{
+		ID:     c.newID(),
+		Type:   "0x2::dynamic_field::Field<" + key.Type + ", " + c.typeName("cartridge::Asset") + ">",
+		Parent: cartridge.ID,
+		Name:   &key,
+		Fields: map[string]interface{}{
+			"name": key.Value,
+			"value": map[string]interface{}{
+				"type": c.typeName("cartridge::Asset"),
+				"fields": map[string]interface{}{
+					"name":       name,
+					"blob_id":    blobID,
+					"sha256":     sha,
+					"size_bytes": fmt.Sprintf("%d", uint64(size)),
+				},
+			},
+		},
+	})
+	t.mutated(cartridge)
+	return t.commit()
+}
+
+func (c *Chain) removeAsset(cartridge *Object, name string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	field := c.dynamicField(cartridge.ID, c.assetKey(name))
+	if field == nil {
+		return "", abort("cartridge", "remove_asset", errAssetNotFound)
+	}
+
+	t := c.newTx()
+	t.deleted(field)
+	t.mutated(cartridge)
+	return t.commit()
+}
+
+func (c *Chain) deltaKey() sui.DynamicFieldName {
+	return sui.DynamicFieldName{
+		Type:  c.typeName("cartridge::DeltaKey"),
+		Value: map[string]interface{}{"dummy_field": false},
+	}
+}
+
+func (c *Chain) setDelta(cartridge *Object, a *argReader) (string, error) {
+	base := a.str()
+	format := a.str()
+	sha := a.bytes()
+	size := a.num()
+	if err := a.done(); err != nil {
+		return "", err
+	}
+	key := c.deltaKey()
+	if c.dynamicField(cartridge.ID, key) != nil {
+		return "", abort("cartridge", "set_delta", errDeltaExists)
+	}
+
+	t := c.newTx()
+	t.created(&Object{
+		ID:     c.newID(),
+		Type:   "0x2::dynamic_field::Field<" + key.Type + ", " + c.typeName("cartridge::Delta") + ">",
+		Parent: cartridge.ID,
+		Name:   &key,
+		Fields: map[string]interface{}{
+			"name": key.Value,
+			"value": map[string]interface{}{
+				"type": c.typeName("cartridge::Delta"),
+				"fields": map[string]interface{}{
+					"base_cartridge":   base,
+					"format":           format,
+					"patch_sha256":     sha,
+					"patch_size_bytes": fmt.Sprintf("%d", uint64(size)),
+				},
+			},
+		},
+	})
+	t.mutated(cartridge)
+	return t.commit()
+}
+
+func (c *Chain) createCollection(name, description string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	t := c.newTx()
+	collection := &Object{
+		ID:    c.newID(),
+		Type:  c.typeName("collection::Collection"),
+		Owner: c.state.ActiveAddress,
+		Fields: map[string]interface{}{
+			"name":        name,
+			"description": description,
+			"cartridges":  []interface{}{},
+		},
+	}
+	t.created(collection)
+	t.emit("collection::CollectionCreated", map[string]interface{}{
+		"collection_id": collection.ID,
+		"owner":         c.state.ActiveAddress,
+		"name":          name,
+	})
+	return t.commit()
+}
+
+// ownedCollection loads a collection owned by the active address
+func (c *Chain) ownedCollection(id, function string) (*Object, error) {
+	obj, ok := c.state.Objects[id]
+	if !ok || obj.Type != c.typeName("collection::Collection") {
+		return nil, fmt.Errorf("memory backend: collection %s not found", id)
+	}
+	if obj.Owner != c.state.ActiveAddress {
+		return nil, fmt.Errorf("memory backend: %s: collection %s is not owned by %s", function, id, c.state.ActiveAddress)
+	}
+	return obj, nil
+}
+
+// collectionCartridges returns the cartridge IDs a collection lists
+func collectionCartridges(collection *Object) []interface{} {
+	ids, _ := collection.Fields["cartridges"].([]interface{})
+	return ids
+}
+
+func (c *Chain) collectCartridge(collection *Object, cartridgeID string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	ids := collectionCartridges(collection)
+	for _, id := range ids {
+		if id == cartridgeID {
+			return "", abort("collection", "add_cartridge", errCartridgeCollected)
+		}
+	}
+	if len(ids) >= maxCollectionCartridges {
+		return "", abort("collection", "add_cartridge", errCollectionFull)
+	}
+
+	t := c.newTx()
+	collection.Fields["cartridges"] = append(ids, cartridgeID)
+	t.mutated(collection)
+	t.emit("collection::CartridgeCollected", map[string]interface{}{
+		"collection_id": collection.ID,
+		"cartridge_id":  cartridgeID,
+	})
+	return t.commit()
+}
+
+func (c *Chain) uncollectCartridge(collection *Object, cartridgeID string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	ids := collectionCartridges(collection)
+	kept := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		if id != cartridgeID {
+			kept = append(kept, id)
+		}
+	}
+	if len(kept) == len(ids) {
+		return "", abort("collection", "remove_cartridge", errCartridgeNotCollected)
+	}
+
+	t := c.newTx()
+	collection.Fields["cartridges"] = kept
+	t.mutated(collection)
+	t.emit("collection::CartridgeUncollected", map[string]interface{}{
+		"collection_id": collection.ID,
+		"cartridge_id":  cartridgeID,
+	})
+	return t.commit()
+}
+
+// transfer moves an owned object to another address
+func (c *Chain) transfer(objectID, recipient string) (string, error) {
+	obj, ok := c.state.Objects[objectID]
+	if !ok || obj.Owner != c.state.ActiveAddress {
+		return "", fmt.Errorf("memory backend: object %s is not owned by %s", objectID, c.state.ActiveAddress)
+	}
+	t := c.newTx()
+	obj.Owner = recipient
+	t.mutated(obj)
+	return t.commit()
+}
@@ -0,0 +1,219 @@
+// Package memchain is an in-memory stand-in for Sui and Walrus, used by
+// --backend memory. It executes the catalog and cartridge Move calls that
+// catalogctl sends through the sui CLI, answers the JSON-RPC reads and the
+// Walrus publisher/aggregator HTTP API, and persists everything to a local
+// JSON file so a publish/list/download cycle works offline.
+package memchain
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/base58"
+	"github.com/retro-crypto/sui/internal/sui"
+)
+
+// Simulated gas charged for every transaction (MIST)
+const (
+	computationCost = 1000000
+	storageCost     = 2500000
+	storageRebate   = 980000
+)
+
+// Chain is the simulated network. All methods are safe for concurrent use
+// within one process; separate processes should not share a database.
+type Chain struct {
+	path string
+
+	mu    sync.Mutex
+	state state
+}
+
+type state struct {
+	PackageID     string             `json:"package_id"`
+	ActiveAddress string             `json:"active_address"`
+	Seq           uint64             `json:"seq"`
+	Objects       map[string]*Object `json:"objects"`
+	Events        []sui.Event        `json:"events"`
+	Blobs         map[string][]byte  `json:"blobs"`
+	// Settings holds config values saved while the backend was active
+	// (e.g. catalog_id from create-catalog --save-config)
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// Object is a Move object. Dynamic fields are objects with a Parent and Name.
+type Object struct {
+	ID      string                 `json:"id"`
+	Type    string                 `json:"type"`
+	Owner   string                 `json:"owner,omitempty"` // empty for shared objects and dynamic fields
+	Version uint64                 `json:"version"`
+	Fields  map[string]interface{} `json:"fields"`
+
+	Parent string                `json:"parent,omitempty"`
+	Name   *sui.DynamicFieldName `json:"name,omitempty"`
+}
+
+// Open loads the database at path, creating an empty chain if it doesn't exist
+func Open(path string) (*Chain, error) {
+	c := &Chain{path: path}
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		c.state = state{
+			Objects:  map[string]*Object{},
+			Blobs:    map[string][]byte{},
+			Settings: map[string]string{},
+		}
+		c.state.PackageID = c.newID()
+		c.state.ActiveAddress = c.newID()
+		return c, c.save()
+	case err != nil:
+		return nil, fmt.Errorf("failed to read memory database: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &c.state); err != nil {
+		return nil, fmt.Errorf("failed to parse memory database %s: %w", path, err)
+	}
+	if c.state.Objects == nil {
+		c.state.Objects = map[string]*Object{}
+	}
+	if c.state.Blobs == nil {
+		c.state.Blobs = map[string][]byte{}
+	}
+	if c.state.Settings == nil {
+		c.state.Settings = map[string]string{}
+	}
+	return c, nil
+}
+
+// Path returns the database file
+func (c *Chain) Path() string { return c.path }
+
+// PackageID returns the ID the simulated cartridge_storage package lives at
+func (c *Chain) PackageID() string { return c.state.PackageID }
+
+// ActiveAddress returns the address simulated transactions are sent from
+func (c *Chain) ActiveAddress() string { return c.state.ActiveAddress }
+
+// Setting returns a value saved with SetSetting
+func (c *Chain) Setting(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state.Settings[key]
+}
+
+// SetSetting saves a config value in the database instead of the config file
+func (c *Chain) SetSetting(key, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state.Settings[key] = value
+	return c.save()
+}
+
+// save writes the database; callers hold c.mu (or own c exclusively)
+func (c *Chain) save() error {
+	data, err := json.MarshalIndent(c.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory database: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(c.path), err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write memory database: %w", err)
+	}
+	return nil
+}
+
+// next returns 32 deterministic bytes derived from a counter, so the same
+// sequence of commands always produces the same IDs
+func (c *Chain) next() []byte {
+	c.state.Seq++
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], c.state.Seq)
+	sum := sha256.Sum256(append([]byte("memchain:"), seq[:]...))
+	return sum[:]
+}
+
+func (c *Chain) newID() string { return "0x" + hex.EncodeToString(c.next()) }
+
+func (c *Chain) newDigest() string { return base58.Encode(c.next()) }
+
+// typeName qualifies a module::Struct name with the package ID
+func (c *Chain) typeName(name string) string { return c.state.PackageID + "::" + name }
+
+// objectData renders an object the way sui_getObject does
+func (c *Chain) objectData(obj *Object) *sui.ObjectData {
+	var owner interface{} = map[string]interface{}{"Shared": map[string]interface{}{"initial_shared_version": 1}}
+	switch {
+	case obj.Parent != "":
+		owner = map[string]string{"ObjectOwner": obj.Parent}
+	case obj.Owner != "":
+		owner = map[string]string{"AddressOwner": obj.Owner}
+	}
+
+	fields := map[string]interface{}{"id": map[string]string{"id": obj.ID}}
+	for k, v := range obj.Fields {
+		fields[k] = v
+	}
+	return &sui.ObjectData{
+		ObjectID: obj.ID,
+		Version:  fmt.Sprintf("%d", obj.Version),
+		Digest:   base58.Encode(sha256Bytes(obj.ID, obj.Version)),
+		Type:     obj.Type,
+		Owner:    owner,
+		Content: map[string]interface{}{
+			"dataType":          "moveObject",
+			"type":              obj.Type,
+			"hasPublicTransfer": obj.Parent == "",
+			"fields":            fields,
+		},
+	}
+}
+
+func sha256Bytes(id string, version uint64) []byte {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", id, version)))
+	return sum[:]
+}
+
+// dynamicField finds the dynamic field of parent with the given name
+func (c *Chain) dynamicField(parent string, name sui.DynamicFieldName) *Object {
+	for _, obj := range c.state.Objects {
+		if obj.Parent == parent && obj.Name.Type == name.Type && obj.Name.KeyString() == name.KeyString() {
+			return obj
+		}
+	}
+	return nil
+}
+
+// StoreBlob stores data and returns its blob ID and whether it was new. Blob
+// IDs are the base58 SHA256 of the content.
+func (c *Chain) StoreBlob(data []byte) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sum := sha256.Sum256(data)
+	blobID := base58.Encode(sum[:])
+	if _, ok := c.state.Blobs[blobID]; ok {
+		return blobID, false, nil
+	}
+	c.state.Blobs[blobID] = append([]byte(nil), data...)
+	return blobID, true, c.save()
+}
+
+// ReadBlob returns a stored blob
+func (c *Chain) ReadBlob(blobID string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.state.Blobs[blobID]
+	return data, ok
+}
+
+func nowMs() string { return fmt.Sprintf("%d", time.Now().UnixMilli()) }
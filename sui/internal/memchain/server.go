@@ -0,0 +1,319 @@
+package memchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/sui"
+	"github.com/retro-crypto/sui/internal/walrus"
+)
+
+// Simulated Walrus storage price (FROST per byte per epoch)
+const walrusPricePerByteEpoch = 1
+
+// maxBlobSize bounds uploads to the simulated publisher
+const maxBlobSize = 64 << 20
+
+// Serve starts the Sui JSON-RPC and Walrus HTTP endpoints on a random local
+// port and returns their base URL. The server runs until the process exits.
+func (c *Chain) Serve() (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to start memory backend: %w", err)
+	}
+	go http.Serve(ln, c.Handler())
+	return "http://" + ln.Addr().String(), nil
+}
+
+// Handler serves JSON-RPC on / and the Walrus API under /v1/
+func (c *Chain) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", c.handleRPC)
+	mux.HandleFunc("/v1/api", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"backend": "memory"})
+	})
+	mux.HandleFunc("/v1/store", c.handleStore)
+	mux.HandleFunc("/v1/blobs", c.handleStore)
+	mux.HandleFunc("/v1/blobs/", c.handleRead)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (c *Chain) handleStore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	epochs, _ := strconv.ParseUint(r.URL.Query().Get("epochs"), 10, 64)
+	if epochs == 0 {
+		epochs = 1
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxBlobSize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxBlobSize {
+		http.Error(w, "blob too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	blobID, created, err := c.StoreBlob(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !created {
+		writeJSON(w, http.StatusOK, walrus.StoreResponse{
+			AlreadyCertified: &walrus.AlreadyCertifiedInfo{BlobID: blobID, EndEpoch: epochs},
+		})
+		return
+	}
+
+	c.mu.Lock()
+	objectID := c.newID()
+	c.mu.Unlock()
+	size := uint64(len(data))
+	writeJSON(w, http.StatusOK, walrus.StoreResponse{
+		NewlyCreated: &walrus.NewlyCreatedInfo{
+			BlobObject: walrus.BlobObjectInfo{
+				ID:              objectID,
+				BlobID:          blobID,
+				Size:            size,
+				ErasureCodeType: "RedStuff",
+				Storage:         walrus.StorageInfo{ID: objectID, EndEpoch: epochs, StorageSize: size},
+			},
+			Cost: size * epochs * walrusPricePerByteEpoch,
+		},
+	})
+}
+
+func (c *Chain) handleRead(w http.ResponseWriter, r *http.Request) {
+	blobID := strings.TrimPrefix(r.URL.Path, "/v1/blobs/")
+	data, ok := c.ReadBlob(blobID)
+	if !ok {
+		http.Error(w, "blob not found", http.StatusNotFound)
+		return
+	}
+	// Blobs never change, so the ID is a strong ETag; ServeContent answers
+	// Range requests like the Walrus aggregator
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("ETag", `"`+blobID+`"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+func (c *Chain) handleRPC(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     int               `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON-RPC request", http.StatusBadRequest)
+		return
+	}
+
+	resp := sui.RPCResponse{JSONRPC: "2.0", ID: req.ID}
+	result, err := c.rpc(req.Method, req.Params)
+	if err != nil {
+		resp.Error = &sui.RPCError{Code: -32602, Message: err.Error()}
+	} else if resp.Result, err = json.Marshal(result); err != nil {
+		resp.Error = &sui.RPCError{Code: -32603, Message: err.Error()}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// param decodes the i-th positional parameter (missing ones stay zero)
+func param(params []json.RawMessage, i int, v interface{}) error {
+	if i >= len(params) || string(params[i]) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return fmt.Errorf("invalid parameter %d: %w", i, err)
+	}
+	return nil
+}
+
+func (c *Chain) rpc(method string, params []json.RawMessage) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch method {
+	case "sui_getLatestCheckpointSequenceNumber":
+		return fmt.Sprintf("%d", c.state.Seq), nil
+
+	case "sui_getObject":
+		var id string
+		if err := param(params, 0, &id); err != nil {
+			return nil, err
+		}
+		obj, ok := c.state.Objects[id]
+		if !ok {
+			return map[string]interface{}{"error": map[string]string{"code": "notExists", "object_id": id}}, nil
+		}
+		return sui.ObjectResponse{Data: c.objectData(obj)}, nil
+
+	case "sui_multiGetObjects":
+		var ids []string
+		if err := param(params, 0, &ids); err != nil {
+			return nil, err
+		}
+		if len(ids) > sui.MaxMultiGetObjects {
+			return nil, fmt.Errorf("at most %d object IDs per call", sui.MaxMultiGetObjects)
+		}
+		results := make([]interface{}, len(ids))
+		for i, id := range ids {
+			if obj, ok := c.state.Objects[id]; ok {
+				results[i] = sui.ObjectResponse{Data: c.objectData(obj)}
+			} else {
+				results[i] = map[string]interface{}{"error": map[string]string{"code": "notExists", "object_id": id}}
+			}
+		}
+		return results, nil
+
+	case "suix_getDynamicFields":
+		var parent string
+		var cursor *string
+		var limit int
+		if err := param(params, 0, &parent); err != nil {
+			return nil, err
+		}
+		if err := param(params, 1, &cursor); err != nil {
+			return nil, err
+		}
+		if err := param(params, 2, &limit); err != nil {
+			return nil, err
+		}
+		return c.dynamicFields(parent, cursor, limit), nil
+
+	case "suix_getDynamicFieldObject":
+		var parent string
+		var name sui.DynamicFieldName
+		if err := param(params, 0, &parent); err != nil {
+			return nil, err
+		}
+		if err := param(params, 1, &name); err != nil {
+			return nil, err
+		}
+		obj := c.dynamicField(parent, name)
+		if obj == nil {
+			return map[string]interface{}{"error": map[string]string{"code": "dynamicFieldNotFound", "parent_object_id": parent}}, nil
+		}
+		return sui.ObjectResponse{Data: c.objectData(obj)}, nil
+
+	case "suix_getOwnedObjects":
+		var owner string
+		var query struct {
+			Filter struct {
+				StructType string
+			} `json:"filter"`
+		}
+		if err := param(params, 0, &owner); err != nil {
+			return nil, err
+		}
+		if err := param(params, 1, &query); err != nil {
+			return nil, err
+		}
+		resp := sui.OwnedObjectsResponse{Data: []sui.ObjectResponse{}}
+		for _, obj := range c.sortedObjects() {
+			if obj.Owner == owner && (query.Filter.StructType == "" || obj.Type == query.Filter.StructType) {
+				resp.Data = append(resp.Data, sui.ObjectResponse{Data: c.objectData(obj)})
+			}
+		}
+		return resp, nil
+
+	case "suix_queryEvents":
+		var query struct {
+			MoveEventType string
+		}
+		var descending bool
+		if err := param(params, 0, &query); err != nil {
+			return nil, err
+		}
+		if err := param(params, 3, &descending); err != nil {
+			return nil, err
+		}
+		resp := sui.EventsResponse{Data: []sui.Event{}}
+		for _, event := range c.state.Events {
+			if query.MoveEventType == "" || event.Type == query.MoveEventType {
+				resp.Data = append(resp.Data, event)
+			}
+		}
+		if descending {
+			for i, j := 0, len(resp.Data)-1; i < j; i, j = i+1, j-1 {
+				resp.Data[i], resp.Data[j] = resp.Data[j], resp.Data[i]
+			}
+		}
+		return resp, nil
+
+	case "sui_executeTransactionBlock":
+		return nil, fmt.Errorf("the memory backend cannot execute signed transactions; drop --unsigned-out")
+
+	default:
+		return nil, fmt.Errorf("method %s is not supported by the memory backend", method)
+	}
+}
+
+// dynamicFields lists the dynamic fields of parent in a single page
+func (c *Chain) dynamicFields(parent string, cursor *string, limit int) sui.DynamicFieldsResponse {
+	// Like the RPC, pages hold at most 50 fields and the cursor is the ID of
+	// the last field of the previous page
+	if limit <= 0 || limit > 50 {
+		limit = 50
+	}
+	resp := sui.DynamicFieldsResponse{Data: []sui.DynamicFieldInfo{}}
+	for _, obj := range c.sortedObjects() {
+		if obj.Parent != parent || (cursor != nil && obj.ID <= *cursor) {
+			continue
+		}
+		if len(resp.Data) == limit {
+			last := resp.Data[limit-1].ObjectID
+			resp.NextCursor = &last
+			resp.HasNextPage = true
+			break
+		}
+		data := c.objectData(obj)
+		resp.Data = append(resp.Data, sui.DynamicFieldInfo{
+			Name:       *obj.Name,
+			Type:       "DynamicField",
+			ObjectType: valueType(obj),
+			ObjectID:   obj.ID,
+			Version:    int(obj.Version),
+			Digest:     data.Digest,
+		})
+	}
+	return resp
+}
+
+// valueType returns the type of a dynamic field's value
+func valueType(field *Object) string {
+	if value, ok := field.Fields["value"].(map[string]interface{}); ok {
+		if t, ok := value["type"].(string); ok {
+			return t
+		}
+	}
+	return field.Type
+}
+
+// sortedObjects returns objects in a stable order (by ID)
+func (c *Chain) sortedObjects() []*Object {
+	objects := make([]*Object, 0, len(c.state.Objects))
+	for _, obj := range c.state.Objects {
+		objects = append(objects, obj)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].ID < objects[j].ID })
+	return objects
+}
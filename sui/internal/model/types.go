@@ -4,6 +4,7 @@ package model
 import (
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -106,6 +107,32 @@ func (c *Cartridge) SHA256Bytes() ([]byte, error) {
 	return hex.DecodeString(c.SHA256)
 }
 
+// Asset is an extra file shipped with a cartridge (manual, soundtrack, ...),
+// stored as its own Walrus blob
+type Asset struct {
+	// Asset name (e.g., "manual")
+	Name string `json:"name"`
+	// Walrus blob ID (hex encoded)
+	BlobID string `json:"blob_id"`
+	// SHA256 hash of the file (hex encoded)
+	SHA256 string `json:"sha256"`
+	// Size in bytes
+	SizeBytes uint64 `json:"size_bytes"`
+}
+
+// Delta marks a cartridge whose blob is a patch against an earlier
+// cartridge; the cartridge's SHA256 and size describe the patched file
+type Delta struct {
+	// Cartridge the patch applies to
+	BaseCartridgeID string `json:"base_cartridge"`
+	// Patch format (see internal/delta)
+	Format string `json:"format"`
+	// SHA256 hash of the patch blob (hex encoded)
+	PatchSHA256 string `json:"patch_sha256"`
+	// Size of the patch blob in bytes
+	PatchSizeBytes uint64 `json:"patch_size_bytes"`
+}
+
 // Catalog represents a curated list of games
 type Catalog struct {
 	// Object ID on Sui
@@ -138,6 +165,66 @@ type CatalogEntry struct {
 	Version uint16 `json:"version"`
 	// Optional cover image blob ID
 	CoverBlobID string `json:"cover_blob_id,omitempty"`
+	// Release channel, from the key suffix (see ChannelKey)
+	Channel string `json:"channel"`
+	// Genre and feature tags, from the entry's extended metadata
+	Tags []string `json:"tags,omitempty"`
+}
+
+// HasTags reports whether the entry carries every one of tags
+func (e *CatalogEntry) HasTags(tags []string) bool {
+	for _, want := range tags {
+		found := false
+		for _, tag := range e.Tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Release channels. Stable entries are keyed by their slug; entries on other
+// channels are keyed "<slug>@<channel>" so default listings can hide them.
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+// Channels lists the known release channels
+var Channels = []string{ChannelStable, ChannelBeta}
+
+// ParseChannel checks a channel name; "" means stable
+func ParseChannel(s string) (string, error) {
+	if s == "" {
+		return ChannelStable, nil
+	}
+	for _, c := range Channels {
+		if s == c {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown channel %q (expected one of: %s)", s, strings.Join(Channels, ", "))
+}
+
+// ChannelKey returns the catalog key of a slug on a channel
+func ChannelKey(slug, channel string) string {
+	if channel == "" || channel == ChannelStable {
+		return slug
+	}
+	return slug + "@" + channel
+}
+
+// SplitChannelKey splits a catalog key into slug and channel
+func SplitChannelKey(key string) (slug, channel string) {
+	if i := strings.LastIndex(key, "@"); i > 0 {
+		return key[:i], key[i+1:]
+	}
+	return key, ChannelStable
 }
 
 // PublishResult contains the result of a publish operation
@@ -0,0 +1,488 @@
+// Package nimiq reads retro-crypto catalogs and cartridges from a Nimiq node
+// over JSON-RPC: CENT catalog entries, CART headers and DATA chunks (see the
+// nimiq-uploader for the formats). Writing is left to the nimiq-uploader.
+package nimiq
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/retro-crypto/sui/internal/httpclient"
+)
+
+// Payload magics
+const (
+	MagicCART = "CART"
+	MagicDATA = "DATA"
+	MagicCENT = "CENT"
+	MagicSHRD = "SHRD"
+)
+
+// Flags
+const (
+	FlagRetired     = 0x01 // CENT: app is retired
+	FlagBeta        = 0x02 // CENT: version is on the beta channel
+	CARTFlagSharded = 0x01 // CART: data is in shard cartridges linked by SHRD records
+)
+
+// dataHeaderSize is the part of a DATA payload before the chunk bytes
+const dataHeaderSize = 13
+
+// CatalogAliases are the catalog addresses known to the nimiq-uploader by name
+var CatalogAliases = map[string]string{
+	"main": "NQ15 NXMP 11A0 TMKP G1Q8 4ABD U16C XD6Q D948",
+	"test": "NQ32 0VD4 26TR 1394 KXBJ 862C NFKG 61M5 GFJ0",
+}
+
+// ResolveCatalog returns the address of a catalog alias, or addr itself
+func ResolveCatalog(addr string) string {
+	if a, ok := CatalogAliases[strings.ToLower(addr)]; ok {
+		return a
+	}
+	return addr
+}
+
+// Transaction is a transaction as returned by getTransactionsByAddress
+type Transaction struct {
+	Hash          string `json:"hash"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Data          string `json:"data"`
+	RecipientData string `json:"recipientData"`
+	Height        int64  `json:"height"`
+	// Timestamp is the block time in milliseconds (0 if the node omits it)
+	Timestamp int64 `json:"timestamp"`
+}
+
+// Payload returns the decoded data of a transaction (nil if none)
+func (tx Transaction) Payload() []byte {
+	dataHex := tx.RecipientData
+	if dataHex == "" {
+		dataHex = tx.Data
+	}
+	data, err := hex.DecodeString(dataHex)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// Client is a read-only Nimiq JSON-RPC client
+type Client struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for a Nimiq RPC endpoint
+func NewClient(url string) *Client {
+	return &Client{url: url, httpClient: httpclient.New(30 * time.Second)}
+}
+
+// call performs a JSON-RPC call with object params
+func (c *Client) call(method string, params map[string]interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Post(c.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &rpcResp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("RPC error: %s (code %d)", rpcResp.Error.Message, rpcResp.Error.Code)
+	}
+	// Nodes return either {"data": [...]} or the bare result
+	var wrapped struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(rpcResp.Result, &wrapped) == nil && wrapped.Data != nil {
+		return json.Unmarshal(wrapped.Data, result)
+	}
+	return json.Unmarshal(rpcResp.Result, result)
+}
+
+// Transactions returns every transaction of an address, newest first
+func (c *Client) Transactions(address string) ([]Transaction, error) {
+	var all []Transaction
+	seen := make(map[string]bool)
+	startAt := ""
+	for {
+		params := map[string]interface{}{"address": NormalizeAddress(address), "max": 500}
+		if startAt != "" {
+			params["startAt"] = startAt
+		}
+		var page []Transaction
+		if err := c.call("getTransactionsByAddress", params, &page); err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, tx := range page {
+			if !seen[tx.Hash] {
+				seen[tx.Hash] = true
+				all = append(all, tx)
+				added++
+			}
+		}
+		if added == 0 || len(page) < 500 {
+			break
+		}
+		startAt = page[len(page)-1].Hash
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Height > all[j].Height })
+	return all, nil
+}
+
+// ============================================================================
+// Payloads
+// ============================================================================
+
+// Entry is a CENT catalog entry and the transaction that registered it
+type Entry struct {
+	AppID         uint32
+	Title         string
+	Semver        [3]uint8
+	Platform      uint8
+	Flags         uint8
+	CartridgeAddr string
+	Publisher     string
+	TxHash        string
+	Timestamp     int64
+}
+
+// Version returns the entry's semver as "major.minor.patch"
+func (e Entry) Version() string {
+	return fmt.Sprintf("%d.%d.%d", e.Semver[0], e.Semver[1], e.Semver[2])
+}
+
+// Channel returns "beta" or "stable"
+func (e Entry) Channel() string {
+	if e.Flags&FlagBeta != 0 {
+		return "beta"
+	}
+	return "stable"
+}
+
+// Retired reports whether the entry retires its app
+func (e Entry) Retired() bool {
+	return e.Flags&FlagRetired != 0
+}
+
+func decodeCENT(p []byte) (*Entry, bool) {
+	if len(p) < 64 || string(p[0:4]) != MagicCENT {
+		return nil, false
+	}
+	var addr [20]byte
+	copy(addr[:], p[14:34])
+	title := p[34:50]
+	if i := bytes.IndexByte(title, 0); i >= 0 {
+		title = title[:i]
+	}
+	return &Entry{
+		Platform:      p[5],
+		Flags:         p[6],
+		AppID:         binary.LittleEndian.Uint32(p[7:11]),
+		Semver:        [3]uint8{p[11], p[12], p[13]},
+		CartridgeAddr: BytesToAddress(addr),
+		Title:         string(title),
+	}, true
+}
+
+// Header is a CART header
+type Header struct {
+	Platform    uint8
+	ChunkSize   uint8
+	Flags       uint8
+	CartridgeID uint32
+	TotalSize   uint64
+	SHA256      [32]byte
+}
+
+func decodeCART(p []byte) (*Header, bool) {
+	if len(p) < 64 || string(p[0:4]) != MagicCART {
+		return nil, false
+	}
+	h := &Header{
+		Platform:    p[5],
+		ChunkSize:   p[6],
+		Flags:       p[7],
+		CartridgeID: binary.LittleEndian.Uint32(p[8:12]),
+		TotalSize:   binary.LittleEndian.Uint64(p[12:20]),
+	}
+	copy(h.SHA256[:], p[20:52])
+	return h, true
+}
+
+type shardLink struct {
+	cartridgeID uint32
+	index       uint16
+	count       uint16
+	addr        string
+	offset      uint64
+	size        uint32
+}
+
+func decodeSHRD(p []byte) (*shardLink, bool) {
+	if len(p) < 64 || string(p[0:4]) != MagicSHRD {
+		return nil, false
+	}
+	var addr [20]byte
+	copy(addr[:], p[14:34])
+	return &shardLink{
+		cartridgeID: binary.LittleEndian.Uint32(p[6:10]),
+		index:       binary.LittleEndian.Uint16(p[10:12]),
+		count:       binary.LittleEndian.Uint16(p[12:14]),
+		addr:        BytesToAddress(addr),
+		offset:      binary.LittleEndian.Uint64(p[34:42]),
+		size:        binary.LittleEndian.Uint32(p[42:46]),
+	}, true
+}
+
+// ============================================================================
+// Catalogs and cartridges
+// ============================================================================
+
+// Catalog returns the CENT entries of a catalog address, newest first. With
+// publisher set, other senders' entries are skipped.
+func (c *Client) Catalog(catalogAddr, publisher string) ([]Entry, error) {
+	txs, err := c.Transactions(catalogAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog: %w", err)
+	}
+	var entries []Entry
+	for _, tx := range txs {
+		if NormalizeAddress(tx.To) != NormalizeAddress(catalogAddr) {
+			continue
+		}
+		if publisher != "" && NormalizeAddress(tx.From) != NormalizeAddress(publisher) {
+			continue
+		}
+		if entry, ok := decodeCENT(tx.Payload()); ok {
+			entry.Publisher = FormatAddress(tx.From)
+			entry.TxHash = tx.Hash
+			entry.Timestamp = tx.Timestamp
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+// CartridgeHeader returns the newest CART header on a cartridge address from
+// publisher (any sender if empty)
+func (c *Client) CartridgeHeader(addr, publisher string) (*Header, error) {
+	txs, err := c.Transactions(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cartridge: %w", err)
+	}
+	for _, tx := range txs {
+		if publisher != "" && NormalizeAddress(tx.From) != NormalizeAddress(publisher) {
+			continue
+		}
+		if header, ok := decodeCART(tx.Payload()); ok {
+			return header, nil
+		}
+	}
+	return nil, fmt.Errorf("no CART header found on %s", FormatAddress(addr))
+}
+
+// ReadCartridge reassembles the file of a cartridge the way loaders do: the
+// newest CART header and the newest DATA chunk of each index from publisher
+// (any sender if empty), following SHRD links for sharded cartridges. The
+// file is checked against the header's size and SHA256.
+func (c *Client) ReadCartridge(addr, publisher string) ([]byte, *Header, error) {
+	txs, err := c.Transactions(addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query cartridge: %w", err)
+	}
+	var mine []Transaction
+	var header *Header
+	for _, tx := range txs {
+		if publisher != "" && NormalizeAddress(tx.From) != NormalizeAddress(publisher) {
+			continue
+		}
+		mine = append(mine, tx)
+		if header == nil {
+			header, _ = decodeCART(tx.Payload())
+		}
+	}
+	if header == nil {
+		return nil, nil, fmt.Errorf("no CART header found on %s", FormatAddress(addr))
+	}
+
+	var data []byte
+	if header.Flags&CARTFlagSharded != 0 {
+		if data, err = c.readShards(mine, header, publisher); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if data, err = readChunks(mine, header); err != nil {
+			return nil, nil, err
+		}
+	}
+	if uint64(len(data)) != header.TotalSize {
+		return nil, nil, fmt.Errorf("reassembled %d bytes, CART header says %d", len(data), header.TotalSize)
+	}
+	if sha256.Sum256(data) != header.SHA256 {
+		return nil, nil, fmt.Errorf("SHA256 mismatch: reassembled file doesn't match the CART header")
+	}
+	return data, header, nil
+}
+
+// readChunks reassembles the DATA chunks of a (non-sharded) cartridge
+func readChunks(txs []Transaction, header *Header) ([]byte, error) {
+	if header.ChunkSize == 0 {
+		return nil, fmt.Errorf("CART header has a chunk size of 0")
+	}
+	expected := int((header.TotalSize + uint64(header.ChunkSize) - 1) / uint64(header.ChunkSize))
+	chunks := make(map[uint32][]byte, expected)
+	for _, tx := range txs {
+		p := tx.Payload()
+		if len(p) < dataHeaderSize || string(p[0:4]) != MagicDATA || binary.LittleEndian.Uint32(p[4:8]) != header.CartridgeID {
+			continue
+		}
+		index, length := binary.LittleEndian.Uint32(p[8:12]), int(p[12])
+		if length > int(header.ChunkSize) || dataHeaderSize+length > len(p) {
+			continue
+		}
+		if _, ok := chunks[index]; !ok {
+			chunks[index] = p[dataHeaderSize : dataHeaderSize+length]
+		}
+	}
+	data := make([]byte, 0, header.TotalSize)
+	for i := 0; i < expected; i++ {
+		chunk, ok := chunks[uint32(i)]
+		if !ok {
+			return nil, fmt.Errorf("chunk %d of %d is missing", i, expected)
+		}
+		data = append(data, chunk...)
+	}
+	return data, nil
+}
+
+// readShards reassembles a sharded cartridge from its SHRD links
+func (c *Client) readShards(txs []Transaction, header *Header, publisher string) ([]byte, error) {
+	links := make(map[uint16]*shardLink)
+	var count uint16
+	for _, tx := range txs {
+		if link, ok := decodeSHRD(tx.Payload()); ok && link.cartridgeID == header.CartridgeID {
+			if _, seen := links[link.index]; !seen {
+				links[link.index] = link
+				count = max(count, link.count)
+			}
+		}
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("sharded CART header but no SHRD records")
+	}
+	data := make([]byte, 0, header.TotalSize)
+	for i := uint16(0); i < count; i++ {
+		link, ok := links[i]
+		if !ok {
+			return nil, fmt.Errorf("SHRD record of shard %d is missing", i)
+		}
+		if link.offset != uint64(len(data)) {
+			return nil, fmt.Errorf("shard %d starts at byte %d, expected %d", i, link.offset, len(data))
+		}
+		shard, _, err := c.ReadCartridge(link.addr, publisher)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+		data = append(data, shard...)
+	}
+	return data, nil
+}
+
+// ============================================================================
+// Addresses
+// ============================================================================
+
+// alphabet is the Nimiq base32 alphabet (no I, O, W, Z)
+const alphabet = "0123456789ABCDEFGHJKLMNPQRSTUVXY"
+
+// NormalizeAddress removes spaces and uppercases an address
+func NormalizeAddress(addr string) string {
+	return strings.ToUpper(strings.ReplaceAll(addr, " ", ""))
+}
+
+// FormatAddress groups an address in blocks of four characters
+func FormatAddress(addr string) string {
+	n := NormalizeAddress(addr)
+	var groups []string
+	for i := 0; i < len(n); i += 4 {
+		groups = append(groups, n[i:min(i+4, len(n))])
+	}
+	return strings.Join(groups, " ")
+}
+
+// BytesToAddress formats a 20-byte address as "NQxx xxxx ..."
+func BytesToAddress(addr [20]byte) string {
+	var body strings.Builder
+	var buf uint64
+	bits := 0
+	for _, b := range addr {
+		buf = buf<<8 | uint64(b)
+		bits += 8
+		for bits >= 5 {
+			body.WriteByte(alphabet[(buf>>(bits-5))&0x1f])
+			bits -= 5
+			buf &= 1<<bits - 1
+		}
+	}
+	check := 98 - ibanCheck(body.String()+"NQ00")
+	return FormatAddress(fmt.Sprintf("NQ%02d%s", check, body.String()))
+}
+
+// ValidateAddress checks the format and checksum of a Nimiq address
+func ValidateAddress(addr string) error {
+	n := NormalizeAddress(addr)
+	if len(n) != 36 || !strings.HasPrefix(n, "NQ") {
+		return fmt.Errorf("invalid Nimiq address %q", addr)
+	}
+	for _, ch := range n[4:] {
+		if !strings.ContainsRune(alphabet, ch) {
+			return fmt.Errorf("invalid Nimiq address %q", addr)
+		}
+	}
+	if ibanCheck(n[4:]+n[:4]) != 1 {
+		return fmt.Errorf("invalid Nimiq address checksum: %s", FormatAddress(addr))
+	}
+	return nil
+}
+
+// ibanCheck computes the MOD-97-10 remainder of an IBAN-style string
+func ibanCheck(s string) int {
+	var digits strings.Builder
+	for _, ch := range strings.ToUpper(s) {
+		if ch >= '0' && ch <= '9' {
+			digits.WriteRune(ch)
+		} else {
+			fmt.Fprintf(&digits, "%d", ch-'A'+10)
+		}
+	}
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	return int(new(big.Int).Mod(n, big.NewInt(97)).Int64())
+}
@@ -0,0 +1,162 @@
+// Package output writes command results as JSON or YAML for scripts. Values
+// are marshaled through encoding/json first, so json struct tags name the
+// fields in both formats.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formats
+const (
+	Table = "table"
+	JSON  = "json"
+	YAML  = "yaml"
+)
+
+// ParseFormat checks an output format name; "" means Table
+func ParseFormat(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "", Table:
+		return Table, nil
+	case JSON:
+		return JSON, nil
+	case YAML, "yml":
+		return YAML, nil
+	}
+	return "", fmt.Errorf("unknown output format %q (use json, table or yaml)", s)
+}
+
+// Structured reports whether a format is for machines
+func Structured(format string) bool {
+	return format == JSON || format == YAML
+}
+
+// Write writes v to w as JSON or YAML
+func Write(w io.Writer, format string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if format != YAML {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	}
+
+	// Decode generically, keeping numbers as written
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	writeYAML(&buf, generic, 0)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// writeYAML writes a decoded JSON value as block-style YAML
+func writeYAML(buf *bytes.Buffer, v interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString(pad + "{}\n")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			buf.WriteString(pad + yamlString(k) + ":")
+			writeYAMLChild(buf, v[k], indent)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString(pad + "[]\n")
+			return
+		}
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok && len(m) > 0 {
+				// "- " takes the place of the first key's indentation
+				var child bytes.Buffer
+				writeYAML(&child, m, indent+1)
+				buf.WriteString(pad + "- " + strings.TrimPrefix(child.String(), pad+"  "))
+				continue
+			}
+			buf.WriteString(pad + "-")
+			writeYAMLChild(buf, item, indent)
+		}
+	default:
+		buf.WriteString(pad + yamlScalar(v) + "\n")
+	}
+}
+
+// writeYAMLChild writes the value after "key:" or "-"
+func writeYAMLChild(buf *bytes.Buffer, v interface{}, indent int) {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		if len(c) == 0 {
+			buf.WriteString(" {}\n")
+			return
+		}
+	case []interface{}:
+		if len(c) == 0 {
+			buf.WriteString(" []\n")
+			return
+		}
+	default:
+		buf.WriteString(" " + yamlScalar(v) + "\n")
+		return
+	}
+	buf.WriteString("\n")
+	writeYAML(buf, v, indent+1)
+}
+
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v)
+	}
+	return yamlString(fmt.Sprint(v))
+}
+
+// yamlNumberLike matches strings YAML 1.1 readers take for numbers or dates:
+// hex, octal and binary integers (object IDs are hex), digits with
+// underscores and ISO dates
+var yamlNumberLike = regexp.MustCompile(`^(0x[0-9a-fA-F_]+|0o?[0-7_]+|0b[01_]+|[-+]?[0-9][0-9_]*(\.[0-9_]*)?|\d{4}-\d{1,2}-\d{1,2}.*)$`)
+
+// yamlString quotes strings YAML would read as something else
+func yamlString(s string) string {
+	if s == "" || strings.ContainsAny(s, ":#{}[],&*!|>'\"%@`\n\t\\") ||
+		strings.TrimSpace(s) != s || strings.HasPrefix(s, "-") || strings.HasPrefix(s, "?") {
+		return strconv.Quote(s)
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~", "y", "n":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	if yamlNumberLike.MatchString(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,291 @@
+// Package plan describes publish operations ahead of time so they can be
+// reviewed, estimated and executed later
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// Version is the version of the plan file format
+	Version = 1
+	// KindPublishGame identifies publish-game plans
+	KindPublishGame = "publish-game"
+
+	// OpWalrusStore uploads the plan's file (or the operation's own file) to Walrus
+	OpWalrusStore = "walrus_store"
+	// OpSuiCall executes a Move call via the sui CLI
+	OpSuiCall = "sui_call"
+
+	// DefaultGasBudget is the gas budget (MIST) used for each Move call
+	DefaultGasBudget uint64 = 10000000
+	// MistPerSUI is the number of MIST in one SUI
+	MistPerSUI = 1000000000
+)
+
+// Placeholders for values that only exist once an earlier operation ran.
+// They are substituted into Move call arguments at execution time.
+const (
+	// PlaceholderBlobIDHex is the 0x-prefixed hex of the stored Walrus blob ID
+	PlaceholderBlobIDHex = "{{blob_id_hex}}"
+	// PlaceholderCartridgeID is the object ID of the created cartridge
+	PlaceholderCartridgeID = "{{cartridge_id}}"
+	// PlaceholderNowMs is the current time in milliseconds
+	PlaceholderNowMs = "{{now_ms}}"
+)
+
+// Rough throughput figures used for duration estimates
+const (
+	// EstimatedSuiCallSeconds is the typical time for a Move call to finalize
+	EstimatedSuiCallSeconds = 3.0
+	// EstimatedWalrusBytesPerSecond is the assumed upload speed to a publisher
+	EstimatedWalrusBytesPerSecond = 1 << 20
+	// EstimatedWalrusOverheadSeconds covers encoding and certification
+	EstimatedWalrusOverheadSeconds = 10.0
+	// WalrusEncodingFactor approximates the stored size after erasure coding
+	WalrusEncodingFactor = 5
+)
+
+// Plan is a complete, machine-readable description of a publish. It contains
+// no timestamps, so the same inputs always produce the same plan.
+type Plan struct {
+	Version    int         `json:"version"`
+	Kind       string      `json:"kind"`
+	Network    string      `json:"network"`
+	PackageID  string      `json:"package_id"`
+	CatalogID  string      `json:"catalog_id"`
+	File       FileInfo    `json:"file"`
+	Operations []Operation `json:"operations"`
+	Estimate   Estimate    `json:"estimate"`
+}
+
+// FileInfo identifies the file a plan publishes
+type FileInfo struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Operation is a single step of a plan, in execution order
+type Operation struct {
+	Step        int    `json:"step"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	// Output names the value this step produces for later placeholders
+	Output string `json:"output,omitempty"`
+	// ObjectType is the type of the created object whose ID becomes Output
+	ObjectType string `json:"object_type,omitempty"`
+
+	// walrus_store
+	Epochs      int   `json:"epochs,omitempty"`
+	PayloadSize int64 `json:"payload_size,omitempty"`
+	// File is uploaded instead of the plan's file (extra cartridge assets)
+	File *FileInfo `json:"file,omitempty"`
+
+	// sui_call
+	Module    string   `json:"module,omitempty"`
+	Function  string   `json:"function,omitempty"`
+	Args      []string `json:"args,omitempty"`
+	GasBudget uint64   `json:"gas_budget,omitempty"`
+}
+
+// Estimate summarizes the cost and duration of a plan
+type Estimate struct {
+	SuiTransactions    int     `json:"sui_transactions"`
+	MaxGasMist         uint64  `json:"max_gas_mist"`
+	WalrusBlobBytes    int64   `json:"walrus_blob_bytes"`
+	WalrusEncodedBytes int64   `json:"walrus_encoded_bytes"`
+	WalrusEpochs       int     `json:"walrus_epochs"`
+	DurationSeconds    float64 `json:"duration_seconds"`
+}
+
+// Add appends an operation, numbering it
+func (p *Plan) Add(op Operation) {
+	op.Step = len(p.Operations) + 1
+	p.Operations = append(p.Operations, op)
+}
+
+// ComputeEstimate fills in the plan's estimate from its operations
+func (p *Plan) ComputeEstimate() {
+	est := Estimate{}
+	for _, op := range p.Operations {
+		switch op.Type {
+		case OpWalrusStore:
+			est.WalrusBlobBytes += op.PayloadSize
+			est.WalrusEncodedBytes += op.PayloadSize * WalrusEncodingFactor
+			est.WalrusEpochs = op.Epochs
+			est.DurationSeconds += EstimatedWalrusOverheadSeconds + float64(op.PayloadSize)/EstimatedWalrusBytesPerSecond
+		case OpSuiCall:
+			est.SuiTransactions++
+			est.MaxGasMist += op.GasBudget
+			est.DurationSeconds += EstimatedSuiCallSeconds
+		}
+	}
+	p.Estimate = est
+}
+
+// Save writes the plan as indented JSON
+func (p *Plan) Save(filename string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write plan file: %w", err)
+	}
+	return nil
+}
+
+// Load reads a plan written by Save
+func Load(filename string) (*Plan, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file: %w", err)
+	}
+	if p.Kind != KindPublishGame {
+		return nil, fmt.Errorf("unsupported plan kind %q (expected %q)", p.Kind, KindPublishGame)
+	}
+	if p.Version != Version {
+		return nil, fmt.Errorf("unsupported plan version %d (expected %d)", p.Version, Version)
+	}
+	return &p, nil
+}
+
+// Digest returns the SHA256 of the plan's canonical JSON encoding. Progress
+// files record it so they are never applied to a different plan.
+func (p *Plan) Digest() string {
+	data, _ := json.Marshal(p)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Progress records the completed steps of a plan so execution can resume
+type Progress struct {
+	PlanDigest string            `json:"plan_digest"`
+	Outputs    map[string]string `json:"outputs"`
+	// Completed maps step numbers to their result (tx digest or blob ID)
+	Completed map[int]string `json:"completed"`
+	// GasMist is the net gas spent by completed Move calls
+	GasMist int64 `json:"gas_mist,omitempty"`
+	// WalrusCost is the storage cost reported by the Walrus publisher
+	WalrusCost uint64 `json:"walrus_cost,omitempty"`
+	// Log is every step event of every run, oldest first
+	Log []StepEvent `json:"log,omitempty"`
+}
+
+// Step statuses reported in step events
+const (
+	StepStarted = "started"
+	StepDone    = "done"
+	StepFailed  = "failed"
+	StepSkipped = "skipped"
+)
+
+// StepEvent is a structured progress event for one operation of a plan
+type StepEvent struct {
+	Step        int    `json:"step"`
+	Total       int    `json:"total"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	// Attempt counts how many times the step has been started, across runs
+	Attempt int `json:"attempt"`
+	// Outputs are the IDs the step produced (blob ID, object ID, digest)
+	Outputs map[string]string `json:"outputs,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	Time    string            `json:"time"`
+}
+
+// Attempts returns how many times a step has been started according to the log
+func (prog *Progress) Attempts(step int) int {
+	n := 0
+	for _, ev := range prog.Log {
+		if ev.Step == step && ev.Status == StepStarted {
+			n++
+		}
+	}
+	return n
+}
+
+// NewProgress returns empty progress for a plan
+func NewProgress(p *Plan) *Progress {
+	return &Progress{
+		PlanDigest: p.Digest(),
+		Outputs:    make(map[string]string),
+		Completed:  make(map[int]string),
+	}
+}
+
+// LoadProgress reads progress for the plan, returning fresh progress if the
+// file doesn't exist. Progress recorded for a different plan is an error.
+func LoadProgress(filename string, p *Plan) (*Progress, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return NewProgress(p), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read progress file: %w", err)
+	}
+
+	var prog Progress
+	if err := json.Unmarshal(data, &prog); err != nil {
+		return nil, fmt.Errorf("failed to parse progress file: %w", err)
+	}
+	if prog.PlanDigest != p.Digest() {
+		return nil, fmt.Errorf("progress file %s belongs to a different plan; remove it to start over", filename)
+	}
+	if prog.Outputs == nil {
+		prog.Outputs = make(map[string]string)
+	}
+	if prog.Completed == nil {
+		prog.Completed = make(map[int]string)
+	}
+	return &prog, nil
+}
+
+// Save writes the progress as indented JSON
+func (prog *Progress) Save(filename string) error {
+	data, err := json.MarshalIndent(prog, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+	if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write progress file: %w", err)
+	}
+	return nil
+}
+
+// ResolveArgs substitutes {{name}} placeholders with recorded outputs.
+// Values in extra (e.g. now_ms) take precedence.
+func ResolveArgs(args []string, outputs, extra map[string]string) ([]string, error) {
+	resolved := make([]string, len(args))
+	for i, arg := range args {
+		for strings.Contains(arg, "{{") {
+			start := strings.Index(arg, "{{")
+			end := strings.Index(arg[start:], "}}")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated placeholder in argument %q", args[i])
+			}
+			name := arg[start+2 : start+end]
+			value, ok := extra[name]
+			if !ok {
+				value, ok = outputs[name]
+			}
+			if !ok {
+				return nil, fmt.Errorf("placeholder {{%s}} has no value (is an earlier step missing?)", name)
+			}
+			arg = arg[:start] + value + arg[start+end+2:]
+		}
+		resolved[i] = arg
+	}
+	return resolved, nil
+}
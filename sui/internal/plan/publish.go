@@ -0,0 +1,185 @@
+package plan
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/retro-crypto/sui/internal/delta"
+	"github.com/retro-crypto/sui/internal/model"
+)
+
+// CoverAsset is the cartridge asset holding the cover image. Its blob is
+// also stored in the catalog entry (cover_blob_id) for listings.
+const CoverAsset = "cover"
+
+// PublishGame are the inputs of a publish-game plan
+type PublishGame struct {
+	Network   string
+	PackageID string
+	File      FileInfo
+	Slug      string
+	Title     string
+	Platform  model.Platform
+	Emulator  string
+	Version   uint16
+	Epochs    int
+	CatalogID string
+	// CapID is the CuratorCap to add the entry with ("" for the owner)
+	CapID string
+	// Channel is the release channel of the entry
+	Channel string
+	// Assets are extra named files attached to the cartridge
+	Assets []Asset
+	// Delta uploads a patch against an earlier cartridge instead of the file
+	Delta *Delta
+}
+
+// Asset is an extra file published with a game (manual, soundtrack, ...)
+type Asset struct {
+	Name string
+	File FileInfo
+}
+
+// Delta is the patch a delta update uploads instead of the file
+type Delta struct {
+	BaseCartridgeID string
+	Patch           FileInfo
+}
+
+// EntryKey returns the catalog key the entry is added under
+func (p PublishGame) EntryKey() string {
+	return model.ChannelKey(p.Slug, p.Channel)
+}
+
+// NewPublishGame describes the operations of a publish-game run, in order:
+// upload the file (or patch), create the cartridge, attach the assets and
+// add the catalog entry
+func NewPublishGame(p PublishGame) *Plan {
+	pl := &Plan{
+		Version:   Version,
+		Kind:      KindPublishGame,
+		Network:   p.Network,
+		PackageID: p.PackageID,
+		CatalogID: p.CatalogID,
+		File:      p.File,
+	}
+	name := filepath.Base(p.File.Path)
+
+	if p.Delta != nil {
+		patch := p.Delta.Patch
+		pl.Add(Operation{
+			Type:        OpWalrusStore,
+			Description: fmt.Sprintf("Upload patch for %s to Walrus", name),
+			Output:      "blob_id",
+			Epochs:      p.Epochs,
+			PayloadSize: patch.Size,
+			File:        &patch,
+		})
+	} else {
+		pl.Add(Operation{
+			Type:        OpWalrusStore,
+			Description: fmt.Sprintf("Upload %s to Walrus", name),
+			Output:      "blob_id",
+			Epochs:      p.Epochs,
+			PayloadSize: p.File.Size,
+		})
+	}
+
+	pl.Add(Operation{
+		Type:        OpSuiCall,
+		Description: "Create cartridge on Sui",
+		Output:      "cartridge_id",
+		ObjectType:  "Cartridge",
+		Module:      "cartridge",
+		Function:    "create_cartridge",
+		Args: []string{
+			p.Slug,
+			p.Title,
+			fmt.Sprintf("%d", p.Platform),
+			p.Emulator,
+			fmt.Sprintf("%d", p.Version),
+			PlaceholderBlobIDHex,
+			"0x" + p.File.SHA256,
+			fmt.Sprintf("%d", p.File.Size),
+			PlaceholderNowMs,
+		},
+		GasBudget: DefaultGasBudget,
+	})
+
+	if p.Delta != nil {
+		pl.Add(Operation{
+			Type:        OpSuiCall,
+			Description: "Mark cartridge as a delta update",
+			Module:      "cartridge",
+			Function:    "set_delta",
+			Args: []string{
+				PlaceholderCartridgeID,
+				p.Delta.BaseCartridgeID,
+				delta.Format,
+				"0x" + p.Delta.Patch.SHA256,
+				fmt.Sprintf("%d", p.Delta.Patch.Size),
+			},
+			GasBudget: DefaultGasBudget,
+		})
+	}
+
+	for _, asset := range p.Assets {
+		file := asset.File
+		output := "asset_" + asset.Name + "_blob_id"
+		pl.Add(Operation{
+			Type:        OpWalrusStore,
+			Description: fmt.Sprintf("Upload %s asset %s to Walrus", asset.Name, filepath.Base(file.Path)),
+			Output:      output,
+			Epochs:      p.Epochs,
+			PayloadSize: file.Size,
+			File:        &file,
+		})
+		pl.Add(Operation{
+			Type:        OpSuiCall,
+			Description: fmt.Sprintf("Attach %s asset to cartridge", asset.Name),
+			Module:      "cartridge",
+			Function:    "add_asset",
+			Args: []string{
+				PlaceholderCartridgeID,
+				asset.Name,
+				"{{" + output + "_hex}}",
+				"0x" + file.SHA256,
+				fmt.Sprintf("%d", file.Size),
+			},
+			GasBudget: DefaultGasBudget,
+		})
+	}
+
+	// The catalog entry points listings at the cover asset's blob
+	cover := "[]"
+	for _, asset := range p.Assets {
+		if asset.Name == CoverAsset {
+			cover = "{{asset_" + CoverAsset + "_blob_id_hex}}"
+		}
+	}
+
+	function, authArgs := "add_entry", []string{p.CatalogID}
+	if p.CapID != "" {
+		function, authArgs = "add_entry_with_cap", []string{p.CatalogID, p.CapID}
+	}
+	pl.Add(Operation{
+		Type:        OpSuiCall,
+		Description: "Add entry to catalog",
+		Module:      "catalog",
+		Function:    function,
+		Args: append(authArgs,
+			p.EntryKey(),
+			PlaceholderCartridgeID,
+			p.Title,
+			fmt.Sprintf("%d", p.Platform),
+			fmt.Sprintf("%d", p.File.Size),
+			p.Emulator,
+			fmt.Sprintf("%d", p.Version),
+			cover,
+		),
+		GasBudget: DefaultGasBudget,
+	})
+
+	pl.ComputeEstimate()
+	return pl
+}
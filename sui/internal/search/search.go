@@ -0,0 +1,177 @@
+// Package search is a small full-text index over catalog entries.
+//
+// An Index is an inverted index from lowercase terms to the documents that
+// contain them, weighted by the field the term comes from. It marshals to
+// compact JSON, so export-site can ship it for client-side search and the
+// gateway can answer /search from the same structure.
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Version of the JSON index format
+const Version = 1
+
+// Field weights: a title match ranks above an ID or tag match, which ranks
+// above a description match
+const (
+	WeightTitle       = 4
+	WeightID          = 2
+	WeightTag         = 2
+	WeightDescription = 1
+)
+
+// Document is one searchable item
+type Document struct {
+	// ID identifies the document (a catalog entry key) and is searchable
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Fields are returned with results as they are (catalog, cartridge, ...)
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Posting is a document (its index in Documents) containing a term, and the
+// weight of the strongest field it appears in
+type Posting [2]int
+
+// Index maps terms to the documents containing them
+type Index struct {
+	Version   int                  `json:"version"`
+	Documents []Document           `json:"documents"`
+	Terms     map[string][]Posting `json:"terms"`
+
+	// sorted holds the keys of Terms for prefix lookups
+	sorted []string
+}
+
+// Result is a matching document and its score
+type Result struct {
+	Document
+	Score int `json:"score"`
+}
+
+// Build indexes documents
+func Build(docs []Document) *Index {
+	idx := &Index{Version: Version, Documents: docs, Terms: make(map[string][]Posting)}
+	for i, doc := range docs {
+		weights := make(map[string]int)
+		add := func(text string, weight int) {
+			for _, term := range Tokenize(text) {
+				if weight > weights[term] {
+					weights[term] = weight
+				}
+			}
+		}
+		add(doc.Title, WeightTitle)
+		add(doc.ID, WeightID)
+		for _, tag := range doc.Tags {
+			add(tag, WeightTag)
+		}
+		add(doc.Description, WeightDescription)
+		for term, weight := range weights {
+			idx.Terms[term] = append(idx.Terms[term], Posting{i, weight})
+		}
+	}
+	idx.sortTerms()
+	return idx
+}
+
+// Parse reads an index written as JSON
+func Parse(data []byte) (*Index, error) {
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("invalid search index: %w", err)
+	}
+	if idx.Version > Version {
+		return nil, fmt.Errorf("search index version %d is newer than supported (%d)", idx.Version, Version)
+	}
+	for term, postings := range idx.Terms {
+		for _, p := range postings {
+			if p[0] < 0 || p[0] >= len(idx.Documents) {
+				return nil, fmt.Errorf("search index term %q points to document %d of %d", term, p[0], len(idx.Documents))
+			}
+		}
+	}
+	idx.sortTerms()
+	return &idx, nil
+}
+
+func (idx *Index) sortTerms() {
+	idx.sorted = make([]string, 0, len(idx.Terms))
+	for term := range idx.Terms {
+		idx.sorted = append(idx.sorted, term)
+	}
+	sort.Strings(idx.sorted)
+}
+
+// Search returns the documents matching every term of query, best first.
+// Query terms also match longer terms they are a prefix of ("zel" finds
+// "zelda"), at half the weight of an exact match. limit <= 0 returns all.
+func (idx *Index) Search(query string, limit int) []Result {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return []Result{}
+	}
+
+	var scores map[int]int
+	for _, term := range terms {
+		best := make(map[int]int)
+		// Terms with this prefix are adjacent in sorted order, the exact
+		// term first
+		for i := sort.SearchStrings(idx.sorted, term); i < len(idx.sorted) && strings.HasPrefix(idx.sorted[i], term); i++ {
+			exact := idx.sorted[i] == term
+			for _, p := range idx.Terms[idx.sorted[i]] {
+				score := p[1] * 2
+				if !exact {
+					score = p[1]
+				}
+				if score > best[p[0]] {
+					best[p[0]] = score
+				}
+			}
+		}
+		if scores == nil {
+			scores = best
+			continue
+		}
+		for doc := range scores {
+			if best[doc] == 0 {
+				delete(scores, doc)
+			} else {
+				scores[doc] += best[doc]
+			}
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for doc, score := range scores {
+		results = append(results, Result{Document: idx.Documents[doc], Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Title != results[j].Title {
+			return results[i].Title < results[j].Title
+		}
+		return results[i].ID < results[j].ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// Tokenize splits text into lowercase terms of letters and digits
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,102 @@
+// Package storage abstracts the decentralized storage game files are kept
+// in, so commands don't depend on a particular system. Walrus is the only
+// backend today.
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Backend stores and serves blobs
+type Backend interface {
+	// Name is the backend's storage_backend value
+	Name() string
+	// Store uploads data for the given number of epochs (or the backend's
+	// equivalent unit of paid storage)
+	Store(data []byte, opts StoreOptions) (*Stored, error)
+	// StoreFile uploads a file like Store, streaming it from disk
+	StoreFile(path string, opts StoreOptions) (*Stored, error)
+	// Read downloads a blob
+	Read(blobID string) ([]byte, error)
+	// Stat reports whether a blob can be retrieved and how large it is
+	Stat(blobID string) (*Info, error)
+	// Extend pays for a blob to be kept for more epochs and returns the
+	// backend's output
+	Extend(blobID string, epochs int) (string, error)
+}
+
+// Expirer is implemented by backends whose blobs are stored for a limited
+// number of epochs
+type Expirer interface {
+	// Expiry reports until when a blob is stored
+	Expiry(blobID string) (*Expiry, error)
+}
+
+// Expiry is how long a blob is paid for
+type Expiry struct {
+	// Exists is false if the backend doesn't know the blob
+	Exists bool
+	// EndEpoch is the first epoch the blob is no longer stored in
+	EndEpoch uint64
+	// CurrentEpoch is the backend's current epoch
+	CurrentEpoch uint64
+}
+
+// Expired reports whether the blob is no longer stored
+func (e *Expiry) Expired() bool {
+	return !e.Exists || e.EndEpoch <= e.CurrentEpoch
+}
+
+// StoreOptions controls an upload
+type StoreOptions struct {
+	Epochs int
+	// HostedOnly uploads through hosted services only, never paying from
+	// the local wallet (used by servers accepting uploads from others)
+	HostedOnly bool
+	// Progress, if set, receives the bytes sent of a StoreFile upload and
+	// the file size; a retried upload starts again from 0
+	Progress func(sent, total int64)
+}
+
+// Stored is the result of an upload
+type Stored struct {
+	BlobID string
+	// Cost is what the upload paid, 0 if the blob was already stored
+	Cost uint64
+	// AlreadyStored is true if the backend already held the blob
+	AlreadyStored bool
+}
+
+// Info describes a stored blob
+type Info struct {
+	BlobID string
+	// Size in bytes, -1 if the backend doesn't report it
+	Size int64
+	// Source is the endpoint that answered
+	Source string
+}
+
+// Default is the backend used when storage_backend is not set
+const Default = "walrus"
+
+// Names lists the supported backends
+var Names = []string{"walrus"}
+
+// Known reports whether name is a supported backend ("" is the default)
+func Known(name string) bool {
+	if name == "" {
+		return true
+	}
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UnknownError is returned when storage_backend names no supported backend
+func UnknownError(name string) error {
+	return fmt.Errorf("unknown storage backend %q (supported: %s)", name, strings.Join(Names, ", "))
+}
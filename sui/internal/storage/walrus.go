@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/retro-crypto/sui/internal/walrus"
+)
+
+// readRetries is how many times Walrus reads go through the mirror list
+const readRetries = 3
+
+// Walrus stores blobs on Walrus: uploads go to the publisher (falling back
+// to the walrus CLI), reads to the fastest healthy aggregator
+type Walrus struct {
+	client  *walrus.Client
+	mirrors *walrus.Mirrors
+	network string
+	// blobObject finds the Blob object owned by the uploader, which Walrus
+	// extends instead of the blob ID
+	blobObject func(blobID string) (string, error)
+}
+
+// NewWalrus creates the Walrus backend. blobObject resolves a blob ID to the
+// Sui Blob object that Extend needs.
+func NewWalrus(client *walrus.Client, mirrors *walrus.Mirrors, network string, blobObject func(blobID string) (string, error)) *Walrus {
+	return &Walrus{client: client, mirrors: mirrors, network: network, blobObject: blobObject}
+}
+
+// Name implements Backend
+func (w *Walrus) Name() string { return "walrus" }
+
+// Mirrors returns the aggregator set reads go through
+func (w *Walrus) Mirrors() *walrus.Mirrors { return w.mirrors }
+
+// Store implements Backend
+func (w *Walrus) Store(data []byte, opts StoreOptions) (*Stored, error) {
+	var resp *walrus.StoreResponse
+	var err error
+	if opts.HostedOnly {
+		resp, err = w.client.StorePublisher(data, opts.Epochs)
+	} else {
+		resp, err = w.client.Store(data, opts.Epochs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return storedFromResponse(resp)
+}
+
+// StoreFile implements Backend. HostedOnly is not supported for files.
+func (w *Walrus) StoreFile(path string, opts StoreOptions) (*Stored, error) {
+	if opts.HostedOnly {
+		return nil, fmt.Errorf("hosted-only uploads take data, not a file")
+	}
+	resp, err := w.client.StoreFileProgress(path, opts.Epochs, opts.Progress)
+	if err != nil {
+		return nil, err
+	}
+	return storedFromResponse(resp)
+}
+
+func storedFromResponse(resp *walrus.StoreResponse) (*Stored, error) {
+	blobID := resp.GetBlobID()
+	if blobID == "" {
+		return nil, fmt.Errorf("no blob ID in response")
+	}
+	stored := &Stored{BlobID: blobID, AlreadyStored: resp.AlreadyCertified != nil}
+	if resp.NewlyCreated != nil {
+		stored.Cost = resp.NewlyCreated.Cost
+	}
+	return stored, nil
+}
+
+// StoreFileResumable uploads a large file in chunks that survive an
+// interrupted upload (see walrus.Client.StoreFileResumable)
+func (w *Walrus) StoreFileResumable(path string, epochs int, opts walrus.ResumableOptions) (*Stored, *walrus.ChunkManifest, error) {
+	resp, manifest, err := w.client.StoreFileResumable(path, epochs, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	stored, err := storedFromResponse(resp)
+	return stored, manifest, err
+}
+
+// Read implements Backend. A chunk manifest left by a resumable upload is
+// read as the file it describes.
+func (w *Walrus) Read(blobID string) ([]byte, error) {
+	data, err := w.readRaw(blobID)
+	if err != nil {
+		return nil, err
+	}
+	manifest := walrus.ParseChunkManifest(data)
+	if manifest == nil {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	buf.Grow(int(manifest.Size))
+	if err := manifest.Assemble(&buf, w.readRaw); err != nil {
+		return nil, fmt.Errorf("chunked blob %s: %w", blobID, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// readRaw reads a blob as stored, without assembling chunks
+func (w *Walrus) readRaw(blobID string) ([]byte, error) {
+	data, _, err := w.mirrors.ReadWithRetry(blobID, readRetries)
+	return data, err
+}
+
+// Stat implements Backend. Aggregators have no metadata endpoint, so the
+// blob is opened and closed after the headers arrive.
+func (w *Walrus) Stat(blobID string) (*Info, error) {
+	resp, aggregator, err := w.mirrors.OpenRange(blobID, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return &Info{BlobID: blobID, Size: resp.Size, Source: aggregator}, nil
+}
+
+// Expiry implements Expirer through the walrus CLI
+func (w *Walrus) Expiry(blobID string) (*Expiry, error) {
+	status, err := w.client.BlobStatus(blobID, w.network)
+	if err != nil {
+		return nil, err
+	}
+	return &Expiry{Exists: status.Exists, EndEpoch: status.EndEpoch, CurrentEpoch: status.CurrentEpoch}, nil
+}
+
+// Extend implements Backend
+func (w *Walrus) Extend(blobID string, epochs int) (string, error) {
+	if w.blobObject == nil {
+		return "", fmt.Errorf("extending blobs is not available here")
+	}
+	objectID, err := w.blobObject(blobID)
+	if err != nil {
+		return "", err
+	}
+	return w.client.Extend(objectID, epochs, w.network)
+}
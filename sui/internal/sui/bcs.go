@@ -0,0 +1,71 @@
+package sui
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// bcsWriter writes Binary Canonical Serialization, the encoding of Sui
+// transactions and Move values: little-endian integers, ULEB128 lengths
+// and enum variant indexes, no padding.
+type bcsWriter struct {
+	buf []byte
+}
+
+func (w *bcsWriter) bytes() []byte { return w.buf }
+
+func (w *bcsWriter) uleb128(n uint64) {
+	for n >= 0x80 {
+		w.buf = append(w.buf, byte(n)|0x80)
+		n >>= 7
+	}
+	w.buf = append(w.buf, byte(n))
+}
+
+func (w *bcsWriter) u8(v uint8) { w.buf = append(w.buf, v) }
+
+func (w *bcsWriter) u16(v uint16) { w.buf = binary.LittleEndian.AppendUint16(w.buf, v) }
+
+func (w *bcsWriter) u64(v uint64) { w.buf = binary.LittleEndian.AppendUint64(w.buf, v) }
+
+func (w *bcsWriter) bool(v bool) {
+	if v {
+		w.u8(1)
+	} else {
+		w.u8(0)
+	}
+}
+
+// vector writes a byte vector with its length
+func (w *bcsWriter) vector(b []byte) {
+	w.uleb128(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *bcsWriter) string(s string) { w.vector([]byte(s)) }
+
+// address writes a 32-byte address or object ID given as 0x-hex; short
+// forms such as 0x2 are padded with leading zeros
+func (w *bcsWriter) address(s string) error {
+	b, err := addressBytes(s)
+	if err != nil {
+		return err
+	}
+	w.buf = append(w.buf, b...)
+	return nil
+}
+
+// addressBytes decodes a 0x-hex address or object ID to its 32 bytes
+func addressBytes(s string) ([]byte, error) {
+	digits, ok := strings.CutPrefix(s, "0x")
+	if !ok || len(digits) == 0 || len(digits) > 64 {
+		return nil, fmt.Errorf("invalid address %q", s)
+	}
+	b, err := hex.DecodeString(strings.Repeat("0", 64-len(digits)) + digits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q", s)
+	}
+	return b, nil
+}
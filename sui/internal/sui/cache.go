@@ -0,0 +1,329 @@
+package sui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ObjectCache is a read-through cache of object reads shared by every
+// Client of the process. An object at a given version never changes, so
+// responses are stored by object ID and version and kept until pruned; which
+// version is the latest is only trusted for the TTL, or until a write of
+// this process touches the object. With a directory the cache is kept on
+// disk too, so later runs start warm.
+type ObjectCache struct {
+	mu  sync.Mutex
+	dir string
+	ttl time.Duration
+
+	// responses holds raw RPC responses by kind, object ID and version
+	responses map[string]json.RawMessage
+	// latest holds the newest known version of each object
+	latest map[string]latestVersion
+	// fields maps a parent ID and dynamic field name to the field's object ID,
+	// which is derived from them and so never changes
+	fields map[string]string
+}
+
+type latestVersion struct {
+	Version string `json:"version"`
+	// Seen is when the version was last confirmed by the RPC (Unix seconds)
+	Seen int64 `json:"seen"`
+}
+
+// Response kinds; sui_getObject and suix_getDynamicFieldObject render the
+// same object differently, so they are cached apart
+const (
+	kindObject = "object"
+	kindField  = "field"
+)
+
+// ObjectCacheMaxAge is how long unused responses stay on disk
+const ObjectCacheMaxAge = 7 * 24 * time.Hour
+
+var (
+	objectCacheMu sync.RWMutex
+	objectCache   *ObjectCache
+)
+
+// NewObjectCache returns a cache trusting latest versions for ttl (0 always
+// asks the RPC which version is the latest). dir may be empty for a cache
+// that only lives in memory.
+func NewObjectCache(dir string, ttl time.Duration) (*ObjectCache, error) {
+	c := &ObjectCache{
+		dir:       dir,
+		ttl:       ttl,
+		responses: make(map[string]json.RawMessage),
+		latest:    make(map[string]latestVersion),
+		fields:    make(map[string]string),
+	}
+	if dir != "" {
+		for _, sub := range []string{"objects", "latest", "fields"} {
+			if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+				return nil, fmt.Errorf("failed to create object cache: %w", err)
+			}
+		}
+		c.prune()
+	}
+	return c, nil
+}
+
+// SetObjectCache makes every Client read through c (nil disables caching)
+func SetObjectCache(c *ObjectCache) {
+	objectCacheMu.Lock()
+	defer objectCacheMu.Unlock()
+	objectCache = c
+}
+
+func currentObjectCache() *ObjectCache {
+	objectCacheMu.RLock()
+	defer objectCacheMu.RUnlock()
+	return objectCache
+}
+
+// InvalidateObjects forgets the latest versions of objects a write changed
+func InvalidateObjects(ids ...string) {
+	if c := currentObjectCache(); c != nil {
+		c.Invalidate(ids...)
+	}
+}
+
+// InvalidateTransaction forgets the latest versions of every object a
+// transaction response (sui CLI --json output or sui_executeTransactionBlock)
+// lists as changed, or of all objects if the response can't be read
+func InvalidateTransaction(response []byte) {
+	c := currentObjectCache()
+	if c == nil {
+		return
+	}
+	var tx struct {
+		ObjectChanges []struct {
+			ObjectID string `json:"objectId"`
+		} `json:"objectChanges"`
+	}
+	if err := json.Unmarshal(response, &tx); err != nil || tx.ObjectChanges == nil {
+		c.InvalidateAll()
+		return
+	}
+	for _, change := range tx.ObjectChanges {
+		c.Invalidate(change.ObjectID)
+	}
+}
+
+// Invalidate forgets the latest versions of objects, so the next read asks
+// the RPC again
+func (c *ObjectCache) Invalidate(ids ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range ids {
+		if !objectIDPattern.MatchString(id) {
+			continue
+		}
+		delete(c.latest, id)
+		if c.dir != "" {
+			os.Remove(c.path("latest", id))
+		}
+	}
+}
+
+// InvalidateAll forgets every latest version. Stored versions stay valid.
+func (c *ObjectCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.latest = make(map[string]latestVersion)
+	if c.dir != "" {
+		os.RemoveAll(filepath.Join(c.dir, "latest"))
+		os.MkdirAll(filepath.Join(c.dir, "latest"), 0700)
+	}
+}
+
+// get returns the cached response of the latest version of an object, if
+// that version is still trusted
+func (c *ObjectCache) get(kind, id string) (json.RawMessage, bool) {
+	if !objectIDPattern.MatchString(id) {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	latest, ok := c.latest[id]
+	if !ok && c.dir != "" {
+		ok = readJSON(c.path("latest", id), &latest)
+		if ok {
+			c.latest[id] = latest
+		}
+	}
+	if !ok || time.Since(time.Unix(latest.Seen, 0)) >= c.ttl {
+		return nil, false
+	}
+	return c.response(kind, id, latest.Version)
+}
+
+// response returns a stored response of an object version; c.mu is held
+func (c *ObjectCache) response(kind, id, version string) (json.RawMessage, bool) {
+	key := kind + ":" + id + "@" + version
+	if raw, ok := c.responses[key]; ok {
+		return raw, true
+	}
+	if c.dir == "" {
+		return nil, false
+	}
+	raw, err := os.ReadFile(c.path("objects", kind+"_"+id+"@"+version))
+	if err != nil || !json.Valid(raw) {
+		return nil, false
+	}
+	c.responses[key] = raw
+	return raw, true
+}
+
+// put stores the response of an object read from the RPC
+func (c *ObjectCache) put(kind, id, version string, raw json.RawMessage) {
+	if !cacheable(id, version) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[kind+":"+id+"@"+version] = raw
+	if c.dir != "" {
+		writeFileAtomic(c.path("objects", kind+"_"+id+"@"+version), raw)
+	}
+	c.seen(id, version)
+}
+
+// seen records version as the latest of an object; c.mu is held
+func (c *ObjectCache) seen(id, version string) {
+	latest := latestVersion{Version: version, Seen: time.Now().Unix()}
+	c.latest[id] = latest
+	if c.dir != "" {
+		raw, _ := json.Marshal(latest)
+		writeFileAtomic(c.path("latest", id), raw)
+	}
+}
+
+// listed records the versions a dynamic field listing reported. They are
+// current, so stored responses of those versions are served without asking
+// the RPC again.
+func (c *ObjectCache) listed(parentID string, fields []DynamicFieldInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, f := range fields {
+		version := strconv.Itoa(f.Version)
+		if f.Version == 0 || !cacheable(f.ObjectID, version) {
+			continue
+		}
+		c.setField(parentID, f.Name, f.ObjectID)
+		if _, ok := c.response(kindField, f.ObjectID, version); ok {
+			c.seen(f.ObjectID, version)
+		} else {
+			// A newer version than the stored one must be fetched
+			delete(c.latest, f.ObjectID)
+		}
+	}
+}
+
+// fieldID returns the object ID of a dynamic field, if known
+func (c *ObjectCache) fieldID(parentID string, name DynamicFieldName) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := fieldKey(parentID, name)
+	if id, ok := c.fields[key]; ok {
+		return id, true
+	}
+	if c.dir == "" {
+		return "", false
+	}
+	raw, err := os.ReadFile(c.path("fields", key))
+	if err != nil || !objectIDPattern.Match(raw) {
+		return "", false
+	}
+	c.fields[key] = string(raw)
+	return string(raw), true
+}
+
+func (c *ObjectCache) addField(parentID string, name DynamicFieldName, id string) {
+	if !objectIDPattern.MatchString(id) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setField(parentID, name, id)
+}
+
+// setField records the object ID of a dynamic field; c.mu is held
+func (c *ObjectCache) setField(parentID string, name DynamicFieldName, id string) {
+	key := fieldKey(parentID, name)
+	if c.fields[key] == id {
+		return
+	}
+	c.fields[key] = id
+	if c.dir != "" {
+		writeFileAtomic(c.path("fields", key), []byte(id))
+	}
+}
+
+// prune removes stored responses not written for ObjectCacheMaxAge
+func (c *ObjectCache) prune() {
+	dir := filepath.Join(c.dir, "objects")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && time.Since(info.ModTime()) > ObjectCacheMaxAge {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+var (
+	objectIDPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{1,64}$`)
+	versionPattern  = regexp.MustCompile(`^[0-9]{1,20}$`)
+)
+
+// path returns the file of a cache entry
+func (c *ObjectCache) path(sub, name string) string {
+	return filepath.Join(c.dir, sub, name)
+}
+
+// cacheable reports whether an object ID and version are safe to use in
+// file names
+func cacheable(id, version string) bool {
+	return objectIDPattern.MatchString(id) && versionPattern.MatchString(version)
+}
+
+func fieldKey(parentID string, name DynamicFieldName) string {
+	raw, _ := json.Marshal(name)
+	sum := sha256.Sum256(append([]byte(parentID+"\x00"), raw...))
+	return hex.EncodeToString(sum[:16])
+}
+
+func readJSON(path string, v interface{}) bool {
+	raw, err := os.ReadFile(path)
+	return err == nil && json.Unmarshal(raw, v) == nil
+}
+
+// writeFileAtomic writes through a temporary file, so concurrent runs never
+// read a partial entry. Errors are ignored: the cache is only an optimization.
+func writeFileAtomic(path string, data []byte) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
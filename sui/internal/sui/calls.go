@@ -0,0 +1,117 @@
+package sui
+
+// Typed constructors of the cartridge_storage Move calls. Each takes the
+// package ID first; object IDs and addresses are passed as 0x-hex strings
+// and typed here (see SharedObject) so the calls can be built natively.
+
+// EntryArgs are the fields of a catalog entry set by add_entry and
+// update_entry
+type EntryArgs struct {
+	// Key is the entry key (slug, or slug@channel)
+	Key          string
+	CartridgeID  string
+	Title        string
+	Platform     uint8
+	SizeBytes    uint64
+	EmulatorCore string
+	Version      uint16
+	// CoverBlobID is the cover image's blob ID as 0x-hex, empty for none
+	CoverBlobID string
+}
+
+func (e EntryArgs) args() []interface{} {
+	return []interface{}{MoveString(e.Key), Address(e.CartridgeID), MoveString(e.Title), e.Platform, e.SizeBytes, MoveString(e.EmulatorCore), e.Version, Bytes(e.CoverBlobID)}
+}
+
+func catalogCall(packageID, function string, args ...interface{}) MoveCall {
+	return MoveCall{Package: packageID, Module: "catalog", Function: function, Args: args}
+}
+
+// authorized picks the owner function, or its _with_cap variant that takes
+// a curator's CuratorCap after the catalog
+func authorized(packageID, function, catalogID, capID string, args ...interface{}) MoveCall {
+	if capID == "" {
+		return catalogCall(packageID, function, append([]interface{}{SharedObject(catalogID)}, args...)...)
+	}
+	return catalogCall(packageID, function+"_with_cap", append([]interface{}{SharedObject(catalogID), OwnedObject(capID)}, args...)...)
+}
+
+// CreateCatalog creates a catalog owned by the sender
+func CreateCatalog(packageID, name, description string) MoveCall {
+	return catalogCall(packageID, "create_catalog", MoveString(name), MoveString(description))
+}
+
+// AddEntry adds an entry as the owner, or as a curator when capID is set
+func AddEntry(packageID, catalogID, capID string, entry EntryArgs) MoveCall {
+	return authorized(packageID, "add_entry", catalogID, capID, entry.args()...)
+}
+
+// UpdateEntry points an existing entry at a new cartridge (owner only)
+func UpdateEntry(packageID, catalogID string, entry EntryArgs) MoveCall {
+	return catalogCall(packageID, "update_entry", append([]interface{}{SharedObject(catalogID)}, entry.args()...)...)
+}
+
+// RemoveEntry removes an entry as the owner, or as a curator when capID is set
+func RemoveEntry(packageID, catalogID, capID, key string) MoveCall {
+	return authorized(packageID, "remove_entry", catalogID, capID, MoveString(key))
+}
+
+// SetEntryTags replaces an entry's tags as the owner, or as a curator when
+// capID is set
+func SetEntryTags(packageID, catalogID, capID, key string, tags []string) MoveCall {
+	if tags == nil {
+		tags = []string{}
+	}
+	return authorized(packageID, "set_entry_tags", catalogID, capID, MoveString(key), tags)
+}
+
+// MintCuratorCap mints a CuratorCap for the catalog to recipient
+func MintCuratorCap(packageID, catalogID, recipient string) MoveCall {
+	return catalogCall(packageID, "mint_curator_cap", SharedObject(catalogID), Address(recipient))
+}
+
+// RevokeCuratorCap stops a CuratorCap from working on the catalog
+func RevokeCuratorCap(packageID, catalogID, capID string) MoveCall {
+	return catalogCall(packageID, "revoke_curator_cap", SharedObject(catalogID), Address(capID))
+}
+
+// FixCount sets the catalog's entry count; it aborts unless the stored
+// count is still expected
+func FixCount(packageID, catalogID string, expected, count uint64) MoveCall {
+	return catalogCall(packageID, "fix_count", SharedObject(catalogID), expected, count)
+}
+
+// CreateRegistry creates a shared catalog registry administered by the sender
+func CreateRegistry(packageID string) MoveCall {
+	return MoveCall{Package: packageID, Module: "registry", Function: "create_registry"}
+}
+
+// RegisterCatalog lists a catalog in a registry
+func RegisterCatalog(packageID, registryID, catalogID, name, description string, platform uint8) MoveCall {
+	return MoveCall{Package: packageID, Module: "registry", Function: "register_catalog",
+		Args: []interface{}{SharedObject(registryID), Address(catalogID), MoveString(name), MoveString(description), platform}}
+}
+
+// UnregisterCatalog removes a catalog from a registry
+func UnregisterCatalog(packageID, registryID, catalogID string) MoveCall {
+	return MoveCall{Package: packageID, Module: "registry", Function: "unregister_catalog",
+		Args: []interface{}{SharedObject(registryID), Address(catalogID)}}
+}
+
+// CreateCollection creates a collection owned by the sender
+func CreateCollection(packageID, name, description string) MoveCall {
+	return MoveCall{Package: packageID, Module: "collection", Function: "create_collection",
+		Args: []interface{}{MoveString(name), MoveString(description)}}
+}
+
+// AddToCollection appends a cartridge to a collection
+func AddToCollection(packageID, collectionID, cartridgeID string) MoveCall {
+	return MoveCall{Package: packageID, Module: "collection", Function: "add_cartridge",
+		Args: []interface{}{OwnedObject(collectionID), Address(cartridgeID)}}
+}
+
+// RemoveFromCollection removes a cartridge from a collection
+func RemoveFromCollection(packageID, collectionID, cartridgeID string) MoveCall {
+	return MoveCall{Package: packageID, Module: "collection", Function: "remove_cartridge",
+		Args: []interface{}{OwnedObject(collectionID), Address(cartridgeID)}}
+}
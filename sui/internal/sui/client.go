@@ -7,7 +7,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/retro-crypto/sui/internal/httpclient"
 )
 
 // Client is a Sui blockchain JSON-RPC client
@@ -78,14 +82,48 @@ type DynamicFieldName struct {
 	Value interface{} `json:"value"`
 }
 
+// KeyType returns a short name for the key type of a dynamic field
+// (string, u64, address, ID, ...). Unknown types are returned as-is.
+func (n DynamicFieldName) KeyType() string {
+	switch {
+	case strings.HasSuffix(n.Type, "::string::String"), strings.HasSuffix(n.Type, "::ascii::String"):
+		return "string"
+	case strings.HasSuffix(n.Type, "::object::ID"):
+		return "ID"
+	default:
+		return n.Type
+	}
+}
+
+// KeyString renders the dynamic field key value as a printable string.
+// Strings, addresses and IDs are returned unchanged; u64 and larger integers
+// are serialized as strings by the RPC; smaller integers arrive as numbers;
+// composite (struct) keys are rendered as compact JSON.
+func (n DynamicFieldName) KeyString() string {
+	switch v := n.Value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	}
+}
+
 // NewClient creates a new Sui client
 func NewClient(rpcURL string) *Client {
 	return &Client{
-		rpcURL: rpcURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		requestID: 1,
+		rpcURL:     rpcURL,
+		httpClient: httpclient.New(30 * time.Second),
+		requestID:  1,
 	}
 }
 
@@ -134,8 +172,106 @@ func (c *Client) call(method string, params []interface{}) (json.RawMessage, err
 	return rpcResp.Result, nil
 }
 
-// GetObject fetches an object by ID
+// GetLatestCheckpoint returns the sequence number of the latest checkpoint
+func (c *Client) GetLatestCheckpoint() (string, error) {
+	result, err := c.call("sui_getLatestCheckpointSequenceNumber", []interface{}{})
+	if err != nil {
+		return "", err
+	}
+	var seq string
+	if err := json.Unmarshal(result, &seq); err != nil {
+		return "", fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	return seq, nil
+}
+
+// ExecuteTransactionBlock submits signed transaction bytes (base64) and
+// returns the raw response with effects and object changes. A transaction
+// that executed but aborted is returned as an error.
+func (c *Client) ExecuteTransactionBlock(txBytes string, signatures []string) (json.RawMessage, error) {
+	options := map[string]bool{
+		"showEffects":       true,
+		"showObjectChanges": true,
+		"showEvents":        true,
+	}
+	result, err := c.call("sui_executeTransactionBlock", []interface{}{txBytes, signatures, options, "WaitForLocalExecution"})
+	// The transaction may have executed even if the call failed
+	InvalidateTransaction(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Digest  string `json:"digest"`
+		Effects struct {
+			Status struct {
+				Status string `json:"status"`
+				Error  string `json:"error"`
+			} `json:"status"`
+		} `json:"effects"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction response: %w", err)
+	}
+	if resp.Effects.Status.Status != "" && resp.Effects.Status.Status != "success" {
+		return result, fmt.Errorf("transaction %s failed: %s", resp.Digest, resp.Effects.Status.Error)
+	}
+	return result, nil
+}
+
+// MoveCall builds an unsigned Move call transaction with unsafe_moveCall and
+// returns its bytes (base64). Arguments are Sui JSON values: object IDs and
+// strings as strings, numbers, and arrays for vectors. The node picks a gas
+// coin of the signer.
+func (c *Client) MoveCall(signer, packageID, module, function string, typeArgs []string, args []interface{}, gasBudget uint64) (string, error) {
+	if typeArgs == nil {
+		typeArgs = []string{}
+	}
+	if args == nil {
+		args = []interface{}{}
+	}
+	result, err := c.call("unsafe_moveCall", []interface{}{signer, packageID, module, function, typeArgs, args, nil, fmt.Sprintf("%d", gasBudget)})
+	if err != nil {
+		return "", err
+	}
+	return parseTxBytes(result)
+}
+
+// TransferObject builds an unsigned transaction that transfers an object
+// with unsafe_transferObject and returns its bytes (base64)
+func (c *Client) TransferObject(signer, objectID, recipient string, gasBudget uint64) (string, error) {
+	result, err := c.call("unsafe_transferObject", []interface{}{signer, objectID, nil, fmt.Sprintf("%d", gasBudget), recipient})
+	if err != nil {
+		return "", err
+	}
+	return parseTxBytes(result)
+}
+
+func parseTxBytes(result json.RawMessage) (string, error) {
+	var tx struct {
+		TxBytes string `json:"txBytes"`
+	}
+	if err := json.Unmarshal(result, &tx); err != nil {
+		return "", fmt.Errorf("failed to parse transaction bytes: %w", err)
+	}
+	if tx.TxBytes == "" {
+		return "", fmt.Errorf("node returned no transaction bytes")
+	}
+	return tx.TxBytes, nil
+}
+
+// GetObject fetches an object by ID, through the object cache if one is set
 func (c *Client) GetObject(objectID string) (*ObjectResponse, error) {
+	cache := currentObjectCache()
+	if cache != nil {
+		if raw, ok := cache.get(kindObject, objectID); ok {
+			var resp ObjectResponse
+			if err := json.Unmarshal(raw, &resp); err == nil {
+				return &resp, nil
+			}
+		}
+	}
+
 	options := map[string]bool{
 		"showContent": true,
 		"showOwner":   true,
@@ -151,10 +287,68 @@ func (c *Client) GetObject(objectID string) (*ObjectResponse, error) {
 	if err := json.Unmarshal(result, &resp); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal object: %w", err)
 	}
+	if cache != nil && resp.Data != nil {
+		cache.put(kindObject, resp.Data.ObjectID, resp.Data.Version, result)
+	}
 
 	return &resp, nil
 }
 
+// MaxMultiGetObjects is the most object IDs sui_multiGetObjects accepts
+const MaxMultiGetObjects = 50
+
+// MultiGetObjects fetches objects by ID with sui_multiGetObjects, in batches
+// of MaxMultiGetObjects, through the object cache if one is set. Responses
+// are in the order of ids; objects that don't exist have no Data.
+func (c *Client) MultiGetObjects(ids []string) ([]ObjectResponse, error) {
+	responses := make([]ObjectResponse, len(ids))
+	cache := currentObjectCache()
+
+	var missing []int
+	for i, id := range ids {
+		if cache != nil {
+			if raw, ok := cache.get(kindObject, id); ok && json.Unmarshal(raw, &responses[i]) == nil {
+				continue
+			}
+		}
+		missing = append(missing, i)
+	}
+
+	options := map[string]bool{
+		"showContent": true,
+		"showOwner":   true,
+		"showType":    true,
+	}
+	for start := 0; start < len(missing); start += MaxMultiGetObjects {
+		batch := missing[start:min(start+MaxMultiGetObjects, len(missing))]
+		batchIDs := make([]string, len(batch))
+		for j, i := range batch {
+			batchIDs[j] = ids[i]
+		}
+
+		result, err := c.call("sui_multiGetObjects", []interface{}{batchIDs, options})
+		if err != nil {
+			return nil, err
+		}
+		var raws []json.RawMessage
+		if err := json.Unmarshal(result, &raws); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal objects: %w", err)
+		}
+		if len(raws) != len(batch) {
+			return nil, fmt.Errorf("sui_multiGetObjects returned %d objects for %d IDs", len(raws), len(batch))
+		}
+		for j, i := range batch {
+			if err := json.Unmarshal(raws[j], &responses[i]); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal object %s: %w", ids[i], err)
+			}
+			if data := responses[i].Data; cache != nil && data != nil {
+				cache.put(kindObject, data.ObjectID, data.Version, raws[j])
+			}
+		}
+	}
+	return responses, nil
+}
+
 // GetDynamicFields fetches dynamic fields of an object
 func (c *Client) GetDynamicFields(objectID string, cursor *string, limit int) (*DynamicFieldsResponse, error) {
 	params := []interface{}{objectID, cursor, limit}
@@ -172,8 +366,74 @@ func (c *Client) GetDynamicFields(objectID string, cursor *string, limit int) (*
 	return &resp, nil
 }
 
-// GetDynamicFieldObject fetches a specific dynamic field
+// MaxDynamicFieldPages caps how many pages GetAllDynamicFields will request
+// before giving up, so a misbehaving RPC can never make us loop forever
+const MaxDynamicFieldPages = 1000
+
+// GetAllDynamicFields pages through all dynamic fields of an object.
+// Fields are deduplicated by object ID (some RPCs return overlapping pages),
+// and an error is returned if the RPC repeats a cursor or the page cap is hit.
+func (c *Client) GetAllDynamicFields(objectID string, pageSize int) ([]DynamicFieldInfo, error) {
+	var (
+		fields      []DynamicFieldInfo
+		cursor      *string
+		seenObjects = make(map[string]bool)
+		seenCursors = make(map[string]bool)
+	)
+
+	for page := 0; ; page++ {
+		if page >= MaxDynamicFieldPages {
+			return nil, fmt.Errorf("dynamic field pagination exceeded %d pages for %s (RPC may be returning inconsistent cursors)", MaxDynamicFieldPages, objectID)
+		}
+
+		resp, err := c.GetDynamicFields(objectID, cursor, pageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range resp.Data {
+			if field.ObjectID != "" {
+				if seenObjects[field.ObjectID] {
+					continue
+				}
+				seenObjects[field.ObjectID] = true
+			}
+			fields = append(fields, field)
+		}
+
+		if !resp.HasNextPage || resp.NextCursor == nil {
+			break
+		}
+
+		next := *resp.NextCursor
+		if seenCursors[next] || (cursor != nil && *cursor == next) {
+			return nil, fmt.Errorf("dynamic field pagination cycle detected for %s: cursor %s was already visited", objectID, next)
+		}
+		seenCursors[next] = true
+		cursor = &next
+	}
+
+	if cache := currentObjectCache(); cache != nil {
+		cache.listed(objectID, fields)
+	}
+	return fields, nil
+}
+
+// GetDynamicFieldObject fetches a specific dynamic field, through the
+// object cache if one is set
 func (c *Client) GetDynamicFieldObject(parentID string, name DynamicFieldName) (*ObjectResponse, error) {
+	cache := currentObjectCache()
+	if cache != nil {
+		if id, ok := cache.fieldID(parentID, name); ok {
+			if raw, ok := cache.get(kindField, id); ok {
+				var resp ObjectResponse
+				if err := json.Unmarshal(raw, &resp); err == nil {
+					return &resp, nil
+				}
+			}
+		}
+	}
+
 	result, err := c.call("suix_getDynamicFieldObject", []interface{}{parentID, name})
 	if err != nil {
 		return nil, err
@@ -183,10 +443,107 @@ func (c *Client) GetDynamicFieldObject(parentID string, name DynamicFieldName) (
 	if err := json.Unmarshal(result, &resp); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal dynamic field object: %w", err)
 	}
+	if cache != nil && resp.Data != nil {
+		cache.addField(parentID, name, resp.Data.ObjectID)
+		cache.put(kindField, resp.Data.ObjectID, resp.Data.Version, result)
+	}
 
 	return &resp, nil
 }
 
+// OwnedObjectsResponse represents suix_getOwnedObjects response
+type OwnedObjectsResponse struct {
+	Data        []ObjectResponse `json:"data"`
+	NextCursor  *string          `json:"nextCursor"`
+	HasNextPage bool             `json:"hasNextPage"`
+}
+
+// GetOwnedObjects fetches all objects of the given struct type owned by an
+// address (every owned object if structType is empty)
+func (c *Client) GetOwnedObjects(owner, structType string) ([]ObjectData, error) {
+	query := map[string]interface{}{
+		"options": map[string]bool{
+			"showContent": true,
+			"showType":    true,
+			"showOwner":   true,
+		},
+	}
+	if structType != "" {
+		query["filter"] = map[string]string{"StructType": structType}
+	}
+
+	var objects []ObjectData
+	var cursor *string
+	for page := 0; page < MaxDynamicFieldPages; page++ {
+		result, err := c.call("suix_getOwnedObjects", []interface{}{owner, query, cursor, 50})
+		if err != nil {
+			return nil, err
+		}
+
+		var resp OwnedObjectsResponse
+		if err := json.Unmarshal(result, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal owned objects: %w", err)
+		}
+		for _, obj := range resp.Data {
+			if obj.Data != nil {
+				objects = append(objects, *obj.Data)
+			}
+		}
+
+		if !resp.HasNextPage || resp.NextCursor == nil {
+			return objects, nil
+		}
+		cursor = resp.NextCursor
+	}
+
+	return nil, fmt.Errorf("owned objects of %s exceed %d pages", owner, MaxDynamicFieldPages)
+}
+
+// Event is a Move event returned by suix_queryEvents
+type Event struct {
+	ID struct {
+		TxDigest string `json:"txDigest"`
+		EventSeq string `json:"eventSeq"`
+	} `json:"id"`
+	Type        string                 `json:"type"`
+	ParsedJSON  map[string]interface{} `json:"parsedJson"`
+	TimestampMs string                 `json:"timestampMs"`
+}
+
+// EventsResponse represents suix_queryEvents response
+type EventsResponse struct {
+	Data        []Event     `json:"data"`
+	NextCursor  interface{} `json:"nextCursor"`
+	HasNextPage bool        `json:"hasNextPage"`
+}
+
+// QueryEvents fetches events of a Move event type, newest first
+func (c *Client) QueryEvents(eventType string) ([]Event, error) {
+	query := map[string]string{"MoveEventType": eventType}
+
+	var events []Event
+	var cursor interface{}
+	for page := 0; page < MaxDynamicFieldPages; page++ {
+		result, err := c.call("suix_queryEvents", []interface{}{query, cursor, 50, true})
+		if err != nil {
+			return nil, err
+		}
+
+		var resp EventsResponse
+		if err := json.Unmarshal(result, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal events: %w", err)
+		}
+		events = append(events, resp.Data...)
+
+		if !resp.HasNextPage || resp.NextCursor == nil {
+			return events, nil
+		}
+		cursor = resp.NextCursor
+	}
+
+	return nil, fmt.Errorf("events of %s exceed %d pages", eventType, MaxDynamicFieldPages)
+}
+
 // ParseCatalog extracts catalog data from object content
 func ParseCatalog(data *ObjectData) map[string]interface{} {
 	if data == nil || data.Content == nil {
@@ -0,0 +1,115 @@
+package sui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dynamicFieldPages serves suix_getDynamicFields from pages keyed by the
+// request cursor ("" for the first page) and records the cursors requested
+func dynamicFieldPages(t *testing.T, pages map[string]DynamicFieldsResponse, cursors *[]string) *Client {
+	return newRPCServer(t, func(method string, params []json.RawMessage) (interface{}, *RPCError) {
+		if method != "suix_getDynamicFields" {
+			return nil, &RPCError{Code: -32601, Message: "unexpected method " + method}
+		}
+		var cursor *string
+		json.Unmarshal(params[1], &cursor)
+		key := ""
+		if cursor != nil {
+			key = *cursor
+		}
+		*cursors = append(*cursors, key)
+		page, ok := pages[key]
+		if !ok {
+			return nil, &RPCError{Code: -32602, Message: "unknown cursor " + key}
+		}
+		return page, nil
+	})
+}
+
+func fieldPage(next string, ids ...string) DynamicFieldsResponse {
+	page := DynamicFieldsResponse{Data: []DynamicFieldInfo{}}
+	for _, id := range ids {
+		page.Data = append(page.Data, DynamicFieldInfo{ObjectID: id, Name: DynamicFieldName{Type: "0x1::string::String", Value: id}})
+	}
+	if next != "" {
+		page.NextCursor = &next
+		page.HasNextPage = true
+	}
+	return page
+}
+
+func TestGetAllDynamicFieldsPages(t *testing.T) {
+	var cursors []string
+	client := dynamicFieldPages(t, map[string]DynamicFieldsResponse{
+		"":   fieldPage("c1", "0x1", "0x2"),
+		"c1": fieldPage("c2", "0x2", "0x3"),
+		"c2": fieldPage("", "0x4"),
+	}, &cursors)
+
+	fields, err := client.GetAllDynamicFields("0xcatalog", 2)
+	if err != nil {
+		t.Fatalf("GetAllDynamicFields: %v", err)
+	}
+	var ids []string
+	for _, f := range fields {
+		ids = append(ids, f.ObjectID)
+	}
+	if got := strings.Join(ids, ","); got != "0x1,0x2,0x3,0x4" {
+		t.Errorf("fields = %s, want 0x1,0x2,0x3,0x4 (overlap deduplicated)", got)
+	}
+	if got := strings.Join(cursors, ","); got != ",c1,c2" {
+		t.Errorf("cursors requested = %q, want \",c1,c2\"", got)
+	}
+}
+
+func TestGetAllDynamicFieldsStopsWithoutNextPage(t *testing.T) {
+	// A cursor without hasNextPage ends the listing
+	last := fieldPage("", "0x2")
+	next := "c2"
+	last.NextCursor = &next
+
+	var cursors []string
+	client := dynamicFieldPages(t, map[string]DynamicFieldsResponse{
+		"":   fieldPage("c1", "0x1"),
+		"c1": last,
+	}, &cursors)
+
+	fields, err := client.GetAllDynamicFields("0xcatalog", 1)
+	if err != nil {
+		t.Fatalf("GetAllDynamicFields: %v", err)
+	}
+	if len(fields) != 2 || len(cursors) != 2 {
+		t.Fatalf("got %d fields in %d requests, want 2 in 2", len(fields), len(cursors))
+	}
+}
+
+func TestGetAllDynamicFieldsCursorCycle(t *testing.T) {
+	var cursors []string
+	client := dynamicFieldPages(t, map[string]DynamicFieldsResponse{
+		"":   fieldPage("c1", "0x1"),
+		"c1": fieldPage("c1", "0x2"),
+	}, &cursors)
+
+	_, err := client.GetAllDynamicFields("0xcatalog", 1)
+	if err == nil || !strings.Contains(err.Error(), "cycle detected") {
+		t.Fatalf("error = %v, want a cursor cycle error", err)
+	}
+}
+
+func TestGetAllDynamicFieldsRPCError(t *testing.T) {
+	var cursors []string
+	client := dynamicFieldPages(t, map[string]DynamicFieldsResponse{
+		"": fieldPage("missing", "0x1"),
+	}, &cursors)
+
+	fields, err := client.GetAllDynamicFields("0xcatalog", 1)
+	if err == nil || fields != nil {
+		t.Fatalf("GetAllDynamicFields = %v, %v; want an error", fields, err)
+	}
+	if want := fmt.Sprintf("RPC error %d: unknown cursor missing", -32602); err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+}
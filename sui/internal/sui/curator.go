@@ -0,0 +1,74 @@
+package sui
+
+import "fmt"
+
+// CuratorCapType returns the fully qualified CuratorCap struct type of the
+// package that first defined the catalog types
+func CuratorCapType(typePackageID string) string {
+	return typePackageID + "::catalog::CuratorCap"
+}
+
+// CuratorCapCatalogID returns the catalog a CuratorCap object applies to
+func CuratorCapCatalogID(obj *ObjectData) string {
+	catalogID, _ := ParseCatalog(obj)["catalog_id"].(string)
+	return catalogID
+}
+
+// CatalogOwner returns the owner of a catalog. It fails if catalogID isn't
+// a shared object: anything else (e.g. a cartridge ID) would only fail later
+// with an obscure transaction error.
+func (c *Client) CatalogOwner(catalogID string) (string, error) {
+	resp, err := c.GetObject(catalogID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get catalog: %w", err)
+	}
+	if resp.Data == nil {
+		return "", fmt.Errorf("catalog not found")
+	}
+	if _, err := resp.Data.InitialSharedVersion(); err != nil {
+		return "", fmt.Errorf("%s is not a catalog: %w", catalogID, err)
+	}
+	owner, _ := ParseCatalog(resp.Data)["owner"].(string)
+	return owner, nil
+}
+
+// ActiveCuratorCaps returns the IDs of the catalog's non-revoked curator caps
+func (c *Client) ActiveCuratorCaps(typePackageID, catalogID string) (map[string]bool, error) {
+	fieldObj, err := c.GetDynamicFieldObject(catalogID, DynamicFieldName{
+		Type:  typePackageID + "::catalog::CuratorsKey",
+		Value: map[string]interface{}{"dummy_field": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	active := map[string]bool{}
+	value := ParseCatalogEntry(fieldObj.Data)
+	contents, _ := value["contents"].([]interface{})
+	for _, id := range contents {
+		if s, ok := id.(string); ok {
+			active[s] = true
+		}
+	}
+	return active, nil
+}
+
+// CuratorCap returns an active CuratorCap that holder owns for the catalog,
+// or "" and the number of its caps for the catalog that were revoked
+func (c *Client) CuratorCap(typePackageID, catalogID, holder string) (capID string, revoked int, err error) {
+	caps, err := c.GetOwnedObjects(holder, CuratorCapType(typePackageID))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to look up curator caps: %w", err)
+	}
+	active, _ := c.ActiveCuratorCaps(typePackageID, catalogID)
+	for _, obj := range caps {
+		if CuratorCapCatalogID(&obj) != catalogID {
+			continue
+		}
+		if active[obj.ObjectID] {
+			return obj.ObjectID, 0, nil
+		}
+		revoked++
+	}
+	return "", revoked, nil
+}
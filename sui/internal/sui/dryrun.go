@@ -0,0 +1,131 @@
+package sui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// DryRun summarizes what a transaction would do
+type DryRun struct {
+	// Status is "success" or "failure"
+	Status string
+	// Error is the abort reason of a failed transaction
+	Error string
+	// Commands lists the transaction's commands; Move calls as
+	// package::module::function
+	Commands []string
+	// BalanceChanges are the coin balances the transaction would change
+	BalanceChanges []BalanceChange
+	// GasUsed is what the transaction would cost in gas
+	GasUsed GasCost
+}
+
+// GasCost is the gas summary of a transaction's effects, in MIST
+type GasCost struct {
+	ComputationCost         int64
+	StorageCost             int64
+	StorageRebate           int64
+	NonRefundableStorageFee int64
+}
+
+// Net returns the gas actually charged: computation and storage minus the
+// storage rebate of deleted or rewritten objects
+func (g GasCost) Net() int64 {
+	return g.ComputationCost + g.StorageCost - g.StorageRebate
+}
+
+// BalanceChange is a change of one owner's balance of one coin type
+type BalanceChange struct {
+	Owner    string
+	CoinType string
+	Amount   string
+}
+
+// DryRunTransactionBlock simulates transaction bytes (base64) without
+// signing or executing them
+func (c *Client) DryRunTransactionBlock(txBytes string) (*DryRun, error) {
+	result, err := c.call("sui_dryRunTransactionBlock", []interface{}{txBytes})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Effects struct {
+			Status struct {
+				Status string `json:"status"`
+				Error  string `json:"error"`
+			} `json:"status"`
+			GasUsed struct {
+				ComputationCost         string `json:"computationCost"`
+				StorageCost             string `json:"storageCost"`
+				StorageRebate           string `json:"storageRebate"`
+				NonRefundableStorageFee string `json:"nonRefundableStorageFee"`
+			} `json:"gasUsed"`
+		} `json:"effects"`
+		BalanceChanges []struct {
+			Owner    json.RawMessage `json:"owner"`
+			CoinType string          `json:"coinType"`
+			Amount   string          `json:"amount"`
+		} `json:"balanceChanges"`
+		Input struct {
+			Transaction struct {
+				Transactions []map[string]json.RawMessage `json:"transactions"`
+			} `json:"transaction"`
+		} `json:"input"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse dry run: %w", err)
+	}
+
+	dry := &DryRun{Status: resp.Effects.Status.Status, Error: resp.Effects.Status.Error}
+	gas := resp.Effects.GasUsed
+	for _, field := range []struct {
+		value string
+		dst   *int64
+	}{
+		{gas.ComputationCost, &dry.GasUsed.ComputationCost},
+		{gas.StorageCost, &dry.GasUsed.StorageCost},
+		{gas.StorageRebate, &dry.GasUsed.StorageRebate},
+		{gas.NonRefundableStorageFee, &dry.GasUsed.NonRefundableStorageFee},
+	} {
+		if field.value != "" {
+			*field.dst, _ = strconv.ParseInt(field.value, 10, 64)
+		}
+	}
+	for _, command := range resp.Input.Transaction.Transactions {
+		for kind, body := range command {
+			if kind != "MoveCall" {
+				dry.Commands = append(dry.Commands, kind)
+				continue
+			}
+			var call struct {
+				Package  string `json:"package"`
+				Module   string `json:"module"`
+				Function string `json:"function"`
+			}
+			json.Unmarshal(body, &call)
+			dry.Commands = append(dry.Commands, fmt.Sprintf("%s::%s::%s", call.Package, call.Module, call.Function))
+		}
+	}
+	for _, bc := range resp.BalanceChanges {
+		dry.BalanceChanges = append(dry.BalanceChanges, BalanceChange{Owner: ownerString(bc.Owner), CoinType: bc.CoinType, Amount: bc.Amount})
+	}
+	return dry, nil
+}
+
+// ownerString flattens an owner ({"AddressOwner": "0x..."}, "Immutable", ...)
+func ownerString(raw json.RawMessage) string {
+	var owner map[string]json.RawMessage
+	if json.Unmarshal(raw, &owner) == nil {
+		for kind, value := range owner {
+			var addr string
+			if json.Unmarshal(value, &addr) == nil {
+				return addr
+			}
+			return kind
+		}
+	}
+	var s string
+	json.Unmarshal(raw, &s)
+	return s
+}
@@ -0,0 +1,70 @@
+package sui
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// TransactionDigest returns the digest of a transaction from its JSON
+// response (or sui CLI output), or "unknown"
+func TransactionDigest(jsonOutput string) string {
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonOutput), &result); err == nil {
+		if digest, ok := result["digest"].(string); ok {
+			return digest
+		}
+	}
+	return "unknown"
+}
+
+// TransactionGasCost returns the net gas cost (MIST) of a transaction from its
+// JSON output: computation + storage - storage rebate
+func TransactionGasCost(jsonOutput string) int64 {
+	var result struct {
+		Effects struct {
+			GasUsed struct {
+				ComputationCost string `json:"computationCost"`
+				StorageCost     string `json:"storageCost"`
+				StorageRebate   string `json:"storageRebate"`
+			} `json:"gasUsed"`
+		} `json:"effects"`
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &result); err != nil {
+		return 0
+	}
+	gas := result.Effects.GasUsed
+	parse := func(s string) int64 {
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return n
+	}
+	return parse(gas.ComputationCost) + parse(gas.StorageCost) - parse(gas.StorageRebate)
+}
+
+// CreatedObjectID returns the ID of the object created by a transaction
+// whose type contains typeName, or ""
+func CreatedObjectID(jsonOutput string, typeName string) string {
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonOutput), &result); err != nil {
+		return ""
+	}
+
+	// Look in objectChanges array
+	if objectChanges, ok := result["objectChanges"].([]interface{}); ok {
+		for _, change := range objectChanges {
+			if changeMap, ok := change.(map[string]interface{}); ok {
+				if changeType, ok := changeMap["type"].(string); ok && changeType == "created" {
+					if objectType, ok := changeMap["objectType"].(string); ok {
+						if strings.Contains(objectType, typeName) {
+							if objectId, ok := changeMap["objectId"].(string); ok {
+								return objectId
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return ""
+}
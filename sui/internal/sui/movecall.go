@@ -0,0 +1,176 @@
+package sui
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultGasBudget is the gas budget (MIST) of catalogctl's Move calls
+const DefaultGasBudget = 10000000
+
+// MoveCall is one call of a Move entry function. The constructors in
+// calls.go build it with typed arguments; it is then sent natively
+// (Client.BuildMoveCall) or rendered for the sui CLI (CLIArgs), so both
+// paths send the same call.
+type MoveCall struct {
+	Package  string
+	Module   string
+	Function string
+	TypeArgs []string
+	// Args are the typed values below (SharedObject, OwnedObject, Address,
+	// MoveString, Bytes, uint8, uint16, uint64, []string for
+	// vector<String>), or untyped Sui JSON values (see CLIArgValues)
+	Args      []interface{}
+	GasBudget uint64
+}
+
+// Typed arguments. Calls whose arguments are all typed are built natively
+// as programmable transactions; the others go through unsafe_moveCall.
+type (
+	// SharedObject is a mutable shared object, referenced by its initial
+	// shared version
+	SharedObject string
+	// OwnedObject is an object owned by the sender, referenced by its
+	// current version
+	OwnedObject string
+	// Address is an address or an ID
+	Address string
+	// MoveString is a std::string::String
+	MoveString string
+	// Bytes is a vector<u8> given as 0x-hex; empty is an empty vector
+	Bytes string
+)
+
+func (b Bytes) decode() ([]byte, error) {
+	if b == "" {
+		return nil, nil
+	}
+	digits, ok := strings.CutPrefix(string(b), "0x")
+	data, err := hex.DecodeString(digits)
+	if !ok || err != nil {
+		return nil, fmt.Errorf("invalid byte vector %q", b)
+	}
+	return data, nil
+}
+
+// jsonValue returns the Sui JSON value of an argument
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case SharedObject:
+		return string(v)
+	case OwnedObject:
+		return string(v)
+	case Address:
+		return string(v)
+	case MoveString:
+		return string(v)
+	case Bytes:
+		if v == "" {
+			return []interface{}{}
+		}
+		return string(v)
+	}
+	return v
+}
+
+// typed reports whether the call can be built natively
+func (m MoveCall) typed() bool {
+	if len(m.TypeArgs) > 0 {
+		return false
+	}
+	for _, v := range m.Args {
+		switch v.(type) {
+		case SharedObject, OwnedObject, Address, MoveString, Bytes, uint8, uint16, uint64, []string:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Target returns package::module::function
+func (m MoveCall) Target() string {
+	return fmt.Sprintf("%s::%s::%s", m.Package, m.Module, m.Function)
+}
+
+// CLIArgs renders the call as 'sui client call' arguments with --json
+func (m MoveCall) CLIArgs() []string {
+	args := []string{
+		"client", "call",
+		"--package", m.Package,
+		"--module", m.Module,
+		"--function", m.Function,
+	}
+	if len(m.TypeArgs) > 0 {
+		args = append(args, "--type-args")
+		args = append(args, m.TypeArgs...)
+	}
+	if len(m.Args) > 0 {
+		args = append(args, "--args")
+		for _, v := range m.Args {
+			args = append(args, cliValue(v))
+		}
+	}
+	return append(args, "--gas-budget", strconv.FormatUint(m.gasBudget(), 10), "--json")
+}
+
+func (m MoveCall) gasBudget() uint64 {
+	if m.GasBudget == 0 {
+		return DefaultGasBudget
+	}
+	return m.GasBudget
+}
+
+// cliValue writes a Sui JSON value the way the sui CLI parses it: strings
+// as they are, everything else as JSON
+func cliValue(v interface{}) string {
+	v = jsonValue(v)
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// BuildMoveCall builds the unsigned transaction of a Move call (see
+// MoveCall) and returns its bytes (base64). Typed calls are built here, so
+// shared objects are referenced by their initial shared version; untyped
+// ones, such as plan operations, by unsafe_moveCall.
+func (c *Client) BuildMoveCall(signer string, call MoveCall) (string, error) {
+	if call.typed() {
+		return c.buildProgrammable(signer, call)
+	}
+	args := make([]interface{}, len(call.Args))
+	for i, v := range call.Args {
+		args[i] = jsonValue(v)
+	}
+	return c.MoveCall(signer, call.Package, call.Module, call.Function, call.TypeArgs, args, call.gasBudget())
+}
+
+// CLIArgValues converts 'sui client call' argument strings, as stored in
+// plans, to Sui JSON values the way the sui CLI does: valid JSON (numbers,
+// arrays, booleans) is used as is, anything else is a string
+func CLIArgValues(args []string) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = cliArgValue(arg)
+	}
+	return values
+}
+
+func cliArgValue(arg string) interface{} {
+	// Numbers are kept as written so u64 values don't lose precision
+	dec := json.NewDecoder(strings.NewReader(arg))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err == nil && !dec.More() {
+		return v
+	}
+	return arg
+}
@@ -0,0 +1,167 @@
+package sui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/retro-crypto/sui/internal/base58"
+)
+
+var testEntry = EntryArgs{
+	Key:          "doom@beta",
+	CartridgeID:  "0xcart",
+	Title:        "1993",
+	Platform:     1,
+	SizeBytes:    2 << 40,
+	EmulatorCore: "dosbox",
+	Version:      3,
+}
+
+func TestMoveCallCLIArgs(t *testing.T) {
+	tests := []struct {
+		call MoveCall
+		want string
+	}{
+		{
+			AddEntry("0xpkg", "0xcat", "", testEntry),
+			"client call --package 0xpkg --module catalog --function add_entry --args 0xcat doom@beta 0xcart 1993 1 2199023255552 dosbox 3 [] --gas-budget 10000000 --json",
+		},
+		{
+			RemoveEntry("0xpkg", "0xcat", "0xcap", "doom"),
+			"client call --package 0xpkg --module catalog --function remove_entry_with_cap --args 0xcat 0xcap doom --gas-budget 10000000 --json",
+		},
+		{
+			SetEntryTags("0xpkg", "0xcat", "", "doom", nil),
+			"client call --package 0xpkg --module catalog --function set_entry_tags --args 0xcat doom [] --gas-budget 10000000 --json",
+		},
+		{
+			CreateRegistry("0xpkg"),
+			"client call --package 0xpkg --module registry --function create_registry --gas-budget 10000000 --json",
+		},
+		{
+			MoveCall{Package: "0xpkg", Module: "m", Function: "f", TypeArgs: []string{"0x2::sui::SUI"}, Args: CLIArgValues([]string{"7", "x"}), GasBudget: 5},
+			"client call --package 0xpkg --module m --function f --type-args 0x2::sui::SUI --args 7 x --gas-budget 5 --json",
+		},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tt.call.CLIArgs(), " "); got != tt.want {
+			t.Errorf("CLIArgs() =\n  %s\nwant\n  %s", got, tt.want)
+		}
+	}
+}
+
+func TestBuildMoveCallUntyped(t *testing.T) {
+	var params []json.RawMessage
+	client := newRPCServer(t, func(method string, p []json.RawMessage) (interface{}, *RPCError) {
+		if method != "unsafe_moveCall" {
+			return nil, &RPCError{Code: -32601, Message: "unexpected method " + method}
+		}
+		params = p
+		return map[string]string{"txBytes": "AAE="}, nil
+	})
+
+	// Plan operations store their arguments as CLI strings
+	call := MoveCall{Package: "0xpkg", Module: "catalog", Function: "add_entry_with_cap",
+		Args: CLIArgValues([]string{"0xcat", "0xcap", "doom@beta", "0xcart", `"1993"`, "1", "2199023255552", "dosbox", "3", "[]"})}
+	txBytes, err := client.BuildMoveCall("0xsender", call)
+	if err != nil {
+		t.Fatalf("BuildMoveCall: %v", err)
+	}
+	if txBytes != "AAE=" {
+		t.Errorf("txBytes = %q", txBytes)
+	}
+
+	want := []string{
+		`"0xsender"`, `"0xpkg"`, `"catalog"`, `"add_entry_with_cap"`, `[]`,
+		`["0xcat","0xcap","doom@beta","0xcart","1993",1,2199023255552,"dosbox",3,[]]`,
+		`null`, `"10000000"`,
+	}
+	if len(params) != len(want) {
+		t.Fatalf("got %d params, want %d", len(params), len(want))
+	}
+	for i, p := range params {
+		if string(p) != want[i] {
+			t.Errorf("param %d = %s, want %s", i, p, want[i])
+		}
+	}
+}
+
+func TestBuildMoveCallSharedVersion(t *testing.T) {
+	const (
+		sender  = "0x5e"
+		catalog = "0xca7"
+		capID   = "0xcab"
+	)
+	digest := base58.Encode(bytes.Repeat([]byte{0xd1}, 32))
+	client := newRPCServer(t, func(method string, p []json.RawMessage) (interface{}, *RPCError) {
+		var id string
+		if len(p) > 0 {
+			json.Unmarshal(p[0], &id)
+		}
+		switch {
+		case method == "sui_getObject" && id == catalog:
+			// Written many times since it became shared at version 7
+			return map[string]interface{}{"data": map[string]interface{}{
+				"objectId": catalog, "version": "42", "digest": digest,
+				"owner": map[string]interface{}{"Shared": map[string]interface{}{"initial_shared_version": 7}},
+			}}, nil
+		case method == "sui_getObject" && id == capID:
+			return map[string]interface{}{"data": map[string]interface{}{
+				"objectId": capID, "version": "9", "digest": digest,
+				"owner": map[string]interface{}{"AddressOwner": sender},
+			}}, nil
+		case method == "suix_getReferenceGasPrice":
+			return "750", nil
+		case method == "suix_getCoins":
+			return map[string]interface{}{"data": []map[string]string{
+				{"coinObjectId": "0xc01", "version": "3", "digest": digest, "balance": "20000000"},
+			}}, nil
+		}
+		return nil, &RPCError{Code: -32601, Message: "unexpected method " + method}
+	})
+
+	txBytes, err := client.BuildMoveCall(sender, RemoveEntry("0x1", catalog, capID, "doom"))
+	if err != nil {
+		t.Fatalf("BuildMoveCall: %v", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(txBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, _ := addressBytes(catalog)
+	shared := append([]byte{1, 1}, id...)
+	shared = binary.LittleEndian.AppendUint64(shared, 7)
+	shared = append(shared, 1)
+	// V1, programmable, 3 inputs: the catalog comes first
+	if !bytes.HasPrefix(data, append([]byte{0, 0, 3}, shared...)) {
+		t.Fatalf("transaction doesn't start with the catalog at its initial shared version:\n%x", data)
+	}
+	current := binary.LittleEndian.AppendUint64(append([]byte{1, 1}, id...), 42)
+	if bytes.Contains(data, current) {
+		t.Errorf("transaction references the catalog at its current version")
+	}
+
+	want := &ProgrammableMoveCall{
+		Sender: sender, Package: "0x1", Module: "catalog", Function: "remove_entry_with_cap",
+		Inputs: []CallArg{
+			{Shared: &SharedObjectArg{ObjectID: catalog, InitialSharedVersion: 7, Mutable: true}},
+			{Object: &ObjectRef{ObjectID: capID, Version: 9, Digest: digest}},
+			{Pure: []byte{4, 'd', 'o', 'o', 'm'}},
+		},
+		GasCoins:  []ObjectRef{{ObjectID: "0xc01", Version: 3, Digest: digest}},
+		GasPrice:  750,
+		GasBudget: DefaultGasBudget,
+	}
+	wantData, err := want.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, wantData) {
+		t.Errorf("transaction =\n%x\nwant\n%x", data, wantData)
+	}
+}
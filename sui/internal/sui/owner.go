@@ -0,0 +1,56 @@
+package sui
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// NotSharedError is returned for an object that transactions can't use as
+// a shared object, such as an owned cartridge passed where a catalog is
+// expected
+type NotSharedError struct {
+	ObjectID string
+	// Owner describes the actual owner ("address 0x…", "object 0x…",
+	// "immutable")
+	Owner string
+}
+
+func (e *NotSharedError) Error() string {
+	return fmt.Sprintf("object %s is not shared (owner: %s)", e.ObjectID, e.Owner)
+}
+
+// InitialSharedVersion returns the version at which the object became
+// shared, from its owner info ({"Shared": {"initial_shared_version": N}}).
+// Transactions reference a shared object by this version, not by its
+// current one, which only matches for objects never written since sharing.
+func (o *ObjectData) InitialSharedVersion() (uint64, error) {
+	notShared := func(owner string) error {
+		return &NotSharedError{ObjectID: o.ObjectID, Owner: owner}
+	}
+	switch owner := o.Owner.(type) {
+	case nil:
+		return 0, fmt.Errorf("object %s has no owner info (request it with showOwner)", o.ObjectID)
+	case string:
+		return 0, notShared(owner)
+	case map[string]interface{}:
+		if shared, ok := owner["Shared"].(map[string]interface{}); ok {
+			switch v := shared["initial_shared_version"].(type) {
+			case float64:
+				return uint64(v), nil
+			case string:
+				version, err := strconv.ParseUint(v, 10, 64)
+				if err != nil {
+					return 0, fmt.Errorf("object %s has an invalid initial shared version %q", o.ObjectID, v)
+				}
+				return version, nil
+			}
+			return 0, fmt.Errorf("object %s is shared but its initial shared version is missing", o.ObjectID)
+		}
+		for kind, label := range map[string]string{"AddressOwner": "address", "ObjectOwner": "object"} {
+			if id, ok := owner[kind].(string); ok {
+				return 0, notShared(label + " " + id)
+			}
+		}
+	}
+	return 0, notShared(fmt.Sprint(o.Owner))
+}
@@ -0,0 +1,111 @@
+package sui
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// rpcHandler answers one JSON-RPC call with a result or an error
+type rpcHandler func(method string, params []json.RawMessage) (interface{}, *RPCError)
+
+// newRPCServer starts a JSON-RPC server backed by handle and returns a
+// client for it
+func newRPCServer(t *testing.T, handle rpcHandler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int               `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, rpcErr := handle(req.Method, req.Params)
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if rpcErr != nil {
+			resp["error"] = rpcErr
+		} else {
+			resp["result"] = result
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL)
+}
+
+func TestInitialSharedVersion(t *testing.T) {
+	owners := map[string]interface{}{
+		"0xshared":    map[string]interface{}{"Shared": map[string]interface{}{"initial_shared_version": 7}},
+		"0xsharedstr": map[string]interface{}{"Shared": map[string]interface{}{"initial_shared_version": "12"}},
+		"0xaddress":   map[string]interface{}{"AddressOwner": "0xabc"},
+		"0xchild":     map[string]interface{}{"ObjectOwner": "0xparent"},
+		"0ximmutable": "Immutable",
+	}
+	client := newRPCServer(t, func(method string, params []json.RawMessage) (interface{}, *RPCError) {
+		if method != "sui_getObject" {
+			return nil, &RPCError{Code: -32601, Message: "unexpected method " + method}
+		}
+		var id string
+		json.Unmarshal(params[0], &id)
+		return map[string]interface{}{"data": map[string]interface{}{
+			"objectId": id,
+			"version":  "42",
+			"type":     "0x2::catalog::Catalog",
+			"owner":    owners[id],
+		}}, nil
+	})
+
+	tests := []struct {
+		id      string
+		version uint64
+		owner   string // non-empty when a *NotSharedError is expected
+	}{
+		{id: "0xshared", version: 7},
+		{id: "0xsharedstr", version: 12},
+		{id: "0xaddress", owner: "address 0xabc"},
+		{id: "0xchild", owner: "object 0xparent"},
+		{id: "0ximmutable", owner: "Immutable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			resp, err := client.GetObject(tt.id)
+			if err != nil {
+				t.Fatalf("GetObject: %v", err)
+			}
+			version, err := resp.Data.InitialSharedVersion()
+			if tt.owner == "" {
+				if err != nil || version != tt.version {
+					t.Fatalf("InitialSharedVersion() = %d, %v; want %d", version, err, tt.version)
+				}
+				return
+			}
+			var notShared *NotSharedError
+			if !errors.As(err, &notShared) {
+				t.Fatalf("InitialSharedVersion() error = %v, want *NotSharedError", err)
+			}
+			if notShared.ObjectID != tt.id || notShared.Owner != tt.owner {
+				t.Fatalf("NotSharedError = %+v, want object %s owner %q", notShared, tt.id, tt.owner)
+			}
+		})
+	}
+}
+
+func TestInitialSharedVersionMissing(t *testing.T) {
+	tests := map[string]*ObjectData{
+		"no owner":    {ObjectID: "0x1"},
+		"no version":  {ObjectID: "0x1", Owner: map[string]interface{}{"Shared": map[string]interface{}{}}},
+		"bad version": {ObjectID: "0x1", Owner: map[string]interface{}{"Shared": map[string]interface{}{"initial_shared_version": "x"}}},
+	}
+	for name, obj := range tests {
+		_, err := obj.InitialSharedVersion()
+		var notShared *NotSharedError
+		if err == nil || errors.As(err, &notShared) {
+			t.Errorf("%s: error = %v, want a non-NotSharedError error", name, err)
+		}
+	}
+}
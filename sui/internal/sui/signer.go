@@ -0,0 +1,154 @@
+package sui
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// ed25519Flag is the signature scheme flag of Ed25519 keys
+const ed25519Flag = 0x00
+
+// TxSigner signs transactions for one address. Signer holds the key itself;
+// the signing agent's client asks an agent process instead.
+type TxSigner interface {
+	// SuiAddress is the address the signatures are for
+	SuiAddress() string
+	// SignTransaction signs base64 transaction bytes like Signer.SignTransaction
+	SignTransaction(txBytes string) (string, error)
+}
+
+// Signer signs transactions with an Ed25519 key
+type Signer struct {
+	key ed25519.PrivateKey
+	// Address is the Sui address of the key
+	Address string
+}
+
+// NewSigner creates a signer for an Ed25519 key
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	pub := key.Public().(ed25519.PublicKey)
+	digest := blake2b.Sum256(append([]byte{ed25519Flag}, pub...))
+	return &Signer{key: key, Address: "0x" + hex.EncodeToString(digest[:])}
+}
+
+// SuiAddress implements TxSigner
+func (s *Signer) SuiAddress() string { return s.Address }
+
+// SignTransaction signs base64 transaction bytes and returns the serialized
+// signature (flag, signature and public key, base64) for
+// sui_executeTransactionBlock
+func (s *Signer) SignTransaction(txBytes string) (string, error) {
+	tx, err := base64.StdEncoding.DecodeString(txBytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction bytes: %w", err)
+	}
+	// Intent: transaction data, version 0, Sui app
+	digest := blake2b.Sum256(append([]byte{0, 0, 0}, tx...))
+	sig := ed25519.Sign(s.key, digest[:])
+
+	serialized := []byte{ed25519Flag}
+	serialized = append(serialized, sig...)
+	serialized = append(serialized, s.key.Public().(ed25519.PublicKey)...)
+	return base64.StdEncoding.EncodeToString(serialized), nil
+}
+
+// SignerFromMnemonic derives the first Ed25519 account of a BIP39 mnemonic
+// (m/44'/784'/0'/0'/0', like the Sui CLI and wallets)
+func SignerFromMnemonic(mnemonic, passphrase string) (*Signer, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 {
+		return nil, fmt.Errorf("mnemonic must have at least 12 words, got %d", len(words))
+	}
+	seed := pbkdf2.Key([]byte(strings.Join(words, " ")), []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+
+	// SLIP-10 derivation; Ed25519 only supports hardened indexes
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chain := sum[:32], sum[32:]
+	for _, index := range []uint32{44, 784, 0, 0, 0} {
+		data := append([]byte{0}, key...)
+		data = binary.BigEndian.AppendUint32(data, index|0x80000000)
+		mac := hmac.New(sha512.New, chain)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		key, chain = sum[:32], sum[32:]
+	}
+	return NewSigner(ed25519.NewKeyFromSeed(key)), nil
+}
+
+// CLIConfigDir returns the sui CLI config directory ($SUI_CONFIG_DIR or
+// ~/.sui/sui_config)
+func CLIConfigDir() string {
+	if dir := os.Getenv("SUI_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".sui", "sui_config")
+}
+
+// SignerFromKeystore returns the key of the sui CLI's active address from
+// its keystore (sui.keystore and client.yaml in dir). Only Ed25519 keys are
+// supported.
+func SignerFromKeystore(dir string) (*Signer, error) {
+	active, err := activeAddressFromClientYAML(filepath.Join(dir, "client.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "sui.keystore"))
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Join(dir, "sui.keystore"), err)
+	}
+	for _, k := range keys {
+		raw, err := base64.StdEncoding.DecodeString(k)
+		if err != nil || len(raw) != 1+ed25519.SeedSize || raw[0] != ed25519Flag {
+			continue
+		}
+		s := NewSigner(ed25519.NewKeyFromSeed(raw[1:]))
+		if strings.EqualFold(s.Address, active) {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no Ed25519 key for the active address %s in %s", active, filepath.Join(dir, "sui.keystore"))
+}
+
+// activeAddressFromClientYAML reads active_address from a sui client.yaml
+func activeAddressFromClientYAML(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if value, ok := strings.CutPrefix(line, "active_address:"); ok {
+			value = strings.Trim(strings.TrimSpace(value), `"'`)
+			if value == "" || value == "~" {
+				break
+			}
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("no active_address in %s", path)
+}
@@ -0,0 +1,51 @@
+package sui
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestNewSignerAddress(t *testing.T) {
+	// BLAKE2b-256 of the flag byte and the public key of the all-zero seed,
+	// from Python's hashlib.blake2b(b"\x00" + pub, digest_size=32)
+	s := NewSigner(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+	if want := "0x7a1378aafadef8ce743b72e8b248295c8f61c102c94040161146ea4d51a182b6"; s.Address != want {
+		t.Errorf("Address = %s, want %s", s.Address, want)
+	}
+}
+
+func TestSignerFromMnemonic(t *testing.T) {
+	// Test vector of the Sui TypeScript SDK's Ed25519 keypair tests
+	s, err := SignerFromMnemonic("film crazy soon outside stand loop subway crumble thrive popular green nuclear struggle pistol arm wife phrase warfare march wheat nephew ask sunny firm", "")
+	if err != nil {
+		t.Fatalf("SignerFromMnemonic: %v", err)
+	}
+	if want := "0xa2d14fad60c56049ecf75246a481934691214ce413e6a8ae2fe6834c173a6133"; s.Address != want {
+		t.Errorf("Address = %s, want %s", s.Address, want)
+	}
+
+	if _, err := SignerFromMnemonic("too few words", ""); err == nil {
+		t.Error("SignerFromMnemonic accepted a 3-word mnemonic")
+	}
+}
+
+func TestSignTransaction(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	sig, err := NewSigner(key).SignTransaction(base64.StdEncoding.EncodeToString([]byte{1, 2, 3, 4, 5}))
+	if err != nil {
+		t.Fatalf("SignTransaction: %v", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil || len(raw) != 1+ed25519.SignatureSize+ed25519.PublicKeySize {
+		t.Fatalf("signature %q is not flag, signature and public key", sig)
+	}
+	// The signed message is the intent digest: BLAKE2b-256 of 00 00 00 and
+	// the transaction bytes, from Python's hashlib.blake2b
+	digest, _ := hex.DecodeString("70bc00160b1e410475986f4ad4a6a548a788250e00a057ea98057e5938a116cf")
+	pub := ed25519.PublicKey(raw[1+ed25519.SignatureSize:])
+	if raw[0] != ed25519Flag || !ed25519.Verify(pub, digest, raw[1:1+ed25519.SignatureSize]) {
+		t.Error("signature doesn't verify against the intent digest")
+	}
+}
@@ -0,0 +1,298 @@
+package sui
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/retro-crypto/sui/internal/base58"
+)
+
+// Programmable transactions built in Go rather than by unsafe_moveCall, so
+// every input is chosen here. Shared objects in particular are referenced by
+// their initial shared version (see ObjectData.InitialSharedVersion).
+
+// ObjectRef references an owned or immutable object at one version
+type ObjectRef struct {
+	ObjectID string
+	Version  uint64
+	// Digest is the object digest (base58)
+	Digest string
+}
+
+// CallArg is an input of a programmable transaction: a pure value, an
+// owned or immutable object, or a shared object
+type CallArg struct {
+	// Pure is the BCS value of a pure input
+	Pure []byte
+	// Object is set for owned and immutable objects
+	Object *ObjectRef
+	// Shared is set for shared objects
+	Shared *SharedObjectArg
+}
+
+// SharedObjectArg references a shared object. The version is the one at
+// which the object became shared; validators pick the current one.
+type SharedObjectArg struct {
+	ObjectID             string
+	InitialSharedVersion uint64
+	Mutable              bool
+}
+
+// ProgrammableMoveCall is a single Move call transaction: inputs, the call
+// using all of them in order, and the gas it pays with
+type ProgrammableMoveCall struct {
+	Sender    string
+	Package   string
+	Module    string
+	Function  string
+	Inputs    []CallArg
+	GasCoins  []ObjectRef
+	GasPrice  uint64
+	GasBudget uint64
+}
+
+// Encode returns the BCS TransactionData of the call
+func (p *ProgrammableMoveCall) Encode() ([]byte, error) {
+	w := &bcsWriter{}
+	w.uleb128(0) // TransactionData::V1
+	w.uleb128(0) // TransactionKind::ProgrammableTransaction
+
+	w.uleb128(uint64(len(p.Inputs)))
+	for i, in := range p.Inputs {
+		if err := in.encode(w); err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+
+	w.uleb128(1) // one command
+	w.uleb128(0) // Command::MoveCall
+	if err := w.address(p.Package); err != nil {
+		return nil, err
+	}
+	w.string(p.Module)
+	w.string(p.Function)
+	w.uleb128(0) // no type arguments
+	w.uleb128(uint64(len(p.Inputs)))
+	for i := range p.Inputs {
+		w.uleb128(1) // Argument::Input
+		w.u16(uint16(i))
+	}
+
+	if err := w.address(p.Sender); err != nil {
+		return nil, err
+	}
+	// GasData: payment, owner, price, budget
+	w.uleb128(uint64(len(p.GasCoins)))
+	for _, coin := range p.GasCoins {
+		if err := coin.encode(w); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.address(p.Sender); err != nil {
+		return nil, err
+	}
+	w.u64(p.GasPrice)
+	w.u64(p.GasBudget)
+	w.uleb128(0) // TransactionExpiration::None
+	return w.bytes(), nil
+}
+
+func (a CallArg) encode(w *bcsWriter) error {
+	switch {
+	case a.Shared != nil:
+		w.uleb128(1) // CallArg::Object
+		w.uleb128(1) // ObjectArg::SharedObject
+		if err := w.address(a.Shared.ObjectID); err != nil {
+			return err
+		}
+		w.u64(a.Shared.InitialSharedVersion)
+		w.bool(a.Shared.Mutable)
+	case a.Object != nil:
+		w.uleb128(1) // CallArg::Object
+		w.uleb128(0) // ObjectArg::ImmOrOwnedObject
+		return a.Object.encode(w)
+	default:
+		w.uleb128(0) // CallArg::Pure
+		w.vector(a.Pure)
+	}
+	return nil
+}
+
+func (r ObjectRef) encode(w *bcsWriter) error {
+	if err := w.address(r.ObjectID); err != nil {
+		return err
+	}
+	w.u64(r.Version)
+	digest, err := base58.Decode(r.Digest)
+	if err != nil || len(digest) != 32 {
+		return fmt.Errorf("object %s has an invalid digest %q", r.ObjectID, r.Digest)
+	}
+	w.vector(digest)
+	return nil
+}
+
+// buildProgrammable builds a typed Move call (see MoveCall.typed) as a
+// programmable transaction and returns its bytes (base64)
+func (c *Client) buildProgrammable(signer string, call MoveCall) (string, error) {
+	tx := &ProgrammableMoveCall{
+		Sender:    signer,
+		Package:   call.Package,
+		Module:    call.Module,
+		Function:  call.Function,
+		GasBudget: call.gasBudget(),
+	}
+	for i, arg := range call.Args {
+		in, err := c.callArg(arg)
+		if err != nil {
+			return "", fmt.Errorf("argument %d of %s: %w", i, call.Target(), err)
+		}
+		tx.Inputs = append(tx.Inputs, in)
+	}
+	var err error
+	if tx.GasPrice, err = c.GetReferenceGasPrice(); err != nil {
+		return "", err
+	}
+	if tx.GasCoins, err = c.gasCoins(signer, tx.GasBudget); err != nil {
+		return "", err
+	}
+	data, err := tx.Encode()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// callArg resolves one typed argument to a transaction input
+func (c *Client) callArg(arg interface{}) (CallArg, error) {
+	switch v := arg.(type) {
+	case SharedObject:
+		resp, err := c.GetObject(string(v))
+		if err != nil {
+			return CallArg{}, err
+		}
+		if resp.Data == nil {
+			return CallArg{}, fmt.Errorf("object %s not found", v)
+		}
+		version, err := resp.Data.InitialSharedVersion()
+		if err != nil {
+			return CallArg{}, err
+		}
+		return CallArg{Shared: &SharedObjectArg{ObjectID: string(v), InitialSharedVersion: version, Mutable: true}}, nil
+	case OwnedObject:
+		ref, err := c.objectRef(string(v))
+		if err != nil {
+			return CallArg{}, err
+		}
+		return CallArg{Object: ref}, nil
+	}
+	pure, err := pureValue(arg)
+	if err != nil {
+		return CallArg{}, err
+	}
+	return CallArg{Pure: pure}, nil
+}
+
+// pureValue encodes a typed pure argument as BCS
+func pureValue(arg interface{}) ([]byte, error) {
+	w := &bcsWriter{}
+	switch v := arg.(type) {
+	case MoveString:
+		w.string(string(v))
+	case Address:
+		if err := w.address(string(v)); err != nil {
+			return nil, err
+		}
+	case Bytes:
+		b, err := v.decode()
+		if err != nil {
+			return nil, err
+		}
+		w.vector(b)
+	case uint8:
+		w.u8(v)
+	case uint16:
+		w.u16(v)
+	case uint64:
+		w.u64(v)
+	case []string:
+		w.uleb128(uint64(len(v)))
+		for _, s := range v {
+			w.string(s)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported argument type %T", arg)
+	}
+	return w.bytes(), nil
+}
+
+// objectRef reads the current version and digest of an owned object. It
+// bypasses the object cache: a stale reference fails the transaction.
+func (c *Client) objectRef(objectID string) (*ObjectRef, error) {
+	result, err := c.call("sui_getObject", []interface{}{objectID, map[string]bool{}})
+	if err != nil {
+		return nil, err
+	}
+	var resp ObjectResponse
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal object: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("object %s not found", objectID)
+	}
+	version, err := strconv.ParseUint(resp.Data.Version, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("object %s has an invalid version %q", objectID, resp.Data.Version)
+	}
+	return &ObjectRef{ObjectID: resp.Data.ObjectID, Version: version, Digest: resp.Data.Digest}, nil
+}
+
+// GetReferenceGasPrice returns the gas price of the current epoch (MIST)
+func (c *Client) GetReferenceGasPrice() (uint64, error) {
+	result, err := c.call("suix_getReferenceGasPrice", []interface{}{})
+	if err != nil {
+		return 0, err
+	}
+	var price string
+	if err := json.Unmarshal(result, &price); err != nil {
+		return 0, fmt.Errorf("failed to parse gas price: %w", err)
+	}
+	return strconv.ParseUint(price, 10, 64)
+}
+
+// maxGasCoins is the most coins one transaction pays with
+const maxGasCoins = 50
+
+// gasCoins picks SUI coins of owner that together cover budget
+func (c *Client) gasCoins(owner string, budget uint64) ([]ObjectRef, error) {
+	result, err := c.call("suix_getCoins", []interface{}{owner, "0x2::sui::SUI", nil, maxGasCoins})
+	if err != nil {
+		return nil, err
+	}
+	var page struct {
+		Data []struct {
+			CoinObjectID string `json:"coinObjectId"`
+			Version      string `json:"version"`
+			Digest       string `json:"digest"`
+			Balance      string `json:"balance"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(result, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse coins: %w", err)
+	}
+	var coins []ObjectRef
+	var total uint64
+	for _, coin := range page.Data {
+		version, err1 := strconv.ParseUint(coin.Version, 10, 64)
+		balance, err2 := strconv.ParseUint(coin.Balance, 10, 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("invalid coin %s", coin.CoinObjectID)
+		}
+		coins = append(coins, ObjectRef{ObjectID: coin.CoinObjectID, Version: version, Digest: coin.Digest})
+		if total += balance; total >= budget {
+			return coins, nil
+		}
+	}
+	return nil, fmt.Errorf("%s has %d MIST in its first %d SUI coins, the gas budget is %d", owner, total, maxGasCoins, budget)
+}
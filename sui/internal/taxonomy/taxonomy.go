@@ -0,0 +1,97 @@
+// Package taxonomy reads the list of tags catalog entries may carry.
+//
+// A taxonomy file is JSON mapping groups to their tags:
+//
+//	{
+//	  "genre": ["action", "platformer", "puzzle", "rpg"],
+//	  "feature": ["multiplayer", "save-states"]
+//	}
+//
+// Entries store plain tags; the group is looked up here. A tag belongs to
+// one group only.
+package taxonomy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/validate"
+)
+
+// Taxonomy is the set of known tags, by group
+type Taxonomy struct {
+	// Groups lists the tags of each group as written in the file
+	Groups map[string][]string
+	// groups maps each tag to its group
+	groups map[string]string
+}
+
+// Load reads a taxonomy file
+func Load(path string) (*Taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tag taxonomy: %w", err)
+	}
+	t, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Parse reads a taxonomy from JSON
+func Parse(data []byte) (*Taxonomy, error) {
+	var groups map[string][]string
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("invalid tag taxonomy (expected {\"group\": [\"tag\", ...]}): %w", err)
+	}
+	t := &Taxonomy{Groups: groups, groups: make(map[string]string)}
+	for group, tags := range groups {
+		if group == "" {
+			return nil, fmt.Errorf("tag taxonomy has a group without a name")
+		}
+		for _, tag := range tags {
+			if err := validate.Tag(tag); err != nil {
+				return nil, fmt.Errorf("group %s: %w", group, err)
+			}
+			if other, ok := t.groups[tag]; ok {
+				return nil, fmt.Errorf("tag %q is in both %s and %s", tag, other, group)
+			}
+			t.groups[tag] = group
+		}
+	}
+	return t, nil
+}
+
+// Group returns the group of a tag, false if the taxonomy doesn't have it
+func (t *Taxonomy) Group(tag string) (string, bool) {
+	group, ok := t.groups[tag]
+	return group, ok
+}
+
+// Check returns an error naming the tags that aren't in the taxonomy
+func (t *Taxonomy) Check(tags []string) error {
+	var unknown []string
+	for _, tag := range tags {
+		if _, ok := t.groups[tag]; !ok {
+			unknown = append(unknown, tag)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("not in the tag taxonomy: %s (known groups: %s)", strings.Join(unknown, ", "), strings.Join(t.GroupNames(), ", "))
+	}
+	return nil
+}
+
+// GroupNames returns the groups, sorted
+func (t *Taxonomy) GroupNames() []string {
+	names := make([]string, 0, len(t.Groups))
+	for group := range t.Groups {
+		names = append(names, group)
+	}
+	sort.Strings(names)
+	return names
+}
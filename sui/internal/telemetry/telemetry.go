@@ -0,0 +1,213 @@
+// Package telemetry reports anonymous usage after a user opted in.
+//
+// Nothing is sent until `telemetry enable` wrote a settings file with a
+// random installation ID. An event names the command, the flags that were
+// set (names only, never values), how long it ran and, for a failed run, a
+// coarse error category; never arguments, addresses, object IDs, file names
+// or error messages. DO_NOT_TRACK=1 turns reporting off regardless of the
+// settings.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileName is the settings file in the config directory
+const FileName = "telemetry.json"
+
+// Settings is the opt-in state kept in FileName
+type Settings struct {
+	Enabled bool `json:"enabled"`
+	// ID identifies an installation between reports; it is random, derived
+	// from nothing, and replaced every time reporting is enabled
+	ID string `json:"id,omitempty"`
+	// Endpoint receives the events; empty uses the built-in endpoint
+	Endpoint  string `json:"endpoint,omitempty"`
+	EnabledAt string `json:"enabled_at,omitempty"`
+}
+
+// Event is one command run, as posted to the endpoint
+type Event struct {
+	ID      string `json:"id"`
+	Tool    string `json:"tool"`
+	Version string `json:"version"`
+	OS      string `json:"os"`
+	Arch    string `json:"arch"`
+	// Command is the command path without the tool, e.g. "key status"
+	Command string `json:"command"`
+	// Flags are the names of the flags that were set
+	Flags         []string `json:"flags,omitempty"`
+	DurationMS    int64    `json:"duration_ms"`
+	Success       bool     `json:"success"`
+	ErrorCategory string   `json:"error_category,omitempty"`
+	CI            bool     `json:"ci"`
+}
+
+// Load reads the settings; a missing file means reporting is off
+func Load(path string) (*Settings, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Settings{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Save writes the settings, readable by the owner only
+func (s *Settings) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Enable turns reporting on with a fresh installation ID
+func (s *Settings) Enable(endpoint string) error {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	s.Enabled = true
+	s.ID = hex.EncodeToString(id)
+	if endpoint != "" {
+		s.Endpoint = endpoint
+	}
+	s.EnabledAt = time.Now().UTC().Format(time.RFC3339)
+	return nil
+}
+
+// Disable turns reporting off and forgets the installation ID
+func (s *Settings) Disable() {
+	s.Enabled = false
+	s.ID = ""
+	s.EnabledAt = ""
+}
+
+// DoNotTrack reports whether DO_NOT_TRACK (consoledonottrack.com) is set
+func DoNotTrack() bool {
+	v := strings.ToLower(os.Getenv("DO_NOT_TRACK"))
+	return v != "" && v != "0" && v != "false"
+}
+
+// ValidateEndpoint checks that an endpoint is an http(s) URL
+func ValidateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("telemetry endpoint must be an http(s) URL, got %q", endpoint)
+	}
+	return nil
+}
+
+// NewEvent describes a command run. commandPath is the full command path
+// including the tool; flags are the names of the flags that were set.
+func NewEvent(s *Settings, tool, version, commandPath string, flags []string, duration time.Duration, err error) Event {
+	flags = append([]string(nil), flags...)
+	sort.Strings(flags)
+	return Event{
+		ID:            s.ID,
+		Tool:          tool,
+		Version:       version,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		Command:       strings.TrimSpace(strings.TrimPrefix(commandPath, tool)),
+		Flags:         flags,
+		DurationMS:    duration.Milliseconds(),
+		Success:       err == nil,
+		ErrorCategory: Categorize(err),
+		CI:            os.Getenv("CI") != "",
+	}
+}
+
+// Categorize maps an error to a coarse category, so the message itself
+// (which may name files, addresses or URLs) is never reported
+func Categorize(err error) string {
+	if err == nil {
+		return ""
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &opErr), errors.As(err, &dnsErr), errors.As(err, &urlErr):
+		return "network"
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrPermission):
+		return "file"
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, c := range []struct {
+		category string
+		words    []string
+	}{
+		{"usage", []string{"unknown command", "unknown flag", "unknown shorthand", "required flag", "accepts ", "arg(s)", "flag needs an argument"}},
+		{"auth", []string{"passphrase", "unauthorized", "forbidden", "signature", "private key", "mnemonic"}},
+		{"funds", []string{"insufficient", "balance", "gas budget"}},
+		{"timeout", []string{"timeout", "timed out", "deadline"}},
+		{"network", []string{"connection refused", "no such host", "connection reset", "eof"}},
+		{"server", []string{"status 5", "http 5", "rate limit", "429"}},
+		{"not_found", []string{"not found", "does not exist", "no such"}},
+		{"invalid_input", []string{"invalid", "must be", "must not", "is required"}},
+	} {
+		for _, w := range c.words {
+			if strings.Contains(msg, w) {
+				return c.category
+			}
+		}
+	}
+	return "other"
+}
+
+// Send posts an event; the caller bounds it with the client's timeout
+func Send(client *http.Client, endpoint, userAgent string, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
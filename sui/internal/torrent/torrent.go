@@ -0,0 +1,225 @@
+// Package torrent builds BitTorrent v2 (BEP 52) metainfo for single files,
+// with HTTP webseeds (BEP 19), so published blobs can also be shared over
+// BitTorrent
+package torrent
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// BlockSize is the size of the merkle tree leaves defined by BEP 52
+const BlockSize = 16 << 10
+
+// MaxPieces is the piece count PieceLength aims to stay under
+const MaxPieces = 1024
+
+// Torrent is the metainfo of one file
+type Torrent struct {
+	// Metainfo is the bencoded .torrent file
+	Metainfo []byte
+	// InfoHash is the v2 info hash (SHA256 of the bencoded info dictionary)
+	InfoHash [32]byte
+	// Name is the file name
+	Name string
+	// WebSeeds are the HTTP URLs serving the file
+	WebSeeds []string
+}
+
+// InfoHashHex returns the info hash as hex
+func (t *Torrent) InfoHashHex() string {
+	return hex.EncodeToString(t.InfoHash[:])
+}
+
+// Magnet returns a magnet link with the v2 info hash (as a multihash),
+// the file name and the webseeds
+func (t *Torrent) Magnet() string {
+	q := "xt=urn:btmh:1220" + t.InfoHashHex() + "&dn=" + url.QueryEscape(t.Name)
+	for _, ws := range t.WebSeeds {
+		q += "&ws=" + url.QueryEscape(ws)
+	}
+	return "magnet:?" + q
+}
+
+// PieceLength returns the smallest power-of-two piece length (at least
+// BlockSize) that keeps a file of size under MaxPieces pieces
+func PieceLength(size int64) int64 {
+	length := int64(BlockSize)
+	for size/length >= MaxPieces {
+		length *= 2
+	}
+	return length
+}
+
+// New builds the metainfo of data. comment is free text stored in the
+// torrent (e.g. the on-chain reference of the file).
+func New(name string, data []byte, webSeeds []string, comment string) (*Torrent, error) {
+	if name == "" {
+		return nil, fmt.Errorf("torrent name is empty")
+	}
+	pieceLength := PieceLength(int64(len(data)))
+
+	fileEntry := map[string]interface{}{"length": int64(len(data))}
+	var pieceLayers map[string]interface{}
+	if len(data) > 0 {
+		root, layer := merkle(data, pieceLength)
+		fileEntry["pieces root"] = root
+		// Files of a single piece have no piece layer
+		if int64(len(data)) > pieceLength {
+			pieceLayers = map[string]interface{}{string(root): layer}
+		}
+	}
+
+	info := map[string]interface{}{
+		"name":         name,
+		"piece length": pieceLength,
+		"meta version": int64(2),
+		"file tree": map[string]interface{}{
+			name: map[string]interface{}{"": fileEntry},
+		},
+	}
+	infoBytes, err := bencode(info)
+	if err != nil {
+		return nil, err
+	}
+
+	// No creation date, so the same file always gives the same metainfo
+	meta := map[string]interface{}{
+		"info":       rawBencode(infoBytes),
+		"created by": "catalogctl",
+	}
+	if comment != "" {
+		meta["comment"] = comment
+	}
+	if pieceLayers != nil {
+		meta["piece layers"] = pieceLayers
+	}
+	if len(webSeeds) > 0 {
+		seeds := make([]interface{}, len(webSeeds))
+		for i, ws := range webSeeds {
+			seeds[i] = ws
+		}
+		meta["url-list"] = seeds
+	}
+	metainfo, err := bencode(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Torrent{
+		Metainfo: metainfo,
+		InfoHash: sha256.Sum256(infoBytes),
+		Name:     name,
+		WebSeeds: webSeeds,
+	}, nil
+}
+
+// merkle returns the BEP 52 pieces root of data and its piece layer: the
+// concatenated hashes of the subtrees covering one piece each. Leaves are
+// SHA256 of 16 KiB blocks; missing leaves up to the next power of two are
+// zero.
+func merkle(data []byte, pieceLength int64) (root []byte, layer []byte) {
+	var level [][]byte
+	for off := 0; off < len(data); off += BlockSize {
+		end := off + BlockSize
+		if end > len(data) {
+			end = len(data)
+		}
+		sum := sha256.Sum256(data[off:end])
+		level = append(level, sum[:])
+	}
+
+	blocksPerPiece := int(pieceLength / BlockSize)
+	leaves := 1
+	for leaves < len(level) {
+		leaves *= 2
+	}
+	pad := make([]byte, 32)
+
+	width := 1
+	for {
+		if width == blocksPerPiece {
+			// The piece layer covers the file only, not trailing padding pieces
+			pieces := (len(data) + int(pieceLength) - 1) / int(pieceLength)
+			layer = bytes.Join(level[:pieces], nil)
+		}
+		if leaves == 1 {
+			return level[0], layer
+		}
+		next := make([][]byte, 0, leaves/2)
+		for i := 0; i < leaves; i += 2 {
+			left, right := pad, pad
+			if i < len(level) {
+				left = level[i]
+			}
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			sum := sha256.Sum256(append(append([]byte{}, left...), right...))
+			next = append(next, sum[:])
+		}
+		// Hash of a subtree made only of padding, for the next level up
+		padSum := sha256.Sum256(append(append([]byte{}, pad...), pad...))
+		pad = padSum[:]
+		level = next
+		leaves /= 2
+		width *= 2
+	}
+}
+
+// rawBencode is an already encoded value
+type rawBencode []byte
+
+// bencode encodes strings, byte slices, integers, lists and dictionaries
+// (keys sorted as raw strings)
+func bencode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encode(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encode(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case rawBencode:
+		buf.Write(v)
+	case string:
+		buf.WriteString(strconv.Itoa(len(v)) + ":" + v)
+	case []byte:
+		buf.WriteString(strconv.Itoa(len(v)) + ":")
+		buf.Write(v)
+	case int64:
+		buf.WriteString("i" + strconv.FormatInt(v, 10) + "e")
+	case []interface{}:
+		buf.WriteByte('l')
+		for _, item := range v {
+			if err := encode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('d')
+		for _, k := range keys {
+			encode(buf, k)
+			if err := encode(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	default:
+		return fmt.Errorf("bencode: unsupported type %T", v)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Package validate provides format checks for Sui object IDs, Walrus blob IDs,
+// SHA256 digests, catalog alias names, cartridge asset names and entry tags
+package validate
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/retro-crypto/sui/internal/base58"
+)
+
+// ObjectIDHexLength is the number of hex characters in a Sui object ID (32 bytes)
+const ObjectIDHexLength = 64
+
+// Walrus blob IDs encode 32 bytes, which takes 40-44 base58 characters
+const (
+	BlobIDLength        = 32
+	MinBlobIDCharLength = 40
+	MaxBlobIDCharLength = 44
+)
+
+// ObjectID checks that s is a Sui object ID: 0x followed by 64 hex characters
+func ObjectID(s string) error {
+	if !strings.HasPrefix(s, "0x") {
+		return fmt.Errorf("object ID must start with 0x")
+	}
+	body := s[2:]
+	if len(body) != ObjectIDHexLength {
+		return fmt.Errorf("object ID must have %d hex characters after 0x, got %d", ObjectIDHexLength, len(body))
+	}
+	if _, err := hex.DecodeString(body); err != nil {
+		return fmt.Errorf("object ID contains non-hex characters")
+	}
+	return nil
+}
+
+// BlobID checks that s is a base58 Walrus blob ID of plausible length
+func BlobID(s string) error {
+	if len(s) < MinBlobIDCharLength || len(s) > MaxBlobIDCharLength {
+		return fmt.Errorf("blob ID must be %d-%d characters, got %d", MinBlobIDCharLength, MaxBlobIDCharLength, len(s))
+	}
+	decoded, err := base58.Decode(s)
+	if err != nil {
+		return fmt.Errorf("blob ID is not valid base58: %w", err)
+	}
+	if len(decoded) > BlobIDLength {
+		return fmt.Errorf("blob ID decodes to %d bytes, expected at most %d", len(decoded), BlobIDLength)
+	}
+	return nil
+}
+
+// SHA256Hex checks that s is a SHA256 digest in hex (64 characters)
+func SHA256Hex(s string) error {
+	if len(s) != 64 {
+		return fmt.Errorf("SHA256 must have 64 hex characters, got %d", len(s))
+	}
+	if _, err := hex.DecodeString(s); err != nil {
+		return fmt.Errorf("SHA256 contains non-hex characters")
+	}
+	return nil
+}
+
+// AliasName checks that s is a usable catalog alias: letters, digits, '-' and
+// '_', not starting with 0x (which would be taken for an object ID)
+func AliasName(s string) error {
+	if s == "" {
+		return fmt.Errorf("alias name is empty")
+	}
+	if strings.HasPrefix(s, "0x") {
+		return fmt.Errorf("alias name must not start with 0x")
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("alias name may only contain letters, digits, '-' and '_'")
+		}
+	}
+	return nil
+}
+
+// AssetName checks that s is a usable cartridge asset name: lowercase
+// letters, digits, '-' and '_', starting with a letter or digit
+func AssetName(s string) error {
+	if s == "" {
+		return fmt.Errorf("asset name is empty")
+	}
+	for i, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || i > 0 && (r == '-' || r == '_')) {
+			return fmt.Errorf("asset name may only contain lowercase letters, digits, '-' and '_'")
+		}
+	}
+	return nil
+}
+
+// MaxTagLength is the longest entry tag in bytes
+const MaxTagLength = 32
+
+// Tag checks that s is a usable entry tag: lowercase letters, digits and
+// '-', starting with a letter or digit
+func Tag(s string) error {
+	if s == "" {
+		return fmt.Errorf("tag is empty")
+	}
+	if len(s) > MaxTagLength {
+		return fmt.Errorf("tag %q is longer than %d characters", s, MaxTagLength)
+	}
+	for i, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || i > 0 && r == '-') {
+			return fmt.Errorf("tag %q may only contain lowercase letters, digits and '-'", s)
+		}
+	}
+	return nil
+}
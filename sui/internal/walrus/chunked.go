@@ -0,0 +1,274 @@
+package walrus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ChunkedFormat marks a blob that is the manifest of a chunked upload
+const ChunkedFormat = "walrus-chunked-v1"
+
+// DefaultChunkSize is the chunk size of resumable uploads
+const DefaultChunkSize = 16 << 20
+
+// DefaultChunkRetries is how many times a chunk upload is retried
+const DefaultChunkRetries = 5
+
+// MaxManifestSize bounds the blobs checked for a chunk manifest; a manifest
+// of a 200MB file in 1MB chunks is well under it
+const MaxManifestSize = 1 << 20
+
+// ChunkManifest lists the blobs a large file was uploaded as. It is stored
+// as a blob of its own, whose ID stands for the whole file.
+type ChunkManifest struct {
+	Format    string  `json:"format"`
+	Size      int64   `json:"size"`
+	SHA256    string  `json:"sha256"`
+	ChunkSize int64   `json:"chunk_size"`
+	Chunks    []Chunk `json:"chunks"`
+}
+
+// Chunk is one uploaded piece of a file
+type Chunk struct {
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	BlobID string `json:"blob_id"`
+}
+
+// ResumableOptions configures StoreFileResumable
+type ResumableOptions struct {
+	// ChunkSize is the size of each uploaded piece (DefaultChunkSize if 0)
+	ChunkSize int64
+	// Retries is how many times a failed chunk is retried (DefaultChunkRetries if 0)
+	Retries int
+	// StatePath is the resume manifest; chunks recorded there are not
+	// uploaded again
+	StatePath string
+	// Progress is called after each chunk with the number of chunks done
+	Progress func(done, total int, resumed bool)
+}
+
+// uploadState is the resume manifest of an unfinished chunked upload;
+// chunks without a blob ID are still to be uploaded
+type uploadState struct {
+	Epochs    int           `json:"epochs"`
+	Manifest  ChunkManifest `json:"manifest"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// StoreFileResumable uploads a file through the publisher as a series of
+// chunk blobs followed by a manifest blob, whose ID is returned. Progress is
+// saved to opts.StatePath after every chunk, so an interrupted upload can be
+// run again and only the missing chunks are sent. Only one chunk is held in
+// memory at a time.
+func (c *Client) StoreFileResumable(path string, epochs int, opts ResumableOptions) (*StoreResponse, *ChunkManifest, error) {
+	if c.publisherURL == "" {
+		return nil, nil, fmt.Errorf("resumable uploads need a publisher URL")
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	if opts.Retries <= 0 {
+		opts.Retries = DefaultChunkRetries
+	}
+
+	manifest, err := planChunks(path, opts.ChunkSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	state := &uploadState{Epochs: epochs, Manifest: *manifest}
+	if saved := loadUploadState(opts.StatePath); saved != nil && saved.matches(state) {
+		state = saved
+	}
+
+	for i := range state.Manifest.Chunks {
+		chunk := &state.Manifest.Chunks[i]
+		if chunk.BlobID != "" {
+			if opts.Progress != nil {
+				opts.Progress(i+1, len(state.Manifest.Chunks), true)
+			}
+			continue
+		}
+		data, err := readSection(path, chunk.Offset, chunk.Size)
+		if err != nil {
+			return nil, nil, err
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != chunk.SHA256 {
+			return nil, nil, fmt.Errorf("%s changed during the upload; remove %s to start over", path, opts.StatePath)
+		}
+		resp, err := c.storeWithRetry(data, epochs, opts.Retries)
+		if err != nil {
+			return nil, nil, fmt.Errorf("chunk %d of %d failed, run again to resume: %w", i+1, len(state.Manifest.Chunks), err)
+		}
+		chunk.BlobID = resp.GetBlobID()
+		if err := state.save(opts.StatePath); err != nil {
+			return nil, nil, fmt.Errorf("failed to save upload state: %w", err)
+		}
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(state.Manifest.Chunks), false)
+		}
+	}
+
+	data, err := json.MarshalIndent(state.Manifest, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.storeWithRetry(data, epochs, opts.Retries)
+	if err != nil {
+		return nil, nil, fmt.Errorf("all chunks are stored but the manifest upload failed, run again to finish: %w", err)
+	}
+	if opts.StatePath != "" {
+		os.Remove(opts.StatePath)
+	}
+	return resp, &state.Manifest, nil
+}
+
+// storeWithRetry uploads data through the publisher, backing off between
+// attempts
+func (c *Client) storeWithRetry(data []byte, epochs, retries int) (*StoreResponse, error) {
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+		}
+		resp, err := c.storeViaHTTP(bytesBody(data), int64(len(data)), epochs, nil)
+		if err == nil && resp.GetBlobID() != "" {
+			return resp, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("no blob ID in response")
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("failed after %d retries: %w", retries, lastErr)
+}
+
+// planChunks hashes the file and splits it into chunks
+func planChunks(path string, chunkSize int64) (*ChunkManifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	manifest := &ChunkManifest{Format: ChunkedFormat, ChunkSize: chunkSize}
+	whole := sha256.New()
+	for {
+		h := sha256.New()
+		n, err := io.Copy(io.MultiWriter(whole, h), io.LimitReader(f, chunkSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if n == 0 && len(manifest.Chunks) > 0 {
+			break
+		}
+		manifest.Chunks = append(manifest.Chunks, Chunk{
+			Offset: manifest.Size,
+			Size:   n,
+			SHA256: hex.EncodeToString(h.Sum(nil)),
+		})
+		manifest.Size += n
+		if n < chunkSize {
+			break
+		}
+	}
+	manifest.SHA256 = hex.EncodeToString(whole.Sum(nil))
+	return manifest, nil
+}
+
+func readSection(path string, offset, size int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	data := make([]byte, size)
+	if _, err := f.ReadAt(data, offset); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
+func loadUploadState(path string) *uploadState {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var state uploadState
+	if json.Unmarshal(data, &state) != nil {
+		return nil
+	}
+	return &state
+}
+
+// matches reports whether a saved state belongs to the same file, chunk
+// size and epochs as a new upload
+func (s *uploadState) matches(other *uploadState) bool {
+	return s.Epochs == other.Epochs &&
+		s.Manifest.SHA256 == other.Manifest.SHA256 &&
+		s.Manifest.ChunkSize == other.Manifest.ChunkSize &&
+		len(s.Manifest.Chunks) == len(other.Manifest.Chunks)
+}
+
+func (s *uploadState) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	s.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ParseChunkManifest returns the manifest held by a blob, or nil if the blob
+// is an ordinary file
+func ParseChunkManifest(data []byte) *ChunkManifest {
+	if len(data) > MaxManifestSize || len(data) == 0 || data[0] != '{' {
+		return nil
+	}
+	var m ChunkManifest
+	if json.Unmarshal(data, &m) != nil || m.Format != ChunkedFormat {
+		return nil
+	}
+	return &m
+}
+
+// Assemble writes the file a manifest describes to w, reading each chunk
+// with read and checking its SHA256 and the file's
+func (m *ChunkManifest) Assemble(w io.Writer, read func(blobID string) ([]byte, error)) error {
+	whole := sha256.New()
+	var size int64
+	for i, chunk := range m.Chunks {
+		data, err := read(chunk.BlobID)
+		if err != nil {
+			return fmt.Errorf("failed to read chunk %d of %d: %w", i+1, len(m.Chunks), err)
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != chunk.SHA256 {
+			return fmt.Errorf("chunk %d of %d (%s) has the wrong SHA256", i+1, len(m.Chunks), chunk.BlobID)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		whole.Write(data)
+		size += int64(len(data))
+	}
+	if size != m.Size || hex.EncodeToString(whole.Sum(nil)) != m.SHA256 {
+		return fmt.Errorf("assembled file doesn't match the manifest (%d bytes, expected %d)", size, m.Size)
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package walrus
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,7 +12,10 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/retro-crypto/sui/internal/httpclient"
 )
 
 // Client is a Walrus blob storage client
@@ -18,6 +23,15 @@ type Client struct {
 	aggregatorURL string
 	publisherURL  string
 	httpClient    *http.Client
+	// signer authenticates publisher requests (see SetSigner)
+	signer RequestSigner
+	// publishers uploads can fail over between (see SetPublishers)
+	publishersMu     sync.Mutex
+	publishers       []string
+	rankedPublishers []string
+	lastPublisher    string
+	// currentEpoch is cached by BlobStatus
+	currentEpoch uint64
 }
 
 // StoreResponse represents the response from storing a blob
@@ -71,9 +85,7 @@ func NewClient(aggregatorURL, publisherURL string) *Client {
 	return &Client{
 		aggregatorURL: aggregatorURL,
 		publisherURL:  publisherURL,
-		httpClient: &http.Client{
-			Timeout: 5 * time.Minute, // Long timeout for large files
-		},
+		httpClient:    httpclient.New(5 * time.Minute), // Long timeout for large files
 	}
 }
 
@@ -81,62 +93,121 @@ func NewClient(aggregatorURL, publisherURL string) *Client {
 // If publisher nodes fail, it will attempt to use the Walrus CLI as a fallback
 func (c *Client) Store(data []byte, epochs int) (*StoreResponse, error) {
 	// First, try HTTP publisher API
+	var httpErr error
 	if c.publisherURL != "" {
-		result, err := c.storeViaHTTP(data, epochs)
+		result, err := c.storeViaHTTP(bytesBody(data), int64(len(data)), epochs, nil)
 		if err == nil {
 			return result, nil
 		}
 		// If HTTP fails, fall through to CLI fallback
+		httpErr = err
 	}
 
 	// Fallback: Try using Walrus CLI (uses your own SUI balance)
-	return c.storeViaCLI(data, epochs)
+	result, err := c.storeViaCLI(data, epochs)
+	return result, withPublisherError(err, httpErr)
 }
 
-// storeViaHTTP attempts to upload via HTTP publisher API
-func (c *Client) storeViaHTTP(data []byte, epochs int) (*StoreResponse, error) {
-	if c.publisherURL == "" {
-		return nil, fmt.Errorf("publisher URL not configured")
+// withPublisherError adds why the publishers failed to a CLI fallback error
+func withPublisherError(cliErr, httpErr error) error {
+	if cliErr == nil || httpErr == nil {
+		return cliErr
 	}
+	return fmt.Errorf("%w (after the publisher upload failed: %v)", cliErr, httpErr)
+}
 
-	// Try v1/store first, fallback to v1/blobs if needed
-	url := fmt.Sprintf("%s/v1/store?epochs=%d", c.publisherURL, epochs)
+// StoreFile uploads a file to Walrus like Store, but streams it from disk
+// instead of holding it in memory
+func (c *Client) StoreFile(path string, epochs int) (*StoreResponse, error) {
+	return c.StoreFileProgress(path, epochs, nil)
+}
 
-	req, err := http.NewRequest("PUT", url, bytes.NewReader(data))
+// StoreFileProgress is StoreFile reporting the bytes sent to the publisher
+// to progress (which may be nil). The walrus CLI fallback reports nothing.
+func (c *Client) StoreFileProgress(path string, epochs int, progress ProgressFunc) (*StoreResponse, error) {
+	info, err := os.Stat(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/octet-stream")
+	var httpErr error
+	if c.publisherURL != "" {
+		result, err := c.storeViaHTTP(fileBody(path), info.Size(), epochs, progress)
+		if err == nil {
+			return result, nil
+		}
+		httpErr = err
+	}
+	result, err := c.storeFileViaCLI(path, epochs)
+	return result, withPublisherError(err, httpErr)
+}
 
-	resp, err := c.httpClient.Do(req)
+// StorePublisher uploads a blob through the HTTP publisher only, without
+// the CLI fallback that would spend the local wallet's SUI
+func (c *Client) StorePublisher(data []byte, epochs int) (*StoreResponse, error) {
+	return c.storeViaHTTP(bytesBody(data), int64(len(data)), epochs, nil)
+}
+
+// bodyOpener opens a fresh request body; the publisher upload may be sent
+// twice when the first endpoint is missing
+type bodyOpener func() (io.ReadCloser, error)
+
+func bytesBody(data []byte) bodyOpener {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+func fileBody(path string) bodyOpener {
+	return func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}
+}
+
+// ProgressFunc receives the bytes of an upload sent so far and its size. An
+// upload retried through another publisher starts again from 0.
+type ProgressFunc func(sent, total int64)
+
+// progressReader reports the bytes read from an upload body
+type progressReader struct {
+	io.ReadCloser
+	sent, total int64
+	progress    ProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		r.progress(r.sent, r.total)
+	}
+	return n, err
+}
+
+// storeAt uploads to one publisher
+func (c *Client) storeAt(publisherURL string, open bodyOpener, size int64, epochs int, progress ProgressFunc) (*StoreResponse, error) {
+	// Try v1/store first, fallback to v1/blobs if needed
+	url := fmt.Sprintf("%s/v1/store?epochs=%d", publisherURL, epochs)
+
+	resp, err := c.putBlob(url, open, size, progress)
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload blob: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	// If 404, try alternative endpoint
 	if resp.StatusCode == http.StatusNotFound {
 		// Try v1/blobs endpoint
-		url = fmt.Sprintf("%s/v1/blobs?epochs=%d", c.publisherURL, epochs)
-		req, err = http.NewRequest("PUT", url, bytes.NewReader(data))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-		req.Header.Set("Content-Type", "application/octet-stream")
-		
-		resp, err = c.httpClient.Do(req)
+		url = fmt.Sprintf("%s/v1/blobs?epochs=%d", publisherURL, epochs)
+		resp, err = c.putBlob(url, open, size, progress)
 		if err != nil {
-			return nil, fmt.Errorf("failed to upload blob: %w", err)
+			return nil, err
 		}
 		defer resp.Body.Close()
 	}
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
-		bodyStr := string(body)
-		
-		// Return error to trigger CLI fallback
-		return nil, fmt.Errorf("HTTP upload failed: status %d: %s", resp.StatusCode, bodyStr)
+		return nil, &UploadStatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var result StoreResponse
@@ -147,6 +218,54 @@ func (c *Client) storeViaHTTP(data []byte, epochs int) (*StoreResponse, error) {
 	return &result, nil
 }
 
+// putBlob sends one upload request with a fresh body of the given size
+func (c *Client) putBlob(url string, open bodyOpener, size int64, progress ProgressFunc) (*http.Response, error) {
+	body, err := open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	req, err := http.NewRequest("PUT", url, body)
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if c.signer != nil {
+		if err := c.signRequest(req, open); err != nil {
+			body.Close()
+			return nil, err
+		}
+	}
+	if progress != nil {
+		progress(0, size)
+		req.Body = &progressReader{ReadCloser: body, total: size, progress: progress}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload blob: %w", err)
+	}
+	return resp, nil
+}
+
+// signRequest hashes a fresh copy of the body and signs req with it
+func (c *Client) signRequest(req *http.Request, open bodyOpener) error {
+	body, err := open()
+	if err != nil {
+		return fmt.Errorf("failed to open blob: %w", err)
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return fmt.Errorf("failed to hash blob: %w", err)
+	}
+	if err := c.signer.Sign(req, hex.EncodeToString(h.Sum(nil))); err != nil {
+		return fmt.Errorf("failed to sign publisher request: %w", err)
+	}
+	return nil
+}
+
 // storeViaCLI uploads using Walrus CLI (requires walrus binary to be installed)
 func (c *Client) storeViaCLI(data []byte, epochs int) (*StoreResponse, error) {
 	// Create a temporary file
@@ -163,9 +282,14 @@ func (c *Client) storeViaCLI(data []byte, epochs int) (*StoreResponse, error) {
 	}
 	tmpFile.Close()
 
+	return c.storeFileViaCLI(tmpFile.Name(), epochs)
+}
+
+// storeFileViaCLI uploads a file on disk using the Walrus CLI
+func (c *Client) storeFileViaCLI(path string, epochs int) (*StoreResponse, error) {
 	// Execute walrus CLI
 	// Syntax: walrus store <file> --epochs <n> --context testnet
-	cmd := exec.Command("walrus", "store", tmpFile.Name(), "--epochs", fmt.Sprintf("%d", epochs), "--context", "testnet")
+	cmd := exec.Command("walrus", "store", path, "--epochs", fmt.Sprintf("%d", epochs), "--context", "testnet")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("walrus CLI failed (make sure 'walrus' is installed: cargo install --git https://github.com/MystenLabs/walrus.git walrus): %w\nOutput: %s", err, string(output))
@@ -213,6 +337,17 @@ func (c *Client) storeViaCLI(data []byte, epochs int) (*StoreResponse, error) {
 	return nil, fmt.Errorf("failed to extract blob ID from walrus CLI output\nOutput: %s", outputStr)
 }
 
+// Extend extends the storage of a blob object by epochs using the Walrus CLI.
+// The blob object must be owned by the CLI's active address.
+func (c *Client) Extend(blobObjectID string, epochs int, network string) (string, error) {
+	cmd := exec.Command("walrus", "extend", "--blob-obj-id", blobObjectID, "--epochs-extended", fmt.Sprintf("%d", epochs), "--context", network)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("walrus CLI failed (make sure 'walrus' is installed: cargo install --git https://github.com/MystenLabs/walrus.git walrus): %w\nOutput: %s", err, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // GetBlobID extracts the blob ID from a store response
 func (r *StoreResponse) GetBlobID() string {
 	if r.NewlyCreated != nil {
@@ -240,12 +375,114 @@ func (c *Client) Read(blobID string) ([]byte, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("download failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	return io.ReadAll(resp.Body)
 }
 
+// StatusError is returned by Read when the aggregator answers with an error status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("download failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// RangeResponse is an open blob download returned by OpenRange
+type RangeResponse struct {
+	// Body streams the blob from Start on; the caller closes it
+	Body io.ReadCloser
+	// Start is the offset of the first byte of Body: the requested offset
+	// if the range was honoured, 0 if the whole blob is sent
+	Start int64
+	// Size is the size of the whole blob, or -1 if unknown
+	Size int64
+	// ETag is the aggregator's ETag for the blob, if any
+	ETag string
+}
+
+// OpenRange requests a blob from offset on. ifRange is the ETag of the
+// bytes already held: if the aggregator no longer matches it, or doesn't
+// support ranges, the whole blob is sent and Start is 0.
+func (c *Client) OpenRange(blobID string, offset int64, ifRange string) (*RangeResponse, error) {
+	if c.aggregatorURL == "" {
+		return nil, fmt.Errorf("aggregator URL not configured")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/blobs/%s", c.aggregatorURL, blobID), nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if ifRange != "" {
+			req.Header.Set("If-Range", ifRange)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download blob: %w", err)
+	}
+	etag := resp.Header.Get("ETag")
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return &RangeResponse{Body: resp.Body, Start: 0, Size: resp.ContentLength, ETag: etag}, nil
+	case http.StatusPartialContent:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unexpected Content-Range %q for offset %d", resp.Header.Get("Content-Range"), offset)
+		}
+		return &RangeResponse{Body: resp.Body, Start: start, Size: size, ETag: etag}, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The offset is the end of the blob: everything is already there
+		_, size, _ := parseContentRange(resp.Header.Get("Content-Range"))
+		if size == offset {
+			resp.Body.Close()
+			return &RangeResponse{Body: io.NopCloser(bytes.NewReader(nil)), Start: offset, Size: size, ETag: etag}, nil
+		}
+	}
+
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+}
+
+// parseContentRange parses "bytes START-END/SIZE" or "bytes */SIZE"; an
+// unknown size ("*") is -1
+func parseContentRange(header string) (start, size int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, -1, false
+	}
+	rng, total, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, -1, false
+	}
+	size = -1
+	if total != "*" {
+		if _, err := fmt.Sscanf(total, "%d", &size); err != nil {
+			return 0, -1, false
+		}
+	}
+	if rng == "*" {
+		return 0, size, true
+	}
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, size, false
+	}
+	if _, err := fmt.Sscanf(first, "%d", &start); err != nil {
+		return 0, size, false
+	}
+	return start, size, true
+}
+
 // ReadWithRetry downloads a blob with retry logic
 func (c *Client) ReadWithRetry(blobID string, maxRetries int) ([]byte, error) {
 	var lastErr error
@@ -259,3 +496,41 @@ func (c *Client) ReadWithRetry(blobID string, maxRetries int) ([]byte, error) {
 	}
 	return nil, fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
 }
+
+// PingAggregator checks that the aggregator answers HTTP requests
+func (c *Client) PingAggregator(timeout time.Duration) error {
+	return ping(c.aggregatorURL, timeout, nil)
+}
+
+// PingPublisher checks that the publisher answers HTTP requests
+func (c *Client) PingPublisher(timeout time.Duration) error {
+	return ping(c.publisherURL, timeout, c.signer)
+}
+
+// ping requests the API description of a Walrus daemon; any status below 500
+// means the daemon is up
+func ping(baseURL string, timeout time.Duration, signer RequestSigner) error {
+	if baseURL == "" {
+		return fmt.Errorf("URL not configured")
+	}
+	req, err := http.NewRequest(http.MethodGet, baseURL+"/v1/api", nil)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	if signer != nil {
+		if err := signer.Sign(req, emptySHA256); err != nil {
+			return fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+	resp, err := httpclient.NewFixed(timeout).Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
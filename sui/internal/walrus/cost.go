@@ -0,0 +1,114 @@
+package walrus
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Storage pricing. Walrus charges per storage unit of the erasure-coded
+// blob: a storage price per unit and epoch plus a one-off write price.
+const (
+	// UnitSize is the size of one storage unit in bytes (1 MiB)
+	UnitSize = 1 << 20
+	// FrostPerWAL is the number of FROST in one WAL
+	FrostPerWAL = 1000000000
+	// digestLength is the size of the sliver hashes in blob metadata
+	digestLength = 32
+	// blobIDLength is the size of a blob ID
+	blobIDLength = 32
+)
+
+// SystemObjects are the Walrus system objects of the public deployments
+var SystemObjects = map[string]string{
+	"mainnet": "0x2134d52768ea07e8c43570ef975eb3e4c27a39fa6396bef985b5abc58d03ddd2",
+	"testnet": "0x6c2547cbbc38025cf3adac45f63cb0a8d12ecf777cdc75a4971612bf97fdf6af",
+}
+
+// Prices are the storage prices of the current Walrus epoch, read from the
+// system state object
+type Prices struct {
+	Epoch uint64 `json:"epoch"`
+	// NShards is the number of shards blobs are encoded for
+	NShards int `json:"n_shards"`
+	// StoragePricePerUnit is in FROST per storage unit and epoch
+	StoragePricePerUnit uint64 `json:"storage_price_per_unit"`
+	// WritePricePerUnit is in FROST per storage unit, paid once
+	WritePricePerUnit uint64 `json:"write_price_per_unit"`
+}
+
+// ParsePrices reads the prices from the fields of the system state object
+// (the SystemStateInner value stored as a dynamic field of the system object)
+func ParsePrices(fields map[string]interface{}) (*Prices, error) {
+	storage, err := fieldUint(fields, "storage_price_per_unit_size")
+	if err != nil {
+		return nil, err
+	}
+	write, err := fieldUint(fields, "write_price_per_unit_size")
+	if err != nil {
+		return nil, err
+	}
+	committee, ok := fields["committee"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("system state has no committee")
+	}
+	if inner, ok := committee["fields"].(map[string]interface{}); ok {
+		committee = inner
+	}
+	shards, err := fieldUint(committee, "n_shards")
+	if err != nil {
+		return nil, err
+	}
+	if shards == 0 {
+		return nil, fmt.Errorf("system state has no shards")
+	}
+	epoch, _ := fieldUint(committee, "epoch")
+	return &Prices{Epoch: epoch, NShards: int(shards), StoragePricePerUnit: storage, WritePricePerUnit: write}, nil
+}
+
+// fieldUint reads a u64 field, which the RPC returns as a string
+func fieldUint(fields map[string]interface{}, name string) (uint64, error) {
+	switch v := fields[name].(type) {
+	case string:
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", name, v)
+		}
+		return n, nil
+	case float64:
+		return uint64(v), nil
+	}
+	return 0, fmt.Errorf("system state has no %s", name)
+}
+
+// EncodedLength returns the size a blob of size bytes takes on Walrus after
+// RedStuff encoding for nShards shards: every shard stores a primary and a
+// secondary sliver plus the blob metadata (two sliver hashes per shard).
+// Small blobs are dominated by the metadata.
+func EncodedLength(size int64, nShards int) int64 {
+	n := int64(nShards)
+	f := (n - 1) / 3
+	primary, secondary := n-2*f, n-f
+
+	symbolSize := (size + primary*secondary - 1) / (primary * secondary)
+	if symbolSize == 0 {
+		symbolSize = 1
+	}
+	// Reed-Solomon symbols are a whole number of 2-byte words
+	symbolSize += symbolSize % 2
+
+	sliverPair := (primary + secondary) * symbolSize
+	metadata := n*digestLength*2 + blobIDLength
+	return n * (sliverPair + metadata)
+}
+
+// StorageUnits returns the storage units of an encoded length, rounded up
+func StorageUnits(encoded int64) uint64 {
+	return uint64((encoded + UnitSize - 1) / UnitSize)
+}
+
+// Cost returns the storage and write cost in FROST of storing a blob of size
+// bytes for epochs epochs
+func (p *Prices) Cost(size int64, epochs int) (storage, write uint64) {
+	units := StorageUnits(EncodedLength(size, p.NShards))
+	return units * p.StoragePricePerUnit * uint64(epochs), units * p.WritePricePerUnit
+}
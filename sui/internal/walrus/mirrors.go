@@ -0,0 +1,218 @@
+package walrus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Aggregator is an aggregator endpoint with an optional region tag
+type Aggregator struct {
+	URL    string
+	Region string
+}
+
+// Mirrors reads blobs from the best of several aggregators. Aggregators are
+// ranked by latency, measured on first use and cached in a file, with those
+// in the preferred region first; a failed read falls back to the next one.
+type Mirrors struct {
+	aggregators []Aggregator
+	region      string
+	cachePath   string
+
+	mu     sync.Mutex
+	ranked []Aggregator
+}
+
+// mirrorCacheTTL is how long measured latencies are trusted; failures are
+// retried sooner so a brief outage doesn't demote an aggregator for a day
+const (
+	mirrorCacheTTL   = 24 * time.Hour
+	mirrorFailureTTL = 10 * time.Minute
+)
+
+// mirrorProbeTimeout bounds a single latency probe
+const mirrorProbeTimeout = 5 * time.Second
+
+// latencyEntry is the cached measurement of one aggregator
+type latencyEntry struct {
+	LatencyMs  int64     `json:"latency_ms"`
+	Failed     bool      `json:"failed,omitempty"`
+	MeasuredAt time.Time `json:"measured_at"`
+}
+
+// NewMirrors creates a mirror set. region is the preferred region tag ("" for
+// none); cachePath is where latencies are kept ("" measures every run).
+func NewMirrors(aggregators []Aggregator, region, cachePath string) *Mirrors {
+	return &Mirrors{aggregators: aggregators, region: region, cachePath: cachePath}
+}
+
+// Ranked returns the aggregators in the order reads try them, measuring the
+// ones without a fresh cached latency
+func (m *Mirrors) Ranked() []Aggregator {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ranked != nil {
+		return m.ranked
+	}
+
+	// A single aggregator has nothing to be ranked against
+	if len(m.aggregators) < 2 {
+		m.ranked = m.aggregators
+		return m.ranked
+	}
+
+	cache := m.loadCache()
+	measured := false
+	for _, a := range m.aggregators {
+		if entry, ok := cache[a.URL]; ok {
+			ttl := mirrorCacheTTL
+			if entry.Failed {
+				ttl = mirrorFailureTTL
+			}
+			if time.Since(entry.MeasuredAt) < ttl {
+				continue
+			}
+		}
+		entry := latencyEntry{MeasuredAt: time.Now()}
+		start := time.Now()
+		if err := ping(a.URL, mirrorProbeTimeout, nil); err != nil {
+			entry.Failed = true
+		} else {
+			entry.LatencyMs = time.Since(start).Milliseconds()
+		}
+		cache[a.URL] = entry
+		measured = true
+	}
+	if measured {
+		m.saveCache(cache)
+	}
+
+	ranked := append([]Aggregator(nil), m.aggregators...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := cache[ranked[i].URL], cache[ranked[j].URL]
+		if a.Failed != b.Failed {
+			return !a.Failed
+		}
+		inRegionA := m.region != "" && ranked[i].Region == m.region
+		inRegionB := m.region != "" && ranked[j].Region == m.region
+		if inRegionA != inRegionB {
+			return inRegionA
+		}
+		return a.LatencyMs < b.LatencyMs
+	})
+	m.ranked = ranked
+	return ranked
+}
+
+// Healthy reports whether the best ranked aggregator answered its last
+// probe. A single aggregator isn't probed and counts as healthy.
+func (m *Mirrors) Healthy() bool {
+	ranked := m.Ranked()
+	if len(ranked) < 2 {
+		return len(ranked) == 1
+	}
+	entry, ok := m.loadCache()[ranked[0].URL]
+	return !ok || !entry.Failed
+}
+
+// Read downloads a blob from the first aggregator that serves it and returns
+// the data and the aggregator used. An aggregator that is down (no answer or
+// a 5xx status) is marked in the cache so later runs try it last until it is
+// measured again.
+func (m *Mirrors) Read(blobID string) ([]byte, string, error) {
+	ranked := m.Ranked()
+	if len(ranked) == 0 {
+		return nil, "", fmt.Errorf("aggregator URL not configured")
+	}
+
+	var lastErr error
+	for _, a := range ranked {
+		data, err := NewClient(a.URL, "").Read(blobID)
+		if err == nil {
+			return data, a.URL, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", a.URL, err)
+		var status *StatusError
+		if len(ranked) > 1 && (!errors.As(err, &status) || status.StatusCode >= 500) {
+			m.markFailed(a.URL)
+		}
+	}
+	return nil, "", lastErr
+}
+
+// OpenRange opens a blob download from offset on (see Client.OpenRange) at
+// the first aggregator that serves it, and returns the aggregator used
+func (m *Mirrors) OpenRange(blobID string, offset int64, ifRange string) (*RangeResponse, string, error) {
+	ranked := m.Ranked()
+	if len(ranked) == 0 {
+		return nil, "", fmt.Errorf("aggregator URL not configured")
+	}
+
+	var lastErr error
+	for _, a := range ranked {
+		resp, err := NewClient(a.URL, "").OpenRange(blobID, offset, ifRange)
+		if err == nil {
+			return resp, a.URL, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", a.URL, err)
+		var status *StatusError
+		if len(ranked) > 1 && (!errors.As(err, &status) || status.StatusCode >= 500) {
+			m.markFailed(a.URL)
+		}
+	}
+	return nil, "", lastErr
+}
+
+// ReadWithRetry runs Read up to maxRetries times, backing off between rounds
+func (m *Mirrors) ReadWithRetry(blobID string, maxRetries int) ([]byte, string, error) {
+	var lastErr error
+	for i := 0; i < maxRetries; i++ {
+		data, url, err := m.Read(blobID)
+		if err == nil {
+			return data, url, nil
+		}
+		lastErr = err
+		time.Sleep(time.Duration(i+1) * time.Second)
+	}
+	return nil, "", fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
+}
+
+// markFailed records a failed read of an aggregator in the cache
+func (m *Mirrors) markFailed(url string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cache := m.loadCache()
+	cache[url] = latencyEntry{Failed: true, MeasuredAt: time.Now()}
+	m.saveCache(cache)
+}
+
+// loadCache reads the latency cache; a missing or broken file is empty
+func (m *Mirrors) loadCache() map[string]latencyEntry {
+	cache := make(map[string]latencyEntry)
+	if m.cachePath == "" {
+		return cache
+	}
+	if data, err := os.ReadFile(m.cachePath); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+// saveCache writes the latency cache; failures only cost a new measurement
+func (m *Mirrors) saveCache(cache map[string]latencyEntry) {
+	if m.cachePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	os.MkdirAll(filepath.Dir(m.cachePath), 0755)
+	os.WriteFile(m.cachePath, data, 0644)
+}
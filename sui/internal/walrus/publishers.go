@@ -0,0 +1,168 @@
+package walrus
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Uploads go to the fastest healthy publisher. Publishers are probed
+// concurrently before the first upload; one that times out or answers with
+// a 5xx (or 429) status is failed over to the next, and once all of them
+// have failed the round is repeated after an exponentially growing pause.
+const (
+	publisherProbeTimeout = 5 * time.Second
+	publisherRounds       = 3
+	publisherBackoff      = time.Second
+)
+
+// SetPublishers sets the publishers uploads may use, in configured order.
+// The first one stays the client's publisher URL.
+func (c *Client) SetPublishers(urls []string) {
+	c.publishersMu.Lock()
+	defer c.publishersMu.Unlock()
+	c.publishers = nil
+	for _, u := range urls {
+		if u != "" {
+			c.publishers = append(c.publishers, u)
+		}
+	}
+	if len(c.publishers) > 0 {
+		c.publisherURL = c.publishers[0]
+	}
+	c.rankedPublishers = nil
+}
+
+// Publisher returns the publisher the last successful upload went to
+func (c *Client) Publisher() string {
+	c.publishersMu.Lock()
+	defer c.publishersMu.Unlock()
+	return c.lastPublisher
+}
+
+// publisherOrder returns the publishers in the order uploads try them,
+// probing them concurrently the first time
+func (c *Client) publisherOrder() []string {
+	c.publishersMu.Lock()
+	defer c.publishersMu.Unlock()
+	if c.rankedPublishers != nil {
+		return append([]string(nil), c.rankedPublishers...)
+	}
+	publishers := c.publishers
+	if len(publishers) == 0 && c.publisherURL != "" {
+		publishers = []string{c.publisherURL}
+	}
+	// A single publisher has nothing to be ranked against
+	if len(publishers) < 2 {
+		c.rankedPublishers = publishers
+		return append([]string(nil), publishers...)
+	}
+
+	type probe struct {
+		latency time.Duration
+		failed  bool
+	}
+	probes := make([]probe, len(publishers))
+	var wg sync.WaitGroup
+	for i, u := range publishers {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			start := time.Now()
+			if err := ping(u, publisherProbeTimeout, c.signer); err != nil {
+				probes[i].failed = true
+				return
+			}
+			probes[i].latency = time.Since(start)
+		}(i, u)
+	}
+	wg.Wait()
+
+	order := make([]int, len(publishers))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		pa, pb := probes[order[a]], probes[order[b]]
+		if pa.failed != pb.failed {
+			return !pa.failed
+		}
+		return pa.latency < pb.latency
+	})
+	c.rankedPublishers = make([]string, len(order))
+	for i, idx := range order {
+		c.rankedPublishers[i] = publishers[idx]
+	}
+	return append([]string(nil), c.rankedPublishers...)
+}
+
+// demotePublisher moves a publisher that failed to the end of the order, so
+// the rest of the run tries the others first
+func (c *Client) demotePublisher(url string) {
+	c.publishersMu.Lock()
+	defer c.publishersMu.Unlock()
+	for i, u := range c.rankedPublishers {
+		if u == url {
+			c.rankedPublishers = append(append(c.rankedPublishers[:i:i], c.rankedPublishers[i+1:]...), url)
+			return
+		}
+	}
+}
+
+// UploadStatusError is returned when a publisher answers an upload with an
+// error status
+type UploadStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *UploadStatusError) Error() string {
+	return fmt.Sprintf("HTTP upload failed: status %d: %s", e.StatusCode, e.Body)
+}
+
+// failoverError reports whether an upload error is worth trying another
+// publisher for: no answer, a timeout, a 5xx or 429 status. Other 4xx
+// statuses mean the request itself is refused.
+func failoverError(err error) bool {
+	var status *UploadStatusError
+	if errors.As(err, &status) {
+		return status.StatusCode >= 500 || status.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// storeViaHTTP uploads through the publishers, failing over between them
+func (c *Client) storeViaHTTP(open bodyOpener, size int64, epochs int, progress ProgressFunc) (*StoreResponse, error) {
+	publishers := c.publisherOrder()
+	if len(publishers) == 0 {
+		return nil, fmt.Errorf("publisher URL not configured")
+	}
+
+	var lastErr error
+	for round := 0; round < publisherRounds; round++ {
+		if round > 0 {
+			time.Sleep(publisherBackoff << (round - 1))
+		}
+		for _, url := range publishers {
+			result, err := c.storeAt(url, open, size, epochs, progress)
+			if err == nil {
+				c.publishersMu.Lock()
+				c.lastPublisher = url
+				c.publishersMu.Unlock()
+				return result, nil
+			}
+			if len(publishers) > 1 {
+				err = fmt.Errorf("%s: %w", url, err)
+			}
+			lastErr = err
+			if !failoverError(err) {
+				return nil, err
+			}
+			c.demotePublisher(url)
+		}
+	}
+	return nil, lastErr
+}
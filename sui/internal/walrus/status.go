@@ -0,0 +1,79 @@
+package walrus
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+// BlobStatus is how long Walrus keeps a blob
+type BlobStatus struct {
+	// Exists is false if Walrus knows nothing of the blob (never stored, or
+	// expired and gone)
+	Exists bool
+	// EndEpoch is the first epoch the blob is no longer stored in
+	EndEpoch uint64
+	// CurrentEpoch is the network's current epoch
+	CurrentEpoch uint64
+}
+
+// BlobStatus asks the walrus CLI for the storage status of a blob.
+// Aggregators don't report epochs, so this needs the walrus binary.
+func (c *Client) BlobStatus(blobID, network string) (*BlobStatus, error) {
+	if c.currentEpoch == 0 {
+		info, err := walrusJSON("info", "--context", network, "--json")
+		if err != nil {
+			return nil, err
+		}
+		c.currentEpoch, _ = findUint(info, "currentEpoch")
+	}
+
+	out, err := walrusJSON("blob-status", "--blob-id", blobID, "--context", network, "--json")
+	if err != nil {
+		return nil, err
+	}
+	status := &BlobStatus{CurrentEpoch: c.currentEpoch}
+	status.EndEpoch, status.Exists = findUint(out, "endEpoch")
+	return status, nil
+}
+
+// walrusJSON runs the walrus CLI and decodes its JSON output
+func walrusJSON(args ...string) (interface{}, error) {
+	output, err := exec.Command("walrus", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("walrus %s failed (is the walrus CLI installed?): %w", args[0], err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(output, &v); err != nil {
+		return nil, fmt.Errorf("unexpected walrus %s output: %w", args[0], err)
+	}
+	return v, nil
+}
+
+// findUint returns the largest number stored under key anywhere in v; the
+// CLI nests it differently per blob state
+func findUint(v interface{}, key string) (uint64, bool) {
+	var best uint64
+	found := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if n, ok := child.(float64); ok && k == key {
+				if !found || uint64(n) > best {
+					best, found = uint64(n), true
+				}
+				continue
+			}
+			if n, ok := findUint(child, key); ok && (!found || n > best) {
+				best, found = n, true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if n, ok := findUint(child, key); ok && (!found || n > best) {
+				best, found = n, true
+			}
+		}
+	}
+	return best, found
+}
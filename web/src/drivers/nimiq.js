@@ -5,7 +5,7 @@
  * Uses transaction-based storage with CART/DATA/CENT payload formats.
  */
 
-import { parseCENT, parseCART, parseDATA, hexToBytes, normalizeAddress, computeExpectedChunks, verifySHA256, isDataMagicHex } from '../utils/payloads.js'
+import { parseCENT, parseCALW, parseCART, parseDATA, parseSHRD, CART_FLAG_SHARDED, hexToBytes, normalizeAddress, computeExpectedChunks, verifySHA256, decompressCartridge, isDataMagicHex } from '../utils/payloads.js'
 
 /**
  * Nimiq RPC Client
@@ -284,10 +284,32 @@ export function createNimiqDriver(rpcUrl) {
 
       console.log(`Fetched ${transactions.length} transactions from catalog address`)
 
+      // Publisher allowlist: records sent by the catalog address itself,
+      // replayed oldest first. Without any record every publisher is shown.
+      const normalizedCatalog = normalizeAddress(catalogAddress)
+      let allowlist = null
+      for (const tx of [...transactions].sort((a, b) => (a.height || a.blockNumber || 0) - (b.height || b.blockNumber || 0))) {
+        if (normalizeAddress(tx.from) !== normalizedCatalog) continue
+        const txData = tx.recipientData || tx.data || ''
+        if (!txData) continue
+        try {
+          const record = parseCALW(hexToBytes(txData))
+          if (!record) continue
+          allowlist = allowlist || new Set()
+          if (record.op === 1) allowlist.add(normalizeAddress(record.publisher))
+          if (record.op === 2) allowlist.delete(normalizeAddress(record.publisher))
+        } catch (err) {
+          console.warn(`Failed to parse CALW from tx ${tx.hash}:`, err)
+        }
+      }
+
       const entries = []
       const normalizedPublisher = publisherAddress ? normalizeAddress(publisherAddress) : null
       
       for (const tx of transactions) {
+        if (allowlist && !allowlist.has(normalizeAddress(tx.from))) {
+          continue
+        }
         if (normalizedPublisher && normalizeAddress(tx.from) !== normalizedPublisher) {
           continue
         }
@@ -315,6 +337,7 @@ export function createNimiqDriver(rpcUrl) {
       console.log(`Parsed ${entries.length} CENT entries`)
 
       const FLAG_RETIRED = 0x01
+      const FLAG_BETA = 0x02
       
       const retiredAppIds = new Set()
       for (const entry of entries) {
@@ -330,6 +353,10 @@ export function createNimiqDriver(rpcUrl) {
         if (retiredAppIds.has(entry.appId) && !shouldShowRetired) {
           continue
         }
+        // Beta channel versions are only listed by the uploader's catalog apps --channel beta
+        if (entry.flags & FLAG_BETA) {
+          continue
+        }
         
         if (!gamesMap.has(entry.appId)) {
           gamesMap.set(entry.appId, {
@@ -399,7 +426,7 @@ export function createNimiqDriver(rpcUrl) {
               
               // Calculate total cost: 1 Luna per transaction
               // Each chunk requires 1 transaction, plus 1 for the CART header
-              // chunkSize is the number of data bytes per chunk (typically 51)
+              // chunkSize is the number of data bytes per chunk (51 unless uploaded with --chunk-size)
               const numChunks = Math.ceil(cart.totalSize / cart.chunkSize)
               const totalTxs = numChunks + 1 // chunks + CART header
               const totalCostLuna = totalTxs * 1 // 1 Luna per transaction
@@ -454,7 +481,7 @@ export function createNimiqDriver(rpcUrl) {
                 
                 // Calculate total cost: 1 Luna per transaction
                 // Each chunk requires 1 transaction, plus 1 for the CART header
-                // chunkSize is the number of data bytes per chunk (typically 51)
+                // chunkSize is the number of data bytes per chunk (51 unless uploaded with --chunk-size)
                 const numChunks = Math.ceil(cart.totalSize / cart.chunkSize)
                 const totalTxs = numChunks + 1 // chunks + CART header
                 const totalCostLuna = totalTxs * 1 // 1 Luna per transaction
@@ -536,6 +563,10 @@ export function createNimiqDriver(rpcUrl) {
         throw new Error('CART header not found in cartridge address transactions')
       }
 
+      if (cartData.flags & CART_FLAG_SHARDED) {
+        return this.loadShardedCartridge(cartridgeAddress, cartData, cartHeader, publisherAddress, onProgress)
+      }
+
       const expectedChunks = computeExpectedChunks(cartData.totalSize, cartData.chunkSize)
       const startTime = Date.now()
       
@@ -585,7 +616,9 @@ export function createNimiqDriver(rpcUrl) {
               const data = hexToBytes(txData)
               const dataChunk = parseDATA(data)
               
-              if (dataChunk && dataChunk.cartridgeId === cartData.cartridgeId) {
+              // Chunks are at most the CART header's chunkSize (51 by default,
+              // larger on nodes that accept bigger payloads)
+              if (dataChunk && dataChunk.cartridgeId === cartData.cartridgeId && dataChunk.len <= cartData.chunkSize) {
                 if (!chunks.has(dataChunk.chunkIndex)) {
                   chunks.set(dataChunk.chunkIndex, {
                     ...dataChunk,
@@ -648,11 +681,79 @@ export function createNimiqDriver(rpcUrl) {
       }
 
       return {
-        fileData: reconstructed,
+        fileData: await decompressCartridge(reconstructed, cartData),
         verified: true,
         cartHeader
       }
+    },
+
+    /**
+     * Load a sharded cartridge: every shard is a cartridge of its own, linked
+     * from the primary address by SHRD records
+     */
+    async loadShardedCartridge(cartridgeAddress, cartData, cartHeader, publisherAddress = null, onProgress = () => {}) {
+      const normalizedPublisher = publisherAddress ? normalizeAddress(publisherAddress) : null
+      const txs = await rpc.getAllTransactionsByAddress(cartridgeAddress, 500)
+
+      const links = new Map()
+      for (const tx of txs) {
+        if (normalizedPublisher && normalizeAddress(tx.from) !== normalizedPublisher) {
+          continue
+        }
+        const txData = tx.recipientData || tx.data || ''
+        if (!txData) continue
+        try {
+          const link = parseSHRD(hexToBytes(txData))
+          if (link && link.cartridgeId === cartData.cartridgeId && !links.has(link.shardIndex)) {
+            links.set(link.shardIndex, link)
+          }
+        } catch (err) {
+          // Not a SHRD record
+        }
+      }
+
+      const shardCount = links.size > 0 ? links.values().next().value.shardCount : 0
+      if (shardCount === 0 || links.size < shardCount) {
+        throw new Error(`Only found ${links.size} of ${shardCount} shard links`)
+      }
+
+      const reconstructed = new Uint8Array(cartData.totalSize)
+      for (let i = 0; i < shardCount; i++) {
+        const link = links.get(i)
+        if (!link || link.offset + link.size > cartData.totalSize) {
+          throw new Error(`Shard ${i} link is missing or out of range`)
+        }
+        const shard = await this.loadCartridge(link.shardAddress, publisherAddress, (progress) => {
+          onProgress({
+            ...progress,
+            statusMessage: `Shard ${i + 1}/${shardCount}: ${progress.statusMessage}`
+          })
+        })
+        if (shard.fileData.length !== link.size) {
+          throw new Error(`Shard ${i} has ${shard.fileData.length} bytes, expected ${link.size}`)
+        }
+        reconstructed.set(shard.fileData, link.offset)
+      }
+
+      onProgress({
+        chunksFound: 0,
+        expectedChunks: 0,
+        bytes: reconstructed.length,
+        rate: 0,
+        phase: 'verifying',
+        statusMessage: 'Verifying file integrity...'
+      })
+
+      const isValid = await verifySHA256(reconstructed, cartData.sha256)
+      if (!isValid) {
+        throw new Error(`SHA256 verification failed! Expected ${cartData.sha256}`)
+      }
+
+      return {
+        fileData: await decompressCartridge(reconstructed, cartData),
+        verified: true,
+        cartHeader: { ...cartHeader, shardCount }
+      }
     }
   }
 }
-
@@ -13,6 +13,7 @@
 
 import { verifySHA256, bytesToHex } from '../utils/payloads.js'
 import { loadSuiWalrusConfig } from './suiWalrusConfig.js'
+import { applyDeltaPatch, DELTA_FORMAT } from '../utils/delta.js'
 
 // Walrus Base58 alphabet (59 characters)
 // Excludes: 0, O, I (zero, uppercase O, uppercase I)
@@ -360,6 +361,29 @@ function parseCartridgeObject(data) {
     }
 }
 
+// Longest chain of delta updates followed to rebuild one file
+const MAX_DELTA_CHAIN = 32
+
+/**
+ * Find the delta of a cartridge published with `publish-game --delta-from`
+ * @returns {Promise<Object|null>} null if the blob is the complete file
+ */
+async function findCartridgeDelta(suiRpc, cartridgeId) {
+  const fields = await suiRpc.getDynamicFields(cartridgeId)
+  const field = (fields?.data || []).find(f => f.name?.type?.endsWith('::cartridge::DeltaKey'))
+  if (!field) return null
+
+  const fieldObj = await suiRpc.getDynamicFieldObject(cartridgeId, field.name)
+  const value = fieldObj?.data?.content?.fields?.value?.fields
+  if (!value) return null
+  return {
+    baseCartridge: value.base_cartridge,
+    format: value.format,
+    patchSha256: bytesArrayToHex(value.patch_sha256),
+    patchSizeBytes: Number(value.patch_size_bytes) || 0
+  }
+}
+
 /**
  * Create Sui + Walrus Protocol Driver
  * @param {string} rpcUrl - Sui RPC endpoint
@@ -427,7 +451,8 @@ export function createSuiWalrusDriver(rpcUrl) {
               const slug = field.name?.value || field.name || ''
               const entry = parseCatalogEntry(slug, fieldObj.data)
               
-              if (entry) {
+              // Entries on non-stable channels are keyed "<slug>@<channel>"
+              if (entry && !slug.includes('@')) {
                 entries.push(entry)
               }
             }
@@ -517,9 +542,14 @@ export function createSuiWalrusDriver(rpcUrl) {
      * @param {string} cartridgeId - Cartridge object ID
      * @param {string|null} publisherAddress - Not used for Sui
      * @param {Function} onProgress - Progress callback
+     * @param {number} depth - Delta updates followed so far (internal)
      * @returns {Promise<{fileData: Uint8Array, verified: boolean, cartHeader: Object}>}
      */
-    async loadCartridge(cartridgeId, publisherAddress = null, onProgress = () => {}) {
+    async loadCartridge(cartridgeId, publisherAddress = null, onProgress = () => {}, depth = 0) {
+      if (depth > MAX_DELTA_CHAIN) {
+        throw new Error(`Delta chain is longer than ${MAX_DELTA_CHAIN} cartridges`)
+      }
+
       // Get cartridge info first
       const cartHeader = await this.loadCartridgeInfo(cartridgeId)
       if (!cartHeader) {
@@ -561,6 +591,27 @@ export function createSuiWalrusDriver(rpcUrl) {
         throw err
       }
 
+      // Delta updates store a patch against an earlier cartridge
+      const delta = await findCartridgeDelta(suiRpc, cartridgeId)
+      if (delta) {
+        if (delta.format !== DELTA_FORMAT) {
+          throw new Error(`Unsupported patch format: ${delta.format}`)
+        }
+        if (!(await verifySHA256(fileData, delta.patchSha256))) {
+          throw new Error(`Patch SHA256 verification failed! Expected ${delta.patchSha256}`)
+        }
+        onProgress({
+          chunksFound: 0,
+          expectedChunks: 1,
+          bytes: fileData.length,
+          rate: 0,
+          phase: 'downloading',
+          statusMessage: 'Downloading base version...'
+        })
+        const base = await this.loadCartridge(delta.baseCartridge, publisherAddress, () => {}, depth + 1)
+        fileData = await applyDeltaPatch(base.fileData, fileData)
+      }
+
       onProgress({
         chunksFound: 1,
         expectedChunks: 1,
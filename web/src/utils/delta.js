@@ -0,0 +1,86 @@
+/**
+ * Delta patch support for Sui cartridges published with `publish-game --delta-from`
+ *
+ * A patch is "RCD1" followed by a gzip stream of: uvarint base size,
+ * uvarint target size, then ops (0x01 COPY offset length, 0x02 ADD length
+ * bytes) until 0x00 END. See sui/internal/delta.
+ */
+
+export const DELTA_FORMAT = 'rcd1'
+
+const OP_END = 0x00
+const OP_COPY = 0x01
+const OP_ADD = 0x02
+
+/**
+ * Gunzip bytes with the browser's DecompressionStream
+ */
+async function gunzip(data) {
+  const stream = new Blob([data]).stream().pipeThrough(new DecompressionStream('gzip'))
+  return new Uint8Array(await new Response(stream).arrayBuffer())
+}
+
+/**
+ * Rebuild the target file from its base and a patch
+ * @param {Uint8Array} base - Game file of the base cartridge
+ * @param {Uint8Array} patch - Patch blob
+ * @returns {Promise<Uint8Array>}
+ */
+export async function applyDeltaPatch(base, patch) {
+  const magic = String.fromCharCode(patch[0], patch[1], patch[2], patch[3])
+  if (magic !== 'RCD1') {
+    throw new Error('Not a delta patch (missing RCD1 header)')
+  }
+  const ops = await gunzip(patch.subarray(4))
+
+  let pos = 0
+  const uvarint = () => {
+    let value = 0
+    let scale = 1
+    for (;;) {
+      if (pos >= ops.length) throw new Error('Corrupt delta patch: truncated')
+      const b = ops[pos++]
+      value += (b & 0x7f) * scale
+      if (b < 0x80) return value
+      scale *= 128
+    }
+  }
+
+  const baseSize = uvarint()
+  const targetSize = uvarint()
+  if (baseSize !== base.length) {
+    throw new Error(`Patch expects a ${baseSize}-byte base, got ${base.length} bytes`)
+  }
+
+  const out = new Uint8Array(targetSize)
+  let written = 0
+  for (;;) {
+    if (pos >= ops.length) throw new Error('Corrupt delta patch: truncated')
+    const op = ops[pos++]
+    if (op === OP_END) break
+    if (op === OP_COPY) {
+      const offset = uvarint()
+      const length = uvarint()
+      if (offset + length > base.length || written + length > targetSize) {
+        throw new Error('Corrupt delta patch: copy out of range')
+      }
+      out.set(base.subarray(offset, offset + length), written)
+      written += length
+    } else if (op === OP_ADD) {
+      const length = uvarint()
+      if (pos + length > ops.length || written + length > targetSize) {
+        throw new Error('Corrupt delta patch: data out of range')
+      }
+      out.set(ops.subarray(pos, pos + length), written)
+      pos += length
+      written += length
+    } else {
+      throw new Error(`Corrupt delta patch: unknown op 0x${op.toString(16)}`)
+    }
+  }
+
+  if (written !== targetSize) {
+    throw new Error(`Patch produced ${written} bytes, expected ${targetSize}`)
+  }
+  return out
+}
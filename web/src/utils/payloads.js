@@ -20,6 +20,10 @@ export function parseCART(data) {
   const cartridgeId = view.getUint32(8, true)
   const totalSize = view.getBigUint64(12, true)
   const sha256 = Array.from(data.slice(20, 52))
+  // Schema 2: uncompressed size and the first 8 bytes of the uncompressed
+  // SHA256 of compressed cartridges
+  const uncompressedSize = schema >= 2 ? view.getUint32(52, true) : 0
+  const uncompressedSha256 = schema >= 2 ? Array.from(data.slice(56, 64)) : []
   
   return {
     magic,
@@ -30,12 +34,15 @@ export function parseCART(data) {
     cartridgeId,
     totalSize: Number(totalSize),
     sha256: sha256.map(b => b.toString(16).padStart(2, '0')).join(''),
+    uncompressedSize,
+    uncompressedSha256: uncompressedSha256.map(b => b.toString(16).padStart(2, '0')).join(''),
     raw: data
   }
 }
 
 /**
- * Parse DATA chunk payload (64 bytes)
+ * Parse DATA chunk payload (64 bytes, or 13 + len for chunks over 51 bytes;
+ * the CART header's chunkSize says how large chunks are)
  */
 export function parseDATA(data) {
   if (!data || data.length < 64) return null
@@ -49,7 +56,7 @@ export function parseDATA(data) {
   const chunkIndex = view.getUint32(8, true)
   const len = data[12]
   
-  if (len > 51 || data.length < 13 + len) return null
+  if (data.length < 13 + len) return null
   
   const chunkData = data.slice(13, 13 + len)
   
@@ -106,7 +113,83 @@ export function parseCENT(data) {
   }
 }
 
-const NIMIQ_BASE32_ALPHABET = '0123456789ABCDEFGHJKLMNPQRSTUVXY'
+/**
+ * CART flag: the file is split across shard cartridges linked by SHRD records
+ */
+export const CART_FLAG_SHARDED = 0x01
+
+/**
+ * CART flags (schema 2): the stored file is gzip or zstd compressed
+ */
+export const CART_FLAG_GZIP = 0x02
+export const CART_FLAG_ZSTD = 0x04
+
+/**
+ * Parse SHRD shard link payload (64 bytes), sent to a sharded cartridge's address
+ */
+export function parseSHRD(data) {
+  if (!data || data.length < 64) return null
+
+  const magic = String.fromCharCode(data[0], data[1], data[2], data[3])
+  if (magic !== 'SHRD') return null
+
+  const view = new DataView(data.buffer, data.byteOffset, data.byteLength)
+
+  return {
+    magic,
+    version: data[4],
+    cartridgeId: view.getUint32(6, true),
+    shardIndex: view.getUint16(10, true),
+    shardCount: view.getUint16(12, true),
+    shardAddress: addressBytesToNQ(data.slice(14, 34)),
+    offset: Number(view.getBigUint64(34, true)),
+    size: view.getUint32(42, true)
+  }
+}
+
+/**
+ * Parse CALW catalog allowlist record (64 bytes), sent by the catalog owner
+ */
+export function parseCALW(data) {
+  if (!data || data.length < 64) return null
+
+  const magic = String.fromCharCode(data[0], data[1], data[2], data[3])
+  if (magic !== 'CALW') return null
+
+  return {
+    magic,
+    version: data[4],
+    op: data[5], // 1 = add, 2 = remove
+    publisher: addressBytesToNQ(data.slice(6, 26))
+  }
+}
+
+/**
+ * Parse COLL player collection record (64 bytes), sent by the player
+ */
+export function parseCOLL(data) {
+  if (!data || data.length < 64) return null
+
+  const view = new DataView(data.buffer, data.byteOffset, data.byteLength)
+
+  const magic = String.fromCharCode(data[0], data[1], data[2], data[3])
+  if (magic !== 'COLL') return null
+
+  const op = data[5] // 1 = create/rename, 2 = add, 3 = remove
+  let nameEnd = 30
+  while (nameEnd < 62 && data[nameEnd] !== 0) nameEnd++
+
+  return {
+    magic,
+    version: data[4],
+    op,
+    collectionId: view.getUint32(6, true),
+    cartridge: op === 1 ? null : addressBytesToNQ(data.slice(10, 30)),
+    name: op === 1 ? new TextDecoder().decode(data.slice(30, nameEnd)) : ''
+  }
+}
+
+const NIMIQ_BASE32_ALPHABET ='0123456789ABCDEFGHJKLMNPQRSTUVXY'
 
 function calculateIBANCheck(addressBase32) {
   const toCheck = addressBase32 + 'NQ00'
@@ -177,6 +260,33 @@ export async function verifySHA256(data, expectedHash) {
   return hashHex.toLowerCase() === expectedHash.toLowerCase()
 }
 
+/**
+ * Decompress the stored file of a compressed cartridge (already verified
+ * against the CART header's sha256) and check it against the header's
+ * uncompressed size and SHA256. Uncompressed cartridges are returned as is.
+ */
+export async function decompressCartridge(data, cart) {
+  if (cart.schema < 2 || !(cart.flags & (CART_FLAG_GZIP | CART_FLAG_ZSTD))) {
+    return data
+  }
+  if (cart.flags & CART_FLAG_ZSTD) {
+    throw new Error('This cartridge is zstd compressed, which the browser cannot decompress; it needs to be uploaded with --compress gzip')
+  }
+
+  const stream = new Blob([data]).stream().pipeThrough(new DecompressionStream('gzip'))
+  const output = new Uint8Array(await new Response(stream).arrayBuffer())
+
+  if (output.length !== cart.uncompressedSize) {
+    throw new Error(`Decompressed ${output.length} bytes, the CART header says ${cart.uncompressedSize}`)
+  }
+  const hashBuffer = await crypto.subtle.digest('SHA-256', output)
+  const prefix = Array.from(new Uint8Array(hashBuffer).slice(0, 8)).map(b => b.toString(16).padStart(2, '0')).join('')
+  if (prefix !== cart.uncompressedSha256) {
+    throw new Error('Decompressed file does not match the CART header\'s uncompressed SHA256')
+  }
+  return output
+}
+
 export function hexToBytes(hexString) {
   const hex = hexString.startsWith('0x') ? hexString.slice(2) : hexString
   if (hex.length % 2 !== 0) {